@@ -0,0 +1,165 @@
+// Command archive manages the archive of old event and audit rows in blob
+// storage, configured by the archive section of the config.
+//
+//	archive list [-source s]
+//	archive run [-source s]
+//	archive restore -manifest id
+//
+// list prints the manifests of the archived batches. run archives the rows
+// older than the retention now, as the server does every archive.interval,
+// for one source or every configured one. restore puts the rows of a
+// manifest back into the database, e.g. to answer a request about them;
+// restored rows stay in the database and are not archived again.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/archive"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// listPageSize is how many manifests list reads per query
+const listPageSize = 500
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	if len(os.Args) < 2 || (os.Args[1] != "list" && os.Args[1] != "run" && os.Args[1] != "restore") {
+		usage()
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	source := flags.String("source", "", "table to archive or list: entity_events or audit_log")
+	manifestID := flags.Int64("manifest", 0, "ID of the manifest to restore")
+	flags.Parse(os.Args[2:])
+	if os.Args[1] == "restore" && *manifestID == 0 {
+		usage()
+	}
+
+	cfg, err := config.New()
+	if err != nil {
+		slog.Error("Error loading config:", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.Databases.DbDsn)
+	if err != nil {
+		slog.Error("Failed to connect to database", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	switch os.Args[1] {
+	case "list":
+		err = list(ctx, sqlc.New(pool), *source)
+	case "run":
+		err = run(ctx, cfg, pool, *source)
+	case "restore":
+		err = restore(ctx, cfg, pool, *manifestID)
+	}
+	if err != nil {
+		slog.Error("Archive command failed", "command", os.Args[1], slog.Any("error", err))
+		pool.Close()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: archive list [-source s] | run [-source s] | restore -manifest id")
+	os.Exit(2)
+}
+
+func list(ctx context.Context, q *sqlc.Queries, source string) error {
+	var after int64
+	for {
+		manifests, err := q.ListArchiveManifests(ctx, sqlc.ListArchiveManifestsParams{
+			Source:   source,
+			AfterID:  after,
+			PageSize: listPageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, m := range manifests {
+			status := "archived"
+			if m.RestoredAt.Valid {
+				status = "restored " + m.RestoredAt.Time.Format(time.RFC3339)
+			}
+			fmt.Printf("%d\t%s\t%d-%d\t%d rows\t%s to %s\t%s\t%s\t%s\n",
+				m.ID, m.Source, m.FirstID, m.LastID, m.RowCount,
+				m.OldestAt.Time.Format(time.RFC3339), m.NewestAt.Time.Format(time.RFC3339),
+				m.ObjectKey, hex.EncodeToString(m.Checksum), status)
+			after = m.ID
+		}
+
+		if len(manifests) < listPageSize {
+			return nil
+		}
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool, source string) error {
+	archiver, err := newArchiver(ctx, cfg, pool)
+	if err != nil {
+		return err
+	}
+
+	sources := cfg.Archive.Sources
+	if source != "" {
+		sources = []string{source}
+	}
+	for _, source := range sources {
+		n, err := archiver.Run(ctx, source)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: archived %d rows\n", source, n)
+	}
+	return nil
+}
+
+func restore(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool, manifestID int64) error {
+	archiver, err := newArchiver(ctx, cfg, pool)
+	if err != nil {
+		return err
+	}
+
+	n, err := archiver.Restore(ctx, manifestID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("manifest %d: restored %d rows\n", manifestID, n)
+	return nil
+}
+
+func newArchiver(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool) (*archive.Archiver, error) {
+	if cfg.Archive.StorageURL == "" {
+		return nil, errors.New("archive.storage_url is not set")
+	}
+	store, err := archive.OpenStore(ctx, archive.StoreOptions{
+		URL:      cfg.Archive.StorageURL,
+		Region:   cfg.Archive.StorageRegion,
+		Endpoint: cfg.Archive.StorageEndpoint,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return archive.New(pool, store, archive.Options{
+		Retention:  cfg.Archive.Retention,
+		BatchSize:  cfg.Archive.BatchSize,
+		MaxBatches: cfg.Archive.MaxBatches,
+	}), nil
+}
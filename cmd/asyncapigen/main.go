@@ -0,0 +1,225 @@
+// Command asyncapigen generates the AsyncAPI document of the domain events
+// from the proto/event/v1 definitions and the event routing of the config,
+// so event consumers have a versioned contract of the topics, headers,
+// payloads and delivery guarantees instead of reading the protos.
+//
+//	asyncapigen -version 1.4.0 [-out docs/asyncapi]
+//
+// The config is loaded like the services load it, e.g. APP_ENV=production
+// documents the broker of production. The document is written to
+// <out>/<version>/events.asyncapi.json, where the HTTP server lists it on
+// /asyncapi/ next to the Swagger UI; versions generated before are kept.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/pkg/asyncapi"
+	"github.com/erry-az/go-init/pkg/watmil"
+	_ "github.com/erry-az/go-init/proto/event/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// eventProtoPackage is the proto package holding the published domain events
+const eventProtoPackage = "proto.event.v1"
+
+// semverRegexp matches the document versions accepted by -version
+var semverRegexp = regexp.MustCompile(`^v?(\d+\.\d+\.\d+)(?:-([0-9A-Za-z.-]+))?$`)
+
+func main() {
+	version := flag.String("version", "", "contract version, e.g. 1.4.0 or 1.5.0-rc.1 (required)")
+	out := flag.String("out", "docs/asyncapi", "directory the versioned documents are written to")
+	flag.Parse()
+
+	if err := run(*version, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "asyncapigen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(version, out string) error {
+	if !semverRegexp.MatchString(version) {
+		return fmt.Errorf("-version %q is not a semantic version like 1.4.0", version)
+	}
+	version = strings.TrimPrefix(version, "v")
+
+	cfg, err := config.New()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	events := eventMessages()
+	if len(events) == 0 {
+		return fmt.Errorf("no %s events registered, run make proto first", eventProtoPackage)
+	}
+
+	doc := asyncapi.NewDocument("go-init domain events", version,
+		"Domain events published by the server and the consumer. Payloads are JSON encoded proto messages, "+
+			"headers are the watermill metadata of the message.")
+	doc.Servers = map[string]asyncapi.Server{cfg.Events.BrokerType(): brokerServer(cfg.Events, cfg.Databases.PgMqUrl)}
+
+	encrypted := make(map[string]bool)
+	if cfg.Events.Encryption.Enabled {
+		for _, name := range cfg.Events.Encryption.Events {
+			encrypted[name] = true
+		}
+	}
+	ttls := watmil.TTLPolicy{Default: cfg.Events.DefaultTTL, Events: cfg.Events.TTLs}
+	failureModes := cfg.Events.PublishFailure.Modes()
+
+	for _, event := range events {
+		name := string(event.Name())
+
+		var notes []string
+		if ttl := ttls.For(name); ttl > 0 {
+			notes = append(notes, fmt.Sprintf("Expires %s after publishing, as set in the %s header; consumers drop it afterwards.", ttl, watmil.MetadataExpiresAt))
+		}
+		mode, ok := failureModes[name]
+		if !ok {
+			mode = cfg.Events.PublishFailure.Default
+		}
+		if mode != "" {
+			notes = append(notes, "Publish failure mode: "+mode+".")
+		}
+		if encrypted[name] {
+			notes = append(notes, fmt.Sprintf("The payload is encrypted with the key named in the %s header; the schema is that of the decrypted payload.", watmil.MetadataEncryptionKeyID))
+		}
+
+		doc.AddSent(watmil.EventTopic(name), channelDescription(cfg.Events), asyncapi.Message{
+			Name:        name,
+			Title:       name,
+			Summary:     summary(event),
+			Description: strings.Join(notes, "\n\n"),
+			ContentType: "application/json",
+			Headers:     headersSchema(),
+			Payload:     doc.ProtoSchema(event),
+		})
+	}
+
+	dir := filepath.Join(out, version)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "events.asyncapi.json")
+	if err := os.WriteFile(path, append(body, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ %s: %d events\n", path, len(events))
+	return nil
+}
+
+// eventMessages returns the registered domain event messages sorted by name,
+// e.g. UserCreatedEvent, leaving out the messages nested in them
+func eventMessages() []protoreflect.MessageDescriptor {
+	var events []protoreflect.MessageDescriptor
+	protoregistry.GlobalFiles.RangeFilesByPackage(eventProtoPackage, func(file protoreflect.FileDescriptor) bool {
+		messages := file.Messages()
+		for i := 0; i < messages.Len(); i++ {
+			if strings.HasSuffix(string(messages.Get(i).Name()), "Event") {
+				events = append(events, messages.Get(i))
+			}
+		}
+		return true
+	})
+	sort.Slice(events, func(i, j int) bool { return events[i].Name() < events[j].Name() })
+	return events
+}
+
+// summary returns the leading comment of desc, when the descriptor kept it
+func summary(desc protoreflect.MessageDescriptor) string {
+	location := desc.ParentFile().SourceLocations().ByDescriptor(desc)
+	return strings.TrimSpace(location.LeadingComments)
+}
+
+// brokerServer describes the broker of cfg, without credentials
+func brokerServer(cfg config.EventConfig, sqlDSN string) asyncapi.Server {
+	switch cfg.BrokerType() {
+	// template:begin sqs
+	case config.BrokerSQS:
+		region := cfg.SQS.Region
+		if region == "" {
+			region = "{region}"
+		}
+		return asyncapi.Server{
+			Host:        "sns." + region + ".amazonaws.com",
+			Protocol:    "sns",
+			Description: "One SNS topic per channel, named after its address with characters SNS does not allow replaced by dashes. The SNS message body is a JSON object holding the uuid, the metadata headers and the base64 encoded payload.",
+		}
+	// template:end sqs
+	// template:begin pubsub
+	case config.BrokerPubSub:
+		return asyncapi.Server{
+			Host:        "pubsub.googleapis.com",
+			Protocol:    "googlepubsub",
+			Description: "One topic per channel in project " + cfg.PubSub.ProjectID + ", with the headers as message attributes.",
+		}
+		// template:end pubsub
+	}
+
+	host := "{host}"
+	if u, err := url.Parse(sqlDSN); err == nil && u.Host != "" {
+		host = u.Host + u.Path
+	}
+	return asyncapi.Server{
+		Host:        host,
+		Protocol:    "postgresql",
+		Description: "Events are rows of the watermill_<address> table of the message database, read through watermill-sql.",
+	}
+}
+
+// channelDescription tells how consumers subscribe to a channel of cfg's broker
+func channelDescription(cfg config.EventConfig) string {
+	switch cfg.BrokerType() {
+	// template:begin sqs
+	case config.BrokerSQS:
+		description := "Every consumer handler reads its own SQS queue named " + cfg.SQS.QueuePrefix + "<handler>, subscribed to the topic."
+		if cfg.SQS.FIFO {
+			description += " Topics and queues are FIFO, delivering the events of one ordering_key in publish order."
+		}
+		return description
+	// template:end sqs
+	// template:begin pubsub
+	case config.BrokerPubSub:
+		description := "Every consumer handler reads its own subscription named " + cfg.PubSub.SubscriptionPrefix + "<handler>."
+		if cfg.PubSub.Ordering {
+			description += " Events sharing an ordering_key are delivered in publish order."
+		}
+		return description
+		// template:end pubsub
+	}
+	return "Every consumer handler keeps its own offset in the table, so each handler receives every event."
+}
+
+// headersSchema describes the watermill metadata set on published events
+func headersSchema() asyncapi.Schema {
+	return asyncapi.Schema{
+		"type": "object",
+		"properties": map[string]any{
+			"name": asyncapi.Schema{"type": "string", "description": "Event name, e.g. UserCreatedEvent"},
+			watmil.MetadataExpiresAt: asyncapi.Schema{"type": "string", "format": "date-time",
+				"description": "Time after which consumers drop the event, unset when it never expires"},
+			watmil.MetadataOrderingKey: asyncapi.Schema{"type": "string",
+				"description": "Key of the aggregate whose events brokers with ordered delivery keep in publish order"},
+			watmil.MetadataAggregateType: asyncapi.Schema{"type": "string", "description": "Kind of aggregate the event is about, e.g. product"},
+			watmil.MetadataAggregateID:   asyncapi.Schema{"type": "string", "description": "ID of the aggregate the event is about"},
+			watmil.MetadataEncryptionKeyID: asyncapi.Schema{"type": "string",
+				"description": "Key the payload is encrypted with, unset on plaintext payloads"},
+		},
+		"additionalProperties": asyncapi.Schema{"type": "string"},
+	}
+}
@@ -0,0 +1,81 @@
+// Command audit checks the audit trail the consumer records domain events in.
+//
+//	audit verify [-batch-size n]
+//
+// verify recomputes the hash chain of the audit_log table from its first
+// record and exits non-zero at the first record that was changed, removed or
+// reordered. It prints the hash of the last intact record; keeping that head
+// outside the database, e.g. in a ticket or a WORM bucket, also detects the
+// trail being rewritten from scratch when a later head does not chain to it.
+// Records moved to an archive by cmd/archive are skipped through their
+// manifests, which record the hashes they chain with.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/audit"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	if len(os.Args) < 2 || os.Args[1] != "verify" {
+		usage()
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	batchSize := flags.Int("batch-size", 1000, "number of records read per query")
+	flags.Parse(os.Args[2:])
+
+	cfg, err := config.New()
+	if err != nil {
+		slog.Error("Error loading config:", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	intact, err := verify(context.Background(), cfg, int32(*batchSize))
+	if err != nil {
+		slog.Error("Audit command failed", "command", os.Args[1], slog.Any("error", err))
+		os.Exit(1)
+	}
+	if !intact {
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: audit verify [-batch-size n]")
+	os.Exit(2)
+}
+
+// verify prints the verification result and reports whether the trail is intact
+func verify(ctx context.Context, cfg *config.Config, batchSize int32) (bool, error) {
+	pool, err := pgxpool.New(ctx, cfg.Databases.DbDsn)
+	if err != nil {
+		return false, fmt.Errorf("connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	result, err := audit.Verify(ctx, sqlc.New(pool), batchSize)
+	if err != nil {
+		return false, err
+	}
+
+	if result.BrokenAt != 0 {
+		fmt.Printf("audit trail broken at record %d: %s\n", result.BrokenAt, result.Reason)
+		fmt.Printf("%d records verified before it, last intact hash %s\n", result.Records, hex.EncodeToString(result.Head))
+		return false, nil
+	}
+	fmt.Printf("audit trail intact: %d records, %d archived, head hash %s\n", result.Records, result.Archived, hex.EncodeToString(result.Head))
+	return true, nil
+}
@@ -0,0 +1,36 @@
+// Command configcheck loads the config the way the services do and prints the
+// effective values as YAML, to debug which source won for each of them.
+//
+//	configcheck [--servers.grpc-port=9001 ...]
+//
+// It takes the config flags of the services, and reads the same files, layers
+// (APP_ENV, config.local.yaml) and environment variables. Every value is
+// commented with the config file, APP_ environment variable or flag it came
+// from, or default when none set it. Secrets are resolved, so unreachable
+// secret stores fail the check, but printed redacted. It exits non-zero when
+// the config does not load or is invalid.
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/erry-az/go-init/config"
+)
+
+func main() {
+	// Logs go to stderr, keeping stdout to the config
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	cfg, err := config.NewWithFlags("configcheck", os.Args[1:])
+	if err != nil {
+		slog.Error("Error loading config:", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	if err := config.Dump(os.Stdout, cfg); err != nil {
+		slog.Error("Failed to print config", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
@@ -13,12 +13,21 @@ func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
-	cfg, err := config.New()
+	cfg, err := config.NewWithFlags("consumer", os.Args[1:])
 	if err != nil {
 		slog.Error("Error loading config:", slog.Any("error", err))
 		return
 	}
 
+	// Switch to the configured log targets
+	logger, logCloser, err := app.NewLogger(cfg.Logging)
+	if err != nil {
+		slog.Error("Failed to configure logging", slog.Any("error", err))
+		return
+	}
+	defer logCloser.Close()
+	slog.SetDefault(logger)
+
 	// Create consumer application
 	consumerApp, err := app.NewConsumerApp(cfg)
 	if err != nil {
@@ -0,0 +1,301 @@
+// Command dev wires the application against the configured local databases
+// and broker for quick manual testing.
+//
+//	dev [-serve] [command [args]]
+//
+// Without a command it reads commands from stdin until quit or EOF:
+//
+//	users [n]      create n users, 1 by default
+//	events [n]     create, update and delete n sample products, publishing their events
+//	query sql      run a SQL statement on the main database and print its rows
+//	cache clear    empty the read caches
+//	help           list the commands
+//	quit           exit
+//
+// With a command it runs that one and exits. -serve also starts the servers
+// and background workers, so the API can be called while commands run.
+// Everything created is real: point the config at a local database.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/app"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/google/uuid"
+)
+
+// maxQueryRows bounds the rows query prints
+const maxQueryRows = 100
+
+// errQuit ends the command loop
+var errQuit = errors.New("quit")
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	flags := flag.NewFlagSet("dev", flag.ExitOnError)
+	flags.Usage = usage
+	serve := flags.Bool("serve", false, "also start the servers and background workers")
+	flags.Parse(os.Args[1:])
+
+	cfg, err := config.New()
+	if err != nil {
+		slog.Error("Error loading config:", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	application, err := app.NewEndpoint(cfg)
+	if err != nil {
+		slog.Error("Failed to initialize application", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer application.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Run logs its own failure; the servers stop once the commands are done
+	served := make(chan struct{})
+	if *serve {
+		go func() {
+			defer close(served)
+			application.Run(ctx)
+		}()
+	} else {
+		close(served)
+	}
+
+	d := &dev{app: application}
+	var failed bool
+	if flags.NArg() > 0 {
+		if err := d.run(ctx, strings.Join(flags.Args(), " ")); err != nil && !errors.Is(err, errQuit) {
+			slog.Error("Dev command failed", "command", flags.Arg(0), slog.Any("error", err))
+			failed = true
+		}
+	} else {
+		d.loop(ctx)
+	}
+
+	stop()
+	<-served
+	if failed {
+		application.Close()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dev [-serve] [users [n] | events [n] | query sql | cache clear]")
+	os.Exit(2)
+}
+
+// dev runs the commands against the wired application
+type dev struct {
+	app *app.App
+}
+
+// loop reads and runs commands from stdin until quit, EOF or ctx is done
+func (d *dev) loop(ctx context.Context) {
+	fmt.Println("go-init dev, type help for the commands")
+
+	// Lines are read in the background so an interrupt ends the loop while
+	// waiting for input
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	for {
+		fmt.Print("dev> ")
+		var line string
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return
+		case l, ok := <-lines:
+			if !ok {
+				fmt.Println()
+				return
+			}
+			line = l
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := d.run(ctx, line); err != nil {
+			if errors.Is(err, errQuit) {
+				return
+			}
+			fmt.Println("error:", err)
+		}
+	}
+}
+
+// run runs the command of line; query takes the rest of the line verbatim
+func (d *dev) run(ctx context.Context, line string) error {
+	command, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	args := strings.Fields(rest)
+
+	switch command {
+	case "users":
+		n, err := count(args)
+		if err != nil {
+			return err
+		}
+		return d.createUsers(ctx, n)
+	case "events":
+		n, err := count(args)
+		if err != nil {
+			return err
+		}
+		return d.publishEvents(ctx, n)
+	case "query":
+		if len(args) == 0 {
+			return errors.New("usage: query sql")
+		}
+		return d.query(ctx, strings.TrimSpace(rest))
+	case "cache":
+		if len(args) != 1 || args[0] != "clear" {
+			return errors.New("usage: cache clear")
+		}
+		if !d.app.ClearCaches() {
+			fmt.Println("caching is disabled, nothing to clear")
+			return nil
+		}
+		fmt.Println("caches cleared")
+		return nil
+	case "help":
+		help()
+		return nil
+	case "quit", "exit":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command %q, type help for the commands", command)
+	}
+}
+
+func help() {
+	fmt.Println(`users [n]      create n users, 1 by default
+events [n]     create, update and delete n sample products, publishing their events
+query sql      run a SQL statement on the main database and print its rows
+cache clear    empty the read caches
+help           list the commands
+quit           exit`)
+}
+
+// count parses the optional count argument of a command
+func count(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid count %q", args[0])
+	}
+	return n, nil
+}
+
+// createUsers creates n users with unique sample emails
+func (d *dev) createUsers(ctx context.Context, n int) error {
+	for i := range n {
+		suffix := uuid.NewString()[:8]
+		user, err := d.app.UserUsecase.CreateUser(ctx, "Dev User "+suffix, "dev-"+suffix+"@example.com", map[string]string{"source": "dev"})
+		if err != nil {
+			return fmt.Errorf("creating user %d: %w", i+1, err)
+		}
+		fmt.Printf("created user %s <%s>\n", user.ID, user.Email)
+	}
+	return nil
+}
+
+// publishEvents takes n sample products through their lifecycle, publishing
+// a created, an updated and a deleted event for each
+func (d *dev) publishEvents(ctx context.Context, n int) error {
+	products := d.app.ProductUsecase
+	metadata := map[string]string{"source": "dev"}
+
+	for i := range n {
+		name := "Dev Product " + uuid.NewString()[:8]
+		product, err := products.CreateProduct(ctx, name, "Sample product", "9.99", "", nil, metadata, nil, domain.ProductActive)
+		if err != nil {
+			return fmt.Errorf("creating product %d: %w", i+1, err)
+		}
+		id := product.ID.String()
+		if _, err := products.UpdateProduct(ctx, id, name, "Sample product", "19.99", "", nil, metadata, nil); err != nil {
+			return fmt.Errorf("updating product %s: %w", id, err)
+		}
+		if err := products.DeleteProduct(ctx, id); err != nil {
+			return fmt.Errorf("deleting product %s: %w", id, err)
+		}
+		fmt.Printf("published created, updated and deleted events of product %s\n", id)
+	}
+	return nil
+}
+
+// query runs sql on the main database and prints up to maxQueryRows rows
+func (d *dev) query(ctx context.Context, sql string) error {
+	rows, err := d.app.DB().Query(ctx, sql)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	var columns []string
+	for _, field := range rows.FieldDescriptions() {
+		columns = append(columns, field.Name)
+	}
+	if len(columns) > 0 {
+		fmt.Fprintln(w, strings.Join(columns, "\t"))
+	}
+
+	printed, total := 0, 0
+	for rows.Next() {
+		total++
+		if printed == maxQueryRows {
+			continue
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = fmt.Sprint(v)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+		printed++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	w.Flush()
+
+	if total > printed {
+		fmt.Printf("(%d of %d rows)\n", printed, total)
+	} else if len(columns) > 0 {
+		fmt.Printf("(%d rows)\n", total)
+	} else {
+		fmt.Println(rows.CommandTag().String())
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/migration"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// dualWriteTables lists the tables being moved to a new schema, e.g.
+//
+//	{
+//		Name:    "products_v2",
+//		Old:     "products",
+//		New:     "products_v2",
+//		Key:     "id",
+//		KeyType: "uuid",
+//		Columns: []migration.Column{
+//			{Name: "name"},
+//			{Name: "price_cents", Expr: "(price * 100)::bigint"},
+//			{Name: "created_at"},
+//			{Name: "updated_at"},
+//		},
+//	},
+//
+// The repositories writing the old table write the new one through
+// migration.DualWrite under the same name.
+var dualWriteTables = []migration.Table{}
+
+// dualWriteTable returns the registered table of the migration name
+func dualWriteTable(name string) (migration.Table, error) {
+	for _, t := range dualWriteTables {
+		if t.Name == name {
+			return t, t.Validate()
+		}
+	}
+	return migration.Table{}, fmt.Errorf("unknown dual-write migration %q", name)
+}
+
+// dualWritePhase returns the configured phase of the migration name,
+// refusing it unless it is one of allowed
+func dualWritePhase(cfg *config.Config, name string, allowed ...migration.Phase) (migration.Phase, error) {
+	phases, err := migration.ParsePhases(cfg.Migration.DualWrite)
+	if err != nil {
+		return "", err
+	}
+
+	phase := phases.Of(name)
+	for _, p := range allowed {
+		if phase == p {
+			return phase, nil
+		}
+	}
+	return "", fmt.Errorf("dual-write migration %s is in phase %s, expected one of %v", name, phase, allowed)
+}
+
+// backfill copies the existing rows of the migration name to its new table
+func backfill(ctx context.Context, cfg *config.Config, name string, batchSize int32, restart bool) error {
+	t, err := dualWriteTable(name)
+	if err != nil {
+		return err
+	}
+	if _, err := dualWritePhase(cfg, name, migration.PhaseBackfill); err != nil {
+		return err
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Databases.DbDsn)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	if restart {
+		if err := sqlc.New(pool).ResetDataMigration(ctx, name); err != nil {
+			return fmt.Errorf("reset progress: %w", err)
+		}
+	}
+
+	result, err := migration.Backfill(ctx, pool, t, batchSize)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d row(s) read in %d batch(es), %d copied\n", result.Read, result.Batches, result.Copied)
+	return nil
+}
+
+// verify compares the old and new tables of the migration name
+func verify(ctx context.Context, cfg *config.Config, name string, chunkSize int32, repair bool) error {
+	t, err := dualWriteTable(name)
+	if err != nil {
+		return err
+	}
+	allowed := []migration.Phase{migration.PhaseVerify, migration.PhaseReadNew}
+	if repair {
+		// Chunks are copied again from the old table, which must still be complete
+		allowed = []migration.Phase{migration.PhaseVerify}
+	}
+	if _, err := dualWritePhase(cfg, name, allowed...); err != nil {
+		return err
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Databases.DbDsn)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	result, err := migration.Verify(ctx, pool, t, chunkSize, repair)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range result.Mismatched {
+		fmt.Printf("mismatched chunk %s\n", chunk)
+	}
+	fmt.Printf("%d row(s) in %d chunk(s) verified, %d repaired, %d mismatched\n",
+		result.Rows, result.Chunks, result.Repaired, len(result.Mismatched))
+	if len(result.Mismatched) > 0 {
+		return fmt.Errorf("%d chunk(s) of %s differ", len(result.Mismatched), name)
+	}
+	return nil
+}
+
+// status prints the phase and progress of every registered migration
+func status(ctx context.Context, cfg *config.Config) error {
+	phases, err := migration.ParsePhases(cfg.Migration.DualWrite)
+	if err != nil {
+		return err
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Databases.DbDsn)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	rows, err := sqlc.New(pool).ListDataMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("list progress: %w", err)
+	}
+	progress := make(map[string]sqlc.DataMigration, len(rows))
+	for _, row := range rows {
+		progress[row.Name] = row
+	}
+
+	for _, t := range dualWriteTables {
+		line := fmt.Sprintf("%s: %s -> %s, phase %s", t.Name, t.Old, t.New, phases.Of(t.Name))
+		if p, ok := progress[t.Name]; ok {
+			line += fmt.Sprintf(", %d row(s) copied", p.RowsCopied)
+			if p.BackfillFinishedAt.Valid {
+				line += ", backfill finished"
+			}
+			if p.VerifiedAt.Valid {
+				line += fmt.Sprintf(", %d row(s) verified with %d mismatched chunk(s)", p.RowsVerified, p.MismatchedChunks)
+			}
+			if err := migration.CheckCutover(p); err == nil {
+				line += ", ready to read the new table"
+			}
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/envelope"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// backfillEmails canonicalizes stored user emails and, with merge, merges the
+// flagged duplicates. It reads emails through the PII encryption when enabled.
+func backfillEmails(ctx context.Context, cfg *config.Config, merge bool, batchSize int32) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch size must be positive")
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Databases.DbDsn)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	querier, err := emailQuerier(cfg.Encryption, sqlc.New(pool))
+	if err != nil {
+		return err
+	}
+
+	updated, flagged, err := canonicalizeEmails(ctx, querier, cfg.User.StripEmailPlusTags, batchSize)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d email(s) canonicalized, %d duplicate user(s) flagged\n", updated, flagged)
+
+	if !merge {
+		return nil
+	}
+
+	merged, skipped, err := mergeDuplicates(ctx, pool, batchSize)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d duplicate user(s) merged, %d skipped\n", merged, skipped)
+	return nil
+}
+
+// emailQuerier wraps querier with the PII encryption of user emails when configured
+func emailQuerier(cfg config.EncryptionConfig, querier sqlc.Querier) (sqlc.Querier, error) {
+	if !cfg.Enabled || !cfg.EncryptUserEmail {
+		return querier, nil
+	}
+
+	provider, err := envelope.NewLocalKeyProvider(cfg.ActiveKeyID, cfg.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("create key provider: %w", err)
+	}
+	return repository.NewEncryptedQuerier(querier, envelope.NewEncryptor(provider), []byte(cfg.BlindIndexKey)), nil
+}
+
+// canonicalizeEmails rewrites every email that is not canonical. When another
+// user already holds the canonical email, the newer of the two is flagged as a
+// duplicate of the older one instead, and only the older one is rewritten.
+func canonicalizeEmails(ctx context.Context, querier sqlc.Querier, stripPlusTags bool, batchSize int32) (updated, flagged int, err error) {
+	afterID := uuid.Nil
+	for {
+		users, err := querier.ListUsersForEmailBackfill(ctx, sqlc.ListUsersForEmailBackfillParams{
+			AfterID:   afterID,
+			BatchSize: batchSize,
+		})
+		if err != nil {
+			return updated, flagged, fmt.Errorf("list users: %w", err)
+		}
+
+		for _, user := range users {
+			afterID = user.ID
+
+			canonical := domain.CanonicalEmail(user.Email, stripPlusTags)
+			if canonical == user.Email {
+				continue
+			}
+
+			existing, err := querier.GetUserByEmail(ctx, sqlc.GetUserByEmailParams{Email: canonical})
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return updated, flagged, fmt.Errorf("look up email of user %s: %w", user.ID, err)
+			}
+
+			if err == nil && existing.ID != user.ID {
+				keep, duplicate := existing, user
+				if user.CreatedAt.Time.Before(existing.CreatedAt.Time) {
+					keep, duplicate = user, existing
+				}
+
+				err := querier.FlagDuplicateUser(ctx, sqlc.FlagDuplicateUserParams{
+					DuplicateOf: pgtype.UUID{Bytes: keep.ID, Valid: true},
+					ID:          duplicate.ID,
+				})
+				if err != nil {
+					return updated, flagged, fmt.Errorf("flag user %s: %w", duplicate.ID, err)
+				}
+				slog.Info("Flagged duplicate user", "user_id", duplicate.ID, "duplicate_of", keep.ID)
+				flagged++
+
+				if duplicate.ID == user.ID {
+					continue
+				}
+			}
+
+			_, err = querier.UpdateUser(ctx, sqlc.UpdateUserParams{
+				ID:       user.ID,
+				Name:     user.Name,
+				Email:    canonical,
+				Metadata: user.Metadata,
+			})
+			if err != nil {
+				return updated, flagged, fmt.Errorf("update email of user %s: %w", user.ID, err)
+			}
+			updated++
+		}
+
+		if len(users) < int(batchSize) {
+			return updated, flagged, nil
+		}
+	}
+}
+
+// mergeDuplicates folds the metadata of each flagged duplicate into the user
+// it duplicates, keeping that user's values on conflicts, and deletes the
+// duplicate along with its pending email changes. Duplicates whose user no
+// longer exists are skipped and stay flagged.
+func mergeDuplicates(ctx context.Context, pool *pgxpool.Pool, batchSize int32) (merged, skipped int, err error) {
+	queries := sqlc.New(pool)
+
+	afterID := uuid.Nil
+	for {
+		duplicates, err := queries.ListDuplicateUsers(ctx, sqlc.ListDuplicateUsersParams{
+			AfterID:   afterID,
+			BatchSize: batchSize,
+		})
+		if err != nil {
+			return merged, skipped, fmt.Errorf("list duplicate users: %w", err)
+		}
+
+		for _, duplicate := range duplicates {
+			afterID = duplicate.ID
+
+			ok, err := mergeDuplicate(ctx, pool, duplicate)
+			if err != nil {
+				return merged, skipped, fmt.Errorf("merge user %s: %w", duplicate.ID, err)
+			}
+			if !ok {
+				slog.Warn("Skipping duplicate user of missing user", "user_id", duplicate.ID,
+					"duplicate_of", uuid.UUID(duplicate.DuplicateOf.Bytes))
+				skipped++
+				continue
+			}
+			slog.Info("Merged duplicate user", "user_id", duplicate.ID, "duplicate_of", uuid.UUID(duplicate.DuplicateOf.Bytes))
+			merged++
+		}
+
+		if len(duplicates) < int(batchSize) {
+			return merged, skipped, nil
+		}
+	}
+}
+
+// mergeDuplicate merges duplicate in one transaction and reports whether the
+// user it duplicates was found
+func mergeDuplicate(ctx context.Context, pool *pgxpool.Pool, duplicate sqlc.User) (bool, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := sqlc.New(tx)
+	rows, err := queries.MergeUserMetadata(ctx, sqlc.MergeUserMetadataParams{
+		Metadata: duplicate.Metadata,
+		ID:       uuid.UUID(duplicate.DuplicateOf.Bytes),
+	})
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if err := queries.DeleteUser(ctx, duplicate.ID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
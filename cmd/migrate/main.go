@@ -0,0 +1,180 @@
+// Command migrate checks migrations before Atlas applies them and backfills
+// data they cannot migrate in SQL.
+//
+//	migrate lint [-all]
+//	migrate emails [-merge] [-batch-size n]
+//	migrate backfill [-batch-size n] [-restart] <name>
+//	migrate verify [-chunk-size n] [-repair] <name>
+//	migrate status
+//
+// lint reports dangerous statements in the migrations not yet applied to the
+// configured database, or in every migration with -all, and exits non-zero
+// when a finding has error severity under the configured policy.
+//
+// emails rewrites stored user emails to their canonical form and flags users
+// whose canonical emails collide as duplicates of the oldest one. With -merge
+// it then folds the metadata of every flagged duplicate into the user it
+// duplicates and deletes the duplicate. Neither step publishes user events.
+//
+// backfill, verify and status drive the dual-write migrations of tables to a
+// new schema, each in the phase set by migration.dual_write. backfill copies
+// the rows of the old table missing from the new one, resuming where the last
+// run stopped unless -restart is given, and runs in phase backfill. verify
+// compares checksums of both tables in key ranges and exits non-zero when one
+// differs; -repair copies such ranges again, only in phase verify. status
+// prints the phase and progress of each migration.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/migration"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultMigrationDir is used when the config leaves migration.dir unset
+const defaultMigrationDir = "db/migrations"
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	var run func(ctx context.Context, cfg *config.Config) error
+	switch os.Args[1] {
+	case "lint":
+		all := flags.Bool("all", false, "lint every migration instead of only the pending ones")
+		run = func(ctx context.Context, cfg *config.Config) error {
+			blocked, err := lint(ctx, cfg, *all)
+			if err != nil {
+				return fmt.Errorf("lint migrations: %w", err)
+			}
+			if blocked {
+				os.Exit(1)
+			}
+			return nil
+		}
+	case "emails":
+		merge := flags.Bool("merge", false, "merge flagged duplicate users into the user they duplicate")
+		batchSize := flags.Int("batch-size", 500, "number of users read per query")
+		run = func(ctx context.Context, cfg *config.Config) error {
+			return backfillEmails(ctx, cfg, *merge, int32(*batchSize))
+		}
+	case "backfill":
+		batchSize := flags.Int("batch-size", 1000, "number of rows copied per transaction")
+		restart := flags.Bool("restart", false, "copy from the first row instead of resuming")
+		run = func(ctx context.Context, cfg *config.Config) error {
+			return backfill(ctx, cfg, migrationName(flags), int32(*batchSize), *restart)
+		}
+	case "verify":
+		chunkSize := flags.Int("chunk-size", 10000, "number of rows compared per checksum")
+		repair := flags.Bool("repair", false, "copy mismatched chunks again from the old table")
+		run = func(ctx context.Context, cfg *config.Config) error {
+			return verify(ctx, cfg, migrationName(flags), int32(*chunkSize), *repair)
+		}
+	case "status":
+		run = status
+	default:
+		usage()
+	}
+	flags.Parse(os.Args[2:])
+
+	cfg, err := config.New()
+	if err != nil {
+		slog.Error("Error loading config:", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	if err := run(context.Background(), cfg); err != nil {
+		slog.Error("Migrate command failed", "command", os.Args[1], slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate lint [-all] | migrate emails [-merge] [-batch-size n] |")
+	fmt.Fprintln(os.Stderr, "       migrate backfill [-batch-size n] [-restart] <name> | migrate verify [-chunk-size n] [-repair] <name> | migrate status")
+	os.Exit(2)
+}
+
+// migrationName returns the dual-write migration named by the only argument
+func migrationName(flags *flag.FlagSet) string {
+	if flags.NArg() != 1 {
+		usage()
+	}
+	return flags.Arg(0)
+}
+
+// lint prints the findings and reports whether any of them blocks the migration
+func lint(ctx context.Context, cfg *config.Config, all bool) (bool, error) {
+	policy, err := lintPolicy(cfg.Migration.Lint)
+	if err != nil {
+		return false, err
+	}
+
+	dir := cfg.Migration.Dir
+	if dir == "" {
+		dir = defaultMigrationDir
+	}
+	files, err := migration.Files(dir)
+	if err != nil {
+		return false, err
+	}
+
+	if !all {
+		pool, err := pgxpool.New(ctx, cfg.Databases.DbDsn)
+		if err != nil {
+			return false, err
+		}
+		defer pool.Close()
+
+		applied, err := migration.Applied(ctx, pool)
+		if err != nil {
+			return false, fmt.Errorf("read applied migrations: %w", err)
+		}
+		files = migration.Pending(files, applied)
+	}
+
+	findings, err := migration.LintFiles(files, policy)
+	if err != nil {
+		return false, err
+	}
+
+	blocked := false
+	for _, finding := range findings {
+		fmt.Println(finding)
+		if finding.Severity == migration.SeverityError {
+			blocked = true
+		}
+	}
+	fmt.Printf("%d migration(s) checked, %d finding(s)\n", len(files), len(findings))
+
+	return blocked, nil
+}
+
+// lintPolicy converts the configured rule severities
+func lintPolicy(rules map[string]string) (migration.Policy, error) {
+	policy := migration.Policy{}
+	for rule, severity := range rules {
+		if _, ok := migration.DefaultPolicy[rule]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q", rule)
+		}
+
+		switch s := migration.Severity(severity); s {
+		case migration.SeverityError, migration.SeverityWarn, migration.SeverityOff:
+			policy[rule] = s
+		default:
+			return nil, fmt.Errorf("invalid severity %q for lint rule %s", severity, rule)
+		}
+	}
+	return policy, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// archiveTime is the modification time of every archived file, the one npm
+// pack uses, so archives of the same sources are byte-identical
+var archiveTime = time.Date(1985, time.October, 26, 8, 15, 0, 0, time.UTC)
+
+// writeArchive packs the files below root into a gzipped tarball at
+// filename, under the directory prefix, and writes its SHA-256 checksum to
+// filename.sha256 in the format sha256sum -c reads
+func writeArchive(filename, root, prefix string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(f, sum))
+	tw := tar.NewWriter(gz)
+
+	// WalkDir visits files in lexical order, keeping the archive stable
+	err = filepath.WalkDir(root, func(file string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    path.Join(prefix, filepath.ToSlash(rel)),
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: archiveTime,
+			Format:  tar.FormatPAX,
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	checksum := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum.Sum(nil)), filepath.Base(filename))
+	return os.WriteFile(filename+".sha256", []byte(checksum), 0644)
+}
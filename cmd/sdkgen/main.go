@@ -0,0 +1,137 @@
+// Command sdkgen generates client SDKs for the public gRPC API from the proto
+// definitions and packages them as versioned artifacts, so consuming teams
+// install a package instead of running protoc themselves.
+//
+//	sdkgen -version 1.4.0 [-lang typescript,python] [-name go-init-sdk] [-out dist/sdk]
+//
+// Code is generated with buf from the protos under -path. Every SDK ships
+// helpers that send the API key, client ID and tenant ID as metadata and
+// retry UNAVAILABLE and RESOURCE_EXHAUSTED calls with exponential backoff.
+// The artifacts land in <out>/<version>:
+//
+//   - typescript: <name>-<version>.tgz, an npm package (protobuf-es and
+//     Connect over gRPC) installable with npm install ./<file>
+//   - python: <name>-<version>.tar.gz, a source distribution (grpcio)
+//     installable with pip install ./<file>
+//
+// Each artifact gets a .sha256 file next to it, and the unpacked package is
+// kept in a directory named after its language for inspection.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// semverRegexp matches the SDK versions accepted by -version
+var semverRegexp = regexp.MustCompile(`^v?(\d+\.\d+\.\d+)(?:-([0-9A-Za-z.-]+))?$`)
+
+func main() {
+	version := flag.String("version", "", "SDK version, e.g. 1.4.0 or 1.5.0-rc.1 (required)")
+	langs := flag.String("lang", "typescript,python", "comma-separated SDK languages to generate")
+	name := flag.String("name", "", "package name (default: the Go module name with -sdk)")
+	out := flag.String("out", "dist/sdk", "directory the versioned artifacts are written to")
+	input := flag.String("path", "proto/api", "proto directory of the public API")
+	flag.Parse()
+
+	if err := run(*version, *langs, *name, *out, *input); err != nil {
+		fmt.Fprintf(os.Stderr, "sdkgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(version, langs, name, out, input string) error {
+	if !semverRegexp.MatchString(version) {
+		return fmt.Errorf("-version %q is not a semantic version like 1.4.0", version)
+	}
+	version = strings.TrimPrefix(version, "v")
+
+	if name == "" {
+		module, err := moduleName()
+		if err != nil {
+			return err
+		}
+		name = module[strings.LastIndex(module, "/")+1:] + "-sdk"
+	}
+
+	var generators []generator
+	for _, lang := range strings.Split(langs, ",") {
+		g, ok := languages[strings.TrimSpace(lang)]
+		if !ok {
+			return fmt.Errorf("unknown language %q, use typescript or python", lang)
+		}
+		generators = append(generators, g)
+	}
+
+	if _, err := exec.LookPath("buf"); err != nil {
+		return fmt.Errorf("buf is not installed or not in PATH")
+	}
+
+	protos, err := protoFiles(input)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(out, version)
+	for _, g := range generators {
+		pkg, err := newPackage(g, name, version, input, protos, dir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("→ Generating the %s SDK %s %s\n", g.lang, pkg.Name, pkg.Version)
+		artifact, err := pkg.build()
+		if err != nil {
+			return fmt.Errorf("%s SDK: %w", g.lang, err)
+		}
+		fmt.Printf("  ✓ %s\n", artifact)
+	}
+	return nil
+}
+
+// moduleName reads the module path from go.mod in the working directory
+func moduleName() (string, error) {
+	f, err := os.Open("go.mod")
+	if err != nil {
+		return "", fmt.Errorf("reading the module name, run from the repo root or set -name: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("go.mod has no module directive")
+}
+
+// protoFiles returns the .proto files below dir, relative to the buf module
+// root they are imported from
+func protoFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".proto" {
+			return err
+		}
+		rel, err := filepath.Rel(protoRoot, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("%s is outside the proto module %s", path, protoRoot)
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing protos: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no protos found in %s", dir)
+	}
+	return files, nil
+}
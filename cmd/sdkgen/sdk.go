@@ -0,0 +1,216 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// protoRoot is the buf module directory proto imports are relative to, see buf.yaml
+const protoRoot = "proto"
+
+//go:embed templates
+var templates embed.FS
+
+// generator describes how the SDK of one language is generated and packaged
+type generator struct {
+	lang string
+	// bufTemplate is the buf generation config, run with its output in genDir
+	bufTemplate string
+	genDir      string
+	// provided are generated import directories a runtime dependency of the
+	// SDK already ships, dropped to avoid conflicting copies
+	provided []string
+	// files maps templates in templates/<lang> to their package paths, which
+	// are templates too
+	files map[string]string
+	// archiveName returns the artifact file name and the directory its files
+	// are archived under
+	archiveName func(p *sdkPackage) (file, prefix string)
+	// version turns a semantic version into the one the language's tooling accepts
+	version func(semver string) (string, error)
+}
+
+var languages = map[string]generator{
+	"typescript": {
+		lang: "typescript",
+		bufTemplate: `{"version":"v2","plugins":[{"remote":"buf.build/bufbuild/es:v2.2.3","out":".",` +
+			`"opt":["target=js+dts","import_extension=js"]}]}`,
+		genDir: "gen",
+		files: map[string]string{
+			"package.json.tmpl": "package.json",
+			"index.js.tmpl":     "index.js",
+			"index.d.ts.tmpl":   "index.d.ts",
+			"README.md.tmpl":    "README.md",
+		},
+		archiveName: func(p *sdkPackage) (string, string) {
+			// npm pack naming: @scope/name becomes scope-name
+			name := strings.ReplaceAll(strings.TrimPrefix(p.Name, "@"), "/", "-")
+			return fmt.Sprintf("%s-%s.tgz", name, p.Version), "package"
+		},
+		version: func(semver string) (string, error) { return semver, nil },
+	},
+	"python": {
+		lang: "python",
+		bufTemplate: `{"version":"v2","plugins":[` +
+			`{"remote":"buf.build/protocolbuffers/python:v29.3","out":"."},` +
+			`{"remote":"buf.build/protocolbuffers/pyi:v29.3","out":"."},` +
+			`{"remote":"buf.build/grpc/python:v1.70.1","out":"."}]}`,
+		genDir: "src",
+		// googleapis-common-protos ships the google.api modules
+		provided: []string{"google"},
+		files: map[string]string{
+			"pyproject.toml.tmpl": "pyproject.toml",
+			"PKG-INFO.tmpl":       "PKG-INFO",
+			"README.md.tmpl":      "README.md",
+			"init.py.tmpl":        "src/{{.ImportName}}/__init__.py",
+			"client.py.tmpl":      "src/{{.ImportName}}/client.py",
+		},
+		archiveName: func(p *sdkPackage) (string, string) {
+			stem := p.ImportName + "-" + p.Version
+			return stem + ".tar.gz", stem
+		},
+		version: pythonVersion,
+	},
+}
+
+// sdkPackage is an SDK package being built, and the values its templates are rendered with
+type sdkPackage struct {
+	Name    string
+	Version string
+	// ImportName is Name as a Python module name
+	ImportName string
+	// Modules are the generated JavaScript modules of the API protos, relative
+	// to the package root
+	Modules []string
+	// Archive is the artifact file name
+	Archive string
+
+	generator
+	input  string
+	dir    string
+	prefix string
+}
+
+func newPackage(g generator, name, semver, input string, protos []string, dir string) (*sdkPackage, error) {
+	version, err := g.version(semver)
+	if err != nil {
+		return nil, fmt.Errorf("%s SDK: %w", g.lang, err)
+	}
+
+	p := &sdkPackage{
+		Name:       name,
+		Version:    version,
+		ImportName: pythonModuleName(name),
+		generator:  g,
+		input:      input,
+		dir:        dir,
+	}
+	p.Archive, p.prefix = g.archiveName(p)
+	for _, proto := range protos {
+		p.Modules = append(p.Modules, path.Join(g.genDir, strings.TrimSuffix(proto, ".proto")+"_pb.js"))
+	}
+	return p, nil
+}
+
+// build generates the package in <dir>/<lang> and archives it, returning the artifact path
+func (p *sdkPackage) build() (string, error) {
+	root := filepath.Join(p.dir, p.lang)
+	if err := os.RemoveAll(root); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("buf", "generate",
+		"--template", p.bufTemplate,
+		"--output", filepath.Join(root, p.genDir),
+		"--path", p.input,
+		"--include-imports")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("buf generate: %w", err)
+	}
+
+	for _, dir := range p.provided {
+		if err := os.RemoveAll(filepath.Join(root, p.genDir, dir)); err != nil {
+			return "", err
+		}
+	}
+
+	for tmpl, dst := range p.files {
+		if err := p.render(tmpl, dst, root); err != nil {
+			return "", err
+		}
+	}
+
+	artifact := filepath.Join(p.dir, p.Archive)
+	if err := writeArchive(artifact, root, p.prefix); err != nil {
+		return "", fmt.Errorf("archiving: %w", err)
+	}
+	return artifact, nil
+}
+
+// render writes the package file of template tmpl to dst below root
+func (p *sdkPackage) render(tmpl, dst, root string) error {
+	var name strings.Builder
+	if err := template.Must(template.New("path").Parse(dst)).Execute(&name, p); err != nil {
+		return fmt.Errorf("rendering %s: %w", dst, err)
+	}
+
+	t, err := template.New(tmpl).Option("missingkey=error").ParseFS(templates, path.Join("templates", p.lang, tmpl))
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", tmpl, err)
+	}
+
+	filename := filepath.Join(root, filepath.FromSlash(name.String()))
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := t.Execute(f, p); err != nil {
+		return fmt.Errorf("rendering %s: %w", name.String(), err)
+	}
+	return f.Close()
+}
+
+// pythonPrereleaseRegexp matches the semver prereleases PEP 440 has a spelling for
+var pythonPrereleaseRegexp = regexp.MustCompile(`^(alpha|a|beta|b|rc)\.?(\d*)$`)
+
+// pythonVersion turns a semantic version into a PEP 440 one, e.g. 1.5.0-rc.1 into 1.5.0rc1
+func pythonVersion(semver string) (string, error) {
+	m := semverRegexp.FindStringSubmatch(semver)
+	if m[2] == "" {
+		return m[1], nil
+	}
+
+	pre := pythonPrereleaseRegexp.FindStringSubmatch(m[2])
+	if pre == nil {
+		return "", fmt.Errorf("prerelease %q has no Python equivalent, use alpha.N, beta.N or rc.N", m[2])
+	}
+	number := pre[2]
+	if number == "" {
+		number = "0"
+	}
+	return m[1] + map[string]string{"alpha": "a", "a": "a", "beta": "b", "b": "b", "rc": "rc"}[pre[1]] + number, nil
+}
+
+// pythonModuleName turns a package name into a Python module name, e.g.
+// @acme/shop-sdk into shop_sdk
+func pythonModuleName(name string) string {
+	name = name[strings.LastIndex(name, "/")+1:]
+	return strings.ToLower(regexp.MustCompile(`[^A-Za-z0-9]+`).ReplaceAllString(name, "_"))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// backupDir holds the original content of every file rewritten by the last
+// initialization, along with a manifest describing how to restore them
+const backupDir = ".template-init-backup"
+
+// Layout inside backupDir
+const (
+	backupManifestFile = "manifest.json"
+	backupFilesDir     = "files"
+)
+
+type backupManifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	OldModule string    `json:"old_module"`
+	NewModule string    `json:"new_module"`
+	// GitRemote is the origin URL before initialization, empty when there was none
+	GitRemote string       `json:"git_remote,omitempty"`
+	Files     []backupFile `json:"files"`
+}
+
+type backupFile struct {
+	Path string      `json:"path"`
+	Mode fs.FileMode `json:"mode"`
+}
+
+// backup records original files before they are rewritten. The manifest is
+// written after every file so an initialization failing halfway can be undone.
+type backup struct {
+	manifest backupManifest
+	saved    map[string]bool
+}
+
+// newBackup starts a backup for config, refusing to overwrite the backup of a
+// previous initialization
+func newBackup(config Config) (*backup, error) {
+	if _, err := os.Stat(backupDir); err == nil {
+		return nil, fmt.Errorf("%s already exists; run with -undo to restore it or remove it first", backupDir)
+	}
+	if err := os.MkdirAll(filepath.Join(backupDir, backupFilesDir), 0755); err != nil {
+		return nil, fmt.Errorf("creating backup directory: %w", err)
+	}
+
+	b := &backup{
+		manifest: backupManifest{
+			CreatedAt: time.Now().UTC(),
+			OldModule: config.OldModule,
+			NewModule: config.NewModule,
+			GitRemote: currentGitRemote(),
+			Files:     []backupFile{},
+		},
+		saved: make(map[string]bool),
+	}
+	return b, b.writeManifest()
+}
+
+// save stores the original content of filename before it is rewritten or
+// removed. Only the first save of a file is kept, since later ones would see
+// content already rewritten.
+func (b *backup) save(filename string, content []byte, mode fs.FileMode) error {
+	if b.saved[filename] {
+		return nil
+	}
+
+	dst := filepath.Join(backupDir, backupFilesDir, filename)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("backing up %s: %w", filename, err)
+	}
+	if err := os.WriteFile(dst, content, 0644); err != nil {
+		return fmt.Errorf("backing up %s: %w", filename, err)
+	}
+
+	b.manifest.Files = append(b.manifest.Files, backupFile{Path: filename, Mode: mode.Perm()})
+	b.saved[filename] = true
+	return b.writeManifest()
+}
+
+// writeManifest replaces the manifest atomically so it is never left half written
+func (b *backup) writeManifest() error {
+	data, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(backupDir, backupManifestFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("writing backup manifest: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// undo restores the files and git remote recorded in the backup and removes it
+func undo() error {
+	data, err := os.ReadFile(filepath.Join(backupDir, backupManifestFile))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no backup found in %s", backupDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	var manifest backupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("reading backup manifest: %w", err)
+	}
+
+	fmt.Printf("%s↩️  Restoring initialization from %s (%s → %s)%s\n", colorBlue,
+		manifest.CreatedAt.Local().Format(time.DateTime), manifest.OldModule, manifest.NewModule, colorReset)
+
+	// The template version is recorded by the initialization, restored below
+	// if an earlier one recorded it too
+	if err := os.Remove(templateVersionFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, file := range manifest.Files {
+		content, err := os.ReadFile(filepath.Join(backupDir, backupFilesDir, file.Path))
+		if err != nil {
+			return fmt.Errorf("reading backup of %s: %w", file.Path, err)
+		}
+		// Files of removed components are restored along with their directories
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return fmt.Errorf("restoring %s: %w", file.Path, err)
+		}
+		if err := os.WriteFile(file.Path, content, file.Mode); err != nil {
+			return fmt.Errorf("restoring %s: %w", file.Path, err)
+		}
+		fmt.Printf("    - %s\n", file.Path)
+	}
+
+	if manifest.GitRemote != "" && manifest.GitRemote != currentGitRemote() {
+		if err := executeCommand("git remote set-url origin " + manifest.GitRemote); err != nil {
+			return fmt.Errorf("restoring git remote: %w", err)
+		}
+		fmt.Printf("%s✅ Git remote restored to: %s%s\n", colorGreen, manifest.GitRemote, colorReset)
+	}
+
+	return os.RemoveAll(backupDir)
+}
+
+// currentGitRemote returns the origin URL, or "" when it cannot be read
+func currentGitRemote() string {
+	output, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
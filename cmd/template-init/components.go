@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// markerRegexp matches a line marking the beginning or end of a component's
+// section, written as a // or # comment on its own line, e.g. "# template:end gateway"
+var markerRegexp = regexp.MustCompile(`^\s*(?://|#)\s*template:(begin|end)\s+([\w-]+)\s*$`)
+
+// Component is an optional part of the template that initialization can remove
+type Component struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Paths are files, or directories ending in /, that only the component uses
+	Paths []string `yaml:"paths"`
+}
+
+// parseComponentList reads a comma-separated list of component names
+func parseComponentList(list string, components []Component) (map[string]bool, error) {
+	known := make(map[string]bool, len(components))
+	for _, component := range components {
+		known[component.Name] = true
+	}
+
+	removed := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unknown component %q; available: %s", name, componentNames(components))
+		}
+		removed[name] = true
+	}
+	return removed, nil
+}
+
+// promptComponents asks whether to keep each component, keeping it unless the answer is no
+func promptComponents(reader *bufio.Reader, components []Component) map[string]bool {
+	removed := make(map[string]bool)
+	if len(components) == 0 {
+		return removed
+	}
+
+	fmt.Printf("\n%s🧩 Optional components%s\n", colorBlue, colorReset)
+	for _, component := range components {
+		fmt.Printf("Keep %s (%s)? (Y/n): ", component.Name, component.Description)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response == "n" || response == "no" {
+			removed[component.Name] = true
+		}
+	}
+	return removed
+}
+
+func componentNames(components []Component) string {
+	names := make([]string, len(components))
+	for i, component := range components {
+		names[i] = component.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// sortedNames returns the names in set in a stable order for display
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// processComponents deletes the paths of removed components and strips the
+// marked sections of every file: the whole section for removed components and
+// only the marker lines for kept ones. Originals are backed up in b first; a
+// nil b is a dry run.
+func processComponents(manifest *Manifest, removed map[string]bool, b *backup) error {
+	for _, component := range manifest.Components {
+		if !removed[component.Name] {
+			continue
+		}
+
+		fmt.Printf("  → Removing %s\n", component.Name)
+		for _, path := range component.Paths {
+			if err := removePath(path, b); err != nil {
+				return fmt.Errorf("removing %s: %w", path, err)
+			}
+		}
+	}
+
+	files, _, err := listFiles(manifest.MaxFileSize)
+	if err != nil {
+		return fmt.Errorf("listing files: %w", err)
+	}
+
+	known := make(map[string]bool, len(manifest.Components))
+	for _, component := range manifest.Components {
+		known[component.Name] = true
+	}
+
+	fmt.Println("  → Stripping component markers")
+	for _, file := range files {
+		changed, err := stripFile(file, known, removed, b)
+		if err != nil {
+			return fmt.Errorf("processing %s: %w", file, err)
+		}
+		if changed {
+			fmt.Printf("    - %s\n", file)
+		}
+	}
+
+	return nil
+}
+
+// removePath deletes a file or directory of a removed component after backing
+// up every file in it. Missing paths are skipped.
+func removePath(path string, b *backup) error {
+	root := filepath.FromSlash(strings.TrimSuffix(path, "/"))
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		if b == nil {
+			fmt.Printf("    - would remove %s\n", filepath.ToSlash(file))
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := b.save(filepath.ToSlash(file), content, info.Mode()); err != nil {
+			return err
+		}
+		fmt.Printf("    - %s\n", filepath.ToSlash(file))
+		return nil
+	})
+	if err != nil || b == nil {
+		return err
+	}
+
+	return os.RemoveAll(root)
+}
+
+// backupModule backs up go.mod and go.sum, which go mod tidy rewrites after
+// components are removed, so undo restores the requirements they used
+func backupModule(b *backup) error {
+	for _, file := range []string{"go.mod", "go.sum"} {
+		info, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := b.save(file, content, info.Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prunesModule reports whether commands run go mod tidy, which drops the
+// requirements only removed components used
+func prunesModule(commands []string) bool {
+	for _, command := range commands {
+		if strings.Contains(command, "go mod tidy") {
+			return true
+		}
+	}
+	return false
+}
+
+// stripFile strips the component sections of filename, formatting Go files
+// afterwards since removed sections can leave imports or blank lines behind
+func stripFile(filename string, known, removed map[string]bool, b *backup) (bool, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+	if !strings.Contains(string(content), "template:begin") {
+		return false, nil
+	}
+
+	stripped, err := stripContent(filename, string(content), known, removed)
+	if err != nil {
+		return false, err
+	}
+	if stripped == string(content) {
+		return false, nil
+	}
+
+	if b == nil {
+		fmt.Print(unifiedDiff(filename, string(content), stripped))
+		return true, nil
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return false, err
+	}
+	if err := b.save(filename, content, info.Mode()); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(filename, []byte(stripped), info.Mode().Perm()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// stripContent strips the component sections of content, the content of
+// filename, formatting Go files afterwards
+func stripContent(filename, content string, known, removed map[string]bool) (string, error) {
+	stripped, err := stripComponents(content, known, removed)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(filename, ".go") {
+		formatted, err := format.Source([]byte(stripped))
+		if err != nil {
+			return "", fmt.Errorf("formatting after stripping components: %w", err)
+		}
+		stripped = string(formatted)
+	}
+	return stripped, nil
+}
+
+// stripComponents drops the marker lines of content along with the lines
+// between the markers of removed components. Sections may nest.
+func stripComponents(content string, known, removed map[string]bool) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+
+	var sb strings.Builder
+	var open []string
+	dropping := 0
+	for i, line := range lines {
+		match := markerRegexp.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if match == nil {
+			if dropping == 0 {
+				sb.WriteString(line)
+			}
+			continue
+		}
+
+		name := match[2]
+		if !known[name] {
+			return "", fmt.Errorf("line %d: unknown component %q", i+1, name)
+		}
+
+		if match[1] == "begin" {
+			open = append(open, name)
+			if dropping > 0 || removed[name] {
+				dropping++
+			}
+			continue
+		}
+
+		if len(open) == 0 || open[len(open)-1] != name {
+			return "", fmt.Errorf("line %d: unexpected end of component %q", i+1, name)
+		}
+		open = open[:len(open)-1]
+		if dropping > 0 {
+			dropping--
+		}
+	}
+
+	if len(open) > 0 {
+		return "", fmt.Errorf("component %q is never ended", open[len(open)-1])
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// maxDiffCells bounds the table lineDiff fills, above which a change is shown
+// as one hunk replacing the whole file
+const maxDiffCells = 1 << 22
+
+// unifiedDiff renders the change from oldContent to newContent as a unified
+// diff of filename. Replacements never span lines, so files of the same
+// length only need their changed lines found; others, like files merged by
+// sync, are diffed line by line.
+func unifiedDiff(filename, oldContent, newContent string) string {
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+	if len(oldLines) != len(newLines) {
+		if ops, ok := lineDiff(oldLines, newLines); ok {
+			return diffHeader(filename) + opsHunks(ops)
+		}
+		return diffHeader(filename) + wholeFileHunk(oldLines, newLines)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(diffHeader(filename))
+
+	for i := 0; i < len(oldLines); i++ {
+		if oldLines[i] == newLines[i] {
+			continue
+		}
+
+		// Extend the hunk while the next change is within reach of its context
+		start := max(0, i-diffContext)
+		end := i + 1
+		for j := end; j < len(oldLines) && j < end+2*diffContext; j++ {
+			if oldLines[j] != newLines[j] {
+				end = j + 1
+			}
+		}
+		stop := min(len(oldLines), end+diffContext)
+
+		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", start+1, stop-start, start+1, stop-start))
+		for k := start; k < stop; k++ {
+			if oldLines[k] == newLines[k] {
+				writeDiffLine(&sb, " ", oldLines[k])
+				continue
+			}
+			writeDiffLine(&sb, "-", oldLines[k])
+			writeDiffLine(&sb, "+", newLines[k])
+		}
+		i = stop - 1
+	}
+
+	return sb.String()
+}
+
+func diffHeader(filename string) string {
+	return fmt.Sprintf("--- a/%s\n+++ b/%s\n", filename, filename)
+}
+
+// splitLines splits content after each newline, without an empty last line
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOp is a line of a line diff, kept (' '), removed ('-') or added ('+')
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// lineDiff returns the edits turning oldLines into newLines along their
+// longest common subsequence, or false when the files differ in too many
+// lines to compute it cheaply
+func lineDiff(oldLines, newLines []string) ([]diffOp, bool) {
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	a, b := oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix]
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		return nil, false
+	}
+
+	// lcs[i][j] is the common subsequence length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(oldLines)+len(b))
+	for _, line := range oldLines[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i, j = i+1, j+1
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops, true
+}
+
+// opsHunks renders the changes of ops as hunks with diffContext lines around them
+func opsHunks(ops []diffOp) string {
+	// oldAt and newAt count the old and new lines before each op
+	oldAt := make([]int, len(ops)+1)
+	newAt := make([]int, len(ops)+1)
+	for k, op := range ops {
+		oldAt[k+1], newAt[k+1] = oldAt[k], newAt[k]
+		if op.kind != '+' {
+			oldAt[k+1]++
+		}
+		if op.kind != '-' {
+			newAt[k+1]++
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == ' ' {
+			continue
+		}
+
+		// Extend the hunk while the next change is within reach of its context
+		start := max(0, i-diffContext)
+		end := i + 1
+		for j := end; j < len(ops) && j < end+2*diffContext; j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			}
+		}
+		stop := min(len(ops), end+diffContext)
+
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(oldAt[start], oldAt[stop]), hunkRange(newAt[start], newAt[stop])))
+		for _, op := range ops[start:stop] {
+			writeDiffLine(&sb, string(op.kind), op.line)
+		}
+		i = stop - 1
+	}
+	return sb.String()
+}
+
+// hunkRange renders the lines after the first from up to to of a hunk header,
+// which names the line before an empty range like diff does
+func hunkRange(from, to int) string {
+	if from == to {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
+
+// wholeFileHunk renders every old line as removed and every new line as added
+func wholeFileHunk(oldLines, newLines []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("@@ -1,%d +1,%d @@\n", len(oldLines), len(newLines)))
+	for _, line := range oldLines {
+		writeDiffLine(&sb, "-", line)
+	}
+	for _, line := range newLines {
+		writeDiffLine(&sb, "+", line)
+	}
+	return sb.String()
+}
+
+// writeDiffLine writes line with prefix, marking a missing final newline the way diff does
+func writeDiffLine(sb *strings.Builder, prefix, line string) {
+	sb.WriteString(prefix)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
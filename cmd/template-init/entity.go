@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+//go:embed entity/*.tmpl
+var entityTemplates embed.FS
+
+// entityNameRegexp matches entity names, which are written in UpperCamelCase like User
+var entityNameRegexp = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// entityReservedVars are the identifiers the generated code already uses,
+// which the lowerCamelCase entity name would shadow
+var entityReservedVars = map[string]bool{
+	"base64": true, "context": true, "cqrs": true, "domain": true, "emptypb": true,
+	"errors": true, "eventv1": true, "fmt": true, "log": true, "pgx": true, "sqlc": true,
+	"timestamppb": true, "usecase": true, "uuid": true, "err": true, "id": true, "event": true,
+}
+
+// entityFile is a file generated for a new entity from one of entityTemplates
+type entityFile struct {
+	template string
+	path     string
+}
+
+// entityValues are the values entity templates are rendered with
+type entityValues struct {
+	Module string
+	// Name and Plural are UpperCamelCase, e.g. OrderItem and OrderItems
+	Name   string
+	Plural string
+	// Var and VarPlural are lowerCamelCase, e.g. orderItem and orderItems
+	Var       string
+	VarPlural string
+	// Receiver is the method receiver of generated types
+	Receiver string
+	// Snake, SnakePlural, Kebab and KebabPlural are order_item, order_items,
+	// order-item and order-items
+	Snake       string
+	SnakePlural string
+	Kebab       string
+	KebabPlural string
+	// Human, HumanPlural and Title are used in comments and messages, e.g.
+	// "order item", "order items" and "Order item"
+	Human       string
+	HumanPlural string
+	Title       string
+	// Article is the indefinite article of Human, a or an
+	Article string
+	// Table is the database table, Topic the prefix of the event topics
+	Table string
+	Topic string
+}
+
+// runAddEntity implements the add-entity subcommand, generating the domain
+// struct, queries, migration, usecase, gRPC service, proto definitions and
+// consumer of a new entity the way the existing User and Product are laid out
+func runAddEntity(args []string) error {
+	flags := flag.NewFlagSet("add-entity", flag.ExitOnError)
+	plural := flags.String("plural", "", "plural of the entity name when adding s is wrong, e.g. People")
+	dryRun := flags.Bool("dry-run", false, "list the files that would be generated without writing them")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: template-init add-entity [flags] <Name>")
+		flags.PrintDefaults()
+	}
+
+	// Flags may come before or after the name
+	var names []string
+	for {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			break
+		}
+		names = append(names, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(names) != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one entity name")
+	}
+
+	name := names[0]
+	if !entityNameRegexp.MatchString(name) {
+		return fmt.Errorf("entity name %q must be UpperCamelCase, e.g. OrderItem", name)
+	}
+	if *plural == "" {
+		*plural = pluralize(name)
+	}
+	if !entityNameRegexp.MatchString(*plural) {
+		return fmt.Errorf("plural %q must be UpperCamelCase, e.g. OrderItems", *plural)
+	}
+
+	for _, v := range []string{lowerFirst(name), lowerFirst(*plural)} {
+		if token.IsKeyword(v) || entityReservedVars[v] {
+			return fmt.Errorf("entity name %s clashes with the identifier %s in the generated code", name, v)
+		}
+	}
+
+	module, err := detectCurrentModule()
+	if err != nil {
+		return fmt.Errorf("detecting current module: %w", err)
+	}
+
+	values := newEntityValues(module, name, *plural)
+	files := []entityFile{
+		{"domain.go.tmpl", "internal/domain/" + values.Snake + ".go"},
+		{"queries.sql.tmpl", "db/queries/" + values.SnakePlural + ".sql"},
+		{"migration.sql.tmpl", "db/migrations/" + time.Now().UTC().Format("20060102150405") + "_add_" + values.SnakePlural + "_table.sql"},
+		{"usecase_interface.go.tmpl", "internal/usecase/" + values.Snake + "_interface.go"},
+		{"usecase.go.tmpl", "internal/usecase/" + values.Snake + ".go"},
+		{"handler.go.tmpl", "internal/handler/grpc/" + values.Snake + ".go"},
+		{"consumer.go.tmpl", "internal/handler/consumer/" + values.Snake + ".go"},
+		{"api.proto.tmpl", "proto/api/v1/" + values.Snake + ".proto"},
+		{"events.proto.tmpl", "proto/event/v1/" + values.Snake + "_events.proto"},
+	}
+
+	// Render everything before writing anything so a failure leaves no partial entity
+	rendered := make([][]byte, len(files))
+	for i, file := range files {
+		if _, err := os.Stat(file.path); err == nil {
+			return fmt.Errorf("%s already exists", file.path)
+		}
+		if rendered[i], err = renderEntityFile(file, values); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%s🧱 Adding entity %s%s\n", colorBlue, name, colorReset)
+	for i, file := range files {
+		if *dryRun {
+			fmt.Printf("    - would create %s\n", file.path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file.path), 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", file.path, err)
+		}
+		if err := os.WriteFile(file.path, rendered[i], 0644); err != nil {
+			return fmt.Errorf("creating %s: %w", file.path, err)
+		}
+		fmt.Printf("    - %s\n", file.path)
+	}
+
+	showEntityNextSteps(values)
+	return nil
+}
+
+func renderEntityFile(file entityFile, values entityValues) ([]byte, error) {
+	tmpl, err := template.New(file.template).Option("missingkey=error").ParseFS(entityTemplates, "entity/"+file.template)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file.template, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", file.template, err)
+	}
+	if !strings.HasSuffix(file.path, ".go") {
+		return buf.Bytes(), nil
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", file.path, err)
+	}
+	return formatted, nil
+}
+
+func newEntityValues(module, name, plural string) entityValues {
+	words := splitWords(name)
+	pluralWords := splitWords(plural)
+
+	human := strings.Join(words, " ")
+	// i would be shadowed by the loop index in List
+	receiver := words[0][:1]
+	if receiver == "i" {
+		receiver = "uc"
+	}
+	article := "a"
+	if strings.ContainsRune("aeiou", rune(human[0])) {
+		article = "an"
+	}
+
+	return entityValues{
+		Module:      module,
+		Name:        name,
+		Plural:      plural,
+		Var:         lowerFirst(name),
+		VarPlural:   lowerFirst(plural),
+		Receiver:    receiver,
+		Snake:       strings.Join(words, "_"),
+		SnakePlural: strings.Join(pluralWords, "_"),
+		Kebab:       strings.Join(words, "-"),
+		KebabPlural: strings.Join(pluralWords, "-"),
+		Human:       human,
+		HumanPlural: strings.Join(pluralWords, " "),
+		Title:       strings.ToUpper(human[:1]) + human[1:],
+		Article:     article,
+		Table:       strings.Join(pluralWords, "_"),
+		Topic:       strings.Join(words, "."),
+	}
+}
+
+// splitWords splits an UpperCamelCase name into lowercase words, keeping
+// acronyms together, so APIKey becomes api and key
+func splitWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		prevUpper := unicode.IsUpper(runes[i-1])
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if !prevUpper || nextLower {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+	return append(words, strings.ToLower(string(runes[start:])))
+}
+
+// lowerFirst lowercases the first word of an UpperCamelCase name
+func lowerFirst(name string) string {
+	first := splitWords(name)[0]
+	return first + name[len(first):]
+}
+
+// pluralize returns the English plural of name for the common cases; -plural
+// covers the rest
+func pluralize(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
+
+func showEntityNextSteps(values entityValues) {
+	fmt.Printf("\n%s🔗 Next steps:%s\n", colorBlue, colorReset)
+	fmt.Println("  1. Hash the new migration: docker compose run --rm migrate migrate hash --env local")
+	fmt.Println("  2. Generate code from the protos and queries: make generate")
+	fmt.Printf("  3. Create New%sUsecase and New%sService in internal/app/endpoint.go\n", values.Name, values.Name)
+	fmt.Printf("  4. Register %sService in internal/server/grpc.go and its gateway handler in internal/server/http/http.go\n", values.Name)
+	fmt.Printf("  5. Add the New%sConsumer handlers in internal/app/consumer.go\n", values.Name)
+}
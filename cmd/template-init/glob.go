@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// glob matches slash-separated relative paths against a doublestar pattern.
+// Within a segment, * and ? match any run of characters and any single
+// character, [a-z] and [^a-z] match character classes and \ escapes the next
+// character. A ** segment matches any number of directories, including none,
+// so **/*.go matches main.go and cmd/app/main.go, and a trailing ** matches
+// everything below a directory. {a,b} matches either alternative and may nest.
+type glob struct {
+	// alternatives are the brace expansions of the pattern, split into segments
+	alternatives [][]string
+}
+
+// compileGlob parses pattern, reporting unbalanced braces and malformed classes
+func compileGlob(pattern string) (*glob, error) {
+	expanded, err := expandBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &glob{}
+	for _, alternative := range expanded {
+		alternative = strings.TrimPrefix(alternative, "./")
+		if strings.HasSuffix(alternative, "/") {
+			alternative += "**"
+		}
+
+		segments := strings.Split(alternative, "/")
+		for _, segment := range segments {
+			if segment == "**" {
+				continue
+			}
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("malformed segment %q", segment)
+			}
+		}
+		g.alternatives = append(g.alternatives, segments)
+	}
+	return g, nil
+}
+
+// Match reports whether name, a slash-separated relative path, matches the glob
+func (g *glob) Match(name string) bool {
+	segments := strings.Split(name, "/")
+	for _, alternative := range g.alternatives {
+		if matchSegments(alternative, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated ** and try every number of skipped directories
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		// A ** within a segment, e.g. a**b, matches like a single *
+		matched, _ := path.Match(strings.ReplaceAll(pattern[0], "**", "*"), name[0])
+		if !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+var errUnbalancedBraces = errors.New("unbalanced braces")
+
+// expandBraces returns every pattern pattern's {a,b} alternatives spell out,
+// e.g. *.{yaml,y{a,}ml} gives *.yaml, *.yaml and *.yml. Escaped braces and
+// braces inside character classes are kept as they are.
+func expandBraces(pattern string) ([]string, error) {
+	open, close, commas := -1, -1, []int(nil)
+	depth, inClass := 0, false
+	for i := 0; i < len(pattern) && close < 0; i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+		case c == '{':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case c == '}':
+			depth--
+			if depth < 0 {
+				return nil, errUnbalancedBraces
+			}
+			if depth == 0 {
+				close = i
+			}
+		case c == ',' && depth == 1:
+			commas = append(commas, i)
+		}
+	}
+	if depth != 0 {
+		return nil, errUnbalancedBraces
+	}
+	if open < 0 {
+		return []string{pattern}, nil
+	}
+
+	prefix, suffix := pattern[:open], pattern[close+1:]
+	bounds := append(append([]int{open}, commas...), close)
+
+	var expanded []string
+	for i := 0; i+1 < len(bounds); i++ {
+		rest, err := expandBraces(prefix + pattern[bounds[i]+1:bounds[i+1]] + suffix)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, rest...)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// goPackageRegexp matches the go_package option of a proto file, capturing the
+// import path and the optional ;package name after it
+var goPackageRegexp = regexp.MustCompile(`(?m)^(\s*option\s+go_package\s*=\s*")([^";]*)(?:;([^"]*))?("\s*;)`)
+
+// bufGenPathRegexp matches the values of a buf generation config that hold
+// Go import paths or output directories derived from them: out, the
+// go_package_prefix override value and the module= option of the Go plugins
+var bufGenPathRegexp = regexp.MustCompile(`(?m)^(\s*(?:-\s*)?(?:out|value|go_package_prefix)\s*:\s*["']?|\s*-\s*["']?module=)([^"'\s#]+)`)
+
+// importPathRewriter moves the Go import paths generated code lands under from
+// the old module to the new one, so it matches the imports rewritten in Go
+// sources after make generate. Unlike plain module replacements it only
+// touches whole path elements, so a module like github.com/org/app-extra is
+// left alone when github.com/org/app is renamed.
+type importPathRewriter struct {
+	module *regexp.Regexp
+	// newModule replaces the old module; newPackage replaces ;package names
+	// derived from the old project name
+	newModule  string
+	oldPackage string
+	newPackage string
+}
+
+func newImportPathRewriter(config Config) *importPathRewriter {
+	return &importPathRewriter{
+		module:     regexp.MustCompile(`(^|/)` + regexp.QuoteMeta(config.OldModule) + `(/|$)`),
+		newModule:  config.NewModule,
+		oldPackage: packageName(extractProjectName(config.OldModule)),
+		newPackage: packageName(config.ProjectName),
+	}
+}
+
+// appliesTo reports whether filename holds import paths the rewriter moves
+func (r *importPathRewriter) appliesTo(filename string) bool {
+	base := path.Base(filename)
+	return strings.HasSuffix(base, ".proto") ||
+		strings.HasPrefix(base, "buf.gen") && (strings.HasSuffix(base, ".yaml") || strings.HasSuffix(base, ".yml"))
+}
+
+// rewrite returns content with the import paths of filename moved
+func (r *importPathRewriter) rewrite(filename, content string) string {
+	if strings.HasSuffix(filename, ".proto") {
+		return goPackageRegexp.ReplaceAllStringFunc(content, func(option string) string {
+			m := goPackageRegexp.FindStringSubmatch(option)
+			importPath, pkg := r.movePath(m[2]), m[3]
+			if pkg != "" && pkg == r.oldPackage && r.newPackage != "" {
+				pkg = r.newPackage
+			}
+			if pkg != "" {
+				importPath += ";" + pkg
+			}
+			return m[1] + importPath + m[4]
+		})
+	}
+
+	return bufGenPathRegexp.ReplaceAllStringFunc(content, func(value string) string {
+		m := bufGenPathRegexp.FindStringSubmatch(value)
+		return m[1] + r.movePath(m[2])
+	})
+}
+
+// movePath replaces the old module where it appears as whole path elements of p
+func (r *importPathRewriter) movePath(p string) string {
+	return r.module.ReplaceAllString(p, "${1}"+strings.ReplaceAll(r.newModule, "$", "$$")+"${2}")
+}
+
+// packageName turns a project name into the Go package name it would get,
+// e.g. go-init becomes goinit
+func packageName(projectName string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToLower(projectName) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' {
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
@@ -2,11 +2,11 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
-	"io/fs"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -27,6 +27,39 @@ type Config struct {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "add-entity" {
+		if err := runAddEntity(os.Args[2:]); err != nil {
+			fmt.Printf("%sError: %v%s\n", colorRed, err, colorReset)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "sync" {
+		if err := runSync(os.Args[2:]); err != nil {
+			fmt.Printf("%sError: %v%s\n", colorRed, err, colorReset)
+			os.Exit(1)
+		}
+		return
+	}
+
+	dryRun := flag.Bool("dry-run", false, "print a unified diff of every change instead of writing files")
+	undoLast := flag.Bool("undo", false, "restore the files and git remote changed by the last initialization")
+	manifestPath := flag.String("manifest", defaultManifestPath, "manifest declaring the files to rewrite and the post-init commands")
+	skipPostInit := flag.Bool("skip-post-init", false, "only rewrite files, leaving the manifest post-init commands to run by hand")
+	web := flag.Bool("web", false, "review the pending replacements in a local web page, accepting or rejecting each file")
+	without := flag.String("without", "", "comma-separated optional components to remove, e.g. gateway,consumer; skips the component prompts")
+	flag.Parse()
+
+	if *undoLast {
+		if err := undo(); err != nil {
+			fmt.Printf("%sError: %v%s\n", colorRed, err, colorReset)
+			os.Exit(1)
+		}
+		fmt.Printf("\n%s✅ Template initialization undone%s\n", colorGreen, colorReset)
+		return
+	}
+
 	// Auto-detect current module from go.mod
 	oldModule, err := detectCurrentModule()
 	if err != nil {
@@ -70,28 +103,79 @@ func main() {
 		OldModule:   oldModule,
 		NewModule:   newModule,
 		ProjectName: projectName,
-		DryRun:      false,
+		DryRun:      *dryRun,
+	}
+
+	manifest, err := loadManifest(*manifestPath, config)
+	if err != nil {
+		fmt.Printf("%sError: %v%s\n", colorRed, err, colorReset)
+		os.Exit(1)
+	}
+
+	// Components are prompted for unless -without lists them, even as empty
+	withoutSet := false
+	flag.Visit(func(f *flag.Flag) { withoutSet = withoutSet || f.Name == "without" })
+
+	removed := map[string]bool{}
+	if withoutSet {
+		if removed, err = parseComponentList(*without, manifest.Components); err != nil {
+			fmt.Printf("%sError: %v%s\n", colorRed, err, colorReset)
+			os.Exit(1)
+		}
+	} else {
+		removed = promptComponents(reader, manifest.Components)
 	}
 
 	fmt.Printf("\n%s📋 Configuration:%s\n", colorBlue, colorReset)
 	fmt.Printf("  Old module: %s%s%s\n", colorYellow, config.OldModule, colorReset)
 	fmt.Printf("  New module: %s%s%s\n", colorGreen, config.NewModule, colorReset)
 	fmt.Printf("  Project name: %s%s%s\n", colorGreen, config.ProjectName, colorReset)
+	if len(removed) > 0 {
+		fmt.Printf("  Removed components: %s%s%s\n", colorYellow, strings.Join(sortedNames(removed), ", "), colorReset)
+	}
+	if config.DryRun {
+		fmt.Printf("  Dry run: %sno files will be changed%s\n", colorYellow, colorReset)
+	}
 	fmt.Println()
 
-	fmt.Print("Continue with template initialization? (y/N): ")
-	response, _ := reader.ReadString('\n')
-	response = strings.TrimSpace(strings.ToLower(response))
-	if response != "y" && response != "yes" {
-		fmt.Println("❌ Template initialization cancelled")
-		return
+	// The page replaces the prompt: applying the selection there confirms it
+	var accepted map[string]bool
+	if *web {
+		accepted, err = reviewInBrowser(config, manifest, removed)
+		if errors.Is(err, errReviewCancelled) {
+			fmt.Println("❌ Template initialization cancelled")
+			return
+		}
+		if err != nil {
+			fmt.Printf("%sError: %v%s\n", colorRed, err, colorReset)
+			os.Exit(1)
+		}
+	} else {
+		fmt.Print("Continue with template initialization? (y/N): ")
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			fmt.Println("❌ Template initialization cancelled")
+			return
+		}
 	}
 
-	if err := processTemplate(config); err != nil {
+	if err := processTemplate(config, manifest, removed, accepted); err != nil {
 		fmt.Printf("%sError: %v%s\n", colorRed, err, colorReset)
+		if !config.DryRun {
+			fmt.Println("Restore the previous state with: go run ./cmd/template-init -undo")
+		}
 		os.Exit(1)
 	}
 
+	if config.DryRun {
+		for _, command := range manifest.PostInit {
+			fmt.Printf("  → would run: %s\n", command)
+		}
+		fmt.Printf("\n%s✅ Dry run completed, no files or git remotes were changed%s\n", colorGreen, colorReset)
+		return
+	}
+
 	// Automatically set git remote if possible
 	if err := setGitRemote(config.NewModule); err != nil {
 		fmt.Printf("%sWarning: Could not set git remote automatically: %v%s\n", colorYellow, err, colorReset)
@@ -115,7 +199,27 @@ func main() {
 		fmt.Printf("%s✅ Git remote updated to: %s%s\n", colorGreen, displayURL, colorReset)
 	}
 
+	if *skipPostInit {
+		if len(manifest.PostInit) > 0 {
+			fmt.Printf("\n%s⚙️  Skipped post-init commands, run them before building:%s\n", colorYellow, colorReset)
+			for _, command := range manifest.PostInit {
+				fmt.Printf("    %s\n", command)
+			}
+		}
+	} else if len(manifest.PostInit) > 0 {
+		fmt.Printf("\n%s⚙️  Running post-init commands...%s\n", colorBlue, colorReset)
+		if err := runPostInit(manifest.PostInit); err != nil {
+			printPostInitFailure(err)
+			os.Exit(1)
+		}
+	}
+
+	if len(removed) > 0 && !prunesModule(manifest.PostInit) {
+		fmt.Printf("%sRun go mod tidy to drop the requirements of the removed components%s\n", colorYellow, colorReset)
+	}
+
 	fmt.Printf("\n%s✅ Template initialization completed!%s\n", colorGreen, colorReset)
+	fmt.Printf("Original files are kept in %s; revert with go run ./cmd/template-init -undo\n", backupDir)
 	showNextSteps(config.NewModule)
 }
 
@@ -142,223 +246,208 @@ func detectCurrentModule() (string, error) {
 	return "", fmt.Errorf("module declaration not found in go.mod")
 }
 
-func processTemplate(config Config) error {
+// processTemplate rewrites the files matched by the manifest patterns and
+// removes the components in removed. Only the files in accepted are
+// rewritten unless it is nil.
+func processTemplate(config Config, manifest *Manifest, removed, accepted map[string]bool) error {
 	fmt.Printf("%s📝 Processing files...%s\n", colorBlue, colorReset)
 
-	// File patterns and their replacement rules
-	patterns := []FilePattern{
-		{
-			Pattern:     "go.mod",
-			Description: "Go module file",
-			Replacements: []Replacement{
-				{Old: config.OldModule, New: config.NewModule},
-			},
-		},
-		{
-			Pattern:     "**/*.go",
-			Description: "Go source files",
-			Replacements: []Replacement{
-				{Old: config.OldModule, New: config.NewModule},
-			},
-		},
-		{
-			Pattern:     "**/*.proto",
-			Description: "Protocol buffer files",
-			Replacements: []Replacement{
-				{Old: config.OldModule, New: config.NewModule},
-			},
-		},
-		{
-			Pattern:     "buf.yaml",
-			Description: "Buf configuration",
-			Replacements: []Replacement{
-				{Old: config.OldModule, New: config.NewModule},
-			},
-		},
-		{
-			Pattern:     "files/**/*.{yaml,yml,json}",
-			Description: "Configuration files",
-			Replacements: []Replacement{
-				{Old: extractProjectName(config.OldModule), New: config.ProjectName},
-				{Old: strings.ReplaceAll(extractProjectName(config.OldModule), "-", "_"), New: strings.ReplaceAll(config.ProjectName, "-", "_")},
-			},
-		},
-		{
-			Pattern:     "docker-compose.yml",
-			Description: "Docker Compose configuration",
-			Replacements: []Replacement{
-				{Old: strings.ReplaceAll(extractProjectName(config.OldModule), "-", "_"), New: strings.ReplaceAll(config.ProjectName, "-", "_")},
-			},
-		},
-		{
-			Pattern:     "atlas.hcl",
-			Description: "Atlas migration configuration",
-			Replacements: []Replacement{
-				{Old: strings.ReplaceAll(extractProjectName(config.OldModule), "-", "_"), New: strings.ReplaceAll(config.ProjectName, "-", "_")},
-			},
-		},
-	}
-
-	for _, pattern := range patterns {
-		if err := processPattern(pattern, config.DryRun); err != nil {
-			return fmt.Errorf("processing %s: %w", pattern.Description, err)
-		}
-	}
-
-	return nil
-}
-
-func extractProjectName(module string) string {
-	parts := strings.Split(module, "/")
-	return parts[len(parts)-1]
-}
-
-type FilePattern struct {
-	Pattern      string
-	Description  string
-	Replacements []Replacement
-}
-
-type Replacement struct {
-	Old string
-	New string
-}
-
-func processPattern(pattern FilePattern, dryRun bool) error {
-	files, err := findFiles(pattern.Pattern)
+	files, skipped, err := listFiles(manifest.MaxFileSize)
 	if err != nil {
-		return err
+		return fmt.Errorf("listing files: %w", err)
 	}
 
-	if len(files) == 0 {
-		return nil
+	// Keep the originals so a failed or unwanted initialization can be undone
+	var b *backup
+	if !config.DryRun {
+		if b, err = newBackup(config); err != nil {
+			return err
+		}
 	}
 
-	fmt.Printf("  → %s\n", pattern.Description)
-
-	for _, file := range files {
-		changed, err := processFile(file, pattern.Replacements, dryRun)
-		if err != nil {
-			return fmt.Errorf("processing %s: %w", file, err)
+	rewriter := newImportPathRewriter(config)
+	reportSkipped(manifest, skipped, rewriter)
+	for _, plan := range planPatterns(manifest, files, rewriter) {
+		matched := plan.files
+		if accepted != nil {
+			matched = nil
+			for _, file := range plan.files {
+				if accepted[file] {
+					matched = append(matched, file)
+				}
+			}
 		}
-		if changed {
-			fmt.Printf("    - %s\n", file)
+		if err := processPattern(plan.pattern, matched, rewriter, b); err != nil {
+			return fmt.Errorf("processing %s: %w", plan.pattern.Description, err)
 		}
 	}
 
-	return nil
-}
-
-func findFiles(pattern string) ([]string, error) {
-	var files []string
-
-	// Handle special patterns
-	if pattern == "**/*.go" {
-		return findByExtension(".go"), nil
-	}
-	if pattern == "**/*.proto" {
-		return findByExtension(".proto"), nil
-	}
-	if pattern == "files/**/*.{yaml,yml,json}" {
-		return findConfigFiles(), nil
+	if len(manifest.Components) > 0 {
+		if err := processComponents(manifest, removed, b); err != nil {
+			return err
+		}
 	}
 
-	// Single file
-	if _, err := os.Stat(pattern); err == nil {
-		files = append(files, pattern)
+	// go mod tidy drops the requirements only removed components used
+	if len(removed) > 0 && b != nil {
+		if err := backupModule(b); err != nil {
+			return err
+		}
 	}
 
-	return files, nil
+	if b != nil {
+		return recordTemplateVersion(config, removed, b)
+	}
+	return nil
 }
 
-func findByExtension(ext string) []string {
-	var files []string
+// patternFiles is a manifest pattern and the files it rewrites
+type patternFiles struct {
+	pattern FilePattern
+	files   []string
+}
 
-	filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil
+// planPatterns matches files against the manifest patterns in order. Files
+// no pattern covers follow when the import path rewriter applies to them:
+// generated code must land under the new module even where no pattern
+// rewrites the files deciding its import path, like buf.gen.yaml.
+func planPatterns(manifest *Manifest, files []string, rewriter *importPathRewriter) []patternFiles {
+	var plan []patternFiles
+	covered := make(map[string]bool)
+	for _, pattern := range manifest.Patterns {
+		matched := matchFiles(pattern, files)
+		for _, file := range matched {
+			covered[file] = true
 		}
+		plan = append(plan, patternFiles{pattern: pattern, files: matched})
+	}
 
-		// Skip directories and hidden files
-		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
-			return nil
+	var generation []string
+	for _, file := range files {
+		if !covered[file] && rewriter.appliesTo(file) {
+			generation = append(generation, file)
 		}
+	}
+	return append(plan, patternFiles{pattern: FilePattern{Description: "Generated code import paths"}, files: generation})
+}
 
-		// Skip vendor, node_modules, and bin directories
-		if strings.Contains(path, "vendor/") ||
-			strings.Contains(path, "node_modules/") ||
-			strings.Contains(path, "bin/") {
-			return nil
+// reportSkipped lists the skipped files the manifest would otherwise rewrite
+func reportSkipped(manifest *Manifest, skipped []skippedFile, rewriter *importPathRewriter) {
+	paths := make([]string, len(skipped))
+	for i, file := range skipped {
+		paths[i] = file.path
+	}
+	matched := make(map[string]bool)
+	for _, plan := range planPatterns(manifest, paths, rewriter) {
+		for _, path := range plan.files {
+			matched[path] = true
 		}
+	}
+	if len(matched) == 0 {
+		return
+	}
 
-		if strings.HasSuffix(path, ext) {
-			files = append(files, path)
+	fmt.Printf("  → %sLeft unchanged, unsafe to rewrite:%s\n", colorYellow, colorReset)
+	for _, file := range skipped {
+		if matched[file.path] {
+			fmt.Printf("    - %s (%s)\n", file.path, file.reason)
 		}
+	}
+}
 
-		return nil
-	})
+func extractProjectName(module string) string {
+	parts := strings.Split(module, "/")
+	return parts[len(parts)-1]
+}
+
+// FilePattern rewrites the files matching a glob in the manifest
+type FilePattern struct {
+	Pattern      string        `yaml:"pattern"`
+	Description  string        `yaml:"description"`
+	Replacements []Replacement `yaml:"replacements"`
 
-	return files
+	glob *glob
 }
 
-func findConfigFiles() []string {
-	var files []string
+type Replacement struct {
+	Old string `yaml:"old"`
+	New string `yaml:"new"`
+}
 
-	if _, err := os.Stat("files"); err != nil {
-		return files
+// processPattern rewrites the files matching pattern, backing them up in b
+// first. A nil b is a dry run.
+func processPattern(pattern FilePattern, files []string, rewriter *importPathRewriter, b *backup) error {
+	if len(files) == 0 {
+		return nil
 	}
 
-	filepath.WalkDir("files", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil
-		}
+	fmt.Printf("  → %s\n", pattern.Description)
 
-		if d.IsDir() {
-			return nil
+	for _, file := range files {
+		changed, err := processFile(file, pattern.Replacements, rewriter, b)
+		if err != nil {
+			return fmt.Errorf("processing %s: %w", file, err)
 		}
-
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".yaml" || ext == ".yml" || ext == ".json" {
-			files = append(files, path)
+		if changed {
+			fmt.Printf("    - %s\n", file)
 		}
+	}
 
-		return nil
-	})
-
-	return files
+	return nil
 }
 
-func processFile(filename string, replacements []Replacement, dryRun bool) (bool, error) {
+// processFile applies replacements to filename after moving the import paths
+// rewriter handles
+func processFile(filename string, replacements []Replacement, rewriter *importPathRewriter, b *backup) (bool, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return false, err
+	}
 	content, err := os.ReadFile(filename)
 	if err != nil {
 		return false, err
 	}
 
 	originalContent := string(content)
-	newContent := originalContent
-
-	// Apply all replacements
-	for _, repl := range replacements {
-		if strings.Contains(newContent, repl.Old) {
-			newContent = strings.ReplaceAll(newContent, repl.Old, repl.New)
-		}
-	}
+	newContent := rewriteContent(filename, originalContent, replacements, rewriter)
 
 	// Check if file was changed
 	if newContent == originalContent {
 		return false, nil
 	}
 
-	// Write file if not dry run
-	if !dryRun {
-		if err := os.WriteFile(filename, []byte(newContent), 0644); err != nil {
-			return false, err
-		}
+	// Show the change instead of writing it on a dry run
+	if b == nil {
+		fmt.Print(unifiedDiff(filename, originalContent, newContent))
+		return true, nil
+	}
+
+	if err := b.save(filename, content, info.Mode()); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(filename, []byte(newContent), 0644); err != nil {
+		return false, err
 	}
 
 	return true, nil
 }
 
+// rewriteContent returns content of filename with the import paths rewriter
+// handles moved and replacements applied
+func rewriteContent(filename, content string, replacements []Replacement, rewriter *importPathRewriter) string {
+	if rewriter.appliesTo(filename) {
+		content = rewriter.rewrite(filename, content)
+	}
+
+	// Apply all replacements
+	for _, repl := range replacements {
+		if strings.Contains(content, repl.Old) {
+			content = strings.ReplaceAll(content, repl.Old, repl.New)
+		}
+	}
+	return content
+}
+
 func setGitRemote(newModule string) error {
 	// Check if git is available and this is a git repository
 	if _, err := os.Stat(".git"); os.IsNotExist(err) {
@@ -414,10 +503,6 @@ func executeCommand(command string) error {
 }
 
 func showNextSteps(newModule string) {
-	fmt.Printf("\n%s🔗 Makefile will automatically run:%s\n", colorBlue, colorReset)
-	fmt.Println("  1. go mod tidy")
-	fmt.Println("  2. make generate")
-	fmt.Println("  3. make test")
 	fmt.Printf("\n%s🔗 Manual steps:%s\n", colorBlue, colorReset)
 	var remoteURL string
 	if strings.HasPrefix(newModule, "github.com/") {
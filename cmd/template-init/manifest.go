@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultManifestPath is read when -manifest is not given
+const defaultManifestPath = ".template.yaml"
+
+// defaultMaxFileSize is the size above which files are not rewritten unless
+// the manifest sets max_file_size
+const defaultMaxFileSize = 1 << 20
+
+// skippedDirs are never searched for files to rewrite, in addition to hidden directories
+var skippedDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+	"bin":          true,
+}
+
+// Manifest declares what template initialization rewrites and runs afterwards
+type Manifest struct {
+	Patterns []FilePattern `yaml:"patterns"`
+	// Components are the optional parts of the template that can be removed
+	Components []Component `yaml:"components"`
+	// PostInit lists shell commands run from the repo root after the files are rewritten
+	PostInit []string `yaml:"post_init"`
+	// MaxFileSize is the size in bytes above which files are left alone,
+	// defaultMaxFileSize when zero
+	MaxFileSize int64 `yaml:"max_file_size"`
+}
+
+// manifestValues are the values replacement templates are rendered with
+type manifestValues struct {
+	OldModule      string
+	NewModule      string
+	OldProjectName string
+	ProjectName    string
+}
+
+// componentNameRegexp matches the component names usable in section markers
+var componentNameRegexp = regexp.MustCompile(`^[\w-]+$`)
+
+var manifestFuncs = template.FuncMap{
+	"snake": func(s string) string { return strings.ReplaceAll(s, "-", "_") },
+}
+
+// loadManifest reads the manifest at path and renders its replacements for config
+func loadManifest(path string, config Config) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	return parseManifest(path, data, config)
+}
+
+// parseManifest parses the manifest content data read from path
+func parseManifest(path string, data []byte, config Config) (*Manifest, error) {
+	var manifest Manifest
+	err := yaml.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", path, err)
+	}
+
+	values := manifestValues{
+		OldModule:      config.OldModule,
+		NewModule:      config.NewModule,
+		OldProjectName: extractProjectName(config.OldModule),
+		ProjectName:    config.ProjectName,
+	}
+
+	for i := range manifest.Patterns {
+		pattern := &manifest.Patterns[i]
+		if pattern.Pattern == "" {
+			return nil, fmt.Errorf("manifest pattern %d has no pattern", i)
+		}
+		if pattern.Description == "" {
+			pattern.Description = pattern.Pattern
+		}
+		if pattern.glob, err = compileGlob(pattern.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern.Pattern, err)
+		}
+
+		for j := range pattern.Replacements {
+			repl := &pattern.Replacements[j]
+			if repl.Old, err = renderValue(repl.Old, values); err != nil {
+				return nil, fmt.Errorf("pattern %s: %w", pattern.Pattern, err)
+			}
+			if repl.New, err = renderValue(repl.New, values); err != nil {
+				return nil, fmt.Errorf("pattern %s: %w", pattern.Pattern, err)
+			}
+			// An empty old value would match between every character
+			if repl.Old == "" {
+				return nil, fmt.Errorf("pattern %s: replacement %d has an empty old value", pattern.Pattern, j)
+			}
+		}
+	}
+
+	if manifest.MaxFileSize < 0 {
+		return nil, fmt.Errorf("manifest max_file_size %d is negative", manifest.MaxFileSize)
+	}
+	if manifest.MaxFileSize == 0 {
+		manifest.MaxFileSize = defaultMaxFileSize
+	}
+
+	seen := make(map[string]bool, len(manifest.Components))
+	for i, component := range manifest.Components {
+		if !componentNameRegexp.MatchString(component.Name) {
+			return nil, fmt.Errorf("manifest component %d has an invalid name %q", i, component.Name)
+		}
+		if seen[component.Name] {
+			return nil, fmt.Errorf("manifest component %s is declared twice", component.Name)
+		}
+		seen[component.Name] = true
+	}
+
+	return &manifest, nil
+}
+
+func renderValue(text string, values manifestValues) (string, error) {
+	tmpl, err := template.New("value").Funcs(manifestFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, values); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// skippedPath reports whether path, slash-separated, is hidden or lies in a
+// hidden or skipped directory
+func skippedPath(path string) bool {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ".") || i < len(segments)-1 && skippedDirs[segment] {
+			return true
+		}
+	}
+	return false
+}
+
+// skippedFile is a file listFiles leaves out because rewriting it is unsafe
+type skippedFile struct {
+	path   string
+	reason string
+}
+
+// listFiles returns every file below the current directory that is safe to
+// rewrite as a slash-separated relative path, leaving out hidden files,
+// skipped directories and files git ignores. Binary files, files larger than
+// maxSize and anything but regular files are returned as skipped instead.
+func listFiles(maxSize int64) ([]string, []skippedFile, error) {
+	paths, err := gitListFiles()
+	if err != nil {
+		// Without git, e.g. in an unpacked archive, nothing is known to be ignored
+		if paths, err = walkFiles(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var files []string
+	var skipped []skippedFile
+	for _, path := range paths {
+		if skippedPath(path) {
+			continue
+		}
+		reason, err := unsafeToRewrite(filepath.FromSlash(path), maxSize)
+		if os.IsNotExist(err) {
+			// Tracked but deleted, like the paths of removed components
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if reason != "" {
+			skipped = append(skipped, skippedFile{path: path, reason: reason})
+			continue
+		}
+		files = append(files, path)
+	}
+	return files, skipped, nil
+}
+
+// gitListFiles lists the tracked files and the untracked ones git does not ignore
+func gitListFiles() ([]string, error) {
+	output, err := gitOutputBytes("ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, path := range strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00") {
+		// Files with unresolved conflicts are listed once per stage
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// walkFiles lists every file below the current directory, not descending
+// into hidden or skipped directories
+func walkFiles() ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path == "." {
+			return nil
+		}
+
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		paths = append(paths, filepath.ToSlash(path))
+		return nil
+	})
+	return paths, err
+}
+
+// binarySniffLen is how much of a file is searched for a NUL byte, as git does
+// to tell binary files from text
+const binarySniffLen = 8000
+
+// unsafeToRewrite returns why filename must not be rewritten, or "" when it
+// is a text file of at most maxSize bytes
+func unsafeToRewrite(filename string, maxSize int64) (string, error) {
+	info, err := os.Lstat(filename)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "not a regular file", nil
+	}
+	if info.Size() > maxSize {
+		return fmt.Sprintf("larger than %d bytes", maxSize), nil
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, binarySniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if isBinary(head[:n]) {
+		return "binary", nil
+	}
+	return "", nil
+}
+
+// isBinary reports whether content, or its start, looks like binary data
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binarySniffLen)], 0) >= 0
+}
+
+// matchFiles returns the files matching pattern
+func matchFiles(pattern FilePattern, files []string) []string {
+	var matched []string
+	for _, file := range files {
+		if pattern.glob.Match(file) {
+			matched = append(matched, file)
+		}
+	}
+	return matched
+}
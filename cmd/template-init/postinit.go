@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// outputPrefix indents the streamed output of post-init commands under their step
+const outputPrefix = "    │ "
+
+// postInitError reports the post-init command that failed and the ones skipped after it
+type postInitError struct {
+	command string
+	err     error
+	skipped []string
+}
+
+func (e *postInitError) Error() string {
+	return fmt.Sprintf("post-init command %q failed: %v", e.command, e.err)
+}
+
+func (e *postInitError) Unwrap() error {
+	return e.err
+}
+
+// runPostInit runs the post-init commands in order from the repo root,
+// streaming their output line by line as it is written. It stops at the first
+// failure, returning a *postInitError listing the commands left to run.
+func runPostInit(commands []string) error {
+	for i, command := range commands {
+		fmt.Printf("  → [%d/%d] %s\n", i+1, len(commands), command)
+
+		start := time.Now()
+		if err := runStreamed(command); err != nil {
+			return &postInitError{command: command, err: err, skipped: commands[i+1:]}
+		}
+		fmt.Printf("    %s✓ done in %s%s\n", colorGreen, time.Since(start).Round(100*time.Millisecond), colorReset)
+	}
+	return nil
+}
+
+// runStreamed runs command through sh, writing its stdout and stderr to
+// stdout with outputPrefix in front of every line
+func runStreamed(command string) error {
+	// Report missing tools clearly instead of as sh's exit status 127
+	if fields := strings.Fields(command); len(fields) > 0 && !strings.Contains(fields[0], "=") {
+		if _, err := exec.LookPath(fields[0]); err != nil {
+			return fmt.Errorf("%s is not installed or not in PATH", fields[0])
+		}
+	}
+
+	out := &prefixWriter{w: os.Stdout, prefix: outputPrefix, atLineStart: true}
+	defer out.Flush()
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("exit status %d", exitErr.ExitCode())
+		}
+		return err
+	}
+	return nil
+}
+
+// printPostInitFailure explains how to finish a failed post-init phase by hand
+func printPostInitFailure(err error) {
+	fmt.Printf("%sError: %v%s\n", colorRed, err, colorReset)
+
+	if failure, ok := err.(*postInitError); ok {
+		fmt.Println("The files were rewritten. Fix the failure, then run the remaining commands:")
+		for _, command := range append([]string{failure.command}, failure.skipped...) {
+			fmt.Printf("    %s\n", command)
+		}
+	}
+	fmt.Println("Or restore the previous state with: go run ./cmd/template-init -undo")
+}
+
+// prefixWriter writes prefix in front of every line written to w. Writes are
+// serialized so stdout and stderr of one command can share it.
+type prefixWriter struct {
+	mu          sync.Mutex
+	w           io.Writer
+	prefix      string
+	atLineStart bool
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, c := range data {
+		if p.atLineStart {
+			buf.WriteString(p.prefix)
+			p.atLineStart = false
+		}
+		buf.WriteByte(c)
+		if c == '\n' {
+			p.atLineStart = true
+		}
+	}
+	if _, err := p.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Flush ends a last line written without a trailing newline
+func (p *prefixWriter) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.atLineStart {
+		fmt.Fprintln(p.w)
+		p.atLineStart = true
+	}
+}
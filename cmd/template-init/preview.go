@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed preview.html
+var previewFS embed.FS
+
+var previewTemplate = template.Must(template.ParseFS(previewFS, "preview.html"))
+
+// errReviewCancelled is returned when initialization is cancelled from the page
+var errReviewCancelled = errors.New("review cancelled")
+
+// previewFile is a file initialization would rewrite, as listed for review
+type previewFile struct {
+	Path string
+	// Changes describe every rewrite of the file, by pattern
+	Changes []string
+	Diff    []previewLine
+}
+
+// previewLine is a unified diff line and its kind: add, remove, hunk or context
+type previewLine struct {
+	Kind string
+	Text string
+}
+
+// previewPage holds the values the review page is rendered with
+type previewPage struct {
+	Config  Config
+	Removed []string
+	Files   []previewFile
+	Token   string
+}
+
+// buildPreview rewrites every file the manifest patterns cover in memory,
+// returning those that would change
+func buildPreview(manifest *Manifest, rewriter *importPathRewriter) ([]previewFile, error) {
+	files, _, err := listFiles(manifest.MaxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("listing files: %w", err)
+	}
+
+	// Files matched by several patterns are rewritten by each in turn
+	original := make(map[string]string)
+	current := make(map[string]string)
+	changes := make(map[string][]string)
+	var order []string
+	for _, plan := range planPatterns(manifest, files, rewriter) {
+		for _, file := range plan.files {
+			content, ok := current[file]
+			if !ok {
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return nil, err
+				}
+				content = string(data)
+				original[file] = content
+				order = append(order, file)
+			}
+
+			rewritten := rewriteContent(file, content, plan.pattern.Replacements, rewriter)
+			current[file] = rewritten
+			if rewritten == content {
+				continue
+			}
+			if rewriter.appliesTo(file) && rewriter.rewrite(file, content) != content {
+				changes[file] = append(changes[file], plan.pattern.Description+": import paths")
+			}
+			for _, repl := range plan.pattern.Replacements {
+				if strings.Contains(content, repl.Old) {
+					changes[file] = append(changes[file], fmt.Sprintf("%s: %s → %s", plan.pattern.Description, repl.Old, repl.New))
+				}
+			}
+		}
+	}
+
+	var preview []previewFile
+	for _, file := range order {
+		if current[file] == original[file] {
+			continue
+		}
+		preview = append(preview, previewFile{
+			Path:    file,
+			Changes: changes[file],
+			Diff:    previewDiff(unifiedDiff(file, original[file], current[file])),
+		})
+	}
+	return preview, nil
+}
+
+// previewDiff splits a unified diff into lines classified for display,
+// leaving out the file header the page already shows
+func previewDiff(diff string) []previewLine {
+	var lines []previewLine
+	for _, line := range splitLines(diff)[2:] {
+		line = strings.TrimSuffix(line, "\n")
+		kind := "context"
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			kind = "hunk"
+		case strings.HasPrefix(line, "+"):
+			kind = "add"
+		case strings.HasPrefix(line, "-"):
+			kind = "remove"
+		}
+		lines = append(lines, previewLine{Kind: kind, Text: line})
+	}
+	return lines
+}
+
+// reviewInBrowser serves a page on localhost listing the files initialization
+// would rewrite, and waits for the files accepted there. Submissions must
+// carry the token of the page, so other sites cannot apply one.
+func reviewInBrowser(config Config, manifest *Manifest, removed map[string]bool) (map[string]bool, error) {
+	files, err := buildPreview(manifest, newImportPathRewriter(config))
+	if err != nil {
+		return nil, err
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	page := previewPage{
+		Config:  config,
+		Removed: sortedNames(removed),
+		Files:   files,
+		Token:   hex.EncodeToString(token),
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("starting the review page: %w", err)
+	}
+
+	decided := make(chan map[string]bool, 1)
+	var once sync.Once
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := previewTemplate.Execute(w, page); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("POST /{$}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("token") != page.Token {
+			http.Error(w, "invalid token, reload the page", http.StatusForbidden)
+			return
+		}
+
+		// A nil selection cancels
+		var accepted map[string]bool
+		if r.PostFormValue("action") == "apply" {
+			accepted = make(map[string]bool)
+			for _, file := range r.PostForm["file"] {
+				accepted[file] = true
+			}
+		}
+		once.Do(func() { decided <- accepted })
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "Done, continue in the terminal. This page can be closed.")
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+
+	fmt.Printf("%s🌐 Review the %d files to rewrite at http://%s/%s\n", colorBlue, len(files), listener.Addr(), colorReset)
+	fmt.Println("   Waiting for the selection to be applied or cancelled there...")
+	accepted := <-decided
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(ctx)
+
+	if accepted == nil {
+		return nil, errReviewCancelled
+	}
+	fmt.Printf("  → %d of %d files accepted\n", len(accepted), len(files))
+	return accepted, nil
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// syncChange is a file changed in the template between two revisions
+type syncChange struct {
+	path   string
+	status byte // A, M or D as in git diff --raw
+	mode   fs.FileMode
+}
+
+// syncer applies template changes to a derived project. Template files are
+// rewritten as initialization would rewrite them before they are compared
+// with or merged into the project's.
+type syncer struct {
+	manifest *Manifest
+	rewriter *importPathRewriter
+	known    map[string]bool
+	removed  map[string]bool
+	dryRun   bool
+
+	applied, conflicts []string
+}
+
+// runSync implements the sync subcommand: it fetches the template, applies
+// the changes made upstream since the recorded revision where they do not
+// conflict with the project's own and records the new revision.
+func runSync(args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	ref := flags.String("ref", "main", "template branch or tag to sync to")
+	dryRun := flags.Bool("dry-run", false, "print a unified diff of every change instead of writing files")
+	manifestPath := flags.String("manifest", defaultManifestPath, "manifest in the template declaring how files are rewritten")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: template-init sync [-ref branch] [-dry-run]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	version, err := readTemplateVersion()
+	if err != nil {
+		return err
+	}
+	if status, err := gitOutput("status", "--porcelain"); err != nil {
+		return err
+	} else if status != "" && !*dryRun {
+		return fmt.Errorf("the working tree has uncommitted changes; commit or stash them so the sync can be reviewed on its own")
+	}
+
+	fmt.Printf("%s🔄 Syncing from %s%s\n", colorBlue, version.Repository, colorReset)
+	target, err := fetchTemplate(version, *ref)
+	if err != nil {
+		return err
+	}
+	if target == version.Commit {
+		fmt.Printf("%s✅ Already up to date with %s (%s)%s\n", colorGreen, *ref, version.describe(), colorReset)
+		return nil
+	}
+
+	changes, err := templateChanges(version.Commit, target)
+	if err != nil {
+		return err
+	}
+
+	newModule, err := detectCurrentModule()
+	if err != nil {
+		return err
+	}
+	config := Config{OldModule: version.Module, NewModule: newModule, ProjectName: version.ProjectName, DryRun: *dryRun}
+
+	manifestData, err := gitOutputBytes("show", target+":"+*manifestPath)
+	if err != nil {
+		return fmt.Errorf("reading the template manifest: %w", err)
+	}
+	manifest, err := parseManifest(*manifestPath, manifestData, config)
+	if err != nil {
+		return err
+	}
+
+	s := &syncer{
+		manifest: manifest,
+		rewriter: newImportPathRewriter(config),
+		known:    make(map[string]bool),
+		removed:  make(map[string]bool),
+		dryRun:   *dryRun,
+	}
+	for _, component := range manifest.Components {
+		s.known[component.Name] = true
+	}
+	for _, name := range version.RemovedComponents {
+		s.removed[name] = true
+	}
+
+	fmt.Printf("  → %d template files changed since %s\n", len(changes), version.describe())
+	for _, change := range changes {
+		if !s.syncs(change.path) {
+			continue
+		}
+		if err := s.apply(change, version.Commit, target); err != nil {
+			return fmt.Errorf("syncing %s: %w", change.path, err)
+		}
+	}
+
+	// Conflicting changes are left to merge by hand; the next sync starts from
+	// target either way so they are not reported again
+	if len(s.conflicts) > 0 {
+		fmt.Printf("\n%s⚠️  Not applied, the project changed these files too:%s\n", colorYellow, colorReset)
+		for _, conflict := range s.conflicts {
+			fmt.Printf("    - %s\n", conflict)
+		}
+		fmt.Printf("Merge the template changes by hand, see: git diff %s %s -- <file>\n", shortCommit(version.Commit), shortCommit(target))
+	}
+
+	if *dryRun {
+		fmt.Printf("\n%s✅ Dry run completed, %d files would be updated%s\n", colorGreen, len(s.applied), colorReset)
+		return nil
+	}
+
+	now := time.Now().UTC()
+	version.Commit = target
+	version.Version = describeCommit(target)
+	version.SyncedAt = &now
+	if err := version.write(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%s✅ Synced %d files to %s; review them with git diff%s\n", colorGreen, len(s.applied), version.describe(), colorReset)
+	return nil
+}
+
+// fetchTemplate fetches ref from the template repository along with the
+// recorded revision, which projects created without the template history lack
+func fetchTemplate(version *templateVersion, ref string) (string, error) {
+	if _, err := gitOutput("fetch", "--quiet", "--no-tags", version.Repository, ref); err != nil {
+		return "", err
+	}
+	target, err := gitOutput("rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := gitOutput("cat-file", "-e", version.Commit+"^{commit}"); err != nil {
+		if _, err := gitOutput("fetch", "--quiet", "--no-tags", version.Repository, version.Commit); err != nil {
+			return "", fmt.Errorf("fetching the recorded template commit %s: %w", shortCommit(version.Commit), err)
+		}
+	}
+	return target, nil
+}
+
+// templateChanges lists the files changed between the template revisions from and to
+func templateChanges(from, to string) ([]syncChange, error) {
+	output, err := gitOutputBytes("diff", "--raw", "--no-renames", "-z", from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Records are ":oldmode newmode oldsha newsha status" and the path, NUL-separated
+	fields := bytes.Split(bytes.TrimSuffix(output, []byte{0}), []byte{0})
+	var changes []syncChange
+	for i := 0; i+1 < len(fields); i += 2 {
+		meta := strings.Fields(string(fields[i]))
+		if len(meta) != 5 {
+			return nil, fmt.Errorf("unexpected git diff output %q", fields[i])
+		}
+		var mode uint32
+		fmt.Sscanf(meta[1], "%o", &mode)
+		changes = append(changes, syncChange{
+			path:   string(fields[i+1]),
+			status: meta[4][0],
+			mode:   fs.FileMode(mode & 0777),
+		})
+	}
+	return changes, nil
+}
+
+// syncs reports whether path is a project file sync manages: hidden files,
+// skipped directories and files of removed components are left alone
+func (s *syncer) syncs(path string) bool {
+	if skippedPath(path) {
+		return false
+	}
+	for _, component := range s.manifest.Components {
+		if !s.removed[component.Name] {
+			continue
+		}
+		for _, componentPath := range component.Paths {
+			if path == componentPath || strings.HasSuffix(componentPath, "/") && strings.HasPrefix(path, componentPath) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// apply applies change between the template revisions from and to to the
+// project's file, three-way merging files both sides modified
+func (s *syncer) apply(change syncChange, from, to string) error {
+	local, err := os.ReadFile(filepath.FromSlash(change.path))
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	switch change.status {
+	case 'A':
+		upstream, err := s.templateFile(to, change.path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !exists:
+			return s.write(change, "", upstream)
+		case string(local) != upstream:
+			s.conflicts = append(s.conflicts, change.path+" (added by both)")
+		}
+		return nil
+
+	case 'M':
+		// Deleted in the project, e.g. unused parts of the template
+		if !exists {
+			return nil
+		}
+		base, err := s.templateFile(from, change.path)
+		if err != nil {
+			return err
+		}
+		upstream, err := s.templateFile(to, change.path)
+		if err != nil {
+			return err
+		}
+		switch string(local) {
+		case upstream:
+			return nil
+		case base:
+			return s.write(change, base, upstream)
+		}
+		merged, clean, err := mergeFile(string(local), base, upstream)
+		if err != nil {
+			return err
+		}
+		if !clean {
+			s.conflicts = append(s.conflicts, change.path)
+			return nil
+		}
+		return s.write(change, string(local), merged)
+
+	case 'D':
+		if !exists {
+			return nil
+		}
+		base, err := s.templateFile(from, change.path)
+		if err != nil {
+			return err
+		}
+		if string(local) != base {
+			s.conflicts = append(s.conflicts, change.path+" (deleted upstream)")
+			return nil
+		}
+		return s.write(change, base, "")
+	}
+	return nil
+}
+
+// templateFile returns the content of path at the template revision rev as
+// initialization would have left it in the project
+func (s *syncer) templateFile(rev, path string) (string, error) {
+	content, err := gitOutputBytes("show", rev+":"+path)
+	if err != nil {
+		return "", err
+	}
+	// Initialization leaves binary and large files unchanged too
+	if isBinary(content) || int64(len(content)) > s.manifest.MaxFileSize {
+		return string(content), nil
+	}
+
+	rewritten := string(content)
+	matched := false
+	for _, pattern := range s.manifest.Patterns {
+		if pattern.glob.Match(path) {
+			rewritten = rewriteContent(path, rewritten, pattern.Replacements, s.rewriter)
+			matched = true
+		}
+	}
+	// Like initialization, files no pattern covers only get their import paths moved
+	if !matched && s.rewriter.appliesTo(path) {
+		rewritten = rewriteContent(path, rewritten, nil, s.rewriter)
+	}
+
+	if !strings.Contains(rewritten, "template:begin") {
+		return rewritten, nil
+	}
+	return stripContent(path, rewritten, s.known, s.removed)
+}
+
+// write replaces the project's file holding current with content, deleting
+// it when content is empty. Nothing is written on a dry run.
+func (s *syncer) write(change syncChange, current, content string) error {
+	s.applied = append(s.applied, change.path)
+	if s.dryRun {
+		fmt.Print(unifiedDiff(change.path, current, content))
+		return nil
+	}
+
+	filename := filepath.FromSlash(change.path)
+	if change.status == 'D' {
+		fmt.Printf("    - deleted %s\n", change.path)
+		return os.Remove(filename)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	mode := change.mode
+	if mode == 0 {
+		mode = 0644
+	}
+	if err := os.WriteFile(filename, []byte(content), mode); err != nil {
+		return err
+	}
+	fmt.Printf("    - %s\n", change.path)
+	return nil
+}
+
+// mergeFile three-way merges the changes from base to upstream into local,
+// reporting whether they applied without conflicts
+func mergeFile(local, base, upstream string) (string, bool, error) {
+	dir, err := os.MkdirTemp("", "template-sync")
+	if err != nil {
+		return "", false, err
+	}
+	defer os.RemoveAll(dir)
+
+	files := make([]string, 3)
+	for i, content := range []string{local, base, upstream} {
+		files[i] = filepath.Join(dir, fmt.Sprint(i))
+		if err := os.WriteFile(files[i], []byte(content), 0644); err != nil {
+			return "", false, err
+		}
+	}
+
+	// merge-file exits with the number of conflicts, or negative on errors
+	output, err := exec.Command("git", "merge-file", "-p", files[0], files[1], files[2]).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("git merge-file: %w", err)
+	}
+	return string(output), true, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateVersionFile records which template revision a project was
+// initialized or last synced from, so sync knows what changed upstream since
+const templateVersionFile = ".template-version"
+
+const templateVersionHeader = "# Written by template-init; template-init sync reads it to apply upstream\n# template changes. Commit it along with the project.\n"
+
+type templateVersion struct {
+	// Repository is where the template is fetched from
+	Repository string `yaml:"repository"`
+	// Commit is the template revision the project matches
+	Commit string `yaml:"commit"`
+	// Version describes Commit relative to the template tags, e.g. v1.2.0-3-gabc1234
+	Version string `yaml:"version,omitempty"`
+	// Module is the template's module, rewritten in every template file
+	Module string `yaml:"module"`
+	// ProjectName is the project name the replacements were rendered with
+	ProjectName string `yaml:"project_name"`
+	// RemovedComponents were left out at initialization and are left out of syncs
+	RemovedComponents []string   `yaml:"removed_components,omitempty"`
+	InitializedAt     time.Time  `yaml:"initialized_at"`
+	SyncedAt          *time.Time `yaml:"synced_at,omitempty"`
+}
+
+// newTemplateVersion describes the template checked out in the working
+// directory, before its git remote is changed
+func newTemplateVersion(config Config, removed map[string]bool) (*templateVersion, error) {
+	commit, err := gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("reading the template commit: %w", err)
+	}
+
+	repository := currentGitRemote()
+	if repository == "" {
+		repository = "https://" + config.OldModule + ".git"
+	}
+
+	return &templateVersion{
+		Repository:        repository,
+		Commit:            commit,
+		Version:           describeCommit(commit),
+		Module:            config.OldModule,
+		ProjectName:       config.ProjectName,
+		RemovedComponents: sortedNames(removed),
+		InitializedAt:     time.Now().UTC(),
+	}, nil
+}
+
+// recordTemplateVersion writes templateVersionFile for the template being
+// initialized, backing up the one of a previous initialization in b. Without
+// git history there is nothing to sync from later, so that only warns.
+func recordTemplateVersion(config Config, removed map[string]bool, b *backup) error {
+	version, err := newTemplateVersion(config, removed)
+	if err != nil {
+		fmt.Printf("%sWarning: not recording the template version, sync will be unavailable: %v%s\n", colorYellow, err, colorReset)
+		return nil
+	}
+
+	if content, err := os.ReadFile(templateVersionFile); err == nil {
+		if err := b.save(templateVersionFile, content, 0644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("  → Recording template version %s in %s\n", version.describe(), templateVersionFile)
+	return version.write()
+}
+
+// describe returns the version, or the abbreviated commit without one
+func (v *templateVersion) describe() string {
+	if v.Version != "" {
+		return v.Version
+	}
+	return shortCommit(v.Commit)
+}
+
+// describeCommit returns git describe of commit, or "" without tags to describe it by
+func describeCommit(commit string) string {
+	version, err := gitOutput("describe", "--tags", commit)
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+func readTemplateVersion() (*templateVersion, error) {
+	data, err := os.ReadFile(templateVersionFile)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s not found; only projects initialized with template-init can be synced", templateVersionFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var version templateVersion
+	if err := yaml.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", templateVersionFile, err)
+	}
+	if version.Repository == "" || version.Commit == "" || version.Module == "" {
+		return nil, fmt.Errorf("%s needs a repository, commit and module", templateVersionFile)
+	}
+	return &version, nil
+}
+
+func (v *templateVersion) write() error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(templateVersionFile, append([]byte(templateVersionHeader), data...), 0644)
+}
+
+// gitOutput runs git with args and returns its trimmed output
+func gitOutput(args ...string) (string, error) {
+	output, err := gitOutputBytes(args...)
+	return strings.TrimSpace(string(output)), err
+}
+
+// gitOutputBytes runs git with args and returns its output, or its error output on failure
+func gitOutputBytes(args ...string) ([]byte, error) {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return output, nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+// AdmissionConfig configures the admission interceptor, which bounds the
+// calls running and waiting per usecase once admission is listed in
+// servers.interceptors.unary, refusing calls beyond them with
+// RESOURCE_EXHAUSTED
+type AdmissionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxInFlight is how many calls of a usecase run at once, best kept near
+	// the connections of the database pool
+	MaxInFlight int `mapstructure:"max_in_flight" validate:"positive"`
+	// MaxQueue is how many more calls of a usecase wait for a slot; 0
+	// refuses every call arriving while MaxInFlight run
+	MaxQueue int `mapstructure:"max_queue" validate:"min=0"`
+	// MaxWait is how long a call waits for a slot
+	MaxWait time.Duration `mapstructure:"max_wait" validate:"positive"`
+	// Usecases overrides the limits per usecase: user, product, operation or admin
+	Usecases map[string]AdmissionLimitsConfig `mapstructure:"usecases"`
+}
+
+// AdmissionLimitsConfig overrides the admission limits of one usecase, unset
+// values keeping the defaults
+type AdmissionLimitsConfig struct {
+	MaxInFlight int           `mapstructure:"max_in_flight" validate:"positive"`
+	MaxQueue    int           `mapstructure:"max_queue" validate:"positive"`
+	MaxWait     time.Duration `mapstructure:"max_wait" validate:"positive"`
+}
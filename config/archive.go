@@ -0,0 +1,30 @@
+package config
+
+import "time"
+
+// Tables the archive moves old rows of
+const (
+	ArchiveSourceEntityEvents = "entity_events"
+	ArchiveSourceAuditLog     = "audit_log"
+)
+
+// ArchiveConfig configures moving old event and audit rows to compressed
+// objects in blob storage
+type ArchiveConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Sources lists the tables archived
+	Sources []string `mapstructure:"sources" validate:"oneof=entity_events audit_log"`
+	// Interval is how often the server runs the archive job
+	Interval time.Duration `mapstructure:"interval" validate:"positive"`
+	// Retention keeps rows this long in the database
+	Retention  time.Duration `mapstructure:"retention" validate:"positive"`
+	BatchSize  int           `mapstructure:"batch_size" validate:"positive"`
+	MaxBatches int           `mapstructure:"max_batches" validate:"positive"`
+	// StorageURL is s3://bucket/prefix, or file:///path for a local or
+	// mounted directory
+	StorageURL string `mapstructure:"storage_url" validate:"required_if=Enabled"`
+	// StorageRegion is the S3 region, from the AWS environment when empty
+	StorageRegion string `mapstructure:"storage_region"`
+	// StorageEndpoint overrides the S3 endpoint, e.g. for MinIO
+	StorageEndpoint string `mapstructure:"storage_endpoint"`
+}
@@ -0,0 +1,8 @@
+package config
+
+// AsyncWritesConfig configures the accept-then-process mode of write endpoints
+type AsyncWritesConfig struct {
+	// Enabled makes CreateUser and CreateProduct queue a command and answer
+	// 202 Accepted with an operation ID to poll instead of writing inline
+	Enabled bool `mapstructure:"enabled"`
+}
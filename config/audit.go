@@ -0,0 +1,6 @@
+package config
+
+// AuditConfig configures the consumer mirroring domain events into the audit trail
+type AuditConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// CacheConfig configures the repository read cache
+type CacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TTLs maps an entity (product, user) to how long reads are reused.
+	// Entities without a TTL still collapse concurrent identical reads.
+	TTLs map[string]time.Duration `mapstructure:"ttls"`
+	// Invalidation keeps the cache of every endpoint instance from serving
+	// versions older than those written through the others
+	Invalidation CacheInvalidationConfig `mapstructure:"invalidation"`
+}
+
+// CacheInvalidationConfig configures event-driven cache invalidation. Every
+// endpoint instance subscribes to product and user events on its own, so it
+// needs a broker giving each handler its own subscription: sqs or pubsub.
+type CacheInvalidationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// InstanceID names the subscriptions of this instance, e.g. its SQS
+	// queues; defaults to the hostname. It must be unique and should be
+	// stable across restarts, e.g. a StatefulSet pod name.
+	InstanceID string `mapstructure:"instance_id"`
+}
@@ -0,0 +1,39 @@
+package config
+
+import "time"
+
+// Cleanup jobs
+const (
+	CleanupExpiredEmailChanges = "expired_email_changes"
+	CleanupConsumedEvents      = "consumed_events"
+	CleanupProcessedInbox      = "processed_inbox"
+	CleanupOutboundCalls       = "outbound_calls"
+)
+
+// CleanupConfig configures the batched removal of orphaned records
+type CleanupConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// DryRun only logs and counts what every job would remove
+	DryRun bool `mapstructure:"dry_run"`
+	// Interval, BatchSize and MaxBatches are the defaults of every job
+	Interval   time.Duration `mapstructure:"interval" validate:"positive"`
+	BatchSize  int           `mapstructure:"batch_size" validate:"positive"`
+	MaxBatches int           `mapstructure:"max_batches" validate:"positive"`
+	// Jobs overrides the defaults per job, e.g. consumed_events
+	Jobs map[string]CleanupJobConfig `mapstructure:"jobs"`
+	// ConsumerGroups lists the consumer groups besides this service's reading
+	// the event tables of the SQL broker. consumed_events keeps the events of
+	// a topic until every one of them read from it.
+	ConsumerGroups []string `mapstructure:"consumer_groups"`
+}
+
+// CleanupJobConfig overrides the cleanup defaults for one job
+type CleanupJobConfig struct {
+	Disabled   bool          `mapstructure:"disabled"`
+	DryRun     bool          `mapstructure:"dry_run"`
+	Interval   time.Duration `mapstructure:"interval" validate:"positive"`
+	BatchSize  int           `mapstructure:"batch_size" validate:"positive"`
+	MaxBatches int           `mapstructure:"max_batches" validate:"positive"`
+	// Retention keeps records this long past the point they become eligible
+	Retention time.Duration `mapstructure:"retention" validate:"positive"`
+}
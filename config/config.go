@@ -1,16 +1,40 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"os"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Servers   ServerConfig   `mapstructure:"servers"`
-	Databases DatabaseConfig `mapstructure:"databases"`
-	Consumers ConsumerConfig `mapstructure:"consumers"`
+	Servers        ServerConfig         `mapstructure:"servers"`
+	Databases      DatabaseConfig       `mapstructure:"databases"`
+	Consumers      ConsumerConfig       `mapstructure:"consumers"`
+	Residency      ResidencyConfig      `mapstructure:"residency"`
+	Usage          UsageConfig          `mapstructure:"usage"`
+	Encryption     EncryptionConfig     `mapstructure:"encryption"`
+	Product        ProductConfig        `mapstructure:"product"`
+	Events         EventConfig          `mapstructure:"events"`
+	Logging        LoggingConfig        `mapstructure:"logging"`
+	Cache          CacheConfig          `mapstructure:"cache"`
+	Validation     ValidationConfig     `mapstructure:"validation"`
+	User           UserConfig           `mapstructure:"user"`
+	Metrics        MetricsConfig        `mapstructure:"metrics"`
+	Startup        StartupConfig        `mapstructure:"startup"`
+	Health         HealthConfig         `mapstructure:"health"`
+	Cleanup        CleanupConfig        `mapstructure:"cleanup"`
+	Migration      MigrationConfig      `mapstructure:"migration"`
+	AsyncWrites    AsyncWritesConfig    `mapstructure:"async_writes"`
+	ErrorReporting ErrorReportingConfig `mapstructure:"error_reporting"`
+	Pagination     PaginationConfig     `mapstructure:"pagination"`
+	Secrets        SecretsConfig        `mapstructure:"secrets"`
+	Sandbox        SandboxConfig        `mapstructure:"sandbox"`
+	Archive        ArchiveConfig        `mapstructure:"archive"`
+	PublicIDs      PublicIDConfig       `mapstructure:"public_ids"`
 }
 
 // New loads the config file into Config struct
@@ -20,15 +44,18 @@ func New() (*Config, error) {
 	// Enable environment variable support first
 	viper.AutomaticEnv()
 
-	// Check if we're in Docker environment
-	configName := "config"
-	if isDocker() {
-		configName = "config.docker"
+	source := os.Getenv(SourceEnvVar)
+	switch source {
+	case SourceEnv:
+		return newFromEnv()
+	case "", SourceFile:
+	default:
+		return nil, fmt.Errorf("invalid %s %q, must be %s or %s", SourceEnvVar, source, SourceFile, SourceEnv)
 	}
 
-	slog.Info("Loading configuration from " + configName)
+	slog.Info("Loading configuration", slog.String("environment", os.Getenv(EnvironmentVar)))
 
-	viper.SetConfigName(configName)
+	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 
 	// More option of config path can be added here
@@ -38,6 +65,19 @@ func New() (*Config, error) {
 
 	// Get the config file
 	if err := viper.ReadInConfig(); err != nil {
+		if errors.As(err, new(viper.ConfigFileNotFoundError)) {
+			// Platforms mounting no config file configure from the environment
+			if source == "" {
+				return newFromEnv()
+			}
+			return nil, fmt.Errorf("%w; set %s=%s to configure from environment variables only", err, SourceEnvVar, SourceEnv)
+		}
+		return nil, err
+	}
+
+	// Re-read it with includes resolved and ${ENV_VAR:default} placeholders
+	// expanded, merging the layers of the environment over it
+	if err := readLayers(viper.ConfigFileUsed(), os.Getenv(EnvironmentVar)); err != nil {
 		return nil, err
 	}
 
@@ -46,19 +86,60 @@ func New() (*Config, error) {
 		return nil, err
 	}
 
+	if err := resolveSecrets(&cfg); err != nil {
+		return nil, err
+	}
+	if err := validateConfig(&cfg); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
-// isDocker checks if running in Docker environment
-func isDocker() bool {
-	// Check common Docker environment indicators
-	dockerEnv := viper.GetString("DOCKER_ENV")
-	dbHost := viper.GetString("DB_HOST")
-	
-	slog.Info("Docker environment check", "DOCKER_ENV", dockerEnv, "DB_HOST", dbHost)
-	
-	if dockerEnv != "" || dbHost != "" {
-		return true
+// newFromEnv loads the config from APP_ environment variables only, see EnvName
+func newFromEnv() (*Config, error) {
+	slog.Info("Loading configuration from environment variables")
+
+	if err := bindEnv(); err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	if err := resolveSecrets(&cfg); err != nil {
+		return nil, err
+	}
+	if err := validateConfig(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// NewWithFlags loads the config like New, overridden by the flags in args,
+// the command-line arguments of command name. Every config value has a flag
+// named after its key, e.g. --servers.grpc-port=9001; --help lists them.
+func NewWithFlags(name string, args []string) (*Config, error) {
+	if err := bindFlags(name, args); err != nil {
+		return nil, err
+	}
+	return New()
+}
+
+// validateConfig validates cfg, logging every invalid value so they are all
+// reported at startup rather than the first one failing later
+func validateConfig(cfg *Config) error {
+	err := Validate(cfg)
+	if err == nil {
+		return nil
+	}
+
+	var invalid []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		invalid = joined.Unwrap()
+	}
+	for _, fieldErr := range invalid {
+		slog.Error("Invalid config value", slog.String("error", fieldErr.Error()))
 	}
-	return false
+	return fmt.Errorf("invalid config, %d invalid values:\n%w", len(invalid), err)
 }
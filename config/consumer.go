@@ -15,16 +15,23 @@ const (
 
 type ConsumerConfig struct {
 	Retry *RetryConsumerConfig `mapstructure:"retry"`
+
+	// template:begin consumer
+	Watchdog      WatchdogConfig      `mapstructure:"watchdog"`
+	Audit         AuditConfig         `mapstructure:"audit"`
+	Digest        DigestConfig        `mapstructure:"digest"`
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	// template:end consumer
 }
 
 type RetryConsumerConfig struct {
-	Type                string        `mapstructure:"type"`
-	MaxRetries          int           `mapstructure:"max_retries"`
-	InitialInterval     time.Duration `mapstructure:"initial_interval"`
-	MaxInterval         time.Duration `mapstructure:"max_interval"`
-	Multiplier          float64       `mapstructure:"multiplier"`
-	MaxElapsedTime      time.Duration `mapstructure:"max_elapsed_time"`
-	RandomizationFactor float64       `mapstructure:"randomization_factor"`
+	Type                string        `mapstructure:"type" validate:"oneof=default conservative aggressive"`
+	MaxRetries          int           `mapstructure:"max_retries" validate:"positive"`
+	InitialInterval     time.Duration `mapstructure:"initial_interval" validate:"positive"`
+	MaxInterval         time.Duration `mapstructure:"max_interval" validate:"positive"`
+	Multiplier          float64       `mapstructure:"multiplier" validate:"positive"`
+	MaxElapsedTime      time.Duration `mapstructure:"max_elapsed_time" validate:"positive"`
+	RandomizationFactor float64       `mapstructure:"randomization_factor" validate:"min=0,max=1"`
 }
 
 // GetRetry replace standard retry behaviour
@@ -1,6 +1,25 @@
 package config
 
+import "time"
+
 type DatabaseConfig struct {
-	DbDsn   string `mapstructure:"db_dsn"`
-	PgMqUrl string `mapstructure:"pg_mq"`
+	// DbDsn and PgMqUrl may be secret references, e.g. vault://secret/data/app#db_dsn
+	DbDsn   string `mapstructure:"db_dsn" validate:"required" secret:"true"`
+	PgMqUrl string `mapstructure:"pg_mq" validate:"required" secret:"true"`
+	// CancelGracePeriod bounds how long a statement may run after its request
+	// was cancelled before the connection is closed
+	CancelGracePeriod time.Duration `mapstructure:"cancel_grace_period" validate:"positive"`
+	// QueryPlans logs the plans of slow list and search queries for debugging
+	QueryPlans QueryPlanConfig `mapstructure:"query_plans"`
+}
+
+// QueryPlanConfig configures logging the EXPLAIN (ANALYZE, BUFFERS) plans of
+// sampled slow list and search queries. ANALYZE runs the query again, so it
+// is meant for debugging rather than left on in production.
+type QueryPlanConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Threshold is the duration above which a query is slow
+	Threshold time.Duration `mapstructure:"threshold" validate:"positive"`
+	// SampleRate is the fraction of slow queries explained, from 0 to 1
+	SampleRate float64 `mapstructure:"sample_rate" validate:"min=0,max=1"`
 }
@@ -0,0 +1,28 @@
+package config
+
+import "time"
+
+// DigestConfig configures the consumer collecting high-frequency events into
+// one EventDigestEvent per group, e.g. every price change of a product within
+// an hour
+type DigestConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// FlushInterval is how often groups whose window elapsed are emitted
+	FlushInterval time.Duration      `mapstructure:"flush_interval" validate:"positive"`
+	Rules         []DigestRuleConfig `mapstructure:"rules"`
+}
+
+// DigestRuleConfig collects the events of one type into digests
+type DigestRuleConfig struct {
+	// Name identifies the rule in emitted digests, e.g. product_price_changes
+	Name string `mapstructure:"name" validate:"required"`
+	// Event is the domain event collected, e.g. ProductPriceChangedEvent
+	Event string `mapstructure:"event" validate:"required"`
+	// GroupBy is the dotted path of the event field grouping events into
+	// digests, e.g. product.id; empty collects every event into one group
+	GroupBy string `mapstructure:"group_by"`
+	// Window is how long the first event of a group waits for others
+	Window time.Duration `mapstructure:"window" validate:"required,positive"`
+	// MaxBatchSize emits a digest early once that many events are collected
+	MaxBatchSize int32 `mapstructure:"max_batch_size" validate:"required,positive"`
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the values of secret fields in Dump
+const redacted = "<redacted>"
+
+// loadSources records where the values of the loaded config came from, keyed
+// by config key. Files hold the last file layer setting a key.
+type loadSources struct {
+	files map[string]string
+	env   map[string]string
+	flags map[string]string
+}
+
+var sources = loadSources{
+	files: make(map[string]string),
+	env:   make(map[string]string),
+	flags: make(map[string]string),
+}
+
+// recordFile records the keys set in content, the resolved YAML of path
+func (s loadSources) recordFile(path string, content []byte) error {
+	var values map[string]any
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	// Files are named relative to the working directory, e.g. files/config.yaml
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	s.recordValues(path, values, "")
+	return nil
+}
+
+func (s loadSources) recordValues(path string, values map[string]any, prefix string) {
+	for name, value := range values {
+		key := prefix + strings.ToLower(name)
+		if section, ok := value.(map[string]any); ok && len(section) > 0 {
+			s.recordValues(path, section, key+".")
+			continue
+		}
+		s.files[key] = path
+	}
+}
+
+// of returns where the value of key came from, the highest priority source
+// of key or of the section holding it winning
+func (s loadSources) of(key string) string {
+	for _, source := range []map[string]string{s.flags, s.env, s.files} {
+		for k := key; ; {
+			if from, ok := source[k]; ok {
+				return from
+			}
+			i := strings.LastIndex(k, ".")
+			if i < 0 {
+				break
+			}
+			k = k[:i]
+		}
+	}
+	return "default"
+}
+
+// Dump writes cfg as YAML with its secrets redacted, commenting every value
+// with the config file, environment variable or flag it came from, or
+// default when none set it
+func Dump(w io.Writer, cfg *Config) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(dumpNode(reflect.ValueOf(cfg).Elem(), "", false)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// dumpNode returns the YAML of value, the value of key
+func dumpNode(value reflect.Value, key string, secret bool) *yaml.Node {
+	switch {
+	case value.Type() == durationType:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(value.Interface())}
+	case value.Kind() == reflect.Pointer:
+		if value.IsNil() {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+		}
+		return dumpNode(value.Elem(), key, secret)
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		t := value.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if tag == "" || tag == "-" || !field.IsExported() {
+				continue
+			}
+			appendEntry(node, tag, dumpNode(value.Field(i), joinKey(key, tag), field.Tag.Get("secret") == "true"), joinKey(key, tag))
+		}
+		return node
+	case reflect.Map:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		names := make([]string, 0, value.Len())
+		for _, mapKey := range value.MapKeys() {
+			names = append(names, fmt.Sprint(mapKey.Interface()))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			elem := value.MapIndex(reflect.ValueOf(name).Convert(value.Type().Key()))
+			appendEntry(node, name, dumpNode(elem, joinKey(key, name), secret), joinKey(key, name))
+		}
+		return node
+	case reflect.Slice:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i := 0; i < value.Len(); i++ {
+			node.Content = append(node.Content, dumpNode(value.Index(i), key, secret))
+		}
+		return node
+	case reflect.String:
+		text := value.String()
+		if secret && text != "" {
+			text = redacted
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: text}
+	case reflect.Bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(value.Bool())}
+	case reflect.Float32, reflect.Float64:
+		text := strconv.FormatFloat(value.Float(), 'g', -1, 64)
+		if !strings.ContainsAny(text, ".eIN") {
+			text += ".0"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: text}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprint(value.Interface())}
+}
+
+// appendEntry adds name: value to node, commenting values other than sections
+// with their source
+func appendEntry(node *yaml.Node, name string, value *yaml.Node, key string) {
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+	switch {
+	case value.Kind == yaml.ScalarNode || len(value.Content) == 0:
+		// Empty lists and sections print inline, e.g. deny: []
+		value.Style = yaml.FlowStyle
+		value.LineComment = sources.of(key)
+	case value.Kind == yaml.SequenceNode:
+		keyNode.LineComment = sources.of(key)
+	}
+	node.Content = append(node.Content, keyNode, value)
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+// EncryptionConfig configures application-level encryption of PII columns
+type EncryptionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// ActiveKeyID selects the key used to wrap new data keys
+	ActiveKeyID string `mapstructure:"active_key_id" validate:"required_if=Enabled"`
+	// Keys maps key IDs to base64-encoded 32-byte keys. Retired keys must stay
+	// listed until the rotation job has re-encrypted every value using them.
+	Keys map[string]string `mapstructure:"keys" secret:"true"`
+	// BlindIndexKey is the HMAC key used to derive searchable hashes of encrypted values
+	BlindIndexKey string `mapstructure:"blind_index_key"`
+	// EncryptUserEmail encrypts users.email. Substring search on email is
+	// unavailable while enabled; exact lookups use the blind index.
+	EncryptUserEmail  bool          `mapstructure:"encrypt_user_email"`
+	RotationInterval  time.Duration `mapstructure:"rotation_interval" validate:"positive"`
+	RotationBatchSize int32         `mapstructure:"rotation_batch_size" validate:"positive"`
+}
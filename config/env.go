@@ -0,0 +1,126 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/viper"
+)
+
+// SourceEnvVar selects where the config is read from: "file" reads
+// files/config.yaml, "env" reads the environment only, e.g. on Kubernetes
+// where no config file is mounted. Unset, the config file is read when one
+// exists and the environment otherwise.
+const SourceEnvVar = "CONFIG_SOURCE"
+
+// Config sources
+const (
+	SourceFile = "file"
+	SourceEnv  = "env"
+)
+
+// EnvPrefix starts the environment variable of every config key in the env source
+const EnvPrefix = "APP_"
+
+// EnvName returns the environment variable of a config key, e.g.
+// APP_SERVERS_GRPC_PORT for servers.grpc_port
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// bindEnv reads every config key from its environment variable, falling back
+// to defaults. Scalars and durations are set as text, string lists
+// comma-separated, and maps and lists of sections as JSON, e.g.
+// APP_CACHE_TTLS='{"user":"5s"}'.
+func bindEnv() error {
+	for key, value := range defaults {
+		viper.SetDefault(key, value)
+	}
+	for _, key := range envKeys(reflect.TypeOf(Config{}), "") {
+		if _, ok := os.LookupEnv(EnvName(key.key)); ok {
+			sources.env[key.key] = EnvName(key.key)
+		}
+		if key.json {
+			if err := bindJSONEnv(key.key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := viper.BindEnv(key.key, EnvName(key.key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envKey is a config key read from its environment variable in the env source
+type envKey struct {
+	key string
+	// json is set for maps and lists of sections, which are given as JSON
+	json bool
+}
+
+// envKeys lists the config keys of the fields of t, descending into sections
+func envKeys(t reflect.Type, prefix string) []envKey {
+	var keys []envKey
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		key := prefix + tag
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer && fieldType.Elem().Kind() == reflect.Struct {
+			fieldType = fieldType.Elem()
+		}
+
+		switch {
+		case fieldType == durationType:
+			keys = append(keys, envKey{key: key})
+		case fieldType.Kind() == reflect.Struct:
+			keys = append(keys, envKeys(fieldType, key+".")...)
+		case fieldType.Kind() == reflect.Map,
+			fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() != reflect.String:
+			keys = append(keys, envKey{key: key, json: true})
+		default:
+			keys = append(keys, envKey{key: key})
+		}
+	}
+	return keys
+}
+
+// printEnvNames lists the environment variable of every config key
+func printEnvNames(w io.Writer) {
+	fmt.Fprintf(w, "\nWithout a config file, or with %s=%s, every key is read from its environment variable instead:\n\n", SourceEnvVar, SourceEnv)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, key := range envKeys(reflect.TypeOf(Config{}), "") {
+		format := ""
+		if key.json {
+			format = ", as JSON"
+		}
+		fmt.Fprintf(tw, "  %s\tconfig %s%s\n", EnvName(key.key), key.key, format)
+	}
+	tw.Flush()
+}
+
+// bindJSONEnv sets key from the JSON in its environment variable, if set.
+// It replaces the default, so flags still take precedence.
+func bindJSONEnv(key string) error {
+	raw, ok := os.LookupEnv(EnvName(key))
+	if !ok {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return fmt.Errorf("%s must be JSON: %w", EnvName(key), err)
+	}
+	viper.SetDefault(key, value)
+	return nil
+}
@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// Error reporting providers
+const (
+	ErrorReportingSentry = "sentry"
+)
+
+// ErrorReportingConfig configures where panics and background failures are reported
+type ErrorReportingConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Provider string `mapstructure:"provider" validate:"oneof=sentry"`
+	// DSN is the Sentry project DSN, e.g. https://<key>@o0.ingest.sentry.io/<project>
+	DSN         string `mapstructure:"dsn" validate:"required_if=Enabled" secret:"true"`
+	Release     string `mapstructure:"release"`
+	Environment string `mapstructure:"environment"`
+	// BufferSize is how many reports may wait for delivery before new ones are dropped
+	BufferSize   int           `mapstructure:"buffer_size" validate:"positive"`
+	FlushTimeout time.Duration `mapstructure:"flush_timeout" validate:"positive"`
+}
@@ -0,0 +1,188 @@
+package config
+
+import "time"
+
+// Event brokers
+const (
+	BrokerSQL = "sql"
+	// template:begin sqs
+	BrokerSQS = "sqs"
+	// template:end sqs
+	// template:begin pubsub
+	BrokerPubSub = "pubsub"
+	// template:end pubsub
+)
+
+// EventConfig configures published domain events
+type EventConfig struct {
+	// Broker selects the event transport; defaults to sql
+	Broker string `mapstructure:"broker" validate:"oneof=sql sqs pubsub"`
+	// template:begin sqs
+	SQS SQSConfig `mapstructure:"sqs"`
+	// template:end sqs
+	// template:begin pubsub
+	PubSub PubSubConfig `mapstructure:"pubsub"`
+	// template:end pubsub
+	// DefaultTTL is how long an event stays worth processing; zero never expires
+	DefaultTTL time.Duration `mapstructure:"default_ttl" validate:"positive"`
+	// TTLs overrides DefaultTTL per event name, e.g. ProductUpdatedEvent.
+	// Viper lowercases the names, so they are matched ignoring case.
+	TTLs map[string]time.Duration `mapstructure:"ttls"`
+	// Encryption encrypts the payloads of sensitive events
+	Encryption EventEncryptionConfig `mapstructure:"encryption"`
+	// PublishFailure decides what happens to events the broker does not accept
+	PublishFailure PublishFailureConfig `mapstructure:"publish_failure"`
+	// History records the events about every user and product for the
+	// Get*Events endpoints
+	History EventHistoryConfig `mapstructure:"history"`
+	// ChangeFeeds streams the changes of users and products to the callers
+	// of the Watch* endpoints
+	ChangeFeeds ChangeFeedConfig `mapstructure:"change_feeds"`
+	// Failover moves events to standby brokers when the broker is unreachable
+	Failover EventFailoverConfig `mapstructure:"failover"`
+	// Custom allows publishing events without a proto message of their own
+	// through the PublishCustomEvent admin endpoint
+	Custom CustomEventConfig `mapstructure:"custom"`
+}
+
+// BrokerType returns the configured broker, defaulting to sql
+func (c EventConfig) BrokerType() string {
+	if c.Broker == "" {
+		return BrokerSQL
+	}
+	return c.Broker
+}
+
+// EventEncryptionConfig configures payload encryption of selected events.
+// Subscribers need the keys to decrypt even when they publish nothing.
+type EventEncryptionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Events lists the event names whose payloads are encrypted, e.g. UserCreatedEvent
+	Events []string `mapstructure:"events"`
+	// ActiveKeyID selects the key used to wrap the data keys of new messages
+	ActiveKeyID string `mapstructure:"active_key_id" validate:"required_if=Enabled"`
+	// Keys maps key IDs to base64-encoded 32-byte keys. Retired keys must stay
+	// listed until every message encrypted with them has been consumed.
+	Keys map[string]string `mapstructure:"keys" secret:"true"`
+}
+
+// Publish failure modes
+const (
+	// PublishFailureStrict fails the request publishing the event
+	PublishFailureStrict = "strict"
+	// PublishFailureRetry stores the event and publishes it again once the broker is back
+	PublishFailureRetry = "retry"
+	// PublishFailureBestEffort drops the event, counting it in events_publish_skipped_total
+	PublishFailureBestEffort = "best_effort"
+)
+
+// PublishFailureConfig configures how failing to publish an event is handled.
+// Events are listed by name, e.g. UserCreatedEvent, under the mode they use
+// instead of Default.
+type PublishFailureConfig struct {
+	// Default is the mode of unlisted events; defaults to retry
+	Default    string   `mapstructure:"default" validate:"oneof=strict retry best_effort"`
+	Strict     []string `mapstructure:"strict"`
+	Retry      []string `mapstructure:"retry"`
+	BestEffort []string `mapstructure:"best_effort"`
+	// RetryInterval is how often stored events are published again
+	RetryInterval time.Duration `mapstructure:"retry_interval" validate:"positive"`
+	// RetryBatchSize is the most stored events published per run
+	RetryBatchSize int32 `mapstructure:"retry_batch_size" validate:"positive"`
+}
+
+// Modes maps every listed event name to its mode
+func (c PublishFailureConfig) Modes() map[string]string {
+	modes := make(map[string]string)
+	for mode, events := range map[string][]string{
+		PublishFailureStrict:     c.Strict,
+		PublishFailureRetry:      c.Retry,
+		PublishFailureBestEffort: c.BestEffort,
+	} {
+		for _, event := range events {
+			modes[event] = mode
+		}
+	}
+	return modes
+}
+
+// EventHistoryConfig configures recording published events per aggregate
+type EventHistoryConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// ChangeFeedConfig configures the WatchUsers and WatchProducts streams.
+// Every endpoint instance subscribes to product and user events on its own
+// and fans them out to its watchers, so like cache.invalidation it needs a
+// broker giving each handler its own subscription: sqs or pubsub.
+type ChangeFeedConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// InstanceID names the subscriptions of this instance; defaults to the
+	// hostname. It must be unique and should be stable across restarts.
+	InstanceID string `mapstructure:"instance_id"`
+	// BufferSize is how many changes are held for each watcher; watchers
+	// falling further behind are disconnected
+	BufferSize int `mapstructure:"buffer_size" validate:"positive"`
+}
+
+// CustomEventConfig configures custom events, published as
+// events.custom_<type>. Only types with a schema may be published.
+type CustomEventConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Schemas maps a custom event type, e.g. order_shipped, to the JSON Schema
+	// file its payload must satisfy. The "*" entry applies to unlisted types.
+	Schemas map[string]string `mapstructure:"schemas" validate:"required_if=Enabled"`
+}
+
+// EventFailoverConfig configures active-passive failover of the broker to
+// standbys, e.g. in another region. Not supported by the pubsub broker, whose
+// topics are global already.
+type EventFailoverConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Standbys lists the brokers failed over to, in order of preference:
+	// PostgreSQL DSNs for the sql broker, AWS regions for the sqs broker
+	Standbys []string `mapstructure:"standbys" validate:"required_if=Enabled" secret:"true"`
+	// CheckInterval is how often the active broker is pinged
+	CheckInterval time.Duration `mapstructure:"check_interval" validate:"positive"`
+	// CheckTimeout bounds a single ping
+	CheckTimeout time.Duration `mapstructure:"check_timeout" validate:"positive"`
+	// FailureThreshold is how many pings in a row must fail before failing over
+	FailureThreshold int `mapstructure:"failure_threshold" validate:"positive"`
+}
+
+// template:begin sqs
+// SQSConfig configures the SNS/SQS broker. AWS credentials and the default
+// region come from the standard AWS environment.
+type SQSConfig struct {
+	Region   string `mapstructure:"region"`
+	Endpoint string `mapstructure:"endpoint"`
+	// QueuePrefix is prepended to the per-handler queue names
+	QueuePrefix string `mapstructure:"queue_prefix"`
+	// FIFO delivers the events of one aggregate in publish order
+	FIFO bool `mapstructure:"fifo"`
+	// AutoProvision creates missing topics, queues and subscriptions
+	AutoProvision     bool          `mapstructure:"auto_provision"`
+	VisibilityTimeout time.Duration `mapstructure:"visibility_timeout" validate:"positive"`
+	WaitTime          time.Duration `mapstructure:"wait_time" validate:"positive"`
+}
+
+// template:end sqs
+
+// template:begin pubsub
+// PubSubConfig configures the Google Cloud Pub/Sub broker. Credentials come
+// from the Google Cloud environment; PUBSUB_EMULATOR_HOST selects the emulator.
+type PubSubConfig struct {
+	ProjectID string `mapstructure:"project_id"`
+	// SubscriptionPrefix is prepended to the per-handler subscription names
+	SubscriptionPrefix string `mapstructure:"subscription_prefix"`
+	// Ordering delivers the events of one aggregate in publish order
+	Ordering    bool `mapstructure:"ordering"`
+	ExactlyOnce bool `mapstructure:"exactly_once"`
+	// MaxDeliveryAttempts dead-letters a message after that many failed deliveries; zero disables it
+	MaxDeliveryAttempts int           `mapstructure:"max_delivery_attempts" validate:"positive"`
+	AckDeadline         time.Duration `mapstructure:"ack_deadline" validate:"positive"`
+	// AutoProvision creates missing topics and subscriptions
+	AutoProvision bool `mapstructure:"auto_provision"`
+}
+
+// template:end pubsub
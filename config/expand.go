@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeTag marks a YAML value to be replaced by the contents of another file,
+// e.g. `encryption: !include secrets.yaml`. Paths are relative to the including file.
+const includeTag = "!include"
+
+// maxIncludeDepth guards against include cycles
+const maxIncludeDepth = 8
+
+// envPlaceholder matches ${NAME} and ${NAME:default}
+var envPlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::([^}]*))?\}`)
+
+// loadTemplate reads a YAML config file, resolves !include directives and
+// expands ${ENV_VAR:default} placeholders in every scalar value
+func loadTemplate(path string) ([]byte, error) {
+	root, err := loadNode(path, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	if err := encoder.Encode(root); err != nil {
+		return nil, fmt.Errorf("encode config %s: %w", path, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func loadNode(path string, depth int) (*yaml.Node, error) {
+	if depth > maxIncludeDepth {
+		return nil, fmt.Errorf("include depth exceeded at %s, check for include cycles", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+
+	root := doc.Content[0]
+	if err := resolveNode(root, filepath.Dir(path), depth); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return root, nil
+}
+
+// resolveNode rewrites node in place, replacing includes and expanding placeholders
+func resolveNode(node *yaml.Node, dir string, depth int) error {
+	if node.Tag == includeTag {
+		includePath, err := expandEnv(node.Value)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(dir, includePath)
+		}
+
+		included, err := loadNode(includePath, depth+1)
+		if err != nil {
+			return err
+		}
+		*node = *included
+		return nil
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		value, err := expandEnv(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		if value != node.Value {
+			node.Value = value
+			// Let the expanded text decide the type, e.g. a port or a bool
+			node.Tag = ""
+			node.Style = 0
+		}
+		return nil
+	}
+
+	for _, child := range node.Content {
+		if err := resolveNode(child, dir, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandEnv substitutes ${NAME} and ${NAME:default} with environment values.
+// A variable that is unset and has no default is an error.
+func expandEnv(value string) (string, error) {
+	var missing string
+	expanded := envPlaceholder.ReplaceAllStringFunc(value, func(match string) string {
+		groups := envPlaceholder.FindStringSubmatch(match)
+		if env, ok := os.LookupEnv(groups[1]); ok {
+			return env
+		}
+		if hasDefault := len(match) > len(groups[1])+3; hasDefault {
+			return groups[2]
+		}
+		if missing == "" {
+			missing = groups[1]
+		}
+		return match
+	})
+
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %s is not set and has no default", missing)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// newFlagSet returns a flag for every scalar, list and string map value of
+// Config, named after its config key with dashes, e.g. --servers.grpc-port
+// for servers.grpc_port. Values nested in lists or maps of sections, like
+// logging targets, can only be set in the config file. The returned map
+// holds the config key of every flag.
+func newFlagSet(name string) (*pflag.FlagSet, map[string]string) {
+	flags := pflag.NewFlagSet(name, pflag.ExitOnError)
+	flags.SortFlags = false
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n\nFlags override the config file and environment, e.g. --servers.grpc-port=9001\n\n", name)
+		flags.PrintDefaults()
+		printEnvNames(os.Stderr)
+	}
+
+	keys := make(map[string]string)
+	addFlags(flags, keys, reflect.TypeOf(Config{}), "")
+	return flags, keys
+}
+
+func addFlags(flags *pflag.FlagSet, keys map[string]string, t reflect.Type, prefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		key := prefix + tag
+		name := strings.ReplaceAll(key, "_", "-")
+		usage := "config " + key
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer && fieldType.Elem().Kind() == reflect.Struct {
+			fieldType = fieldType.Elem()
+		}
+
+		switch {
+		case fieldType == durationType:
+			flags.Duration(name, 0, usage)
+		case fieldType.Kind() == reflect.Struct:
+			addFlags(flags, keys, fieldType, key+".")
+			continue
+		case fieldType.Kind() == reflect.String:
+			flags.String(name, "", usage)
+		case fieldType.Kind() == reflect.Bool:
+			flags.Bool(name, false, usage)
+		case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Int64:
+			flags.Int64(name, 0, usage)
+		case fieldType.Kind() >= reflect.Uint && fieldType.Kind() <= reflect.Uint64:
+			flags.Uint64(name, 0, usage)
+		case fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64:
+			flags.Float64(name, 0, usage)
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.String:
+			flags.StringSlice(name, nil, usage+", comma-separated")
+		case fieldType.Kind() == reflect.Map && fieldType.Key().Kind() == reflect.String && fieldType.Elem().Kind() == reflect.String:
+			flags.StringToString(name, nil, usage+", as key=value pairs replacing the configured ones")
+		default:
+			continue
+		}
+		keys[name] = key
+	}
+}
+
+// bindFlags parses args and overrides the config keys of the flags set in
+// them. Flags left out keep the values of the config file and environment.
+func bindFlags(name string, args []string) error {
+	flags, keys := newFlagSet(name)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	flags.Visit(func(flag *pflag.Flag) {
+		if err == nil {
+			err = viper.BindPFlag(keys[flag.Name], flag)
+			sources.flags[keys[flag.Name]] = "--" + flag.Name
+		}
+	})
+	return err
+}
@@ -0,0 +1,46 @@
+package config
+
+import "time"
+
+// GRPCConfig configures the gRPC server at servers.grpc
+type GRPCConfig struct {
+	// Reflection registers the server reflection service, which lists every
+	// service and message for tools such as grpcurl; production deployments
+	// usually disable it
+	Reflection bool `mapstructure:"reflection"`
+	// MaxRecvMsgSize is the largest request message in bytes
+	MaxRecvMsgSize int `mapstructure:"max_recv_msg_size" validate:"positive"`
+	// MaxSendMsgSize is the largest response message in bytes, 0 keeping the
+	// gRPC default of 2 GiB
+	MaxSendMsgSize int `mapstructure:"max_send_msg_size" validate:"min=0"`
+	// MaxConcurrentStreams bounds the calls in flight on one connection, 0
+	// leaving them unbounded
+	MaxConcurrentStreams int `mapstructure:"max_concurrent_streams" validate:"min=0"`
+	// ConnectionTimeout bounds the handshake of new connections, TLS included
+	ConnectionTimeout time.Duration       `mapstructure:"connection_timeout" validate:"positive"`
+	Keepalive         GRPCKeepaliveConfig `mapstructure:"keepalive"`
+}
+
+// GRPCKeepaliveConfig configures the keepalive pings and the lifetime of
+// gRPC connections
+type GRPCKeepaliveConfig struct {
+	// Time is how long a connection is idle before the server pings the client
+	Time time.Duration `mapstructure:"time" validate:"positive"`
+	// Timeout is how long the server waits for a ping to be answered before
+	// closing the connection
+	Timeout time.Duration `mapstructure:"timeout" validate:"positive"`
+	// MinTime is the shortest interval at which clients may ping; connections
+	// of clients pinging more often are closed
+	MinTime time.Duration `mapstructure:"min_time" validate:"positive"`
+	// PermitWithoutStream lets clients ping on connections without calls
+	PermitWithoutStream bool `mapstructure:"permit_without_stream"`
+	// MaxConnectionIdle closes connections without calls for this long, 0
+	// keeping them open
+	MaxConnectionIdle time.Duration `mapstructure:"max_connection_idle" validate:"min=0"`
+	// MaxConnectionAge closes connections this old, so clients reconnect and
+	// spread over new instances behind a load balancer, 0 keeping them open
+	MaxConnectionAge time.Duration `mapstructure:"max_connection_age" validate:"min=0"`
+	// MaxConnectionAgeGrace is how long calls in flight may still run once a
+	// connection reached MaxConnectionAge, 0 waiting for them
+	MaxConnectionAgeGrace time.Duration `mapstructure:"max_connection_age_grace" validate:"min=0"`
+}
@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+// HealthConfig configures the per-dependency statuses of the gRPC health
+// service and of the /healthz and /readyz endpoints
+type HealthConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is how often dependencies are checked
+	Interval time.Duration `mapstructure:"interval" validate:"positive"`
+	// Timeout bounds a single dependency check
+	Timeout time.Duration `mapstructure:"timeout" validate:"positive"`
+	// MaxPublishLag is how long events may wait to be published again once
+	// the broker refused them before the instance is not ready; 0 disables
+	MaxPublishLag time.Duration `mapstructure:"max_publish_lag"`
+}
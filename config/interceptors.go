@@ -0,0 +1,41 @@
+package config
+
+// Interceptors servers.interceptors lists
+const (
+	InterceptorRecovery   = "recovery"
+	InterceptorIPAccess   = "ip_access"
+	InterceptorLogging    = "logging"
+	InterceptorAuth       = "auth"
+	InterceptorMetrics    = "metrics"
+	InterceptorValidation = "validation"
+	InterceptorResidency  = "residency"
+	InterceptorUsage      = "usage"
+	InterceptorSandbox    = "sandbox"
+	InterceptorRateLimit  = "rate_limit"
+	InterceptorJWT        = "jwt"
+	InterceptorAdmission  = "admission"
+	InterceptorRBAC       = "rbac"
+)
+
+// InterceptorConfig orders the interceptors of gRPC calls, the first one
+// running outermost. Interceptors of disabled components, e.g. usage while
+// usage.enabled is false, are skipped. Validation and usage only apply to
+// unary calls, as does admission, which would hold a slot for as long as a
+// stream stays open. Residency takes the tenant from the principal jwt
+// authenticated, so it must come after jwt.
+type InterceptorConfig struct {
+	Unary  []string `mapstructure:"unary" validate:"oneof=recovery ip_access rate_limit logging auth jwt rbac metrics admission validation sandbox residency usage"`
+	Stream []string `mapstructure:"stream" validate:"oneof=recovery ip_access rate_limit logging auth jwt rbac metrics sandbox residency"`
+}
+
+// AuthConfig configures the auth interceptor, which rejects calls without a
+// known API key in x-api-key metadata, the X-Api-Key header over HTTP, and
+// the jwt and rbac interceptors
+type AuthConfig struct {
+	APIKeys []string `mapstructure:"api_keys" secret:"true"`
+	// PublicMethods lists full method names, or prefixes ending in / or .,
+	// callable without an API key or token, e.g. /grpc.health.v1.Health/
+	PublicMethods []string   `mapstructure:"public_methods"`
+	JWT           JWTConfig  `mapstructure:"jwt"`
+	RBAC          RBACConfig `mapstructure:"rbac"`
+}
@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// JWTConfig configures the jwt interceptor and the gateway middleware, which
+// authenticate callers by the JWTs they send as Authorization: Bearer tokens.
+// What each RPC needs is annotated on it with (proto.api.v1.authorization).
+type JWTConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// JWKSURL is the JWKS endpoint of the identity provider publishing the
+	// signing keys
+	JWKSURL string `mapstructure:"jwks_url" validate:"required_if=Enabled"`
+	// Issuer is the iss claim tokens must carry
+	Issuer string `mapstructure:"issuer" validate:"required_if=Enabled"`
+	// Audiences lists the aud claims accepted, e.g. the API identifier
+	Audiences []string `mapstructure:"audiences" validate:"required_if=Enabled"`
+	// Algorithms restricts the accepted signing algorithms; empty accepts
+	// every asymmetric one
+	Algorithms []string `mapstructure:"algorithms" validate:"oneof=RS256 RS384 RS512 PS256 PS384 PS512 ES256 ES384 ES512 EdDSA"`
+	// Leeway tolerates clock skew when checking expiry
+	Leeway time.Duration `mapstructure:"leeway" validate:"positive"`
+	// ScopesClaim names the claim holding the granted scopes
+	ScopesClaim string `mapstructure:"scopes_claim"`
+	// JWKSRefreshInterval is how long fetched keys are used before fetching
+	// them again; tokens signed with unknown keys fetch them sooner
+	JWKSRefreshInterval time.Duration `mapstructure:"jwks_refresh_interval" validate:"positive"`
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvironmentVar selects the config layer of the environment the services
+// run in, e.g. APP_ENV=production for config.production.yaml
+const EnvironmentVar = "APP_ENV"
+
+// localLayer names the last layer, holding the overrides of one machine; it
+// is not committed
+const localLayer = "local"
+
+// configLayers returns the files layered over base in merge order:
+// config.<env>.yaml when env is set, which must then exist, and
+// config.local.yaml when present
+func configLayers(base, env string) ([]string, error) {
+	dir, ext := filepath.Dir(base), filepath.Ext(base)
+	name := strings.TrimSuffix(filepath.Base(base), ext)
+
+	var layers []string
+	if env != "" {
+		if env == localLayer || strings.ContainsAny(env, `/\.`) {
+			return nil, fmt.Errorf("invalid %s %q", EnvironmentVar, env)
+		}
+		path := filepath.Join(dir, name+"."+env+ext)
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("config of %s=%s: %w", EnvironmentVar, env, err)
+		}
+		layers = append(layers, path)
+	}
+
+	local := filepath.Join(dir, name+"."+localLayer+ext)
+	if _, err := os.Stat(local); err == nil {
+		layers = append(layers, local)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// readLayers reads base into viper and deep merges every layer over it:
+// sections merge key by key, while scalars and lists of a layer replace the
+// values below. Includes and ${ENV_VAR:default} placeholders are resolved in
+// every file.
+func readLayers(base, env string) error {
+	content, err := loadTemplate(base)
+	if err != nil {
+		return err
+	}
+	if err := viper.ReadConfig(bytes.NewReader(content)); err != nil {
+		return err
+	}
+	if err := sources.recordFile(base, content); err != nil {
+		return err
+	}
+
+	layers, err := configLayers(base, env)
+	if err != nil {
+		return err
+	}
+	for _, layer := range layers {
+		content, err := loadTemplate(layer)
+		if err != nil {
+			return err
+		}
+		if err := viper.MergeConfig(bytes.NewReader(content)); err != nil {
+			return fmt.Errorf("merge config %s: %w", layer, err)
+		}
+		if err := sources.recordFile(layer, content); err != nil {
+			return err
+		}
+		slog.Info("Merged config layer", slog.String("file", layer))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package config
+
+// Log output target types
+const (
+	LogTargetStdout = "stdout"
+	LogTargetStderr = "stderr"
+	LogTargetFile   = "file"
+	LogTargetSyslog = "syslog"
+)
+
+// LoggingConfig selects the log level, format and where records are written
+type LoggingConfig struct {
+	Level   string            `mapstructure:"level"`                             // debug, info, warn or error
+	Format  string            `mapstructure:"format" validate:"oneof=json text"` // json or text
+	Targets []LogTargetConfig `mapstructure:"targets"`
+	// Requests configures the request logs of the logging interceptor and
+	// the gateway
+	Requests RequestLoggingConfig `mapstructure:"requests"`
+}
+
+// RequestLoggingConfig configures request logs: one record per gRPC call,
+// once logging is listed in servers.interceptors, and per gateway request
+type RequestLoggingConfig struct {
+	// Gateway logs the HTTP requests the gateway serves, health probes and
+	// webhooks included
+	Gateway bool `mapstructure:"gateway"`
+	// SampleRate is the fraction of successful requests logged, from 0 to 1;
+	// failed requests are always logged
+	SampleRate float64 `mapstructure:"sample_rate" validate:"min=0,max=1"`
+	// Payloads logs request and response bodies, which may hold personal
+	// data; meant for debugging rather than left on in production
+	Payloads bool `mapstructure:"payloads"`
+	// MaxPayloadSize is how many bytes of a body are logged at most
+	MaxPayloadSize int `mapstructure:"max_payload_size" validate:"positive"`
+}
+
+// LogTargetConfig is a single log destination; records fan out to every target
+type LogTargetConfig struct {
+	Type string `mapstructure:"type" validate:"required,oneof=stdout stderr file syslog"`
+
+	// File target
+	Path       string `mapstructure:"path"`
+	MaxSizeMB  int    `mapstructure:"max_size_mb" validate:"positive"`
+	MaxAgeDays int    `mapstructure:"max_age_days" validate:"positive"`
+	MaxBackups int    `mapstructure:"max_backups" validate:"positive"`
+
+	// Syslog target; empty network and address use the local daemon (journald included)
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	Tag     string `mapstructure:"tag"`
+}
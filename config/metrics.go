@@ -0,0 +1,15 @@
+package config
+
+import "time"
+
+// MetricsConfig configures the OpenMetrics endpoint
+type MetricsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// KPIInterval is how often business gauges are recomputed from the database
+	KPIInterval time.Duration `mapstructure:"kpi_interval" validate:"positive"`
+	// template:begin consumer
+	// ConsumerPort is the port the consumer serves /metrics on, as it has no
+	// HTTP server of its own
+	ConsumerPort string `mapstructure:"consumer_port" validate:"port"`
+	// template:end consumer
+}
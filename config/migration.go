@@ -0,0 +1,12 @@
+package config
+
+// MigrationConfig configures the checks run before applying migrations
+type MigrationConfig struct {
+	// Dir is the Atlas migration directory
+	Dir string `mapstructure:"dir"`
+	// Lint maps lint rules to error, warn or off, e.g. non_concurrent_index: error
+	Lint map[string]string `mapstructure:"lint"`
+	// DualWrite maps dual-write table migrations to their phase: off,
+	// dual_write, backfill, verify, read_new or complete
+	DualWrite map[string]string `mapstructure:"dual_write"`
+}
@@ -0,0 +1,14 @@
+package config
+
+// NotificationsConfig configures the consumer sending emails rendered from
+// the email templates managed through the admin API
+type NotificationsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// From is the sender address, e.g. "Shop <no-reply@example.com>"
+	From string `mapstructure:"from" validate:"required_if=Enabled"`
+	// SMTPAddr is the host:port of the SMTP server; emails are logged
+	// instead of sent while it is empty
+	SMTPAddr     string `mapstructure:"smtp_addr"`
+	SMTPUsername string `mapstructure:"smtp_username"`
+	SMTPPassword string `mapstructure:"smtp_password" secret:"true"`
+}
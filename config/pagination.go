@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// PaginationConfig configures the page tokens of list endpoints
+type PaginationConfig struct {
+	// LegacyTokensUntil is the date, as YYYY-MM-DD in UTC, from which offset
+	// page tokens issued before keyset pagination are rejected. Empty keeps
+	// accepting them.
+	LegacyTokensUntil string `mapstructure:"legacy_tokens_until"`
+}
+
+// LegacyTokensSunset returns when legacy page tokens stop being accepted, zero for never
+func (c PaginationConfig) LegacyTokensSunset() (time.Time, error) {
+	if c.LegacyTokensUntil == "" {
+		return time.Time{}, nil
+	}
+	sunset, err := time.Parse(time.DateOnly, c.LegacyTokensUntil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid pagination.legacy_tokens_until %q: %w", c.LegacyTokensUntil, err)
+	}
+	return sunset, nil
+}
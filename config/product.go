@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erry-az/go-init/pkg/locale"
+)
+
+// ProductConfig configures product-specific behaviour
+type ProductConfig struct {
+	// AttributeSchemas maps a product category to the JSON Schema file its
+	// attributes must satisfy. The "*" entry applies to unlisted categories.
+	AttributeSchemas map[string]string   `mapstructure:"attribute_schemas"`
+	PriceSchedule    PriceScheduleConfig `mapstructure:"price_schedule"`
+	// DefaultLocale is the BCP 47 locale product names and descriptions are
+	// written in, served when a caller's Accept-Language has no translation
+	DefaultLocale string `mapstructure:"default_locale" validate:"required"`
+}
+
+// Locale returns DefaultLocale in canonical form, e.g. en-US for en-us
+func (c ProductConfig) Locale() (string, error) {
+	tag, err := locale.Canonical(c.DefaultLocale)
+	if err != nil {
+		return "", fmt.Errorf("invalid product.default_locale: %w", err)
+	}
+	return tag, nil
+}
+
+// PriceScheduleConfig configures applying scheduled price changes once they come into effect
+type PriceScheduleConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is how often due changes are looked for, bounding how late a change applies
+	Interval time.Duration `mapstructure:"interval" validate:"positive"`
+	// BatchSize is the most changes applied per run
+	BatchSize int32 `mapstructure:"batch_size" validate:"positive"`
+}
@@ -0,0 +1,15 @@
+package config
+
+// PublicIDConfig configures the form entity IDs take on the external API.
+// Events, logs and the database keep the internal UUIDs.
+type PublicIDConfig struct {
+	// User and Product select the encoding of the IDs of each entity: uuid
+	// shows them as stored, base58 shortens them to 22 characters and
+	// encrypted also hides them, encrypting them with Key. Changing one
+	// invalidates the IDs clients hold.
+	User    string `mapstructure:"user" validate:"oneof=uuid base58 encrypted"`
+	Product string `mapstructure:"product" validate:"oneof=uuid base58 encrypted"`
+	// Key is the secret the keys of encrypted IDs are derived from; startup
+	// fails without one while any entity uses them
+	Key string `mapstructure:"key" secret:"true"`
+}
@@ -0,0 +1,38 @@
+package config
+
+// Rate limit keys
+const (
+	RateLimitKeyPeer   = "peer"
+	RateLimitKeyAPIKey = "api_key"
+)
+
+// RateLimitConfig configures token-bucket rate limiting of gRPC calls, once
+// rate_limit is listed in servers.interceptors, and of gateway requests
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Key is what requests are counted by: peer, the client address read
+	// through the forwarded-for headers of servers.ip_access.trusted_proxies,
+	// or api_key, the authenticated principal or an API key of servers.auth
+	// or sandbox, falling back to the client address
+	Key string `mapstructure:"key" validate:"oneof=peer api_key"`
+	// Rate is how many requests per second a caller may make on average
+	Rate float64 `mapstructure:"rate" validate:"positive"`
+	// Burst is how many requests a caller may make at once
+	Burst int `mapstructure:"burst" validate:"positive"`
+	// Routes override the limit on gRPC methods or HTTP paths by prefix, the
+	// longest matching prefix winning
+	Routes []RateLimitRouteConfig `mapstructure:"routes"`
+}
+
+// RateLimitRouteConfig is the limit of the routes starting with Prefix
+type RateLimitRouteConfig struct {
+	// Prefix is a full gRPC method name or prefix, e.g.
+	// /proto.api.v1.ProductService/, or an HTTP path prefix
+	Prefix string `mapstructure:"prefix" validate:"required"`
+	// Rate is how many requests per second a caller may make on the routes;
+	// 0 leaves them unlimited
+	Rate float64 `mapstructure:"rate" validate:"min=0"`
+	// Burst is how many requests a caller may make at once, the rate rounded
+	// up when 0
+	Burst int `mapstructure:"burst" validate:"min=0"`
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// RBACConfig configures the rbac interceptor, which authorizes the callers
+// the jwt interceptor authenticated against the role the policy file requires
+// for each RPC, e.g. admin for DeleteProduct
+type RBACConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PolicyFile is the YAML policy mapping RPCs to the role they need
+	PolicyFile string `mapstructure:"policy_file" validate:"required_if=Enabled"`
+	// RoleClaim names the token claim holding a role granted by the identity
+	// provider, used over the role stored with the user the token subject
+	// names; empty always uses the stored role
+	RoleClaim string `mapstructure:"role_claim"`
+	// DefaultRole is the role of callers without a stored or granted one;
+	// empty grants none
+	DefaultRole string `mapstructure:"default_role" validate:"oneof=viewer editor admin"`
+	// RoleCacheTTL is how long a stored role is reused, so role changes take
+	// up to this long to apply
+	RoleCacheTTL time.Duration `mapstructure:"role_cache_ttl" validate:"positive"`
+}
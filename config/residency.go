@@ -0,0 +1,22 @@
+package config
+
+// ResidencyConfig configures regional data residency routing
+type ResidencyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// DefaultRegion is used for tenants without an explicit region assignment
+	DefaultRegion string `mapstructure:"default_region"`
+	// ServedRegions lists the regions this deployment is allowed to serve.
+	// Requests for tenants homed in any other region are rejected.
+	ServedRegions []string `mapstructure:"served_regions"`
+	// Regions maps a region name to its database settings
+	Regions map[string]RegionConfig `mapstructure:"regions"`
+	// Tenants maps a tenant ID to its home region
+	Tenants map[string]string `mapstructure:"tenants"`
+	// TenantClaim names the claim of the caller's token holding its tenant;
+	// the x-tenant-id metadata is only accepted when it matches
+	TenantClaim string `mapstructure:"tenant_claim" validate:"required_if=Enabled"`
+}
+
+type RegionConfig struct {
+	DbDsn string `mapstructure:"db_dsn" validate:"required" secret:"true"`
+}
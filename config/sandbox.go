@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// SandboxConfig configures sandbox mode, in which integrators test against the
+// real API without their data reaching production. The writes and reads of
+// sandbox requests go to a schema of their own in the main database, emptied
+// every purge interval, and their events are dropped.
+type SandboxConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Schema holds the sandbox copies of the tables of the public schema
+	Schema string `mapstructure:"schema" validate:"required_if=Enabled"`
+	// APIKeys lists the keys, sent in x-api-key, whose requests always run in
+	// sandbox mode. The auth interceptor accepts them too.
+	APIKeys []string `mapstructure:"api_keys" secret:"true"`
+	// Header lets any caller opt into sandbox mode with an X-Sandbox: true
+	// header, x-sandbox metadata over gRPC
+	Header bool `mapstructure:"header"`
+	// PurgeInterval is how often sandbox data is deleted
+	PurgeInterval time.Duration `mapstructure:"purge_interval" validate:"positive"`
+}
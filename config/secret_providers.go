@@ -0,0 +1,206 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// EnvSecrets reads secrets from environment variables
+type EnvSecrets struct{}
+
+func (EnvSecrets) Secret(_ context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
+
+// FileSecrets reads secrets from files, trimming the trailing newline most
+// secret files end with
+type FileSecrets struct {
+	// Dir is the directory relative paths are read from
+	Dir string
+}
+
+func (s FileSecrets) Secret(_ context.Context, ref string) (string, error) {
+	path := ref
+	if !filepath.IsAbs(path) && s.Dir != "" {
+		path = filepath.Join(s.Dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// VaultSecrets reads keys of HashiCorp Vault KV secrets, referenced as
+// <path>#<key>, e.g. secret/data/go-init#db_dsn. Both KV v1 and v2 paths work.
+type VaultSecrets struct {
+	cfg    VaultSecretsConfig
+	client *http.Client
+}
+
+// NewVaultSecrets creates a Vault provider, defaulting the address and token
+// to VAULT_ADDR and VAULT_TOKEN
+func NewVaultSecrets(cfg VaultSecretsConfig) *VaultSecrets {
+	if cfg.Address == "" {
+		cfg.Address = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("VAULT_TOKEN")
+	}
+	return &VaultSecrets{cfg: cfg, client: &http.Client{}}
+}
+
+func (s *VaultSecrets) Secret(ctx context.Context, ref string) (string, error) {
+	path, key := splitSecretKey(ref)
+	if key == "" {
+		return "", errors.New("vault references need a key, e.g. secret/data/app#db_dsn")
+	}
+	if s.cfg.Address == "" || s.cfg.Token == "" {
+		return "", errors.New("secrets.vault.address and secrets.vault.token, or VAULT_ADDR and VAULT_TOKEN, must be set")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(s.cfg.Address, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", s.cfg.Token)
+	if s.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.cfg.Namespace)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := doSecretRequest(s.client, req, &body); err != nil {
+		return "", err
+	}
+
+	// KV v2 nests the secret under data.data
+	data := body.Data
+	if nested, ok := data["data"]; ok {
+		if err := json.Unmarshal(nested, &data); err != nil {
+			return "", fmt.Errorf("decoding secret %s: %w", path, err)
+		}
+	}
+	return secretKey(data, path, key)
+}
+
+// AWSSecrets reads AWS Secrets Manager secrets, referenced by name or ARN,
+// optionally followed by #<key> to read one key of a JSON secret
+type AWSSecrets struct {
+	cfg    AWSSecretsConfig
+	client *http.Client
+}
+
+// NewAWSSecrets creates a Secrets Manager provider
+func NewAWSSecrets(cfg AWSSecretsConfig) *AWSSecrets {
+	return &AWSSecrets{cfg: cfg, client: &http.Client{}}
+}
+
+// Secret calls GetSecretValue directly, signed with the credentials of the
+// AWS environment, to keep the Secrets Manager SDK out of the dependencies
+func (s *AWSSecrets) Secret(ctx context.Context, ref string) (string, error) {
+	name, key := splitSecretKey(ref)
+
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if s.cfg.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(s.cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return "", fmt.Errorf("load aws config: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return "", errors.New("no AWS region, set secrets.aws.region or AWS_REGION")
+	}
+	credentials, err := awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("retrieve aws credentials: %w", err)
+	}
+
+	endpoint := s.cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + awsCfg.Region + ".amazonaws.com"
+	}
+	payload, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	hash := sha256.Sum256(payload)
+	if err := v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(hash[:]), "secretsmanager", awsCfg.Region, time.Now()); err != nil {
+		return "", fmt.Errorf("sign request: %w", err)
+	}
+
+	var body struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := doSecretRequest(s.client, req, &body); err != nil {
+		return "", err
+	}
+	if body.SecretString == nil {
+		return "", fmt.Errorf("secret %s is binary, only string secrets are supported", name)
+	}
+	if key == "" {
+		return *body.SecretString, nil
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(*body.SecretString), &data); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, reference it without #%s", name, key)
+	}
+	return secretKey(data, name, key)
+}
+
+// doSecretRequest sends req, decoding the JSON response into v
+func doSecretRequest(client *http.Client, req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Error bodies name the failure, e.g. AccessDeniedException or permission denied
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// secretKey returns key of a JSON secret, which must hold a string
+func secretKey(data map[string]json.RawMessage, name, key string) (string, error) {
+	raw, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", name, key)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("key %s of secret %s is not a string", key, name)
+	}
+	return value, nil
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Secret reference schemes. A config value tagged `secret:"true"` holding
+// <scheme>://<reference> is replaced at load time by the secret it refers to,
+// e.g. vault://secret/data/go-init#db_dsn. Other values are used as they are.
+const (
+	// SecretSchemeEnv reads an environment variable, e.g. env://DB_DSN
+	SecretSchemeEnv = "env"
+	// SecretSchemeFile reads a file, e.g. file:///run/secrets/db_dsn; relative
+	// paths are resolved against secrets.file.dir
+	SecretSchemeFile = "file"
+	// SecretSchemeVault reads a HashiCorp Vault KV secret, e.g.
+	// vault://secret/data/go-init#db_dsn for the db_dsn key of a KV v2 secret
+	SecretSchemeVault = "vault"
+	// SecretSchemeAWS reads an AWS Secrets Manager secret, e.g.
+	// awssm://go-init/db for the whole secret string or awssm://go-init/db#dsn
+	// for the dsn key of a JSON secret
+	SecretSchemeAWS = "awssm"
+)
+
+// SecretsProvider resolves secret references of one scheme
+type SecretsProvider interface {
+	// Secret returns the value ref, the reference without its scheme, refers to
+	Secret(ctx context.Context, ref string) (string, error)
+}
+
+// SecretsConfig configures the secret stores config values are resolved from
+type SecretsConfig struct {
+	// Timeout bounds resolving every secret of the config
+	Timeout time.Duration      `mapstructure:"timeout" validate:"positive"`
+	File    FileSecretsConfig  `mapstructure:"file"`
+	Vault   VaultSecretsConfig `mapstructure:"vault"`
+	AWS     AWSSecretsConfig   `mapstructure:"aws"`
+}
+
+// FileSecretsConfig configures reading secrets from files, e.g. Docker or
+// Kubernetes secrets mounted into the container
+type FileSecretsConfig struct {
+	// Dir is the directory relative paths are read from
+	Dir string `mapstructure:"dir"`
+}
+
+// VaultSecretsConfig configures reading secrets from HashiCorp Vault. The
+// address and token default to VAULT_ADDR and VAULT_TOKEN.
+type VaultSecretsConfig struct {
+	Address string `mapstructure:"address"`
+	// Token may itself refer to a secret, e.g. file:///vault/token
+	Token string `mapstructure:"token" secret:"true"`
+	// Namespace is the Vault Enterprise namespace, if any
+	Namespace string `mapstructure:"namespace"`
+}
+
+// AWSSecretsConfig configures reading secrets from AWS Secrets Manager.
+// Credentials and the default region come from the standard AWS environment.
+type AWSSecretsConfig struct {
+	Region string `mapstructure:"region"`
+	// Endpoint overrides the Secrets Manager endpoint, e.g. for LocalStack
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// newSecretsProviders creates the provider of every secret reference scheme
+func newSecretsProviders(cfg SecretsConfig) map[string]SecretsProvider {
+	return map[string]SecretsProvider{
+		SecretSchemeEnv:   EnvSecrets{},
+		SecretSchemeFile:  FileSecrets{Dir: cfg.File.Dir},
+		SecretSchemeVault: NewVaultSecrets(cfg.Vault),
+		SecretSchemeAWS:   NewAWSSecrets(cfg.AWS),
+	}
+}
+
+// resolveSecrets replaces the secret references of cfg by their secrets,
+// returning every reference that could not be resolved at once as joined
+// *FieldError values
+func resolveSecrets(cfg *Config) error {
+	timeout := cfg.Secrets.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The secret stores are configured first, as their own credentials may be
+	// references, e.g. the Vault token to a file
+	r := &secretResolver{
+		providers: newSecretsProviders(cfg.Secrets),
+		cache:     make(map[string]string),
+	}
+	r.resolveStruct(ctx, reflect.ValueOf(&cfg.Secrets).Elem(), "secrets.")
+	if len(r.errs) > 0 {
+		return fmt.Errorf("resolving config secrets: %w", errors.Join(r.errs...))
+	}
+	r.providers = newSecretsProviders(cfg.Secrets)
+	r.resolveStruct(ctx, reflect.ValueOf(cfg).Elem(), "")
+	if len(r.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("resolving config secrets: %w", errors.Join(r.errs...))
+}
+
+// secretResolver walks the config like Validate, resolving the values of
+// fields tagged secret. Secrets referenced several times are read once.
+type secretResolver struct {
+	providers map[string]SecretsProvider
+	cache     map[string]string
+	errs      []error
+}
+
+func (r *secretResolver) resolveStruct(ctx context.Context, v reflect.Value, prefix string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		key := prefix + tag
+		if field.Tag.Get("secret") == "true" {
+			r.resolveValue(ctx, v.Field(i), key)
+			continue
+		}
+		r.resolveNested(ctx, v.Field(i), key)
+	}
+}
+
+// resolveNested resolves the sections held by value, e.g. the regions of a
+// map or the keys of a list
+func (r *secretResolver) resolveNested(ctx context.Context, value reflect.Value, key string) {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if !value.IsNil() {
+			r.resolveNested(ctx, value.Elem(), key)
+		}
+	case reflect.Struct:
+		r.resolveStruct(ctx, value, key+".")
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			r.resolveNested(ctx, value.Index(i), fmt.Sprintf("%s[%d]", key, i))
+		}
+	case reflect.Map:
+		if value.Type().Elem().Kind() != reflect.Struct {
+			return
+		}
+		// Map values cannot be set in place, so they are resolved in a copy
+		for _, mapKey := range value.MapKeys() {
+			elem := reflect.New(value.Type().Elem()).Elem()
+			elem.Set(value.MapIndex(mapKey))
+			r.resolveStruct(ctx, elem, fmt.Sprintf("%s.%v.", key, mapKey))
+			value.SetMapIndex(mapKey, elem)
+		}
+	}
+}
+
+// resolveValue resolves a secret field, a string or a list or map of strings
+func (r *secretResolver) resolveValue(ctx context.Context, value reflect.Value, key string) {
+	switch value.Kind() {
+	case reflect.String:
+		if secret, ok := r.resolve(ctx, value.String(), key); ok {
+			value.SetString(secret)
+		}
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			r.resolveValue(ctx, value.Index(i), fmt.Sprintf("%s[%d]", key, i))
+		}
+	case reflect.Map:
+		if value.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, mapKey := range value.MapKeys() {
+			if secret, ok := r.resolve(ctx, value.MapIndex(mapKey).String(), fmt.Sprintf("%s.%v", key, mapKey)); ok {
+				value.SetMapIndex(mapKey, reflect.ValueOf(secret).Convert(value.Type().Elem()))
+			}
+		}
+	}
+}
+
+// resolve returns the secret value refers to, and false when value is not a
+// secret reference or could not be resolved
+func (r *secretResolver) resolve(ctx context.Context, value, key string) (string, bool) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok {
+		return "", false
+	}
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return "", false
+	}
+
+	if secret, ok := r.cache[value]; ok {
+		return secret, true
+	}
+	secret, err := provider.Secret(ctx, ref)
+	if err != nil {
+		// The reference is reported rather than the value, which may be sensitive
+		r.errs = append(r.errs, &FieldError{Key: key, Message: fmt.Sprintf("could not resolve %s secret: %v", scheme, err)})
+		return "", false
+	}
+	r.cache[value] = secret
+	return secret, true
+}
+
+// splitSecretKey splits a reference into the secret and the key within it
+// after #, empty when the whole secret is referenced
+func splitSecretKey(ref string) (string, string) {
+	name, key, _ := strings.Cut(ref, "#")
+	return name, key
+}
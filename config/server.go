@@ -1,6 +1,147 @@
 package config
 
+import "time"
+
 type ServerConfig struct {
-	GrpcPort string `mapstructure:"grpc_port" default:"8080"`
-	HttpPort string `mapstructure:"http_port" default:"8081"`
+	GrpcPort string         `mapstructure:"grpc_port" default:"8080" validate:"required,port"`
+	IPAccess IPAccessConfig `mapstructure:"ip_access"`
+	TLS      TLSConfig      `mapstructure:"tls"`
+	// GRPC configures the gRPC server: reflection, message sizes and connections
+	GRPC GRPCConfig `mapstructure:"grpc"`
+	// Interceptors orders the interceptors of gRPC calls
+	Interceptors InterceptorConfig `mapstructure:"interceptors"`
+	Auth         AuthConfig        `mapstructure:"auth"`
+	RateLimit    RateLimitConfig   `mapstructure:"rate_limit"`
+	Admission    AdmissionConfig   `mapstructure:"admission"`
+	// DebugAddr is the host:port of the internal listener serving
+	// /debug/vars, kept off the public ports as it exposes the command line
+	// and memory statistics; empty disables it
+	DebugAddr string `mapstructure:"debug_addr"`
+	// ShutdownTimeout bounds how long the endpoints wait for calls in flight
+	// once stopping before cancelling them; 0 waits however long they take
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" validate:"min=0"`
+
+	// template:begin gateway
+	HttpPort     string             `mapstructure:"http_port" default:"8081" validate:"required,port"`
+	Compression  CompressionConfig  `mapstructure:"compression"`
+	CORS         CORSConfig         `mapstructure:"cors"`
+	Headers      HeadersConfig      `mapstructure:"security_headers"`
+	GatewayRetry GatewayRetryConfig `mapstructure:"gateway_retry"`
+	Signing      SigningConfig      `mapstructure:"request_signing"`
+	Limits       RequestLimitConfig `mapstructure:"request_limits"`
+	Webhooks     WebhooksConfig     `mapstructure:"webhooks"`
+	GRPCWeb      GRPCWebConfig      `mapstructure:"grpc_web"`
+	// template:end gateway
+}
+
+// template:begin gateway
+
+// CompressionConfig configures gzip/zstd compression of HTTP responses
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MinSize is the smallest response body in bytes that gets compressed
+	MinSize int `mapstructure:"min_size" validate:"min=0"`
+	// ExcludedPaths lists path prefixes that are never compressed
+	ExcludedPaths []string `mapstructure:"excluded_paths"`
+}
+
+// CORSConfig configures cross-origin requests to the HTTP gateway from browsers
+type CORSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// AllowedOrigins lists origins such as https://app.example.com, a * in
+	// the host matching any subdomain, e.g. https://*.example.com; "*" alone
+	// allows every origin
+	AllowedOrigins []string `mapstructure:"allowed_origins" validate:"required_if=Enabled"`
+	AllowedMethods []string `mapstructure:"allowed_methods" validate:"required_if=Enabled"`
+	// AllowedHeaders lists request headers callers may send, "*" allowing any
+	AllowedHeaders []string `mapstructure:"allowed_headers"`
+	// ExposedHeaders lists response headers scripts may read
+	ExposedHeaders []string `mapstructure:"exposed_headers"`
+	// AllowCredentials lets browsers send cookies and authorization headers;
+	// it cannot be combined with the "*" origin
+	AllowCredentials bool `mapstructure:"allow_credentials"`
+	// MaxAge is how long browsers cache a preflight response
+	MaxAge time.Duration `mapstructure:"max_age" validate:"min=0"`
+}
+
+// HeadersConfig configures the security headers sent with every HTTP response
+type HeadersConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// HSTSMaxAge is the Strict-Transport-Security max-age, 0 omitting the
+	// header; browsers only honour it over HTTPS
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age" validate:"min=0"`
+	HSTSIncludeSubdomains bool          `mapstructure:"hsts_include_subdomains"`
+	// FrameOptions is the X-Frame-Options value, empty omitting the header
+	FrameOptions string `mapstructure:"frame_options" validate:"oneof=DENY SAMEORIGIN"`
+	// ReferrerPolicy is the Referrer-Policy value, empty omitting the header
+	ReferrerPolicy string `mapstructure:"referrer_policy"`
+}
+
+// GatewayRetryConfig configures retries of idempotent gateway requests while
+// the gRPC endpoint is unavailable, e.g. during a rolling restart
+type GatewayRetryConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxAttempts includes the first call
+	MaxAttempts int `mapstructure:"max_attempts" validate:"positive"`
+	// Budget caps the total time spent waiting between attempts
+	Budget time.Duration `mapstructure:"budget" validate:"positive"`
+	// InitialBackoff is the longest first wait, doubled per attempt
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" validate:"positive"`
+}
+
+// SigningConfig configures HMAC request signing for server-to-server callers
+// that cannot use mTLS or OAuth
+type SigningConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Tolerance is how far a signature timestamp may be from the server clock
+	Tolerance time.Duration `mapstructure:"tolerance" validate:"positive"`
+	// MaxBodySize caps the request body in bytes read to verify a signature
+	MaxBodySize int64              `mapstructure:"max_body_size" validate:"positive"`
+	Keys        []SigningKeyConfig `mapstructure:"keys"`
+}
+
+// SigningKeyConfig is the signing secret of one API key
+type SigningKeyConfig struct {
+	APIKey string `mapstructure:"api_key" validate:"required"`
+	Secret string `mapstructure:"secret" validate:"required" secret:"true"`
+	// Required rejects unsigned requests with this API key
+	Required bool `mapstructure:"required"`
+}
+
+// GRPCWebConfig serves gRPC-Web on the HTTP port, so browser apps call the
+// gRPC services without a proxy. Cross-origin apps also need CORS, which
+// then allows the headers gRPC-Web clients send.
+type GRPCWebConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Services lists the full names of the services browsers may call
+	Services []string `mapstructure:"services" validate:"required_if=Enabled"`
+}
+
+// RequestLimitConfig bounds request bodies decoded by the HTTP gateway
+type RequestLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxBodySize is the largest request body in bytes, answered with 413 beyond it
+	MaxBodySize int64 `mapstructure:"max_body_size" validate:"positive"`
+	// MaxJSONDepth is how deeply objects and arrays may nest in a JSON body
+	MaxJSONDepth int `mapstructure:"max_json_depth" validate:"positive"`
+	// BodyReadTimeout bounds receiving the body, answered with 408 beyond it
+	BodyReadTimeout time.Duration `mapstructure:"body_read_timeout" validate:"positive"`
+}
+
+// template:end gateway
+
+// IPAccessConfig configures network access control of HTTP and gRPC requests,
+// checked before any other middleware. Networks are CIDRs or single addresses.
+type IPAccessConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TrustedProxies lists proxies whose X-Forwarded-For is believed; loopback always is
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// Deny lists networks refused on every route
+	Deny []string `mapstructure:"deny"`
+	// AdminAllow lists the only networks that may reach admin routes; empty allows any
+	AdminAllow []string `mapstructure:"admin_allow"`
+	// AdminRoutes are HTTP path or gRPC method prefixes treated as admin routes
+	AdminRoutes []string `mapstructure:"admin_routes"`
+	// RefreshInterval is how often rules added through the admin API are reloaded
+	RefreshInterval time.Duration `mapstructure:"refresh_interval" validate:"positive"`
 }
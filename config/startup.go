@@ -0,0 +1,29 @@
+package config
+
+// Initialization modes for optional components
+const (
+	// InitEager builds the component during boot; failures abort startup
+	InitEager = "eager"
+	// InitLazy builds the component on first use; failures mark it degraded
+	InitLazy = "lazy"
+)
+
+// Optional components whose initialization mode can be configured
+const (
+	ComponentAttributeSchemas = "attribute_schemas"
+	ComponentValidation       = "validation"
+	// ComponentCache serves reads from the cache only once its invalidation
+	// subscription is consuming; until then reads query the database
+	ComponentCache = "cache"
+)
+
+// StartupConfig configures how optional components are initialized
+type StartupConfig struct {
+	// Components maps a component name to its initialization mode, defaulting to eager
+	Components map[string]string `mapstructure:"components"`
+}
+
+// Lazy reports whether the component is initialized on first use
+func (c StartupConfig) Lazy(component string) bool {
+	return c.Components[component] == InitLazy
+}
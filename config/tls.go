@@ -0,0 +1,63 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLS versions servers.tls.min_version accepts
+const (
+	TLSVersion12 = "1.2"
+	TLSVersion13 = "1.3"
+)
+
+// TLSConfig configures TLS on the gRPC and HTTP servers. With mutual TLS,
+// clients of both must present a certificate issued by the client CA.
+type TLSConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	CertFile string `mapstructure:"cert_file" validate:"required_if=Enabled"`
+	KeyFile  string `mapstructure:"key_file" validate:"required_if=Enabled"`
+	// MinVersion is the oldest TLS version accepted, 1.2 or 1.3
+	MinVersion string `mapstructure:"min_version" validate:"oneof=1.2 1.3"`
+	MutualTLS  bool   `mapstructure:"mutual_tls"`
+	// ClientCAFile holds the PEM certificates client certificates are verified against
+	ClientCAFile string `mapstructure:"client_ca_file" validate:"required_if=MutualTLS"`
+}
+
+// ServerTLS loads the certificates of c into a server TLS config, nil when
+// TLS is disabled
+func (c TLSConfig) ServerTLS() (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.MinVersion == TLSVersion13 {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	if c.MutualTLS {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client CA file holds no PEM certificate")
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
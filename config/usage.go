@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+// UsageConfig configures per-client API usage analytics
+type UsageConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	BufferSize    int           `mapstructure:"buffer_size" validate:"positive"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" validate:"positive"`
+	// RollupInterval controls how often the daily rollup job runs
+	RollupInterval time.Duration `mapstructure:"rollup_interval" validate:"positive"`
+}
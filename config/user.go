@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// UserConfig configures user workflows
+type UserConfig struct {
+	// EmailChangeTTL is how long an email change confirmation token is valid
+	EmailChangeTTL time.Duration `mapstructure:"email_change_ttl" validate:"positive"`
+	// RequireEmailConfirmation forces email changes through the confirmation workflow
+	RequireEmailConfirmation bool `mapstructure:"require_email_confirmation"`
+	// StripEmailPlusTags treats foo+tag@x.com as foo@x.com when storing and comparing emails
+	StripEmailPlusTags bool `mapstructure:"strip_email_plus_tags"`
+}
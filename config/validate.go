@@ -0,0 +1,178 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError is an invalid config value
+type FieldError struct {
+	// Key is the config key of the value, e.g. servers.grpc_port or
+	// logging.targets[1].type
+	Key     string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Key + " " + e.Message
+}
+
+// Validate checks cfg against the validate tags of its fields, returning
+// every invalid value at once as joined *FieldError values. Tags hold comma
+// separated rules:
+//
+//   - required: the value is set
+//   - required_if=Field: the value is set when the bool field Field next to it is true
+//   - port: a TCP port between 1 and 65535, as a number or text
+//   - positive: a number or duration above zero
+//   - min=N, max=N: bounds of a number or duration, or of the length of text,
+//     lists and maps
+//   - oneof=a b c: one of the space separated values, or for lists, each item
+//
+// Rules other than required and required_if skip unset values, which most
+// settings treat as their default.
+func Validate(cfg *Config) error {
+	var errs []error
+	validateStruct(reflect.ValueOf(cfg).Elem(), "", &errs)
+	return errors.Join(errs...)
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *[]error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		key := prefix + tag
+		value := v.Field(i)
+		if rules := field.Tag.Get("validate"); rules != "" {
+			for _, rule := range strings.Split(rules, ",") {
+				if message := checkRule(v, value, rule); message != "" {
+					*errs = append(*errs, &FieldError{Key: key, Message: message})
+				}
+			}
+		}
+		validateNested(value, key, errs)
+	}
+}
+
+// validateNested validates the sections held by value, e.g. the targets of a
+// list or the jobs of a map
+func validateNested(value reflect.Value, key string, errs *[]error) {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if !value.IsNil() {
+			validateNested(value.Elem(), key, errs)
+		}
+	case reflect.Struct:
+		validateStruct(value, key+".", errs)
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			validateNested(value.Index(i), fmt.Sprintf("%s[%d]", key, i), errs)
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+		for _, k := range keys {
+			validateNested(value.MapIndex(k), key+"."+k.String(), errs)
+		}
+	}
+}
+
+// checkRule returns why value, a field of parent, breaks rule, empty when it does not
+func checkRule(parent, value reflect.Value, rule string) string {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if value.IsZero() {
+			return "is required"
+		}
+		return ""
+	case "required_if":
+		field, ok := parent.Type().FieldByName(arg)
+		if !ok || field.Type.Kind() != reflect.Bool {
+			return fmt.Sprintf("has rule %q on a missing bool field", rule)
+		}
+		if parent.FieldByIndex(field.Index).Bool() && value.IsZero() {
+			return fmt.Sprintf("is required when %s is true", strings.Split(field.Tag.Get("mapstructure"), ",")[0])
+		}
+		return ""
+	}
+
+	if value.IsZero() {
+		return ""
+	}
+	switch name {
+	case "port":
+		port, err := strconv.ParseInt(fmt.Sprint(value.Interface()), 10, 64)
+		if err != nil || port < 1 || port > 65535 {
+			return "must be a port between 1 and 65535"
+		}
+	case "positive":
+		if n, ok := number(value); ok && n <= 0 {
+			return "must be positive"
+		}
+	case "min", "max":
+		limit, err := parseLimit(value, arg)
+		if err != nil {
+			return fmt.Sprintf("has invalid rule %q: %v", rule, err)
+		}
+		n, ok := number(value)
+		if !ok {
+			n = float64(value.Len())
+		}
+		if name == "min" && n < limit {
+			return "must be at least " + arg
+		}
+		if name == "max" && n > limit {
+			return "must be at most " + arg
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		if value.Kind() == reflect.Slice {
+			for i := 0; i < value.Len(); i++ {
+				if !slices.Contains(allowed, value.Index(i).String()) {
+					return fmt.Sprintf("has %q, items must be one of %s", value.Index(i).String(), strings.Join(allowed, ", "))
+				}
+			}
+			return ""
+		}
+		if !slices.Contains(allowed, value.String()) {
+			return "must be one of " + strings.Join(allowed, ", ")
+		}
+	default:
+		return fmt.Sprintf("has unknown rule %q", rule)
+	}
+	return ""
+}
+
+// number returns value as a float, durations in nanoseconds, and whether it is a number
+func number(value reflect.Value) (float64, bool) {
+	switch {
+	case value.CanInt():
+		return float64(value.Int()), true
+	case value.CanUint():
+		return float64(value.Uint()), true
+	case value.CanFloat():
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+// parseLimit parses the argument of min or max for value: a duration for
+// durations, a number otherwise
+func parseLimit(value reflect.Value, arg string) (float64, error) {
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(arg)
+		return float64(d), err
+	}
+	return strconv.ParseFloat(arg, 64)
+}
@@ -0,0 +1,8 @@
+package config
+
+// ValidationConfig configures request validation
+type ValidationConfig struct {
+	// Mode is "strict" (reject invalid requests) or "log_only" (log violations
+	// and continue), which lets new rules be canaried safely
+	Mode string `mapstructure:"mode" validate:"oneof=strict log_only"`
+}
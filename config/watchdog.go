@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// WatchdogConfig configures the supervisor that health-checks and restarts consumer components
+type WatchdogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is how often components are health-checked
+	Interval time.Duration `mapstructure:"interval" validate:"positive"`
+	// MinBackoff and MaxBackoff bound the jittered exponential delay between restart attempts
+	MinBackoff time.Duration `mapstructure:"min_backoff" validate:"positive"`
+	MaxBackoff time.Duration `mapstructure:"max_backoff" validate:"positive"`
+}
@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// WebhooksConfig configures the inbound webhooks of third parties, received
+// on POST /webhooks/<provider> and processed through the command bus
+type WebhooksConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxBodySize caps the payload in bytes read to verify a signature
+	MaxBodySize int64               `mapstructure:"max_body_size" validate:"positive"`
+	Stripe      StripeWebhookConfig `mapstructure:"stripe"`
+}
+
+// StripeWebhookConfig configures the Stripe webhook endpoint, /webhooks/stripe
+type StripeWebhookConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SigningSecret is the whsec_ secret of the endpoint in the Stripe dashboard
+	SigningSecret string `mapstructure:"signing_secret" validate:"required_if=Enabled" secret:"true"`
+	// Tolerance is how far a signature timestamp may be from the server clock
+	Tolerance time.Duration `mapstructure:"tolerance" validate:"positive"`
+}
@@ -0,0 +1,4 @@
+-- Create "api_usage_hourly" table
+CREATE TABLE "api_usage_hourly" ("client_id" character varying(255) NOT NULL, "method" character varying(255) NOT NULL, "bucket_start" timestamptz NOT NULL, "request_count" bigint NOT NULL DEFAULT 0, "error_count" bigint NOT NULL DEFAULT 0, "total_latency_ms" bigint NOT NULL DEFAULT 0, "max_latency_ms" bigint NOT NULL DEFAULT 0, PRIMARY KEY ("client_id", "method", "bucket_start"));
+-- Create "api_usage_daily" table
+CREATE TABLE "api_usage_daily" ("client_id" character varying(255) NOT NULL, "day" date NOT NULL, "request_count" bigint NOT NULL DEFAULT 0, "error_count" bigint NOT NULL DEFAULT 0, "total_latency_ms" bigint NOT NULL DEFAULT 0, "max_latency_ms" bigint NOT NULL DEFAULT 0, PRIMARY KEY ("client_id", "day"));
@@ -0,0 +1,4 @@
+-- Modify "users" table
+ALTER TABLE "users" ALTER COLUMN "email" TYPE text, ADD COLUMN "email_hash" character varying(64) NULL;
+-- Create index "users_email_hash_key" to table: "users"
+CREATE UNIQUE INDEX "users_email_hash_key" ON "users" ("email_hash");
@@ -0,0 +1,6 @@
+-- Modify "products" table
+ALTER TABLE "products" ADD COLUMN "category" character varying(100) NOT NULL DEFAULT '', ADD COLUMN "attributes" jsonb NOT NULL DEFAULT '{}';
+-- Create index "products_category_idx" to table: "products"
+CREATE INDEX "products_category_idx" ON "products" ("category");
+-- Create index "products_attributes_idx" to table: "products"
+CREATE INDEX "products_attributes_idx" ON "products" USING GIN ("attributes" jsonb_path_ops);
@@ -0,0 +1,4 @@
+-- Create "email_change_requests" table
+CREATE TABLE "email_change_requests" ("token_hash" character varying(64) NOT NULL, "user_id" uuid NOT NULL, "new_email" text NOT NULL, "expires_at" timestamptz NOT NULL, "created_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("token_hash"), CONSTRAINT "email_change_requests_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
+-- Create index "email_change_requests_user_id_idx" to table: "email_change_requests"
+CREATE INDEX "email_change_requests_user_id_idx" ON "email_change_requests" ("user_id");
@@ -0,0 +1,2 @@
+-- Create index "email_change_requests_expires_at_idx" to table: "email_change_requests"
+CREATE INDEX "email_change_requests_expires_at_idx" ON "email_change_requests" ("expires_at");
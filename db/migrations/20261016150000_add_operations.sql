@@ -0,0 +1,2 @@
+-- Create "operations" table
+CREATE TABLE "operations" ("id" uuid NOT NULL, "kind" character varying(50) NOT NULL, "status" character varying(20) NOT NULL DEFAULT 'pending', "resource_id" uuid NULL, "error" text NULL, "created_at" timestamptz NOT NULL DEFAULT now(), "updated_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("id"));
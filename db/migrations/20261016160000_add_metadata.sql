@@ -0,0 +1,4 @@
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+-- Modify "products" table
+ALTER TABLE "products" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
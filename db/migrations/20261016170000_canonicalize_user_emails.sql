@@ -0,0 +1,15 @@
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "duplicate_of" uuid NULL;
+-- Flag users whose emails only differ in case as duplicates of the oldest one;
+-- "migrate emails -merge" merges them afterwards
+UPDATE "users" AS u
+SET "duplicate_of" = d."keep_id", "email_hash" = NULL
+FROM (
+    SELECT "id", first_value("id") OVER (PARTITION BY lower("email") ORDER BY "created_at", "id") AS "keep_id"
+    FROM "users"
+) AS d
+WHERE u."id" = d."id" AND d."keep_id" <> u."id";
+-- Modify "users" table
+ALTER TABLE "users" DROP CONSTRAINT "users_email_key";
+-- Create index "users_email_canonical_key" to table: "users"
+CREATE UNIQUE INDEX "users_email_canonical_key" ON "users" ((lower("email"))) WHERE ("duplicate_of" IS NULL);
@@ -0,0 +1,2 @@
+-- Create "ip_access_rules" table
+CREATE TABLE "ip_access_rules" ("list" character varying(20) NOT NULL, "network" character varying(64) NOT NULL, "description" text NOT NULL DEFAULT '', "created_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("list", "network"));
@@ -0,0 +1,6 @@
+-- Create "scheduled_prices" table
+CREATE TABLE "scheduled_prices" ("id" uuid NOT NULL, "product_id" uuid NOT NULL, "price" numeric(10,2) NOT NULL, "effective_at" timestamptz NOT NULL, "created_at" timestamptz NOT NULL DEFAULT now(), "applied_at" timestamptz NULL, PRIMARY KEY ("id"), CONSTRAINT "scheduled_prices_product_id_fkey" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
+-- Create index "scheduled_prices_product_id_idx" to table: "scheduled_prices"
+CREATE INDEX "scheduled_prices_product_id_idx" ON "scheduled_prices" ("product_id");
+-- Create index "scheduled_prices_pending_idx" to table: "scheduled_prices"
+CREATE INDEX "scheduled_prices_pending_idx" ON "scheduled_prices" ("effective_at") WHERE ("applied_at" IS NULL);
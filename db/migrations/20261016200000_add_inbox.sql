@@ -0,0 +1,4 @@
+-- Create "inbox" table
+CREATE TABLE "inbox" ("event_id" character varying(255) NOT NULL, "handler" character varying(255) NOT NULL, "processed_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("event_id", "handler"));
+-- Create index "inbox_processed_at_idx" to table: "inbox"
+CREATE INDEX "inbox_processed_at_idx" ON "inbox" ("processed_at");
@@ -0,0 +1,8 @@
+-- Create "audit_log" table
+CREATE TABLE "audit_log" ("sequence" bigint NOT NULL, "event_id" character varying(255) NOT NULL, "event_name" character varying(255) NOT NULL, "payload" bytea NOT NULL, "recorded_at" timestamptz NOT NULL, "prev_hash" bytea NOT NULL, "hash" bytea NOT NULL, PRIMARY KEY ("sequence"), CONSTRAINT "audit_log_event_id_key" UNIQUE ("event_id"));
+-- Create "audit_log_append_only" function rejecting changes to recorded entries
+CREATE FUNCTION "audit_log_append_only" () RETURNS trigger LANGUAGE plpgsql AS $$ BEGIN RAISE EXCEPTION 'audit_log is append-only'; END; $$;
+-- Create trigger "audit_log_no_update_delete" on table: "audit_log"
+CREATE TRIGGER "audit_log_no_update_delete" BEFORE UPDATE OR DELETE ON "audit_log" FOR EACH ROW EXECUTE FUNCTION "audit_log_append_only"();
+-- Create trigger "audit_log_no_truncate" on table: "audit_log"
+CREATE TRIGGER "audit_log_no_truncate" BEFORE TRUNCATE ON "audit_log" FOR EACH STATEMENT EXECUTE FUNCTION "audit_log_append_only"();
@@ -0,0 +1,2 @@
+-- Create "publish_retries" table
+CREATE TABLE "publish_retries" ("id" bigserial NOT NULL, "topic" character varying(255) NOT NULL, "message_uuid" character varying(255) NOT NULL, "metadata" jsonb NOT NULL, "payload" bytea NOT NULL, "attempts" integer NOT NULL DEFAULT 1, "last_error" text NOT NULL, "created_at" timestamptz NOT NULL DEFAULT now(), "last_attempt_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("id"));
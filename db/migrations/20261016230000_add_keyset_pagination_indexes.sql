@@ -0,0 +1,4 @@
+-- Create index "users_created_at_id_idx" to table: "users"
+CREATE INDEX "users_created_at_id_idx" ON "users" ("created_at", "id");
+-- Create index "products_created_at_id_idx" to table: "products"
+CREATE INDEX "products_created_at_id_idx" ON "products" ("created_at", "id");
@@ -0,0 +1,4 @@
+-- Create "digest_buffer" table
+CREATE TABLE "digest_buffer" ("id" bigserial NOT NULL, "digest" character varying(255) NOT NULL, "group_key" text NOT NULL, "event_id" character varying(255) NOT NULL, "event_name" character varying(255) NOT NULL, "payload" jsonb NOT NULL, "buffered_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("id"));
+-- Create index "digest_buffer_digest_group_key_idx" to table: "digest_buffer"
+CREATE INDEX "digest_buffer_digest_group_key_idx" ON "digest_buffer" ("digest", "group_key", "id");
@@ -0,0 +1,4 @@
+-- Create "entity_events" table
+CREATE TABLE "entity_events" ("id" bigserial NOT NULL, "aggregate_type" character varying(255) NOT NULL, "aggregate_id" character varying(255) NOT NULL, "event_id" character varying(255) NOT NULL, "event_name" character varying(255) NOT NULL, "metadata" jsonb NOT NULL, "payload" bytea NOT NULL, "published_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("id"));
+-- Create index "entity_events_aggregate_idx" to table: "entity_events"
+CREATE INDEX "entity_events_aggregate_idx" ON "entity_events" ("aggregate_type", "aggregate_id", "id");
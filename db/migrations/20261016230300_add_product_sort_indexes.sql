@@ -0,0 +1,6 @@
+-- Create index "products_price_id_idx" to table: "products"
+CREATE INDEX "products_price_id_idx" ON "products" ("price", "id");
+-- Create index "products_name_id_idx" to table: "products"
+CREATE INDEX "products_name_id_idx" ON "products" ("name", "id");
+-- Create index "products_updated_at_id_idx" to table: "products"
+CREATE INDEX "products_updated_at_id_idx" ON "products" ("updated_at", "id");
@@ -0,0 +1,2 @@
+-- Create "webhook_deliveries" table
+CREATE TABLE "webhook_deliveries" ("id" uuid NOT NULL, "provider" character varying(50) NOT NULL, "event_id" character varying(255) NOT NULL, "event_type" character varying(255) NOT NULL, "payload" bytea NOT NULL, "status" character varying(20) NOT NULL DEFAULT 'pending', "deliveries" integer NOT NULL DEFAULT 1, "attempts" integer NOT NULL DEFAULT 0, "error" text NULL, "received_at" timestamptz NOT NULL DEFAULT now(), "processed_at" timestamptz NULL, PRIMARY KEY ("id"), CONSTRAINT "webhook_deliveries_provider_event_id_key" UNIQUE ("provider", "event_id"));
@@ -0,0 +1,4 @@
+-- Create "jobs" table
+CREATE TABLE "jobs" ("id" uuid NOT NULL, "kind" character varying(50) NOT NULL, "status" character varying(20) NOT NULL DEFAULT 'pending', "processed" bigint NOT NULL DEFAULT 0, "total" bigint NULL, "attempts" integer NOT NULL DEFAULT 0, "error" text NULL, "result_ref" text NULL, "created_at" timestamptz NOT NULL DEFAULT now(), "started_at" timestamptz NULL, "finished_at" timestamptz NULL, "updated_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("id"));
+-- Create index "jobs_created_at_id_idx" to table: "jobs"
+CREATE INDEX "jobs_created_at_id_idx" ON "jobs" ("created_at", "id");
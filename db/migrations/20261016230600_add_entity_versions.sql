@@ -0,0 +1,4 @@
+-- Modify "products" table
+ALTER TABLE "products" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+-- Create "archive_manifests" table
+CREATE TABLE "archive_manifests" ("id" bigserial NOT NULL, "source" character varying(50) NOT NULL, "object_key" text NOT NULL, "first_id" bigint NOT NULL, "last_id" bigint NOT NULL, "row_count" bigint NOT NULL, "oldest_at" timestamptz NOT NULL, "newest_at" timestamptz NOT NULL, "size_bytes" bigint NOT NULL, "checksum" bytea NOT NULL, "first_prev_hash" bytea NULL, "last_hash" bytea NULL, "archived_at" timestamptz NOT NULL DEFAULT now(), "restored_at" timestamptz NULL, PRIMARY KEY ("id"), CONSTRAINT "archive_manifests_object_key_key" UNIQUE ("object_key"));
+-- Create index "archive_manifests_source_last_id_idx" to table: "archive_manifests"
+CREATE INDEX "archive_manifests_source_last_id_idx" ON "archive_manifests" ("source", "last_id");
+-- Modify "audit_log_append_only" function to let archived entries be deleted
+CREATE OR REPLACE FUNCTION "audit_log_append_only" () RETURNS trigger LANGUAGE plpgsql AS $$ BEGIN IF TG_OP = 'DELETE' AND EXISTS (SELECT 1 FROM archive_manifests WHERE source = 'audit_log' AND OLD.sequence BETWEEN first_id AND last_id) THEN RETURN OLD; END IF; RAISE EXCEPTION 'audit_log is append-only'; END; $$;
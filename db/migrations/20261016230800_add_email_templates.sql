@@ -0,0 +1,2 @@
+-- Create "email_templates" table
+CREATE TABLE "email_templates" ("name" character varying(100) NOT NULL, "version" integer NOT NULL, "subject" text NOT NULL, "html_body" text NOT NULL DEFAULT '', "text_body" text NOT NULL DEFAULT '', "created_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("name", "version"));
@@ -0,0 +1,2 @@
+-- Modify "products" table
+ALTER TABLE "products" ADD COLUMN "description" text NOT NULL DEFAULT '', ADD COLUMN "translations" jsonb NOT NULL DEFAULT '{}';
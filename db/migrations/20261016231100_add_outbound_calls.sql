@@ -0,0 +1,4 @@
+-- Create "outbound_calls" table
+CREATE TABLE "outbound_calls" ("idempotency_key" character varying(64) NOT NULL, "event_id" character varying(255) NOT NULL, "handler" character varying(255) NOT NULL, "call" character varying(100) NOT NULL, "result" bytea NOT NULL, "called_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("idempotency_key"));
+-- Create index "outbound_calls_called_at_idx" to table: "outbound_calls"
+CREATE INDEX "outbound_calls_called_at_idx" ON "outbound_calls" ("called_at");
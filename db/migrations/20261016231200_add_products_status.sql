@@ -0,0 +1,4 @@
+-- Modify "products" table
+ALTER TABLE "products" ADD COLUMN "status" character varying(20) NOT NULL DEFAULT 'active';
+-- Create index "products_status_idx" to table: "products"
+CREATE INDEX "products_status_idx" ON "products" ("status");
@@ -0,0 +1,2 @@
+-- Create "data_migrations" table
+CREATE TABLE "data_migrations" ("name" character varying(100) NOT NULL, "backfill_cursor" text NULL, "rows_copied" bigint NOT NULL DEFAULT 0, "backfill_started_at" timestamptz NULL, "backfill_finished_at" timestamptz NULL, "rows_verified" bigint NOT NULL DEFAULT 0, "mismatched_chunks" integer NOT NULL DEFAULT 0, "verified_at" timestamptz NULL, "updated_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("name"));
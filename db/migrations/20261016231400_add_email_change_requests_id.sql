@@ -0,0 +1,6 @@
+-- Confirmation tokens are minted when the confirmation email is sent, so
+-- requests are keyed by an id and get their token hash later
+ALTER TABLE "email_change_requests" ADD COLUMN "id" uuid NULL;
+UPDATE "email_change_requests" SET "id" = uuid_generate_v4();
+-- Modify "email_change_requests" table
+ALTER TABLE "email_change_requests" DROP CONSTRAINT "email_change_requests_pkey", ALTER COLUMN "token_hash" DROP NOT NULL, ALTER COLUMN "id" SET DEFAULT uuid_generate_v4(), ALTER COLUMN "id" SET NOT NULL, ADD PRIMARY KEY ("id"), ADD CONSTRAINT "email_change_requests_token_hash_key" UNIQUE ("token_hash");
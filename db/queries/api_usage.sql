@@ -0,0 +1,63 @@
+-- name: UpsertAPIUsageHourly :exec
+INSERT INTO api_usage_hourly (
+    client_id,
+    method,
+    bucket_start,
+    request_count,
+    error_count,
+    total_latency_ms,
+    max_latency_ms
+) VALUES (
+    @client_id,
+    @method,
+    @bucket_start,
+    @request_count,
+    @error_count,
+    @total_latency_ms,
+    @max_latency_ms
+) ON CONFLICT (client_id, method, bucket_start) DO UPDATE
+SET
+    request_count = api_usage_hourly.request_count + EXCLUDED.request_count,
+    error_count = api_usage_hourly.error_count + EXCLUDED.error_count,
+    total_latency_ms = api_usage_hourly.total_latency_ms + EXCLUDED.total_latency_ms,
+    max_latency_ms = GREATEST(api_usage_hourly.max_latency_ms, EXCLUDED.max_latency_ms);
+
+-- name: RollupAPIUsageDaily :exec
+INSERT INTO api_usage_daily (
+    client_id,
+    day,
+    request_count,
+    error_count,
+    total_latency_ms,
+    max_latency_ms
+)
+SELECT
+    client_id,
+    @day::date,
+    SUM(request_count),
+    SUM(error_count),
+    SUM(total_latency_ms),
+    MAX(max_latency_ms)
+FROM api_usage_hourly
+WHERE bucket_start >= @day::date AND bucket_start < @day::date + 1
+GROUP BY client_id
+ON CONFLICT (client_id, day) DO UPDATE
+SET
+    request_count = EXCLUDED.request_count,
+    error_count = EXCLUDED.error_count,
+    total_latency_ms = EXCLUDED.total_latency_ms,
+    max_latency_ms = EXCLUDED.max_latency_ms;
+
+-- name: ListAPIUsageDaily :many
+SELECT * FROM api_usage_daily
+WHERE client_id = @client_id AND day BETWEEN @start_day AND @end_day
+ORDER BY day;
+
+-- name: ListAllAPIUsageDaily :many
+SELECT * FROM api_usage_daily
+WHERE day BETWEEN @start_day AND @end_day
+ORDER BY client_id, day;
+
+-- name: DeleteAPIUsageHourlyBefore :exec
+DELETE FROM api_usage_hourly
+WHERE bucket_start < @before;
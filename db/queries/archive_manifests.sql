@@ -0,0 +1,55 @@
+-- name: CreateArchiveManifest :one
+INSERT INTO archive_manifests (
+    source,
+    object_key,
+    first_id,
+    last_id,
+    row_count,
+    oldest_at,
+    newest_at,
+    size_bytes,
+    checksum,
+    first_prev_hash,
+    last_hash
+) VALUES (
+    @source,
+    @object_key,
+    @first_id,
+    @last_id,
+    @row_count,
+    @oldest_at,
+    @newest_at,
+    @size_bytes,
+    @checksum,
+    @first_prev_hash,
+    @last_hash
+) RETURNING *;
+
+-- name: GetArchiveManifest :one
+SELECT * FROM archive_manifests
+WHERE id = @id;
+
+-- name: GetLastArchiveManifest :one
+SELECT * FROM archive_manifests
+WHERE source = @source
+ORDER BY last_id DESC
+LIMIT 1;
+
+-- name: GetArchiveManifestStartingAt :one
+SELECT * FROM archive_manifests
+WHERE source = @source
+  AND first_id = @first_id
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListArchiveManifests :many
+SELECT * FROM archive_manifests
+WHERE (@source::text = '' OR source = @source)
+  AND id > @after_id
+ORDER BY id
+LIMIT @page_size;
+
+-- name: MarkArchiveManifestRestored :exec
+UPDATE archive_manifests
+SET restored_at = NOW()
+WHERE id = @id;
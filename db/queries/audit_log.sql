@@ -0,0 +1,43 @@
+-- name: LockAuditLog :exec
+LOCK TABLE audit_log IN EXCLUSIVE MODE;
+
+-- name: GetLastAuditRecord :one
+SELECT * FROM audit_log
+ORDER BY sequence DESC
+LIMIT 1;
+
+-- name: InsertAuditRecord :execrows
+INSERT INTO audit_log (
+    sequence,
+    event_id,
+    event_name,
+    payload,
+    recorded_at,
+    prev_hash,
+    hash
+) VALUES (
+    @sequence,
+    @event_id,
+    @event_name,
+    @payload,
+    @recorded_at,
+    @prev_hash,
+    @hash
+) ON CONFLICT (event_id) DO NOTHING;
+
+-- name: ListAuditRecords :many
+SELECT * FROM audit_log
+WHERE sequence > @after_sequence
+ORDER BY sequence
+LIMIT @batch_size;
+
+-- name: ListArchivableAuditRecords :many
+SELECT * FROM audit_log
+WHERE sequence > @after_sequence
+  AND recorded_at < @recorded_before
+ORDER BY sequence
+LIMIT @batch_size;
+
+-- name: DeleteArchivedAuditRecords :execrows
+DELETE FROM audit_log
+WHERE sequence BETWEEN @first_sequence AND @last_sequence;
@@ -0,0 +1,57 @@
+-- name: GetDataMigration :one
+SELECT * FROM data_migrations
+WHERE name = @name;
+
+-- name: ListDataMigrations :many
+SELECT * FROM data_migrations
+ORDER BY name;
+
+-- name: StartDataMigrationBackfill :one
+INSERT INTO data_migrations (
+    name,
+    backfill_started_at
+) VALUES (
+    @name,
+    NOW()
+) ON CONFLICT (name) DO UPDATE
+SET
+    backfill_started_at = COALESCE(data_migrations.backfill_started_at, NOW()),
+    updated_at = NOW()
+RETURNING *;
+
+-- name: RecordDataMigrationBatch :exec
+UPDATE data_migrations
+SET
+    backfill_cursor = @backfill_cursor,
+    rows_copied = rows_copied + @rows_copied::bigint,
+    updated_at = NOW()
+WHERE name = @name;
+
+-- name: FinishDataMigrationBackfill :exec
+UPDATE data_migrations
+SET
+    backfill_finished_at = NOW(),
+    updated_at = NOW()
+WHERE name = @name;
+
+-- name: RecordDataMigrationVerification :exec
+UPDATE data_migrations
+SET
+    rows_verified = @rows_verified,
+    mismatched_chunks = @mismatched_chunks,
+    verified_at = NOW(),
+    updated_at = NOW()
+WHERE name = @name;
+
+-- name: ResetDataMigration :exec
+UPDATE data_migrations
+SET
+    backfill_cursor = NULL,
+    rows_copied = 0,
+    backfill_started_at = NULL,
+    backfill_finished_at = NULL,
+    rows_verified = 0,
+    mismatched_chunks = 0,
+    verified_at = NULL,
+    updated_at = NOW()
+WHERE name = @name;
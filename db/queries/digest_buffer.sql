@@ -0,0 +1,39 @@
+-- name: BufferDigestEvent :exec
+INSERT INTO digest_buffer (
+    digest,
+    group_key,
+    event_id,
+    event_name,
+    payload
+) VALUES (
+    @digest,
+    @group_key,
+    @event_id,
+    @event_name,
+    @payload
+);
+
+-- name: CountDigestGroup :one
+SELECT COUNT(*) FROM digest_buffer
+WHERE digest = @digest AND group_key = @group_key;
+
+-- name: ListDueDigestGroups :many
+SELECT group_key FROM digest_buffer
+WHERE digest = @digest
+GROUP BY group_key
+HAVING MIN(buffered_at) <= @due_before::timestamptz OR COUNT(*) >= @max_batch_size::bigint
+ORDER BY MIN(buffered_at)
+LIMIT @group_limit;
+
+-- name: TryLockDigestGroup :one
+SELECT pg_try_advisory_xact_lock(hashtextextended(@digest::text || ':' || @group_key::text, 0))::boolean AS locked;
+
+-- name: ListDigestGroupEvents :many
+SELECT * FROM digest_buffer
+WHERE digest = @digest AND group_key = @group_key
+ORDER BY id
+LIMIT @max_batch_size;
+
+-- name: DeleteDigestEvents :exec
+DELETE FROM digest_buffer
+WHERE id = ANY(@ids::bigint[]);
@@ -0,0 +1,36 @@
+-- name: CreateEmailChangeRequest :one
+INSERT INTO email_change_requests (
+    user_id,
+    new_email,
+    expires_at
+) VALUES (
+    @user_id,
+    @new_email,
+    @expires_at
+) RETURNING *;
+
+-- name: SetEmailChangeTokenHash :execrows
+UPDATE email_change_requests
+SET token_hash = @token_hash::text
+WHERE id = @id;
+
+-- name: GetEmailChangeRequest :one
+SELECT * FROM email_change_requests
+WHERE token_hash = @token_hash::text;
+
+-- name: DeleteEmailChangeRequests :exec
+DELETE FROM email_change_requests
+WHERE user_id = @user_id;
+
+-- name: DeleteExpiredEmailChangeRequests :execrows
+DELETE FROM email_change_requests
+WHERE id IN (
+    SELECT id FROM email_change_requests
+    WHERE expires_at < @expired_before
+    ORDER BY expires_at
+    LIMIT @batch_size
+);
+
+-- name: CountExpiredEmailChangeRequests :one
+SELECT count(*) FROM email_change_requests
+WHERE expires_at < @expired_before;
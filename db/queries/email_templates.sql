@@ -0,0 +1,54 @@
+-- name: ListEmailTemplates :many
+SELECT * FROM email_templates
+WHERE (name, version) IN (
+    SELECT name, max(version) FROM email_templates GROUP BY name
+)
+ORDER BY name;
+
+-- name: GetEmailTemplate :one
+SELECT * FROM email_templates
+WHERE name = @name
+ORDER BY version DESC
+LIMIT 1;
+
+-- name: GetEmailTemplateVersion :one
+SELECT * FROM email_templates
+WHERE name = @name AND version = @version;
+
+-- name: ListEmailTemplateVersions :many
+SELECT * FROM email_templates
+WHERE name = @name
+ORDER BY version DESC;
+
+-- name: CreateEmailTemplate :one
+INSERT INTO email_templates (
+    name,
+    version,
+    subject,
+    html_body,
+    text_body
+) VALUES (
+    @name,
+    1,
+    @subject,
+    @html_body,
+    @text_body
+) RETURNING *;
+
+-- name: CreateEmailTemplateVersion :one
+INSERT INTO email_templates (
+    name,
+    version,
+    subject,
+    html_body,
+    text_body
+)
+SELECT name, max(version) + 1, @subject::text, @html_body::text, @text_body::text
+FROM email_templates
+WHERE name = @name
+GROUP BY name
+RETURNING *;
+
+-- name: DeleteEmailTemplate :execrows
+DELETE FROM email_templates
+WHERE name = @name;
@@ -0,0 +1,57 @@
+-- name: InsertEntityEvent :exec
+INSERT INTO entity_events (
+    aggregate_type,
+    aggregate_id,
+    event_id,
+    event_name,
+    metadata,
+    payload
+) VALUES (
+    @aggregate_type,
+    @aggregate_id,
+    @event_id,
+    @event_name,
+    @metadata,
+    @payload
+);
+
+-- name: ListEntityEvents :many
+SELECT * FROM entity_events
+WHERE aggregate_type = @aggregate_type
+  AND aggregate_id = @aggregate_id
+  AND id > @after_id
+ORDER BY id
+LIMIT @page_size;
+
+-- name: ListArchivableEntityEvents :many
+SELECT * FROM entity_events
+WHERE id > @after_id
+  AND published_at < @published_before
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: DeleteArchivedEntityEvents :execrows
+DELETE FROM entity_events
+WHERE id BETWEEN @first_id AND @last_id
+  AND published_at < @published_before;
+
+-- name: RestoreEntityEvent :execrows
+INSERT INTO entity_events (
+    id,
+    aggregate_type,
+    aggregate_id,
+    event_id,
+    event_name,
+    metadata,
+    payload,
+    published_at
+) VALUES (
+    @id,
+    @aggregate_type,
+    @aggregate_id,
+    @event_id,
+    @event_name,
+    @metadata,
+    @payload,
+    @published_at
+) ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,21 @@
+-- name: InsertInboxMessage :execrows
+INSERT INTO inbox (
+    event_id,
+    handler
+) VALUES (
+    @event_id,
+    @handler
+) ON CONFLICT (event_id, handler) DO NOTHING;
+
+-- name: DeleteProcessedInboxMessages :execrows
+DELETE FROM inbox
+WHERE (event_id, handler) IN (
+    SELECT event_id, handler FROM inbox
+    WHERE processed_at < @processed_before
+    ORDER BY processed_at
+    LIMIT @batch_size
+);
+
+-- name: CountProcessedInboxMessages :one
+SELECT count(*) FROM inbox
+WHERE processed_at < @processed_before;
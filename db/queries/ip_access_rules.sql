@@ -0,0 +1,18 @@
+-- name: ListIPAccessRules :many
+SELECT * FROM ip_access_rules
+ORDER BY list, network;
+
+-- name: CreateIPAccessRule :one
+INSERT INTO ip_access_rules (
+    list,
+    network,
+    description
+) VALUES (
+    @list,
+    @network,
+    @description
+) RETURNING *;
+
+-- name: DeleteIPAccessRule :execrows
+DELETE FROM ip_access_rules
+WHERE list = @list AND network = @network;
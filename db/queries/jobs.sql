@@ -0,0 +1,45 @@
+-- name: CreateJob :one
+INSERT INTO jobs (
+    id,
+    kind
+) VALUES (
+    @id,
+    @kind
+) RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = @id;
+
+-- name: ListJobs :many
+SELECT * FROM jobs
+WHERE (@kind::text = '' OR kind = @kind)
+  AND (@status::text = '' OR status = @status)
+  AND (created_at, id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY created_at, id
+LIMIT @page_size OFFSET @page_offset;
+
+-- name: StartJob :exec
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1, started_at = COALESCE(started_at, NOW()), updated_at = NOW()
+WHERE id = @id;
+
+-- name: UpdateJobProgress :exec
+UPDATE jobs
+SET processed = @processed, total = @total, updated_at = NOW()
+WHERE id = @id;
+
+-- name: CompleteJob :exec
+UPDATE jobs
+SET status = 'succeeded', result_ref = @result_ref, error = NULL, finished_at = NOW(), updated_at = NOW()
+WHERE id = @id;
+
+-- name: FailJob :exec
+UPDATE jobs
+SET status = 'failed', error = @error, finished_at = NOW(), updated_at = NOW()
+WHERE id = @id;
+
+-- name: RecordJobError :exec
+UPDATE jobs
+SET error = @error, updated_at = NOW()
+WHERE id = @id;
@@ -0,0 +1,22 @@
+-- name: CreateOperation :one
+INSERT INTO operations (
+    id,
+    kind
+) VALUES (
+    @id,
+    @kind
+) RETURNING *;
+
+-- name: GetOperation :one
+SELECT * FROM operations
+WHERE id = @id;
+
+-- name: CompleteOperation :exec
+UPDATE operations
+SET status = 'succeeded', resource_id = @resource_id, error = NULL, updated_at = NOW()
+WHERE id = @id;
+
+-- name: FailOperation :exec
+UPDATE operations
+SET status = 'failed', error = @error, updated_at = NOW()
+WHERE id = @id;
@@ -0,0 +1,31 @@
+-- name: GetOutboundCallResult :one
+SELECT result FROM outbound_calls
+WHERE idempotency_key = @idempotency_key;
+
+-- name: InsertOutboundCall :exec
+INSERT INTO outbound_calls (
+    idempotency_key,
+    event_id,
+    handler,
+    call,
+    result
+) VALUES (
+    @idempotency_key,
+    @event_id,
+    @handler,
+    @call,
+    @result
+) ON CONFLICT (idempotency_key) DO NOTHING;
+
+-- name: DeleteOutboundCalls :execrows
+DELETE FROM outbound_calls
+WHERE idempotency_key IN (
+    SELECT idempotency_key FROM outbound_calls
+    WHERE called_at < @called_before
+    ORDER BY called_at
+    LIMIT @batch_size
+);
+
+-- name: CountOutboundCalls :one
+SELECT count(*) FROM outbound_calls
+WHERE called_at < @called_before;
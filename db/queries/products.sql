@@ -2,47 +2,32 @@
 INSERT INTO products (
     id,
     name,
-    price
+    price,
+    category,
+    attributes,
+    metadata,
+    description,
+    translations,
+    status
 ) VALUES (
     @id,
     @name,
-    @price
+    @price,
+    @category,
+    @attributes,
+    @metadata,
+    @description,
+    @translations,
+    @status
 ) RETURNING *;
 
 -- name: GetProductByID :one
 SELECT * FROM products
 WHERE id = @id;
 
--- name: ListProducts :many
-SELECT * FROM products
-ORDER BY created_at
-LIMIT $1 OFFSET $2;
-
--- name: SearchProducts :many
-SELECT * FROM products
-WHERE name ILIKE @search_query
-ORDER BY created_at
-LIMIT $1 OFFSET $2;
-
--- name: ListProductsByPriceRange :many
-SELECT * FROM products
-WHERE price BETWEEN @min_price AND @max_price
-ORDER BY created_at
-LIMIT $1 OFFSET $2;
-
--- name: SearchProductsWithPriceRange :many
-SELECT * FROM products
-WHERE name ILIKE @search_query AND price BETWEEN @min_price AND @max_price
-ORDER BY created_at
-LIMIT $1 OFFSET $2;
-
 -- name: CountProducts :one
 SELECT COUNT(*) FROM products;
 
--- name: CountProductsBySearch :one
-SELECT COUNT(*) FROM products
-WHERE name ILIKE @search_query;
-
 -- name: GetAveragePrice :one
 SELECT COALESCE(AVG(price), 0) FROM products;
 
@@ -57,10 +42,30 @@ UPDATE products
 SET 
     name = @name,
     price = @price,
-    updated_at = NOW()
+    category = @category,
+    attributes = @attributes,
+    metadata = @metadata,
+    description = @description,
+    translations = @translations,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = @id
+RETURNING *;
+
+-- name: UpdateProductStatus :one
+UPDATE products
+SET
+    status = @status,
+    updated_at = NOW(),
+    version = version + 1
 WHERE id = @id
 RETURNING *;
 
 -- name: DeleteProduct :exec
 DELETE FROM products
-WHERE id = @id;
\ No newline at end of file
+WHERE id = @id;
+-- name: ListProductsAfterID :many
+SELECT * FROM products
+WHERE id > @after_id
+ORDER BY id
+LIMIT @batch_size;
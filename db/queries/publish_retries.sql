@@ -0,0 +1,35 @@
+-- name: InsertPublishRetry :exec
+INSERT INTO publish_retries (
+    topic,
+    message_uuid,
+    metadata,
+    payload,
+    last_error
+) VALUES (
+    @topic,
+    @message_uuid,
+    @metadata,
+    @payload,
+    @last_error
+);
+
+-- name: ListPublishRetries :many
+SELECT * FROM publish_retries
+ORDER BY id
+LIMIT @batch_size
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeletePublishRetry :exec
+DELETE FROM publish_retries
+WHERE id = @id;
+
+-- name: RecordPublishRetryFailure :exec
+UPDATE publish_retries
+SET attempts = attempts + 1,
+    last_error = @last_error,
+    last_attempt_at = NOW()
+WHERE id = @id;
+
+-- name: GetPublishRetryLag :one
+SELECT COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0)::float8 AS lag_seconds
+FROM publish_retries;
@@ -0,0 +1,33 @@
+-- name: CreateScheduledPrice :one
+INSERT INTO scheduled_prices (
+    id,
+    product_id,
+    price,
+    effective_at
+) VALUES (
+    @id,
+    @product_id,
+    @price,
+    @effective_at
+) RETURNING *;
+
+-- name: ListPendingScheduledPrices :many
+SELECT * FROM scheduled_prices
+WHERE product_id = @product_id AND applied_at IS NULL
+ORDER BY effective_at, id;
+
+-- name: ListDueScheduledPrices :many
+SELECT * FROM scheduled_prices
+WHERE applied_at IS NULL AND effective_at <= @now
+ORDER BY effective_at, id
+LIMIT @batch_size;
+
+-- name: ClaimScheduledPrice :execrows
+UPDATE scheduled_prices
+SET applied_at = NOW()
+WHERE id = @id AND applied_at IS NULL;
+
+-- name: ReleaseScheduledPrice :exec
+UPDATE scheduled_prices
+SET applied_at = NULL
+WHERE id = @id;
@@ -2,44 +2,113 @@
 INSERT INTO users (
     id,
     name,
-    email
+    email,
+    email_hash,
+    metadata
 ) VALUES (
     @id,
     @name,
-    @email
+    @email,
+    @email_hash,
+    @metadata
 ) RETURNING *;
 
 -- name: GetUserByID :one
 SELECT * FROM users
 WHERE id = @id;
 
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE (lower(email) = @email::text OR email_hash = @email_hash)
+  AND duplicate_of IS NULL;
+
 -- name: ListUsers :many
 SELECT * FROM users
-ORDER BY created_at
-LIMIT $1 OFFSET $2;
+WHERE (created_at, id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY created_at, id
+LIMIT @page_size OFFSET @page_offset;
 
 -- name: SearchUsers :many
 SELECT * FROM users
-WHERE name ILIKE @search_query OR email ILIKE @search_query
-ORDER BY created_at
-LIMIT $1 OFFSET $2;
+WHERE (name ILIKE @search_query OR email ILIKE sqlc.narg('email_query') OR email_hash = sqlc.narg('email_hash'))
+  AND (created_at, id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY created_at, id
+LIMIT @page_size OFFSET @page_offset;
 
 -- name: CountUsers :one
 SELECT COUNT(*) FROM users;
 
 -- name: CountUsersBySearch :one
 SELECT COUNT(*) FROM users
-WHERE name ILIKE @search_query OR email ILIKE @search_query;
+WHERE name ILIKE @search_query OR email ILIKE sqlc.narg('email_query') OR email_hash = sqlc.narg('email_hash');
 
 -- name: UpdateUser :one
 UPDATE users
 SET 
     name = @name,
     email = @email,
-    updated_at = NOW()
+    email_hash = @email_hash,
+    metadata = @metadata,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = @id
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET
+    role = @role,
+    updated_at = NOW(),
+    version = version + 1
 WHERE id = @id
 RETURNING *;
 
+-- name: GetUserRole :one
+SELECT role FROM users
+WHERE id = @id;
+
 -- name: DeleteUser :exec
 DELETE FROM users
-WHERE id = @id;
\ No newline at end of file
+WHERE id = @id;
+
+-- name: ListUserEmailsForRotation :many
+SELECT id, email FROM users
+WHERE email NOT LIKE @active_prefix
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: UpdateUserEmailCiphertext :exec
+UPDATE users
+SET
+    email = @email,
+    email_hash = @email_hash,
+    version = version + 1
+WHERE id = @id;
+
+-- name: ListUsersForEmailBackfill :many
+SELECT * FROM users
+WHERE duplicate_of IS NULL AND id > @after_id
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: FlagDuplicateUser :exec
+UPDATE users
+SET
+    duplicate_of = @duplicate_of,
+    email_hash = NULL,
+    version = version + 1
+WHERE id = @id;
+
+-- name: ListDuplicateUsers :many
+SELECT * FROM users
+WHERE duplicate_of IS NOT NULL AND id > @after_id
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: MergeUserMetadata :execrows
+UPDATE users
+SET
+    metadata = @metadata || metadata,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = @id AND duplicate_of IS NULL;
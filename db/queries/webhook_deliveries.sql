@@ -0,0 +1,35 @@
+-- name: UpsertWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+    id,
+    provider,
+    event_id,
+    event_type,
+    payload
+) VALUES (
+    @id,
+    @provider,
+    @event_id,
+    @event_type,
+    @payload
+) ON CONFLICT (provider, event_id) DO UPDATE
+SET deliveries = webhook_deliveries.deliveries + 1
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = @id;
+
+-- name: CompleteWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = 'processed', attempts = attempts + 1, error = NULL, processed_at = NOW()
+WHERE id = @id;
+
+-- name: FailWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = 'failed', attempts = attempts + 1, error = @error, processed_at = NOW()
+WHERE id = @id;
+
+-- name: RecordWebhookDeliveryError :exec
+UPDATE webhook_deliveries
+SET attempts = attempts + 1, error = @error
+WHERE id = @id;
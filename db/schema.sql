@@ -5,17 +5,339 @@ create table public.products
     name       varchar(255)                                        not null,
     price      numeric(10, 2)                                      not null,
     created_at timestamp with time zone default now()              not null,
-    updated_at timestamp with time zone default now()              not null
+    updated_at timestamp with time zone default now()              not null,
+    category   varchar(100)             default ''::character varying not null,
+    attributes jsonb                    default '{}'::jsonb        not null,
+    metadata   jsonb                    default '{}'::jsonb        not null,
+    version    bigint                   default 1                  not null,
+    description  text                   default ''::text           not null,
+    translations jsonb                  default '{}'::jsonb        not null,
+    status     varchar(20)              default 'active'::character varying not null
 );
 
+create index products_category_idx
+    on public.products (category);
+
+create index products_attributes_idx
+    on public.products using gin (attributes jsonb_path_ops);
+
 create table public.users
 (
     id         uuid                     default uuid_generate_v4() not null
         primary key,
     name       varchar(100)                                        not null,
-    email      varchar(255)                                        not null
-        unique,
+    email      text                                                not null,
     created_at timestamp with time zone default now()              not null,
-    updated_at timestamp with time zone default now()              not null
+    updated_at timestamp with time zone default now()              not null,
+    email_hash varchar(64)
+        unique,
+    metadata   jsonb                    default '{}'::jsonb        not null,
+    duplicate_of uuid,
+    version    bigint                   default 1                  not null,
+    role       varchar(20)              default 'viewer'::character varying not null
+);
+
+create unique index users_email_canonical_key
+    on public.users (lower(email))
+    where (duplicate_of IS NULL);
+
+
+create table public.api_usage_hourly
+(
+    client_id        varchar(255)             not null,
+    method           varchar(255)             not null,
+    bucket_start     timestamp with time zone not null,
+    request_count    bigint default 0         not null,
+    error_count      bigint default 0         not null,
+    total_latency_ms bigint default 0         not null,
+    max_latency_ms   bigint default 0         not null,
+    primary key (client_id, method, bucket_start)
+);
+
+create table public.api_usage_daily
+(
+    client_id        varchar(255)     not null,
+    day              date             not null,
+    request_count    bigint default 0 not null,
+    error_count      bigint default 0 not null,
+    total_latency_ms bigint default 0 not null,
+    max_latency_ms   bigint default 0 not null,
+    primary key (client_id, day)
+);
+
+create table public.email_change_requests
+(
+    token_hash varchar(64)
+        unique,
+    user_id    uuid                                               not null
+        references public.users
+            on delete cascade,
+    new_email  text                                               not null,
+    expires_at timestamp with time zone                           not null,
+    created_at timestamp with time zone default now()             not null,
+    id         uuid                     default uuid_generate_v4() not null
+        primary key
+);
+
+create index email_change_requests_user_id_idx
+    on public.email_change_requests (user_id);
+
+create index email_change_requests_expires_at_idx
+    on public.email_change_requests (expires_at);
+
+create table public.operations
+(
+    id          uuid                                   not null
+        primary key,
+    kind        varchar(50)                            not null,
+    status      varchar(20) default 'pending'::character varying not null,
+    resource_id uuid,
+    error       text,
+    created_at  timestamp with time zone default now() not null,
+    updated_at  timestamp with time zone default now() not null
+);
+
+create table public.ip_access_rules
+(
+    list        varchar(20)                            not null,
+    network     varchar(64)                            not null,
+    description text default ''::text                  not null,
+    created_at  timestamp with time zone default now() not null,
+    primary key (list, network)
+);
+
+create table public.scheduled_prices
+(
+    id           uuid                                   not null
+        primary key,
+    product_id   uuid                                   not null
+        references public.products
+            on delete cascade,
+    price        numeric(10, 2)                         not null,
+    effective_at timestamp with time zone               not null,
+    created_at   timestamp with time zone default now() not null,
+    applied_at   timestamp with time zone
+);
+
+create index scheduled_prices_product_id_idx
+    on public.scheduled_prices (product_id);
+
+create index scheduled_prices_pending_idx
+    on public.scheduled_prices (effective_at)
+    where (applied_at IS NULL);
+
+create table public.inbox
+(
+    event_id     varchar(255)                           not null,
+    handler      varchar(255)                           not null,
+    processed_at timestamp with time zone default now() not null,
+    primary key (event_id, handler)
+);
+
+create index inbox_processed_at_idx
+    on public.inbox (processed_at);
+
+create table public.audit_log
+(
+    sequence    bigint                   not null
+        primary key,
+    event_id    varchar(255)             not null
+        constraint audit_log_event_id_key
+            unique,
+    event_name  varchar(255)             not null,
+    payload     bytea                    not null,
+    recorded_at timestamp with time zone not null,
+    prev_hash   bytea                    not null,
+    hash        bytea                    not null
+);
+
+create function public.audit_log_append_only() returns trigger
+    language plpgsql
+as
+$$
+BEGIN
+    -- Entries moved to an archive are the only ones that may be removed
+    IF TG_OP = 'DELETE' AND EXISTS (SELECT 1
+                                    FROM public.archive_manifests
+                                    WHERE source = 'audit_log'
+                                      AND OLD.sequence BETWEEN first_id AND last_id) THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$;
+
+create trigger audit_log_no_update_delete
+    before update or delete
+    on public.audit_log
+    for each row
+execute procedure public.audit_log_append_only();
+
+create trigger audit_log_no_truncate
+    before truncate
+    on public.audit_log
+execute procedure public.audit_log_append_only();
+
+create table public.publish_retries
+(
+    id              bigserial
+        primary key,
+    topic           varchar(255)                           not null,
+    message_uuid    varchar(255)                           not null,
+    metadata        jsonb                                  not null,
+    payload         bytea                                  not null,
+    attempts        integer                  default 1     not null,
+    last_error      text                                   not null,
+    created_at      timestamp with time zone default now() not null,
+    last_attempt_at timestamp with time zone default now() not null
+);
+
+create index users_created_at_id_idx
+    on public.users (created_at, id);
+
+create index products_created_at_id_idx
+    on public.products (created_at, id);
+
+create table public.digest_buffer
+(
+    id          bigserial
+        primary key,
+    digest      varchar(255)                           not null,
+    group_key   text                                   not null,
+    event_id    varchar(255)                           not null,
+    event_name  varchar(255)                           not null,
+    payload     jsonb                                  not null,
+    buffered_at timestamp with time zone default now() not null
+);
+
+create index digest_buffer_digest_group_key_idx
+    on public.digest_buffer (digest, group_key, id);
+
+create table public.entity_events
+(
+    id             bigserial
+        primary key,
+    aggregate_type varchar(255)                           not null,
+    aggregate_id   varchar(255)                           not null,
+    event_id       varchar(255)                           not null,
+    event_name     varchar(255)                           not null,
+    metadata       jsonb                                  not null,
+    payload        bytea                                  not null,
+    published_at   timestamp with time zone default now() not null
+);
+
+create index entity_events_aggregate_idx
+    on public.entity_events (aggregate_type, aggregate_id, id);
+
+create index products_price_id_idx
+    on public.products (price, id);
+
+create index products_name_id_idx
+    on public.products (name, id);
+
+create index products_updated_at_id_idx
+    on public.products (updated_at, id);
+
+create index products_status_idx
+    on public.products (status);
+
+create table public.webhook_deliveries
+(
+    id           uuid                                   not null
+        primary key,
+    provider     varchar(50)                            not null,
+    event_id     varchar(255)                           not null,
+    event_type   varchar(255)                           not null,
+    payload      bytea                                  not null,
+    status       varchar(20) default 'pending'::character varying not null,
+    deliveries   integer     default 1                  not null,
+    attempts     integer     default 0                  not null,
+    error        text,
+    received_at  timestamp with time zone default now() not null,
+    processed_at timestamp with time zone,
+    constraint webhook_deliveries_provider_event_id_key
+        unique (provider, event_id)
+);
+
+create table public.jobs
+(
+    id          uuid                                   not null
+        primary key,
+    kind        varchar(50)                            not null,
+    status      varchar(20) default 'pending'::character varying not null,
+    processed   bigint      default 0                  not null,
+    total       bigint,
+    attempts    integer     default 0                  not null,
+    error       text,
+    result_ref  text,
+    created_at  timestamp with time zone default now() not null,
+    started_at  timestamp with time zone,
+    finished_at timestamp with time zone,
+    updated_at  timestamp with time zone default now() not null
+);
+
+create index jobs_created_at_id_idx
+    on public.jobs (created_at, id);
+
+create table public.archive_manifests
+(
+    id              bigserial
+        primary key,
+    source          varchar(50)                            not null,
+    object_key      text                                   not null
+        constraint archive_manifests_object_key_key
+            unique,
+    first_id        bigint                                 not null,
+    last_id         bigint                                 not null,
+    row_count       bigint                                 not null,
+    oldest_at       timestamp with time zone               not null,
+    newest_at       timestamp with time zone               not null,
+    size_bytes      bigint                                 not null,
+    checksum        bytea                                  not null,
+    first_prev_hash bytea,
+    last_hash       bytea,
+    archived_at     timestamp with time zone default now() not null,
+    restored_at     timestamp with time zone
+);
+
+create index archive_manifests_source_last_id_idx
+    on public.archive_manifests (source, last_id);
+
+create table public.email_templates
+(
+    name       varchar(100)                           not null,
+    version    integer                                not null,
+    subject    text                                   not null,
+    html_body  text        default ''::text           not null,
+    text_body  text        default ''::text           not null,
+    created_at timestamp with time zone default now() not null,
+    primary key (name, version)
 );
 
+create table public.outbound_calls
+(
+    idempotency_key varchar(64)                            not null
+        primary key,
+    event_id        varchar(255)                           not null,
+    handler         varchar(255)                           not null,
+    call            varchar(100)                           not null,
+    result          bytea                                  not null,
+    called_at       timestamp with time zone default now() not null
+);
+
+create index outbound_calls_called_at_idx
+    on public.outbound_calls (called_at);
+
+create table public.data_migrations
+(
+    name                 varchar(100)                           not null
+        primary key,
+    backfill_cursor      text,
+    rows_copied          bigint                   default 0     not null,
+    backfill_started_at  timestamp with time zone,
+    backfill_finished_at timestamp with time zone,
+    rows_verified        bigint                   default 0     not null,
+    mismatched_chunks    integer                  default 0     not null,
+    verified_at          timestamp with time zone,
+    updated_at           timestamp with time zone default now() not null
+);
@@ -0,0 +1,16 @@
+// Package docs embeds the generated API documentation the HTTP gateway
+// serves: the OpenAPI specs buf generates here, the AsyncAPI documents of
+// cmd/asyncapigen and the Swagger UI assets of `make swagger-ui`. Documents
+// generated after a build are served once the binary is rebuilt.
+package docs
+
+import "embed"
+
+// FS holds the generated documents only. The build fails until `make
+// generate` wrote the OpenAPI specs and the Swagger UI assets, which the
+// generate stage of the Dockerfile does for image builds; AsyncAPI documents
+// are written per release by `make asyncapi`, so their directory is kept in
+// the repository.
+//
+//go:embed */*/*.swagger.json swagger-ui/swagger-ui.css swagger-ui/swagger-ui-bundle.js all:asyncapi
+var FS embed.FS
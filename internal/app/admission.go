@@ -0,0 +1,63 @@
+package app
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/erry-az/go-init/internal/server/interceptor"
+	"github.com/erry-az/go-init/pkg/admission"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// initAdmission creates the admission queues of servers.admission, one per
+// usecase behind an API service
+func (a *App) initAdmission() {
+	cfg := a.config.Servers.Admission
+
+	usecases := apiUsecases()
+	var names []string
+	for _, name := range usecases {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	overrides := make(map[string]admission.Limits, len(cfg.Usecases))
+	for name, limits := range cfg.Usecases {
+		if !slices.Contains(names, name) {
+			slog.Warn("Ignoring admission limits of unknown usecase", "usecase", name, "usecases", names)
+			continue
+		}
+		overrides[name] = admission.Limits{
+			MaxInFlight: limits.MaxInFlight,
+			MaxQueue:    limits.MaxQueue,
+			MaxWait:     limits.MaxWait,
+		}
+	}
+
+	limits := admission.Limits{MaxInFlight: cfg.MaxInFlight, MaxQueue: cfg.MaxQueue, MaxWait: cfg.MaxWait}
+	a.admission = &interceptor.AdmissionOptions{
+		Controller: admission.NewController(names, limits, overrides),
+		Usecases:   usecases,
+	}
+
+	slog.Info("Admission control enabled", "usecases", names, "max_in_flight", cfg.MaxInFlight, "max_queue", cfg.MaxQueue)
+}
+
+// apiUsecases maps the method prefix of every API service to the usecase it
+// calls into, named after the service, e.g. user for UserService
+func apiUsecases() map[string]string {
+	usecases := make(map[string]string)
+	protoregistry.GlobalFiles.RangeFilesByPackage(apiProtoPackage, func(file protoreflect.FileDescriptor) bool {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			service := services.Get(i)
+			name := strings.ToLower(strings.TrimSuffix(string(service.Name()), "Service"))
+			usecases["/"+string(service.FullName())+"/"] = name
+		}
+		return true
+	})
+	return usecases
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/archive"
+)
+
+// initArchive schedules moving old rows of every archived table to blob storage
+func (a *App) initArchive() error {
+	cfg := a.config.Archive
+	store, err := archive.OpenStore(context.Background(), archive.StoreOptions{
+		URL:      cfg.StorageURL,
+		Region:   cfg.StorageRegion,
+		Endpoint: cfg.StorageEndpoint,
+	})
+	if err != nil {
+		return fmt.Errorf("archive.storage_url: %w", err)
+	}
+
+	archiver := archive.New(a.dbPool, store, archive.Options{
+		Retention:  cfg.Retention,
+		BatchSize:  cfg.BatchSize,
+		MaxBatches: cfg.MaxBatches,
+	})
+	for _, source := range cfg.Sources {
+		a.scheduler.Every("archive_"+source, cfg.Interval, func(ctx context.Context) error {
+			_, err := archiver.Run(ctx, source)
+			return err
+		})
+	}
+
+	slog.Info("Archive enabled", "sources", cfg.Sources, "retention", cfg.Retention)
+	return nil
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/handler/consumer"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/usecase"
+)
+
+// initAsyncWrites creates the operation usecase queuing writes as commands and
+// registers their processing on the command subscriber of this instance.
+// Operations are kept in the main database so they can be polled without a
+// tenant, in its sandbox schema for sandbox requests.
+func (a *App) initAsyncWrites() error {
+	a.OperationUsecase = usecase.NewOperationUsecase(sqlc.New(a.mainDB()), a.commandBus, a.UserUsecase, a.ProductUsecase)
+
+	subscriber, err := a.commandSubscriber()
+	if err != nil {
+		return err
+	}
+
+	err = subscriber.RegisterCommandHandlers(consumer.NewOperationConsumer(a.OperationUsecase).AddHandlers)
+	if err != nil {
+		slog.Error("Failed to register command handlers", slog.Any("error", err))
+		return err
+	}
+
+	slog.Info("Async writes enabled")
+	return nil
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/erry-az/go-init/internal/auth"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/server/interceptor"
+	"github.com/erry-az/go-init/proto/api/v1"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// jwksStartupTimeout bounds fetching the signing keys at boot
+const jwksStartupTimeout = 10 * time.Second
+
+// initJWT creates the verifier of servers.auth.jwt. The signing keys are
+// fetched at boot so a wrong JWKS URL shows in the logs straight away, but the
+// identity provider being down only warns: the keys are fetched again on the
+// first token.
+func (a *App) initJWT() error {
+	cfg := a.config.Servers.Auth.JWT
+
+	keys := auth.NewKeySet(cfg.JWKSURL, auth.KeySetOptions{RefreshInterval: cfg.JWKSRefreshInterval})
+	verifier, err := auth.NewVerifier(keys, auth.VerifierOptions{
+		Issuer:      cfg.Issuer,
+		Audiences:   cfg.Audiences,
+		Algorithms:  cfg.Algorithms,
+		Leeway:      cfg.Leeway,
+		ScopesClaim: cfg.ScopesClaim,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, jwksStartupTimeout)
+	defer cancel()
+	if err := keys.Refresh(ctx); err != nil {
+		slog.Warn("Failed to fetch JWKS, retrying on the first token", "url", cfg.JWKSURL, slog.Any("error", err))
+	}
+
+	a.jwt = verifier
+	slog.Info("JWT authentication enabled", "issuer", cfg.Issuer)
+	return nil
+}
+
+// initRBAC loads the policy of servers.auth.rbac. Callers are the principals
+// of the jwt interceptor, their stored roles those of the users their token
+// subjects name.
+func (a *App) initRBAC() error {
+	cfg := a.config.Servers.Auth.RBAC
+	if a.jwt == nil {
+		return errors.New("servers.auth.rbac needs servers.auth.jwt to authenticate callers")
+	}
+
+	policy, err := auth.LoadPolicy(cfg.PolicyFile)
+	if err != nil {
+		return err
+	}
+
+	queries := sqlc.New(a.dbPool)
+	roles := auth.NewRoleResolver(func(ctx context.Context, subject string) (domain.Role, bool, error) {
+		id, err := uuid.Parse(subject)
+		if err != nil {
+			// Subjects of other identities, e.g. service accounts, name no user
+			return "", false, nil
+		}
+		role, err := queries.GetUserRole(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return domain.Role(role), true, nil
+	}, auth.RoleResolverOptions{
+		Claim:    cfg.RoleClaim,
+		Default:  domain.Role(cfg.DefaultRole),
+		CacheTTL: cfg.RoleCacheTTL,
+	})
+
+	a.rbac = &interceptor.RBACOptions{Policy: policy, Roles: roles}
+	slog.Info("RBAC enabled", "policy_file", cfg.PolicyFile, "rules", len(policy.Rules))
+	return nil
+}
+
+// authorizationRules reads the (proto.api.v1.authorization) annotations of
+// the public API RPCs, keyed by full method name
+func authorizationRules() map[string]auth.Rule {
+	rules := make(map[string]auth.Rule)
+	protoregistry.GlobalFiles.RangeFilesByPackage(apiProtoPackage, func(file protoreflect.FileDescriptor) bool {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			service := services.Get(i)
+			methods := service.Methods()
+			for j := 0; j < methods.Len(); j++ {
+				method := methods.Get(j)
+				opts, ok := method.Options().(*descriptorpb.MethodOptions)
+				if !ok || !proto.HasExtension(opts, v1.E_Authorization) {
+					continue
+				}
+				annotation := proto.GetExtension(opts, v1.E_Authorization).(*v1.Authorization)
+				rules["/"+string(service.FullName())+"/"+string(method.Name())] = auth.Rule{
+					Public: annotation.GetPublic(),
+					Scopes: annotation.GetScopes(),
+				}
+			}
+		}
+		return true
+	})
+	return rules
+}
+
+// apiKeys lists the API keys callers may send: those of servers.auth and,
+// as they authenticate their callers like the others, the sandbox keys
+func (a *App) apiKeys() []string {
+	keys := a.config.Servers.Auth.APIKeys
+	if a.sandbox != nil {
+		keys = append(slices.Clone(keys), a.config.Sandbox.APIKeys...)
+	}
+	return keys
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/pkg/watmil"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newBroker creates the configured event transport, failing over to standbys
+// when enabled. pool backs the SQL broker.
+func newBroker(ctx context.Context, cfg config.EventConfig, pool *pgxpool.Pool) (watmil.Broker, error) {
+	broker, err := newPrimaryBroker(ctx, cfg, pool)
+	if err != nil || !cfg.Failover.Enabled {
+		return broker, err
+	}
+
+	members := []watmil.FailoverMember{{Name: "primary", Broker: broker}}
+	for i, standby := range cfg.Failover.Standbys {
+		standbyBroker, err := newStandbyBroker(ctx, cfg, standby)
+		if err != nil {
+			return nil, fmt.Errorf("standby %d: %w", i+1, err)
+		}
+		members = append(members, watmil.FailoverMember{Name: fmt.Sprintf("standby_%d", i+1), Broker: standbyBroker})
+	}
+
+	return watmil.NewFailoverBroker(members, watmil.FailoverOptions{
+		Interval:  cfg.Failover.CheckInterval,
+		Timeout:   cfg.Failover.CheckTimeout,
+		Threshold: cfg.Failover.FailureThreshold,
+		OnFailover: func(from, to string, cause error) {
+			slog.Warn("Event broker failed over", "from", from, "to", to, slog.Any("error", cause))
+		},
+	})
+}
+
+// newPrimaryBroker creates the configured broker itself
+func newPrimaryBroker(ctx context.Context, cfg config.EventConfig, pool *pgxpool.Pool) (watmil.Broker, error) {
+	switch cfg.BrokerType() {
+	case config.BrokerSQL:
+		return watmil.SQLBroker{Pool: pool}, nil
+	// template:begin sqs
+	case config.BrokerSQS:
+		return watmil.NewSQSBroker(ctx, watmil.SQSOptions{
+			Region:            cfg.SQS.Region,
+			Endpoint:          cfg.SQS.Endpoint,
+			QueuePrefix:       cfg.SQS.QueuePrefix,
+			FIFO:              cfg.SQS.FIFO,
+			AutoProvision:     cfg.SQS.AutoProvision,
+			VisibilityTimeout: cfg.SQS.VisibilityTimeout,
+			WaitTime:          cfg.SQS.WaitTime,
+		})
+	// template:end sqs
+	// template:begin pubsub
+	case config.BrokerPubSub:
+		return watmil.NewPubSubBroker(ctx, watmil.PubSubOptions{
+			ProjectID:           cfg.PubSub.ProjectID,
+			SubscriptionPrefix:  cfg.PubSub.SubscriptionPrefix,
+			Ordering:            cfg.PubSub.Ordering,
+			ExactlyOnce:         cfg.PubSub.ExactlyOnce,
+			MaxDeliveryAttempts: cfg.PubSub.MaxDeliveryAttempts,
+			AckDeadline:         cfg.PubSub.AckDeadline,
+			AutoProvision:       cfg.PubSub.AutoProvision,
+		})
+	// template:end pubsub
+	default:
+		return nil, fmt.Errorf("unknown event broker %q", cfg.Broker)
+	}
+}
+
+// newStandbyBroker creates a broker like the configured one at standby, a
+// PostgreSQL DSN for the sql broker or an AWS region for the sqs broker
+func newStandbyBroker(ctx context.Context, cfg config.EventConfig, standby string) (watmil.Broker, error) {
+	switch cfg.BrokerType() {
+	case config.BrokerSQL:
+		pool, err := pgxpool.New(ctx, standby)
+		if err != nil {
+			return nil, fmt.Errorf("create pool: %w", err)
+		}
+		return watmil.SQLBroker{Pool: pool}, nil
+	// template:begin sqs
+	case config.BrokerSQS:
+		return watmil.NewSQSBroker(ctx, watmil.SQSOptions{
+			Region:            standby,
+			Endpoint:          cfg.SQS.Endpoint,
+			QueuePrefix:       cfg.SQS.QueuePrefix,
+			FIFO:              cfg.SQS.FIFO,
+			AutoProvision:     cfg.SQS.AutoProvision,
+			VisibilityTimeout: cfg.SQS.VisibilityTimeout,
+			WaitTime:          cfg.SQS.WaitTime,
+		})
+	// template:end sqs
+	default:
+		return nil, fmt.Errorf("event broker %q does not support failover", cfg.BrokerType())
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/handler/consumer"
+	"github.com/erry-az/go-init/internal/repository"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/lazy"
+)
+
+// newCache caches the reads of querier. Eagerly initialized, the cache serves
+// reads from boot; lazily, only once its invalidation subscription consumes,
+// reading from the database and reporting the cache degraded until then.
+func (a *App) newCache(querier sqlc.Querier) (*repository.CachedQuerier, error) {
+	cached := repository.NewCachedQuerier(querier, a.config.Cache.TTLs)
+	if !a.config.Cache.Invalidation.Enabled {
+		return cached, nil
+	}
+
+	if err := a.initCacheInvalidation(cached); err != nil {
+		slog.Error("Failed to initialize cache invalidation", slog.Any("error", err))
+		return nil, err
+	}
+
+	if a.config.Startup.Lazy(config.ComponentCache) {
+		slog.Info("Cache will serve reads once its invalidation subscription runs")
+		subscription := lazy.New(config.ComponentCache, func() (struct{}, error) {
+			if !a.commands.IsRunning() {
+				return struct{}{}, errors.New("cache invalidation subscription is not running")
+			}
+			return struct{}{}, nil
+		})
+		cached.SetReady(func() bool {
+			_, err := subscription.Get()
+			return err == nil
+		})
+	}
+	return cached, nil
+}
+
+// initCacheInvalidation subscribes this instance to the product and user
+// events so its cache stops serving versions written through other instances
+func (a *App) initCacheInvalidation(cache *repository.CachedQuerier) error {
+	cfg := a.config.Cache.Invalidation
+
+	// The sql broker shares its subscriptions between handlers, so the
+	// instance would take events away from the consumer instead
+	if broker := a.config.Events.BrokerType(); broker == config.BrokerSQL {
+		return fmt.Errorf("cache.invalidation needs a broker with a subscription per handler, not %s", broker)
+	}
+
+	instance, err := instanceID(cfg.InstanceID, "cache.invalidation.instance_id")
+	if err != nil {
+		return err
+	}
+
+	subscriber, err := a.commandSubscriber()
+	if err != nil {
+		return err
+	}
+
+	err = subscriber.RegisterHandlers(consumer.NewCacheConsumer(cache, instance).AddHandlers)
+	if err != nil {
+		slog.Error("Failed to register cache invalidation handlers", slog.Any("error", err))
+		return err
+	}
+
+	slog.Info("Cache invalidation enabled", "instance", instance)
+	return nil
+}
+
+// instanceID returns configured, or the hostname when unset, to name the
+// subscriptions of this instance by; key is the setting configured is read from
+func instanceID(configured, key string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("%s is unset and the hostname is unknown: %w", key, err)
+	}
+	return hostname, nil
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/handler/consumer"
+	"github.com/erry-az/go-init/internal/usecase"
+)
+
+// initChangeFeeds subscribes this instance to the product and user events
+// and fans them out to the callers of WatchUsers and WatchProducts
+func (a *App) initChangeFeeds() error {
+	cfg := a.config.Events.ChangeFeeds
+
+	// The sql broker shares its subscriptions between handlers, so the
+	// instance would take events away from the consumer instead
+	if broker := a.config.Events.BrokerType(); broker == config.BrokerSQL {
+		return fmt.Errorf("events.change_feeds needs a broker with a subscription per handler, not %s", broker)
+	}
+
+	instance, err := instanceID(cfg.InstanceID, "events.change_feeds.instance_id")
+	if err != nil {
+		return err
+	}
+
+	subscriber, err := a.commandSubscriber()
+	if err != nil {
+		return err
+	}
+
+	feed := usecase.NewChangeFeedUsecase(cfg.BufferSize)
+	err = subscriber.RegisterHandlers(consumer.NewChangeFeedConsumer(feed, instance).AddHandlers)
+	if err != nil {
+		slog.Error("Failed to register change feed handlers", slog.Any("error", err))
+		return err
+	}
+	a.ChangeFeedUsecase = feed
+
+	slog.Info("Change feeds enabled", "instance", instance)
+	return nil
+}
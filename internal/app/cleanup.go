@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/cleanup"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/watmil"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// initCleanup schedules every cleanup job that applies and is not disabled.
+// Consumed events are only kept in the database by the SQL broker.
+func (a *App) initCleanup() {
+	cfg := a.config.Cleanup
+	cleaner := cleanup.New(a.scheduler, cleanup.Options{
+		Interval:   cfg.Interval,
+		BatchSize:  cfg.BatchSize,
+		MaxBatches: cfg.MaxBatches,
+		DryRun:     cfg.DryRun,
+	})
+
+	jobs := map[string]func(retention time.Duration) cleanup.BatchFunc{
+		config.CleanupExpiredEmailChanges: a.cleanupExpiredEmailChanges,
+		config.CleanupProcessedInbox:      a.cleanupProcessedInbox,
+		config.CleanupOutboundCalls:       a.cleanupOutboundCalls,
+	}
+	if deleters := consumedDeleters(a.broker); len(deleters) > 0 {
+		groups := append([]string{watmil.DefaultConsumerGroup}, cfg.ConsumerGroups...)
+		jobs[config.CleanupConsumedEvents] = cleanupConsumedEvents(deleters, groups)
+	}
+
+	for name, newJob := range jobs {
+		jobCfg := cfg.Jobs[name]
+		if jobCfg.Disabled {
+			continue
+		}
+		cleaner.Register(name, newJob(jobCfg.Retention), cleanup.Options{
+			Interval:   jobCfg.Interval,
+			BatchSize:  jobCfg.BatchSize,
+			MaxBatches: jobCfg.MaxBatches,
+			DryRun:     jobCfg.DryRun,
+		})
+	}
+}
+
+// cleanupExpiredEmailChanges removes email change requests that expired
+// longer than retention ago, in every region database
+func (a *App) cleanupExpiredEmailChanges(retention time.Duration) cleanup.BatchFunc {
+	return func(ctx context.Context, limit int, dryRun bool) (int64, error) {
+		expiredBefore := pgtype.Timestamptz{Time: time.Now().Add(-retention), Valid: true}
+
+		var total int64
+		for _, pool := range a.cleanupPools() {
+			remaining := int64(limit) - total
+			if remaining <= 0 {
+				break
+			}
+
+			db := sqlc.New(pool)
+			if dryRun {
+				n, err := db.CountExpiredEmailChangeRequests(ctx, expiredBefore)
+				if err != nil {
+					return total, err
+				}
+				total += min(n, remaining)
+				continue
+			}
+
+			n, err := db.DeleteExpiredEmailChangeRequests(ctx, sqlc.DeleteExpiredEmailChangeRequestsParams{
+				ExpiredBefore: expiredBefore,
+				BatchSize:     int32(remaining),
+			})
+			total += n
+			if err != nil {
+				return total, err
+			}
+		}
+		return total, nil
+	}
+}
+
+// cleanupProcessedInbox removes the inbox entries of events consumers
+// processed longer than retention ago. Retention must outlast redelivery, or
+// a late duplicate is handled again.
+func (a *App) cleanupProcessedInbox(retention time.Duration) cleanup.BatchFunc {
+	return func(ctx context.Context, limit int, dryRun bool) (int64, error) {
+		processedBefore := pgtype.Timestamptz{Time: time.Now().Add(-retention), Valid: true}
+
+		db := sqlc.New(a.dbPool)
+		if dryRun {
+			n, err := db.CountProcessedInboxMessages(ctx, processedBefore)
+			return min(n, int64(limit)), err
+		}
+
+		return db.DeleteProcessedInboxMessages(ctx, sqlc.DeleteProcessedInboxMessagesParams{
+			ProcessedBefore: processedBefore,
+			BatchSize:       int32(limit),
+		})
+	}
+}
+
+// cleanupOutboundCalls removes the results of external API calls made
+// longer than retention ago. Like the inbox, retention must outlast
+// redelivery, or a late duplicate calls again.
+func (a *App) cleanupOutboundCalls(retention time.Duration) cleanup.BatchFunc {
+	return func(ctx context.Context, limit int, dryRun bool) (int64, error) {
+		calledBefore := pgtype.Timestamptz{Time: time.Now().Add(-retention), Valid: true}
+
+		db := sqlc.New(a.dbPool)
+		if dryRun {
+			n, err := db.CountOutboundCalls(ctx, calledBefore)
+			return min(n, int64(limit)), err
+		}
+
+		return db.DeleteOutboundCalls(ctx, sqlc.DeleteOutboundCallsParams{
+			CalledBefore: calledBefore,
+			BatchSize:    int32(limit),
+		})
+	}
+}
+
+// consumedDeleters returns the brokers keeping consumed events, including the
+// standbys of a failover broker, which keep the events published while active
+func consumedDeleters(broker watmil.Broker) []watmil.ConsumedDeleter {
+	brokers := []watmil.Broker{broker}
+	if failover, ok := broker.(*watmil.FailoverBroker); ok {
+		brokers = failover.Brokers()
+	}
+
+	var deleters []watmil.ConsumedDeleter
+	for _, broker := range brokers {
+		if deleter, ok := broker.(watmil.ConsumedDeleter); ok {
+			deleters = append(deleters, deleter)
+		}
+	}
+	return deleters
+}
+
+// cleanupConsumedEvents removes event rows every consumer group processed
+// longer than retention ago from each of deleters, once every group of groups
+// read them
+func cleanupConsumedEvents(deleters []watmil.ConsumedDeleter, groups []string) func(retention time.Duration) cleanup.BatchFunc {
+	return func(retention time.Duration) cleanup.BatchFunc {
+		return func(ctx context.Context, limit int, dryRun bool) (int64, error) {
+			olderThan := time.Now().Add(-retention)
+
+			var total int64
+			for _, deleter := range deleters {
+				remaining := int64(limit) - total
+				if remaining <= 0 {
+					break
+				}
+				n, err := deleter.DeleteConsumed(ctx, olderThan, groups, int(remaining), dryRun)
+				total += n
+				if err != nil {
+					return total, err
+				}
+			}
+			return total, nil
+		}
+	}
+}
+
+// cleanupPools returns the main pool and every distinct region pool
+func (a *App) cleanupPools() []*pgxpool.Pool {
+	pools := []*pgxpool.Pool{a.dbPool}
+	for _, pool := range a.regionPools {
+		if pool != a.dbPool {
+			pools = append(pools, pool)
+		}
+	}
+	return pools
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/outbound"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/watmil"
+)
+
+// commandSubscriber returns the subscriber processing commands in this
+// instance, and the events it observes to invalidate its cache, created on
+// first use
+func (a *App) commandSubscriber() (*watmil.Subscriber, error) {
+	if a.commands != nil {
+		return a.commands, nil
+	}
+
+	// Event metrics are recorded by the consumer, which handles the events
+	subscriber, err := watmil.NewSubscriber(a.broker, a.logger, a.encryption, nil,
+		reportHandlerPanics(a.reporter),
+		a.config.Consumers.Retry.MiddlewareRetry(a.logger).Middleware,
+		outbound.New(sqlc.New(a.dbPool)).Middleware)
+	if err != nil {
+		slog.Error("Failed to create command subscriber", slog.Any("error", err))
+		return nil, err
+	}
+	a.commands = subscriber
+	return subscriber, nil
+}
@@ -2,27 +2,73 @@ package app
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/audit"
+	"github.com/erry-az/go-init/internal/errreport"
+	"github.com/erry-az/go-init/internal/eventhistory"
 	"github.com/erry-az/go-init/internal/handler/consumer"
+	"github.com/erry-az/go-init/internal/inbox"
+	"github.com/erry-az/go-init/internal/mail"
+	"github.com/erry-az/go-init/internal/outbound"
+	"github.com/erry-az/go-init/internal/publishretry"
+	"github.com/erry-az/go-init/internal/repository"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/residency"
+	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/internal/watchdog"
+	"github.com/erry-az/go-init/pkg/metrics"
+	"github.com/erry-az/go-init/pkg/pagetoken"
 	"github.com/erry-az/go-init/pkg/watmil"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// subscriberStartTimeout bounds how long a restarted subscriber may take to start running
+const subscriberStartTimeout = 30 * time.Second
+
 // ConsumerApp represents the consumer application
 type ConsumerApp struct {
 	ProductConsumer *consumer.ProductConsumer
 	UserConsumer    *consumer.UserConsumer
-	Subscriber      *watmil.Subscriber
+	JobConsumer     *consumer.JobConsumer
+	// AuditConsumer is nil unless the audit trail is enabled
+	AuditConsumer *consumer.AuditConsumer
+	// DigestConsumer is nil unless event digests are enabled
+	DigestConsumer *consumer.DigestConsumer
+	// NotificationConsumer is nil unless notifications are enabled
+	NotificationConsumer *consumer.NotificationConsumer
+	Subscriber           *watmil.Subscriber
+
+	config   *config.Config
+	dbPool   *pgxpool.Pool
+	dataPool *pgxpool.Pool
+	// regionPools are the pools of residency regions, nil unless residency is enabled
+	regionPools map[string]*pgxpool.Pool
+	broker      watmil.Broker
+	encryption  *watmil.PayloadEncryption
+	logger      watermill.LoggerAdapter
+	reporter    *errreport.Reporter
+	// outbound records the results of the external API calls of handlers
+	outbound *outbound.Recorder
+	// metrics and eventMetrics are nil unless metrics are enabled
+	metrics      *metrics.Registry
+	eventMetrics *watmil.EventMetrics
 }
 
 // NewConsumerApp creates a new consumer application with all dependencies
 func NewConsumerApp(cfg *config.Config) (*ConsumerApp, error) {
-	// Create consumers
-	productConsumer := consumer.NewProductConsumer()
-	userConsumer := consumer.NewUserConsumer()
+	reporter, err := newReporter(cfg.ErrorReporting)
+	if err != nil {
+		slog.Error("Failed to create error reporter", slog.Any("error", err))
+		return nil, err
+	}
 
 	// Create pgxpool connection for SQLC
 	dbPool, err := pgxpool.New(context.Background(), cfg.Databases.PgMqUrl)
@@ -30,41 +76,303 @@ func NewConsumerApp(cfg *config.Config) (*ConsumerApp, error) {
 		slog.Error("Failed to create pgx pool ", slog.Any("error", err))
 		return nil, err
 	}
-	defer dbPool.Close()
 
 	// Test pgxpool connection
 	if err := dbPool.Ping(context.Background()); err != nil {
 		slog.Error("Failed to ping database pool ", slog.Any("error", err))
+		dbPool.Close()
+		return nil, err
+	}
+
+	broker, err := newBroker(context.Background(), cfg.Events, dbPool)
+	if err != nil {
+		slog.Error("Failed to create event broker", slog.Any("error", err))
+		dbPool.Close()
 		return nil, err
 	}
 
 	logger := watermill.NewSlogLogger(slog.Default())
 
-	subscriber, err := watmil.NewSubscriber(dbPool, logger,
-		cfg.Consumers.Retry.MiddlewareRetry(logger).Middleware)
+	dataPool, err := repository.NewPool(context.Background(), cfg.Databases.DbDsn, poolOptions(cfg.Databases))
+	if err != nil {
+		slog.Error("Failed to create data pool", slog.Any("error", err))
+		dbPool.Close()
+		return nil, err
+	}
+
+	encryption, err := newPayloadEncryption(cfg.Events.Encryption)
+	if err != nil {
+		slog.Error("Failed to create event payload encryption", slog.Any("error", err))
+		dataPool.Close()
+		dbPool.Close()
+		return nil, err
+	}
+
+	var history watmil.HistoryRecorder
+	if cfg.Events.History.Enabled {
+		history = eventhistory.New(dataPool, encryption)
+	}
+
+	// Command handlers publish events, e.g. one per product during a reindex;
+	// events the broker does not accept are stored for the endpoint to retry
+	publisher, err := watmil.NewPublisher(broker, logger, watmil.TTLPolicy{
+		Default: cfg.Events.DefaultTTL,
+		Events:  cfg.Events.TTLs,
+	}, encryption, newPublishFailurePolicy(cfg.Events.PublishFailure), publishretry.New(dataPool), history)
+	if err != nil {
+		slog.Error("Failed to create event publisher", slog.Any("error", err))
+		dataPool.Close()
+		dbPool.Close()
+		return nil, err
+	}
+
+	defaultLocale, err := cfg.Product.Locale()
 	if err != nil {
-		slog.Error("Failed to subscribe to SQL database", slog.Any("error", err))
+		slog.Error("Failed to configure product locales", slog.Any("error", err))
+		dataPool.Close()
 		dbPool.Close()
 		return nil, err
 	}
 
-	return &ConsumerApp{
-		ProductConsumer: productConsumer,
-		UserConsumer:    userConsumer,
-		Subscriber:      subscriber,
-	}, nil
+	// Products and users live in the region of their tenant, which handlers
+	// take from the message; jobs and the inbox stay on the main database
+	var regionDB sqlc.DBTX = dataPool
+	var regionPools map[string]*pgxpool.Pool
+	if cfg.Residency.Enabled {
+		regionPools, err = openRegionPools(context.Background(), cfg, dataPool)
+		if err != nil {
+			dataPool.Close()
+			dbPool.Close()
+			return nil, err
+		}
+		regionDB = repository.NewRegionRouter(residency.NewResolver(cfg.Residency), regionPools)
+	}
+
+	productUsecase := usecase.NewProductUsecase(sqlc.New(regionDB), repository.NewProductFilter(regionDB), publisher, nil, pagetoken.Codec{}, defaultLocale)
+	jobUsecase := usecase.NewJobUsecase(sqlc.New(dataPool), nil, productUsecase, pagetoken.Codec{})
+
+	// Handlers with side effects record the events they processed alongside the data they own
+	processed := inbox.New(dataPool)
+
+	app := &ConsumerApp{
+		// Create consumers
+		ProductConsumer: consumer.NewProductConsumer(productUsecase, processed),
+		UserConsumer:    consumer.NewUserConsumer(processed),
+		JobConsumer:     consumer.NewJobConsumer(jobUsecase),
+		config:          cfg,
+		dbPool:          dbPool,
+		dataPool:        dataPool,
+		regionPools:     regionPools,
+		broker:          broker,
+		encryption:      encryption,
+		logger:          logger,
+		reporter:        reporter,
+		outbound:        outbound.New(sqlc.New(dataPool)),
+	}
+
+	if cfg.Consumers.Audit.Enabled {
+		app.AuditConsumer = consumer.NewAuditConsumer(audit.New(dataPool))
+	}
+
+	if cfg.Consumers.Digest.Enabled {
+		app.DigestConsumer, err = consumer.NewDigestConsumer(dataPool, publisher, processed, newDigestRules(cfg.Consumers.Digest.Rules))
+		if err != nil {
+			slog.Error("Failed to create digest consumer", slog.Any("error", err))
+			closeRegionPools(regionPools, dataPool)
+			dataPool.Close()
+			dbPool.Close()
+			return nil, err
+		}
+	}
+
+	if cfg.Consumers.Notifications.Enabled {
+		mailer, err := newMailer(cfg.Consumers.Notifications)
+		if err != nil {
+			slog.Error("Failed to create mailer", slog.Any("error", err))
+			closeRegionPools(regionPools, dataPool)
+			dataPool.Close()
+			dbPool.Close()
+			return nil, err
+		}
+		app.NotificationConsumer = consumer.NewNotificationConsumer(usecase.NewEmailTemplateUsecase(sqlc.New(dataPool)), sqlc.New(regionDB), mailer, processed)
+	}
+
+	if cfg.Metrics.Enabled {
+		app.initMetrics()
+	}
+
+	subscriber, err := app.newSubscriber()
+	if err != nil {
+		closeRegionPools(regionPools, dataPool)
+		dataPool.Close()
+		dbPool.Close()
+		return nil, err
+	}
+	app.Subscriber = subscriber
+
+	return app, nil
 }
 
-// Run starts the consumer application
-func (app *ConsumerApp) Run(ctx context.Context) error {
-	err := app.Subscriber.RegisterHandlers(
+// newMailer creates the mailer sending notifications, logging them while no
+// SMTP server is configured
+func newMailer(cfg config.NotificationsConfig) (mail.Mailer, error) {
+	if cfg.SMTPAddr == "" {
+		return mail.LogMailer{}, nil
+	}
+	return mail.NewSMTPMailer(mail.SMTPOptions{
+		Addr:     cfg.SMTPAddr,
+		From:     cfg.From,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+	})
+}
+
+// newSubscriber creates a subscriber on the configured broker with every consumer handler registered
+func (app *ConsumerApp) newSubscriber() (*watmil.Subscriber, error) {
+	subscriber, err := watmil.NewSubscriber(app.broker, app.logger, app.encryption, app.eventMetrics,
+		reportHandlerPanics(app.reporter),
+		app.config.Consumers.Retry.MiddlewareRetry(app.logger).Middleware,
+		app.outbound.Middleware)
+	if err != nil {
+		slog.Error("Failed to create subscriber", slog.Any("error", err))
+		return nil, err
+	}
+
+	handlers := []func(eventProcessor *cqrs.EventProcessor) error{
 		app.ProductConsumer.AddHandlers,
 		app.UserConsumer.AddHandlers,
-	)
+	}
+	if app.AuditConsumer != nil {
+		handlers = append(handlers, app.AuditConsumer.AddHandlers)
+	}
+	if app.DigestConsumer != nil {
+		handlers = append(handlers, app.DigestConsumer.AddHandlers)
+	}
+	if app.NotificationConsumer != nil {
+		handlers = append(handlers, app.NotificationConsumer.AddHandlers)
+	}
+
+	err = subscriber.RegisterHandlers(handlers...)
 	if err != nil {
 		slog.Error("Failed to register handlers", slog.Any("error", err))
+		return nil, err
+	}
+
+	err = subscriber.RegisterCommandHandlers(
+		app.JobConsumer.AddHandlers,
+	)
+	if err != nil {
+		slog.Error("Failed to register command handlers", slog.Any("error", err))
+		return nil, err
+	}
+
+	return subscriber, nil
+}
+
+// Run starts the consumer application. With the watchdog enabled the
+// subscriber is rebuilt whenever it stops or, on the SQL broker, loses its database.
+func (app *ConsumerApp) Run(ctx context.Context) error {
+	defer app.dbPool.Close()
+	defer app.dataPool.Close()
+	defer closeRegionPools(app.regionPools, app.dataPool)
+
+	if app.reporter != nil {
+		// Outlive ctx so reports of the last messages are still flushed
+		reporterCtx, stopReporter := context.WithCancel(context.Background())
+		reporterDone := make(chan struct{})
+		go func() {
+			defer close(reporterDone)
+			app.reporter.Run(reporterCtx)
+		}()
+		defer func() {
+			stopReporter()
+			<-reporterDone
+		}()
+	}
+
+	if app.metrics != nil {
+		metricsCtx, stopMetrics := context.WithCancel(ctx)
+		defer stopMetrics()
+		go app.serveMetrics(metricsCtx)
+	}
+
+	if failover, ok := app.broker.(*watmil.FailoverBroker); ok {
+		failoverCtx, stopFailover := context.WithCancel(ctx)
+		defer stopFailover()
+		go failover.Run(failoverCtx)
+	}
+
+	if app.DigestConsumer != nil {
+		flushCtx, stopFlush := context.WithCancel(ctx)
+		defer stopFlush()
+		go app.runDigestFlush(flushCtx)
+	}
+
+	if !app.config.Consumers.Watchdog.Enabled {
+		return app.Subscriber.Run(ctx)
+	}
+
+	// The watchdog must not mistake a shutdown for a failure
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	app.startSubscriber(ctx, app.Subscriber)
+
+	supervisor := watchdog.New(watchdog.Options{
+		Interval:   app.config.Consumers.Watchdog.Interval,
+		MinBackoff: app.config.Consumers.Watchdog.MinBackoff,
+		MaxBackoff: app.config.Consumers.Watchdog.MaxBackoff,
+	})
+	supervisor.Watch("event_subscriber", app.checkSubscriber, app.restartSubscriber)
+
+	err := supervisor.Run(ctx)
+	if closeErr := app.Subscriber.Close(); closeErr != nil {
+		slog.Error("Failed to close subscriber", slog.Any("error", closeErr))
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func (app *ConsumerApp) startSubscriber(ctx context.Context, subscriber *watmil.Subscriber) {
+	go func() {
+		if err := subscriber.Run(ctx); err != nil {
+			slog.Error("Subscriber stopped", slog.Any("error", err))
+		}
+	}()
+}
+
+// checkSubscriber fails when the subscriber stopped or its database is unreachable
+func (app *ConsumerApp) checkSubscriber(ctx context.Context) error {
+	if !app.Subscriber.IsRunning() {
+		return errors.New("subscriber is not running")
+	}
+	if _, ok := app.broker.(watmil.SQLBroker); ok {
+		return app.dbPool.Ping(ctx)
+	}
+	return nil
+}
+
+// restartSubscriber replaces the subscriber with a new one and waits for it to start
+func (app *ConsumerApp) restartSubscriber(ctx context.Context) error {
+	if err := app.Subscriber.Close(); err != nil {
+		slog.Warn("Failed to close subscriber", slog.Any("error", err))
+	}
+
+	subscriber, err := app.newSubscriber()
+	if err != nil {
 		return err
 	}
+	app.Subscriber = subscriber
+	app.startSubscriber(ctx, subscriber)
 
-	return app.Subscriber.Run(ctx)
+	select {
+	case <-subscriber.Started():
+		return nil
+	case <-time.After(subscriberStartTimeout):
+		return errors.New("subscriber did not start in time")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
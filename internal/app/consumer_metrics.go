@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/erry-az/go-init/pkg/metrics"
+	"github.com/erry-az/go-init/pkg/watmil"
+)
+
+// initMetrics exposes runtime metrics and records event handling, served on
+// the consumer metrics port
+func (app *ConsumerApp) initMetrics() {
+	app.metrics = metrics.NewRegistry()
+	app.metrics.Register(metrics.RuntimeCollector)
+	app.metrics.Register(metrics.ExpvarCollector)
+	app.eventMetrics = watmil.NewEventMetrics(app.metrics)
+}
+
+// serveMetrics serves /metrics on the consumer metrics port until ctx is cancelled
+func (app *ConsumerApp) serveMetrics(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", app.metrics.Handler())
+	server := &http.Server{
+		Addr:              ":" + app.config.Metrics.ConsumerPort,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	slog.Info("Serving consumer metrics", slog.String("port", app.config.Metrics.ConsumerPort))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Consumer metrics server stopped", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/pkg/jsonschema"
+)
+
+// initCustomEvents loads the schemas of the custom event types and lets them
+// be published through publisher
+func (a *App) initCustomEvents(publisher *cqrs.EventBus) error {
+	schemas := a.config.Events.Custom.Schemas
+	if len(schemas) == 0 {
+		return errors.New("custom events need events.custom.schemas")
+	}
+
+	registry, err := jsonschema.NewRegistry(schemas)
+	if err != nil {
+		return fmt.Errorf("events.custom.schemas: %w", err)
+	}
+	a.CustomEventUsecase = usecase.NewCustomEventUsecase(publisher, registry)
+
+	slog.Info("Custom events enabled", "types", len(schemas))
+	return nil
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/migration"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5"
+)
+
+// initDualWrites reads the phases of the dual-write table migrations, which
+// repositories consult to write and read the old and new tables. Reading a
+// new table is refused until its backfill finished and verified clean.
+func (a *App) initDualWrites() error {
+	phases, err := migration.ParsePhases(a.config.Migration.DualWrite)
+	if err != nil {
+		return err
+	}
+
+	querier := sqlc.New(a.dbPool)
+	for name, phase := range phases {
+		if phase.ReadsNew() {
+			progress, err := querier.GetDataMigration(a.ctx, name)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("dual-write migration %s is in phase %s but was never backfilled", name, phase)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read progress of dual-write migration %s: %w", name, err)
+			}
+			if err := migration.CheckCutover(progress); err != nil {
+				return err
+			}
+		}
+		slog.Info("Dual-write migration enabled", "name", name, "phase", phase)
+	}
+
+	a.dualWrites = phases
+	return nil
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// serveDebug serves /debug/vars, runtime variables such as cancelled
+// database statements, on the internal debug address until ctx is done. It
+// fails straight away when the address cannot be bound.
+func (a *App) serveDebug(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	lis, err := net.Listen("tcp", a.config.Servers.DebugAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on debug address %s: %w", a.config.Servers.DebugAddr, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	slog.Info("Serving debug variables", slog.String("addr", a.config.Servers.DebugAddr))
+	if err := server.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("debug listener error: %w", err)
+	}
+	return nil
+}
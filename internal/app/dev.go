@@ -0,0 +1,21 @@
+package app
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DB returns the pool of the main database, for tools such as cmd/dev
+// querying it directly. It is closed with the application.
+func (a *App) DB() *pgxpool.Pool {
+	return a.dbPool
+}
+
+// ClearCaches empties the read caches so the next reads query the database.
+// It reports false when caching is disabled.
+func (a *App) ClearCaches() bool {
+	if a.cache == nil {
+		return false
+	}
+	a.cache.Purge()
+	return true
+}
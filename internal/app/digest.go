@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/digest"
+	"github.com/erry-az/go-init/internal/handler/consumer"
+	"github.com/erry-az/go-init/internal/scheduler"
+)
+
+// newDigestRules converts the configured digest rules
+func newDigestRules(cfg []config.DigestRuleConfig) []consumer.DigestRule {
+	rules := make([]consumer.DigestRule, len(cfg))
+	for i, rule := range cfg {
+		rules[i] = consumer.DigestRule{
+			Rule: digest.Rule{
+				Name:         rule.Name,
+				Window:       rule.Window,
+				MaxBatchSize: rule.MaxBatchSize,
+			},
+			Event:   rule.Event,
+			GroupBy: rule.GroupBy,
+		}
+	}
+	return rules
+}
+
+// runDigestFlush emits the digests whose window elapsed until ctx is
+// cancelled. Every instance runs it; groups flushed elsewhere are skipped.
+func (app *ConsumerApp) runDigestFlush(ctx context.Context) {
+	interval := app.config.Consumers.Digest.FlushInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	jobs := scheduler.New()
+	if app.reporter != nil {
+		jobs.OnFailure(reportJobFailure(app.reporter))
+	}
+	jobs.Every("event_digest_flush", interval, func(ctx context.Context) error {
+		emitted, err := app.DigestConsumer.Flush(ctx)
+		if emitted > 0 {
+			slog.Info("Emitted event digests", slog.Int("count", emitted))
+		}
+		return err
+	})
+	jobs.Run(ctx)
+}
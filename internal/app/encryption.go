@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/repository"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/envelope"
+	"github.com/erry-az/go-init/pkg/watmil"
+)
+
+// newEncryptedQuerier wraps querier with PII encryption and schedules key rotation
+func (a *App) newEncryptedQuerier(querier sqlc.Querier) (sqlc.Querier, error) {
+	cfg := a.config.Encryption
+	if !cfg.EncryptUserEmail {
+		return querier, nil
+	}
+
+	provider, err := envelope.NewLocalKeyProvider(cfg.ActiveKeyID, cfg.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key provider: %w", err)
+	}
+	if cfg.BlindIndexKey == "" {
+		return nil, fmt.Errorf("encryption.blind_index_key is required when encrypting user email")
+	}
+
+	encrypted := repository.NewEncryptedQuerier(querier, envelope.NewEncryptor(provider), []byte(cfg.BlindIndexKey))
+
+	interval := cfg.RotationInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	batchSize := cfg.RotationBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	a.scheduler.Every("pii_key_rotation", interval, func(ctx context.Context) error {
+		for {
+			count, err := encrypted.ReencryptUsers(ctx, batchSize)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				slog.Info("Re-encrypted user emails", "count", count)
+			}
+			if count < int(batchSize) {
+				return nil
+			}
+		}
+	})
+
+	slog.Info("PII encryption enabled", "active_key_id", cfg.ActiveKeyID)
+	return encrypted, nil
+}
+
+// newPayloadEncryption creates the encryption of sensitive event payloads, or nil when disabled
+func newPayloadEncryption(cfg config.EventEncryptionConfig) (*watmil.PayloadEncryption, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	provider, err := envelope.NewLocalKeyProvider(cfg.ActiveKeyID, cfg.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event key provider: %w", err)
+	}
+
+	slog.Info("Event payload encryption enabled", "active_key_id", cfg.ActiveKeyID, "events", cfg.Events)
+	return watmil.NewPayloadEncryption(provider, cfg.Events), nil
+}
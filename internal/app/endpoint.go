@@ -2,40 +2,102 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"log/slog"
-	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/auth"
+	"github.com/erry-az/go-init/internal/errreport"
+	"github.com/erry-az/go-init/internal/eventhistory"
 	handlergrpc "github.com/erry-az/go-init/internal/handler/grpc"
+	"github.com/erry-az/go-init/internal/health"
+	"github.com/erry-az/go-init/internal/ipaccess"
+	"github.com/erry-az/go-init/internal/migration"
+	"github.com/erry-az/go-init/internal/publishretry"
+	"github.com/erry-az/go-init/internal/repository"
 	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/residency"
+	"github.com/erry-az/go-init/internal/sandbox"
+	"github.com/erry-az/go-init/internal/scheduler"
 	"github.com/erry-az/go-init/internal/server"
+	// template:begin gateway
 	"github.com/erry-az/go-init/internal/server/http"
+	// template:end gateway
+	"github.com/erry-az/go-init/internal/server/interceptor"
+	"github.com/erry-az/go-init/internal/usage"
 	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/pkg/metrics"
+	"github.com/erry-az/go-init/pkg/pagetoken"
 	"github.com/erry-az/go-init/pkg/watmil"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
+// apiProtoPackage is the proto package of the public API whose requests are validated
+const apiProtoPackage = "proto.api.v1"
+
 // App represents the application with all dependencies
 type App struct {
 	// Business logic components
-	UserUsecase    usecase.UserUsecase
-	ProductUsecase usecase.ProductUsecase
-	UserService    *handlergrpc.UserService
-	ProductService *handlergrpc.ProductService
-	Publisher      *cqrs.EventBus
+	UserUsecase          usecase.UserUsecase
+	ProductUsecase       usecase.ProductUsecase
+	UsageUsecase         usecase.UsageUsecase
+	OperationUsecase     usecase.OperationUsecase
+	JobUsecase           usecase.JobUsecase
+	IPAccessUsecase      usecase.IPAccessUsecase
+	EventHistoryUsecase  usecase.EventHistoryUsecase
+	ChangeFeedUsecase    usecase.ChangeFeedUsecase
+	CustomEventUsecase   usecase.CustomEventUsecase
+	EmailTemplateUsecase usecase.EmailTemplateUsecase
+	UserService          *handlergrpc.UserService
+	ProductService       *handlergrpc.ProductService
+	AdminService         *handlergrpc.AdminService
+	OperationService     *handlergrpc.OperationService
+	Publisher            *cqrs.EventBus
 
 	// Infrastructure components
-	config     *config.Config
-	dbPool     *pgxpool.Pool
-	logger     watermill.LoggerAdapter
-	grpcServer *server.GRPCServer
-	httpServer *http.HTTPServer
-	ctx        context.Context
-	cancel     context.CancelFunc
+	config      *config.Config
+	dbPool      *pgxpool.Pool
+	regionPools map[string]*pgxpool.Pool
+	residency   *residency.Resolver
+	// sandboxPool queries the sandbox schema, nil unless sandbox mode is enabled
+	sandboxPool   *pgxpool.Pool
+	sandboxSchema *repository.SandboxSchema
+	sandbox       *sandbox.Detector
+	cache         *repository.CachedQuerier
+	dualWrites    migration.Phases
+	ipAccess      *ipaccess.Controller
+	rateLimit     *rateLimiting
+	jwt           *auth.Verifier
+	rbac          *interceptor.RBACOptions
+	admission     *interceptor.AdmissionOptions
+	logger        watermill.LoggerAdapter
+	usage         *usage.Recorder
+	scheduler     *scheduler.Scheduler
+	metrics       *metrics.Registry
+	health        *health.Monitor
+	retries       *publishretry.Store
+	reporter      *errreport.Reporter
+	broker        watmil.Broker
+	encryption    *watmil.PayloadEncryption
+	commandBus    *cqrs.CommandBus
+	commands      *watmil.Subscriber
+	grpcServer    *server.GRPCServer
+	ctx           context.Context
+	cancel        context.CancelFunc
+
+	// template:begin gateway
+	WebhookUsecase usecase.WebhookUsecase
+	httpServer     *http.HTTPServer
+	// template:end gateway
 }
 
 // NewEndpoint creates a new application with all dependencies wired
@@ -48,12 +110,28 @@ func NewEndpoint(cfg *config.Config) (*App, error) {
 		cancel: cancel,
 	}
 
+	// Initialize error reporting first so every component can report to it
+	reporter, err := newReporter(cfg.ErrorReporting)
+	if err != nil {
+		slog.Error("Failed to create error reporter", slog.Any("error", err))
+		cancel()
+		return nil, err
+	}
+	app.reporter = reporter
+
 	// Initialize database connection
 	if err := app.initDatabase(); err != nil {
 		cancel()
 		return nil, err
 	}
 
+	// Refuse to read tables whose dual-write migration is not verified
+	if err := app.initDualWrites(); err != nil {
+		slog.Error("Failed to check dual-write migrations", slog.Any("error", err))
+		cancel()
+		return nil, err
+	}
+
 	// Initialize logger
 	app.initLogger()
 
@@ -74,7 +152,7 @@ func NewEndpoint(cfg *config.Config) (*App, error) {
 
 // initDatabase initializes the database connection pool
 func (a *App) initDatabase() error {
-	dbPool, err := pgxpool.New(a.ctx, a.config.Databases.DbDsn)
+	dbPool, err := repository.NewPool(a.ctx, a.config.Databases.DbDsn, poolOptions(a.config.Databases))
 	if err != nil {
 		slog.Error("Failed to create pgx pool", slog.Any("error", err))
 		return err
@@ -89,9 +167,106 @@ func (a *App) initDatabase() error {
 
 	a.dbPool = dbPool
 	slog.Info("Database connection established")
+
+	if a.config.Sandbox.Enabled {
+		if err := a.initSandboxDatabase(); err != nil {
+			return err
+		}
+	}
+
+	if a.config.Residency.Enabled {
+		return a.initRegionDatabases()
+	}
+
 	return nil
 }
 
+// grpcOptions returns the options of the gRPC endpoint, serving calls
+// through the unary and stream interceptors
+func grpcOptions(cfg config.GRPCConfig, unary []grpc.UnaryServerInterceptor, stream []grpc.StreamServerInterceptor, tlsConfig *tls.Config) server.GRPCOptions {
+	return server.GRPCOptions{
+		UnaryInterceptors:    unary,
+		StreamInterceptors:   stream,
+		TLS:                  tlsConfig,
+		Reflection:           cfg.Reflection,
+		MaxRecvMsgSize:       cfg.MaxRecvMsgSize,
+		MaxSendMsgSize:       cfg.MaxSendMsgSize,
+		MaxConcurrentStreams: uint32(cfg.MaxConcurrentStreams),
+		ConnectionTimeout:    cfg.ConnectionTimeout,
+		Keepalive: keepalive.ServerParameters{
+			Time:                  cfg.Keepalive.Time,
+			Timeout:               cfg.Keepalive.Timeout,
+			MaxConnectionIdle:     cfg.Keepalive.MaxConnectionIdle,
+			MaxConnectionAge:      cfg.Keepalive.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.Keepalive.MaxConnectionAgeGrace,
+		},
+		KeepalivePolicy: keepalive.EnforcementPolicy{
+			MinTime:             cfg.Keepalive.MinTime,
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		},
+	}
+}
+
+// poolOptions returns the options of every pool opened on the configured databases
+func poolOptions(cfg config.DatabaseConfig) repository.PoolOptions {
+	return repository.PoolOptions{
+		CancelGracePeriod: cfg.CancelGracePeriod,
+		QueryPlans: repository.QueryPlanOptions{
+			Enabled:    cfg.QueryPlans.Enabled,
+			Threshold:  cfg.QueryPlans.Threshold,
+			SampleRate: cfg.QueryPlans.SampleRate,
+		},
+	}
+}
+
+// initRegionDatabases opens one pool per residency region.
+// The default region reuses the main pool unless it has its own DSN.
+func (a *App) initRegionDatabases() error {
+	pools, err := openRegionPools(a.ctx, a.config, a.dbPool)
+	if err != nil {
+		return err
+	}
+
+	a.residency = residency.NewResolver(a.config.Residency)
+	a.regionPools = pools
+	return nil
+}
+
+// openRegionPools opens one pool per residency region with a DSN of its own,
+// serving the default region from defaultPool otherwise
+func openRegionPools(ctx context.Context, cfg *config.Config, defaultPool *pgxpool.Pool) (map[string]*pgxpool.Pool, error) {
+	pools := make(map[string]*pgxpool.Pool)
+
+	for region, regionCfg := range cfg.Residency.Regions {
+		if regionCfg.DbDsn == "" {
+			continue
+		}
+
+		pool, err := repository.NewPool(ctx, regionCfg.DbDsn, poolOptions(cfg.Databases))
+		if err != nil {
+			slog.Error("Failed to create region pool", "region", region, slog.Any("error", err))
+			closeRegionPools(pools, defaultPool)
+			return nil, err
+		}
+
+		if err := pool.Ping(ctx); err != nil {
+			slog.Error("Failed to ping region database", "region", region, slog.Any("error", err))
+			pool.Close()
+			closeRegionPools(pools, defaultPool)
+			return nil, err
+		}
+
+		pools[region] = pool
+	}
+
+	if _, ok := pools[cfg.Residency.DefaultRegion]; !ok {
+		pools[cfg.Residency.DefaultRegion] = defaultPool
+	}
+
+	slog.Info("Region database connections established", "regions", len(pools))
+	return pools, nil
+}
+
 // initLogger initializes the watermill logger
 func (a *App) initLogger() {
 	a.logger = watermill.NewSlogLogger(slog.Default())
@@ -99,108 +274,549 @@ func (a *App) initLogger() {
 
 // initBusinessLogic initializes business logic components
 func (a *App) initBusinessLogic() error {
+	a.scheduler = scheduler.New()
+	if a.reporter != nil {
+		a.scheduler.OnFailure(reportJobFailure(a.reporter))
+	}
+
 	// Create Watermill publisher
-	publisher, err := watmil.NewPublisher(a.dbPool, a.logger)
+	broker, err := newBroker(a.ctx, a.config.Events, a.dbPool)
+	if err != nil {
+		slog.Error("Failed to create event broker", slog.Any("error", err))
+		return err
+	}
+	a.broker = broker
+
+	encryption, err := newPayloadEncryption(a.config.Events.Encryption)
+	if err != nil {
+		slog.Error("Failed to create event payload encryption", slog.Any("error", err))
+		return err
+	}
+	a.encryption = encryption
+
+	// Events the broker does not accept are stored in the main database to retry
+	retries := publishretry.New(a.dbPool)
+	a.retries = retries
+
+	// Events about users and products are recorded in the main database as published
+	var history watmil.HistoryRecorder
+	if a.config.Events.History.Enabled {
+		store := eventhistory.New(a.dbPool, encryption)
+		history = store
+		a.EventHistoryUsecase = usecase.NewEventHistoryUsecase(store)
+	}
+
+	publisher, err := watmil.NewPublisher(broker, a.logger, watmil.TTLPolicy{
+		Default: a.config.Events.DefaultTTL,
+		Events:  a.config.Events.TTLs,
+	}, encryption, newPublishFailurePolicy(a.config.Events.PublishFailure), retries, history)
+	if err != nil {
+		slog.Error("Failed to create event publisher", slog.Any("error", err))
+		return err
+	}
+	if err := a.initPublishRetry(retries); err != nil {
+		return err
+	}
+
+	if a.config.Events.Custom.Enabled {
+		if err := a.initCustomEvents(publisher); err != nil {
+			slog.Error("Failed to initialize custom events", slog.Any("error", err))
+			return err
+		}
+	}
+
+	commandBus, err := watmil.NewCommandBus(broker, a.logger)
+	if err != nil {
+		slog.Error("Failed to create command bus", slog.Any("error", err))
+		return err
+	}
+	a.commandBus = commandBus
+
+	// Create SQLC querier, routed per tenant region when residency is enabled
+	// and to the sandbox schema for sandbox requests
+	var db sqlc.DBTX = a.dbPool
+	if a.residency != nil {
+		db = repository.NewRegionRouter(a.residency, a.regionPools)
+	}
+	if a.sandboxPool != nil {
+		db = repository.NewSandboxRouter(db, a.sandboxPool)
+	}
+	var querier sqlc.Querier = sqlc.New(db)
+	var userFilter usecase.UserFilterer = repository.NewUserFilter(db)
+
+	// Encrypt PII columns transparently when configured
+	if a.config.Encryption.Enabled {
+		encrypted, err := a.newEncryptedQuerier(querier)
+		if err != nil {
+			return err
+		}
+		querier = encrypted
+		if encrypted, ok := encrypted.(*repository.EncryptedQuerier); ok {
+			userFilter = repository.NewEncryptedUserFilter(repository.NewUserFilter(db), encrypted)
+		}
+	}
+
+	// Collapse and briefly cache hot reads by ID
+	if a.config.Cache.Enabled {
+		cached, err := a.newCache(querier)
+		if err != nil {
+			return err
+		}
+		querier = cached
+		a.cache = cached
+	}
+
+	// Load product attribute schemas per category
+	attributeSchemas, err := a.newAttributeSchemas()
+	if err != nil {
+		slog.Error("Failed to load product attribute schemas", slog.Any("error", err))
+		return err
+	}
+
+	sunset, err := a.config.Pagination.LegacyTokensSunset()
 	if err != nil {
+		slog.Error("Failed to configure page tokens", slog.Any("error", err))
 		return err
 	}
+	pageTokens := pagetoken.Codec{Sunset: sunset}
 
-	// Create SQLC querier
-	querier := sqlc.New(a.dbPool)
+	defaultLocale, err := a.config.Product.Locale()
+	if err != nil {
+		slog.Error("Failed to configure product locales", slog.Any("error", err))
+		return err
+	}
+
+	ids, err := publicIDs(a.config.PublicIDs)
+	if err != nil {
+		slog.Error("Failed to configure public IDs", slog.Any("error", err))
+		return err
+	}
 
 	// Create usecases
-	a.UserUsecase = usecase.NewUserUsecase(querier, publisher)
-	a.ProductUsecase = usecase.NewProductUsecase(querier, publisher)
+	a.UserUsecase = usecase.NewUserUsecase(querier, userFilter, publisher, usecase.UserOptions{
+		EmailChangeTTL:           a.config.User.EmailChangeTTL,
+		RequireEmailConfirmation: a.config.User.RequireEmailConfirmation,
+		StripEmailPlusTags:       a.config.User.StripEmailPlusTags,
+		PageTokens:               pageTokens,
+	})
+	a.ProductUsecase = usecase.NewProductUsecase(querier, repository.NewProductFilter(db), publisher, attributeSchemas, pageTokens, defaultLocale)
+	a.JobUsecase = usecase.NewJobUsecase(sqlc.New(a.mainDB()), commandBus, a.ProductUsecase, pageTokens)
+	a.UsageUsecase = usecase.NewUsageUsecase(sqlc.New(a.dbPool))
+	a.EmailTemplateUsecase = usecase.NewEmailTemplateUsecase(sqlc.New(a.mainDB()))
+
+	if a.config.Servers.IPAccess.Enabled {
+		if err := a.initIPAccess(); err != nil {
+			slog.Error("Failed to initialize IP access control", slog.Any("error", err))
+			return err
+		}
+	}
+
+	// Queue writes as commands when async writes are enabled
+	if a.config.AsyncWrites.Enabled {
+		if err := a.initAsyncWrites(); err != nil {
+			return err
+		}
+		a.OperationService = handlergrpc.NewOperationService(a.OperationUsecase, ids)
+	}
+
+	// template:begin gateway
+	// Store verified webhooks and process them as commands
+	if a.config.Servers.Webhooks.Enabled {
+		if err := a.initWebhooks(); err != nil {
+			return err
+		}
+	}
+	// template:end gateway
+
+	// Stream the changes events report to the Watch* endpoints
+	if a.config.Events.ChangeFeeds.Enabled {
+		if err := a.initChangeFeeds(); err != nil {
+			slog.Error("Failed to initialize change feeds", slog.Any("error", err))
+			return err
+		}
+	}
 
 	// Create services
-	a.UserService = handlergrpc.NewUserService(a.UserUsecase)
-	a.ProductService = handlergrpc.NewProductService(a.ProductUsecase)
+	a.UserService = handlergrpc.NewUserService(a.UserUsecase, a.OperationUsecase, a.EventHistoryUsecase, a.ChangeFeedUsecase, ids.User)
+	a.ProductService = handlergrpc.NewProductService(a.ProductUsecase, a.OperationUsecase, a.EventHistoryUsecase, a.ChangeFeedUsecase, defaultLocale, ids.Product)
+	a.AdminService = handlergrpc.NewAdminService(a.UsageUsecase, a.JobUsecase, a.IPAccessUsecase, a.CustomEventUsecase, a.EmailTemplateUsecase)
 	a.Publisher = publisher
 
+	// Create background components
+	if a.config.Usage.Enabled {
+		a.usage = usage.NewRecorder(sqlc.New(a.dbPool), a.config.Usage.BufferSize, a.config.Usage.FlushInterval)
+		a.scheduler.Every("api_usage_daily_rollup", a.usageRollupInterval(), a.rollupUsage)
+	}
+
+	if a.config.Metrics.Enabled {
+		a.initMetrics()
+	}
+
+	if a.config.Cleanup.Enabled {
+		a.initCleanup()
+	}
+
+	if a.config.Archive.Enabled {
+		if err := a.initArchive(); err != nil {
+			slog.Error("Failed to initialize archive", slog.Any("error", err))
+			return err
+		}
+	}
+
+	if a.config.Product.PriceSchedule.Enabled {
+		a.initPriceSchedule()
+	}
+
+	if a.sandboxSchema != nil {
+		a.initSandboxPurge()
+	}
+
 	slog.Info("Business logic components initialized")
 	return nil
 }
 
+// usageRollupInterval returns how often daily usage is rolled up
+func (a *App) usageRollupInterval() time.Duration {
+	if a.config.Usage.RollupInterval > 0 {
+		return a.config.Usage.RollupInterval
+	}
+	return time.Hour
+}
+
+// rollupUsage refreshes yesterday's and today's daily usage so late flushes are included
+func (a *App) rollupUsage(ctx context.Context) error {
+	now := time.Now().UTC()
+	if err := a.UsageUsecase.RollupDailyUsage(ctx, now.AddDate(0, 0, -1)); err != nil {
+		return err
+	}
+	return a.UsageUsecase.RollupDailyUsage(ctx, now)
+}
+
 // initServers initializes gRPC and HTTP servers
 func (a *App) initServers() error {
+	// Compile request validation rules for the public API
+	validator, err := a.newValidator()
+	if err != nil {
+		slog.Error("Failed to create request validator", slog.Any("error", err))
+		return err
+	}
+
+	if a.config.Servers.RateLimit.Enabled {
+		if err := a.initRateLimit(); err != nil {
+			slog.Error("Failed to configure rate limiting", slog.Any("error", err))
+			return err
+		}
+	}
+
+	if a.config.Servers.Auth.JWT.Enabled {
+		if err := a.initJWT(); err != nil {
+			slog.Error("Failed to configure JWT authentication", slog.Any("error", err))
+			return err
+		}
+	}
+
+	if a.config.Servers.Auth.RBAC.Enabled {
+		if err := a.initRBAC(); err != nil {
+			slog.Error("Failed to configure RBAC", slog.Any("error", err))
+			return err
+		}
+	}
+
+	if a.config.Servers.Admission.Enabled {
+		a.initAdmission()
+	}
+
+	unary, stream, err := a.grpcInterceptors(validator)
+	if err != nil {
+		slog.Error("Failed to configure gRPC interceptors", slog.Any("error", err))
+		return err
+	}
+
+	// Both endpoints are served over TLS when it is enabled
+	tlsConfig, err := a.config.Servers.TLS.ServerTLS()
+	if err != nil {
+		slog.Error("Failed to configure TLS", slog.Any("error", err))
+		return err
+	}
+
 	// Create gRPC endpoint with services
+	options := grpcOptions(a.config.Servers.GRPC, unary, stream, tlsConfig)
+	options.ShutdownTimeout = a.config.Servers.ShutdownTimeout
 	grpcServer, err := server.NewGRPCServer(server.GRPCServices{
-		UserService:    a.UserService,
-		ProductService: a.ProductService,
-	})
+		UserService:      a.UserService,
+		ProductService:   a.ProductService,
+		AdminService:     a.AdminService,
+		OperationService: a.OperationService,
+	}, options)
 	if err != nil {
 		slog.Error("Failed to create gRPC endpoint", slog.Any("error", err))
 		return err
 	}
 	a.grpcServer = grpcServer
 
+	if a.config.Health.Enabled {
+		a.initHealth()
+		a.grpcServer.RegisterService(a.health.Register)
+	}
+
+	// template:begin gateway
 	// Create HTTP endpoint (gRPC Gateway)
-	httpServer, err := http.NewHTTPServer(a.config.Servers.GrpcPort)
+	retry := http.RetryOptions{MaxAttempts: 1}
+	if retryCfg := a.config.Servers.GatewayRetry; retryCfg.Enabled {
+		retry = http.RetryOptions{
+			MaxAttempts:    retryCfg.MaxAttempts,
+			Budget:         retryCfg.Budget,
+			InitialBackoff: retryCfg.InitialBackoff,
+		}
+	}
+
+	httpServer, err := http.NewHTTPServer(a.config.Servers.GrpcPort, retry, tlsConfig, a.config.Servers.GRPC.MaxSendMsgSize)
 	if err != nil {
 		slog.Error("Failed to create HTTP endpoint", slog.Any("error", err))
 		return err
 	}
 	a.httpServer = httpServer
+	a.httpServer.SetShutdownTimeout(a.config.Servers.ShutdownTimeout)
+
+	if a.metrics != nil {
+		a.httpServer.Handle("/metrics", a.metrics.Handler())
+	}
+
+	if a.health != nil {
+		a.httpServer.Handle("/healthz", http.Liveness(a.health))
+		a.httpServer.Handle("/readyz", http.Readiness(a.health))
+	}
+
+	if a.WebhookUsecase != nil {
+		a.httpServer.Handle(http.WebhookPattern, http.Webhooks(a.WebhookUsecase, a.webhookOptions()))
+	}
+
+	if grpcWeb := a.config.Servers.GRPCWeb; grpcWeb.Enabled {
+		a.httpServer.ServeGRPCWeb(a.grpcServer.Server(), http.GRPCWebOptions{Services: grpcWeb.Services})
+	}
+
+	// Give every request its request and correlation IDs before anything logs it
+	a.httpServer.Use(http.Correlation())
+
+	// Log every request, those refused by the middlewares below included
+	if requests := a.config.Logging.Requests; requests.Gateway {
+		a.httpServer.Use(http.RequestLogging(http.LoggingOptions{
+			SampleRate:     requests.SampleRate,
+			Payloads:       requests.Payloads,
+			MaxPayloadSize: requests.MaxPayloadSize,
+		}))
+	}
+
+	if headers := a.config.Servers.Headers; headers.Enabled {
+		a.httpServer.Use(http.SecurityHeaders(http.HeadersOptions{
+			HSTSMaxAge:            headers.HSTSMaxAge,
+			HSTSIncludeSubdomains: headers.HSTSIncludeSubdomains,
+			FrameOptions:          headers.FrameOptions,
+			ReferrerPolicy:        headers.ReferrerPolicy,
+		}))
+	}
+
+	// Refuse denied networks before anything else looks at the request
+	if a.ipAccess != nil {
+		a.httpServer.Use(http.IPAccess(a.ipAccess))
+	}
+
+	// Answer preflights before authentication, and let browsers read the
+	// errors of the middlewares below
+	if cors := a.config.Servers.CORS; cors.Enabled {
+		options := http.CORSOptions{
+			AllowedOrigins:   cors.AllowedOrigins,
+			AllowedMethods:   cors.AllowedMethods,
+			AllowedHeaders:   cors.AllowedHeaders,
+			ExposedHeaders:   cors.ExposedHeaders,
+			AllowCredentials: cors.AllowCredentials,
+			MaxAge:           cors.MaxAge,
+		}
+		if a.config.Servers.GRPCWeb.Enabled {
+			options = options.WithGRPCWeb()
+		}
+		middleware, err := http.CORS(options)
+		if err != nil {
+			slog.Error("Failed to configure CORS", slog.Any("error", err))
+			return err
+		}
+		a.httpServer.Use(middleware)
+	}
+
+	// Throttle callers before their requests cost anything more
+	if a.rateLimit != nil {
+		a.httpServer.Use(http.RateLimit(http.RateLimitOptions{
+			Limiter:  a.rateLimit.http,
+			Proxies:  a.rateLimit.proxies,
+			ByAPIKey: a.rateLimit.byAPIKey,
+			Keys:     a.rateLimit.keys,
+		}))
+	}
+
+	// Verify tokens sent to any route, not only those behind the gateway
+	if a.jwt != nil {
+		a.httpServer.Use(http.Authentication(a.jwt))
+	}
+
+	// Bound request bodies before anything reads them
+	if limits := a.config.Servers.Limits; limits.Enabled {
+		a.httpServer.Use(http.RequestLimits(http.LimitOptions{
+			MaxBodySize:     limits.MaxBodySize,
+			MaxJSONDepth:    limits.MaxJSONDepth,
+			BodyReadTimeout: limits.BodyReadTimeout,
+		}))
+	}
+
+	// Verify signatures before anything else reads the request
+	if a.config.Servers.Signing.Enabled {
+		signing, err := a.newSigningOptions()
+		if err != nil {
+			slog.Error("Failed to configure request signing", slog.Any("error", err))
+			return err
+		}
+		a.httpServer.Use(http.RequestSigning(signing))
+	}
+
+	if a.config.Servers.Compression.Enabled {
+		a.httpServer.Use(http.Compression(http.CompressionOptions{
+			MinSize:       a.config.Servers.Compression.MinSize,
+			ExcludedPaths: a.config.Servers.Compression.ExcludedPaths,
+		}))
+	}
+	// template:end gateway
 
 	slog.Info("Servers initialized")
 	return nil
 }
 
-// Start starts the application servers and handles graceful shutdown
+// Start runs the application until an interrupt signal or a component failure
 func (a *App) Start() error {
+	ctx, stop := signal.NotifyContext(a.ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return a.Run(ctx)
+}
+
+// Run starts the servers and background workers and blocks until ctx is done
+// or one of them fails. A failure, such as a port already in use, stops the
+// others and is returned once the application has shut down.
+func (a *App) Run(ctx context.Context) error {
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	// Watches last as long as their callers, so they are ended for the
+	// endpoints to stop gracefully
+	if a.ChangeFeedUsecase != nil {
+		context.AfterFunc(groupCtx, a.ChangeFeedUsecase.Close)
+	}
+
 	// Start gRPC endpoint
-	go func() {
-		if err := a.grpcServer.Start(a.ctx, a.config.Servers.GrpcPort); err != nil {
-			slog.Error("gRPC endpoint error", slog.Any("error", err))
-		}
-	}()
+	group.Go(func() error {
+		return a.grpcServer.Start(groupCtx, a.config.Servers.GrpcPort)
+	})
 
+	// template:begin gateway
 	// Start HTTP endpoint
-	go func() {
-		if err := a.httpServer.Start(a.ctx, a.config.Servers.HttpPort); err != nil {
-			slog.Error("HTTP endpoint error", slog.Any("error", err))
+	group.Go(func() error {
+		return a.httpServer.Start(groupCtx, a.config.Servers.HttpPort)
+	})
+	// template:end gateway
+
+	if a.config.Servers.DebugAddr != "" {
+		group.Go(func() error {
+			return a.serveDebug(groupCtx)
+		})
+	}
+
+	// Start background workers
+	if a.usage != nil {
+		group.Go(func() error {
+			return a.usage.Run(groupCtx)
+		})
+	}
+
+	group.Go(func() error {
+		if err := a.scheduler.Run(groupCtx); !errors.Is(err, context.Canceled) {
+			return err
 		}
-	}()
+		return nil
+	})
+
+	if a.commands != nil {
+		group.Go(func() error {
+			return a.commands.Run(groupCtx)
+		})
+	}
+
+	if failover, ok := a.broker.(*watmil.FailoverBroker); ok {
+		group.Go(func() error {
+			if err := failover.Run(groupCtx); !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		})
+	}
+
+	if a.health != nil {
+		group.Go(func() error {
+			if err := a.health.Run(groupCtx); !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		})
+	}
+
+	if a.reporter != nil {
+		group.Go(func() error {
+			if err := a.reporter.Run(groupCtx); !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		})
+	}
 
 	slog.Info("🚀 Application started successfully")
 	slog.Info("📡 gRPC endpoint listening", "port", a.config.Servers.GrpcPort)
+	// template:begin gateway
 	slog.Info("🌐 HTTP endpoint listening", "port", a.config.Servers.HttpPort)
+	// template:end gateway
 	slog.Info("👋 Press Ctrl+C to gracefully shutdown...")
 
-	// Wait for interrupt signal
-	return a.waitForShutdown()
-}
+	<-groupCtx.Done()
+	if ctx.Err() != nil {
+		slog.Info("🛑 Shutdown signal received, starting graceful shutdown...")
+	} else {
+		slog.Error("🛑 Application component failed, starting graceful shutdown...")
+	}
 
-// waitForShutdown waits for shutdown signals and handles graceful shutdown
-func (a *App) waitForShutdown() error {
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	<-sigCh
+	err := group.Wait()
+	if err != nil {
+		slog.Error("Application stopped with error", slog.Any("error", err))
+	}
 
-	slog.Info("🛑 Shutdown signal received, starting graceful shutdown...")
+	a.shutdown()
+	return err
+}
 
+// shutdown releases the resources left once every server and worker has returned
+func (a *App) shutdown() {
 	// Cancel context to signal shutdown to all components
 	a.cancel()
-
-	// Stop servers
-	if a.grpcServer != nil {
-		a.grpcServer.Stop()
-		slog.Info("✅ gRPC endpoint stopped")
-	}
-
-	if a.httpServer != nil {
-		a.httpServer.Stop()
-		slog.Info("✅ HTTP endpoint stopped")
-	}
+	slog.Info("✅ gRPC endpoint stopped")
+	// template:begin gateway
+	slog.Info("✅ HTTP endpoint stopped")
+	// template:end gateway
 
 	// Close database connection
+	a.closeRegionPools()
+	if a.sandboxPool != nil {
+		a.sandboxPool.Close()
+	}
 	if a.dbPool != nil {
 		a.dbPool.Close()
 		slog.Info("✅ Database connection closed")
 	}
 
 	slog.Info("🎉 Application shutdown completed successfully")
-	return nil
 }
 
 // Close performs cleanup of application resources
@@ -209,9 +825,28 @@ func (a *App) Close() error {
 		a.cancel()
 	}
 
+	a.closeRegionPools()
+	if a.sandboxPool != nil {
+		a.sandboxPool.Close()
+	}
 	if a.dbPool != nil {
 		a.dbPool.Close()
 	}
 
 	return nil
 }
+
+// closeRegionPools closes region pools other than the shared main pool
+func (a *App) closeRegionPools() {
+	closeRegionPools(a.regionPools, a.dbPool)
+}
+
+// closeRegionPools closes the pools of pools other than shared and forgets them
+func closeRegionPools(pools map[string]*pgxpool.Pool, shared *pgxpool.Pool) {
+	for region, pool := range pools {
+		if pool != shared {
+			pool.Close()
+		}
+		delete(pools, region)
+	}
+}
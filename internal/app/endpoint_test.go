@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/scheduler"
+	"github.com/erry-az/go-init/internal/server"
+	// template:begin gateway
+	"github.com/erry-az/go-init/internal/server/http"
+	// template:end gateway
+)
+
+// occupiedPort listens on a free port until the test ends
+func occupiedPort(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	return strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+}
+
+// freePort returns a port nothing listens on
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+}
+
+// newServingApp creates an endpoint serving no services on grpcPort and
+// httpPort, without the components needing a database or a broker
+func newServingApp(t *testing.T, grpcPort, httpPort string) *App {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Servers.GrpcPort = grpcPort
+	cfg.Servers.ShutdownTimeout = time.Second
+
+	grpcServer, err := server.NewGRPCServer(server.GRPCServices{}, server.GRPCOptions{ShutdownTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	app := &App{
+		config:     cfg,
+		grpcServer: grpcServer,
+		scheduler:  scheduler.New(),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	// template:begin gateway
+	cfg.Servers.HttpPort = httpPort
+	app.httpServer, err = http.NewHTTPServer(grpcPort, http.RetryOptions{MaxAttempts: 1}, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.httpServer.SetShutdownTimeout(time.Second)
+	// template:end gateway
+
+	return app
+}
+
+func TestApp_Run_FailsOnOccupiedPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		grpcPort func(t *testing.T) string
+		httpPort func(t *testing.T) string
+	}{
+		{name: "gRPC port", grpcPort: occupiedPort, httpPort: freePort},
+		// template:begin gateway
+		{name: "HTTP port", grpcPort: freePort, httpPort: occupiedPort},
+		// template:end gateway
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grpcPort, httpPort := tt.grpcPort(t), tt.httpPort(t)
+			app := newServingApp(t, grpcPort, httpPort)
+
+			// Nothing cancels ctx, so only the failure may end Run
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			start := time.Now()
+			err := app.Run(ctx)
+			if err == nil {
+				t.Fatal("expected Run to fail on the occupied port")
+			}
+			if !strings.Contains(err.Error(), "failed to listen") {
+				t.Errorf("Run error = %v, want a listen failure", err)
+			}
+			if ctx.Err() != nil {
+				t.Fatalf("Run returned after %s, only once ctx expired", time.Since(start))
+			}
+		})
+	}
+}
+
+func TestApp_Run_StopsOnCancel(t *testing.T) {
+	app := newServingApp(t, freePort(t), freePort(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run(ctx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run = %v, want nil after cancel", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return within the shutdown timeout after cancel")
+	}
+}
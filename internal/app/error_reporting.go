@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/errreport"
+	"github.com/erry-az/go-init/internal/scheduler"
+	"github.com/erry-az/go-init/pkg/watmil"
+)
+
+// newReporter creates the configured error reporter, or nil when reporting is disabled
+func newReporter(cfg config.ErrorReportingConfig) (*errreport.Reporter, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var provider errreport.Provider
+	switch cfg.Provider {
+	case config.ErrorReportingSentry, "":
+		sentry, err := errreport.NewSentryProvider(cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+		provider = sentry
+	default:
+		return nil, fmt.Errorf("unknown error reporting provider %q", cfg.Provider)
+	}
+
+	slog.Info("Error reporting enabled", "provider", cfg.Provider, "release", cfg.Release, "environment", cfg.Environment)
+	return errreport.New(provider, errreport.Options{
+		Release:      cfg.Release,
+		Environment:  cfg.Environment,
+		BufferSize:   cfg.BufferSize,
+		FlushTimeout: cfg.FlushTimeout,
+	}), nil
+}
+
+// reportJobFailure returns a scheduler hook reporting failed and panicking jobs
+func reportJobFailure(reporter *errreport.Reporter) scheduler.FailureFunc {
+	return func(ctx context.Context, job string, err error) {
+		tags := map[string]string{"job": job}
+
+		var panicErr *scheduler.PanicError
+		if errors.As(err, &panicErr) {
+			reporter.CapturePanic(ctx, panicErr.Value, panicErr.Stack, tags)
+			return
+		}
+		reporter.CaptureError(ctx, err, tags)
+	}
+}
+
+// reportHandlerPanics returns a subscriber middleware reporting panicking
+// message handlers, tagged with the handler and the message correlation ID
+func reportHandlerPanics(reporter *errreport.Reporter) message.HandlerMiddleware {
+	return watmil.ReportPanics(func(msg *message.Message, recovered any, stack []byte) {
+		ctx := msg.Context()
+		if correlationID := middleware.MessageCorrelationID(msg); correlationID != "" {
+			ctx = errreport.WithCorrelationID(ctx, correlationID)
+		}
+
+		reporter.CapturePanic(ctx, recovered, stack, map[string]string{
+			"handler":    message.HandlerNameFromCtx(msg.Context()),
+			"message_id": msg.UUID,
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/health"
+	"github.com/erry-az/go-init/pkg/lazy"
+	"github.com/erry-az/go-init/pkg/watmil"
+)
+
+// Service names reported on the gRPC health service, one per dependency
+const (
+	healthDatabase = "database"
+	healthBroker   = "broker"
+	healthOutbox   = "publish_retries"
+	// healthComponents fails while a lazily initialized component is degraded
+	healthComponents = "components"
+)
+
+// initHealth reports the database, the event broker when it can be pinged,
+// how long refused events have been waiting to be published and the
+// components initialized on first use
+func (a *App) initHealth() {
+	a.health = health.New(health.Options{
+		Interval: a.config.Health.Interval,
+		Timeout:  a.config.Health.Timeout,
+	})
+
+	a.health.Watch(healthDatabase, a.checkDatabases)
+	if pinger, ok := a.broker.(watmil.Pinger); ok {
+		a.health.Watch(healthBroker, pinger.Ping)
+	}
+	if a.retries != nil && a.config.Health.MaxPublishLag > 0 {
+		a.health.Watch(healthOutbox, a.checkPublishLag)
+	}
+	if slices.Contains(slices.Collect(maps.Values(a.config.Startup.Components)), config.InitLazy) {
+		a.health.Watch(healthComponents, checkComponents)
+	}
+}
+
+// checkComponents fails while the last initialization of a lazily
+// initialized component failed. Components not used yet are healthy.
+func checkComponents(context.Context) error {
+	if degraded := lazy.Degraded(); len(degraded) > 0 {
+		return fmt.Errorf("degraded components: %s", strings.Join(degraded, ", "))
+	}
+	return nil
+}
+
+// checkDatabases pings the main pool and every region pool
+func (a *App) checkDatabases(ctx context.Context) error {
+	if err := a.dbPool.Ping(ctx); err != nil {
+		return err
+	}
+	for region, pool := range a.regionPools {
+		if err := pool.Ping(ctx); err != nil {
+			return fmt.Errorf("region %s: %w", region, err)
+		}
+	}
+	return nil
+}
+
+// checkPublishLag fails once the oldest event the broker refused has waited
+// longer than health.max_publish_lag to be published again
+func (a *App) checkPublishLag(ctx context.Context) error {
+	lag, err := a.retries.Lag(ctx)
+	if err != nil {
+		return err
+	}
+	if limit := a.config.Health.MaxPublishLag; lag > limit {
+		return fmt.Errorf("oldest stored event is %s old, over %s", lag.Round(time.Second), limit)
+	}
+	return nil
+}
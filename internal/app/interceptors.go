@@ -0,0 +1,176 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/clientid"
+	"github.com/erry-az/go-init/internal/server/interceptor"
+	"github.com/erry-az/go-init/pkg/validation"
+	"google.golang.org/grpc"
+)
+
+// grpcInterceptors builds the unary and stream interceptor chains listed in
+// servers.interceptors, skipping those of disabled components. Request and
+// correlation IDs are always stored first, for every other interceptor to log.
+func (a *App) grpcInterceptors(validator *validation.Validator) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor, error) {
+	cfg := a.config.Servers.Interceptors
+	if !slices.Contains(cfg.Unary, config.InterceptorValidation) {
+		slog.Warn("Requests are not validated, validation is missing from servers.interceptors.unary")
+	}
+
+	auth := interceptor.AuthOptions{
+		APIKeys:       a.apiKeys(),
+		PublicMethods: a.config.Servers.Auth.PublicMethods,
+	}
+	if (slices.Contains(cfg.Unary, config.InterceptorAuth) || slices.Contains(cfg.Stream, config.InterceptorAuth)) && len(auth.APIKeys) == 0 {
+		return nil, nil, errors.New("the auth interceptor needs servers.auth.api_keys")
+	}
+
+	requests := a.config.Logging.Requests
+	logging := interceptor.LoggingOptions{
+		SampleRate:     requests.SampleRate,
+		Payloads:       requests.Payloads,
+		MaxPayloadSize: requests.MaxPayloadSize,
+	}
+
+	var rateLimit interceptor.RateLimitOptions
+	if a.rateLimit != nil {
+		rateLimit = interceptor.RateLimitOptions{
+			Limiter:  a.rateLimit.grpc,
+			Proxies:  a.rateLimit.proxies,
+			ByAPIKey: a.rateLimit.byAPIKey,
+			Keys:     a.rateLimit.keys,
+		}
+	}
+
+	var jwt interceptor.JWTOptions
+	if a.jwt != nil {
+		jwt = interceptor.JWTOptions{
+			Verifier:      a.jwt,
+			Rules:         authorizationRules(),
+			PublicMethods: a.config.Servers.Auth.PublicMethods,
+		}
+		// RPCs the RBAC policy opens to everyone need no token either
+		if a.rbac != nil {
+			jwt.PublicMethods = append(slices.Clone(jwt.PublicMethods), a.rbac.Policy.PublicMethods()...)
+		}
+	}
+
+	// Tenants are those of the principals jwt authenticates
+	var residency interceptor.ResidencyOptions
+	if a.residency != nil {
+		for _, chain := range [][]string{cfg.Unary, cfg.Stream} {
+			at, jwtAt := slices.Index(chain, config.InterceptorResidency), slices.Index(chain, config.InterceptorJWT)
+			if at >= 0 && (a.jwt == nil || jwtAt < 0 || jwtAt > at) {
+				return nil, nil, errors.New("the residency interceptor needs the jwt interceptor before it")
+			}
+		}
+		residency = interceptor.ResidencyOptions{
+			Resolver:    a.residency,
+			TenantClaim: a.config.Residency.TenantClaim,
+		}
+	}
+
+	unary := []grpc.UnaryServerInterceptor{interceptor.Correlation()}
+	for _, name := range cfg.Unary {
+		switch name {
+		case config.InterceptorRecovery:
+			unary = append(unary, interceptor.Recovery(a.reporter))
+		case config.InterceptorIPAccess:
+			if a.ipAccess != nil {
+				unary = append(unary, interceptor.IPAccess(a.ipAccess))
+			}
+		case config.InterceptorRateLimit:
+			if a.rateLimit != nil {
+				unary = append(unary, interceptor.RateLimit(rateLimit))
+			}
+		case config.InterceptorLogging:
+			unary = append(unary, interceptor.Logging(logging))
+		case config.InterceptorAuth:
+			unary = append(unary, interceptor.Auth(auth))
+		case config.InterceptorJWT:
+			if a.jwt != nil {
+				unary = append(unary, interceptor.JWT(jwt))
+			}
+		case config.InterceptorRBAC:
+			if a.rbac != nil {
+				unary = append(unary, interceptor.RBAC(*a.rbac))
+			}
+		case config.InterceptorMetrics:
+			if a.metrics != nil {
+				unary = append(unary, interceptor.Metrics(a.metrics))
+			}
+		case config.InterceptorAdmission:
+			if a.admission != nil {
+				unary = append(unary, interceptor.Admission(*a.admission))
+			}
+		case config.InterceptorValidation:
+			unary = append(unary, interceptor.Validation(validator))
+		case config.InterceptorSandbox:
+			if a.sandbox != nil {
+				unary = append(unary, interceptor.Sandbox(a.sandbox))
+			}
+		case config.InterceptorResidency:
+			if a.residency != nil {
+				unary = append(unary, interceptor.Residency(residency))
+			}
+		case config.InterceptorUsage:
+			if a.usage != nil {
+				unary = append(unary, interceptor.Usage(interceptor.UsageOptions{
+					Recorder: a.usage,
+					Keys:     clientid.NewKeys(auth.APIKeys),
+				}))
+			}
+		default:
+			return nil, nil, fmt.Errorf("unknown unary interceptor %q", name)
+		}
+	}
+
+	stream := []grpc.StreamServerInterceptor{interceptor.StreamCorrelation()}
+	for _, name := range cfg.Stream {
+		switch name {
+		case config.InterceptorRecovery:
+			stream = append(stream, interceptor.StreamRecovery(a.reporter))
+		case config.InterceptorIPAccess:
+			if a.ipAccess != nil {
+				stream = append(stream, interceptor.StreamIPAccess(a.ipAccess))
+			}
+		case config.InterceptorRateLimit:
+			if a.rateLimit != nil {
+				stream = append(stream, interceptor.StreamRateLimit(rateLimit))
+			}
+		case config.InterceptorLogging:
+			stream = append(stream, interceptor.StreamLogging(logging))
+		case config.InterceptorAuth:
+			stream = append(stream, interceptor.StreamAuth(auth))
+		case config.InterceptorJWT:
+			if a.jwt != nil {
+				stream = append(stream, interceptor.StreamJWT(jwt))
+			}
+		case config.InterceptorRBAC:
+			if a.rbac != nil {
+				stream = append(stream, interceptor.StreamRBAC(*a.rbac))
+			}
+		case config.InterceptorMetrics:
+			if a.metrics != nil {
+				stream = append(stream, interceptor.StreamMetrics(a.metrics))
+			}
+		case config.InterceptorSandbox:
+			if a.sandbox != nil {
+				stream = append(stream, interceptor.StreamSandbox(a.sandbox))
+			}
+		case config.InterceptorResidency:
+			if a.residency != nil {
+				stream = append(stream, interceptor.StreamResidency(residency))
+			}
+		default:
+			return nil, nil, fmt.Errorf("unknown stream interceptor %q", name)
+		}
+	}
+
+	return unary, stream, nil
+}
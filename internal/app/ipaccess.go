@@ -0,0 +1,49 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/erry-az/go-init/internal/ipaccess"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/usecase"
+)
+
+// defaultIPAccessRefreshInterval is used when ip_access.refresh_interval is unset
+const defaultIPAccessRefreshInterval = 30 * time.Second
+
+// initIPAccess creates the access controller from the configured rules and
+// applies the rules stored through the admin API, failing closed when they
+// cannot be loaded
+func (a *App) initIPAccess() error {
+	cfg := a.config.Servers.IPAccess
+
+	trustedProxies, err := ipaccess.ParsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("servers.ip_access.trusted_proxies: %w", err)
+	}
+	deny, err := ipaccess.ParsePrefixes(cfg.Deny)
+	if err != nil {
+		return fmt.Errorf("servers.ip_access.deny: %w", err)
+	}
+	adminAllow, err := ipaccess.ParsePrefixes(cfg.AdminAllow)
+	if err != nil {
+		return fmt.Errorf("servers.ip_access.admin_allow: %w", err)
+	}
+
+	a.ipAccess = ipaccess.NewController(ipaccess.Rules{Deny: deny, AdminAllow: adminAllow}, trustedProxies, cfg.AdminRoutes)
+	a.IPAccessUsecase = usecase.NewIPAccessUsecase(sqlc.New(a.dbPool), a.ipAccess)
+	if err := a.IPAccessUsecase.Reload(a.ctx); err != nil {
+		return err
+	}
+
+	interval := cfg.RefreshInterval
+	if interval <= 0 {
+		interval = defaultIPAccessRefreshInterval
+	}
+	a.scheduler.Every("ip_access_rules_refresh", interval, a.IPAccessUsecase.Reload)
+
+	slog.Info("IP access control enabled", "deny", len(deny), "admin_allow", len(adminAllow), "trusted_proxies", len(trustedProxies))
+	return nil
+}
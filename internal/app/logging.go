@@ -0,0 +1,90 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/pkg/logging"
+)
+
+// NewLogger builds the process logger from the logging config.
+// The returned closer releases file and syslog targets and must be called on exit.
+// Without targets the logger writes JSON to stdout.
+func NewLogger(cfg config.LoggingConfig) (*slog.Logger, io.Closer, error) {
+	var level slog.Level
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, nil, fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+		}
+	}
+
+	var writers logging.Fanout
+	var closers closerList
+	for _, target := range cfg.Targets {
+		w, err := newLogTarget(target)
+		if err != nil {
+			closers.Close()
+			return nil, nil, err
+		}
+		writers = append(writers, w)
+		if c, ok := w.(io.Closer); ok {
+			closers = append(closers, c)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if len(writers) > 0 {
+		out = writers
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "", "json":
+		handler = slog.NewJSONHandler(out, opts)
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		closers.Close()
+		return nil, nil, fmt.Errorf("invalid log format %q", cfg.Format)
+	}
+
+	return slog.New(handler), closers, nil
+}
+
+// newLogTarget opens the writer for a single log target.
+// stdout and stderr are returned as-is so closing the logger leaves them open.
+func newLogTarget(target config.LogTargetConfig) (io.Writer, error) {
+	switch target.Type {
+	case config.LogTargetStdout:
+		return os.Stdout, nil
+	case config.LogTargetStderr:
+		return os.Stderr, nil
+	case config.LogTargetFile:
+		if target.Path == "" {
+			return nil, errors.New("file log target requires a path")
+		}
+		return logging.NewRotatingFile(target.Path, target.MaxSizeMB, target.MaxAgeDays, target.MaxBackups)
+	case config.LogTargetSyslog:
+		return logging.NewSyslog(target.Network, target.Address, target.Tag)
+	default:
+		return nil, fmt.Errorf("unknown log target type %q", target.Type)
+	}
+}
+
+// closerList closes every element, reporting all failures
+type closerList []io.Closer
+
+func (c closerList) Close() error {
+	var errs []error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
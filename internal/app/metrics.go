@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/metrics"
+	"github.com/erry-az/go-init/pkg/watmil"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// eventProtoPackage is the proto package holding the published domain events
+const eventProtoPackage = "proto.event.v1"
+
+// initMetrics exposes runtime metrics and schedules the business KPI refresh
+func (a *App) initMetrics() {
+	a.metrics = metrics.NewRegistry()
+	a.metrics.Register(metrics.RuntimeCollector)
+	a.metrics.Register(metrics.ExpvarCollector)
+
+	interval := a.config.Metrics.KPIInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	a.scheduler.Every("business_kpis", interval, a.refreshKPIs)
+}
+
+// refreshKPIs derives the business gauges from the read models
+func (a *App) refreshKPIs(ctx context.Context) error {
+	db := sqlc.New(a.dbPool)
+
+	users, err := db.CountUsers(ctx)
+	if err != nil {
+		return err
+	}
+	a.metrics.SetGauge("business_users", "Total number of users.", nil, float64(users))
+
+	analytics, err := a.ProductUsecase.GetProductAnalytics(ctx)
+	if err != nil {
+		return err
+	}
+	a.metrics.SetGauge("business_products", "Total number of products.", nil, float64(analytics.TotalProducts))
+	if price, err := strconv.ParseFloat(analytics.AveragePrice, 64); err == nil {
+		a.metrics.SetGauge("business_product_average_price", "Average product price.", nil, price)
+	}
+
+	// Acknowledged offsets are only tracked by the SQL broker
+	if a.config.Events.BrokerType() != config.BrokerSQL {
+		return nil
+	}
+
+	processed, err := watmil.ProcessedEvents(ctx, a.dbPool, eventNames())
+	if err != nil {
+		return err
+	}
+	for eventName, count := range processed {
+		a.metrics.SetGauge("business_events_processed", "Events acknowledged by consumers per event type.",
+			map[string]string{"event": eventName}, float64(count))
+	}
+
+	return nil
+}
+
+// eventNames lists the registered domain event message names, e.g. UserCreatedEvent
+func eventNames() []string {
+	var names []string
+	protoregistry.GlobalFiles.RangeFilesByPackage(eventProtoPackage, func(file protoreflect.FileDescriptor) bool {
+		messages := file.Messages()
+		for i := 0; i < messages.Len(); i++ {
+			if name := string(messages.Get(i).Name()); strings.HasSuffix(name, "Event") {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	return names
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/erry-az/go-init/internal/sandbox"
+)
+
+// initPriceSchedule schedules applying the product price changes that have come into effect
+func (a *App) initPriceSchedule() {
+	cfg := a.config.Product.PriceSchedule
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	a.scheduler.Every("product_price_schedule", interval, func(ctx context.Context) error {
+		_, err := a.ProductUsecase.ApplyDuePriceChanges(ctx, cfg.BatchSize)
+		return err
+	})
+
+	// Price changes scheduled by sandbox requests come into effect as well
+	if a.sandboxPool != nil {
+		a.scheduler.Every("sandbox_product_price_schedule", interval, func(ctx context.Context) error {
+			_, err := a.ProductUsecase.ApplyDuePriceChanges(sandbox.With(ctx), cfg.BatchSize)
+			return err
+		})
+	}
+}
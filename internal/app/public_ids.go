@@ -0,0 +1,20 @@
+package app
+
+import (
+	"github.com/erry-az/go-init/config"
+	handlergrpc "github.com/erry-az/go-init/internal/handler/grpc"
+	"github.com/erry-az/go-init/pkg/publicid"
+)
+
+// publicIDs creates the codecs of the public IDs of each entity
+func publicIDs(cfg config.PublicIDConfig) (handlergrpc.PublicIDs, error) {
+	user, err := publicid.New(cfg.User, []byte(cfg.Key), "user")
+	if err != nil {
+		return handlergrpc.PublicIDs{}, err
+	}
+	product, err := publicid.New(cfg.Product, []byte(cfg.Key), "product")
+	if err != nil {
+		return handlergrpc.PublicIDs{}, err
+	}
+	return handlergrpc.PublicIDs{User: user, Product: product}, nil
+}
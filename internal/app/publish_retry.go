@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/publishretry"
+	"github.com/erry-az/go-init/pkg/watmil"
+)
+
+// newPublishFailurePolicy converts the configured publish failure modes
+func newPublishFailurePolicy(cfg config.PublishFailureConfig) watmil.PublishFailurePolicy {
+	policy := watmil.PublishFailurePolicy{
+		Default: watmil.PublishMode(cfg.Default),
+		Events:  make(map[string]watmil.PublishMode),
+	}
+	for event, mode := range cfg.Modes() {
+		policy.Events[event] = watmil.PublishMode(mode)
+	}
+	return policy
+}
+
+// initPublishRetry schedules publishing the events stored while the broker
+// was down. Every instance runs it; concurrent runs skip each other's events.
+func (a *App) initPublishRetry(retries *publishretry.Store) error {
+	cfg := a.config.Events.PublishFailure
+
+	// Stored events are already stamped and encrypted, so they bypass the event bus
+	publisher, err := a.broker.NewPublisher(a.logger)
+	if err != nil {
+		slog.Error("Failed to create publisher for stored events", slog.Any("error", err))
+		return err
+	}
+
+	interval := cfg.RetryInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	batchSize := cfg.RetryBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	a.scheduler.Every("event_publish_retry", interval, func(ctx context.Context) error {
+		published, err := retries.Redeliver(ctx, publisher, batchSize)
+		if published > 0 {
+			slog.Info("Published stored events", slog.Int("count", published))
+		}
+		return err
+	})
+	return nil
+}
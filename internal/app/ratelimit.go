@@ -0,0 +1,59 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/clientid"
+	"github.com/erry-az/go-init/internal/ipaccess"
+	"github.com/erry-az/go-init/pkg/ratelimit"
+)
+
+// rateLimiting holds the limiters of gRPC calls and gateway requests. They are
+// separate so a gateway request and the gRPC call made for it are not
+// counted against the same bucket twice.
+type rateLimiting struct {
+	grpc     *ratelimit.Limiter
+	http     *ratelimit.Limiter
+	proxies  *ipaccess.Controller
+	byAPIKey bool
+	// keys are the API keys callers are counted by with byAPIKey
+	keys clientid.Keys
+}
+
+// initRateLimit creates the limiters of servers.rate_limit. Client addresses
+// are resolved through the IP access controller, or through one believing
+// only the trusted proxies while access control is disabled.
+func (a *App) initRateLimit() error {
+	cfg := a.config.Servers.RateLimit
+
+	proxies := a.ipAccess
+	if proxies == nil {
+		trustedProxies, err := ipaccess.ParsePrefixes(a.config.Servers.IPAccess.TrustedProxies)
+		if err != nil {
+			return fmt.Errorf("servers.ip_access.trusted_proxies: %w", err)
+		}
+		proxies = ipaccess.NewController(ipaccess.Rules{}, trustedProxies, nil)
+	}
+
+	limit := ratelimit.Limit{Rate: cfg.Rate, Burst: cfg.Burst}
+	rules := make([]ratelimit.Rule, 0, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		rules = append(rules, ratelimit.Rule{
+			Prefix: route.Prefix,
+			Limit:  ratelimit.Limit{Rate: route.Rate, Burst: route.Burst},
+		})
+	}
+
+	a.rateLimit = &rateLimiting{
+		grpc:     ratelimit.New(limit, rules),
+		http:     ratelimit.New(limit, rules),
+		proxies:  proxies,
+		byAPIKey: cfg.Key == config.RateLimitKeyAPIKey,
+		keys:     clientid.NewKeys(a.apiKeys()),
+	}
+
+	slog.Info("Rate limiting enabled", "key", cfg.Key, "rate", cfg.Rate, "burst", cfg.Burst, "routes", len(rules))
+	return nil
+}
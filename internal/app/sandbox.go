@@ -0,0 +1,55 @@
+package app
+
+import (
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/repository"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/sandbox"
+)
+
+// initSandboxDatabase copies the tables of the main database to the sandbox
+// schema and opens the pool sandbox requests query it through
+func (a *App) initSandboxDatabase() error {
+	cfg := a.config.Sandbox
+
+	a.sandboxSchema = repository.NewSandboxSchema(a.dbPool, cfg.Schema)
+	if err := a.sandboxSchema.Prepare(a.ctx); err != nil {
+		slog.Error("Failed to prepare sandbox schema", "schema", cfg.Schema, slog.Any("error", err))
+		return err
+	}
+
+	options := poolOptions(a.config.Databases)
+	options.SearchPath = a.sandboxSchema.SearchPath()
+	pool, err := repository.NewPool(a.ctx, a.config.Databases.DbDsn, options)
+	if err != nil {
+		slog.Error("Failed to create sandbox pool", slog.Any("error", err))
+		return err
+	}
+
+	if err := pool.Ping(a.ctx); err != nil {
+		slog.Error("Failed to ping sandbox database", slog.Any("error", err))
+		pool.Close()
+		return err
+	}
+
+	a.sandboxPool = pool
+	a.sandbox = sandbox.NewDetector(cfg)
+
+	slog.Info("Sandbox mode enabled", "schema", cfg.Schema, "api_keys", len(cfg.APIKeys), "header", cfg.Header)
+	return nil
+}
+
+// mainDB returns the main database, routed to the sandbox schema for sandbox
+// requests when sandbox mode is enabled
+func (a *App) mainDB() sqlc.DBTX {
+	if a.sandboxPool == nil {
+		return a.dbPool
+	}
+	return repository.NewSandboxRouter(a.dbPool, a.sandboxPool)
+}
+
+// initSandboxPurge schedules deleting the sandbox data
+func (a *App) initSandboxPurge() {
+	a.scheduler.Every("sandbox_purge", a.config.Sandbox.PurgeInterval, a.sandboxSchema.Purge)
+}
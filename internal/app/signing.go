@@ -0,0 +1,31 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/server/http"
+)
+
+// newSigningOptions maps the configured signing keys by API key
+func (a *App) newSigningOptions() (http.SigningOptions, error) {
+	cfg := a.config.Servers.Signing
+
+	keys := make(map[string]http.SigningKey, len(cfg.Keys))
+	for i, key := range cfg.Keys {
+		if key.APIKey == "" || key.Secret == "" {
+			return http.SigningOptions{}, fmt.Errorf("servers.request_signing.keys[%d] needs an api_key and a secret", i)
+		}
+		if _, ok := keys[key.APIKey]; ok {
+			return http.SigningOptions{}, fmt.Errorf("servers.request_signing.keys[%d] repeats an API key", i)
+		}
+		keys[key.APIKey] = http.SigningKey{Secret: []byte(key.Secret), Required: key.Required}
+	}
+
+	slog.Info("Request signing enabled", "keys", len(keys))
+	return http.SigningOptions{
+		Keys:        keys,
+		Tolerance:   cfg.Tolerance,
+		MaxBodySize: cfg.MaxBodySize,
+	}, nil
+}
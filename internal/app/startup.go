@@ -0,0 +1,51 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/pkg/jsonschema"
+	"github.com/erry-az/go-init/pkg/lazy"
+	"github.com/erry-az/go-init/pkg/validation"
+)
+
+// lazySchemas loads the attribute schema registry on the first product write
+type lazySchemas struct {
+	registry *lazy.Value[*jsonschema.Registry]
+}
+
+func (s lazySchemas) Validate(category string, attributes any) error {
+	registry, err := s.registry.Get()
+	if err != nil {
+		return fmt.Errorf("attribute schemas unavailable: %w", err)
+	}
+	return registry.Validate(category, attributes)
+}
+
+// newAttributeSchemas loads product attribute schemas now or on first use,
+// depending on the startup mode of the attribute_schemas component
+func (a *App) newAttributeSchemas() (usecase.AttributeValidator, error) {
+	load := func() (*jsonschema.Registry, error) {
+		return jsonschema.NewRegistry(a.config.Product.AttributeSchemas)
+	}
+
+	if a.config.Startup.Lazy(config.ComponentAttributeSchemas) {
+		slog.Info("Product attribute schemas will load on first use")
+		return lazySchemas{registry: lazy.New(config.ComponentAttributeSchemas, load)}, nil
+	}
+
+	return load()
+}
+
+// newValidator compiles request validation rules for the public API at boot,
+// or leaves each message's rules to compile on its first request in lazy mode
+func (a *App) newValidator() (*validation.Validator, error) {
+	if a.config.Startup.Lazy(config.ComponentValidation) {
+		slog.Info("Request validation rules will compile on first use")
+		return validation.New(a.config.Validation.Mode)
+	}
+
+	return validation.New(a.config.Validation.Mode, apiProtoPackage)
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/handler/consumer"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/server/http"
+	"github.com/erry-az/go-init/internal/usecase"
+)
+
+// initWebhooks creates the webhook usecase storing the deliveries of the
+// enabled providers and registers their processing on the command subscriber
+// of this instance. Deliveries are kept in the main database, as they arrive
+// without a tenant.
+func (a *App) initWebhooks() error {
+	handlers := make(map[string]usecase.WebhookHandler)
+	if a.config.Servers.Webhooks.Stripe.Enabled {
+		handlers[domain.WebhookProviderStripe] = usecase.HandleStripeWebhook
+	}
+	a.WebhookUsecase = usecase.NewWebhookUsecase(sqlc.New(a.dbPool), a.commandBus, handlers)
+
+	subscriber, err := a.commandSubscriber()
+	if err != nil {
+		return err
+	}
+
+	err = subscriber.RegisterCommandHandlers(consumer.NewWebhookConsumer(a.WebhookUsecase).AddHandlers)
+	if err != nil {
+		slog.Error("Failed to register webhook handlers", slog.Any("error", err))
+		return err
+	}
+
+	slog.Info("Webhooks enabled", "providers", len(handlers))
+	return nil
+}
+
+// webhookOptions returns the signature verifiers of the enabled providers
+func (a *App) webhookOptions() http.WebhookOptions {
+	cfg := a.config.Servers.Webhooks
+	verifiers := make(map[string]http.WebhookVerifier)
+	if cfg.Stripe.Enabled {
+		verifiers[domain.WebhookProviderStripe] = http.StripeWebhooks{
+			Secret:    []byte(cfg.Stripe.SigningSecret),
+			Tolerance: cfg.Stripe.Tolerance,
+		}
+	}
+
+	return http.WebhookOptions{
+		Verifiers:   verifiers,
+		MaxBodySize: cfg.MaxBodySize,
+	}
+}
@@ -0,0 +1,475 @@
+// Package archive moves old rows of the append-only event and audit tables to
+// compressed NDJSON objects in blob storage, keeping the hot tables small.
+// Every archived batch is recorded in a manifest, in the archive_manifests
+// table and as a JSON object next to the data, from which it can be restored.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/erry-az/go-init/internal/audit"
+	"github.com/erry-az/go-init/internal/eventhistory"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Tables archived, the sources of manifests
+const (
+	SourceEntityEvents = "entity_events"
+	SourceAuditLog     = "audit_log"
+)
+
+// Defaults used when Options leaves a value unset
+const (
+	defaultRetention  = 90 * 24 * time.Hour
+	defaultBatchSize  = 10000
+	defaultMaxBatches = 10
+)
+
+var (
+	// ErrManifestNotFound is returned when restoring a manifest that does not exist
+	ErrManifestNotFound = errors.New("archive: manifest not found")
+	// ErrChecksumMismatch is returned when an archived object does not match its manifest
+	ErrChecksumMismatch = errors.New("archive: object does not match its manifest checksum")
+)
+
+// Counts keyed by source, exported on /debug/vars
+var (
+	archivedRows    = expvar.NewMap("archive_rows_total")
+	archivedObjects = expvar.NewMap("archive_objects_total")
+)
+
+// DB runs the archive queries and transactions, e.g. a *pgxpool.Pool
+type DB interface {
+	sqlc.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Options configures which rows are archived and how many per run
+type Options struct {
+	// Retention keeps rows this long in the database before archiving them
+	Retention time.Duration
+	// BatchSize is how many rows go into one archive object
+	BatchSize int
+	// MaxBatches caps the objects of one run so a large backlog is worked off over several runs
+	MaxBatches int
+}
+
+// Manifest describes an archived batch of rows. It is stored as JSON next to
+// the object holding the rows, so archives stay readable without the
+// database.
+type Manifest struct {
+	Source    string    `json:"source"`
+	ObjectKey string    `json:"object_key"`
+	FirstID   int64     `json:"first_id"`
+	LastID    int64     `json:"last_id"`
+	RowCount  int64     `json:"row_count"`
+	OldestAt  time.Time `json:"oldest_at"`
+	NewestAt  time.Time `json:"newest_at"`
+	SizeBytes int64     `json:"size_bytes"`
+	// Checksum is the SHA-256 of the object
+	Checksum []byte `json:"checksum"`
+	// FirstPrevHash and LastHash chain the archived audit records to the
+	// records before and after them
+	FirstPrevHash []byte `json:"first_prev_hash,omitempty"`
+	LastHash      []byte `json:"last_hash,omitempty"`
+}
+
+// Archiver archives rows older than the retention in batches, each written
+// to the store before it is deleted from the database
+type Archiver struct {
+	db    DB
+	store Store
+	opts  Options
+	now   func() time.Time
+}
+
+// New creates an archiver moving rows of db to store
+func New(db DB, store Store, opts Options) *Archiver {
+	if opts.Retention <= 0 {
+		opts.Retention = defaultRetention
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.MaxBatches <= 0 {
+		opts.MaxBatches = defaultMaxBatches
+	}
+	return &Archiver{db: db, store: store, opts: opts, now: time.Now}
+}
+
+// Run archives the rows of source older than the retention until a batch
+// comes back short or MaxBatches is reached, and returns how many it archived
+func (a *Archiver) Run(ctx context.Context, source string) (int64, error) {
+	cutoff := pgtype.Timestamptz{Time: a.now().Add(-a.opts.Retention), Valid: true}
+
+	var total int64
+	for batch := 0; batch < a.opts.MaxBatches; batch++ {
+		n, err := a.archiveBatch(ctx, source, cutoff)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < int64(a.opts.BatchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info("Archive finished", "source", source, "rows", total)
+	}
+	return total, nil
+}
+
+// archiveBatch archives the oldest rows of source after the last archived
+// one. Rows restored from an archive are at or before it, so they stay.
+func (a *Archiver) archiveBatch(ctx context.Context, source string, cutoff pgtype.Timestamptz) (int64, error) {
+	q := sqlc.New(a.db)
+	last, err := q.GetLastArchiveManifest(ctx, source)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("archive: reading last manifest of %s: %w", source, err)
+	}
+
+	var b *batch
+	switch source {
+	case SourceEntityEvents:
+		b, err = a.entityEventsBatch(ctx, q, last, cutoff)
+	case SourceAuditLog:
+		b, err = a.auditBatch(ctx, q, last, cutoff)
+	default:
+		return 0, fmt.Errorf("archive: unknown source %q", source)
+	}
+	if err != nil || b == nil {
+		return 0, err
+	}
+
+	manifest, err := a.upload(ctx, source, b)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.commit(ctx, manifest, cutoff); err != nil {
+		return 0, err
+	}
+
+	archivedRows.Add(source, manifest.RowCount)
+	archivedObjects.Add(source, 1)
+	return manifest.RowCount, nil
+}
+
+// batch is a run of rows about to be archived, encoded one JSON object per line
+type batch struct {
+	rows          [][]byte
+	firstID       int64
+	lastID        int64
+	oldest        time.Time
+	newest        time.Time
+	firstPrevHash []byte
+	lastHash      []byte
+}
+
+func (b *batch) add(id int64, at time.Time, row any) error {
+	line, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Errorf("archive: encoding row %d: %w", id, err)
+	}
+	if len(b.rows) == 0 {
+		b.firstID, b.oldest, b.newest = id, at, at
+	}
+	b.rows = append(b.rows, line)
+	b.lastID = id
+	b.oldest = minTime(b.oldest, at)
+	b.newest = maxTime(b.newest, at)
+	return nil
+}
+
+func (a *Archiver) entityEventsBatch(ctx context.Context, q *sqlc.Queries, last sqlc.ArchiveManifest, cutoff pgtype.Timestamptz) (*batch, error) {
+	events, err := q.ListArchivableEntityEvents(ctx, sqlc.ListArchivableEntityEventsParams{
+		AfterID:         last.LastID,
+		PublishedBefore: cutoff,
+		BatchSize:       int32(a.opts.BatchSize),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("archive: listing entity events: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	b := &batch{}
+	for _, event := range events {
+		// Archives outlive the database rows, so secrets recorded before
+		// their fields were marked are left out
+		event, err := eventhistory.RedactRow(event)
+		if err != nil {
+			return nil, fmt.Errorf("archive: %w", err)
+		}
+		if err := b.add(event.ID, event.PublishedAt.Time, event); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// auditBatch reads the oldest audit records, refusing to archive a broken
+// chain: deleting the records would destroy the evidence of tampering.
+// Records are archived unchanged, as redacting them would break their
+// hashes; the audit consumer redacts events before they are recorded.
+func (a *Archiver) auditBatch(ctx context.Context, q *sqlc.Queries, last sqlc.ArchiveManifest, cutoff pgtype.Timestamptz) (*batch, error) {
+	records, err := q.ListArchivableAuditRecords(ctx, sqlc.ListArchivableAuditRecordsParams{
+		AfterSequence:  last.LastID,
+		RecordedBefore: cutoff,
+		BatchSize:      int32(a.opts.BatchSize),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("archive: listing audit records: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	b := &batch{firstPrevHash: records[0].PrevHash}
+	if last.LastHash != nil && records[0].Sequence == last.LastID+1 && !bytes.Equal(records[0].PrevHash, last.LastHash) {
+		return nil, fmt.Errorf("archive: audit record %d does not chain to the last archived record", records[0].Sequence)
+	}
+	for i, record := range records {
+		if i > 0 && (record.Sequence != records[i-1].Sequence+1 || !bytes.Equal(record.PrevHash, records[i-1].Hash)) {
+			return nil, fmt.Errorf("archive: audit record %d does not chain to the record before it", record.Sequence)
+		}
+		if !bytes.Equal(record.Hash, audit.Hash(record)) {
+			return nil, fmt.Errorf("archive: audit record %d does not match its hash", record.Sequence)
+		}
+		if err := b.add(record.Sequence, record.RecordedAt.Time, record); err != nil {
+			return nil, err
+		}
+	}
+	b.lastHash = records[len(records)-1].Hash
+	return b, nil
+}
+
+// upload writes the batch and its manifest to the store. An object left by
+// a run that failed before committing is overwritten by the next one.
+func (a *Archiver) upload(ctx context.Context, source string, b *batch) (Manifest, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, row := range b.rows {
+		zw.Write(row)
+		zw.Write([]byte{'\n'})
+	}
+	if err := zw.Close(); err != nil {
+		return Manifest{}, fmt.Errorf("archive: compressing %s rows: %w", source, err)
+	}
+	data := buf.Bytes()
+	checksum := sha256.Sum256(data)
+
+	manifest := Manifest{
+		Source:        source,
+		ObjectKey:     fmt.Sprintf("%s/%s/%s-%d-%d.ndjson.gz", source, b.oldest.UTC().Format("2006/01"), source, b.firstID, b.lastID),
+		FirstID:       b.firstID,
+		LastID:        b.lastID,
+		RowCount:      int64(len(b.rows)),
+		OldestAt:      b.oldest,
+		NewestAt:      b.newest,
+		SizeBytes:     int64(len(data)),
+		Checksum:      checksum[:],
+		FirstPrevHash: b.firstPrevHash,
+		LastHash:      b.lastHash,
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("archive: encoding manifest: %w", err)
+	}
+
+	if err := a.store.Put(ctx, manifest.ObjectKey, data); err != nil {
+		return Manifest{}, err
+	}
+	if err := a.store.Put(ctx, manifest.ObjectKey+".manifest.json", manifestJSON); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, nil
+}
+
+// commit records the manifest and deletes the archived rows in one
+// transaction, failing when the rows changed since they were read
+func (a *Archiver) commit(ctx context.Context, manifest Manifest, cutoff pgtype.Timestamptz) error {
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("archive: beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := sqlc.New(tx)
+	_, err = q.CreateArchiveManifest(ctx, sqlc.CreateArchiveManifestParams{
+		Source:        manifest.Source,
+		ObjectKey:     manifest.ObjectKey,
+		FirstID:       manifest.FirstID,
+		LastID:        manifest.LastID,
+		RowCount:      manifest.RowCount,
+		OldestAt:      pgtype.Timestamptz{Time: manifest.OldestAt, Valid: true},
+		NewestAt:      pgtype.Timestamptz{Time: manifest.NewestAt, Valid: true},
+		SizeBytes:     manifest.SizeBytes,
+		Checksum:      manifest.Checksum,
+		FirstPrevHash: manifest.FirstPrevHash,
+		LastHash:      manifest.LastHash,
+	})
+	if err != nil {
+		return fmt.Errorf("archive: recording manifest of %s: %w", manifest.ObjectKey, err)
+	}
+
+	// The audit trail only lets records covered by a manifest be deleted
+	var deleted int64
+	switch manifest.Source {
+	case SourceEntityEvents:
+		deleted, err = q.DeleteArchivedEntityEvents(ctx, sqlc.DeleteArchivedEntityEventsParams{
+			FirstID:         manifest.FirstID,
+			LastID:          manifest.LastID,
+			PublishedBefore: cutoff,
+		})
+	case SourceAuditLog:
+		deleted, err = q.DeleteArchivedAuditRecords(ctx, sqlc.DeleteArchivedAuditRecordsParams{
+			FirstSequence: manifest.FirstID,
+			LastSequence:  manifest.LastID,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("archive: deleting archived %s rows: %w", manifest.Source, err)
+	}
+	if deleted != manifest.RowCount {
+		return fmt.Errorf("archive: %d %s rows changed while archiving %s, retrying next run", manifest.RowCount-deleted, manifest.Source, manifest.ObjectKey)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("archive: committing %s: %w", manifest.ObjectKey, err)
+	}
+	return nil
+}
+
+// Restore puts the rows archived under the manifest with id back into the
+// database, skipping rows that are there already, and returns how many it
+// inserted. Restored rows are not archived again.
+func (a *Archiver) Restore(ctx context.Context, id int64) (int64, error) {
+	manifest, err := sqlc.New(a.db).GetArchiveManifest(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %d", ErrManifestNotFound, id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("archive: reading manifest %d: %w", id, err)
+	}
+
+	data, err := a.store.Get(ctx, manifest.ObjectKey)
+	if err != nil {
+		return 0, err
+	}
+	if checksum := sha256.Sum256(data); !bytes.Equal(checksum[:], manifest.Checksum) {
+		return 0, fmt.Errorf("%w: %s", ErrChecksumMismatch, manifest.ObjectKey)
+	}
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("archive: beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := sqlc.New(tx)
+	var restored int64
+	err = eachRow(data, func(line []byte) error {
+		n, err := restoreRow(ctx, q, manifest.Source, line)
+		restored += n
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("archive: restoring %s: %w", manifest.ObjectKey, err)
+	}
+
+	if err := q.MarkArchiveManifestRestored(ctx, manifest.ID); err != nil {
+		return 0, fmt.Errorf("archive: marking manifest %d restored: %w", manifest.ID, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("archive: committing restore of %s: %w", manifest.ObjectKey, err)
+	}
+
+	slog.Info("Archive restored", "source", manifest.Source, "manifest", manifest.ID, "rows", restored)
+	return restored, nil
+}
+
+func restoreRow(ctx context.Context, q *sqlc.Queries, source string, line []byte) (int64, error) {
+	switch source {
+	case SourceEntityEvents:
+		var event sqlc.EntityEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return 0, err
+		}
+		return q.RestoreEntityEvent(ctx, sqlc.RestoreEntityEventParams{
+			ID:            event.ID,
+			AggregateType: event.AggregateType,
+			AggregateID:   event.AggregateID,
+			EventID:       event.EventID,
+			EventName:     event.EventName,
+			Metadata:      event.Metadata,
+			Payload:       event.Payload,
+			PublishedAt:   event.PublishedAt,
+		})
+	case SourceAuditLog:
+		var record sqlc.AuditLog
+		if err := json.Unmarshal(line, &record); err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(record.Hash, audit.Hash(record)) {
+			return 0, fmt.Errorf("audit record %d does not match its hash", record.Sequence)
+		}
+		return q.InsertAuditRecord(ctx, sqlc.InsertAuditRecordParams{
+			Sequence:   record.Sequence,
+			EventID:    record.EventID,
+			EventName:  record.EventName,
+			Payload:    record.Payload,
+			RecordedAt: record.RecordedAt,
+			PrevHash:   record.PrevHash,
+			Hash:       record.Hash,
+		})
+	default:
+		return 0, fmt.Errorf("unknown source %q", source)
+	}
+}
+
+// eachRow calls fn with every line of a compressed NDJSON object
+func eachRow(data []byte, fn func(line []byte) error) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	scanner := bufio.NewScanner(zr)
+	// Rows hold whole event payloads
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,155 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// s3Timeout bounds a single object request
+const s3Timeout = 5 * time.Minute
+
+// s3Store keeps objects in an S3 bucket, or an S3 compatible one such as
+// MinIO, with requests signed with the credentials of the AWS environment
+type s3Store struct {
+	client   *http.Client
+	signer   *v4.Signer
+	creds    aws.CredentialsProvider
+	region   string
+	endpoint *url.URL
+	bucket   string
+	prefix   string
+	// pathStyle addresses the bucket in the path rather than the host name
+	pathStyle bool
+}
+
+func newS3Store(ctx context.Context, bucket, prefix string, opts StoreOptions) (*s3Store, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(opts.Region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("archive: loading aws config: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("archive: no AWS region configured for bucket %s", bucket)
+	}
+
+	s := &s3Store{
+		client: &http.Client{Timeout: s3Timeout},
+		signer: v4.NewSigner(),
+		creds:  cfg.Credentials,
+		region: cfg.Region,
+		bucket: bucket,
+		prefix: prefix,
+	}
+	if opts.Endpoint != "" {
+		s.endpoint, err = url.Parse(opts.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("archive: parsing S3 endpoint: %w", err)
+		}
+		s.pathStyle = true
+	} else {
+		s.endpoint = &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, cfg.Region)}
+	}
+	return s, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return fmt.Errorf("archive: storing %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("archive: storing %s: %s", key, s3Error(resp))
+	}
+	return nil
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("archive: reading %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	default:
+		return nil, fmt.Errorf("archive: reading %s: %s", key, s3Error(resp))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("archive: reading %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// do sends a request for the object under key signed with AWS Signature V4
+func (s *s3Store) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = int64(len(body))
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	creds, err := s.creds.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving aws credentials: %w", err)
+	}
+	if err := s.signer.SignHTTP(ctx, creds, req, payloadHash, "s3", s.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("signing request: %w", err)
+	}
+	return s.client.Do(req)
+}
+
+func (s *s3Store) objectURL(key string) string {
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+	if s.pathStyle {
+		key = s.bucket + "/" + key
+	}
+
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	return u.String()
+}
+
+// s3Error describes a failed response by its status and error code
+func s3Error(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var code string
+	if _, rest, ok := strings.Cut(string(body), "<Code>"); ok {
+		code, _, _ = strings.Cut(rest, "</Code>")
+	}
+	if code == "" {
+		return fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status %d: %s", resp.StatusCode, code)
+}
@@ -0,0 +1,110 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrObjectNotFound is returned by Store.Get for keys that were never stored
+var ErrObjectNotFound = errors.New("archive: object not found")
+
+// Store keeps archive objects in blob storage under slash separated keys
+type Store interface {
+	// Put stores data under key, replacing an object stored there before
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the object stored under key
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// StoreOptions configures the store OpenStore opens
+type StoreOptions struct {
+	// URL locates the objects: s3://bucket/prefix, or file:///path or a
+	// plain path for a local directory, e.g. a mounted bucket
+	URL string
+	// Region is the S3 region, from the AWS environment when empty
+	Region string
+	// Endpoint overrides the S3 endpoint, e.g. MinIO, addressing buckets by path
+	Endpoint string
+}
+
+// OpenStore opens the store opts.URL names
+func OpenStore(ctx context.Context, opts StoreOptions) (Store, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("archive: parsing store URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("archive: store URL %q names no bucket", opts.URL)
+		}
+		return newS3Store(ctx, u.Host, strings.Trim(u.Path, "/"), opts)
+	case "file":
+		return NewDirStore(u.Path), nil
+	case "":
+		return NewDirStore(opts.URL), nil
+	default:
+		return nil, fmt.Errorf("archive: unsupported store scheme %q", u.Scheme)
+	}
+}
+
+// DirStore keeps objects as files below a directory
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates a store of the files below dir
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+func (s *DirStore) Put(_ context.Context, key string, data []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("archive: creating directory for %s: %w", key, err)
+	}
+
+	// Write aside and rename so a partly written object is never read
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("archive: storing %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("archive: storing %s: %w", key, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("archive: storing %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("archive: storing %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("archive: storing %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *DirStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("archive: reading %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func (s *DirStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
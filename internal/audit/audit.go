@@ -0,0 +1,216 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"expvar"
+	"fmt"
+	"hash"
+	"time"
+
+	"github.com/erry-az/go-init/internal/repository"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrMissingEventID is returned for events without an ID, which could be recorded twice
+var ErrMissingEventID = errors.New("audit: event has no ID")
+
+// genesisHash is the previous hash of the first record
+var genesisHash = make([]byte, sha256.Size)
+
+// archiveSource is the source of the manifests of archived records, see
+// internal/archive
+const archiveSource = "audit_log"
+
+// duplicates counts redelivered events already in the trail
+var duplicates = expvar.NewInt("audit_duplicates_skipped_total")
+
+// Trail is an append-only, hash-chained record of domain events. Every record
+// hashes its content together with the hash of the record before it, so
+// changing, removing or reordering a record breaks every hash after it. The
+// database additionally rejects updates and deletes of recorded entries.
+type Trail struct {
+	db repository.TxBeginner
+}
+
+// New creates a trail stored in db
+func New(db repository.TxBeginner) *Trail {
+	return &Trail{db: db}
+}
+
+// Append records an event at the end of the trail. Events already recorded,
+// e.g. on redelivery, are skipped.
+func (t *Trail) Append(ctx context.Context, eventID, eventName string, payload []byte) error {
+	if eventID == "" {
+		return ErrMissingEventID
+	}
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("audit: beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Appends are serialized so no two records chain to the same one
+	q := sqlc.New(tx)
+	if err := q.LockAuditLog(ctx); err != nil {
+		return fmt.Errorf("audit: locking trail: %w", err)
+	}
+
+	record := sqlc.AuditLog{
+		Sequence:  1,
+		EventID:   eventID,
+		EventName: eventName,
+		Payload:   payload,
+		// Postgres keeps microseconds; hashing more would not verify later
+		RecordedAt: pgtype.Timestamptz{Time: time.Now().UTC().Truncate(time.Microsecond), Valid: true},
+		PrevHash:   genesisHash,
+	}
+	last, err := q.GetLastAuditRecord(ctx)
+	switch {
+	case err == nil:
+		record.Sequence = last.Sequence + 1
+		record.PrevHash = last.Hash
+	case !errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("audit: reading last record: %w", err)
+	}
+	record.Hash = Hash(record)
+
+	inserted, err := q.InsertAuditRecord(ctx, sqlc.InsertAuditRecordParams{
+		Sequence:   record.Sequence,
+		EventID:    record.EventID,
+		EventName:  record.EventName,
+		Payload:    record.Payload,
+		RecordedAt: record.RecordedAt,
+		PrevHash:   record.PrevHash,
+		Hash:       record.Hash,
+	})
+	if err != nil {
+		return fmt.Errorf("audit: recording event %s: %w", eventID, err)
+	}
+	if inserted == 0 {
+		duplicates.Add(1)
+		return nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("audit: committing event %s: %w", eventID, err)
+	}
+	return nil
+}
+
+// Hash returns the hash of record chained to record.PrevHash. Fields are
+// length-prefixed so no two different records hash the same content.
+func Hash(record sqlc.AuditLog) []byte {
+	h := sha256.New()
+	h.Write(record.PrevHash)
+	writeInt(h, record.Sequence)
+	writeField(h, []byte(record.EventID))
+	writeField(h, []byte(record.EventName))
+	writeInt(h, record.RecordedAt.Time.UnixMicro())
+	writeField(h, record.Payload)
+	return h.Sum(nil)
+}
+
+func writeInt(h hash.Hash, v int64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(v))
+	h.Write(buf[:])
+}
+
+func writeField(h hash.Hash, field []byte) {
+	writeInt(h, int64(len(field)))
+	h.Write(field)
+}
+
+// Verification is the result of checking the trail
+type Verification struct {
+	// Records is the number of records checked
+	Records int64
+	// Archived is the number of records skipped as moved to an archive, whose
+	// chain was checked when they were archived
+	Archived int64
+	// Head is the hash of the last intact record, which can be stored
+	// elsewhere to detect the trail being rewritten from scratch later
+	Head []byte
+	// BrokenAt is the sequence of the first record failing verification, 0 when all passed
+	BrokenAt int64
+	// Reason describes why the record at BrokenAt failed
+	Reason string
+}
+
+// Verify walks the trail in order, recomputing every hash and checking that
+// sequences are contiguous and every record chains to the one before it. It
+// stops at the first record failing a check. Records moved to an archive are
+// skipped by the hashes their manifest chains them with.
+func Verify(ctx context.Context, q sqlc.Querier, batchSize int32) (Verification, error) {
+	result := Verification{Head: genesisHash}
+	var after int64
+	for {
+		records, err := q.ListAuditRecords(ctx, sqlc.ListAuditRecordsParams{
+			AfterSequence: after,
+			BatchSize:     batchSize,
+		})
+		if err != nil {
+			return result, fmt.Errorf("audit: reading records after %d: %w", after, err)
+		}
+
+		for _, record := range records {
+			if record.Sequence != after+1 {
+				if err := skipArchived(ctx, q, &result, &after); err != nil {
+					return result, err
+				}
+			}
+
+			switch {
+			case record.Sequence != after+1:
+				result.BrokenAt, result.Reason = after+1, fmt.Sprintf("missing, next record is %d", record.Sequence)
+			case !bytes.Equal(record.PrevHash, result.Head):
+				result.BrokenAt, result.Reason = record.Sequence, "does not chain to the previous record"
+			case !bytes.Equal(record.Hash, Hash(record)):
+				result.BrokenAt, result.Reason = record.Sequence, "content does not match its hash"
+			}
+			if result.BrokenAt != 0 {
+				return result, nil
+			}
+
+			result.Records++
+			result.Head = record.Hash
+			after = record.Sequence
+		}
+
+		if len(records) < int(batchSize) {
+			return result, skipArchived(ctx, q, &result, &after)
+		}
+	}
+}
+
+// skipArchived moves past the archived records following the one at after,
+// as long as their manifests chain on from result.Head
+func skipArchived(ctx context.Context, q sqlc.Querier, result *Verification, after *int64) error {
+	for {
+		manifest, err := q.GetArchiveManifestStartingAt(ctx, sqlc.GetArchiveManifestStartingAtParams{
+			Source:  archiveSource,
+			FirstID: *after + 1,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("audit: reading archive manifest after %d: %w", *after, err)
+		}
+		if !bytes.Equal(manifest.FirstPrevHash, result.Head) {
+			// Left for the next record to be reported as not chaining
+			return nil
+		}
+
+		result.Archived += manifest.RowCount
+		result.Head = manifest.LastHash
+		*after = manifest.LastID
+	}
+}
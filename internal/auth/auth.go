@@ -0,0 +1,90 @@
+// Package auth authenticates callers with JWTs signed by an identity
+// provider, whose keys are fetched from its JWKS endpoint, and authorizes them
+// against the rules of the method they call and the role-based access control
+// policy.
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed, badly signed,
+	// expired or meant for another audience or issuer
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrKeysUnavailable is returned when the signing keys cannot be fetched
+	ErrKeysUnavailable = errors.New("signing keys unavailable")
+	// ErrUnauthenticated is returned when a method needs a token and none was sent
+	ErrUnauthenticated = errors.New("token is required")
+	// ErrForbidden is returned when a token lacks the scopes a method needs
+	ErrForbidden = errors.New("token lacks the required scopes")
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the caller, the sub claim
+	Subject   string
+	Issuer    string
+	Scopes    []string
+	ExpiresAt time.Time
+	// Claims holds every claim of the token
+	Claims map[string]any
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// WithPrincipal attaches the authenticated caller to ctx
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the authenticated caller of the request ctx belongs
+// to, or false for anonymous requests
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// BearerToken returns the token of an Authorization header value using the
+// Bearer scheme
+func BearerToken(authorization string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Rule states what the callers of a method need, e.g. as annotated on the RPC
+type Rule struct {
+	// Public methods may be called without a token
+	Public bool
+	// Scopes lists the scopes a token must hold, all of them
+	Scopes []string
+}
+
+// Authorize checks principal, nil for anonymous callers, against the rule
+func (r Rule) Authorize(principal *Principal) error {
+	if principal == nil {
+		if r.Public {
+			return nil
+		}
+		return ErrUnauthenticated
+	}
+	for _, scope := range r.Scopes {
+		if !principal.HasScope(scope) {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults used when KeySetOptions leaves a value unset
+const (
+	defaultRefreshInterval = time.Hour
+	// defaultMinRefetchInterval bounds how often tokens signed with unknown
+	// keys make the key set be fetched again, e.g. after a key rotation
+	defaultMinRefetchInterval = 30 * time.Second
+	// maxJWKSSize caps the JWKS document read
+	maxJWKSSize = 1 << 20
+)
+
+// jwksFetches counts JWKS fetches keyed by outcome, ok or error
+var jwksFetches = expvar.NewMap("auth_jwks_fetches_total")
+
+// KeySetOptions configures a KeySet
+type KeySetOptions struct {
+	// Client fetches the key set; http.DefaultClient when nil
+	Client *http.Client
+	// RefreshInterval is how long fetched keys are used before fetching
+	// them again
+	RefreshInterval time.Duration
+	// MinRefetchInterval is how long to wait between fetches prompted by
+	// tokens signed with unknown keys
+	MinRefetchInterval time.Duration
+}
+
+// KeySet holds the public keys of an identity provider, fetched from its JWKS
+// endpoint. Keys are fetched again once RefreshInterval has passed or a token
+// names an unknown key, and the last keys fetched are kept while the endpoint
+// fails.
+type KeySet struct {
+	url  string
+	opts KeySetOptions
+
+	mu          sync.Mutex
+	keys        map[string]publicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// publicKey is a verification key of the set
+type publicKey struct {
+	// alg is the algorithm the key is restricted to, empty when it is not
+	alg string
+	key crypto.PublicKey
+}
+
+// NewKeySet creates a key set fetched from the JWKS document at url
+func NewKeySet(url string, opts KeySetOptions) *KeySet {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = defaultRefreshInterval
+	}
+	if opts.MinRefetchInterval <= 0 {
+		opts.MinRefetchInterval = defaultMinRefetchInterval
+	}
+	return &KeySet{url: url, opts: opts}
+}
+
+// Refresh fetches the keys now, e.g. at startup to surface a misconfigured URL
+func (s *KeySet) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetch(ctx)
+}
+
+// key returns the key named kid. An empty kid selects the only key of a set
+// holding a single one.
+func (s *KeySet) key(ctx context.Context, kid string) (publicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	stale := now.Sub(s.fetchedAt) >= s.opts.RefreshInterval
+	key, found := s.lookup(kid)
+	if (stale || !found) && now.Sub(s.lastAttempt) >= s.opts.MinRefetchInterval {
+		if err := s.fetch(ctx); err != nil {
+			if s.keys == nil {
+				return publicKey{}, fmt.Errorf("%w: %v", ErrKeysUnavailable, err)
+			}
+			slog.Warn("Failed to refresh JWKS, using the keys fetched before", "url", s.url, slog.Any("error", err))
+		}
+		key, found = s.lookup(kid)
+	}
+
+	if !found {
+		if s.keys == nil {
+			return publicKey{}, ErrKeysUnavailable
+		}
+		return publicKey{}, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+func (s *KeySet) lookup(kid string) (publicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// fetch replaces the keys with those of the JWKS document. Keys of types
+// that are not supported are skipped.
+func (s *KeySet) fetch(ctx context.Context) error {
+	s.lastAttempt = time.Now()
+
+	keys, err := s.download(ctx)
+	if err != nil {
+		jwksFetches.Add("error", 1)
+		return err
+	}
+	jwksFetches.Add("ok", 1)
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func (s *KeySet) download(ctx context.Context) (map[string]publicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			slog.Warn("Skipping JWKS key", "kid", k.Kid, slog.Any("error", err))
+			continue
+		}
+		keys[k.Kid] = publicKey{alg: k.Alg, key: key}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS holds no usable signing key")
+	}
+	return keys, nil
+}
+
+// jwk is a JSON Web Key (RFC 7517) of type RSA, EC or OKP
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key of %d bits is too small", n.BitLen())
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
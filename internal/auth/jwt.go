@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	// Register the hashes of the supported algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// defaultScopesClaim holds the scopes of a token, space separated as in RFC 8693
+const defaultScopesClaim = "scope"
+
+// algorithms are the supported signing algorithms and their hashes. Symmetric
+// algorithms are left out as the keys come from a public JWKS.
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+// VerifierOptions configures a Verifier
+type VerifierOptions struct {
+	// Issuer is the iss claim tokens must carry
+	Issuer string
+	// Audiences lists the accepted aud claims; tokens must be meant for one
+	Audiences []string
+	// Algorithms restricts the accepted signing algorithms; every supported
+	// one when empty
+	Algorithms []string
+	// Leeway tolerates clock skew when checking exp and nbf
+	Leeway time.Duration
+	// ScopesClaim names the claim holding the scopes, as a space separated
+	// string or an array; scope when empty
+	ScopesClaim string
+}
+
+// Verifier authenticates callers by the JWTs they send
+type Verifier struct {
+	keys *KeySet
+	opts VerifierOptions
+	now  func() time.Time
+}
+
+// NewVerifier creates a verifier of tokens signed with keys
+func NewVerifier(keys *KeySet, opts VerifierOptions) (*Verifier, error) {
+	for _, alg := range opts.Algorithms {
+		if _, ok := algorithms[alg]; !ok {
+			return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+		}
+	}
+	if opts.ScopesClaim == "" {
+		opts.ScopesClaim = defaultScopesClaim
+	}
+	return &Verifier{keys: keys, opts: opts, now: time.Now}, nil
+}
+
+// Verify checks the signature and claims of token and returns its principal.
+// It fails with ErrInvalidToken, or ErrKeysUnavailable when the signing keys
+// could not be fetched.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	hash, ok := algorithms[header.Alg]
+	if !ok || (len(v.opts.Algorithms) > 0 && !slices.Contains(v.opts.Algorithms, header.Alg)) {
+		return nil, fmt.Errorf("%w: signing algorithm %q is not accepted", ErrInvalidToken, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("%w: key %q is not for %s", ErrInvalidToken, header.Kid, header.Alg)
+	}
+	if !verifySignature(header.Alg, hash, key.key, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	return v.principal(claims)
+}
+
+// principal checks the registered claims and builds the principal they describe
+func (v *Verifier) principal(claims map[string]any) (*Principal, error) {
+	now := v.now()
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return nil, fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if !now.Before(exp.Add(v.opts.Leeway)) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.opts.Leeway).Before(nbf) {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+
+	issuer, _ := claims["iss"].(string)
+	if v.opts.Issuer != "" && issuer != v.opts.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if len(v.opts.Audiences) > 0 && !slices.ContainsFunc(stringList(claims["aud"]), func(aud string) bool {
+		return slices.Contains(v.opts.Audiences, aud)
+	}) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+
+	var scopes []string
+	switch value := claims[v.opts.ScopesClaim].(type) {
+	case string:
+		scopes = strings.Fields(value)
+	default:
+		scopes = stringList(value)
+	}
+
+	return &Principal{
+		Subject:   subject,
+		Issuer:    issuer,
+		Scopes:    scopes,
+		ExpiresAt: exp,
+		Claims:    claims,
+	}, nil
+}
+
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signed, signature []byte) bool {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, signature)
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, hash, digest, signature) == nil
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		// The signature is R and S in fixed size, big-endian
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size || pub.Curve.Params().BitSize != ecdsaBits[alg] {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+// ecdsaBits is the curve size each ECDSA algorithm is defined for
+var ecdsaBits = map[string]int{"ES256": 256, "ES384": 384, "ES512": 521}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numericDate reads a NumericDate claim, seconds since the epoch
+func numericDate(value any) (time.Time, bool) {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// stringList reads a claim holding a string or an array of strings
+func stringList(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
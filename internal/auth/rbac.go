@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrRoleForbidden is returned when the role of a caller does not allow a method
+	ErrRoleForbidden = errors.New("role does not allow this method")
+	// ErrMethodDenied is returned for methods a deny-by-default policy does not list
+	ErrMethodDenied = errors.New("method is not allowed by the RBAC policy")
+)
+
+// Policy defaults for methods no rule lists
+const (
+	PolicyDefaultAllow = "allow"
+	PolicyDefaultDeny  = "deny"
+)
+
+// maxCachedRoles bounds the roles a RoleResolver keeps; the cache is emptied
+// once it holds that many
+const maxCachedRoles = 10000
+
+// PolicyRule states the role callers of some methods need
+type PolicyRule struct {
+	// Methods lists full method names, or prefixes ending in / or ., e.g.
+	// /proto.api.v1.ProductService/DeleteProduct
+	Methods []string `yaml:"methods"`
+	// Public methods may be called without a token
+	Public bool `yaml:"public"`
+	// Role is the least role callers need, roles including those below them;
+	// empty lets any authenticated caller through
+	Role domain.Role `yaml:"role"`
+}
+
+// Policy is a role-based access control policy, loaded from a file such as
+//
+//	default: allow
+//	rules:
+//	  - methods: [/proto.api.v1.ProductService/ListProducts]
+//	    public: true
+//	  - methods: [/proto.api.v1.ProductService/DeleteProduct]
+//	    role: admin
+//	  - methods: [/proto.api.v1.ProductService/]
+//	    role: editor
+//
+// The first rule listing a method applies to it. Methods no rule lists are
+// left to the other interceptors, or refused when the default is deny.
+type Policy struct {
+	Default string       `yaml:"default"`
+	Rules   []PolicyRule `yaml:"rules"`
+}
+
+// LoadPolicy reads the policy file at path
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: reading RBAC policy: %w", err)
+	}
+	return ParsePolicy(data)
+}
+
+// ParsePolicy parses and checks a YAML policy
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("auth: parsing RBAC policy: %w", err)
+	}
+
+	switch policy.Default {
+	case "":
+		policy.Default = PolicyDefaultAllow
+	case PolicyDefaultAllow, PolicyDefaultDeny:
+	default:
+		return nil, fmt.Errorf("auth: RBAC policy default must be %s or %s, not %q", PolicyDefaultAllow, PolicyDefaultDeny, policy.Default)
+	}
+	for i, rule := range policy.Rules {
+		if len(rule.Methods) == 0 {
+			return nil, fmt.Errorf("auth: RBAC policy rule %d lists no methods", i)
+		}
+		if rule.Public && rule.Role != "" {
+			return nil, fmt.Errorf("auth: RBAC policy rule %d is public but needs role %s", i, rule.Role)
+		}
+		if rule.Role != "" && !rule.Role.IsValid() {
+			return nil, fmt.Errorf("auth: RBAC policy rule %d needs unknown role %q", i, rule.Role)
+		}
+	}
+	return &policy, nil
+}
+
+// Rule returns the first rule listing method, or false when none does
+func (p *Policy) Rule(method string) (PolicyRule, bool) {
+	for _, rule := range p.Rules {
+		for _, m := range rule.Methods {
+			if method == m || ((strings.HasSuffix(m, "/") || strings.HasSuffix(m, ".")) && strings.HasPrefix(method, m)) {
+				return rule, true
+			}
+		}
+	}
+	return PolicyRule{}, false
+}
+
+// PublicMethods lists the methods of the public rules, which need no token
+func (p *Policy) PublicMethods() []string {
+	var methods []string
+	for _, rule := range p.Rules {
+		if rule.Public {
+			methods = append(methods, rule.Methods...)
+		}
+	}
+	return methods
+}
+
+// Authorize checks a call of method by principal, nil for anonymous callers.
+// role is only called when the method needs one, so public methods and
+// methods open to every authenticated caller never resolve roles.
+func (p *Policy) Authorize(ctx context.Context, method string, principal *Principal, role func(context.Context, *Principal) (domain.Role, error)) error {
+	rule, ok := p.Rule(method)
+	if !ok {
+		if p.Default == PolicyDefaultDeny {
+			return ErrMethodDenied
+		}
+		return nil
+	}
+	if rule.Public {
+		return nil
+	}
+	if principal == nil {
+		return ErrUnauthenticated
+	}
+	if rule.Role == "" {
+		return nil
+	}
+
+	granted, err := role(ctx, principal)
+	if err != nil {
+		return err
+	}
+	if !granted.Includes(rule.Role) {
+		return ErrRoleForbidden
+	}
+	return nil
+}
+
+// RoleLookup returns the stored role of the user a token subject names, or
+// false when there is none
+type RoleLookup func(ctx context.Context, subject string) (domain.Role, bool, error)
+
+// RoleResolverOptions configures a RoleResolver
+type RoleResolverOptions struct {
+	// Claim names the token claim holding a role granted by the identity
+	// provider, used over the stored one; empty ignores claims
+	Claim string
+	// Default is the role of callers without one; empty grants none
+	Default domain.Role
+	// CacheTTL is how long a looked up role is reused
+	CacheTTL time.Duration
+}
+
+// RoleResolver finds the role of a principal, from its token or looked up
+// by its subject
+type RoleResolver struct {
+	lookup RoleLookup
+	opts   RoleResolverOptions
+
+	mu    sync.Mutex
+	cache map[string]cachedRole
+}
+
+type cachedRole struct {
+	role    domain.Role
+	expires time.Time
+}
+
+// NewRoleResolver creates a resolver looking stored roles up with lookup
+func NewRoleResolver(lookup RoleLookup, opts RoleResolverOptions) *RoleResolver {
+	return &RoleResolver{
+		lookup: lookup,
+		opts:   opts,
+		cache:  make(map[string]cachedRole),
+	}
+}
+
+// Role returns the role of principal
+func (r *RoleResolver) Role(ctx context.Context, principal *Principal) (domain.Role, error) {
+	if r.opts.Claim != "" {
+		if claim, ok := principal.Claims[r.opts.Claim].(string); ok && domain.Role(claim).IsValid() {
+			return domain.Role(claim), nil
+		}
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	cached, ok := r.cache[principal.Subject]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.role, nil
+	}
+
+	role, found, err := r.lookup(ctx, principal.Subject)
+	if err != nil {
+		return "", fmt.Errorf("auth: looking up role: %w", err)
+	}
+	if !found {
+		role = r.opts.Default
+	}
+
+	r.mu.Lock()
+	if len(r.cache) >= maxCachedRoles {
+		clear(r.cache)
+	}
+	r.cache[principal.Subject] = cachedRole{role: role, expires: now.Add(r.opts.CacheTTL)}
+	r.mu.Unlock()
+	return role, nil
+}
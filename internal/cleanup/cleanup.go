@@ -0,0 +1,114 @@
+package cleanup
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"time"
+
+	"github.com/erry-az/go-init/internal/scheduler"
+)
+
+// Defaults used when Options leaves a value unset
+const (
+	defaultInterval   = time.Hour
+	defaultBatchSize  = 500
+	defaultMaxBatches = 100
+)
+
+// Row counts keyed by job name, exported on /debug/vars
+var (
+	deleted  = expvar.NewMap("cleanup_rows_deleted_total")
+	eligible = expvar.NewMap("cleanup_rows_eligible")
+)
+
+// BatchFunc removes up to limit orphaned records and returns how many it
+// removed. With dryRun it removes nothing and returns how many of up to limit
+// records it would have removed.
+type BatchFunc func(ctx context.Context, limit int, dryRun bool) (int64, error)
+
+// Options configures how often a job runs and how much it removes per run
+type Options struct {
+	Interval  time.Duration
+	BatchSize int
+	// MaxBatches caps the batches of one run so a large backlog is worked off over several runs
+	MaxBatches int
+	// DryRun only counts and logs what would be removed
+	DryRun bool
+}
+
+// Cleaner runs registered cleanup jobs on a scheduler, deleting in batches
+// so no single statement holds locks for long
+type Cleaner struct {
+	scheduler *scheduler.Scheduler
+	defaults  Options
+}
+
+// New creates a cleaner whose jobs fall back to defaults for unset options
+func New(s *scheduler.Scheduler, defaults Options) *Cleaner {
+	if defaults.Interval <= 0 {
+		defaults.Interval = defaultInterval
+	}
+	if defaults.BatchSize <= 0 {
+		defaults.BatchSize = defaultBatchSize
+	}
+	if defaults.MaxBatches <= 0 {
+		defaults.MaxBatches = defaultMaxBatches
+	}
+	return &Cleaner{scheduler: s, defaults: defaults}
+}
+
+// Register schedules fn under name. A dry run in either opts or the defaults wins.
+func (c *Cleaner) Register(name string, fn BatchFunc, opts Options) {
+	if opts.Interval <= 0 {
+		opts.Interval = c.defaults.Interval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = c.defaults.BatchSize
+	}
+	if opts.MaxBatches <= 0 {
+		opts.MaxBatches = c.defaults.MaxBatches
+	}
+	opts.DryRun = opts.DryRun || c.defaults.DryRun
+
+	c.scheduler.Every("cleanup_"+name, opts.Interval, func(ctx context.Context) error {
+		return run(ctx, name, fn, opts)
+	})
+}
+
+// run removes batches until one comes back short or MaxBatches is reached
+func run(ctx context.Context, name string, fn BatchFunc, opts Options) error {
+	if opts.DryRun {
+		n, err := fn(ctx, opts.BatchSize*opts.MaxBatches, true)
+		if err != nil {
+			return err
+		}
+		eligible.Set(name, intVar(n))
+		slog.Info("Cleanup dry run", "job", name, "eligible", n)
+		return nil
+	}
+
+	var total int64
+	for batch := 0; batch < opts.MaxBatches; batch++ {
+		n, err := fn(ctx, opts.BatchSize, false)
+		total += n
+		deleted.Add(name, n)
+		if err != nil {
+			return err
+		}
+		if n < int64(opts.BatchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info("Cleanup finished", "job", name, "deleted", total)
+	}
+	return nil
+}
+
+func intVar(n int64) *expvar.Int {
+	v := new(expvar.Int)
+	v.Set(n)
+	return v
+}
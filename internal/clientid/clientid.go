@@ -0,0 +1,112 @@
+package clientid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/erry-az/go-init/internal/auth"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	clientIDMetadataKey = "x-client-id"
+	apiKeyMetadataKey   = "x-api-key"
+)
+
+// Anonymous identifies callers sending neither a client ID nor an API key
+const Anonymous = "anonymous"
+
+// FromContext identifies the caller of a gRPC request by explicit client ID,
+// falling back to a hash of the API key so raw keys are never recorded
+func FromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return Anonymous
+	}
+
+	var clientID, apiKey string
+	if values := md.Get(clientIDMetadataKey); len(values) > 0 {
+		clientID = values[0]
+	}
+	if values := md.Get(apiKeyMetadataKey); len(values) > 0 {
+		apiKey = values[0]
+	}
+	return FromValues(clientID, apiKey)
+}
+
+// FromHeader is FromContext for HTTP requests, identified by the X-Client-Id
+// and X-Api-Key headers
+func FromHeader(header http.Header) string {
+	return FromValues(header.Get(clientIDMetadataKey), header.Get(apiKeyMetadataKey))
+}
+
+// FromValues identifies a caller by clientID, or a hash of apiKey without one
+func FromValues(clientID, apiKey string) string {
+	if clientID != "" {
+		return clientID
+	}
+
+	if apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+
+	return Anonymous
+}
+
+// Keys identifies callers by the API keys they may send, so that callers are
+// only told apart by keys that were checked rather than by values of their
+// choosing
+type Keys struct {
+	hashes map[[sha256.Size]byte]bool
+}
+
+// NewKeys accepts the API keys of keys
+func NewKeys(keys []string) Keys {
+	hashes := make(map[[sha256.Size]byte]bool, len(keys))
+	for _, key := range keys {
+		hashes[sha256.Sum256([]byte(key))] = true
+	}
+	return Keys{hashes: hashes}
+}
+
+// Identify returns the ID of the caller sending apiKey, a hash of the key as
+// FromValues returns, or false when apiKey is not one of the keys
+func (k Keys) Identify(apiKey string) (string, bool) {
+	if apiKey == "" {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	if !k.hashes[sum] {
+		return "", false
+	}
+	return "key:" + hex.EncodeToString(sum[:8]), true
+}
+
+// Authenticated identifies the caller of a gRPC request by the principal
+// the jwt interceptor authenticated, else by its API key when it is one of
+// the keys, and returns Anonymous otherwise. x-client-id is never trusted.
+func (k Keys) Authenticated(ctx context.Context) string {
+	if principal, ok := auth.FromContext(ctx); ok {
+		return "sub:" + principal.Subject
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(apiKeyMetadataKey); len(values) > 0 {
+		if id, ok := k.Identify(values[0]); ok {
+			return id
+		}
+	}
+	return Anonymous
+}
+
+// AuthenticatedHeader is Authenticated for HTTP requests, which only carry
+// API keys
+func (k Keys) AuthenticatedHeader(header http.Header) string {
+	if id, ok := k.Identify(header.Get(apiKeyMetadataKey)); ok {
+		return id
+	}
+	return Anonymous
+}
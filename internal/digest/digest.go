@@ -0,0 +1,186 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// groupsPerFlush bounds how many due groups one flush emits per rule
+const groupsPerFlush = 100
+
+var (
+	// buffered counts the events buffered for a digest, keyed by rule
+	buffered = expvar.NewMap("event_digest_buffered_total")
+	// emitted counts the digests emitted, keyed by rule
+	emitted = expvar.NewMap("event_digests_emitted_total")
+)
+
+// DB is the database events are buffered in, e.g. a *pgxpool.Pool
+type DB interface {
+	sqlc.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Rule collects the events of one type into digests per group
+type Rule struct {
+	// Name identifies the rule in the buffer and in emitted digests
+	Name string
+	// Window is how long the first event of a group waits for others
+	Window time.Duration
+	// MaxBatchSize emits a digest as soon as that many events are buffered
+	MaxBatchSize int32
+}
+
+// Event is a buffered event
+type Event struct {
+	ID         string
+	Name       string
+	Payload    []byte
+	BufferedAt time.Time
+}
+
+// Batch is the events of one group collected into a digest, oldest first
+type Batch struct {
+	Rule     string
+	GroupKey string
+	Events   []Event
+}
+
+// EmitFunc sends a digest, e.g. publishes it as an event
+type EmitFunc func(ctx context.Context, batch Batch) error
+
+// Aggregator buffers high-frequency events in the database and emits them as
+// one digest per group once the window of the group's first event elapsed or
+// the group holds MaxBatchSize events. Digests are emitted at least once: a
+// digest emitted right before a failed commit is emitted again.
+type Aggregator struct {
+	db   DB
+	emit EmitFunc
+}
+
+// New creates an aggregator buffering in db and sending digests through emit
+func New(db DB, emit EmitFunc) *Aggregator {
+	return &Aggregator{db: db, emit: emit}
+}
+
+// Buffer adds an event to its group through tx, e.g. an inbox transaction
+// keeping redelivered events out of the buffer. It reports whether the group
+// is full, in which case it should be flushed once tx commits.
+func (a *Aggregator) Buffer(ctx context.Context, tx sqlc.Querier, rule Rule, groupKey string, event Event) (bool, error) {
+	err := tx.BufferDigestEvent(ctx, sqlc.BufferDigestEventParams{
+		Digest:    rule.Name,
+		GroupKey:  groupKey,
+		EventID:   event.ID,
+		EventName: event.Name,
+		Payload:   event.Payload,
+	})
+	if err != nil {
+		return false, fmt.Errorf("digest: buffering event %s: %w", event.ID, err)
+	}
+	buffered.Add(rule.Name, 1)
+
+	count, err := tx.CountDigestGroup(ctx, sqlc.CountDigestGroupParams{Digest: rule.Name, GroupKey: groupKey})
+	if err != nil {
+		return false, fmt.Errorf("digest: counting group %q: %w", groupKey, err)
+	}
+	return count >= int64(rule.MaxBatchSize), nil
+}
+
+// Flush emits the digests of rule that are due and returns how many were emitted
+func (a *Aggregator) Flush(ctx context.Context, rule Rule) (int, error) {
+	groups, err := sqlc.New(a.db).ListDueDigestGroups(ctx, sqlc.ListDueDigestGroupsParams{
+		Digest:       rule.Name,
+		DueBefore:    pgtype.Timestamptz{Time: time.Now().Add(-rule.Window), Valid: true},
+		MaxBatchSize: int64(rule.MaxBatchSize),
+		GroupLimit:   groupsPerFlush,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("digest: listing due groups of %s: %w", rule.Name, err)
+	}
+
+	total := 0
+	var errs []error
+	for _, group := range groups {
+		n, err := a.FlushGroup(ctx, rule, group)
+		total += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// FlushGroup emits the digests of one group while they are due, a batch at a
+// time, and returns how many were emitted. A group being flushed elsewhere,
+// e.g. by another instance, is skipped.
+func (a *Aggregator) FlushGroup(ctx context.Context, rule Rule, groupKey string) (int, error) {
+	total := 0
+	for {
+		sent, full, err := a.flushBatch(ctx, rule, groupKey)
+		if sent {
+			total++
+		}
+		if err != nil || !full {
+			return total, err
+		}
+	}
+}
+
+// flushBatch emits the oldest batch of a group if it is due. It reports
+// whether it was emitted and whether it was full, as more may then be due.
+func (a *Aggregator) flushBatch(ctx context.Context, rule Rule, groupKey string) (bool, bool, error) {
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return false, false, fmt.Errorf("digest: beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := sqlc.New(tx)
+	locked, err := q.TryLockDigestGroup(ctx, sqlc.TryLockDigestGroupParams{Digest: rule.Name, GroupKey: groupKey})
+	if err != nil {
+		return false, false, fmt.Errorf("digest: locking group %q: %w", groupKey, err)
+	}
+	if !locked {
+		return false, false, nil
+	}
+
+	rows, err := q.ListDigestGroupEvents(ctx, sqlc.ListDigestGroupEventsParams{
+		Digest:       rule.Name,
+		GroupKey:     groupKey,
+		MaxBatchSize: rule.MaxBatchSize,
+	})
+	if err != nil {
+		return false, false, fmt.Errorf("digest: listing events of group %q: %w", groupKey, err)
+	}
+	full := len(rows) >= int(rule.MaxBatchSize)
+	if len(rows) == 0 || !full && time.Since(rows[0].BufferedAt.Time) < rule.Window {
+		return false, false, nil
+	}
+
+	batch := Batch{Rule: rule.Name, GroupKey: groupKey, Events: make([]Event, len(rows))}
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		batch.Events[i] = Event{ID: row.EventID, Name: row.EventName, Payload: row.Payload, BufferedAt: row.BufferedAt.Time}
+		ids[i] = row.ID
+	}
+	if err := q.DeleteDigestEvents(ctx, ids); err != nil {
+		return false, false, fmt.Errorf("digest: removing events of group %q: %w", groupKey, err)
+	}
+
+	// Emitted last, so a failure leaves the events buffered
+	if err := a.emit(ctx, batch); err != nil {
+		return false, false, fmt.Errorf("digest: emitting group %q: %w", groupKey, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, false, fmt.Errorf("digest: committing group %q: %w", groupKey, err)
+	}
+	emitted.Add(rule.Name, 1)
+	return true, full, nil
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "strings"
+
+// CanonicalEmail returns the form of email that is stored and compared:
+// trimmed and lowercased, with the "+tag" suffix of the local part removed
+// when stripPlusTag is set, so Foo+news@x.com and foo@x.com are one address.
+func CanonicalEmail(email string, stripPlusTag bool) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !stripPlusTag {
+		return email
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+	local, domain := email[:at], email[at:]
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+	return local + domain
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Email templates the notification consumer sends
+const (
+	EmailTemplateWelcome                 = "welcome"
+	EmailTemplateEmailChangeConfirmation = "email_change_confirmation"
+	EmailTemplateEmailChanged            = "email_changed"
+)
+
+// emailTemplateNamePattern is what template names look like, e.g. welcome
+var emailTemplateNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,99}$`)
+
+// EmailTemplate is one version of an outbound email. Subject and TextBody
+// are text/template templates and HTMLBody an html/template one, rendered
+// with the data of the event the email is sent for, e.g. {{.user.name}}.
+type EmailTemplate struct {
+	Name string
+	// Version increases with every update; the highest one is sent
+	Version   int32
+	Subject   string
+	HTMLBody  string
+	TextBody  string
+	CreatedAt time.Time
+}
+
+// RenderedEmail is an email template rendered with the data of one email
+type RenderedEmail struct {
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// Validate checks the name of t and that its templates parse
+func (t *EmailTemplate) Validate() error {
+	if !emailTemplateNamePattern.MatchString(t.Name) {
+		return NewValidationError("template name must be lowercase letters, digits and underscores, starting with a letter")
+	}
+	if strings.TrimSpace(t.Subject) == "" {
+		return NewValidationError("template subject is required")
+	}
+	if strings.TrimSpace(t.HTMLBody) == "" && strings.TrimSpace(t.TextBody) == "" {
+		return NewValidationError("template needs an HTML or a text body")
+	}
+
+	_, err := t.parse()
+	return err
+}
+
+// Render executes the templates of t with data. Referencing a key missing
+// from data fails rather than sending an email with a blank in it.
+func (t *EmailTemplate) Render(data map[string]any) (*RenderedEmail, error) {
+	parsed, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered RenderedEmail
+	var buf bytes.Buffer
+	if err := parsed.subject.Execute(&buf, data); err != nil {
+		return nil, NewValidationError("rendering subject: " + err.Error())
+	}
+	// A subject is a single header line
+	rendered.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	if parsed.html != nil {
+		buf.Reset()
+		if err := parsed.html.Execute(&buf, data); err != nil {
+			return nil, NewValidationError("rendering HTML body: " + err.Error())
+		}
+		rendered.HTMLBody = buf.String()
+	}
+	if parsed.text != nil {
+		buf.Reset()
+		if err := parsed.text.Execute(&buf, data); err != nil {
+			return nil, NewValidationError("rendering text body: " + err.Error())
+		}
+		rendered.TextBody = buf.String()
+	}
+
+	return &rendered, nil
+}
+
+type parsedEmailTemplate struct {
+	subject *template.Template
+	// html and text are nil when the template has no such body
+	html *htmltemplate.Template
+	text *template.Template
+}
+
+func (t *EmailTemplate) parse() (*parsedEmailTemplate, error) {
+	var parsed parsedEmailTemplate
+	var err error
+
+	parsed.subject, err = template.New("subject").Option("missingkey=error").Parse(t.Subject)
+	if err != nil {
+		return nil, NewValidationError("invalid subject template: " + err.Error())
+	}
+	if t.HTMLBody != "" {
+		parsed.html, err = htmltemplate.New("html_body").Option("missingkey=error").Parse(t.HTMLBody)
+		if err != nil {
+			return nil, NewValidationError("invalid HTML body template: " + err.Error())
+		}
+	}
+	if t.TextBody != "" {
+		parsed.text, err = template.New("text_body").Option("missingkey=error").Parse(t.TextBody)
+		if err != nil {
+			return nil, NewValidationError("invalid text body template: " + err.Error())
+		}
+	}
+
+	return &parsed, nil
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Aggregate types events are recorded under, named after the event field
+// holding the aggregate
+const (
+	AggregateUser    = "user"
+	AggregateProduct = "product"
+)
+
+// EntityEvent is a domain event recorded in the history of its aggregate
+type EntityEvent struct {
+	// Sequence orders the events of an aggregate as they were published
+	Sequence int64
+	ID       string
+	Name     string
+	Metadata map[string]string
+	// Payload is the event as published, in JSON
+	Payload     []byte
+	PublishedAt time.Time
+}
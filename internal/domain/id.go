@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// canonicalUUIDLen is the length of a UUID in its hyphenated form, the only
+// one the API accepts, as its protovalidate uuid rules do
+const canonicalUUIDLen = 36
+
+// ParseID parses the ID of a resource, e.g. a product, returning a validation
+// error naming the resource when raw is not a hyphenated UUID
+func ParseID(resource, raw string) (uuid.UUID, error) {
+	if len(raw) != canonicalUUIDLen {
+		return uuid.Nil, NewValidationError(fmt.Sprintf("invalid %s ID: must be a UUID", resource))
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, NewValidationErrorWithCause(fmt.Sprintf("invalid %s ID: must be a UUID", resource), err)
+	}
+	return id, nil
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"net/netip"
+	"time"
+)
+
+// IPRuleList is the list a network access rule belongs to
+type IPRuleList string
+
+const (
+	// IPRuleListDeny refuses the network on every route
+	IPRuleListDeny IPRuleList = "deny"
+	// IPRuleListAdminAllow lets the network reach admin routes
+	IPRuleListAdminAllow IPRuleList = "admin_allow"
+)
+
+// IsValid reports whether l is a known list
+func (l IPRuleList) IsValid() bool {
+	return l == IPRuleListDeny || l == IPRuleListAdminAllow
+}
+
+// IPRule is a network on a deny or admin allow list
+type IPRule struct {
+	List        IPRuleList
+	Network     netip.Prefix
+	Description string
+	// Static rules come from configuration and cannot be changed at runtime
+	Static    bool
+	CreatedAt time.Time
+}
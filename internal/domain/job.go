@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobStatus is the processing state of a background job
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job kinds
+const (
+	JobReindexProducts = "reindex_products"
+)
+
+// Job tracks background work such as a product reindex from its request to
+// its outcome
+type Job struct {
+	ID     uuid.UUID
+	Kind   string
+	Status JobStatus
+	// Processed is how many items the job went through so far
+	Processed int64
+	// Total is how many items the job goes through, 0 while unknown
+	Total int64
+	// Attempts is how many times the job was started, more than one when
+	// it was resumed after a failure
+	Attempts int32
+	// Error explains why the job failed, or while it is retried, why its
+	// last attempt did
+	Error string
+	// ResultRef points at what the job produced once it succeeded
+	ResultRef  string
+	CreatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	UpdatedAt  time.Time
+}
+
+// IsDone reports whether the job finished, successfully or not
+func (j *Job) IsDone() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// ProgressPercent returns how far the job got, from 0 to 100. Running jobs
+// stay below 100 until they succeed.
+func (j *Job) ProgressPercent() int32 {
+	switch {
+	case j.Status == JobSucceeded:
+		return 100
+	case j.Total <= 0:
+		return 0
+	}
+	percent := j.Processed * 100 / j.Total
+	return int32(min(percent, 99))
+}
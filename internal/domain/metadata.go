@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Limits on user-defined metadata of users and products
+const (
+	MaxMetadataPairs       = 32
+	MaxMetadataKeyLength   = 63
+	MaxMetadataValueLength = 256
+)
+
+// metadataKeyPattern keeps keys addressable in filters as metadata.<key>
+var metadataKeyPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9_-]*[a-z0-9])?$`)
+
+// ValidateMetadata checks user-defined metadata against the limits. Keys are
+// lowercase letters, digits, '-' and '_', starting and ending alphanumeric.
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataPairs {
+		return NewValidationError(fmt.Sprintf("metadata has more than %d entries", MaxMetadataPairs))
+	}
+
+	for key, value := range metadata {
+		if len(key) > MaxMetadataKeyLength || !metadataKeyPattern.MatchString(key) {
+			return NewValidationError(fmt.Sprintf("invalid metadata key %q", key))
+		}
+		if len(value) > MaxMetadataValueLength {
+			return NewValidationError(fmt.Sprintf("metadata value of %q is longer than %d bytes", key, MaxMetadataValueLength))
+		}
+	}
+	return nil
+}
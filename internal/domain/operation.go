@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OperationStatus is the processing state of an asynchronous write
+type OperationStatus string
+
+const (
+	OperationPending   OperationStatus = "pending"
+	OperationSucceeded OperationStatus = "succeeded"
+	OperationFailed    OperationStatus = "failed"
+)
+
+// Operation kinds
+const (
+	OperationCreateUser    = "create_user"
+	OperationCreateProduct = "create_product"
+)
+
+// Operation tracks a write accepted for asynchronous processing
+type Operation struct {
+	ID     uuid.UUID
+	Kind   string
+	Status OperationStatus
+	// ResourceID is the created resource once the operation succeeded
+	ResourceID *uuid.UUID
+	// Error explains why the operation failed
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// IsDone reports whether the operation finished, successfully or not
+func (o *Operation) IsDone() bool {
+	return o.Status != OperationPending
+}
@@ -9,21 +9,38 @@ import (
 
 // Product represents a product in the system
 type Product struct {
-	ID        uuid.UUID
-	Name      string
-	Price     decimal.Decimal
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID uuid.UUID
+	// Name and Description are in the default locale, Translations holding
+	// those of other locales
+	Name         string
+	Description  string
+	Translations map[string]ProductTranslation
+	Price        decimal.Decimal
+	Category     string
+	Attributes   map[string]any
+	Metadata     map[string]string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	// Version is incremented by every update, 0 until the product is stored
+	Version int64
+	// Locale is the locale of Name and Description once localized, see Localize
+	Locale string
+	// Status is where the product is in its lifecycle, see SetStatus
+	Status ProductStatus
 }
 
 // NewProduct creates a new product
 func NewProduct(name string, price decimal.Decimal) *Product {
 	return &Product{
-		ID:        uuid.New(),
-		Name:      name,
-		Price:     price,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:           uuid.New(),
+		Name:         name,
+		Price:        price,
+		Attributes:   map[string]any{},
+		Metadata:     map[string]string{},
+		Translations: map[string]ProductTranslation{},
+		Status:       ProductActive,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
 	}
 }
 
@@ -72,6 +89,25 @@ func (p *Product) UpdatePriceFromString(priceStr string) error {
 	return nil
 }
 
+// SetAttributes replaces the product category and its structured attributes
+func (p *Product) SetAttributes(category string, attributes map[string]any) {
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	p.Category = category
+	p.Attributes = attributes
+	p.UpdatedAt = time.Now()
+}
+
+// SetMetadata replaces the user-defined metadata
+func (p *Product) SetMetadata(metadata map[string]string) {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	p.Metadata = metadata
+	p.UpdatedAt = time.Now()
+}
+
 // GetPriceString returns price as string
 func (p *Product) GetPriceString() string {
 	return p.Price.String()
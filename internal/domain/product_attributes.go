@@ -0,0 +1,8 @@
+package domain
+
+import "github.com/erry-az/go-init/pkg/jsonver"
+
+// ProductAttributes versions the stored attributes of products. Append a
+// migration to change their shape: stored attributes are upgraded as they are
+// read and written back in the new shape on the next product update.
+var ProductAttributes = jsonver.NewSchema("product_attributes")
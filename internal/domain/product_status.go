@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ProductStatus is where a product is in its lifecycle
+type ProductStatus string
+
+const (
+	// ProductDraft is being prepared and not yet offered
+	ProductDraft ProductStatus = "draft"
+	// ProductActive is offered, the status of products created without one
+	ProductActive ProductStatus = "active"
+	// ProductDiscontinued is no longer offered, but kept for reference
+	ProductDiscontinued ProductStatus = "discontinued"
+)
+
+// productTransitions lists the statuses each status may change to. Drafts
+// are published or dropped, discontinued products may be offered again, and
+// nothing returns to draft.
+var productTransitions = map[ProductStatus][]ProductStatus{
+	ProductDraft:        {ProductActive, ProductDiscontinued},
+	ProductActive:       {ProductDiscontinued},
+	ProductDiscontinued: {ProductActive},
+}
+
+// IsValid reports whether s is a known status
+func (s ProductStatus) IsValid() bool {
+	_, ok := productTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a product in status s may change to next
+func (s ProductStatus) CanTransitionTo(next ProductStatus) bool {
+	return slices.Contains(productTransitions[s], next)
+}
+
+// SetStatus moves the product to status, failing for unknown statuses and
+// transitions the lifecycle does not allow
+func (p *Product) SetStatus(status ProductStatus) error {
+	if !status.IsValid() {
+		return NewValidationError(fmt.Sprintf("unknown product status %q", status))
+	}
+	if !p.Status.CanTransitionTo(status) {
+		return NewValidationError(fmt.Sprintf("product status cannot change from %s to %s", p.Status, status))
+	}
+
+	p.Status = status
+	p.UpdatedAt = time.Now()
+	return nil
+}
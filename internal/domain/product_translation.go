@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/erry-az/go-init/pkg/locale"
+)
+
+// Limits on the localized content of products
+const (
+	MaxProductTranslations      = 32
+	MaxProductNameLength        = 255
+	MaxProductDescriptionLength = 4096
+)
+
+// ProductTranslation is the name and description of a product in one locale.
+// Either may be empty, falling back to the next locale of the chain.
+type ProductTranslation struct {
+	Name        string
+	Description string
+}
+
+// SetTranslations replaces the description, in the default locale like the
+// name, and the translations into other locales, keyed by BCP 47 tag. Tags
+// are stored in canonical form, e.g. de-CH for de-ch.
+func (p *Product) SetTranslations(description string, translations map[string]ProductTranslation, defaultLocale string) error {
+	if utf8.RuneCountInString(description) > MaxProductDescriptionLength {
+		return NewValidationError(fmt.Sprintf("description is longer than %d characters", MaxProductDescriptionLength))
+	}
+	if len(translations) > MaxProductTranslations {
+		return NewValidationError(fmt.Sprintf("product has more than %d translations", MaxProductTranslations))
+	}
+
+	canonical := make(map[string]ProductTranslation, len(translations))
+	for tag, translation := range translations {
+		key, err := locale.Canonical(tag)
+		if err != nil {
+			return NewValidationError(fmt.Sprintf("invalid translation locale %q", tag))
+		}
+		if key == defaultLocale {
+			return NewValidationError(fmt.Sprintf("translation locale %s is the default locale, set name and description instead", key))
+		}
+		if _, ok := canonical[key]; ok {
+			return NewValidationError(fmt.Sprintf("translation locale %s is given more than once", key))
+		}
+		if translation.Name == "" && translation.Description == "" {
+			return NewValidationError(fmt.Sprintf("translation %s has neither a name nor a description", key))
+		}
+		if utf8.RuneCountInString(translation.Name) > MaxProductNameLength {
+			return NewValidationError(fmt.Sprintf("name of translation %s is longer than %d characters", key, MaxProductNameLength))
+		}
+		if utf8.RuneCountInString(translation.Description) > MaxProductDescriptionLength {
+			return NewValidationError(fmt.Sprintf("description of translation %s is longer than %d characters", key, MaxProductDescriptionLength))
+		}
+		canonical[key] = translation
+	}
+
+	p.Description = description
+	p.Translations = canonical
+	return nil
+}
+
+// Localize returns a copy of p whose name and description are each the
+// first one of the locales of chain, e.g. de-CH, de. The chain ends at the
+// default locale, whose content are the name and description themselves.
+// Locale is set to the first locale of chain p has a translation into.
+func (p *Product) Localize(chain []string, defaultLocale string) *Product {
+	localized := *p
+	localized.Locale = defaultLocale
+
+	var name, description string
+	for _, tag := range chain {
+		if tag == defaultLocale || name != "" && description != "" {
+			break
+		}
+		translation, ok := p.Translations[tag]
+		if !ok {
+			continue
+		}
+
+		if localized.Locale == defaultLocale {
+			localized.Locale = tag
+		}
+		if name == "" {
+			name = translation.Name
+		}
+		if description == "" {
+			description = translation.Description
+		}
+	}
+
+	if name != "" {
+		localized.Name = name
+	}
+	if description != "" {
+		localized.Description = description
+	}
+	return &localized
+}
@@ -0,0 +1,34 @@
+package domain
+
+// Role is what a user may do through the API, each role including the
+// permissions of the roles below it
+type Role string
+
+const (
+	// RoleViewer reads users and products
+	RoleViewer Role = "viewer"
+	// RoleEditor also creates and updates them
+	RoleEditor Role = "editor"
+	// RoleAdmin also deletes them and manages roles
+	RoleAdmin Role = "admin"
+)
+
+// roleRanks orders the roles, higher ranks including lower ones
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// IsValid reports whether r is a known role
+func (r Role) IsValid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Includes reports whether r grants what required does, e.g. admin includes
+// editor. Unknown roles include nothing.
+func (r Role) Includes(required Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[required]
+}
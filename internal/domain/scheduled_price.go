@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ScheduledPrice is a product price change that takes effect at a future time
+type ScheduledPrice struct {
+	ID          uuid.UUID
+	ProductID   uuid.UUID
+	Price       decimal.Decimal
+	EffectiveAt time.Time
+	CreatedAt   time.Time
+}
+
+// NewScheduledPrice schedules productID to change to priceStr at effectiveAt,
+// which must be in the future
+func NewScheduledPrice(productID uuid.UUID, priceStr string, effectiveAt time.Time) (*ScheduledPrice, error) {
+	price, err := decimal.NewFromString(priceStr)
+	if err != nil {
+		return nil, NewValidationError("invalid price format")
+	}
+	if !effectiveAt.After(time.Now()) {
+		return nil, NewValidationError("effective time must be in the future")
+	}
+
+	return &ScheduledPrice{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		Price:       price,
+		EffectiveAt: effectiveAt,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// GetPriceString returns the scheduled price as a string
+func (s *ScheduledPrice) GetPriceString() string {
+	return s.Price.String()
+}
@@ -11,8 +11,13 @@ type User struct {
 	ID        uuid.UUID
 	Name      string
 	Email     string
+	Metadata  map[string]string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// Version is incremented by every update, 0 until the user is stored
+	Version int64
+	// Role is what the user may do through the API, viewer until changed
+	Role Role
 }
 
 // NewUser creates a new user
@@ -21,8 +26,10 @@ func NewUser(name, email string) *User {
 		ID:        uuid.New(),
 		Name:      name,
 		Email:     email,
+		Metadata:  map[string]string{},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+		Role:      RoleViewer,
 	}
 }
 
@@ -33,3 +40,24 @@ func (u *User) UpdateDetails(name, email string) {
 	u.UpdatedAt = time.Now()
 }
 
+// SetMetadata replaces the user-defined metadata
+func (u *User) SetMetadata(metadata map[string]string) {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	u.Metadata = metadata
+	u.UpdatedAt = time.Now()
+}
+
+// EmailChangeRequest is a pending email change awaiting confirmation
+type EmailChangeRequest struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	NewEmail  string
+	ExpiresAt time.Time
+}
+
+// IsExpired reports whether the confirmation window has passed
+func (r *EmailChangeRequest) IsExpired() bool {
+	return time.Now().After(r.ExpiresAt)
+}
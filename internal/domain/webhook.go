@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryStatus is the processing state of a received webhook
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryProcessed WebhookDeliveryStatus = "processed"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// Webhook providers
+const (
+	WebhookProviderStripe = "stripe"
+)
+
+// WebhookDelivery is a webhook event received from a provider, stored once
+// however often the provider sent it
+type WebhookDelivery struct {
+	ID       uuid.UUID
+	Provider string
+	// EventID identifies the event at the provider
+	EventID   string
+	EventType string
+	// Payload is the request body as the provider signed it
+	Payload []byte
+	Status  WebhookDeliveryStatus
+	// Deliveries counts how often the provider sent the event
+	Deliveries int
+	// Attempts counts how often processing the event was tried
+	Attempts int
+	// Error explains why the last attempt failed
+	Error       string
+	ReceivedAt  time.Time
+	ProcessedAt *time.Time
+}
+
+// IsDone reports whether the delivery was processed, successfully or not
+func (d *WebhookDelivery) IsDone() bool {
+	return d.Status != WebhookDeliveryPending
+}
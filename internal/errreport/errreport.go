@@ -0,0 +1,183 @@
+package errreport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/erry-az/go-init/internal/residency"
+)
+
+// Defaults used when Options leaves a value unset
+const (
+	defaultBufferSize   = 100
+	defaultFlushTimeout = 5 * time.Second
+)
+
+// Level is the severity of a reported event
+type Level string
+
+const (
+	LevelError Level = "error"
+	LevelFatal Level = "fatal"
+)
+
+// Event is an error or recovered panic on its way to an error tracker
+type Event struct {
+	ID    string
+	Time  time.Time
+	Level Level
+	// Type names the error, e.g. its Go type or "panic"
+	Type    string
+	Message string
+	// Stack is the goroutine trace as printed by runtime/debug.Stack
+	Stack         []byte
+	Release       string
+	Environment   string
+	CorrelationID string
+	TenantID      string
+	UserID        string
+	Tags          map[string]string
+}
+
+// Provider delivers events to an error tracker such as Sentry
+type Provider interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// Options configures a Reporter
+type Options struct {
+	Release     string
+	Environment string
+	// BufferSize is how many events may wait for delivery; more are dropped
+	BufferSize int
+	// FlushTimeout bounds delivering the events still queued on shutdown
+	FlushTimeout time.Duration
+}
+
+// Reporter enriches errors and panics with release and request context and
+// delivers them through its provider in the background, so reporting never
+// blocks or fails the caller. A nil *Reporter discards everything.
+type Reporter struct {
+	provider Provider
+	options  Options
+	events   chan Event
+}
+
+// New creates a reporter delivering through provider once Run is called
+func New(provider Provider, opts Options) *Reporter {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultBufferSize
+	}
+	if opts.FlushTimeout <= 0 {
+		opts.FlushTimeout = defaultFlushTimeout
+	}
+	return &Reporter{
+		provider: provider,
+		options:  opts,
+		events:   make(chan Event, opts.BufferSize),
+	}
+}
+
+// CaptureError reports err with the context of ctx and the given tags
+func (r *Reporter) CaptureError(ctx context.Context, err error, tags map[string]string) {
+	if r == nil || err == nil {
+		return
+	}
+	r.enqueue(ctx, Event{
+		Level:   LevelError,
+		Type:    fmt.Sprintf("%T", err),
+		Message: err.Error(),
+		Stack:   debug.Stack(),
+		Tags:    tags,
+	})
+}
+
+// CapturePanic reports a recovered panic value along with the stack it was raised on
+func (r *Reporter) CapturePanic(ctx context.Context, recovered any, stack []byte, tags map[string]string) {
+	if r == nil {
+		return
+	}
+	r.enqueue(ctx, Event{
+		Level:   LevelFatal,
+		Type:    "panic",
+		Message: fmt.Sprint(recovered),
+		Stack:   stack,
+		Tags:    tags,
+	})
+}
+
+func (r *Reporter) enqueue(ctx context.Context, event Event) {
+	event.ID = newEventID()
+	event.Time = time.Now()
+	event.Release = r.options.Release
+	event.Environment = r.options.Environment
+	event.CorrelationID, _ = ctx.Value(correlationIDKey{}).(string)
+	event.UserID, _ = ctx.Value(userIDKey{}).(string)
+	event.TenantID, _ = residency.TenantFromContext(ctx)
+
+	select {
+	case r.events <- event:
+	default:
+		slog.Warn("Dropping error report, buffer is full", "event_id", event.ID, "message", event.Message)
+	}
+}
+
+// Run delivers queued events until ctx is done, then flushes what is left
+// within the flush timeout
+func (r *Reporter) Run(ctx context.Context) error {
+	for {
+		select {
+		case event := <-r.events:
+			r.send(ctx, event)
+		case <-ctx.Done():
+			r.flush()
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *Reporter) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.options.FlushTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case event := <-r.events:
+			r.send(ctx, event)
+		default:
+			return
+		}
+	}
+}
+
+func (r *Reporter) send(ctx context.Context, event Event) {
+	if err := r.provider.Send(ctx, event); err != nil {
+		slog.Error("Failed to deliver error report", "event_id", event.ID, slog.Any("error", err))
+	}
+}
+
+type correlationIDKey struct{}
+
+type userIDKey struct{}
+
+// WithCorrelationID attaches the correlation ID of the request or message being handled
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// WithUser attaches the ID of the calling user or client
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// newEventID returns 32 hex characters, the event ID format Sentry expects
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
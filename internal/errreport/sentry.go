@@ -0,0 +1,213 @@
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sentryClient identifies this reporter to Sentry
+const sentryClient = "go-init-errreport/1.0"
+
+// modulePrefix marks stack frames of this application as in-app
+const modulePrefix = "github.com/erry-az/go-init/"
+
+// SentryProvider sends events to the store endpoint of a Sentry project.
+// Any service speaking the Sentry protocol, such as GlitchTip, works too.
+type SentryProvider struct {
+	endpoint   string
+	auth       string
+	serverName string
+	client     *http.Client
+}
+
+// NewSentryProvider creates a provider for the project of dsn, e.g.
+// https://<key>@o0.ingest.sentry.io/<project>
+func NewSentryProvider(dsn string) (*SentryProvider, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry DSN: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid sentry DSN: missing public key")
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if project == "" {
+		return nil, fmt.Errorf("invalid sentry DSN: missing project ID")
+	}
+
+	auth := "Sentry sentry_version=7, sentry_client=" + sentryClient + ", sentry_key=" + u.User.Username()
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+
+	hostname, _ := os.Hostname()
+	return &SentryProvider{
+		endpoint:   fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path[:slash], project),
+		auth:       auth,
+		serverName: hostname,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Send posts event to Sentry
+func (p *SentryProvider) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(p.payload(event))
+	if err != nil {
+		return fmt.Errorf("encode sentry event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", p.auth)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send sentry event: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("send sentry event: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       Level             `json:"level"`
+	Platform    string            `json:"platform"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Exception   sentryExceptions  `json:"exception"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	User        *sentryUser       `json:"user,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryUser struct {
+	ID string `json:"id"`
+}
+
+func (p *SentryProvider) payload(event Event) sentryEvent {
+	tags := make(map[string]string, len(event.Tags)+2)
+	for k, v := range event.Tags {
+		tags[k] = v
+	}
+	if event.CorrelationID != "" {
+		tags["correlation_id"] = event.CorrelationID
+	}
+	if event.TenantID != "" {
+		tags["tenant_id"] = event.TenantID
+	}
+
+	exception := sentryException{Type: event.Type, Value: event.Message}
+	if frames := parseStack(event.Stack); len(frames) > 0 {
+		exception.Stacktrace = &sentryStacktrace{Frames: frames}
+	}
+
+	payload := sentryEvent{
+		EventID:     event.ID,
+		Timestamp:   event.Time.UTC().Format(time.RFC3339Nano),
+		Level:       event.Level,
+		Platform:    "go",
+		ServerName:  p.serverName,
+		Release:     event.Release,
+		Environment: event.Environment,
+		Exception:   sentryExceptions{Values: []sentryException{exception}},
+		Tags:        tags,
+	}
+	if event.UserID != "" {
+		payload.User = &sentryUser{ID: event.UserID}
+	}
+	return payload
+}
+
+// parseStack turns a runtime/debug.Stack trace into Sentry frames, which are
+// ordered from the outermost call to the innermost
+func parseStack(stack []byte) []sentryFrame {
+	lines := strings.Split(string(stack), "\n")
+
+	var frames []sentryFrame
+	for i := 1; i+1 < len(lines); i++ {
+		location, ok := strings.CutPrefix(lines[i+1], "\t")
+		if !ok || strings.HasPrefix(lines[i], "\t") {
+			continue
+		}
+
+		function := lines[i]
+		if creator, ok := strings.CutPrefix(function, "created by "); ok {
+			function, _, _ = strings.Cut(creator, " in goroutine")
+		} else if open := strings.LastIndex(function, "("); open > 0 {
+			function = function[:open]
+		}
+
+		location, _, _ = strings.Cut(location, " +")
+		file, line := location, ""
+		if colon := strings.LastIndex(location, ":"); colon > 0 {
+			file, line = location[:colon], location[colon+1:]
+		}
+		lineno, _ := strconv.Atoi(line)
+
+		frames = append(frames, sentryFrame{
+			Function: function,
+			Module:   packageOf(function),
+			AbsPath:  file,
+			Lineno:   lineno,
+			InApp:    strings.HasPrefix(function, modulePrefix) && !strings.HasPrefix(function, modulePrefix+"internal/errreport."),
+		})
+		i++
+	}
+
+	for l, r := 0, len(frames)-1; l < r; l, r = l+1, r-1 {
+		frames[l], frames[r] = frames[r], frames[l]
+	}
+	return frames
+}
+
+// packageOf returns the import path of the package defining function
+func packageOf(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package eventhistory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/protoredact"
+	"github.com/erry-az/go-init/pkg/watmil"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// eventProtoPackage is the proto package of the events, which are recorded
+// under their message name
+const eventProtoPackage = "proto.event.v1"
+
+// Store keeps the history of every aggregate in the database: the events
+// about it, stored as published without their secret fields, see
+// protoredact. Encrypted payloads stay encrypted and are decrypted when
+// listed.
+type Store struct {
+	db         sqlc.DBTX
+	encryption *watmil.PayloadEncryption
+}
+
+// New creates a store kept in db, reading payloads encrypted with encryption,
+// which may be nil when events are not encrypted
+func New(db sqlc.DBTX, encryption *watmil.PayloadEncryption) *Store {
+	return &Store{db: db, encryption: encryption}
+}
+
+// Record stores msg in the history of the aggregate it is stamped with
+func (s *Store) Record(ctx context.Context, eventName string, msg *message.Message) error {
+	msg, err := s.redact(ctx, eventName, msg)
+	if err != nil {
+		return fmt.Errorf("eventhistory: redacting message %s: %w", msg.UUID, err)
+	}
+	metadata, err := json.Marshal(msg.Metadata)
+	if err != nil {
+		return err
+	}
+
+	err = sqlc.New(s.db).InsertEntityEvent(ctx, sqlc.InsertEntityEventParams{
+		AggregateType: msg.Metadata.Get(watmil.MetadataAggregateType),
+		AggregateID:   msg.Metadata.Get(watmil.MetadataAggregateID),
+		EventID:       msg.UUID,
+		EventName:     eventName,
+		Metadata:      metadata,
+		Payload:       msg.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("eventhistory: storing message %s: %w", msg.UUID, err)
+	}
+	return nil
+}
+
+// List returns up to limit events of an aggregate in the order they were
+// published, starting after the event with sequence afterID
+func (s *Store) List(ctx context.Context, aggregateType, aggregateID string, afterID int64, limit int32) ([]*domain.EntityEvent, error) {
+	rows, err := sqlc.New(s.db).ListEntityEvents(ctx, sqlc.ListEntityEventsParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		AfterID:       afterID,
+		PageSize:      limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("eventhistory: listing events: %w", err)
+	}
+
+	events := make([]*domain.EntityEvent, len(rows))
+	for i, row := range rows {
+		msg := message.NewMessage(row.EventID, row.Payload)
+		if err := json.Unmarshal(row.Metadata, &msg.Metadata); err != nil {
+			return nil, fmt.Errorf("eventhistory: decoding metadata of message %s: %w", row.EventID, err)
+		}
+		payload, err := s.encryption.DecryptPayload(ctx, msg)
+		if err != nil {
+			return nil, fmt.Errorf("eventhistory: decrypting message %s: %w", row.EventID, err)
+		}
+		// Events recorded before their secret fields were marked still hold them
+		if payload, err = redactPayload(row.EventName, payload); err != nil {
+			return nil, fmt.Errorf("eventhistory: redacting message %s: %w", row.EventID, err)
+		}
+
+		events[i] = &domain.EntityEvent{
+			Sequence:    row.ID,
+			ID:          row.EventID,
+			Name:        row.EventName,
+			Metadata:    msg.Metadata,
+			Payload:     payload,
+			PublishedAt: row.PublishedAt.Time,
+		}
+	}
+	return events, nil
+}
+
+// redact returns msg without the secret fields of its event, encrypted again
+// when it was
+func (s *Store) redact(ctx context.Context, eventName string, msg *message.Message) (*message.Message, error) {
+	payload, err := s.encryption.DecryptPayload(ctx, msg)
+	if err != nil {
+		return msg, err
+	}
+	payload, err = redactPayload(eventName, payload)
+	if err != nil {
+		return msg, err
+	}
+
+	redacted := msg.Copy()
+	redacted.Payload = payload
+	if msg.Metadata.Get(watmil.MetadataEncryptionKeyID) == "" {
+		return redacted, nil
+	}
+	return s.encryption.EncryptPayload(ctx, redacted)
+}
+
+// redactPayload removes the secret fields from the plaintext payload of an
+// eventName event. Events that are not proto messages, e.g. custom events,
+// are returned as they are.
+func redactPayload(eventName string, payload []byte) ([]byte, error) {
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(eventProtoPackage + "." + eventName))
+	if err != nil {
+		return payload, nil
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return payload, nil
+	}
+	return protoredact.JSON(md, payload)
+}
+
+// RedactRow returns row without the secret fields of its event, e.g. before
+// archiving rows recorded before the fields were marked. Encrypted payloads
+// cannot be read without their keys and are returned as they are.
+func RedactRow(row sqlc.EntityEvent) (sqlc.EntityEvent, error) {
+	var metadata message.Metadata
+	if err := json.Unmarshal(row.Metadata, &metadata); err != nil {
+		return row, fmt.Errorf("eventhistory: decoding metadata of message %s: %w", row.EventID, err)
+	}
+	if metadata.Get(watmil.MetadataEncryptionKeyID) != "" {
+		return row, nil
+	}
+
+	payload, err := redactPayload(row.EventName, row.Payload)
+	if err != nil {
+		return row, fmt.Errorf("eventhistory: redacting message %s: %w", row.EventID, err)
+	}
+	row.Payload = payload
+	return row, nil
+}
@@ -0,0 +1,70 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/audit"
+	"github.com/erry-az/go-init/pkg/protoredact"
+	eventv1 "github.com/erry-az/go-init/proto/event/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// AuditConsumer mirrors every domain event into the audit trail
+type AuditConsumer struct {
+	trail *audit.Trail
+}
+
+func NewAuditConsumer(trail *audit.Trail) *AuditConsumer {
+	return &AuditConsumer{
+		trail: trail,
+	}
+}
+
+// AddHandlers registers a handler per event type. Each handler consumes its
+// own subscription, so auditing neither competes with nor waits for other
+// handlers of the same events.
+func (a *AuditConsumer) AddHandlers(eventProcessor *cqrs.EventProcessor) error {
+	return eventProcessor.AddHandlers(
+		auditHandler[eventv1.UserCreatedEvent](a, "AuditUserCreated"),
+		auditHandler[eventv1.UserUpdatedEvent](a, "AuditUserUpdated"),
+		auditHandler[eventv1.UserDeletedEvent](a, "AuditUserDeleted"),
+		auditHandler[eventv1.UserEmailChangeRequestedEvent](a, "AuditUserEmailChangeRequested"),
+		auditHandler[eventv1.UserEmailChangedEvent](a, "AuditUserEmailChanged"),
+		auditHandler[eventv1.ProductCreatedEvent](a, "AuditProductCreated"),
+		auditHandler[eventv1.ProductUpdatedEvent](a, "AuditProductUpdated"),
+		auditHandler[eventv1.ProductDeletedEvent](a, "AuditProductDeleted"),
+		auditHandler[eventv1.ProductPriceChangedEvent](a, "AuditProductPriceChanged"),
+		auditHandler[eventv1.ProductStatusChangedEvent](a, "AuditProductStatusChanged"),
+		auditHandler[eventv1.ProductReindexedEvent](a, "AuditProductReindexed"),
+		auditHandler[eventv1.EventDigestEvent](a, "AuditEventDigest"),
+	)
+}
+
+// domainEvent is a generated domain event
+type domainEvent interface {
+	proto.Message
+	GetEventId() string
+}
+
+// domainEventPtr constrains T to the domain event its pointer is
+type domainEventPtr[T any] interface {
+	*T
+	domainEvent
+}
+
+func auditHandler[T any, E domainEventPtr[T]](a *AuditConsumer, name string) cqrs.EventHandler {
+	return cqrs.NewEventHandler(name, func(ctx context.Context, event *T) error {
+		return a.record(ctx, E(event))
+	})
+}
+
+func (a *AuditConsumer) record(ctx context.Context, event domainEvent) error {
+	// Records cannot be changed once chained, so secrets must not get in
+	payload, err := protojson.Marshal(protoredact.Clone(event))
+	if err != nil {
+		return err
+	}
+	return a.trail.Append(ctx, event.GetEventId(), string(proto.MessageName(event)), payload)
+}
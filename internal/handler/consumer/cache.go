@@ -0,0 +1,117 @@
+package consumer
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	v1 "github.com/erry-az/go-init/proto/api/v1"
+	eventv1 "github.com/erry-az/go-init/proto/event/v1"
+	"github.com/google/uuid"
+)
+
+// CacheInvalidator drops cached entities older than the changes it is told
+// about, e.g. *repository.CachedQuerier
+type CacheInvalidator interface {
+	ProductChanged(id uuid.UUID, version int64)
+	ProductDeleted(id uuid.UUID)
+	UserChanged(id uuid.UUID, version int64)
+	UserDeleted(id uuid.UUID)
+}
+
+// CacheConsumer keeps the read cache of one endpoint instance from serving
+// versions older than those written through other instances. Its handlers
+// are named after the instance so every instance receives every event.
+type CacheConsumer struct {
+	cache    CacheInvalidator
+	instance string
+}
+
+// NewCacheConsumer creates the cache consumer of the endpoint instance
+// called instance, e.g. its hostname
+func NewCacheConsumer(cache CacheInvalidator, instance string) *CacheConsumer {
+	return &CacheConsumer{
+		cache:    cache,
+		instance: instance,
+	}
+}
+
+func (c *CacheConsumer) AddHandlers(eventProcessor *cqrs.EventProcessor) error {
+	return eventProcessor.AddHandlers(
+		cqrs.NewEventHandler(c.handlerName("ProductUpdated"), c.HandleProductUpdated),
+		cqrs.NewEventHandler(c.handlerName("ProductPriceChanged"), c.HandleProductPriceChanged),
+		cqrs.NewEventHandler(c.handlerName("ProductStatusChanged"), c.HandleProductStatusChanged),
+		cqrs.NewEventHandler(c.handlerName("ProductDeleted"), c.HandleProductDeleted),
+		cqrs.NewEventHandler(c.handlerName("UserUpdated"), c.HandleUserUpdated),
+		cqrs.NewEventHandler(c.handlerName("UserEmailChanged"), c.HandleUserEmailChanged),
+		cqrs.NewEventHandler(c.handlerName("UserDeleted"), c.HandleUserDeleted),
+	)
+}
+
+// handlerName names the handler of event for this instance, keeping its
+// subscription apart from those of other instances and of the consumer
+func (c *CacheConsumer) handlerName(event string) string {
+	return "InvalidateCacheOn" + event + "-" + c.instance
+}
+
+func (c *CacheConsumer) HandleProductUpdated(ctx context.Context, pe *eventv1.ProductUpdatedEvent) error {
+	c.productChanged(pe.Product)
+	return nil
+}
+
+func (c *CacheConsumer) HandleProductPriceChanged(ctx context.Context, pe *eventv1.ProductPriceChangedEvent) error {
+	c.productChanged(pe.Product)
+	return nil
+}
+
+func (c *CacheConsumer) HandleProductStatusChanged(ctx context.Context, pe *eventv1.ProductStatusChangedEvent) error {
+	c.productChanged(pe.Product)
+	return nil
+}
+
+func (c *CacheConsumer) HandleProductDeleted(ctx context.Context, pe *eventv1.ProductDeletedEvent) error {
+	if id, ok := parseEntityID("product", pe.Product.GetId()); ok {
+		c.cache.ProductDeleted(id)
+	}
+	return nil
+}
+
+func (c *CacheConsumer) HandleUserUpdated(ctx context.Context, pe *eventv1.UserUpdatedEvent) error {
+	c.userChanged(pe.User)
+	return nil
+}
+
+func (c *CacheConsumer) HandleUserEmailChanged(ctx context.Context, pe *eventv1.UserEmailChangedEvent) error {
+	c.userChanged(pe.User)
+	return nil
+}
+
+func (c *CacheConsumer) HandleUserDeleted(ctx context.Context, pe *eventv1.UserDeletedEvent) error {
+	if id, ok := parseEntityID("user", pe.User.GetId()); ok {
+		c.cache.UserDeleted(id)
+	}
+	return nil
+}
+
+func (c *CacheConsumer) productChanged(product *v1.Product) {
+	if id, ok := parseEntityID("product", product.GetId()); ok {
+		c.cache.ProductChanged(id, product.GetVersion())
+	}
+}
+
+func (c *CacheConsumer) userChanged(user *v1.User) {
+	if id, ok := parseEntityID("user", user.GetId()); ok {
+		c.cache.UserChanged(id, user.GetVersion())
+	}
+}
+
+// parseEntityID parses the ID of the entity an event is about. Events without
+// a valid one are dropped, as redelivering them would not fix them.
+func parseEntityID(entity, raw string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		slog.Warn("Dropping cache invalidation of event with invalid ID", "entity", entity, "id", raw)
+		return uuid.Nil, false
+	}
+	return id, true
+}
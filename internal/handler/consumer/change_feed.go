@@ -0,0 +1,100 @@
+package consumer
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/usecase"
+	eventv1 "github.com/erry-az/go-init/proto/event/v1"
+)
+
+// ChangeFeedConsumer feeds the user and product events one endpoint instance
+// receives to the change feed of its watchers. Its handlers are named after
+// the instance so every instance receives every event.
+type ChangeFeedConsumer struct {
+	feed     usecase.ChangeFeedUsecase
+	instance string
+}
+
+// NewChangeFeedConsumer creates the change feed consumer of the endpoint
+// instance called instance, e.g. its hostname
+func NewChangeFeedConsumer(feed usecase.ChangeFeedUsecase, instance string) *ChangeFeedConsumer {
+	return &ChangeFeedConsumer{
+		feed:     feed,
+		instance: instance,
+	}
+}
+
+func (c *ChangeFeedConsumer) AddHandlers(eventProcessor *cqrs.EventProcessor) error {
+	return eventProcessor.AddHandlers(
+		cqrs.NewEventHandler(c.handlerName("UserCreated"), c.HandleUserCreated),
+		cqrs.NewEventHandler(c.handlerName("UserUpdated"), c.HandleUserUpdated),
+		cqrs.NewEventHandler(c.handlerName("UserEmailChanged"), c.HandleUserEmailChanged),
+		cqrs.NewEventHandler(c.handlerName("UserDeleted"), c.HandleUserDeleted),
+		cqrs.NewEventHandler(c.handlerName("ProductCreated"), c.HandleProductCreated),
+		cqrs.NewEventHandler(c.handlerName("ProductUpdated"), c.HandleProductUpdated),
+		cqrs.NewEventHandler(c.handlerName("ProductPriceChanged"), c.HandleProductPriceChanged),
+		cqrs.NewEventHandler(c.handlerName("ProductStatusChanged"), c.HandleProductStatusChanged),
+		cqrs.NewEventHandler(c.handlerName("ProductDeleted"), c.HandleProductDeleted),
+	)
+}
+
+// handlerName names the handler of event for this instance, keeping its
+// subscription apart from those of other instances and of the consumer
+func (c *ChangeFeedConsumer) handlerName(event string) string {
+	return "FeedChangesOn" + event + "-" + c.instance
+}
+
+func (c *ChangeFeedConsumer) HandleUserCreated(ctx context.Context, ue *eventv1.UserCreatedEvent) error {
+	c.dropInvalid(c.feed.UserChanged(usecase.ChangeCreated, ue.EventId, ue.TenantId, ue.EventTime.AsTime(), ue.User))
+	return nil
+}
+
+func (c *ChangeFeedConsumer) HandleUserUpdated(ctx context.Context, ue *eventv1.UserUpdatedEvent) error {
+	c.dropInvalid(c.feed.UserChanged(usecase.ChangeUpdated, ue.EventId, ue.TenantId, ue.EventTime.AsTime(), ue.User))
+	return nil
+}
+
+func (c *ChangeFeedConsumer) HandleUserEmailChanged(ctx context.Context, ue *eventv1.UserEmailChangedEvent) error {
+	c.dropInvalid(c.feed.UserChanged(usecase.ChangeUpdated, ue.EventId, ue.TenantId, ue.EventTime.AsTime(), ue.User))
+	return nil
+}
+
+func (c *ChangeFeedConsumer) HandleUserDeleted(ctx context.Context, ue *eventv1.UserDeletedEvent) error {
+	c.dropInvalid(c.feed.UserChanged(usecase.ChangeDeleted, ue.EventId, ue.TenantId, ue.EventTime.AsTime(), ue.User))
+	return nil
+}
+
+func (c *ChangeFeedConsumer) HandleProductCreated(ctx context.Context, pe *eventv1.ProductCreatedEvent) error {
+	c.dropInvalid(c.feed.ProductChanged(usecase.ChangeCreated, pe.EventId, pe.TenantId, pe.EventTime.AsTime(), pe.Product))
+	return nil
+}
+
+func (c *ChangeFeedConsumer) HandleProductUpdated(ctx context.Context, pe *eventv1.ProductUpdatedEvent) error {
+	c.dropInvalid(c.feed.ProductChanged(usecase.ChangeUpdated, pe.EventId, pe.TenantId, pe.EventTime.AsTime(), pe.Product))
+	return nil
+}
+
+func (c *ChangeFeedConsumer) HandleProductPriceChanged(ctx context.Context, pe *eventv1.ProductPriceChangedEvent) error {
+	c.dropInvalid(c.feed.ProductChanged(usecase.ChangeUpdated, pe.EventId, pe.TenantId, pe.EventTime.AsTime(), pe.Product))
+	return nil
+}
+
+func (c *ChangeFeedConsumer) HandleProductStatusChanged(ctx context.Context, pe *eventv1.ProductStatusChangedEvent) error {
+	c.dropInvalid(c.feed.ProductChanged(usecase.ChangeUpdated, pe.EventId, pe.TenantId, pe.EventTime.AsTime(), pe.Product))
+	return nil
+}
+
+func (c *ChangeFeedConsumer) HandleProductDeleted(ctx context.Context, pe *eventv1.ProductDeletedEvent) error {
+	c.dropInvalid(c.feed.ProductChanged(usecase.ChangeDeleted, pe.EventId, pe.TenantId, pe.EventTime.AsTime(), pe.Product))
+	return nil
+}
+
+// dropInvalid logs an event the feed could not take. It is dropped, as
+// redelivering it would not fix it.
+func (c *ChangeFeedConsumer) dropInvalid(err error) {
+	if err != nil {
+		slog.Warn("Dropping change feed event", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,248 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/digest"
+	"github.com/erry-az/go-init/internal/inbox"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	eventv1 "github.com/erry-az/go-init/proto/event/v1"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// digestRuleNameRegexp restricts rule names to what brokers accept in subscription names
+var digestRuleNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// DigestRule collects the events of one type into digests grouped by a field
+type DigestRule struct {
+	digest.Rule
+	// Event is the name of the collected domain event, e.g. ProductPriceChangedEvent
+	Event string
+	// GroupBy is the dotted path of the field grouping events, e.g. product.id;
+	// empty collects every event into one group
+	GroupBy string
+}
+
+// digestEvent is a domain event that digest rules can collect
+type digestEvent struct {
+	descriptor protoreflect.MessageDescriptor
+	handler    func(d *DigestConsumer, rule DigestRule) cqrs.EventHandler
+}
+
+// digestEvents are the events digest rules can collect, by name
+var digestEvents = map[string]digestEvent{
+	"UserCreatedEvent":              digestEventOf[eventv1.UserCreatedEvent](),
+	"UserUpdatedEvent":              digestEventOf[eventv1.UserUpdatedEvent](),
+	"UserDeletedEvent":              digestEventOf[eventv1.UserDeletedEvent](),
+	"UserEmailChangeRequestedEvent": digestEventOf[eventv1.UserEmailChangeRequestedEvent](),
+	"UserEmailChangedEvent":         digestEventOf[eventv1.UserEmailChangedEvent](),
+	"ProductCreatedEvent":           digestEventOf[eventv1.ProductCreatedEvent](),
+	"ProductUpdatedEvent":           digestEventOf[eventv1.ProductUpdatedEvent](),
+	"ProductDeletedEvent":           digestEventOf[eventv1.ProductDeletedEvent](),
+	"ProductPriceChangedEvent":      digestEventOf[eventv1.ProductPriceChangedEvent](),
+	"ProductStatusChangedEvent":     digestEventOf[eventv1.ProductStatusChangedEvent](),
+	"ProductReindexedEvent":         digestEventOf[eventv1.ProductReindexedEvent](),
+}
+
+func digestEventOf[T any, E domainEventPtr[T]]() digestEvent {
+	return digestEvent{
+		descriptor: E(new(T)).ProtoReflect().Descriptor(),
+		handler: func(d *DigestConsumer, rule DigestRule) cqrs.EventHandler {
+			return cqrs.NewEventHandler("Digest_"+rule.Name, func(ctx context.Context, event *T) error {
+				return d.collect(ctx, rule, E(event))
+			})
+		},
+	}
+}
+
+// DigestConsumer collects high-frequency events into one EventDigestEvent per
+// group and rule, e.g. every price change of a product within an hour
+type DigestConsumer struct {
+	aggregator *digest.Aggregator
+	publisher  *cqrs.EventBus
+	inbox      *inbox.Inbox
+	rules      []DigestRule
+}
+
+// NewDigestConsumer creates the digest consumer. Events are buffered in db
+// and redelivered events are kept out of digests through inbox.
+func NewDigestConsumer(db digest.DB, publisher *cqrs.EventBus, inbox *inbox.Inbox, rules []DigestRule) (*DigestConsumer, error) {
+	names := make(map[string]bool)
+	for _, rule := range rules {
+		if err := validateDigestRule(rule); err != nil {
+			return nil, err
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("digest rule %s is configured twice", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+
+	d := &DigestConsumer{
+		publisher: publisher,
+		inbox:     inbox,
+		rules:     rules,
+	}
+	d.aggregator = digest.New(db, d.publish)
+	return d, nil
+}
+
+func validateDigestRule(rule DigestRule) error {
+	if !digestRuleNameRegexp.MatchString(rule.Name) {
+		return fmt.Errorf("digest rule name %q must only hold letters, digits, _ and -", rule.Name)
+	}
+	event, ok := digestEvents[rule.Event]
+	if !ok {
+		return fmt.Errorf("digest rule %s: unknown event %q", rule.Name, rule.Event)
+	}
+	if rule.Window <= 0 {
+		return fmt.Errorf("digest rule %s: window must be positive", rule.Name)
+	}
+	if rule.MaxBatchSize <= 0 {
+		return fmt.Errorf("digest rule %s: max batch size must be positive", rule.Name)
+	}
+	if rule.GroupBy == "" {
+		return nil
+	}
+
+	descriptor := event.descriptor
+	segments := strings.Split(rule.GroupBy, ".")
+	for i, segment := range segments {
+		field := descriptor.Fields().ByName(protoreflect.Name(segment))
+		if field == nil || field.IsList() || field.IsMap() {
+			return fmt.Errorf("digest rule %s: %s has no field %q to group by", rule.Name, rule.Event, rule.GroupBy)
+		}
+		isMessage := field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind
+		if last := i == len(segments)-1; last == isMessage {
+			return fmt.Errorf("digest rule %s: %q must end at a scalar field of %s", rule.Name, rule.GroupBy, rule.Event)
+		}
+		if isMessage {
+			descriptor = field.Message()
+		}
+	}
+	return nil
+}
+
+// AddHandlers registers a handler per rule, each on its own subscription, and
+// the handler of the emitted digests
+func (d *DigestConsumer) AddHandlers(eventProcessor *cqrs.EventProcessor) error {
+	handlers := []cqrs.EventHandler{
+		cqrs.NewEventHandler("HandleEventDigest", d.HandleEventDigest),
+	}
+	for _, rule := range d.rules {
+		handlers = append(handlers, digestEvents[rule.Event].handler(d, rule))
+	}
+	return eventProcessor.AddHandlers(handlers...)
+}
+
+// Flush emits the digests whose window elapsed, e.g. from a scheduled job
+func (d *DigestConsumer) Flush(ctx context.Context) (int, error) {
+	total := 0
+	var errs []error
+	for _, rule := range d.rules {
+		n, err := d.aggregator.Flush(ctx, rule.Rule)
+		total += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+func (d *DigestConsumer) HandleEventDigest(ctx context.Context, de *eventv1.EventDigestEvent) error {
+	log.Printf("Event digest: Rule=%s, Event=%s, GroupKey=%s, Count=%d, EventID=%s",
+		de.Data.Rule,
+		de.Data.EventName,
+		de.Data.GroupKey,
+		de.Data.Count,
+		de.EventId,
+	)
+
+	// Here you could:
+	// - Send one notification for the whole group, e.g. a price change summary
+	// - Access the collected events: de.Data.Events
+
+	return nil
+}
+
+// collect buffers event for rule, emitting the digest of its group right away once full
+func (d *DigestConsumer) collect(ctx context.Context, rule DigestRule, event domainEvent) error {
+	payload, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+	groupKey := digestGroupKey(event.ProtoReflect(), rule.GroupBy)
+
+	var full bool
+	err = d.inbox.Once(ctx, event.GetEventId(), "Digest_"+rule.Name, func(ctx context.Context, tx sqlc.Querier) error {
+		full, err = d.aggregator.Buffer(ctx, tx, rule.Rule, groupKey, digest.Event{
+			ID:      event.GetEventId(),
+			Name:    rule.Event,
+			Payload: payload,
+		})
+		return err
+	})
+	if err != nil || !full {
+		return err
+	}
+
+	// A failure leaves the group for the next flush rather than redelivering the event
+	if _, err := d.aggregator.FlushGroup(ctx, rule.Rule, groupKey); err != nil {
+		log.Printf("Failed to emit full digest: Rule=%s, GroupKey=%s, Error=%v", rule.Name, groupKey, err)
+	}
+	return nil
+}
+
+// digestGroupKey returns the value of the field at path in message, empty
+// when a message on the path is not set
+func digestGroupKey(message protoreflect.Message, path string) string {
+	if path == "" {
+		return ""
+	}
+	segments := strings.Split(path, ".")
+	for _, segment := range segments[:len(segments)-1] {
+		field := message.Descriptor().Fields().ByName(protoreflect.Name(segment))
+		if !message.Has(field) {
+			return ""
+		}
+		message = message.Get(field).Message()
+	}
+	field := message.Descriptor().Fields().ByName(protoreflect.Name(segments[len(segments)-1]))
+	return message.Get(field).String()
+}
+
+// publish emits batch as an EventDigestEvent
+func (d *DigestConsumer) publish(ctx context.Context, batch digest.Batch) error {
+	data := &eventv1.EventDigestEventData{
+		Rule:         batch.Rule,
+		EventName:    batch.Events[0].Name,
+		GroupKey:     batch.GroupKey,
+		Count:        int32(len(batch.Events)),
+		FirstEventAt: timestamppb.New(batch.Events[0].BufferedAt),
+		LastEventAt:  timestamppb.New(batch.Events[len(batch.Events)-1].BufferedAt),
+	}
+	for _, event := range batch.Events {
+		payload := &structpb.Struct{}
+		if err := protojson.Unmarshal(event.Payload, payload); err != nil {
+			return fmt.Errorf("decoding event %s: %w", event.ID, err)
+		}
+		data.EventIds = append(data.EventIds, event.ID)
+		data.Events = append(data.Events, payload)
+	}
+
+	return d.publisher.Publish(ctx, &eventv1.EventDigestEvent{
+		EventId:   uuid.New().String(),
+		EventTime: timestamppb.Now(),
+		Data:      data,
+	})
+}
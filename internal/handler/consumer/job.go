@@ -0,0 +1,30 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/usecase"
+	commandv1 "github.com/erry-az/go-init/proto/command/v1"
+)
+
+// JobConsumer performs the background work queued as jobs
+type JobConsumer struct {
+	jobUsecase usecase.JobUsecase
+}
+
+func NewJobConsumer(jobUsecase usecase.JobUsecase) *JobConsumer {
+	return &JobConsumer{
+		jobUsecase: jobUsecase,
+	}
+}
+
+func (j *JobConsumer) AddHandlers(commandProcessor *cqrs.CommandProcessor) error {
+	return commandProcessor.AddHandlers(
+		cqrs.NewCommandHandler("HandleReindexProducts", j.HandleReindexProducts),
+	)
+}
+
+func (j *JobConsumer) HandleReindexProducts(ctx context.Context, cmd *commandv1.ReindexProductsCommand) error {
+	return j.jobUsecase.ProcessReindexProducts(ctx, cmd)
+}
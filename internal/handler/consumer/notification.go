@@ -0,0 +1,154 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/inbox"
+	"github.com/erry-az/go-init/internal/mail"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/residency"
+	"github.com/erry-az/go-init/internal/usecase"
+	v1 "github.com/erry-az/go-init/proto/api/v1"
+	eventv1 "github.com/erry-az/go-init/proto/event/v1"
+	"github.com/google/uuid"
+)
+
+// NotificationConsumer sends the emails of user events, rendered from the
+// email templates managed through the admin API so they change without a deploy
+type NotificationConsumer struct {
+	templates usecase.EmailTemplateUsecase
+	users     sqlc.Querier
+	mailer    mail.Mailer
+	inbox     *inbox.Inbox
+}
+
+// NewNotificationConsumer creates the notification consumer. Every email is
+// sent at most once per event through inbox. Email change tokens are stored
+// through users, which is routed by tenant region when residency is enabled.
+func NewNotificationConsumer(templates usecase.EmailTemplateUsecase, users sqlc.Querier, mailer mail.Mailer, inbox *inbox.Inbox) *NotificationConsumer {
+	return &NotificationConsumer{
+		templates: templates,
+		users:     users,
+		mailer:    mailer,
+		inbox:     inbox,
+	}
+}
+
+func (n *NotificationConsumer) AddHandlers(eventProcessor *cqrs.EventProcessor) error {
+	return eventProcessor.AddHandlers(
+		cqrs.NewEventHandler("NotifyUserCreated", n.NotifyUserCreated),
+		cqrs.NewEventHandler("NotifyUserEmailChangeRequested", n.NotifyUserEmailChangeRequested),
+		cqrs.NewEventHandler("NotifyUserEmailChanged", n.NotifyUserEmailChanged),
+	)
+}
+
+// NotifyUserCreated sends the welcome email
+func (n *NotificationConsumer) NotifyUserCreated(ctx context.Context, pe *eventv1.UserCreatedEvent) error {
+	return n.send(ctx, pe.EventId, "NotifyUserCreated", domain.EmailTemplateWelcome, pe.User.GetEmail(), map[string]any{
+		"user": emailUserData(pe.User),
+	})
+}
+
+// NotifyUserEmailChangeRequested sends the confirmation link to the new
+// address. The token is minted here, in the region of the user's tenant, so
+// it only ever leaves the service inside the email. A redelivered event mints
+// a new token, replacing any whose email did not go out.
+func (n *NotificationConsumer) NotifyUserEmailChangeRequested(ctx context.Context, pe *eventv1.UserEmailChangeRequestedEvent) error {
+	requestID, err := uuid.Parse(pe.Data.GetRequestId())
+	if err != nil {
+		slog.Error("Skipping email change confirmation without request", "event_id", pe.EventId, slog.Any("error", err))
+		return nil
+	}
+	if pe.Data.GetExpiresAt().AsTime().Before(time.Now()) {
+		slog.Warn("Skipping expired email change confirmation", "event_id", pe.EventId)
+		return nil
+	}
+	if pe.TenantId != "" {
+		ctx = residency.WithTenant(ctx, pe.TenantId)
+	}
+
+	return n.sendWith(ctx, pe.EventId, "NotifyUserEmailChangeRequested", domain.EmailTemplateEmailChangeConfirmation, pe.Data.GetNewEmail(), func(ctx context.Context, _ sqlc.Querier) (map[string]any, error) {
+		// A request that is gone gets no token and its email is skipped
+		token, err := usecase.IssueEmailChangeToken(ctx, n.users, requestID)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{
+			"user":               emailUserData(pe.User),
+			"new_email":          pe.Data.GetNewEmail(),
+			"confirmation_token": token,
+			"expires_at":         pe.Data.GetExpiresAt().AsTime().Format(time.RFC3339),
+		}, nil
+	})
+}
+
+// NotifyUserEmailChanged tells the old address that the email was changed
+func (n *NotificationConsumer) NotifyUserEmailChanged(ctx context.Context, pe *eventv1.UserEmailChangedEvent) error {
+	return n.send(ctx, pe.EventId, "NotifyUserEmailChanged", domain.EmailTemplateEmailChanged, pe.Data.GetOldEmail(), map[string]any{
+		"user":      emailUserData(pe.User),
+		"old_email": pe.Data.GetOldEmail(),
+		"new_email": pe.Data.GetNewEmail(),
+	})
+}
+
+// send renders the template named name with data and sends it to to, once
+// per event. Emails whose template is missing or fails to render are skipped
+// rather than retried, as retrying does not fix them.
+func (n *NotificationConsumer) send(ctx context.Context, eventID, handler, name, to string, data map[string]any) error {
+	return n.sendWith(ctx, eventID, handler, name, to, func(context.Context, sqlc.Querier) (map[string]any, error) {
+		return data, nil
+	})
+}
+
+// sendWith is send with the data built by data in the transaction of the
+// inbox entry, which it rolls back along with when the email fails
+func (n *NotificationConsumer) sendWith(ctx context.Context, eventID, handler, name, to string, data func(ctx context.Context, q sqlc.Querier) (map[string]any, error)) error {
+	if to == "" {
+		slog.Warn("Skipping email without recipient", "template", name, "event_id", eventID)
+		return nil
+	}
+
+	return n.inbox.Once(ctx, eventID, handler, func(ctx context.Context, q sqlc.Querier) error {
+		rendered, err := n.render(ctx, q, name, data)
+		if err != nil {
+			var domainErr *domain.DomainError
+			if errors.As(err, &domainErr) && domainErr.Type != domain.ErrorTypeInternal {
+				slog.Error("Skipping email", "template", name, "event_id", eventID, slog.Any("error", err))
+				return nil
+			}
+			return err
+		}
+
+		// Sent last, so the inbox entry only commits after the email went out
+		return n.mailer.Send(ctx, mail.Message{
+			To:       to,
+			Subject:  rendered.Subject,
+			HTMLBody: rendered.HTMLBody,
+			TextBody: rendered.TextBody,
+		})
+	})
+}
+
+// render builds the data of the email through q and renders the template name with it
+func (n *NotificationConsumer) render(ctx context.Context, q sqlc.Querier, name string, data func(ctx context.Context, q sqlc.Querier) (map[string]any, error)) (*domain.RenderedEmail, error) {
+	values, err := data(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return n.templates.Render(ctx, name, values)
+}
+
+// emailUserData is the user as email templates see it, e.g. {{.user.name}}
+func emailUserData(user *v1.User) map[string]any {
+	return map[string]any{
+		"id":    user.GetId(),
+		"name":  user.GetName(),
+		"email": user.GetEmail(),
+	}
+}
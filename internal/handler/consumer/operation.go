@@ -0,0 +1,35 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/usecase"
+	commandv1 "github.com/erry-az/go-init/proto/command/v1"
+)
+
+// OperationConsumer performs writes accepted in async write mode
+type OperationConsumer struct {
+	operationUsecase usecase.OperationUsecase
+}
+
+func NewOperationConsumer(operationUsecase usecase.OperationUsecase) *OperationConsumer {
+	return &OperationConsumer{
+		operationUsecase: operationUsecase,
+	}
+}
+
+func (o *OperationConsumer) AddHandlers(commandProcessor *cqrs.CommandProcessor) error {
+	return commandProcessor.AddHandlers(
+		cqrs.NewCommandHandler("HandleCreateUser", o.HandleCreateUser),
+		cqrs.NewCommandHandler("HandleCreateProduct", o.HandleCreateProduct),
+	)
+}
+
+func (o *OperationConsumer) HandleCreateUser(ctx context.Context, cmd *commandv1.CreateUserCommand) error {
+	return o.operationUsecase.ProcessCreateUser(ctx, cmd)
+}
+
+func (o *OperationConsumer) HandleCreateProduct(ctx context.Context, cmd *commandv1.CreateProductCommand) error {
+	return o.operationUsecase.ProcessCreateProduct(ctx, cmd)
+}
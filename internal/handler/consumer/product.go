@@ -5,13 +5,24 @@ import (
 	"log"
 
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/inbox"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/usecase"
 	eventv1 "github.com/erry-az/go-init/proto/event/v1"
 )
 
-type ProductConsumer struct{}
+type ProductConsumer struct {
+	productUsecase usecase.ProductUsecase
+	inbox          *inbox.Inbox
+}
 
-func NewProductConsumer() *ProductConsumer {
-	return &ProductConsumer{}
+// NewProductConsumer creates the product consumer. Handlers with side effects
+// that must not repeat, like notifications, run at most once per event through inbox.
+func NewProductConsumer(productUsecase usecase.ProductUsecase, inbox *inbox.Inbox) *ProductConsumer {
+	return &ProductConsumer{
+		productUsecase: productUsecase,
+		inbox:          inbox,
+	}
 }
 
 func (p *ProductConsumer) AddHandlers(eventProcessor *cqrs.EventProcessor) error {
@@ -20,6 +31,8 @@ func (p *ProductConsumer) AddHandlers(eventProcessor *cqrs.EventProcessor) error
 		cqrs.NewEventHandler("HandleProductUpdated", p.HandleProductUpdated),
 		cqrs.NewEventHandler("HandleProductDeleted", p.HandleProductDeleted),
 		cqrs.NewEventHandler("HandleProductPriceChanged", p.HandleProductPriceChanged),
+		cqrs.NewEventHandler("HandleProductStatusChanged", p.HandleProductStatusChanged),
+		cqrs.NewEventHandler("HandleProductReindexed", p.HandleProductReindexed),
 	)
 }
 
@@ -91,12 +104,51 @@ func (p *ProductConsumer) HandleProductPriceChanged(ctx context.Context, pe *eve
 		pe.Data.Source,
 	)
 
+	return p.inbox.Once(ctx, pe.EventId, "HandleProductPriceChanged", func(ctx context.Context, tx sqlc.Querier) error {
+		// Here you could:
+		// - Store handler-owned state through tx, committed with the inbox entry
+		// - Update pricing alerts
+		// - Recalculate recommendations
+		// - Update analytics dashboards
+		// - Send price change notifications
+		// - Access metadata: pe.Data.Metadata
+
+		return nil
+	})
+}
+
+func (p *ProductConsumer) HandleProductStatusChanged(ctx context.Context, pe *eventv1.ProductStatusChangedEvent) error {
+	log.Printf("Product status changed: ID=%s, Name=%s, PreviousStatus=%s, NewStatus=%s, EventID=%s, Source=%s",
+		pe.Product.Id,
+		pe.Product.Name,
+		pe.Data.PreviousStatus,
+		pe.Data.NewStatus,
+		pe.EventId,
+		pe.Data.Source,
+	)
+
 	// Here you could:
-	// - Update pricing alerts
-	// - Recalculate recommendations
-	// - Update analytics dashboards
-	// - Send price change notifications
+	// - Hide discontinued products from storefronts and search
+	// - Announce newly active products
+	// - Notify subscribers of discontinued products
 	// - Access metadata: pe.Data.Metadata
 
 	return nil
 }
+
+func (p *ProductConsumer) HandleProductReindexed(ctx context.Context, pe *eventv1.ProductReindexedEvent) error {
+	log.Printf("Product reindexed: ID=%s, Name=%s, Price=%s, EventID=%s, ReindexID=%s",
+		pe.Product.Id,
+		pe.Product.Name,
+		pe.Product.Price,
+		pe.EventId,
+		pe.Data.ReindexId,
+	)
+
+	// Here you could:
+	// - Upsert the product into a search index
+	// - Rebuild denormalized read models
+	// - Drop index entries not refreshed by this reindex ID
+
+	return nil
+}
@@ -5,13 +5,21 @@ import (
 	"log"
 
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/inbox"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
 	eventv1 "github.com/erry-az/go-init/proto/event/v1"
 )
 
-type UserConsumer struct{}
+type UserConsumer struct {
+	inbox *inbox.Inbox
+}
 
-func NewUserConsumer() *UserConsumer {
-	return &UserConsumer{}
+// NewUserConsumer creates the user consumer. Handlers with side effects that
+// must not repeat, like emails, run at most once per event through inbox.
+func NewUserConsumer(inbox *inbox.Inbox) *UserConsumer {
+	return &UserConsumer{
+		inbox: inbox,
+	}
 }
 
 func (u *UserConsumer) AddHandlers(eventProcessor *cqrs.EventProcessor) error {
@@ -19,6 +27,8 @@ func (u *UserConsumer) AddHandlers(eventProcessor *cqrs.EventProcessor) error {
 		cqrs.NewEventHandler("HandleUserCreated", u.HandleUserCreated),
 		cqrs.NewEventHandler("HandleUserUpdated", u.HandleUserUpdated),
 		cqrs.NewEventHandler("HandleUserDeleted", u.HandleUserDeleted),
+		cqrs.NewEventHandler("HandleUserEmailChangeRequested", u.HandleUserEmailChangeRequested),
+		cqrs.NewEventHandler("HandleUserEmailChanged", u.HandleUserEmailChanged),
 	)
 }
 
@@ -31,14 +41,17 @@ func (u *UserConsumer) HandleUserCreated(ctx context.Context, pe *eventv1.UserCr
 		pe.Data.Source,
 	)
 
-	// Here you could:
-	// - Send welcome email
-	// - Create user profile in another service
-	// - Update analytics
-	// - Log audit trail
-	// - Access metadata: pe.Data.Metadata
+	return u.inbox.Once(ctx, pe.EventId, "HandleUserCreated", func(ctx context.Context, tx sqlc.Querier) error {
+		// Here you could:
+		// - Store handler-owned state through tx, committed with the inbox entry
+		// - Send welcome email
+		// - Create user profile in another service
+		// - Update analytics
+		// - Log audit trail
+		// - Access metadata: pe.Data.Metadata
 
-	return nil
+		return nil
+	})
 }
 
 func (u *UserConsumer) HandleUserUpdated(ctx context.Context, pe *eventv1.UserUpdatedEvent) error {
@@ -79,3 +92,39 @@ func (u *UserConsumer) HandleUserDeleted(ctx context.Context, pe *eventv1.UserDe
 
 	return nil
 }
+
+func (u *UserConsumer) HandleUserEmailChangeRequested(ctx context.Context, pe *eventv1.UserEmailChangeRequestedEvent) error {
+	log.Printf("User email change requested: ID=%s, NewEmail=%s, ExpiresAt=%s, EventID=%s, Source=%s",
+		pe.User.Id,
+		pe.Data.NewEmail,
+		pe.Data.ExpiresAt.AsTime(),
+		pe.EventId,
+		pe.Data.Source,
+	)
+
+	return u.inbox.Once(ctx, pe.EventId, "HandleUserEmailChangeRequested", func(ctx context.Context, tx sqlc.Querier) error {
+		// Here you could:
+		// - Track pending changes of pe.User by pe.Data.RequestId
+		// - The confirmation token itself is minted and sent by the notification consumer
+
+		return nil
+	})
+}
+
+func (u *UserConsumer) HandleUserEmailChanged(ctx context.Context, pe *eventv1.UserEmailChangedEvent) error {
+	log.Printf("User email changed: ID=%s, OldEmail=%s, NewEmail=%s, EventID=%s, Source=%s",
+		pe.User.Id,
+		pe.Data.OldEmail,
+		pe.Data.NewEmail,
+		pe.EventId,
+		pe.Data.Source,
+	)
+
+	return u.inbox.Once(ctx, pe.EventId, "HandleUserEmailChanged", func(ctx context.Context, tx sqlc.Querier) error {
+		// Here you could:
+		// - Notify the old address that the email was changed
+		// - Update the email in external systems
+
+		return nil
+	})
+}
@@ -0,0 +1,30 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/usecase"
+	commandv1 "github.com/erry-az/go-init/proto/command/v1"
+)
+
+// WebhookConsumer processes the webhook deliveries the HTTP endpoint stored
+type WebhookConsumer struct {
+	webhookUsecase usecase.WebhookUsecase
+}
+
+func NewWebhookConsumer(webhookUsecase usecase.WebhookUsecase) *WebhookConsumer {
+	return &WebhookConsumer{
+		webhookUsecase: webhookUsecase,
+	}
+}
+
+func (w *WebhookConsumer) AddHandlers(commandProcessor *cqrs.CommandProcessor) error {
+	return commandProcessor.AddHandlers(
+		cqrs.NewCommandHandler("HandleProcessWebhook", w.HandleProcessWebhook),
+	)
+}
+
+func (w *WebhookConsumer) HandleProcessWebhook(ctx context.Context, cmd *commandv1.ProcessWebhookCommand) error {
+	return w.webhookUsecase.ProcessWebhook(ctx, cmd)
+}
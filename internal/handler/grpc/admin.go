@@ -0,0 +1,313 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/proto/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type AdminService struct {
+	v1.UnimplementedAdminServiceServer
+	usageUsecase    usecase.UsageUsecase
+	jobUsecase      usecase.JobUsecase
+	ipAccessUsecase usecase.IPAccessUsecase
+	customEvents    usecase.CustomEventUsecase
+	emailTemplates  usecase.EmailTemplateUsecase
+}
+
+// NewAdminService creates the admin service. ipAccessUsecase is nil when
+// network access control is disabled, failing the IP rule endpoints, and
+// customEvents while custom events are, failing PublishCustomEvent.
+func NewAdminService(usageUsecase usecase.UsageUsecase, jobUsecase usecase.JobUsecase, ipAccessUsecase usecase.IPAccessUsecase, customEvents usecase.CustomEventUsecase, emailTemplates usecase.EmailTemplateUsecase) *AdminService {
+	return &AdminService{
+		usageUsecase:    usageUsecase,
+		jobUsecase:      jobUsecase,
+		ipAccessUsecase: ipAccessUsecase,
+		customEvents:    customEvents,
+		emailTemplates:  emailTemplates,
+	}
+}
+
+func (s *AdminService) GetAPIUsage(ctx context.Context, req *v1.GetAPIUsageRequest) (*v1.GetAPIUsageResponse, error) {
+	usage, err := s.usageUsecase.GetAPIUsage(ctx, &usecase.GetAPIUsageRequest{
+		ClientID:  req.ClientId,
+		StartDate: req.StartDate.AsTime(),
+		EndDate:   req.EndDate.AsTime(),
+	})
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	items := make([]*v1.APIUsage, len(usage))
+	for i, u := range usage {
+		items[i] = &v1.APIUsage{
+			ClientId:         u.ClientID,
+			Day:              timestamppb.New(u.Day),
+			RequestCount:     u.RequestCount,
+			ErrorCount:       u.ErrorCount,
+			ErrorRate:        u.ErrorRate,
+			AverageLatencyMs: u.AverageLatencyMs,
+			MaxLatencyMs:     u.MaxLatencyMs,
+		}
+	}
+
+	return &v1.GetAPIUsageResponse{Usage: items}, nil
+}
+
+func (s *AdminService) ReindexProducts(ctx context.Context, req *v1.ReindexProductsRequest) (*v1.ReindexProductsResponse, error) {
+	job, err := s.jobUsecase.EnqueueReindexProducts(ctx, req.BatchSize)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	setAccepted(ctx)
+	return &v1.ReindexProductsResponse{ReindexId: job.ID.String(), Job: domainJobToProto(job)}, nil
+}
+
+// errIPAccessDisabled is returned by the IP rule endpoints while access control is off
+var errIPAccessDisabled = status.Error(codes.FailedPrecondition, "IP access control is not enabled")
+
+func (s *AdminService) ListIPRules(ctx context.Context, _ *v1.ListIPRulesRequest) (*v1.ListIPRulesResponse, error) {
+	if s.ipAccessUsecase == nil {
+		return nil, errIPAccessDisabled
+	}
+
+	rules, err := s.ipAccessUsecase.ListRules(ctx)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	items := make([]*v1.IPRule, len(rules))
+	for i, rule := range rules {
+		items[i] = domainIPRuleToProto(rule)
+	}
+
+	return &v1.ListIPRulesResponse{Rules: items}, nil
+}
+
+func (s *AdminService) AddIPRule(ctx context.Context, req *v1.AddIPRuleRequest) (*v1.IPRule, error) {
+	if s.ipAccessUsecase == nil {
+		return nil, errIPAccessDisabled
+	}
+
+	rule, err := s.ipAccessUsecase.AddRule(ctx, &usecase.AddIPRuleRequest{
+		List:        protoIPRuleLists[req.List],
+		Network:     req.Network,
+		Description: req.Description,
+	})
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return domainIPRuleToProto(rule), nil
+}
+
+func (s *AdminService) DeleteIPRule(ctx context.Context, req *v1.DeleteIPRuleRequest) (*emptypb.Empty, error) {
+	if s.ipAccessUsecase == nil {
+		return nil, errIPAccessDisabled
+	}
+
+	err := s.ipAccessUsecase.DeleteRule(ctx, protoIPRuleLists[req.List], req.Network)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AdminService) PublishCustomEvent(ctx context.Context, req *v1.PublishCustomEventRequest) (*v1.PublishCustomEventResponse, error) {
+	if s.customEvents == nil {
+		return nil, status.Error(codes.FailedPrecondition, "custom events are not enabled")
+	}
+
+	published, err := s.customEvents.PublishCustomEvent(ctx, &usecase.PublishCustomEventRequest{
+		Type:        req.Type,
+		Payload:     req.Payload.AsMap(),
+		OrderingKey: req.OrderingKey,
+	})
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &v1.PublishCustomEventResponse{EventId: published.EventID, Topic: published.Topic}, nil
+}
+
+func (s *AdminService) ListEmailTemplates(ctx context.Context, _ *v1.ListEmailTemplatesRequest) (*v1.ListEmailTemplatesResponse, error) {
+	templates, err := s.emailTemplates.ListTemplates(ctx)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	items := make([]*v1.EmailTemplate, len(templates))
+	for i, tmpl := range templates {
+		items[i] = domainEmailTemplateToProto(tmpl)
+	}
+
+	return &v1.ListEmailTemplatesResponse{Templates: items}, nil
+}
+
+func (s *AdminService) GetEmailTemplate(ctx context.Context, req *v1.GetEmailTemplateRequest) (*v1.EmailTemplate, error) {
+	tmpl, err := s.emailTemplates.GetTemplate(ctx, req.Name, req.Version)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return domainEmailTemplateToProto(tmpl), nil
+}
+
+func (s *AdminService) ListEmailTemplateVersions(ctx context.Context, req *v1.ListEmailTemplateVersionsRequest) (*v1.ListEmailTemplateVersionsResponse, error) {
+	versions, err := s.emailTemplates.ListVersions(ctx, req.Name)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	items := make([]*v1.EmailTemplate, len(versions))
+	for i, tmpl := range versions {
+		items[i] = domainEmailTemplateToProto(tmpl)
+	}
+
+	return &v1.ListEmailTemplateVersionsResponse{Versions: items}, nil
+}
+
+func (s *AdminService) CreateEmailTemplate(ctx context.Context, req *v1.SaveEmailTemplateRequest) (*v1.EmailTemplate, error) {
+	tmpl, err := s.emailTemplates.CreateTemplate(ctx, protoSaveEmailTemplateToUsecase(req))
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return domainEmailTemplateToProto(tmpl), nil
+}
+
+func (s *AdminService) UpdateEmailTemplate(ctx context.Context, req *v1.SaveEmailTemplateRequest) (*v1.EmailTemplate, error) {
+	tmpl, err := s.emailTemplates.UpdateTemplate(ctx, protoSaveEmailTemplateToUsecase(req))
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return domainEmailTemplateToProto(tmpl), nil
+}
+
+func (s *AdminService) DeleteEmailTemplate(ctx context.Context, req *v1.DeleteEmailTemplateRequest) (*emptypb.Empty, error) {
+	err := s.emailTemplates.DeleteTemplate(ctx, req.Name)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AdminService) PreviewEmailTemplate(ctx context.Context, req *v1.PreviewEmailTemplateRequest) (*v1.PreviewEmailTemplateResponse, error) {
+	preview := &usecase.PreviewEmailTemplateRequest{
+		Name:    req.Name,
+		Version: req.Version,
+	}
+	if req.Draft != nil {
+		preview.Draft = &usecase.SaveEmailTemplateRequest{
+			Subject:  req.Draft.Subject,
+			HTMLBody: req.Draft.HtmlBody,
+			TextBody: req.Draft.TextBody,
+		}
+	}
+	if req.Data != nil {
+		preview.Data = req.Data.AsMap()
+	}
+
+	rendered, err := s.emailTemplates.PreviewTemplate(ctx, preview)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &v1.PreviewEmailTemplateResponse{
+		Subject:  rendered.Subject,
+		HtmlBody: rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+	}, nil
+}
+
+var ipRuleLists = map[domain.IPRuleList]v1.IPRuleList{
+	domain.IPRuleListDeny:       v1.IPRuleList_IP_RULE_LIST_DENY,
+	domain.IPRuleListAdminAllow: v1.IPRuleList_IP_RULE_LIST_ADMIN_ALLOW,
+}
+
+var protoIPRuleLists = map[v1.IPRuleList]domain.IPRuleList{
+	v1.IPRuleList_IP_RULE_LIST_DENY:        domain.IPRuleListDeny,
+	v1.IPRuleList_IP_RULE_LIST_ADMIN_ALLOW: domain.IPRuleListAdminAllow,
+}
+
+func domainIPRuleToProto(rule *domain.IPRule) *v1.IPRule {
+	pb := &v1.IPRule{
+		List:        ipRuleLists[rule.List],
+		Network:     rule.Network.String(),
+		Description: rule.Description,
+		Static:      rule.Static,
+	}
+	if !rule.CreatedAt.IsZero() {
+		pb.CreatedAt = timestamppb.New(rule.CreatedAt)
+	}
+	return pb
+}
+
+func protoSaveEmailTemplateToUsecase(req *v1.SaveEmailTemplateRequest) *usecase.SaveEmailTemplateRequest {
+	return &usecase.SaveEmailTemplateRequest{
+		Name:     req.Name,
+		Subject:  req.Subject,
+		HTMLBody: req.HtmlBody,
+		TextBody: req.TextBody,
+	}
+}
+
+func domainEmailTemplateToProto(tmpl *domain.EmailTemplate) *v1.EmailTemplate {
+	return &v1.EmailTemplate{
+		Name:      tmpl.Name,
+		Version:   tmpl.Version,
+		Subject:   tmpl.Subject,
+		HtmlBody:  tmpl.HTMLBody,
+		TextBody:  tmpl.TextBody,
+		CreatedAt: timestamppb.New(tmpl.CreatedAt),
+	}
+}
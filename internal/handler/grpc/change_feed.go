@@ -0,0 +1,32 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/proto/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errChangeFeedDisabled is returned by the Watch* endpoints while change feeds are disabled
+var errChangeFeedDisabled = status.Error(codes.FailedPrecondition, "change feeds are not enabled")
+
+var changeTypes = map[usecase.ChangeType]v1.ChangeType{
+	usecase.ChangeCreated: v1.ChangeType_CHANGE_TYPE_CREATED,
+	usecase.ChangeUpdated: v1.ChangeType_CHANGE_TYPE_UPDATED,
+	usecase.ChangeDeleted: v1.ChangeType_CHANGE_TYPE_DELETED,
+}
+
+// watchError converts the error ending a watch. Callers falling behind get
+// Aborted, to list again before watching anew, and those of an instance
+// shutting down Unavailable, to watch another one.
+func watchError(err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrWatcherBehind):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, usecase.ErrFeedClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/pkg/protopool"
+	"github.com/erry-az/go-init/proto/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// errEventHistoryDisabled is returned by the event history endpoints while no history is recorded
+var errEventHistoryDisabled = status.Error(codes.FailedPrecondition, "event history is not enabled")
+
+// listEntityEvents lists a page of the history of an aggregate for the
+// Get*Events endpoints
+func listEntityEvents(ctx context.Context, history usecase.EventHistoryUsecase, req *usecase.ListEventsRequest) ([]*v1.EntityEvent, string, error) {
+	if history == nil {
+		return nil, "", errEventHistoryDisabled
+	}
+
+	result, err := history.ListEvents(ctx, req)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, "", domainErr.ToGRPCError()
+		}
+		return nil, "", err
+	}
+
+	arena := protopool.FromContext(ctx)
+	events := protopool.MakeSlice(arena, &eventSlicePool, len(result.Events))
+	for i, event := range result.Events {
+		events[i] = domainEntityEventToProto(arena, event)
+	}
+	return events, result.NextPageToken, nil
+}
+
+// Helper function to convert domain entity event to protobuf, allocating in arena
+func domainEntityEventToProto(arena *protopool.Arena, event *domain.EntityEvent) *v1.EntityEvent {
+	// Payloads are JSON objects; one that is not is left out rather than failing the listing
+	payload := &structpb.Struct{}
+	if err := payload.UnmarshalJSON(event.Payload); err != nil {
+		payload = nil
+	}
+
+	proto := protopool.Get(arena, &eventPool)
+	proto.EventId = event.ID
+	proto.EventName = event.Name
+	proto.PublishedAt = newTimestamp(arena, event.PublishedAt)
+	proto.Metadata = event.Metadata
+	proto.Payload = payload
+	return proto
+}
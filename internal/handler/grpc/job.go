@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/proto/api/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *AdminService) GetJob(ctx context.Context, req *v1.GetJobRequest) (*v1.GetJobResponse, error) {
+	job, err := s.jobUsecase.GetJob(ctx, req.Id)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &v1.GetJobResponse{Job: domainJobToProto(job)}, nil
+}
+
+func (s *AdminService) ListJobs(ctx context.Context, req *v1.ListJobsRequest) (*v1.ListJobsResponse, error) {
+	result, err := s.jobUsecase.ListJobs(ctx, &usecase.ListJobsRequest{
+		Kind:      req.Kind,
+		Status:    protoJobStatuses[req.Status],
+		PageSize:  req.PageSize,
+		PageToken: req.PageToken,
+	})
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	jobs := make([]*v1.Job, len(result.Jobs))
+	for i, job := range result.Jobs {
+		jobs[i] = domainJobToProto(job)
+	}
+
+	return &v1.ListJobsResponse{
+		Jobs:          jobs,
+		NextPageToken: result.NextPageToken,
+	}, nil
+}
+
+var jobStatuses = map[domain.JobStatus]v1.JobStatus{
+	domain.JobPending:   v1.JobStatus_JOB_STATUS_PENDING,
+	domain.JobRunning:   v1.JobStatus_JOB_STATUS_RUNNING,
+	domain.JobSucceeded: v1.JobStatus_JOB_STATUS_SUCCEEDED,
+	domain.JobFailed:    v1.JobStatus_JOB_STATUS_FAILED,
+}
+
+// protoJobStatuses maps JOB_STATUS_UNSPECIFIED to no status, listing every job
+var protoJobStatuses = map[v1.JobStatus]domain.JobStatus{
+	v1.JobStatus_JOB_STATUS_PENDING:   domain.JobPending,
+	v1.JobStatus_JOB_STATUS_RUNNING:   domain.JobRunning,
+	v1.JobStatus_JOB_STATUS_SUCCEEDED: domain.JobSucceeded,
+	v1.JobStatus_JOB_STATUS_FAILED:    domain.JobFailed,
+}
+
+func domainJobToProto(job *domain.Job) *v1.Job {
+	pb := &v1.Job{
+		Id:              job.ID.String(),
+		Kind:            job.Kind,
+		Status:          jobStatuses[job.Status],
+		ProgressPercent: job.ProgressPercent(),
+		Processed:       job.Processed,
+		Total:           job.Total,
+		Attempts:        job.Attempts,
+		Error:           job.Error,
+		ResultRef:       job.ResultRef,
+		CreatedAt:       timestamppb.New(job.CreatedAt),
+		UpdatedAt:       timestamppb.New(job.UpdatedAt),
+	}
+	if job.StartedAt != nil {
+		pb.StartedAt = timestamppb.New(*job.StartedAt)
+	}
+	if job.FinishedAt != nil {
+		pb.FinishedAt = timestamppb.New(*job.FinishedAt)
+	}
+	return pb
+}
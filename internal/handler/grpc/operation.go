@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/proto/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// HTTPStatusHeader is the response header through which a handler overrides
+// the HTTP status the gateway answers with
+const HTTPStatusHeader = "x-http-code"
+
+type OperationService struct {
+	v1.UnimplementedOperationServiceServer
+	operationUsecase usecase.OperationUsecase
+	// resourceIDs encode the IDs of the resources operations create, by operation kind
+	resourceIDs map[string]idCodec
+}
+
+// NewOperationService creates the operation service, showing the IDs of the
+// resources operations create as encoded by ids
+func NewOperationService(operationUsecase usecase.OperationUsecase, ids PublicIDs) *OperationService {
+	return &OperationService{
+		operationUsecase: operationUsecase,
+		resourceIDs: map[string]idCodec{
+			domain.OperationCreateUser:    newIDCodec("user", ids.User),
+			domain.OperationCreateProduct: newIDCodec("product", ids.Product),
+		},
+	}
+}
+
+func (s *OperationService) GetOperation(ctx context.Context, req *v1.GetOperationRequest) (*v1.GetOperationResponse, error) {
+	operation, err := s.operationUsecase.GetOperation(ctx, req.Id)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &v1.GetOperationResponse{Operation: s.domainOperationToProto(operation)}, nil
+}
+
+// setAccepted makes the gateway answer 202 Accepted for a write queued as an operation
+func setAccepted(ctx context.Context) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(HTTPStatusHeader, strconv.Itoa(http.StatusAccepted)))
+}
+
+var operationStatuses = map[domain.OperationStatus]v1.OperationStatus{
+	domain.OperationPending:   v1.OperationStatus_OPERATION_STATUS_PENDING,
+	domain.OperationSucceeded: v1.OperationStatus_OPERATION_STATUS_SUCCEEDED,
+	domain.OperationFailed:    v1.OperationStatus_OPERATION_STATUS_FAILED,
+}
+
+func (s *OperationService) domainOperationToProto(operation *domain.Operation) *v1.Operation {
+	pb := &v1.Operation{
+		Id:        operation.ID.String(),
+		Kind:      operation.Kind,
+		Status:    operationStatuses[operation.Status],
+		Error:     operation.Error,
+		CreatedAt: timestamppb.New(operation.CreatedAt),
+		UpdatedAt: timestamppb.New(operation.UpdatedAt),
+	}
+	if operation.ResourceID != nil {
+		pb.ResourceId = operation.ResourceID.String()
+		if ids, ok := s.resourceIDs[operation.Kind]; ok {
+			pb.ResourceId = ids.encode(*operation.ResourceID)
+		}
+	}
+	return pb
+}
@@ -0,0 +1,32 @@
+package grpc
+
+import (
+	"time"
+
+	"github.com/erry-az/go-init/pkg/protopool"
+	"github.com/erry-az/go-init/proto/api/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Pools of the messages list and stream responses are built from, reused
+// through the arena of each RPC
+var (
+	userPool          protopool.Pool[v1.User]
+	userSlicePool     protopool.SlicePool[*v1.User]
+	productPool       protopool.Pool[v1.Product]
+	productSlicePool  protopool.SlicePool[*v1.Product]
+	eventPool         protopool.Pool[v1.EntityEvent]
+	eventSlicePool    protopool.SlicePool[*v1.EntityEvent]
+	categoryStatsPool protopool.Pool[v1.ProductCategoryStats]
+	categorySlicePool protopool.SlicePool[*v1.ProductCategoryStats]
+	analyticsPool     protopool.Pool[v1.ProductAnalyticsResponse]
+	timestampPool     protopool.Pool[timestamppb.Timestamp]
+)
+
+// newTimestamp is timestamppb.New allocating in arena
+func newTimestamp(arena *protopool.Arena, t time.Time) *timestamppb.Timestamp {
+	ts := protopool.Get(arena, &timestampPool)
+	ts.Seconds = t.Unix()
+	ts.Nanos = int32(t.Nanosecond())
+	return ts
+}
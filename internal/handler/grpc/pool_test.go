@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/testutil/builders"
+	"github.com/erry-az/go-init/pkg/protopool"
+)
+
+// pageSize is the number of entities the List* benchmarks map per response
+const pageSize = 100
+
+// arenas are the ways responses are allocated: unpooled outside the gRPC
+// server, pooled in the arena every RPC gets from protopool.StatsHandler
+var arenas = []struct {
+	name  string
+	arena func() *protopool.Arena
+}{
+	{name: "unpooled", arena: func() *protopool.Arena { return nil }},
+	{name: "pooled", arena: protopool.NewArena},
+}
+
+func BenchmarkListUsersMapping(b *testing.B) {
+	s := NewUserService(nil, nil, nil, nil, nil)
+	users := make([]*domain.User, pageSize)
+	for i := range users {
+		users[i] = builders.User().WithMetadata(map[string]string{"source": "benchmark"}).Build()
+	}
+
+	for _, bm := range arenas {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				arena := bm.arena()
+				protos := protopool.MakeSlice(arena, &userSlicePool, len(users))
+				for j, user := range users {
+					protos[j] = s.domainUserToProto(arena, user)
+				}
+				// As the stats handler does once the response was sent
+				arena.Release()
+			}
+		})
+	}
+}
+
+func BenchmarkListProductsMapping(b *testing.B) {
+	s := NewProductService(nil, nil, nil, nil, "en", nil)
+	products := make([]*domain.Product, pageSize)
+	for i := range products {
+		products[i] = builders.Product().
+			WithAttribute("color", "red").
+			WithTranslation("id", "Produk", "Deskripsi").
+			Build()
+	}
+
+	for _, bm := range arenas {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				arena := bm.arena()
+				protos := protopool.MakeSlice(arena, &productSlicePool, len(products))
+				for j, product := range products {
+					protos[j] = s.domainProductToProto(arena, product)
+				}
+				arena.Release()
+			}
+		})
+	}
+}
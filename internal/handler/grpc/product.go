@@ -6,24 +6,58 @@ import (
 
 	"github.com/erry-az/go-init/internal/domain"
 	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/pkg/locale"
+	"github.com/erry-az/go-init/pkg/protopool"
+	"github.com/erry-az/go-init/pkg/publicid"
 	"github.com/erry-az/go-init/proto/api/v1"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type ProductService struct {
 	v1.UnimplementedProductServiceServer
-	productUsecase usecase.ProductUsecase
+	productUsecase   usecase.ProductUsecase
+	operationUsecase usecase.OperationUsecase
+	historyUsecase   usecase.EventHistoryUsecase
+	feedUsecase      usecase.ChangeFeedUsecase
+	defaultLocale    string
+	ids              idCodec
 }
 
-func NewProductService(productUsecase usecase.ProductUsecase) *ProductService {
+// NewProductService creates the product service. A non-nil operationUsecase
+// accepts CreateProduct for asynchronous processing; historyUsecase is nil
+// when no event history is recorded and feedUsecase when change feeds are
+// disabled. Products are answered in the locale negotiated from the
+// Accept-Language of each call, names and descriptions being in
+// defaultLocale unless translated. Product IDs are shown and taken as
+// encoded by ids.
+func NewProductService(productUsecase usecase.ProductUsecase, operationUsecase usecase.OperationUsecase, historyUsecase usecase.EventHistoryUsecase, feedUsecase usecase.ChangeFeedUsecase, defaultLocale string, ids publicid.Codec) *ProductService {
 	return &ProductService{
-		productUsecase: productUsecase,
+		productUsecase:   productUsecase,
+		operationUsecase: operationUsecase,
+		historyUsecase:   historyUsecase,
+		feedUsecase:      feedUsecase,
+		defaultLocale:    defaultLocale,
+		ids:              newIDCodec("product", ids),
 	}
 }
 
 func (s *ProductService) CreateProduct(ctx context.Context, req *v1.CreateProductRequest) (*v1.CreateProductResponse, error) {
-	product, err := s.productUsecase.CreateProduct(ctx, req.Name, req.Price)
+	if s.operationUsecase != nil {
+		operation, err := s.operationUsecase.EnqueueCreateProduct(ctx, req.Name, req.Description, req.Price, req.Category, req.Attributes.AsMap(), req.Metadata, translationsFromProto(req.Translations), productStatusFromProto[req.Status])
+		if err != nil {
+			if domainErr, ok := err.(*domain.DomainError); ok {
+				return nil, domainErr.ToGRPCError()
+			}
+			return nil, err
+		}
+
+		setAccepted(ctx)
+		return &v1.CreateProductResponse{OperationId: operation.ID.String()}, nil
+	}
+
+	product, err := s.productUsecase.CreateProduct(ctx, req.Name, req.Description, req.Price, req.Category, req.Attributes.AsMap(), req.Metadata, translationsFromProto(req.Translations), productStatusFromProto[req.Status])
 	if err != nil {
 		if domainErr, ok := err.(*domain.DomainError); ok {
 			return nil, domainErr.ToGRPCError()
@@ -31,11 +65,49 @@ func (s *ProductService) CreateProduct(ctx context.Context, req *v1.CreateProduc
 		return nil, err
 	}
 
-	return &v1.CreateProductResponse{Product: s.domainProductToProto(product)}, nil
+	return &v1.CreateProductResponse{Product: s.domainProductToProto(nil, s.localize(ctx, product))}, nil
 }
 
 func (s *ProductService) GetProduct(ctx context.Context, req *v1.GetProductRequest) (*v1.GetProductResponse, error) {
-	product, err := s.productUsecase.GetProduct(ctx, req.Id)
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productUsecase.GetProduct(ctx, id)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	scheduled, err := s.productUsecase.ListScheduledPrices(ctx, id)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	pending := make([]*v1.ScheduledPriceChange, len(scheduled))
+	for i, change := range scheduled {
+		pending[i] = s.scheduledPriceToProto(change)
+	}
+
+	return &v1.GetProductResponse{
+		Product:             s.domainProductToProto(nil, s.localize(ctx, product)),
+		PendingPriceChanges: pending,
+	}, nil
+}
+
+func (s *ProductService) SchedulePriceChange(ctx context.Context, req *v1.SchedulePriceChangeRequest) (*v1.SchedulePriceChangeResponse, error) {
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled, err := s.productUsecase.SchedulePriceChange(ctx, id, req.Price, req.EffectiveAt.AsTime())
 	if err != nil {
 		if domainErr, ok := err.(*domain.DomainError); ok {
 			return nil, domainErr.ToGRPCError()
@@ -43,11 +115,16 @@ func (s *ProductService) GetProduct(ctx context.Context, req *v1.GetProductReque
 		return nil, err
 	}
 
-	return &v1.GetProductResponse{Product: s.domainProductToProto(product)}, nil
+	return &v1.SchedulePriceChangeResponse{ScheduledPriceChange: s.scheduledPriceToProto(scheduled)}, nil
 }
 
 func (s *ProductService) UpdateProduct(ctx context.Context, req *v1.UpdateProductRequest) (*v1.UpdateProductResponse, error) {
-	product, err := s.productUsecase.UpdateProduct(ctx, req.Id, req.Name, req.Price)
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productUsecase.UpdateProduct(ctx, id, req.Name, req.Description, req.Price, req.Category, req.Attributes.AsMap(), req.Metadata, translationsFromProto(req.Translations))
 	if err != nil {
 		if domainErr, ok := err.(*domain.DomainError); ok {
 			return nil, domainErr.ToGRPCError()
@@ -55,11 +132,16 @@ func (s *ProductService) UpdateProduct(ctx context.Context, req *v1.UpdateProduc
 		return nil, err
 	}
 
-	return &v1.UpdateProductResponse{Product: s.domainProductToProto(product)}, nil
+	return &v1.UpdateProductResponse{Product: s.domainProductToProto(nil, s.localize(ctx, product))}, nil
 }
 
 func (s *ProductService) DeleteProduct(ctx context.Context, req *v1.DeleteProductRequest) (*emptypb.Empty, error) {
-	err := s.productUsecase.DeleteProduct(ctx, req.Id)
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.productUsecase.DeleteProduct(ctx, id)
 	if err != nil {
 		if domainErr, ok := err.(*domain.DomainError); ok {
 			return nil, domainErr.ToGRPCError()
@@ -70,11 +152,41 @@ func (s *ProductService) DeleteProduct(ctx context.Context, req *v1.DeleteProduc
 	return &emptypb.Empty{}, nil
 }
 
+func (s *ProductService) SetProductStatus(ctx context.Context, req *v1.SetProductStatusRequest) (*v1.SetProductStatusResponse, error) {
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productUsecase.SetProductStatus(ctx, id, productStatusFromProto[req.Status])
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &v1.SetProductStatusResponse{Product: s.domainProductToProto(nil, s.localize(ctx, product))}, nil
+}
+
 func (s *ProductService) ListProducts(ctx context.Context, req *v1.ListProductsRequest) (*v1.ListProductsResponse, error) {
+	locales := locale.FromContext(ctx)
 	listReq := &usecase.ListProductsRequest{
 		PageSize:    req.PageSize,
 		PageToken:   req.PageToken,
 		SearchQuery: req.SearchQuery,
+		Locales:     locales,
+		Category:    req.Category,
+		Filter:      req.Filter,
+		OrderBy:     req.OrderBy,
+	}
+
+	if req.AttributeFilter != nil {
+		listReq.AttributeFilter = req.AttributeFilter.AsMap()
+	}
+
+	for _, status := range req.Status {
+		listReq.Statuses = append(listReq.Statuses, productStatusFromProto[status])
 	}
 
 	// Convert price range if provided
@@ -93,9 +205,10 @@ func (s *ProductService) ListProducts(ctx context.Context, req *v1.ListProductsR
 		return nil, err
 	}
 
-	products := make([]*v1.Product, len(result.Products))
+	arena := protopool.FromContext(ctx)
+	products := protopool.MakeSlice(arena, &productSlicePool, len(result.Products))
 	for i, product := range result.Products {
-		products[i] = s.domainProductToProto(product)
+		products[i] = s.domainProductToProto(arena, product.Localize(locales, s.defaultLocale))
 	}
 
 	return &v1.ListProductsResponse{
@@ -106,12 +219,21 @@ func (s *ProductService) ListProducts(ctx context.Context, req *v1.ListProductsR
 }
 
 func (s *ProductService) BulkUpdatePrices(ctx context.Context, req *v1.BulkUpdatePricesRequest) (*v1.BulkUpdatePricesResponse, error) {
-	updates := make([]usecase.BulkPriceUpdate, len(req.Updates))
-	for i, update := range req.Updates {
-		updates[i] = usecase.BulkPriceUpdate{
-			ID:    update.Id,
-			Price: update.Price,
+	// Failed updates are reported under the IDs they were requested with
+	var failedIDs []string
+	publicIDs := make(map[string]string, len(req.Updates))
+	updates := make([]usecase.BulkPriceUpdate, 0, len(req.Updates))
+	for _, update := range req.Updates {
+		id, err := s.ids.decode(update.Id)
+		if err != nil {
+			failedIDs = append(failedIDs, update.Id)
+			continue
 		}
+		publicIDs[id] = update.Id
+		updates = append(updates, usecase.BulkPriceUpdate{
+			ID:    id,
+			Price: update.Price,
+		})
 	}
 
 	result, err := s.productUsecase.BulkUpdatePrices(ctx, updates)
@@ -122,14 +244,20 @@ func (s *ProductService) BulkUpdatePrices(ctx context.Context, req *v1.BulkUpdat
 		return nil, err
 	}
 
-	updatedProducts := make([]*v1.Product, len(result.UpdatedProducts))
+	for _, id := range result.FailedIDs {
+		failedIDs = append(failedIDs, publicIDs[id])
+	}
+
+	arena := protopool.FromContext(ctx)
+	updatedProducts := protopool.MakeSlice(arena, &productSlicePool, len(result.UpdatedProducts))
+	locales := locale.FromContext(ctx)
 	for i, product := range result.UpdatedProducts {
-		updatedProducts[i] = s.domainProductToProto(product)
+		updatedProducts[i] = s.domainProductToProto(arena, product.Localize(locales, s.defaultLocale))
 	}
 
 	return &v1.BulkUpdatePricesResponse{
 		UpdatedProducts: updatedProducts,
-		FailedIds:       result.FailedIDs,
+		FailedIds:       failedIDs,
 	}, nil
 }
 
@@ -143,30 +271,149 @@ func (s *ProductService) GetProductAnalytics(ctx context.Context, req *v1.Produc
 		return nil, err
 	}
 
-	categoryStats := make([]*v1.ProductCategoryStats, len(result.CategoryStats))
-	for i, stat := range result.CategoryStats {
-		categoryStats[i] = &v1.ProductCategoryStats{
-			Category: stat.Category,
-			Count:    stat.Count,
+	return analyticsToProto(protopool.FromContext(ctx), result), nil
+}
+
+func (s *ProductService) WatchProductAnalytics(req *v1.WatchProductAnalyticsRequest, stream v1.ProductService_WatchProductAnalyticsServer) error {
+	minInterval := req.MinInterval.AsDuration()
+
+	// Send marshals the update before returning, so its messages are reused for the next one
+	arena := protopool.NewArena()
+	err := s.productUsecase.WatchProductAnalytics(stream.Context(), minInterval, func(result *usecase.ProductAnalyticsResponse) error {
+		defer arena.Release()
+		return stream.Send(analyticsToProto(arena, result))
+	})
+	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) {
+			return domainErr.ToGRPCError()
 		}
+		return err
 	}
+	return nil
+}
 
-	return &v1.ProductAnalyticsResponse{
-		TotalProducts: result.TotalProducts,
-		AveragePrice:  result.AveragePrice,
-		HighestPrice:  result.HighestPrice,
-		LowestPrice:   result.LowestPrice,
-		CategoryStats: categoryStats,
-	}, nil
+func analyticsToProto(arena *protopool.Arena, result *usecase.ProductAnalyticsResponse) *v1.ProductAnalyticsResponse {
+	categoryStats := protopool.MakeSlice(arena, &categorySlicePool, len(result.CategoryStats))
+	for i, stat := range result.CategoryStats {
+		categoryStats[i] = protopool.Get(arena, &categoryStatsPool)
+		categoryStats[i].Category = stat.Category
+		categoryStats[i].Count = stat.Count
+	}
+
+	response := protopool.Get(arena, &analyticsPool)
+	response.TotalProducts = result.TotalProducts
+	response.AveragePrice = result.AveragePrice
+	response.HighestPrice = result.HighestPrice
+	response.LowestPrice = result.LowestPrice
+	response.CategoryStats = categoryStats
+	return response
+}
+
+func (s *ProductService) scheduledPriceToProto(scheduled *domain.ScheduledPrice) *v1.ScheduledPriceChange {
+	return &v1.ScheduledPriceChange{
+		Id:          scheduled.ID.String(),
+		ProductId:   s.ids.encode(scheduled.ProductID),
+		Price:       scheduled.GetPriceString(),
+		EffectiveAt: timestamppb.New(scheduled.EffectiveAt),
+		CreatedAt:   timestamppb.New(scheduled.CreatedAt),
+	}
+}
+
+func (s *ProductService) GetProductEvents(ctx context.Context, req *v1.GetProductEventsRequest) (*v1.GetProductEventsResponse, error) {
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, nextPageToken, err := listEntityEvents(ctx, s.historyUsecase, &usecase.ListEventsRequest{
+		AggregateType: domain.AggregateProduct,
+		AggregateID:   id,
+		PageSize:      req.PageSize,
+		PageToken:     req.PageToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.GetProductEventsResponse{Events: events, NextPageToken: nextPageToken}, nil
+}
+
+func (s *ProductService) WatchProducts(req *v1.WatchProductsRequest, stream v1.ProductService_WatchProductsServer) error {
+	if s.feedUsecase == nil {
+		return errChangeFeedDisabled
+	}
+
+	ctx := stream.Context()
+	err := s.feedUsecase.WatchProducts(ctx, func(change *usecase.ProductChange) error {
+		return stream.Send(&v1.ProductChange{
+			Type:       changeTypes[change.Type],
+			Product:    s.domainProductToProto(nil, s.localize(ctx, change.Product)),
+			EventId:    change.EventID,
+			OccurredAt: timestamppb.New(change.OccurredAt),
+		})
+	})
+	return watchError(err)
+}
+
+// localize returns product in the locale negotiated from the Accept-Language of ctx
+func (s *ProductService) localize(ctx context.Context, product *domain.Product) *domain.Product {
+	return product.Localize(locale.FromContext(ctx), s.defaultLocale)
+}
+
+// Helper method to convert domain product to protobuf, allocating in arena,
+// which is nil for single product responses
+func (s *ProductService) domainProductToProto(arena *protopool.Arena, product *domain.Product) *v1.Product {
+	attributes, _ := structpb.NewStruct(product.Attributes)
+
+	proto := protopool.Get(arena, &productPool)
+	proto.Id = s.ids.encode(product.ID)
+	proto.Name = product.Name
+	proto.Description = product.Description
+	proto.Translations = translationsToProto(product.Translations)
+	proto.Locale = product.Locale
+	proto.Price = product.GetPriceString()
+	proto.Category = product.Category
+	proto.Attributes = attributes
+	proto.Metadata = product.Metadata
+	proto.Status = productStatusToProto[product.Status]
+	proto.CreatedAt = newTimestamp(arena, product.CreatedAt)
+	proto.UpdatedAt = newTimestamp(arena, product.UpdatedAt)
+	proto.Version = product.Version
+	return proto
+}
+
+// productStatusToProto maps product statuses to their protobuf enum values
+var productStatusToProto = map[domain.ProductStatus]v1.ProductStatus{
+	domain.ProductDraft:        v1.ProductStatus_PRODUCT_STATUS_DRAFT,
+	domain.ProductActive:       v1.ProductStatus_PRODUCT_STATUS_ACTIVE,
+	domain.ProductDiscontinued: v1.ProductStatus_PRODUCT_STATUS_DISCONTINUED,
+}
+
+// productStatusFromProto maps protobuf enum values to product statuses,
+// unspecified to none
+var productStatusFromProto = map[v1.ProductStatus]domain.ProductStatus{
+	v1.ProductStatus_PRODUCT_STATUS_DRAFT:        domain.ProductDraft,
+	v1.ProductStatus_PRODUCT_STATUS_ACTIVE:       domain.ProductActive,
+	v1.ProductStatus_PRODUCT_STATUS_DISCONTINUED: domain.ProductDiscontinued,
+}
+
+func translationsToProto(translations map[string]domain.ProductTranslation) map[string]*v1.ProductTranslation {
+	if len(translations) == 0 {
+		return nil
+	}
+
+	protos := make(map[string]*v1.ProductTranslation, len(translations))
+	for tag, translation := range translations {
+		protos[tag] = &v1.ProductTranslation{Name: translation.Name, Description: translation.Description}
+	}
+	return protos
 }
 
-// Helper method to convert domain product to protobuf
-func (s *ProductService) domainProductToProto(product *domain.Product) *v1.Product {
-	return &v1.Product{
-		Id:        product.ID.String(),
-		Name:      product.Name,
-		Price:     product.GetPriceString(),
-		CreatedAt: timestamppb.New(product.CreatedAt),
-		UpdatedAt: timestamppb.New(product.UpdatedAt),
+func translationsFromProto(protos map[string]*v1.ProductTranslation) map[string]domain.ProductTranslation {
+	translations := make(map[string]domain.ProductTranslation, len(protos))
+	for tag, translation := range protos {
+		translations[tag] = domain.ProductTranslation{Name: translation.GetName(), Description: translation.GetDescription()}
 	}
+	return translations
 }
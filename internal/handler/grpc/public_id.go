@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/pkg/publicid"
+	"github.com/google/uuid"
+)
+
+// PublicIDs holds the codecs mapping the IDs of each entity to the public
+// IDs the API shows in their place. Unset codecs show IDs as stored.
+type PublicIDs struct {
+	User    publicid.Codec
+	Product publicid.Codec
+}
+
+// idCodec maps the IDs of one entity between their public form, taken and
+// returned by the API, and the internal form the usecases take
+type idCodec struct {
+	entity string
+	codec  publicid.Codec
+}
+
+func newIDCodec(entity string, codec publicid.Codec) idCodec {
+	if codec == nil {
+		codec, _ = publicid.New(publicid.UUID, nil, entity)
+	}
+	return idCodec{entity: entity, codec: codec}
+}
+
+func (c idCodec) encode(id uuid.UUID) string {
+	return c.codec.Encode(id)
+}
+
+// decode returns the internal ID of the public ID id, or an InvalidArgument
+// error when id is not one
+func (c idCodec) decode(id string) (string, error) {
+	internal, err := c.codec.Decode(id)
+	if err != nil {
+		return "", domain.NewValidationError(fmt.Sprintf("invalid %s ID", c.entity)).ToGRPCError()
+	}
+	return internal.String(), nil
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/erry-az/go-init/internal/domain"
 	"github.com/erry-az/go-init/internal/usecase"
+	"github.com/erry-az/go-init/pkg/protopool"
+	"github.com/erry-az/go-init/pkg/publicid"
 	"github.com/erry-az/go-init/proto/api/v1"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -12,17 +14,55 @@ import (
 
 type UserService struct {
 	v1.UnimplementedUserServiceServer
-	userUsecase usecase.UserUsecase
+	userUsecase      usecase.UserUsecase
+	operationUsecase usecase.OperationUsecase
+	historyUsecase   usecase.EventHistoryUsecase
+	feedUsecase      usecase.ChangeFeedUsecase
+	ids              idCodec
 }
 
-func NewUserService(userUsecase usecase.UserUsecase) *UserService {
+// NewUserService creates the user service. A non-nil operationUsecase
+// accepts CreateUser for asynchronous processing; historyUsecase is nil
+// when no event history is recorded and feedUsecase when change feeds are
+// disabled. User IDs are shown and taken as encoded by ids.
+func NewUserService(userUsecase usecase.UserUsecase, operationUsecase usecase.OperationUsecase, historyUsecase usecase.EventHistoryUsecase, feedUsecase usecase.ChangeFeedUsecase, ids publicid.Codec) *UserService {
 	return &UserService{
-		userUsecase: userUsecase,
+		userUsecase:      userUsecase,
+		operationUsecase: operationUsecase,
+		historyUsecase:   historyUsecase,
+		feedUsecase:      feedUsecase,
+		ids:              newIDCodec("user", ids),
 	}
 }
 
 func (s *UserService) CreateUser(ctx context.Context, req *v1.CreateUserRequest) (*v1.CreateUserResponse, error) {
-	user, err := s.userUsecase.CreateUser(ctx, req.Name, req.Email)
+	// get_if_exists needs the existing user in the response, so it stays synchronous
+	if s.operationUsecase != nil && !req.GetIfExists {
+		operation, err := s.operationUsecase.EnqueueCreateUser(ctx, req.Name, req.Email, req.Metadata)
+		if err != nil {
+			if domainErr, ok := err.(*domain.DomainError); ok {
+				return nil, domainErr.ToGRPCError()
+			}
+			return nil, err
+		}
+
+		setAccepted(ctx)
+		return &v1.CreateUserResponse{OperationId: operation.ID.String()}, nil
+	}
+
+	if req.GetIfExists {
+		user, created, err := s.userUsecase.CreateOrGetUser(ctx, req.Name, req.Email, req.Metadata)
+		if err != nil {
+			if domainErr, ok := err.(*domain.DomainError); ok {
+				return nil, domainErr.ToGRPCError()
+			}
+			return nil, err
+		}
+
+		return &v1.CreateUserResponse{User: s.domainUserToProto(nil, user), Created: created}, nil
+	}
+
+	user, err := s.userUsecase.CreateUser(ctx, req.Name, req.Email, req.Metadata)
 	if err != nil {
 		if domainErr, ok := err.(*domain.DomainError); ok {
 			return nil, domainErr.ToGRPCError()
@@ -30,11 +70,16 @@ func (s *UserService) CreateUser(ctx context.Context, req *v1.CreateUserRequest)
 		return nil, err
 	}
 
-	return &v1.CreateUserResponse{User: s.domainUserToProto(user)}, nil
+	return &v1.CreateUserResponse{User: s.domainUserToProto(nil, user), Created: true}, nil
 }
 
 func (s *UserService) GetUser(ctx context.Context, req *v1.GetUserRequest) (*v1.GetUserResponse, error) {
-	user, err := s.userUsecase.GetUser(ctx, req.Id)
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userUsecase.GetUser(ctx, id)
 	if err != nil {
 		if domainErr, ok := err.(*domain.DomainError); ok {
 			return nil, domainErr.ToGRPCError()
@@ -42,11 +87,33 @@ func (s *UserService) GetUser(ctx context.Context, req *v1.GetUserRequest) (*v1.
 		return nil, err
 	}
 
-	return &v1.GetUserResponse{User: s.domainUserToProto(user)}, nil
+	return &v1.GetUserResponse{User: s.domainUserToProto(nil, user)}, nil
 }
 
 func (s *UserService) UpdateUser(ctx context.Context, req *v1.UpdateUserRequest) (*v1.UpdateUserResponse, error) {
-	user, err := s.userUsecase.UpdateUser(ctx, req.Id, req.Name, req.Email)
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userUsecase.UpdateUser(ctx, id, req.Name, req.Email, req.Metadata)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &v1.UpdateUserResponse{User: s.domainUserToProto(nil, user)}, nil
+}
+
+func (s *UserService) SetUserRole(ctx context.Context, req *v1.SetUserRoleRequest) (*v1.SetUserRoleResponse, error) {
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userUsecase.SetUserRole(ctx, id, domain.Role(req.Role))
 	if err != nil {
 		if domainErr, ok := err.(*domain.DomainError); ok {
 			return nil, domainErr.ToGRPCError()
@@ -54,11 +121,16 @@ func (s *UserService) UpdateUser(ctx context.Context, req *v1.UpdateUserRequest)
 		return nil, err
 	}
 
-	return &v1.UpdateUserResponse{User: s.domainUserToProto(user)}, nil
+	return &v1.SetUserRoleResponse{User: s.domainUserToProto(nil, user)}, nil
 }
 
 func (s *UserService) DeleteUser(ctx context.Context, req *v1.DeleteUserRequest) (*emptypb.Empty, error) {
-	err := s.userUsecase.DeleteUser(ctx, req.Id)
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.userUsecase.DeleteUser(ctx, id)
 	if err != nil {
 		if domainErr, ok := err.(*domain.DomainError); ok {
 			return nil, domainErr.ToGRPCError()
@@ -74,6 +146,7 @@ func (s *UserService) ListUsers(ctx context.Context, req *v1.ListUsersRequest) (
 		PageSize:    req.PageSize,
 		PageToken:   req.PageToken,
 		SearchQuery: req.SearchQuery,
+		Filter:      req.Filter,
 	}
 
 	result, err := s.userUsecase.ListUsers(ctx, listReq)
@@ -84,9 +157,10 @@ func (s *UserService) ListUsers(ctx context.Context, req *v1.ListUsersRequest) (
 		return nil, err
 	}
 
-	users := make([]*v1.User, len(result.Users))
+	arena := protopool.FromContext(ctx)
+	users := protopool.MakeSlice(arena, &userSlicePool, len(result.Users))
 	for i, user := range result.Users {
-		users[i] = s.domainUserToProto(user)
+		users[i] = s.domainUserToProto(arena, user)
 	}
 
 	return &v1.ListUsersResponse{
@@ -100,8 +174,9 @@ func (s *UserService) BulkCreateUsers(ctx context.Context, req *v1.BulkCreateUse
 	bulkUsers := make([]usecase.BulkCreateUserRequest, len(req.Users))
 	for i, userReq := range req.Users {
 		bulkUsers[i] = usecase.BulkCreateUserRequest{
-			Name:  userReq.Name,
-			Email: userReq.Email,
+			Name:     userReq.Name,
+			Email:    userReq.Email,
+			Metadata: userReq.Metadata,
 		}
 	}
 
@@ -113,9 +188,10 @@ func (s *UserService) BulkCreateUsers(ctx context.Context, req *v1.BulkCreateUse
 		return nil, err
 	}
 
-	users := make([]*v1.User, len(result.Users))
+	arena := protopool.FromContext(ctx)
+	users := protopool.MakeSlice(arena, &userSlicePool, len(result.Users))
 	for i, user := range result.Users {
-		users[i] = s.domainUserToProto(user)
+		users[i] = s.domainUserToProto(arena, user)
 	}
 
 	return &v1.BulkCreateUsersResponse{
@@ -124,14 +200,81 @@ func (s *UserService) BulkCreateUsers(ctx context.Context, req *v1.BulkCreateUse
 	}, nil
 }
 
-// Helper method to convert domain user to protobuf
-func (s *UserService) domainUserToProto(user *domain.User) *v1.User {
-	return &v1.User{
-		Id:        user.ID.String(),
-		Name:      user.Name,
-		Email:     user.Email,
-		CreatedAt: timestamppb.New(user.CreatedAt),
-		UpdatedAt: timestamppb.New(user.UpdatedAt),
+func (s *UserService) GetUserEvents(ctx context.Context, req *v1.GetUserEventsRequest) (*v1.GetUserEventsResponse, error) {
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, nextPageToken, err := listEntityEvents(ctx, s.historyUsecase, &usecase.ListEventsRequest{
+		AggregateType: domain.AggregateUser,
+		AggregateID:   id,
+		PageSize:      req.PageSize,
+		PageToken:     req.PageToken,
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	return &v1.GetUserEventsResponse{Events: events, NextPageToken: nextPageToken}, nil
 }
 
+func (s *UserService) WatchUsers(req *v1.WatchUsersRequest, stream v1.UserService_WatchUsersServer) error {
+	if s.feedUsecase == nil {
+		return errChangeFeedDisabled
+	}
+
+	err := s.feedUsecase.WatchUsers(stream.Context(), func(change *usecase.UserChange) error {
+		return stream.Send(&v1.UserChange{
+			Type:       changeTypes[change.Type],
+			User:       s.domainUserToProto(nil, change.User),
+			EventId:    change.EventID,
+			OccurredAt: timestamppb.New(change.OccurredAt),
+		})
+	})
+	return watchError(err)
+}
+
+// Helper method to convert domain user to protobuf, allocating in arena,
+// which is nil for single user responses
+func (s *UserService) domainUserToProto(arena *protopool.Arena, user *domain.User) *v1.User {
+	proto := protopool.Get(arena, &userPool)
+	proto.Id = s.ids.encode(user.ID)
+	proto.Name = user.Name
+	proto.Email = user.Email
+	proto.Metadata = user.Metadata
+	proto.CreatedAt = newTimestamp(arena, user.CreatedAt)
+	proto.UpdatedAt = newTimestamp(arena, user.UpdatedAt)
+	proto.Version = user.Version
+	proto.Role = string(user.Role)
+	return proto
+}
+
+func (s *UserService) RequestEmailChange(ctx context.Context, req *v1.RequestEmailChangeRequest) (*v1.RequestEmailChangeResponse, error) {
+	id, err := s.ids.decode(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := s.userUsecase.RequestEmailChange(ctx, id, req.NewEmail)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &v1.RequestEmailChangeResponse{ExpiresAt: timestamppb.New(request.ExpiresAt)}, nil
+}
+
+func (s *UserService) ConfirmEmailChange(ctx context.Context, req *v1.ConfirmEmailChangeRequest) (*v1.ConfirmEmailChangeResponse, error) {
+	user, err := s.userUsecase.ConfirmEmailChange(ctx, req.Token)
+	if err != nil {
+		if domainErr, ok := err.(*domain.DomainError); ok {
+			return nil, domainErr.ToGRPCError()
+		}
+		return nil, err
+	}
+
+	return &v1.ConfirmEmailChangeResponse{User: s.domainUserToProto(nil, user)}, nil
+}
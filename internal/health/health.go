@@ -0,0 +1,198 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Defaults used when Options leaves a value unset
+const (
+	defaultInterval = 10 * time.Second
+	defaultTimeout  = 2 * time.Second
+)
+
+// CheckFunc returns an error when a dependency is unavailable
+type CheckFunc func(ctx context.Context) error
+
+// Options configures how often and how long dependencies are checked
+type Options struct {
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+type component struct {
+	name  string
+	check CheckFunc
+}
+
+// Result is the latest check of one dependency
+type Result struct {
+	Name    string
+	Healthy bool
+	// Error is why the dependency is unhealthy
+	Error     string
+	Duration  time.Duration
+	CheckedAt time.Time
+}
+
+// Report is the latest check of every dependency
+type Report struct {
+	// Healthy is true while every dependency is
+	Healthy bool
+	// Live is false once checks stopped completing, e.g. when they hang
+	Live    bool
+	Results []Result
+}
+
+// Monitor checks downstream dependencies and reports each one on the gRPC
+// health service under its own service name. The empty service name is
+// SERVING only while every dependency is.
+type Monitor struct {
+	opts       Options
+	server     *grpchealth.Server
+	components []component
+	created    time.Time
+
+	mu        sync.RWMutex
+	results   map[string]Result
+	lastRound time.Time
+}
+
+// New creates a monitor with no components; every status starts as NOT_SERVING
+func New(opts Options) *Monitor {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	server := grpchealth.NewServer()
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return &Monitor{
+		opts:    opts,
+		server:  server,
+		created: time.Now(),
+		results: make(map[string]Result),
+	}
+}
+
+// Watch registers a dependency reported under the service name name.
+// It must be called before Run.
+func (m *Monitor) Watch(name string, check CheckFunc) {
+	m.components = append(m.components, component{name: name, check: check})
+	m.server.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// Register adds the health service to a gRPC server
+func (m *Monitor) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, m.server)
+}
+
+// Run checks every dependency on each interval and blocks until ctx is
+// cancelled, after which every status is NOT_SERVING
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.checkAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			m.server.Shutdown()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAll runs the checks concurrently so a slow dependency cannot delay the others
+func (m *Monitor) checkAll(ctx context.Context) {
+	healthy := make([]bool, len(m.components))
+
+	var wg sync.WaitGroup
+	for i, c := range m.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			healthy[i] = m.check(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	overall := healthpb.HealthCheckResponse_SERVING
+	for _, ok := range healthy {
+		if !ok {
+			overall = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	m.server.SetServingStatus("", overall)
+
+	m.mu.Lock()
+	m.lastRound = time.Now()
+	m.mu.Unlock()
+}
+
+func (m *Monitor) check(ctx context.Context, c component) bool {
+	checkCtx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.check(checkCtx)
+	if err != nil && ctx.Err() != nil {
+		return false
+	}
+
+	result := Result{Name: c.name, Healthy: err == nil, Duration: time.Since(start), CheckedAt: start}
+	if err != nil {
+		slog.Warn("Dependency unhealthy", "component", c.name, slog.Any("error", err))
+		m.server.SetServingStatus(c.name, healthpb.HealthCheckResponse_NOT_SERVING)
+		result.Error = err.Error()
+	} else {
+		m.server.SetServingStatus(c.name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	m.mu.Lock()
+	m.results[c.name] = result
+	m.mu.Unlock()
+	return err == nil
+}
+
+// Report returns the latest check of every dependency, in the order they were
+// watched. Dependencies not checked yet are unhealthy.
+func (m *Monitor) Report() Report {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// A round takes at most the timeout, so missing a few means checks hang
+	since := m.created
+	if m.lastRound.After(since) {
+		since = m.lastRound
+	}
+	report := Report{
+		Healthy: true,
+		Live:    time.Since(since) < 3*m.opts.Interval+m.opts.Timeout,
+		Results: make([]Result, len(m.components)),
+	}
+	for i, c := range m.components {
+		result, ok := m.results[c.name]
+		if !ok {
+			result = Result{Name: c.name, Error: "not checked yet"}
+		}
+		report.Results[i] = result
+		report.Healthy = report.Healthy && result.Healthy
+	}
+	return report
+}
@@ -0,0 +1,73 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/repository"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+)
+
+// ErrMissingEventID is returned for events without an ID, which cannot be deduplicated
+var ErrMissingEventID = errors.New("inbox: event has no ID")
+
+// skipped counts redelivered events whose handler already ran, keyed by handler
+var skipped = expvar.NewMap("inbox_duplicates_skipped_total")
+
+// Func is the body of a handler run at most once per event. Handler-owned
+// state must be written through tx so it commits together with the inbox entry.
+type Func func(ctx context.Context, tx sqlc.Querier) error
+
+// Inbox records which handlers processed which events so redelivered events
+// are not handled twice
+type Inbox struct {
+	db repository.TxBeginner
+}
+
+// New creates an inbox stored in db, which must also hold the state handlers write
+func New(db repository.TxBeginner) *Inbox {
+	return &Inbox{db: db}
+}
+
+// Once runs fn unless handler already processed eventID. The inbox entry and
+// everything fn writes through tx commit in one transaction, so a failing fn
+// leaves no entry and the event is handled again on redelivery.
+//
+// Side effects outside the database, like sending an email, should come last
+// in fn: they are skipped on every redelivery once the transaction commits,
+// but repeat if the commit itself fails.
+func (i *Inbox) Once(ctx context.Context, eventID, handler string, fn Func) error {
+	if eventID == "" {
+		return ErrMissingEventID
+	}
+
+	tx, err := i.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("inbox: beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := sqlc.New(tx)
+	inserted, err := q.InsertInboxMessage(ctx, sqlc.InsertInboxMessageParams{
+		EventID: eventID,
+		Handler: handler,
+	})
+	if err != nil {
+		return fmt.Errorf("inbox: recording event %s: %w", eventID, err)
+	}
+	if inserted == 0 {
+		skipped.Add(handler, 1)
+		return nil
+	}
+
+	if err := fn(ctx, q); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("inbox: committing event %s: %w", eventID, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package ipaccess
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// ForwardedForMetadataKey is the header, and gRPC metadata key, listing the
+// addresses a request was forwarded for, client first
+const ForwardedForMetadataKey = "x-forwarded-for"
+
+var (
+	// ErrDenied is returned for client addresses on the denylist
+	ErrDenied = errors.New("client address is denied")
+	// ErrAdminNotAllowed is returned for admin routes requested from outside the admin allowlist
+	ErrAdminNotAllowed = errors.New("client address is not allowed to reach admin routes")
+)
+
+// rejections counts rejected requests keyed by reason
+var rejections = expvar.NewMap("ip_access_rejections_total")
+
+// Rules are the networks requests are checked against
+type Rules struct {
+	// Deny lists networks refused on every route
+	Deny []netip.Prefix
+	// AdminAllow lists the only networks that may reach admin routes; empty allows any
+	AdminAllow []netip.Prefix
+}
+
+// Controller decides which client addresses may reach which routes. Static
+// rules come from configuration; dynamic rules are managed at runtime and
+// replaced as a whole by SetDynamic. Both apply together.
+type Controller struct {
+	static         Rules
+	trustedProxies []netip.Prefix
+	adminRoutes    []string
+
+	mu      sync.RWMutex
+	dynamic Rules
+}
+
+// NewController creates a controller enforcing static. Forwarded-for headers
+// are only believed from trustedProxies and loopback addresses, since the
+// gateway reaches the gRPC endpoint over loopback. adminRoutes are prefixes of
+// HTTP paths or full gRPC method names, e.g. /proto.api.v1.AdminService/.
+func NewController(static Rules, trustedProxies []netip.Prefix, adminRoutes []string) *Controller {
+	return &Controller{
+		static:         static,
+		trustedProxies: trustedProxies,
+		adminRoutes:    adminRoutes,
+	}
+}
+
+// Static returns the configured rules
+func (c *Controller) Static() Rules {
+	return c.static
+}
+
+// SetDynamic replaces the rules managed at runtime
+func (c *Controller) SetDynamic(rules Rules) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dynamic = rules
+}
+
+// IsAdminRoute reports whether route, an HTTP path or gRPC method, is an admin route
+func (c *Controller) IsAdminRoute(route string) bool {
+	for _, prefix := range c.adminRoutes {
+		if strings.HasPrefix(route, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns ErrDenied when addr is on a denylist and ErrAdminNotAllowed
+// when route is an admin route and addr is outside a non-empty admin
+// allowlist. An unknown address is only refused on allowlisted admin routes.
+func (c *Controller) Check(addr netip.Addr, route string) error {
+	addr = addr.Unmap()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if contains(c.static.Deny, addr) || contains(c.dynamic.Deny, addr) {
+		rejections.Add("deny", 1)
+		return ErrDenied
+	}
+
+	if !c.IsAdminRoute(route) || len(c.static.AdminAllow)+len(c.dynamic.AdminAllow) == 0 {
+		return nil
+	}
+	if contains(c.static.AdminAllow, addr) || contains(c.dynamic.AdminAllow, addr) {
+		return nil
+	}
+	rejections.Add("admin_allow", 1)
+	return ErrAdminNotAllowed
+}
+
+// ClientAddr returns the address of the client behind remote. While the
+// nearest hop is a trusted proxy, the address it forwarded for, read from the
+// right of the forwarded-for values, is taken instead.
+func (c *Controller) ClientAddr(remote netip.Addr, forwardedFor []string) netip.Addr {
+	var hops []string
+	for _, value := range forwardedFor {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	addr := remote.Unmap()
+	for i := len(hops) - 1; i >= 0 && c.trusted(addr); i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+	}
+	return addr
+}
+
+func (c *Controller) trusted(addr netip.Addr) bool {
+	return addr.IsLoopback() || contains(c.trustedProxies, addr)
+}
+
+func contains(networks []netip.Prefix, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAddr parses a host:port or bare address, returning the zero Addr when
+// it is neither
+func ParseAddr(address string) netip.Addr {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// ParsePrefix parses a CIDR network or a single address, which becomes a
+// network of just that address. Host bits are cleared, so 10.1.2.3/8 reads as
+// 10.0.0.0/8.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid address %q", s)
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid network %q", s)
+	}
+	return prefix.Masked(), nil
+}
+
+// ParsePrefixes parses every entry with ParsePrefix
+func ParsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		prefix, err := ParsePrefix(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
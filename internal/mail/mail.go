@@ -0,0 +1,231 @@
+// Package mail sends outbound emails, such as the ones the notification
+// consumer renders from email templates.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// smtpTimeout bounds sending one email when the context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// Message is an email to one recipient with an HTML body, a text body or both
+type Message struct {
+	To       string
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPOptions configures an SMTPMailer
+type SMTPOptions struct {
+	// Addr is the host:port of the SMTP server
+	Addr string
+	// From is the sender address, e.g. "Shop <no-reply@example.com>"
+	From string
+	// Username and Password authenticate with PLAIN auth when Username is set
+	Username string
+	Password string
+}
+
+// SMTPMailer sends emails through an SMTP server, upgrading the connection
+// with STARTTLS when the server offers it
+type SMTPMailer struct {
+	addr string
+	host string
+	from *mail.Address
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a mailer sending through the server at opts.Addr
+func NewSMTPMailer(opts SMTPOptions) (*SMTPMailer, error) {
+	host, _, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("mail: parsing SMTP address: %w", err)
+	}
+	from, err := mail.ParseAddress(opts.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail: parsing sender address: %w", err)
+	}
+
+	m := &SMTPMailer{
+		addr: opts.Addr,
+		host: host,
+		from: from,
+	}
+	if opts.Username != "" {
+		m.auth = smtp.PlainAuth("", opts.Username, opts.Password, host)
+	}
+	return m, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("mail: parsing recipient address: %w", err)
+	}
+	body, err := m.compose(to, msg)
+	if err != nil {
+		return err
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("mail: connecting to SMTP server: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mail: connecting to SMTP server: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("mail: starting TLS: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return fmt.Errorf("mail: authenticating: %w", err)
+		}
+	}
+	if err := c.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("mail: sending: %w", err)
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("mail: sending to %s: %w", to.Address, err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("mail: sending: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("mail: sending: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail: sending: %w", err)
+	}
+	return c.Quit()
+}
+
+// compose builds the MIME message of msg, a multipart/alternative one when
+// it has both bodies
+func (m *SMTPMailer) compose(to *mail.Address, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("From", m.from.String())
+	header.Set("To", to.String())
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-ID", m.messageID())
+	header.Set("MIME-Version", "1.0")
+
+	if msg.HTMLBody == "" || msg.TextBody == "" {
+		contentType, body := "text/plain; charset=utf-8", msg.TextBody
+		if msg.HTMLBody != "" {
+			contentType, body = "text/html; charset=utf-8", msg.HTMLBody
+		}
+		header.Set("Content-Type", contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeHeader(&buf, header)
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	// Clients show the last part they support, so the HTML body goes last
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.TextBody},
+		{"text/html; charset=utf-8", msg.HTMLBody},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("mail: composing message: %w", err)
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("mail: composing message: %w", err)
+	}
+
+	header.Set("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	writeHeader(&buf, header)
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+func (m *SMTPMailer) messageID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	domain := m.host
+	if at := strings.LastIndex(m.from.Address, "@"); at >= 0 {
+		domain = m.from.Address[at+1:]
+	}
+	return "<" + hex.EncodeToString(b[:]) + "@" + domain + ">"
+}
+
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, key := range []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type", "Content-Transfer-Encoding"} {
+		if value := header.Get(key); value != "" {
+			fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return fmt.Errorf("mail: composing message: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("mail: composing message: %w", err)
+	}
+	return nil
+}
+
+// LogMailer logs emails instead of sending them, for environments without
+// an SMTP server
+type LogMailer struct{}
+
+func (LogMailer) Send(_ context.Context, msg Message) error {
+	slog.Info("Email not sent, no SMTP server configured", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
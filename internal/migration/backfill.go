@@ -0,0 +1,76 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BackfillResult sums up a backfill run
+type BackfillResult struct {
+	Batches int
+	// Read counts the rows of the old table read, Copied those missing from
+	// the new table and copied
+	Read   int64
+	Copied int64
+}
+
+// Backfill copies the rows of the old table missing from the new one in
+// batches of batchSize, resuming after the last batch a previous run
+// recorded. Each batch commits with its progress, so the run can stop at any
+// point. Dual writes must be on, otherwise rows written during the run are
+// missed.
+func Backfill(ctx context.Context, pool *pgxpool.Pool, t Table, batchSize int32) (BackfillResult, error) {
+	var result BackfillResult
+	if batchSize <= 0 {
+		return result, fmt.Errorf("batch size must be positive")
+	}
+
+	progress, err := sqlc.New(pool).StartDataMigrationBackfill(ctx, t.Name)
+	if err != nil {
+		return result, fmt.Errorf("start backfill: %w", err)
+	}
+
+	query := t.backfillQuery()
+	cursor := progress.BackfillCursor
+	for {
+		var last pgtype.Text
+		var read, copied int64
+		err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+			if err := tx.QueryRow(ctx, query, cursor, batchSize).Scan(&last, &read, &copied); err != nil {
+				return fmt.Errorf("copy batch: %w", err)
+			}
+			if read == 0 {
+				return nil
+			}
+			return sqlc.New(tx).RecordDataMigrationBatch(ctx, sqlc.RecordDataMigrationBatchParams{
+				Name:           t.Name,
+				BackfillCursor: last,
+				RowsCopied:     copied,
+			})
+		})
+		if err != nil {
+			return result, err
+		}
+		if read == 0 {
+			break
+		}
+
+		result.Batches++
+		result.Read += read
+		result.Copied += copied
+		cursor = last
+		if read < int64(batchSize) {
+			break
+		}
+	}
+
+	if err := sqlc.New(pool).FinishDataMigrationBackfill(ctx, t.Name); err != nil {
+		return result, fmt.Errorf("finish backfill: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package migration
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Phase is how far the dual-write migration of a table has gone. Phases only
+// move forward, one at a time: writes first go to both tables, existing rows
+// are then copied and compared, and reads switch to the new table last.
+type Phase string
+
+const (
+	// PhaseOff writes and reads only the old table
+	PhaseOff Phase = "off"
+	// PhaseDualWrite writes both tables and reads the old one
+	PhaseDualWrite Phase = "dual_write"
+	// PhaseBackfill writes both tables while migrate backfill copies existing rows
+	PhaseBackfill Phase = "backfill"
+	// PhaseVerify writes both tables while migrate verify compares their checksums
+	PhaseVerify Phase = "verify"
+	// PhaseReadNew writes both tables and reads the new one, so reads can still move back
+	PhaseReadNew Phase = "read_new"
+	// PhaseComplete writes and reads only the new table
+	PhaseComplete Phase = "complete"
+)
+
+// phaseOrder lists the phases in the order a migration goes through them
+var phaseOrder = []Phase{PhaseOff, PhaseDualWrite, PhaseBackfill, PhaseVerify, PhaseReadNew, PhaseComplete}
+
+// AtLeast reports whether p is other or a later phase
+func (p Phase) AtLeast(other Phase) bool {
+	return slices.Index(phaseOrder, p) >= slices.Index(phaseOrder, other)
+}
+
+// WritesOld reports whether writes still go to the old table
+func (p Phase) WritesOld() bool {
+	return p != PhaseComplete
+}
+
+// WritesNew reports whether writes go to the new table
+func (p Phase) WritesNew() bool {
+	return p.AtLeast(PhaseDualWrite)
+}
+
+// ReadsNew reports whether reads go to the new table
+func (p Phase) ReadsNew() bool {
+	return p.AtLeast(PhaseReadNew)
+}
+
+// Phases maps dual-write migrations to their phase
+type Phases map[string]Phase
+
+// ParsePhases converts the configured phases of migration.dual_write
+func ParsePhases(flags map[string]string) (Phases, error) {
+	phases := make(Phases, len(flags))
+	for name, value := range flags {
+		phase := Phase(value)
+		if !slices.Contains(phaseOrder, phase) {
+			return nil, fmt.Errorf("invalid phase %q for dual-write migration %s", value, name)
+		}
+		phases[name] = phase
+	}
+	return phases, nil
+}
+
+// Of returns the phase of the migration name, off when unset
+func (p Phases) Of(name string) Phase {
+	if phase, ok := p[name]; ok {
+		return phase
+	}
+	return PhaseOff
+}
+
+// DualWrite runs the writes phase calls for: writeOld while the old table is
+// written and writeNew once the new one is. The old table is written first as
+// it stays authoritative until reads move. writeNew must upsert, since the
+// row it writes may not have been backfilled yet. Run both in one
+// transaction so they commit together.
+func DualWrite(phase Phase, writeOld, writeNew func() error) error {
+	if phase.WritesOld() {
+		if err := writeOld(); err != nil {
+			return err
+		}
+	}
+	if phase.WritesNew() {
+		return writeNew()
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Severity decides whether a finding blocks the migration
+type Severity string
+
+const (
+	SeverityError Severity = "error"
+	SeverityWarn  Severity = "warn"
+	SeverityOff   Severity = "off"
+)
+
+// Lint rules
+const (
+	// RuleTableRewrite flags statements that rewrite a whole table under an exclusive lock
+	RuleTableRewrite = "table_rewrite"
+	// RuleNonConcurrentIndex flags index builds that block writes to an existing table
+	RuleNonConcurrentIndex = "non_concurrent_index"
+	// RuleDropColumn flags column drops that break instances still reading the column
+	RuleDropColumn = "drop_column"
+)
+
+// Policy maps rule names to their severity
+type Policy map[string]Severity
+
+// DefaultPolicy applies to rules a policy leaves unset
+var DefaultPolicy = Policy{
+	RuleTableRewrite:       SeverityError,
+	RuleNonConcurrentIndex: SeverityWarn,
+	RuleDropColumn:         SeverityError,
+}
+
+// severity returns the configured severity of rule, falling back to DefaultPolicy
+func (p Policy) severity(rule string) Severity {
+	if severity, ok := p[rule]; ok {
+		return severity
+	}
+	return DefaultPolicy[rule]
+}
+
+// Finding is a dangerous statement found in a migration
+type Finding struct {
+	File     string
+	Line     int
+	Rule     string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s [%s] %s", f.File, f.Line, f.Severity, f.Rule, f.Message)
+}
+
+// volatileDefaults are functions whose column default forces a table rewrite
+var volatileDefaults = []string{"random(", "gen_random_uuid(", "uuid_generate_v", "clock_timestamp(", "nextval(", "timeofday("}
+
+// dropKeywords follow DROP in ALTER TABLE actions that keep the column
+var dropKeywords = map[string]bool{"CONSTRAINT": true, "DEFAULT": true, "NOT": true, "EXPRESSION": true, "IDENTITY": true}
+
+var (
+	createTablePattern = regexp.MustCompile(`(?i)^CREATE\s+(?:UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)`)
+	createIndexPattern = regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?.*?\bON\s+(?:ONLY\s+)?([^\s(]+)`)
+	alterTablePattern  = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([^\s]+)\s+(.*)$`)
+	alterTypePattern   = regexp.MustCompile(`(?i)\bALTER\s+(?:COLUMN\s+)?[^\s,]+\s+(?:SET\s+DATA\s+)?TYPE\b`)
+	dropColumnPattern  = regexp.MustCompile(`(?i)\bDROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?([^\s,;]+)`)
+	addColumnPattern   = regexp.MustCompile(`(?i)\bADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?([^\s,]+)\s+([^,]*)`)
+	serialPattern      = regexp.MustCompile(`(?i)^(?:small|big)?serial\b`)
+)
+
+// Lint checks the statements of one migration file. Tables created by the
+// file itself are exempt, as nothing reads or writes them yet.
+func Lint(file, content string, policy Policy) []Finding {
+	var findings []Finding
+	report := func(stmt statement, rule, message string) {
+		severity := policy.severity(rule)
+		if severity == SeverityOff {
+			return
+		}
+		findings = append(findings, Finding{File: file, Line: stmt.line, Rule: rule, Severity: severity, Message: message})
+	}
+
+	statements := splitStatements(content)
+
+	created := make(map[string]bool)
+	for _, stmt := range statements {
+		if m := createTablePattern.FindStringSubmatch(stmt.text); m != nil {
+			created[tableName(m[1])] = true
+		}
+	}
+
+	for _, stmt := range statements {
+		if m := createIndexPattern.FindStringSubmatch(stmt.text); m != nil {
+			if m[1] == "" && !created[tableName(m[2])] {
+				report(stmt, RuleNonConcurrentIndex, fmt.Sprintf(
+					"index on %s blocks writes while it builds; use CREATE INDEX CONCURRENTLY in a migration with -- atlas:txmode none",
+					tableName(m[2])))
+			}
+			continue
+		}
+
+		m := alterTablePattern.FindStringSubmatch(stmt.text)
+		if m == nil || created[tableName(m[1])] {
+			continue
+		}
+		table, actions := tableName(m[1]), m[2]
+
+		if alterTypePattern.MatchString(actions) {
+			report(stmt, RuleTableRewrite, fmt.Sprintf("changing a column type rewrites %s under an exclusive lock", table))
+		}
+
+		for _, add := range addColumnPattern.FindAllStringSubmatch(actions, -1) {
+			definition := strings.ToLower(add[2])
+			if serialPattern.MatchString(definition) || containsAny(definition, volatileDefaults) {
+				report(stmt, RuleTableRewrite, fmt.Sprintf(
+					"column %s has a volatile default, which rewrites %s under an exclusive lock", tableName(add[1]), table))
+			}
+		}
+
+		for _, drop := range dropColumnPattern.FindAllStringSubmatch(actions, -1) {
+			if dropKeywords[strings.ToUpper(drop[1])] {
+				continue
+			}
+			report(stmt, RuleDropColumn, fmt.Sprintf(
+				"dropping %s.%s breaks instances still reading it; stop using the column in a release before dropping it",
+				table, tableName(drop[1])))
+		}
+	}
+
+	return findings
+}
+
+// tableName strips quotes and the public schema so names compare equal
+func tableName(name string) string {
+	name = strings.ReplaceAll(name, `"`, "")
+	return strings.TrimPrefix(strings.ToLower(name), "public.")
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+type statement struct {
+	text string
+	line int
+}
+
+// splitStatements splits SQL on semicolons outside quotes, comments and
+// dollar-quoted bodies. Each statement is collapsed onto one line and keeps
+// the line it starts on.
+func splitStatements(content string) []statement {
+	var statements []statement
+	var sb strings.Builder
+	line, start := 1, 0
+
+	flush := func() {
+		text := strings.Join(strings.Fields(sb.String()), " ")
+		if text != "" {
+			statements = append(statements, statement{text: text, line: start})
+		}
+		sb.Reset()
+		start = 0
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		if c == '\n' {
+			line++
+		}
+
+		switch {
+		case c == '-' && strings.HasPrefix(content[i:], "--"):
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				i = len(content)
+				continue
+			}
+			i += end - 1
+			continue
+
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(content[i+1:], c)
+			if end < 0 {
+				end = len(content) - i - 1
+			}
+			quoted := content[i:min(len(content), i+end+2)]
+			if start == 0 {
+				start = line
+			}
+			sb.WriteString(quoted)
+			line += strings.Count(quoted, "\n")
+			i += end + 1
+			continue
+
+		case c == '$':
+			if tag := dollarTag(content[i:]); tag != "" {
+				end := strings.Index(content[i+len(tag):], tag)
+				if end < 0 {
+					end = len(content) - i - len(tag)
+				}
+				body := content[i:min(len(content), i+len(tag)+end+len(tag))]
+				if start == 0 {
+					start = line
+				}
+				sb.WriteString(body)
+				line += strings.Count(body, "\n")
+				i += len(body) - 1
+				continue
+			}
+
+		case c == ';':
+			flush()
+			continue
+		}
+
+		if start == 0 && c != '\n' && c != ' ' && c != '\t' && c != '\r' {
+			start = line
+		}
+		sb.WriteByte(c)
+	}
+	flush()
+
+	return statements
+}
+
+var dollarTagPattern = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// dollarTag returns the $tag$ opening s, if any
+func dollarTag(s string) string {
+	return dollarTagPattern.FindString(s)
+}
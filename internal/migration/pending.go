@@ -0,0 +1,88 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// undefinedTable is the PostgreSQL error code of a missing relation
+const undefinedTable = "42P01"
+
+// File is one migration in the Atlas migration directory
+type File struct {
+	// Version is the numeric prefix of the file name, e.g. 20261016140000
+	Version string
+	Path    string
+}
+
+// Files lists the migrations in dir in version order
+func Files(dir string) ([]File, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0, len(paths))
+	for _, path := range paths {
+		version, _, _ := strings.Cut(filepath.Base(path), "_")
+		files = append(files, File{Version: version, Path: path})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+	return files, nil
+}
+
+// Applied returns the versions Atlas recorded as applied on the database.
+// A database Atlas never migrated has none.
+func Applied(ctx context.Context, pool *pgxpool.Pool) (map[string]bool, error) {
+	rows, err := pool.Query(ctx, `SELECT version FROM atlas_schema_revisions.atlas_schema_revisions`)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == undefinedTable {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+	return applied, nil
+}
+
+// Pending returns the files whose version is not in applied
+func Pending(files []File, applied map[string]bool) []File {
+	var pending []File
+	for _, file := range files {
+		if !applied[file.Version] {
+			pending = append(pending, file)
+		}
+	}
+	return pending
+}
+
+// LintFiles lints every file and returns the findings in file order
+func LintFiles(files []File, policy Policy) ([]Finding, error) {
+	var findings []Finding
+	for _, file := range files {
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, Lint(file.Path, string(content), policy)...)
+	}
+	return findings, nil
+}
@@ -0,0 +1,145 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Table describes a dual-write migration from an old table to a new one
+type Table struct {
+	// Name identifies the migration in migration.dual_write and in its progress
+	Name string
+	// Old and New are the tables, optionally schema-qualified
+	Old string
+	New string
+	// Key is the primary key column, named the same in both tables. Rows are
+	// copied and compared in key order.
+	Key string
+	// KeyType is the SQL type of Key, e.g. uuid or bigint
+	KeyType string
+	// Columns lists the columns of the new table besides Key
+	Columns []Column
+}
+
+// Column is a column of the new table
+type Column struct {
+	Name string
+	// Expr computes the column from a row of the old table, e.g.
+	// price::numeric(12,2); it defaults to the column of the same name. It
+	// must yield the type of the column for checksums to match.
+	Expr string
+}
+
+// Validate reports a table missing one of its fields
+func (t Table) Validate() error {
+	switch {
+	case t.Name == "":
+		return errors.New("dual-write migration has no name")
+	case t.Old == "" || t.New == "":
+		return fmt.Errorf("dual-write migration %s needs an old and a new table", t.Name)
+	case t.Key == "" || t.KeyType == "":
+		return fmt.Errorf("dual-write migration %s needs a key column and its type", t.Name)
+	case len(t.Columns) == 0:
+		return fmt.Errorf("dual-write migration %s has no columns", t.Name)
+	}
+	return nil
+}
+
+// table quotes a possibly schema-qualified table name
+func table(name string) string {
+	return pgx.Identifier(strings.Split(name, ".")).Sanitize()
+}
+
+// column quotes a column name
+func column(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
+
+// newColumns returns the quoted columns of the new table, key first
+func (t Table) newColumns() []string {
+	columns := []string{column(t.Key)}
+	for _, c := range t.Columns {
+		columns = append(columns, column(c.Name))
+	}
+	return columns
+}
+
+// oldExprs returns the expressions computing the new columns from the old
+// table, key first
+func (t Table) oldExprs() []string {
+	exprs := []string{column(t.Key)}
+	for _, c := range t.Columns {
+		if c.Expr == "" {
+			exprs = append(exprs, column(c.Name))
+			continue
+		}
+		exprs = append(exprs, c.Expr)
+	}
+	return exprs
+}
+
+// keyRange is the predicate of keys after $1 and up to $2, either unbounded when NULL
+func (t Table) keyRange() string {
+	key := column(t.Key)
+	return fmt.Sprintf("($1::text IS NULL OR %[1]s > CAST($1::text AS %[2]s)) AND ($2::text IS NULL OR %[1]s <= CAST($2::text AS %[2]s))",
+		key, t.KeyType)
+}
+
+// backfillQuery copies the next $2 rows of the old table after the key $1,
+// returning the last key read, the rows read and the rows copied. Rows the
+// dual writes already put in the new table are newer and left alone.
+func (t Table) backfillQuery() string {
+	key := column(t.Key)
+	columns := t.newColumns()
+	selects := make([]string, len(columns))
+	for i, expr := range t.oldExprs() {
+		selects[i] = expr + " AS " + columns[i]
+	}
+
+	return fmt.Sprintf(`WITH batch AS (
+	SELECT %[1]s FROM %[2]s
+	WHERE $1::text IS NULL OR %[3]s > CAST($1::text AS %[4]s)
+	ORDER BY %[3]s
+	LIMIT $2
+	FOR SHARE
+), copied AS (
+	INSERT INTO %[5]s (%[6]s)
+	SELECT %[6]s FROM batch
+	ON CONFLICT (%[3]s) DO NOTHING
+	RETURNING 1
+)
+SELECT (SELECT %[3]s::text FROM batch ORDER BY %[3]s DESC LIMIT 1), (SELECT count(*) FROM batch), (SELECT count(*) FROM copied)`,
+		strings.Join(selects, ", "), table(t.Old), key, t.KeyType, table(t.New), strings.Join(columns, ", "))
+}
+
+// chunkEndQuery returns the key ending the chunk of $2 rows of the old table
+// after the key $1, or no row when fewer remain
+func (t Table) chunkEndQuery() string {
+	key := column(t.Key)
+	return fmt.Sprintf(`SELECT %[1]s::text FROM %[2]s
+WHERE $1::text IS NULL OR %[1]s > CAST($1::text AS %[3]s)
+ORDER BY %[1]s
+OFFSET $2 - 1
+LIMIT 1`, key, table(t.Old), t.KeyType)
+}
+
+// checksumQuery counts and hashes the rows of name in the key range, hashing
+// each row as computed by exprs in key order
+func (t Table) checksumQuery(name string, exprs []string) string {
+	return fmt.Sprintf(`SELECT count(*), coalesce(md5(string_agg(md5(ROW(%[1]s)::text), '' ORDER BY %[2]s)), '')
+FROM %[3]s
+WHERE %[4]s`, strings.Join(exprs, ", "), column(t.Key), table(name), t.keyRange())
+}
+
+// repairQueries delete the rows of the new table in the key range and copy
+// them again from the old table
+func (t Table) repairQueries() (string, string) {
+	remove := fmt.Sprintf(`DELETE FROM %s WHERE %s`, table(t.New), t.keyRange())
+	insert := fmt.Sprintf(`INSERT INTO %s (%s)
+SELECT %s FROM %s
+WHERE %s`, table(t.New), strings.Join(t.newColumns(), ", "), strings.Join(t.oldExprs(), ", "), table(t.Old), t.keyRange())
+	return remove, insert
+}
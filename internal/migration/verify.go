@@ -0,0 +1,161 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Chunk is a key range of a verification, after From and up to To, either
+// unbounded when invalid
+type Chunk struct {
+	From pgtype.Text
+	To   pgtype.Text
+}
+
+func (c Chunk) String() string {
+	from, to := "start", "end"
+	if c.From.Valid {
+		from = c.From.String
+	}
+	if c.To.Valid {
+		to = c.To.String
+	}
+	return fmt.Sprintf("(%s, %s]", from, to)
+}
+
+// VerifyResult sums up a verification run
+type VerifyResult struct {
+	Chunks int
+	// Rows counts the rows of the old table compared
+	Rows int64
+	// Mismatched lists the chunks whose checksums still differ
+	Mismatched []Chunk
+	// Repaired counts the chunks copied again that now match
+	Repaired int
+}
+
+// checksum is the row count and hash of a chunk of one table
+type checksum struct {
+	rows int64
+	hash string
+}
+
+// Verify compares the tables in chunks of chunkSize keys of the old table,
+// counting and hashing the rows of each in both tables from one snapshot.
+// With repair, the new table's rows of a mismatched chunk are copied again
+// from the old table, which is only safe while writes go to both. The
+// result is recorded as the latest verification of the migration.
+func Verify(ctx context.Context, pool *pgxpool.Pool, t Table, chunkSize int32, repair bool) (VerifyResult, error) {
+	var result VerifyResult
+	if chunkSize <= 0 {
+		return result, fmt.Errorf("chunk size must be positive")
+	}
+
+	querier := sqlc.New(pool)
+	progress, err := querier.GetDataMigration(ctx, t.Name)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !progress.BackfillFinishedAt.Valid) {
+		return result, fmt.Errorf("dual-write migration %s has not finished its backfill", t.Name)
+	}
+	if err != nil {
+		return result, fmt.Errorf("read progress: %w", err)
+	}
+
+	endQuery := t.chunkEndQuery()
+	var from pgtype.Text
+	for {
+		var to pgtype.Text
+		err := pool.QueryRow(ctx, endQuery, from, chunkSize).Scan(&to)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return result, fmt.Errorf("find chunk end: %w", err)
+		}
+		chunk := Chunk{From: from, To: to}
+
+		old, matched, err := t.compareChunk(ctx, pool, chunk)
+		if err != nil {
+			return result, err
+		}
+		if !matched && repair {
+			if err := t.repairChunk(ctx, pool, chunk); err != nil {
+				return result, err
+			}
+			if old, matched, err = t.compareChunk(ctx, pool, chunk); err != nil {
+				return result, err
+			}
+			if matched {
+				result.Repaired++
+			}
+		}
+
+		result.Chunks++
+		result.Rows += old.rows
+		if !matched {
+			result.Mismatched = append(result.Mismatched, chunk)
+		}
+		// The chunk without an end covers every remaining key
+		if !to.Valid {
+			break
+		}
+		from = to
+	}
+
+	err = querier.RecordDataMigrationVerification(ctx, sqlc.RecordDataMigrationVerificationParams{
+		Name:             t.Name,
+		RowsVerified:     result.Rows,
+		MismatchedChunks: int32(len(result.Mismatched)),
+	})
+	if err != nil {
+		return result, fmt.Errorf("record verification: %w", err)
+	}
+	return result, nil
+}
+
+// compareChunk returns the checksum of the chunk in the old table and whether
+// the new table's matches it
+func (t Table) compareChunk(ctx context.Context, pool *pgxpool.Pool, chunk Chunk) (checksum, bool, error) {
+	var old, updated checksum
+	err := pgx.BeginTxFunc(ctx, pool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, t.checksumQuery(t.Old, t.oldExprs()), chunk.From, chunk.To).Scan(&old.rows, &old.hash); err != nil {
+			return fmt.Errorf("checksum old table: %w", err)
+		}
+		if err := tx.QueryRow(ctx, t.checksumQuery(t.New, t.newColumns()), chunk.From, chunk.To).Scan(&updated.rows, &updated.hash); err != nil {
+			return fmt.Errorf("checksum new table: %w", err)
+		}
+		return nil
+	})
+	return old, old == updated, err
+}
+
+// repairChunk copies the rows of the chunk from the old table over the new one
+func (t Table) repairChunk(ctx context.Context, pool *pgxpool.Pool, chunk Chunk) error {
+	remove, insert := t.repairQueries()
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, remove, chunk.From, chunk.To); err != nil {
+			return fmt.Errorf("clear chunk %s: %w", chunk, err)
+		}
+		if _, err := tx.Exec(ctx, insert, chunk.From, chunk.To); err != nil {
+			return fmt.Errorf("copy chunk %s: %w", chunk, err)
+		}
+		return nil
+	})
+}
+
+// CheckCutover returns an error unless the recorded progress allows reads to
+// move to the new table: the backfill finished and the latest verification,
+// run after it, found no mismatched chunks
+func CheckCutover(progress sqlc.DataMigration) error {
+	switch {
+	case !progress.BackfillFinishedAt.Valid:
+		return fmt.Errorf("dual-write migration %s has not finished its backfill", progress.Name)
+	case !progress.VerifiedAt.Valid || progress.VerifiedAt.Time.Before(progress.BackfillFinishedAt.Time):
+		return fmt.Errorf("dual-write migration %s has not been verified since its backfill", progress.Name)
+	case progress.MismatchedChunks > 0:
+		return fmt.Errorf("dual-write migration %s has %d mismatched chunk(s)", progress.Name, progress.MismatchedChunks)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package outbound makes the calls consumers make to external APIs, such as
+// payment or CRM providers, take effect once per event despite redelivery.
+//
+// Every call is given an idempotency key derived from the event, the handler
+// and the call, to be sent to the provider, e.g. in an Idempotency-Key
+// header. The result of a successful call is recorded under its key, and
+// redeliveries of the event return the recorded result without calling
+// again. A call that fails, or succeeds without its result being recorded,
+// is made again on redelivery with the same key, for the provider to answer
+// without repeating its effect.
+//
+// Handlers get this through the subscriber middleware of a Recorder, which
+// scopes every message it handles, and call through Call:
+//
+//	receipt, err := outbound.Call(ctx, "create_invoice", func(ctx context.Context, key string) ([]byte, error) {
+//		return billing.CreateInvoice(ctx, key, invoice)
+//	})
+package outbound
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expvar"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoMessage is returned by Call outside the handling of a message scoped
+// by a Recorder
+var ErrNoMessage = errors.New("outbound: no message is being handled, see Recorder.Middleware")
+
+// maxCallNameLength bounds call names, stored alongside the results
+const maxCallNameLength = 100
+
+// replayed counts calls answered from a recorded result, keyed by handler
+var replayed = expvar.NewMap("outbound_calls_replayed_total")
+
+// Func makes a call of an external API, sending key as its idempotency key,
+// and returns the result to record, e.g. the response body
+type Func func(ctx context.Context, key string) ([]byte, error)
+
+// Recorder records the results of the calls handlers make
+type Recorder struct {
+	db sqlc.Querier
+}
+
+// New creates a recorder storing results in db
+func New(db sqlc.Querier) *Recorder {
+	return &Recorder{db: db}
+}
+
+// scope identifies the message being handled
+type scope struct {
+	recorder *Recorder
+	eventID  string
+	handler  string
+}
+
+type scopeKey struct{}
+
+// Middleware is a subscriber middleware scoping the calls made while
+// handling a message to it. Events are published under their event ID, which
+// identifies their messages; commands are identified by the message ID.
+func (r *Recorder) Middleware(h message.HandlerFunc) message.HandlerFunc {
+	return func(msg *message.Message) ([]*message.Message, error) {
+		ctx := msg.Context()
+		msg.SetContext(context.WithValue(ctx, scopeKey{}, scope{
+			recorder: r,
+			eventID:  msg.UUID,
+			handler:  message.HandlerNameFromCtx(ctx),
+		}))
+		return h(msg)
+	}
+}
+
+// Key derives the idempotency key of the call named call that handler makes
+// for eventID. It is the same on every redelivery and differs between
+// handlers and between the calls of one handler.
+func Key(eventID, handler, call string) string {
+	sum := sha256.Sum256([]byte(eventID + "\x00" + handler + "\x00" + call))
+	return hex.EncodeToString(sum[:])
+}
+
+// Call makes the call named call of the message being handled through fn,
+// unless it already succeeded for the message, and returns its result. Names
+// tell apart the calls of one handler, e.g. create_invoice and send_invoice.
+func Call(ctx context.Context, call string, fn Func) ([]byte, error) {
+	s, ok := ctx.Value(scopeKey{}).(scope)
+	if !ok {
+		return nil, ErrNoMessage
+	}
+	if call == "" || len(call) > maxCallNameLength {
+		return nil, fmt.Errorf("outbound: invalid call name %q", call)
+	}
+	return s.recorder.call(ctx, s, call, fn)
+}
+
+func (r *Recorder) call(ctx context.Context, s scope, call string, fn Func) ([]byte, error) {
+	key := Key(s.eventID, s.handler, call)
+
+	result, err := r.db.GetOutboundCallResult(ctx, key)
+	if err == nil {
+		replayed.Add(s.handler, 1)
+		return result, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("outbound: looking up call %s of event %s: %w", call, s.eventID, err)
+	}
+
+	result, err = fn(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		result = []byte{}
+	}
+
+	// Failing here redelivers the event, repeating the call with the same key
+	err = r.db.InsertOutboundCall(ctx, sqlc.InsertOutboundCallParams{
+		IdempotencyKey: key,
+		EventID:        s.eventID,
+		Handler:        s.handler,
+		Call:           call,
+		Result:         result,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("outbound: recording call %s of event %s: %w", call, s.eventID, err)
+	}
+	return result, nil
+}
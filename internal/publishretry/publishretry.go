@@ -0,0 +1,123 @@
+package publishretry
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/erry-az/go-init/internal/repository"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+)
+
+// republished counts stored events published again, keyed by topic
+var republished = expvar.NewMap("events_publish_retried_total")
+
+// DB is the database a Store is kept in, e.g. a *pgxpool.Pool
+type DB interface {
+	repository.TxBeginner
+	sqlc.DBTX
+}
+
+// Store keeps the events the broker did not accept in the database until
+// Redeliver publishes them. Events are stored marshaled, with the metadata
+// stamped at the first attempt, so consumers see them as first published.
+type Store struct {
+	db DB
+}
+
+// New creates a store kept in db
+func New(db DB) *Store {
+	return &Store{db: db}
+}
+
+// Defer stores msg to publish it to topic later
+func (s *Store) Defer(ctx context.Context, topic string, msg *message.Message, cause error) error {
+	metadata, err := json.Marshal(msg.Metadata)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("publishretry: beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = sqlc.New(tx).InsertPublishRetry(ctx, sqlc.InsertPublishRetryParams{
+		Topic:       topic,
+		MessageUuid: msg.UUID,
+		Metadata:    metadata,
+		Payload:     msg.Payload,
+		LastError:   cause.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("publishretry: storing message %s: %w", msg.UUID, err)
+	}
+	return tx.Commit(ctx)
+}
+
+// Redeliver publishes up to batchSize stored events in the order they were
+// stored, removing each once published. It stops at the first failure, as
+// the broker is most likely still down, and returns how many were published.
+// Concurrent runs, e.g. of other instances, skip the events being published.
+// An event published just before a failed commit is published again.
+func (s *Store) Redeliver(ctx context.Context, publisher message.Publisher, batchSize int32) (int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("publishretry: beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := sqlc.New(tx)
+	retries, err := q.ListPublishRetries(ctx, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("publishretry: listing stored messages: %w", err)
+	}
+
+	published := 0
+	for _, retry := range retries {
+		msg := message.NewMessage(retry.MessageUuid, retry.Payload)
+		if err := json.Unmarshal(retry.Metadata, &msg.Metadata); err != nil {
+			return published, fmt.Errorf("publishretry: decoding metadata of message %s: %w", retry.MessageUuid, err)
+		}
+		msg.SetContext(ctx)
+
+		if publishErr := publisher.Publish(retry.Topic, msg); publishErr != nil {
+			err := q.RecordPublishRetryFailure(ctx, sqlc.RecordPublishRetryFailureParams{
+				ID:        retry.ID,
+				LastError: publishErr.Error(),
+			})
+			if err != nil {
+				return published, fmt.Errorf("publishretry: recording failure of message %s: %w", retry.MessageUuid, err)
+			}
+			if err := tx.Commit(ctx); err != nil {
+				return published, fmt.Errorf("publishretry: committing: %w", err)
+			}
+			return published, fmt.Errorf("publishretry: publishing message %s: %w", retry.MessageUuid, publishErr)
+		}
+
+		if err := q.DeletePublishRetry(ctx, retry.ID); err != nil {
+			return published, fmt.Errorf("publishretry: removing message %s: %w", retry.MessageUuid, err)
+		}
+		republished.Add(retry.Topic, 1)
+		published++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return published, fmt.Errorf("publishretry: committing: %w", err)
+	}
+	return published, nil
+}
+
+// Lag returns how long the oldest stored event has been waiting to be
+// published, zero when none is stored
+func (s *Store) Lag(ctx context.Context) (time.Duration, error) {
+	seconds, err := sqlc.New(s.db).GetPublishRetryLag(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("publishretry: getting lag: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
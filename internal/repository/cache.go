@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// cacheSweepThreshold is the entry count above which expired entries are swept on write
+const cacheSweepThreshold = 10000
+
+// cacheStats counts cache outcomes per entity, e.g. product_hits.
+// It is exported on /debug/vars as repository_cache.
+var cacheStats = expvar.NewMap("repository_cache")
+
+// cacheKey identifies a cached entity within the scope it was read in
+type cacheKey struct {
+	// scope is the tenant and sandbox mode of the request
+	scope string
+	id    uuid.UUID
+}
+
+func (k cacheKey) String() string {
+	return k.scope + "/" + k.id.String()
+}
+
+type cacheEntry[V any] struct {
+	value     V
+	version   int64
+	expiresAt time.Time
+}
+
+// versionFloor is the oldest version of an entity that may be served until
+// expiresAt, raised as its changes are observed
+type versionFloor struct {
+	version   int64
+	expiresAt time.Time
+}
+
+// readCache collapses concurrent loads of the same key into one and keeps
+// the result for a short TTL. A zero TTL only collapses concurrent loads.
+//
+// Values are stamped with the version of the row they were read from. A
+// value never replaces a newer one, whatever order loads and writes finish
+// in, and once a newer version is observed, e.g. from an event published by
+// another instance, older values are neither served nor stored.
+type readCache[V any] struct {
+	entity  string
+	ttl     time.Duration
+	version func(V) int64
+	group   singleflight.Group
+
+	mu         sync.Mutex
+	entries    map[cacheKey]cacheEntry[V]
+	floors     map[uuid.UUID]versionFloor
+	generation uint64
+}
+
+func newReadCache[V any](entity string, ttl time.Duration, version func(V) int64) *readCache[V] {
+	return &readCache[V]{
+		entity:  entity,
+		ttl:     ttl,
+		version: version,
+		entries: make(map[cacheKey]cacheEntry[V]),
+		floors:  make(map[uuid.UUID]versionFloor),
+	}
+}
+
+// get returns the cached value for key or loads it, sharing the load with
+// concurrent callers. The load runs with the first caller's context, so its
+// cancellation fails the shared load; errors are never cached.
+func (c *readCache[V]) get(ctx context.Context, key cacheKey, load func(context.Context) (V, error)) (V, error) {
+	if value, ok := c.lookup(key); ok {
+		cacheStats.Add(c.entity+"_hits", 1)
+		return value, nil
+	}
+
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	ch := c.group.DoChan(key.String(), func() (any, error) {
+		cacheStats.Add(c.entity+"_misses", 1)
+		value, err := load(ctx)
+		if err == nil {
+			c.store(key, value, generation)
+		}
+		return value, err
+	})
+
+	select {
+	case res := <-ch:
+		if res.Shared {
+			cacheStats.Add(c.entity+"_shared", 1)
+		}
+		if res.Err != nil {
+			var zero V
+			return zero, res.Err
+		}
+		return res.Val.(V), nil
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// put stores value, just written, unless a newer version is cached
+func (c *readCache[V]) put(key cacheKey, value V) {
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	c.store(key, value, generation)
+}
+
+// invalidate drops key and makes loads already in flight skip storing their result
+func (c *readCache[V]) invalidate(key cacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	c.generation++
+	c.group.Forget(key.String())
+}
+
+// observe records that version of the entity id exists, e.g. on event
+// delivery, dropping older values of it in every scope
+func (c *readCache[V]) observe(id uuid.UUID, version int64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if floor, ok := c.floors[id]; ok && floor.version >= version && now.Before(floor.expiresAt) {
+		return
+	}
+	if len(c.floors) >= cacheSweepThreshold {
+		for floorID, floor := range c.floors {
+			if now.After(floor.expiresAt) {
+				delete(c.floors, floorID)
+			}
+		}
+	}
+	// Values stored before now expire within the TTL, so the floor can too
+	c.floors[id] = versionFloor{version: version, expiresAt: now.Add(c.ttl)}
+	cacheStats.Add(c.entity+"_observed", 1)
+}
+
+// forget drops every value of the entity id, e.g. once it was deleted
+func (c *readCache[V]) forget(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if key.id == id {
+			delete(c.entries, key)
+			c.group.Forget(key.String())
+		}
+	}
+	c.generation++
+}
+
+// purge drops every value and observed version, e.g. from a developer command
+func (c *readCache[V]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		c.group.Forget(key.String())
+	}
+	clear(c.entries)
+	clear(c.floors)
+	c.generation++
+}
+
+func (c *readCache[V]) lookup(key cacheKey) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	if entry.version < c.floor(key.id, now) {
+		cacheStats.Add(c.entity+"_stale", 1)
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// floor returns the oldest version of the entity id that may be served.
+// It must be called with mu held.
+func (c *readCache[V]) floor(id uuid.UUID, now time.Time) int64 {
+	floor, ok := c.floors[id]
+	if !ok || now.After(floor.expiresAt) {
+		return 0
+	}
+	return floor.version
+}
+
+func (c *readCache[V]) store(key cacheKey, value V, generation uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// An entity was deleted while loading, the value may be gone already
+	if generation != c.generation {
+		return
+	}
+
+	now := time.Now()
+	version := c.version(value)
+	// Compare on write: an older value never replaces a newer one
+	if version < c.floor(key.id, now) {
+		cacheStats.Add(c.entity+"_stale", 1)
+		return
+	}
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expiresAt) && entry.version > version {
+		return
+	}
+
+	if len(c.entries) >= cacheSweepThreshold {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = cacheEntry[V]{value: value, version: version, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/residency"
+	"github.com/erry-az/go-init/internal/sandbox"
+	"github.com/google/uuid"
+)
+
+// Cached entity names, used as TTL configuration keys and metric prefixes
+const (
+	CacheEntityProduct = "product"
+	CacheEntityUser    = "user"
+)
+
+// CachedQuerier wraps a sqlc.Querier so concurrent identical reads by ID share
+// a single query and results are reused for a short per-entity TTL.
+// Writes through the querier store the written row, and changes made through
+// other instances are observed from their events, so a read never returns an
+// older version than the last one written or observed.
+type CachedQuerier struct {
+	sqlc.Querier
+	products *readCache[sqlc.Product]
+	users    *readCache[sqlc.User]
+	ready    func() bool
+}
+
+// NewCachedQuerier creates a caching querier; ttls maps entity names to their TTL
+func NewCachedQuerier(querier sqlc.Querier, ttls map[string]time.Duration) *CachedQuerier {
+	return &CachedQuerier{
+		Querier:  querier,
+		products: newReadCache(CacheEntityProduct, ttls[CacheEntityProduct], func(p sqlc.Product) int64 { return p.Version }),
+		users:    newReadCache(CacheEntityUser, ttls[CacheEntityUser], func(u sqlc.User) int64 { return u.Version }),
+		ready:    func() bool { return true },
+	}
+}
+
+// SetReady makes reads and writes bypass the cache while ready reports false,
+// e.g. until the changes made through other instances can be observed. It
+// must be called before the querier is used.
+func (q *CachedQuerier) SetReady(ready func() bool) {
+	q.ready = ready
+}
+
+func (q *CachedQuerier) GetProductByID(ctx context.Context, id uuid.UUID) (sqlc.Product, error) {
+	if !q.ready() {
+		return q.Querier.GetProductByID(ctx, id)
+	}
+	return q.products.get(ctx, scopedKey(ctx, id), func(ctx context.Context) (sqlc.Product, error) {
+		return q.Querier.GetProductByID(ctx, id)
+	})
+}
+
+func (q *CachedQuerier) UpdateProduct(ctx context.Context, arg sqlc.UpdateProductParams) (sqlc.Product, error) {
+	product, err := q.Querier.UpdateProduct(ctx, arg)
+	if err != nil {
+		q.products.invalidate(scopedKey(ctx, arg.ID))
+		return product, err
+	}
+	if q.ready() {
+		q.products.put(scopedKey(ctx, arg.ID), product)
+	}
+	return product, nil
+}
+
+func (q *CachedQuerier) UpdateProductStatus(ctx context.Context, arg sqlc.UpdateProductStatusParams) (sqlc.Product, error) {
+	product, err := q.Querier.UpdateProductStatus(ctx, arg)
+	if err != nil {
+		q.products.invalidate(scopedKey(ctx, arg.ID))
+		return product, err
+	}
+	if q.ready() {
+		q.products.put(scopedKey(ctx, arg.ID), product)
+	}
+	return product, nil
+}
+
+func (q *CachedQuerier) DeleteProduct(ctx context.Context, id uuid.UUID) error {
+	defer q.products.invalidate(scopedKey(ctx, id))
+	return q.Querier.DeleteProduct(ctx, id)
+}
+
+func (q *CachedQuerier) GetUserByID(ctx context.Context, id uuid.UUID) (sqlc.User, error) {
+	if !q.ready() {
+		return q.Querier.GetUserByID(ctx, id)
+	}
+	return q.users.get(ctx, scopedKey(ctx, id), func(ctx context.Context) (sqlc.User, error) {
+		return q.Querier.GetUserByID(ctx, id)
+	})
+}
+
+func (q *CachedQuerier) UpdateUser(ctx context.Context, arg sqlc.UpdateUserParams) (sqlc.User, error) {
+	user, err := q.Querier.UpdateUser(ctx, arg)
+	if err != nil {
+		q.users.invalidate(scopedKey(ctx, arg.ID))
+		return user, err
+	}
+	if q.ready() {
+		q.users.put(scopedKey(ctx, arg.ID), user)
+	}
+	return user, nil
+}
+
+func (q *CachedQuerier) UpdateUserRole(ctx context.Context, arg sqlc.UpdateUserRoleParams) (sqlc.User, error) {
+	user, err := q.Querier.UpdateUserRole(ctx, arg)
+	if err != nil {
+		q.users.invalidate(scopedKey(ctx, arg.ID))
+		return user, err
+	}
+	if q.ready() {
+		q.users.put(scopedKey(ctx, arg.ID), user)
+	}
+	return user, nil
+}
+
+func (q *CachedQuerier) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	defer q.users.invalidate(scopedKey(ctx, id))
+	return q.Querier.DeleteUser(ctx, id)
+}
+
+// ProductChanged records that version of product id was written, e.g. on
+// delivery of its event, so older cached versions are no longer served
+func (q *CachedQuerier) ProductChanged(id uuid.UUID, version int64) {
+	q.products.observe(id, version)
+}
+
+// ProductDeleted drops the cached versions of product id
+func (q *CachedQuerier) ProductDeleted(id uuid.UUID) {
+	q.products.forget(id)
+}
+
+// UserChanged is ProductChanged for users
+func (q *CachedQuerier) UserChanged(id uuid.UUID, version int64) {
+	q.users.observe(id, version)
+}
+
+// UserDeleted is ProductDeleted for users
+func (q *CachedQuerier) UserDeleted(id uuid.UUID) {
+	q.users.forget(id)
+}
+
+// Purge empties the caches of every entity, so the next reads query the database
+func (q *CachedQuerier) Purge() {
+	q.products.purge()
+	q.users.purge()
+}
+
+// scopedKey scopes id to the request tenant and to sandbox mode so neither
+// residency nor sandbox routing is ever bypassed
+func scopedKey(ctx context.Context, id uuid.UUID) cacheKey {
+	tenantID, _ := residency.TenantFromContext(ctx)
+	if sandbox.FromContext(ctx) {
+		return cacheKey{scope: "sandbox:" + tenantID, id: id}
+	}
+	return cacheKey{scope: tenantID, id: id}
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/envelope"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// EncryptedQuerier wraps a sqlc.Querier and transparently encrypts PII
+// columns on write and decrypts them on read. Usecases keep working with plaintext.
+type EncryptedQuerier struct {
+	sqlc.Querier
+	encryptor     *envelope.Encryptor
+	blindIndexKey []byte
+}
+
+// NewEncryptedQuerier creates a querier encrypting user emails with encryptor.
+// blindIndexKey derives the email hash used to enforce uniqueness on ciphertext.
+func NewEncryptedQuerier(querier sqlc.Querier, encryptor *envelope.Encryptor, blindIndexKey []byte) *EncryptedQuerier {
+	return &EncryptedQuerier{
+		Querier:       querier,
+		encryptor:     encryptor,
+		blindIndexKey: blindIndexKey,
+	}
+}
+
+func (q *EncryptedQuerier) CreateUser(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.User, error) {
+	email, hash, err := q.encryptEmail(ctx, arg.Email)
+	if err != nil {
+		return sqlc.User{}, err
+	}
+	arg.Email, arg.EmailHash = email, hash
+
+	user, err := q.Querier.CreateUser(ctx, arg)
+	if err != nil {
+		return user, err
+	}
+	return q.decryptUser(ctx, user)
+}
+
+func (q *EncryptedQuerier) UpdateUser(ctx context.Context, arg sqlc.UpdateUserParams) (sqlc.User, error) {
+	email, hash, err := q.encryptEmail(ctx, arg.Email)
+	if err != nil {
+		return sqlc.User{}, err
+	}
+	arg.Email, arg.EmailHash = email, hash
+
+	user, err := q.Querier.UpdateUser(ctx, arg)
+	if err != nil {
+		return user, err
+	}
+	return q.decryptUser(ctx, user)
+}
+
+func (q *EncryptedQuerier) UpdateUserRole(ctx context.Context, arg sqlc.UpdateUserRoleParams) (sqlc.User, error) {
+	user, err := q.Querier.UpdateUserRole(ctx, arg)
+	if err != nil {
+		return user, err
+	}
+	return q.decryptUser(ctx, user)
+}
+
+func (q *EncryptedQuerier) GetUserByID(ctx context.Context, id uuid.UUID) (sqlc.User, error) {
+	user, err := q.Querier.GetUserByID(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	return q.decryptUser(ctx, user)
+}
+
+// GetUserByEmail looks the user up by blind index since stored emails are ciphertext
+func (q *EncryptedQuerier) GetUserByEmail(ctx context.Context, arg sqlc.GetUserByEmailParams) (sqlc.User, error) {
+	arg.EmailHash = pgtype.Text{String: q.blindIndex(arg.Email), Valid: true}
+
+	user, err := q.Querier.GetUserByEmail(ctx, arg)
+	if err != nil {
+		return user, err
+	}
+	return q.decryptUser(ctx, user)
+}
+
+func (q *EncryptedQuerier) CreateEmailChangeRequest(ctx context.Context, arg sqlc.CreateEmailChangeRequestParams) (sqlc.EmailChangeRequest, error) {
+	email, err := q.encryptor.EncryptString(ctx, arg.NewEmail)
+	if err != nil {
+		return sqlc.EmailChangeRequest{}, fmt.Errorf("encrypt email: %w", err)
+	}
+	arg.NewEmail = email
+
+	request, err := q.Querier.CreateEmailChangeRequest(ctx, arg)
+	if err != nil {
+		return request, err
+	}
+	return q.decryptEmailChangeRequest(ctx, request)
+}
+
+func (q *EncryptedQuerier) GetEmailChangeRequest(ctx context.Context, tokenHash string) (sqlc.EmailChangeRequest, error) {
+	request, err := q.Querier.GetEmailChangeRequest(ctx, tokenHash)
+	if err != nil {
+		return request, err
+	}
+	return q.decryptEmailChangeRequest(ctx, request)
+}
+
+func (q *EncryptedQuerier) ListUsers(ctx context.Context, arg sqlc.ListUsersParams) ([]sqlc.User, error) {
+	users, err := q.Querier.ListUsers(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return q.decryptUsers(ctx, users)
+}
+
+// SearchUsers matches names as given but emails only whole, by blind index,
+// as ILIKE cannot look into ciphertext
+func (q *EncryptedQuerier) SearchUsers(ctx context.Context, arg sqlc.SearchUsersParams) ([]sqlc.User, error) {
+	arg.EmailQuery, arg.EmailHash = q.searchEmail(arg.EmailQuery)
+
+	users, err := q.Querier.SearchUsers(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return q.decryptUsers(ctx, users)
+}
+
+// CountUsersBySearch counts the users SearchUsers matches
+func (q *EncryptedQuerier) CountUsersBySearch(ctx context.Context, arg sqlc.CountUsersBySearchParams) (int64, error) {
+	arg.EmailQuery, arg.EmailHash = q.searchEmail(arg.EmailQuery)
+	return q.Querier.CountUsersBySearch(ctx, arg)
+}
+
+// searchEmail turns the ILIKE pattern of a search, the term wrapped in
+// wildcards, into the blind index of the term, leaving no pattern to match
+// ciphertext against
+func (q *EncryptedQuerier) searchEmail(pattern pgtype.Text) (pgtype.Text, pgtype.Text) {
+	if !pattern.Valid {
+		return pgtype.Text{}, pgtype.Text{}
+	}
+	term := strings.TrimSuffix(strings.TrimPrefix(pattern.String, "%"), "%")
+	return pgtype.Text{}, pgtype.Text{String: q.blindIndex(term), Valid: true}
+}
+
+func (q *EncryptedQuerier) ListUsersForEmailBackfill(ctx context.Context, arg sqlc.ListUsersForEmailBackfillParams) ([]sqlc.User, error) {
+	users, err := q.Querier.ListUsersForEmailBackfill(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return q.decryptUsers(ctx, users)
+}
+
+// ReencryptUsers re-encrypts up to batchSize emails that are plaintext or
+// wrapped by a retired key, returning how many rows were rewritten
+func (q *EncryptedQuerier) ReencryptUsers(ctx context.Context, batchSize int32) (int, error) {
+	rows, err := q.Querier.ListUserEmailsForRotation(ctx, sqlc.ListUserEmailsForRotationParams{
+		ActivePrefix: escapeLike(q.encryptor.ActivePrefix()) + "%",
+		BatchSize:    batchSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("list users for rotation: %w", err)
+	}
+
+	for _, row := range rows {
+		plaintext, err := q.encryptor.DecryptString(ctx, row.Email)
+		if err != nil {
+			return 0, fmt.Errorf("decrypt email of user %s: %w", row.ID, err)
+		}
+
+		email, hash, err := q.encryptEmail(ctx, plaintext)
+		if err != nil {
+			return 0, err
+		}
+
+		err = q.Querier.UpdateUserEmailCiphertext(ctx, sqlc.UpdateUserEmailCiphertextParams{
+			Email:     email,
+			EmailHash: hash,
+			ID:        row.ID,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("update email of user %s: %w", row.ID, err)
+		}
+	}
+
+	return len(rows), nil
+}
+
+func (q *EncryptedQuerier) encryptEmail(ctx context.Context, email string) (string, pgtype.Text, error) {
+	ciphertext, err := q.encryptor.EncryptString(ctx, email)
+	if err != nil {
+		return "", pgtype.Text{}, fmt.Errorf("encrypt email: %w", err)
+	}
+	return ciphertext, pgtype.Text{String: q.blindIndex(email), Valid: true}, nil
+}
+
+// blindIndex returns a keyed hash of the normalized email for equality lookups
+func (q *EncryptedQuerier) blindIndex(email string) string {
+	mac := hmac.New(sha256.New, q.blindIndexKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (q *EncryptedQuerier) decryptUser(ctx context.Context, user sqlc.User) (sqlc.User, error) {
+	email, err := q.encryptor.DecryptString(ctx, user.Email)
+	if err != nil {
+		return user, fmt.Errorf("decrypt email of user %s: %w", user.ID, err)
+	}
+	user.Email = email
+	return user, nil
+}
+
+func (q *EncryptedQuerier) decryptEmailChangeRequest(ctx context.Context, request sqlc.EmailChangeRequest) (sqlc.EmailChangeRequest, error) {
+	email, err := q.encryptor.DecryptString(ctx, request.NewEmail)
+	if err != nil {
+		return request, fmt.Errorf("decrypt pending email of user %s: %w", request.UserID, err)
+	}
+	request.NewEmail = email
+	return request, nil
+}
+
+func (q *EncryptedQuerier) decryptUsers(ctx context.Context, users []sqlc.User) ([]sqlc.User, error) {
+	for i := range users {
+		user, err := q.decryptUser(ctx, users[i])
+		if err != nil {
+			return nil, err
+		}
+		users[i] = user
+	}
+	return users, nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, with the default
+// backslash escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike returns a LIKE pattern matching s literally, e.g. a key ID
+// containing an underscore
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
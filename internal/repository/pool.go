@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultCancelGracePeriod is how long a cancelled statement may keep running
+// before the connection is torn down instead of waiting for the server
+const defaultCancelGracePeriod = 2 * time.Second
+
+// Cancellation reasons reported by the cancelled statements metric
+const (
+	CancelReasonClientDisconnect = "client_disconnect"
+	CancelReasonDeadline         = "deadline_exceeded"
+)
+
+// cancelledStatements counts in-flight statements cancelled because the
+// caller's context ended, keyed by cancellation reason.
+// It is exported on /debug/vars as db_statements_cancelled_total.
+var cancelledStatements = expvar.NewMap("db_statements_cancelled_total")
+
+// PoolOptions configures the pools created by NewPool
+type PoolOptions struct {
+	// CancelGracePeriod bounds how long a cancelled statement may keep
+	// running; a non-positive period uses the default
+	CancelGracePeriod time.Duration
+	// QueryPlans logs the plans of slow list queries when enabled
+	QueryPlans QueryPlanOptions
+	// SearchPath overrides the schemas unqualified names resolve to, e.g.
+	// the sandbox schema; empty keeps the server's
+	SearchPath string
+}
+
+// NewPool creates a pgx pool whose statements are cancelled server-side when
+// the calling context ends, so an abandoned RPC frees its backend right away
+// instead of running the query to completion.
+func NewPool(ctx context.Context, dsn string, options PoolOptions) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	gracePeriod := options.CancelGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = defaultCancelGracePeriod
+	}
+
+	cfg.ConnConfig.BuildContextWatcherHandler = func(conn *pgconn.PgConn) ctxwatch.Handler {
+		return &cancelRequestHandler{
+			CancelRequestContextWatcherHandler: &pgconn.CancelRequestContextWatcherHandler{
+				Conn:          conn,
+				DeadlineDelay: gracePeriod,
+			},
+		}
+	}
+
+	if options.SearchPath != "" {
+		cfg.ConnConfig.RuntimeParams["search_path"] = options.SearchPath
+	}
+
+	var tracer *planTracer
+	if options.QueryPlans.Enabled {
+		tracer = &planTracer{options: options.QueryPlans}
+		cfg.ConnConfig.Tracer = tracer
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tracer != nil {
+		tracer.pool = pool
+	}
+	return pool, nil
+}
+
+// cancelRequestHandler sends a Postgres cancel request as soon as the context
+// is done and records why the statement was cancelled.
+// The connection deadline set after the grace period covers an unresponsive server.
+type cancelRequestHandler struct {
+	*pgconn.CancelRequestContextWatcherHandler
+}
+
+func (h *cancelRequestHandler) HandleCancel(ctx context.Context) {
+	cancelledStatements.Add(cancelReason(ctx), 1)
+	h.CancelRequestContextWatcherHandler.HandleCancel(ctx)
+}
+
+// cancelReason tells a client that went away apart from an expired deadline.
+// gRPC cancels the handler context when the client disconnects or cancels the call.
+func cancelReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return CancelReasonDeadline
+	}
+	return CancelReasonClientDisconnect
+}
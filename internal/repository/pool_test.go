@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"expvar"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/testutil/builders"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPool connects to the migrated database of TEST_DATABASE_DSN, skipping
+// the test without one
+func testPool(t *testing.T, options PoolOptions) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	pool, err := NewPool(context.Background(), dsn, options)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// lockUser persists a user and holds its row lock until the test ends, so
+// statements writing the user block
+func lockUser(t *testing.T, pool *pgxpool.Pool) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+
+	user, err := builders.User().Persist(ctx, sqlc.New(pool))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlc.New(pool).DeleteUser(context.Background(), user.ID) })
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tx.Rollback(context.Background()) })
+	if _, err := tx.Exec(ctx, "SELECT 1 FROM users WHERE id = $1 FOR UPDATE", user.ID); err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+// lockWaiters counts the backends of the database waiting for a lock
+func lockWaiters(t *testing.T, pool *pgxpool.Pool) int64 {
+	t.Helper()
+
+	var n int64
+	err := pool.QueryRow(context.Background(),
+		"SELECT count(*) FROM pg_stat_activity WHERE datname = current_database() AND wait_event_type = 'Lock'").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func cancelledCount(reason string) int64 {
+	if v, ok := cancelledStatements.Get(reason).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestNewPool_CancelledStatementFreesBackend(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    func() (context.Context, context.CancelFunc)
+		reason string
+	}{
+		{
+			name: "client disconnect",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(100*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			reason: CancelReasonClientDisconnect,
+		},
+		{
+			name: "deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 100*time.Millisecond)
+			},
+			reason: CancelReasonDeadline,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A grace period longer than the test shows the cancel request,
+			// not the connection deadline, frees the backend
+			pool := testPool(t, PoolOptions{CancelGracePeriod: time.Minute})
+			userID := lockUser(t, pool)
+			before := cancelledCount(tt.reason)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+			_, err := pool.Exec(ctx, "UPDATE users SET name = name WHERE id = $1", userID)
+			if err == nil {
+				t.Fatal("expected the blocked statement to fail once cancelled")
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Fatalf("cancelled statement returned after %s", elapsed)
+			}
+
+			if got := cancelledCount(tt.reason); got != before+1 {
+				t.Errorf("db_statements_cancelled_total[%s] = %d, want %d", tt.reason, got, before+1)
+			}
+
+			// The backend stops waiting for the lock instead of staying busy
+			// until the lock is released
+			deadline := time.Now().Add(2 * time.Second)
+			for lockWaiters(t, pool) > 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("backend still waits for the lock after the statement was cancelled")
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/filter"
+	"github.com/erry-az/go-init/pkg/pagetoken"
+	"github.com/jackc/pgx/v5"
+)
+
+// productColumns are the products columns in sqlc.Product field order
+const productColumns = "id, name, price, created_at, updated_at, category, attributes, metadata, version, description, translations, status"
+
+// ProductFilter lists products matching a filter expression. The WHERE clause
+// is built at runtime, which sqlc cannot express, so the queries live here.
+type ProductFilter struct {
+	db sqlc.DBTX
+}
+
+// NewProductFilter creates a product filter querying db, e.g. a *RegionRouter
+func NewProductFilter(db sqlc.DBTX) *ProductFilter {
+	return &ProductFilter{db: db}
+}
+
+// createdAtOrder is the default product order, in which pages start after
+// the (created_at, id) of a page token
+var createdAtOrder = filter.Order{Name: "created_at", Field: filter.Field{Column: "created_at", Type: filter.TypeTimestamp}}
+
+// FilterProducts returns one page of the products matching where in order,
+// oldest first for the zero Order
+func (f *ProductFilter) FilterProducts(ctx context.Context, where filter.Expr, order filter.Order, page pagetoken.Page, limit int32) ([]sqlc.Product, error) {
+	var after filter.Expr
+	switch {
+	case order.IsZero():
+		order = createdAtOrder
+		after = order.After(page.After.CreatedAt, page.After.ID)
+	case page.After.Order != "":
+		// Other orders have no position before the first product, so the
+		// first page has no cursor
+		after = order.After(page.After.Value, page.After.ID)
+	}
+
+	condition, args := filter.Build(filter.And(after, where), 3)
+	query := fmt.Sprintf("SELECT %s FROM products WHERE %s ORDER BY %s LIMIT $1 OFFSET $2",
+		productColumns, condition, order.SQL())
+
+	params := []any{limit, page.Offset}
+	rows, err := f.db.Query(ctx, query, append(params, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (sqlc.Product, error) {
+		var p sqlc.Product
+		err := row.Scan(&p.ID, &p.Name, &p.Price, &p.CreatedAt, &p.UpdatedAt, &p.Category, &p.Attributes, &p.Metadata, &p.Version, &p.Description, &p.Translations, &p.Status)
+		return p, err
+	})
+}
+
+// CountFilteredProducts counts every product matching where
+func (f *ProductFilter) CountFilteredProducts(ctx context.Context, where filter.Expr) (int64, error) {
+	condition, args := filter.Build(where, 1)
+
+	var count int64
+	err := f.db.QueryRow(ctx, "SELECT COUNT(*) FROM products WHERE "+condition, args...).Scan(&count)
+	return count, err
+}
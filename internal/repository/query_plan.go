@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// explainTimeout bounds how long capturing one plan may take, as ANALYZE runs
+// the query again
+const explainTimeout = 30 * time.Second
+
+// capturedPlans counts the plans logged for slow queries, keyed by query name.
+// It is exported on /debug/vars as db_query_plans_captured_total.
+var capturedPlans = expvar.NewMap("db_query_plans_captured_total")
+
+// sqlcNameRegexp matches the name comment sqlc starts every query with
+var sqlcNameRegexp = regexp.MustCompile(`^-- name: (\w+) :(\w+)`)
+
+// QueryPlanOptions configures logging the EXPLAIN (ANALYZE, BUFFERS) plans of
+// slow list and search queries, i.e. sqlc :many queries and the filter
+// queries built at runtime, to find the indexes a schema is missing
+type QueryPlanOptions struct {
+	Enabled bool
+	// Threshold is the duration above which a query is slow
+	Threshold time.Duration
+	// SampleRate is the fraction of slow queries explained, from 0 to 1
+	SampleRate float64
+}
+
+type explainSkipKey struct{}
+
+type queryStartKey struct{}
+
+// queryStart is what planTracer remembers of a query until it ends
+type queryStart struct {
+	name  string
+	sql   string
+	args  []any
+	start time.Time
+}
+
+// planTracer explains the slow list queries of a pool. Plans are captured on
+// another connection of the pool once the query ended, one at a time: slow
+// queries ending while a plan is captured are not explained.
+type planTracer struct {
+	options QueryPlanOptions
+	pool    *pgxpool.Pool
+	busy    atomic.Bool
+}
+
+func (t *planTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if ctx.Value(explainSkipKey{}) != nil {
+		return ctx
+	}
+	name, ok := listQueryName(data.SQL)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, queryStartKey{}, &queryStart{name: name, sql: data.SQL, args: data.Args, start: time.Now()})
+}
+
+func (t *planTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	query, ok := ctx.Value(queryStartKey{}).(*queryStart)
+	if !ok || data.Err != nil {
+		return
+	}
+	elapsed := time.Since(query.start)
+	if elapsed < t.options.Threshold || rand.Float64() >= t.options.SampleRate {
+		return
+	}
+	if !t.busy.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer t.busy.Store(false)
+		t.explain(context.WithoutCancel(ctx), query, elapsed)
+	}()
+}
+
+// explain logs the plan of query in a read-only transaction rolled back
+// afterwards, so running it again changes nothing
+func (t *planTracer) explain(ctx context.Context, query *queryStart, elapsed time.Duration) {
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, explainSkipKey{}, true), explainTimeout)
+	defer cancel()
+
+	logger := slog.With(slog.String("query", query.name), slog.Duration("duration", elapsed))
+	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		logger.Warn("Failed to capture slow query plan", slog.Any("error", err))
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "EXPLAIN (ANALYZE, BUFFERS) "+query.sql, query.args...)
+	if err != nil {
+		logger.Warn("Failed to capture slow query plan", slog.Any("error", err))
+		return
+	}
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		logger.Warn("Failed to capture slow query plan", slog.Any("error", err))
+		return
+	}
+
+	capturedPlans.Add(query.name, 1)
+	logger.Info("Slow query plan",
+		slog.String("sql", query.sql),
+		slog.String("plan", strings.Join(lines, "\n")))
+}
+
+// listQueryName returns the name of sql when it is a list or search query:
+// a sqlc :many query, or a SELECT built at runtime, named "dynamic"
+func listQueryName(sql string) (string, bool) {
+	if match := sqlcNameRegexp.FindStringSubmatch(sql); match != nil {
+		return match[1], match[2] == "many"
+	}
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(sql)), "SELECT") {
+		return "", false
+	}
+	return "dynamic", true
+}
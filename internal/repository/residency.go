@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/residency"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RegionRouter is a sqlc.DBTX that sends each statement to the database pool
+// of the region owning the tenant found in the request context.
+type RegionRouter struct {
+	resolver *residency.Resolver
+	pools    map[string]*pgxpool.Pool
+}
+
+var _ sqlc.DBTX = (*RegionRouter)(nil)
+
+// NewRegionRouter creates a router over region-specific pools keyed by region name
+func NewRegionRouter(resolver *residency.Resolver, pools map[string]*pgxpool.Pool) *RegionRouter {
+	return &RegionRouter{
+		resolver: resolver,
+		pools:    pools,
+	}
+}
+
+func (r *RegionRouter) pool(ctx context.Context) (*pgxpool.Pool, error) {
+	region, err := r.resolver.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, ok := r.pools[region]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", residency.ErrUnknownRegion, region)
+	}
+
+	return pool, nil
+}
+
+func (r *RegionRouter) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	pool, err := r.pool(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return pool.Exec(ctx, sql, args...)
+}
+
+func (r *RegionRouter) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	pool, err := r.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.Query(ctx, sql, args...)
+}
+
+func (r *RegionRouter) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	pool, err := r.pool(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return pool.QueryRow(ctx, sql, args...)
+}
+
+// errRow defers a routing error until Scan, matching pgx.Row semantics
+type errRow struct {
+	err error
+}
+
+func (e errRow) Scan(...any) error {
+	return e.err
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/sandbox"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SandboxRouter is a sqlc.DBTX that sends the statements of sandbox requests
+// to the sandbox pool, whose search path is the sandbox schema, and the
+// others to production.
+type SandboxRouter struct {
+	production sqlc.DBTX
+	sandbox    *pgxpool.Pool
+}
+
+var _ sqlc.DBTX = (*SandboxRouter)(nil)
+
+// NewSandboxRouter creates a router over production, e.g. a *RegionRouter,
+// and a pool opened with the sandbox schema as search path
+func NewSandboxRouter(production sqlc.DBTX, sandbox *pgxpool.Pool) *SandboxRouter {
+	return &SandboxRouter{
+		production: production,
+		sandbox:    sandbox,
+	}
+}
+
+func (r *SandboxRouter) db(ctx context.Context) sqlc.DBTX {
+	if sandbox.FromContext(ctx) {
+		return r.sandbox
+	}
+	return r.production
+}
+
+func (r *SandboxRouter) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return r.db(ctx).Exec(ctx, sql, args...)
+}
+
+func (r *SandboxRouter) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return r.db(ctx).Query(ctx, sql, args...)
+}
+
+func (r *SandboxRouter) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return r.db(ctx).QueryRow(ctx, sql, args...)
+}
+
+// sandboxTablesQuery lists the tables of the public schema, but those of the
+// event broker, with whether the sandbox schema $1 has a copy and whether its
+// columns still match. Columns are compared by name, type and nullability.
+const sandboxTablesQuery = `
+WITH signatures AS (
+	SELECT c.table_schema::text, c.table_name::text,
+		string_agg(c.column_name || ' ' || c.udt_name || ' ' || c.is_nullable, ', ' ORDER BY c.column_name) AS signature
+	FROM information_schema.columns c
+	JOIN information_schema.tables t USING (table_schema, table_name)
+	WHERE c.table_schema::text IN ('public', $1::text) AND t.table_type = 'BASE TABLE'
+	GROUP BY c.table_schema, c.table_name
+)
+SELECT p.table_name, s.table_name IS NOT NULL AS copied, p.signature = s.signature AS current
+FROM signatures p
+LEFT JOIN signatures s ON s.table_schema = $1::text AND s.table_name = p.table_name
+WHERE p.table_schema = 'public' AND p.table_name NOT LIKE 'watermill\_%'
+ORDER BY p.table_name`
+
+// SandboxSchema keeps the sandbox schema a copy of the public schema without
+// its data. Tables are copied with their defaults, constraints and indexes,
+// but not their foreign keys or triggers.
+type SandboxSchema struct {
+	pool   *pgxpool.Pool
+	schema string
+}
+
+// NewSandboxSchema manages schema in the database of pool
+func NewSandboxSchema(pool *pgxpool.Pool, schema string) *SandboxSchema {
+	return &SandboxSchema{pool: pool, schema: schema}
+}
+
+// SearchPath is the search path of the connections of sandbox requests
+func (s *SandboxSchema) SearchPath() string {
+	return pgx.Identifier{s.schema}.Sanitize()
+}
+
+// Prepare creates the schema and copies the tables of the public schema it
+// is missing. Tables whose columns changed since they were copied, e.g. by a
+// migration, are copied again, dropping their sandbox data.
+func (s *SandboxSchema) Prepare(ctx context.Context) error {
+	return s.locked(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := s.sync(ctx, tx)
+		return err
+	})
+}
+
+// Purge deletes the sandbox data, copying the tables again first as Prepare
+// does so migrations reach long-running deployments
+func (s *SandboxSchema) Purge(ctx context.Context) error {
+	return s.locked(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tables, err := s.sync(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if len(tables) == 0 {
+			return nil
+		}
+
+		names := make([]string, len(tables))
+		for i, table := range tables {
+			names[i] = pgx.Identifier{s.schema, table}.Sanitize()
+		}
+		if _, err := tx.Exec(ctx, "TRUNCATE "+strings.Join(names, ", ")); err != nil {
+			return fmt.Errorf("purge sandbox tables: %w", err)
+		}
+		return nil
+	})
+}
+
+// locked runs fn in a transaction holding a lock on the schema, so instances
+// starting together do not copy the same tables
+func (s *SandboxSchema) locked(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended('sandbox:' || $1::text, 0))", s.schema); err != nil {
+		return fmt.Errorf("lock sandbox schema: %w", err)
+	}
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// sync brings the schema in line with the public schema and returns the
+// names of its tables
+func (s *SandboxSchema) sync(ctx context.Context, tx pgx.Tx) ([]string, error) {
+	schema := pgx.Identifier{s.schema}.Sanitize()
+	if _, err := tx.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+schema); err != nil {
+		return nil, fmt.Errorf("create sandbox schema: %w", err)
+	}
+
+	type table struct {
+		name    string
+		copied  bool
+		current bool
+	}
+	rows, err := tx.Query(ctx, sandboxTablesQuery, s.schema)
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	tables, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (table, error) {
+		var t table
+		var current *bool
+		err := row.Scan(&t.name, &t.copied, &current)
+		t.current = current != nil && *current
+		return t, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+
+	names := make([]string, len(tables))
+	for i, t := range tables {
+		names[i] = t.name
+		if t.copied && t.current {
+			continue
+		}
+
+		copied := pgx.Identifier{s.schema, t.name}.Sanitize()
+		source := pgx.Identifier{"public", t.name}.Sanitize()
+		if _, err := tx.Exec(ctx, "DROP TABLE IF EXISTS "+copied); err != nil {
+			return nil, fmt.Errorf("drop sandbox table %s: %w", t.name, err)
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING ALL)", copied, source)); err != nil {
+			return nil, fmt.Errorf("copy table %s: %w", t.name, err)
+		}
+	}
+	return names, nil
+}
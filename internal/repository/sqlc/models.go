@@ -9,18 +9,209 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Product struct {
+type ApiUsageDaily struct {
+	ClientID       string      `json:"client_id"`
+	Day            pgtype.Date `json:"day"`
+	RequestCount   int64       `json:"request_count"`
+	ErrorCount     int64       `json:"error_count"`
+	TotalLatencyMs int64       `json:"total_latency_ms"`
+	MaxLatencyMs   int64       `json:"max_latency_ms"`
+}
+
+type ApiUsageHourly struct {
+	ClientID       string             `json:"client_id"`
+	Method         string             `json:"method"`
+	BucketStart    pgtype.Timestamptz `json:"bucket_start"`
+	RequestCount   int64              `json:"request_count"`
+	ErrorCount     int64              `json:"error_count"`
+	TotalLatencyMs int64              `json:"total_latency_ms"`
+	MaxLatencyMs   int64              `json:"max_latency_ms"`
+}
+
+type ArchiveManifest struct {
+	ID            int64              `json:"id"`
+	Source        string             `json:"source"`
+	ObjectKey     string             `json:"object_key"`
+	FirstID       int64              `json:"first_id"`
+	LastID        int64              `json:"last_id"`
+	RowCount      int64              `json:"row_count"`
+	OldestAt      pgtype.Timestamptz `json:"oldest_at"`
+	NewestAt      pgtype.Timestamptz `json:"newest_at"`
+	SizeBytes     int64              `json:"size_bytes"`
+	Checksum      []byte             `json:"checksum"`
+	FirstPrevHash []byte             `json:"first_prev_hash"`
+	LastHash      []byte             `json:"last_hash"`
+	ArchivedAt    pgtype.Timestamptz `json:"archived_at"`
+	RestoredAt    pgtype.Timestamptz `json:"restored_at"`
+}
+
+type AuditLog struct {
+	Sequence   int64              `json:"sequence"`
+	EventID    string             `json:"event_id"`
+	EventName  string             `json:"event_name"`
+	Payload    []byte             `json:"payload"`
+	RecordedAt pgtype.Timestamptz `json:"recorded_at"`
+	PrevHash   []byte             `json:"prev_hash"`
+	Hash       []byte             `json:"hash"`
+}
+
+type DataMigration struct {
+	Name               string             `json:"name"`
+	BackfillCursor     pgtype.Text        `json:"backfill_cursor"`
+	RowsCopied         int64              `json:"rows_copied"`
+	BackfillStartedAt  pgtype.Timestamptz `json:"backfill_started_at"`
+	BackfillFinishedAt pgtype.Timestamptz `json:"backfill_finished_at"`
+	RowsVerified       int64              `json:"rows_verified"`
+	MismatchedChunks   int32              `json:"mismatched_chunks"`
+	VerifiedAt         pgtype.Timestamptz `json:"verified_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+}
+
+type DigestBuffer struct {
+	ID         int64              `json:"id"`
+	Digest     string             `json:"digest"`
+	GroupKey   string             `json:"group_key"`
+	EventID    string             `json:"event_id"`
+	EventName  string             `json:"event_name"`
+	Payload    []byte             `json:"payload"`
+	BufferedAt pgtype.Timestamptz `json:"buffered_at"`
+}
+
+type EmailChangeRequest struct {
+	TokenHash pgtype.Text        `json:"token_hash"`
+	UserID    uuid.UUID          `json:"user_id"`
+	NewEmail  string             `json:"new_email"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	ID        uuid.UUID          `json:"id"`
+}
+
+type EmailTemplate struct {
 	Name      string             `json:"name"`
-	Price     pgtype.Numeric     `json:"price"`
+	Version   int32              `json:"version"`
+	Subject   string             `json:"subject"`
+	HtmlBody  string             `json:"html_body"`
+	TextBody  string             `json:"text_body"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type EntityEvent struct {
+	ID            int64              `json:"id"`
+	AggregateType string             `json:"aggregate_type"`
+	AggregateID   string             `json:"aggregate_id"`
+	EventID       string             `json:"event_id"`
+	EventName     string             `json:"event_name"`
+	Metadata      []byte             `json:"metadata"`
+	Payload       []byte             `json:"payload"`
+	PublishedAt   pgtype.Timestamptz `json:"published_at"`
+}
+
+type Inbox struct {
+	EventID     string             `json:"event_id"`
+	Handler     string             `json:"handler"`
+	ProcessedAt pgtype.Timestamptz `json:"processed_at"`
+}
+
+type IpAccessRule struct {
+	List        string             `json:"list"`
+	Network     string             `json:"network"`
+	Description string             `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type Job struct {
+	ID         uuid.UUID          `json:"id"`
+	Kind       string             `json:"kind"`
+	Status     string             `json:"status"`
+	Processed  int64              `json:"processed"`
+	Total      pgtype.Int8        `json:"total"`
+	Attempts   int32              `json:"attempts"`
+	Error      pgtype.Text        `json:"error"`
+	ResultRef  pgtype.Text        `json:"result_ref"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	StartedAt  pgtype.Timestamptz `json:"started_at"`
+	FinishedAt pgtype.Timestamptz `json:"finished_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Operation struct {
+	ID         uuid.UUID          `json:"id"`
+	Kind       string             `json:"kind"`
+	Status     string             `json:"status"`
+	ResourceID pgtype.UUID        `json:"resource_id"`
+	Error      pgtype.Text        `json:"error"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
+
+type OutboundCall struct {
+	IdempotencyKey string             `json:"idempotency_key"`
+	EventID        string             `json:"event_id"`
+	Handler        string             `json:"handler"`
+	Call           string             `json:"call"`
+	Result         []byte             `json:"result"`
+	CalledAt       pgtype.Timestamptz `json:"called_at"`
+}
+
+type Product struct {
+	ID           uuid.UUID          `json:"id"`
+	Name         string             `json:"name"`
+	Price        pgtype.Numeric     `json:"price"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	Category     string             `json:"category"`
+	Attributes   []byte             `json:"attributes"`
+	Metadata     []byte             `json:"metadata"`
+	Version      int64              `json:"version"`
+	Description  string             `json:"description"`
+	Translations []byte             `json:"translations"`
+	Status       string             `json:"status"`
+}
+
+type PublishRetry struct {
+	ID            int64              `json:"id"`
+	Topic         string             `json:"topic"`
+	MessageUuid   string             `json:"message_uuid"`
+	Metadata      []byte             `json:"metadata"`
+	Payload       []byte             `json:"payload"`
+	Attempts      int32              `json:"attempts"`
+	LastError     string             `json:"last_error"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	LastAttemptAt pgtype.Timestamptz `json:"last_attempt_at"`
+}
+
+type ScheduledPrice struct {
+	ID          uuid.UUID          `json:"id"`
+	ProductID   uuid.UUID          `json:"product_id"`
+	Price       pgtype.Numeric     `json:"price"`
+	EffectiveAt pgtype.Timestamptz `json:"effective_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	AppliedAt   pgtype.Timestamptz `json:"applied_at"`
 }
 
 type User struct {
-	ID        uuid.UUID          `json:"id"`
-	Name      string             `json:"name"`
-	Email     string             `json:"email"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	ID          uuid.UUID          `json:"id"`
+	Name        string             `json:"name"`
+	Email       string             `json:"email"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	EmailHash   pgtype.Text        `json:"email_hash"`
+	Metadata    []byte             `json:"metadata"`
+	DuplicateOf pgtype.UUID        `json:"duplicate_of"`
+	Version     int64              `json:"version"`
+	Role        string             `json:"role"`
+}
+
+type WebhookDelivery struct {
+	ID          uuid.UUID          `json:"id"`
+	Provider    string             `json:"provider"`
+	EventID     string             `json:"event_id"`
+	EventType   string             `json:"event_type"`
+	Payload     []byte             `json:"payload"`
+	Status      string             `json:"status"`
+	Deliveries  int32              `json:"deliveries"`
+	Attempts    int32              `json:"attempts"`
+	Error       pgtype.Text        `json:"error"`
+	ReceivedAt  pgtype.Timestamptz `json:"received_at"`
+	ProcessedAt pgtype.Timestamptz `json:"processed_at"`
 }
@@ -23,38 +23,54 @@ func (q *Queries) CountProducts(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-const countProductsBySearch = `-- name: CountProductsBySearch :one
-SELECT COUNT(*) FROM products
-WHERE name ILIKE $1
-`
-
-func (q *Queries) CountProductsBySearch(ctx context.Context, searchQuery string) (int64, error) {
-	row := q.db.QueryRow(ctx, countProductsBySearch, searchQuery)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
-}
-
 const createProduct = `-- name: CreateProduct :one
 INSERT INTO products (
     id,
     name,
-    price
+    price,
+    category,
+    attributes,
+    metadata,
+    description,
+    translations,
+    status
 ) VALUES (
     $1,
     $2,
-    $3
-) RETURNING id, name, price, created_at, updated_at
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+) RETURNING id, name, price, created_at, updated_at, category, attributes, metadata, version, description, translations, status
 `
 
 type CreateProductParams struct {
-	ID    uuid.UUID      `json:"id"`
-	Name  string         `json:"name"`
-	Price pgtype.Numeric `json:"price"`
+	ID           uuid.UUID      `json:"id"`
+	Name         string         `json:"name"`
+	Price        pgtype.Numeric `json:"price"`
+	Category     string         `json:"category"`
+	Attributes   []byte         `json:"attributes"`
+	Metadata     []byte         `json:"metadata"`
+	Description  string         `json:"description"`
+	Translations []byte         `json:"translations"`
+	Status       string         `json:"status"`
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
-	row := q.db.QueryRow(ctx, createProduct, arg.ID, arg.Name, arg.Price)
+	row := q.db.QueryRow(ctx, createProduct,
+		arg.ID,
+		arg.Name,
+		arg.Price,
+		arg.Category,
+		arg.Attributes,
+		arg.Metadata,
+		arg.Description,
+		arg.Translations,
+		arg.Status,
+	)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -62,6 +78,13 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Category,
+		&i.Attributes,
+		&i.Metadata,
+		&i.Version,
+		&i.Description,
+		&i.Translations,
+		&i.Status,
 	)
 	return i, err
 }
@@ -110,7 +133,7 @@ func (q *Queries) GetMinPrice(ctx context.Context) (interface{}, error) {
 }
 
 const getProductByID = `-- name: GetProductByID :one
-SELECT id, name, price, created_at, updated_at FROM products
+SELECT id, name, price, created_at, updated_at, category, attributes, metadata, version, description, translations, status FROM products
 WHERE id = $1
 `
 
@@ -123,23 +146,31 @@ func (q *Queries) GetProductByID(ctx context.Context, id uuid.UUID) (Product, er
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Category,
+		&i.Attributes,
+		&i.Metadata,
+		&i.Version,
+		&i.Description,
+		&i.Translations,
+		&i.Status,
 	)
 	return i, err
 }
 
-const listProducts = `-- name: ListProducts :many
-SELECT id, name, price, created_at, updated_at FROM products
-ORDER BY created_at
-LIMIT $1 OFFSET $2
+const listProductsAfterID = `-- name: ListProductsAfterID :many
+SELECT id, name, price, created_at, updated_at, category, attributes, metadata, version, description, translations, status FROM products
+WHERE id > $1
+ORDER BY id
+LIMIT $2
 `
 
-type ListProductsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ListProductsAfterIDParams struct {
+	AfterID   uuid.UUID `json:"after_id"`
+	BatchSize int32     `json:"batch_size"`
 }
 
-func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error) {
-	rows, err := q.db.Query(ctx, listProducts, arg.Limit, arg.Offset)
+func (q *Queries) ListProductsAfterID(ctx context.Context, arg ListProductsAfterIDParams) ([]Product, error) {
+	rows, err := q.db.Query(ctx, listProductsAfterID, arg.AfterID, arg.BatchSize)
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +184,13 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]P
 			&i.Price,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Category,
+			&i.Attributes,
+			&i.Metadata,
+			&i.Version,
+			&i.Description,
+			&i.Translations,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -164,155 +202,79 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]P
 	return items, nil
 }
 
-const listProductsByPriceRange = `-- name: ListProductsByPriceRange :many
-SELECT id, name, price, created_at, updated_at FROM products
-WHERE price BETWEEN $3 AND $4
-ORDER BY created_at
-LIMIT $1 OFFSET $2
+const updateProduct = `-- name: UpdateProduct :one
+UPDATE products
+SET 
+    name = $1,
+    price = $2,
+    category = $3,
+    attributes = $4,
+    metadata = $5,
+    description = $6,
+    translations = $7,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = $8
+RETURNING id, name, price, created_at, updated_at, category, attributes, metadata, version, description, translations, status
 `
 
-type ListProductsByPriceRangeParams struct {
-	Limit    int32          `json:"limit"`
-	Offset   int32          `json:"offset"`
-	MinPrice pgtype.Numeric `json:"min_price"`
-	MaxPrice pgtype.Numeric `json:"max_price"`
+type UpdateProductParams struct {
+	Name         string         `json:"name"`
+	Price        pgtype.Numeric `json:"price"`
+	Category     string         `json:"category"`
+	Attributes   []byte         `json:"attributes"`
+	Metadata     []byte         `json:"metadata"`
+	Description  string         `json:"description"`
+	Translations []byte         `json:"translations"`
+	ID           uuid.UUID      `json:"id"`
 }
 
-func (q *Queries) ListProductsByPriceRange(ctx context.Context, arg ListProductsByPriceRangeParams) ([]Product, error) {
-	rows, err := q.db.Query(ctx, listProductsByPriceRange,
-		arg.Limit,
-		arg.Offset,
-		arg.MinPrice,
-		arg.MaxPrice,
+func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProduct,
+		arg.Name,
+		arg.Price,
+		arg.Category,
+		arg.Attributes,
+		arg.Metadata,
+		arg.Description,
+		arg.Translations,
+		arg.ID,
 	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Product{}
-	for rows.Next() {
-		var i Product
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Price,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const searchProducts = `-- name: SearchProducts :many
-SELECT id, name, price, created_at, updated_at FROM products
-WHERE name ILIKE $3
-ORDER BY created_at
-LIMIT $1 OFFSET $2
-`
-
-type SearchProductsParams struct {
-	Limit       int32  `json:"limit"`
-	Offset      int32  `json:"offset"`
-	SearchQuery string `json:"search_query"`
-}
-
-func (q *Queries) SearchProducts(ctx context.Context, arg SearchProductsParams) ([]Product, error) {
-	rows, err := q.db.Query(ctx, searchProducts, arg.Limit, arg.Offset, arg.SearchQuery)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Product{}
-	for rows.Next() {
-		var i Product
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Price,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const searchProductsWithPriceRange = `-- name: SearchProductsWithPriceRange :many
-SELECT id, name, price, created_at, updated_at FROM products
-WHERE name ILIKE $3 AND price BETWEEN $4 AND $5
-ORDER BY created_at
-LIMIT $1 OFFSET $2
-`
-
-type SearchProductsWithPriceRangeParams struct {
-	Limit       int32          `json:"limit"`
-	Offset      int32          `json:"offset"`
-	SearchQuery string         `json:"search_query"`
-	MinPrice    pgtype.Numeric `json:"min_price"`
-	MaxPrice    pgtype.Numeric `json:"max_price"`
-}
-
-func (q *Queries) SearchProductsWithPriceRange(ctx context.Context, arg SearchProductsWithPriceRangeParams) ([]Product, error) {
-	rows, err := q.db.Query(ctx, searchProductsWithPriceRange,
-		arg.Limit,
-		arg.Offset,
-		arg.SearchQuery,
-		arg.MinPrice,
-		arg.MaxPrice,
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Category,
+		&i.Attributes,
+		&i.Metadata,
+		&i.Version,
+		&i.Description,
+		&i.Translations,
+		&i.Status,
 	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Product{}
-	for rows.Next() {
-		var i Product
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Price,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return i, err
 }
 
-const updateProduct = `-- name: UpdateProduct :one
+const updateProductStatus = `-- name: UpdateProductStatus :one
 UPDATE products
-SET 
-    name = $1,
-    price = $2,
-    updated_at = NOW()
-WHERE id = $3
-RETURNING id, name, price, created_at, updated_at
+SET
+    status = $1,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = $2
+RETURNING id, name, price, created_at, updated_at, category, attributes, metadata, version, description, translations, status
 `
 
-type UpdateProductParams struct {
-	Name  string         `json:"name"`
-	Price pgtype.Numeric `json:"price"`
-	ID    uuid.UUID      `json:"id"`
+type UpdateProductStatusParams struct {
+	Status string    `json:"status"`
+	ID     uuid.UUID `json:"id"`
 }
 
-func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
-	row := q.db.QueryRow(ctx, updateProduct, arg.Name, arg.Price, arg.ID)
+func (q *Queries) UpdateProductStatus(ctx context.Context, arg UpdateProductStatusParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductStatus, arg.Status, arg.ID)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -320,6 +282,13 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Category,
+		&i.Attributes,
+		&i.Metadata,
+		&i.Version,
+		&i.Description,
+		&i.Translations,
+		&i.Status,
 	)
 	return i, err
 }
@@ -8,30 +8,122 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	BufferDigestEvent(ctx context.Context, arg BufferDigestEventParams) error
+	ClaimScheduledPrice(ctx context.Context, id uuid.UUID) (int64, error)
+	CompleteJob(ctx context.Context, arg CompleteJobParams) error
+	CompleteOperation(ctx context.Context, arg CompleteOperationParams) error
+	CompleteWebhookDelivery(ctx context.Context, id uuid.UUID) error
+	CountDigestGroup(ctx context.Context, arg CountDigestGroupParams) (int64, error)
+	CountExpiredEmailChangeRequests(ctx context.Context, expiredBefore pgtype.Timestamptz) (int64, error)
+	CountOutboundCalls(ctx context.Context, calledBefore pgtype.Timestamptz) (int64, error)
+	CountProcessedInboxMessages(ctx context.Context, processedBefore pgtype.Timestamptz) (int64, error)
 	CountProducts(ctx context.Context) (int64, error)
-	CountProductsBySearch(ctx context.Context, searchQuery string) (int64, error)
 	CountUsers(ctx context.Context) (int64, error)
-	CountUsersBySearch(ctx context.Context, searchQuery string) (int64, error)
+	CountUsersBySearch(ctx context.Context, arg CountUsersBySearchParams) (int64, error)
+	CreateArchiveManifest(ctx context.Context, arg CreateArchiveManifestParams) (ArchiveManifest, error)
+	CreateEmailChangeRequest(ctx context.Context, arg CreateEmailChangeRequestParams) (EmailChangeRequest, error)
+	CreateEmailTemplate(ctx context.Context, arg CreateEmailTemplateParams) (EmailTemplate, error)
+	CreateEmailTemplateVersion(ctx context.Context, arg CreateEmailTemplateVersionParams) (EmailTemplate, error)
+	CreateIPAccessRule(ctx context.Context, arg CreateIPAccessRuleParams) (IpAccessRule, error)
+	CreateJob(ctx context.Context, arg CreateJobParams) (Job, error)
+	CreateOperation(ctx context.Context, arg CreateOperationParams) (Operation, error)
 	CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error)
+	CreateScheduledPrice(ctx context.Context, arg CreateScheduledPriceParams) (ScheduledPrice, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAPIUsageHourlyBefore(ctx context.Context, before pgtype.Timestamptz) error
+	DeleteArchivedAuditRecords(ctx context.Context, arg DeleteArchivedAuditRecordsParams) (int64, error)
+	DeleteArchivedEntityEvents(ctx context.Context, arg DeleteArchivedEntityEventsParams) (int64, error)
+	DeleteDigestEvents(ctx context.Context, ids []int64) error
+	DeleteEmailChangeRequests(ctx context.Context, userID uuid.UUID) error
+	DeleteEmailTemplate(ctx context.Context, name string) (int64, error)
+	DeleteExpiredEmailChangeRequests(ctx context.Context, arg DeleteExpiredEmailChangeRequestsParams) (int64, error)
+	DeleteIPAccessRule(ctx context.Context, arg DeleteIPAccessRuleParams) (int64, error)
+	DeleteOutboundCalls(ctx context.Context, arg DeleteOutboundCallsParams) (int64, error)
+	DeleteProcessedInboxMessages(ctx context.Context, arg DeleteProcessedInboxMessagesParams) (int64, error)
 	DeleteProduct(ctx context.Context, id uuid.UUID) error
+	DeletePublishRetry(ctx context.Context, id int64) error
 	DeleteUser(ctx context.Context, id uuid.UUID) error
+	FailJob(ctx context.Context, arg FailJobParams) error
+	FailOperation(ctx context.Context, arg FailOperationParams) error
+	FailWebhookDelivery(ctx context.Context, arg FailWebhookDeliveryParams) error
+	FinishDataMigrationBackfill(ctx context.Context, name string) error
+	FlagDuplicateUser(ctx context.Context, arg FlagDuplicateUserParams) error
+	GetArchiveManifest(ctx context.Context, id int64) (ArchiveManifest, error)
+	GetArchiveManifestStartingAt(ctx context.Context, arg GetArchiveManifestStartingAtParams) (ArchiveManifest, error)
 	GetAveragePrice(ctx context.Context) (interface{}, error)
+	GetDataMigration(ctx context.Context, name string) (DataMigration, error)
+	GetEmailChangeRequest(ctx context.Context, tokenHash string) (EmailChangeRequest, error)
+	GetEmailTemplate(ctx context.Context, name string) (EmailTemplate, error)
+	GetEmailTemplateVersion(ctx context.Context, arg GetEmailTemplateVersionParams) (EmailTemplate, error)
+	GetJob(ctx context.Context, id uuid.UUID) (Job, error)
+	GetLastArchiveManifest(ctx context.Context, source string) (ArchiveManifest, error)
+	GetLastAuditRecord(ctx context.Context) (AuditLog, error)
 	GetMaxPrice(ctx context.Context) (interface{}, error)
 	GetMinPrice(ctx context.Context) (interface{}, error)
+	GetOperation(ctx context.Context, id uuid.UUID) (Operation, error)
+	GetOutboundCallResult(ctx context.Context, idempotencyKey string) ([]byte, error)
 	GetProductByID(ctx context.Context, id uuid.UUID) (Product, error)
+	GetPublishRetryLag(ctx context.Context) (float64, error)
+	GetUserByEmail(ctx context.Context, arg GetUserByEmailParams) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
-	ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error)
-	ListProductsByPriceRange(ctx context.Context, arg ListProductsByPriceRangeParams) ([]Product, error)
+	GetUserRole(ctx context.Context, id uuid.UUID) (string, error)
+	GetWebhookDelivery(ctx context.Context, id uuid.UUID) (WebhookDelivery, error)
+	InsertAuditRecord(ctx context.Context, arg InsertAuditRecordParams) (int64, error)
+	InsertEntityEvent(ctx context.Context, arg InsertEntityEventParams) error
+	InsertInboxMessage(ctx context.Context, arg InsertInboxMessageParams) (int64, error)
+	InsertOutboundCall(ctx context.Context, arg InsertOutboundCallParams) error
+	InsertPublishRetry(ctx context.Context, arg InsertPublishRetryParams) error
+	ListAPIUsageDaily(ctx context.Context, arg ListAPIUsageDailyParams) ([]ApiUsageDaily, error)
+	ListAllAPIUsageDaily(ctx context.Context, arg ListAllAPIUsageDailyParams) ([]ApiUsageDaily, error)
+	ListArchivableAuditRecords(ctx context.Context, arg ListArchivableAuditRecordsParams) ([]AuditLog, error)
+	ListArchivableEntityEvents(ctx context.Context, arg ListArchivableEntityEventsParams) ([]EntityEvent, error)
+	ListArchiveManifests(ctx context.Context, arg ListArchiveManifestsParams) ([]ArchiveManifest, error)
+	ListAuditRecords(ctx context.Context, arg ListAuditRecordsParams) ([]AuditLog, error)
+	ListDataMigrations(ctx context.Context) ([]DataMigration, error)
+	ListDigestGroupEvents(ctx context.Context, arg ListDigestGroupEventsParams) ([]DigestBuffer, error)
+	ListDueDigestGroups(ctx context.Context, arg ListDueDigestGroupsParams) ([]string, error)
+	ListDueScheduledPrices(ctx context.Context, arg ListDueScheduledPricesParams) ([]ScheduledPrice, error)
+	ListDuplicateUsers(ctx context.Context, arg ListDuplicateUsersParams) ([]User, error)
+	ListEmailTemplateVersions(ctx context.Context, name string) ([]EmailTemplate, error)
+	ListEmailTemplates(ctx context.Context) ([]EmailTemplate, error)
+	ListEntityEvents(ctx context.Context, arg ListEntityEventsParams) ([]EntityEvent, error)
+	ListIPAccessRules(ctx context.Context) ([]IpAccessRule, error)
+	ListJobs(ctx context.Context, arg ListJobsParams) ([]Job, error)
+	ListPendingScheduledPrices(ctx context.Context, productID uuid.UUID) ([]ScheduledPrice, error)
+	ListProductsAfterID(ctx context.Context, arg ListProductsAfterIDParams) ([]Product, error)
+	ListPublishRetries(ctx context.Context, batchSize int32) ([]PublishRetry, error)
+	ListUserEmailsForRotation(ctx context.Context, arg ListUserEmailsForRotationParams) ([]ListUserEmailsForRotationRow, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
-	SearchProducts(ctx context.Context, arg SearchProductsParams) ([]Product, error)
-	SearchProductsWithPriceRange(ctx context.Context, arg SearchProductsWithPriceRangeParams) ([]Product, error)
+	ListUsersForEmailBackfill(ctx context.Context, arg ListUsersForEmailBackfillParams) ([]User, error)
+	LockAuditLog(ctx context.Context) error
+	MarkArchiveManifestRestored(ctx context.Context, id int64) error
+	MergeUserMetadata(ctx context.Context, arg MergeUserMetadataParams) (int64, error)
+	RecordDataMigrationBatch(ctx context.Context, arg RecordDataMigrationBatchParams) error
+	RecordDataMigrationVerification(ctx context.Context, arg RecordDataMigrationVerificationParams) error
+	RecordJobError(ctx context.Context, arg RecordJobErrorParams) error
+	RecordPublishRetryFailure(ctx context.Context, arg RecordPublishRetryFailureParams) error
+	RecordWebhookDeliveryError(ctx context.Context, arg RecordWebhookDeliveryErrorParams) error
+	ReleaseScheduledPrice(ctx context.Context, id uuid.UUID) error
+	ResetDataMigration(ctx context.Context, name string) error
+	RestoreEntityEvent(ctx context.Context, arg RestoreEntityEventParams) (int64, error)
+	RollupAPIUsageDaily(ctx context.Context, day pgtype.Date) error
 	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error)
+	SetEmailChangeTokenHash(ctx context.Context, arg SetEmailChangeTokenHashParams) (int64, error)
+	StartDataMigrationBackfill(ctx context.Context, name string) (DataMigration, error)
+	StartJob(ctx context.Context, id uuid.UUID) error
+	TryLockDigestGroup(ctx context.Context, arg TryLockDigestGroupParams) (bool, error)
+	UpdateJobProgress(ctx context.Context, arg UpdateJobProgressParams) error
 	UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error)
+	UpdateProductStatus(ctx context.Context, arg UpdateProductStatusParams) (Product, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserEmailCiphertext(ctx context.Context, arg UpdateUserEmailCiphertextParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpsertAPIUsageHourly(ctx context.Context, arg UpsertAPIUsageHourlyParams) error
+	UpsertWebhookDelivery(ctx context.Context, arg UpsertWebhookDeliveryParams) (WebhookDelivery, error)
 }
 
 var _ Querier = (*Queries)(nil)
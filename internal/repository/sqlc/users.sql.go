@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const countUsers = `-- name: CountUsers :one
@@ -24,11 +25,17 @@ func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
 
 const countUsersBySearch = `-- name: CountUsersBySearch :one
 SELECT COUNT(*) FROM users
-WHERE name ILIKE $1 OR email ILIKE $1
+WHERE name ILIKE $1 OR email ILIKE $2 OR email_hash = $3
 `
 
-func (q *Queries) CountUsersBySearch(ctx context.Context, searchQuery string) (int64, error) {
-	row := q.db.QueryRow(ctx, countUsersBySearch, searchQuery)
+type CountUsersBySearchParams struct {
+	SearchQuery string      `json:"search_query"`
+	EmailQuery  pgtype.Text `json:"email_query"`
+	EmailHash   pgtype.Text `json:"email_hash"`
+}
+
+func (q *Queries) CountUsersBySearch(ctx context.Context, arg CountUsersBySearchParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsersBySearch, arg.SearchQuery, arg.EmailQuery, arg.EmailHash)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -38,22 +45,34 @@ const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     id,
     name,
-    email
+    email,
+    email_hash,
+    metadata
 ) VALUES (
     $1,
     $2,
-    $3
-) RETURNING id, name, email, created_at, updated_at
+    $3,
+    $4,
+    $5
+) RETURNING id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role
 `
 
 type CreateUserParams struct {
-	ID    uuid.UUID `json:"id"`
-	Name  string    `json:"name"`
-	Email string    `json:"email"`
+	ID        uuid.UUID   `json:"id"`
+	Name      string      `json:"name"`
+	Email     string      `json:"email"`
+	EmailHash pgtype.Text `json:"email_hash"`
+	Metadata  []byte      `json:"metadata"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.ID, arg.Name, arg.Email)
+	row := q.db.QueryRow(ctx, createUser,
+		arg.ID,
+		arg.Name,
+		arg.Email,
+		arg.EmailHash,
+		arg.Metadata,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -61,6 +80,11 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailHash,
+		&i.Metadata,
+		&i.DuplicateOf,
+		&i.Version,
+		&i.Role,
 	)
 	return i, err
 }
@@ -75,8 +99,56 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const flagDuplicateUser = `-- name: FlagDuplicateUser :exec
+UPDATE users
+SET
+    duplicate_of = $1,
+    email_hash = NULL,
+    version = version + 1
+WHERE id = $2
+`
+
+type FlagDuplicateUserParams struct {
+	DuplicateOf pgtype.UUID `json:"duplicate_of"`
+	ID          uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) FlagDuplicateUser(ctx context.Context, arg FlagDuplicateUserParams) error {
+	_, err := q.db.Exec(ctx, flagDuplicateUser, arg.DuplicateOf, arg.ID)
+	return err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role FROM users
+WHERE (lower(email) = $1::text OR email_hash = $2)
+  AND duplicate_of IS NULL
+`
+
+type GetUserByEmailParams struct {
+	Email     string      `json:"email"`
+	EmailHash pgtype.Text `json:"email_hash"`
+}
+
+func (q *Queries) GetUserByEmail(ctx context.Context, arg GetUserByEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, arg.Email, arg.EmailHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailHash,
+		&i.Metadata,
+		&i.DuplicateOf,
+		&i.Version,
+		&i.Role,
+	)
+	return i, err
+}
+
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, created_at, updated_at FROM users
+SELECT id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role FROM users
 WHERE id = $1
 `
 
@@ -89,23 +161,174 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailHash,
+		&i.Metadata,
+		&i.DuplicateOf,
+		&i.Version,
+		&i.Role,
 	)
 	return i, err
 }
 
+const getUserRole = `-- name: GetUserRole :one
+SELECT role FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserRole(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRow(ctx, getUserRole, id)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const listDuplicateUsers = `-- name: ListDuplicateUsers :many
+SELECT id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role FROM users
+WHERE duplicate_of IS NOT NULL AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListDuplicateUsersParams struct {
+	AfterID   uuid.UUID `json:"after_id"`
+	BatchSize int32     `json:"batch_size"`
+}
+
+func (q *Queries) ListDuplicateUsers(ctx context.Context, arg ListDuplicateUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listDuplicateUsers, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EmailHash,
+			&i.Metadata,
+			&i.DuplicateOf,
+			&i.Version,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserEmailsForRotation = `-- name: ListUserEmailsForRotation :many
+SELECT id, email FROM users
+WHERE email NOT LIKE $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListUserEmailsForRotationParams struct {
+	ActivePrefix string `json:"active_prefix"`
+	BatchSize    int32  `json:"batch_size"`
+}
+
+type ListUserEmailsForRotationRow struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) ListUserEmailsForRotation(ctx context.Context, arg ListUserEmailsForRotationParams) ([]ListUserEmailsForRotationRow, error) {
+	rows, err := q.db.Query(ctx, listUserEmailsForRotation, arg.ActivePrefix, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUserEmailsForRotationRow{}
+	for rows.Next() {
+		var i ListUserEmailsForRotationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
-SELECT id, name, email, created_at, updated_at FROM users
-ORDER BY created_at
-LIMIT $1 OFFSET $2
+SELECT id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role FROM users
+WHERE (created_at, id) > ($1::timestamptz, $2::uuid)
+ORDER BY created_at, id
+LIMIT $3 OFFSET $4
 `
 
 type ListUsersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        uuid.UUID          `json:"after_id"`
+	PageSize       int32              `json:"page_size"`
+	PageOffset     int32              `json:"page_offset"`
 }
 
 func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
-	rows, err := q.db.Query(ctx, listUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EmailHash,
+			&i.Metadata,
+			&i.DuplicateOf,
+			&i.Version,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersForEmailBackfill = `-- name: ListUsersForEmailBackfill :many
+SELECT id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role FROM users
+WHERE duplicate_of IS NULL AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListUsersForEmailBackfillParams struct {
+	AfterID   uuid.UUID `json:"after_id"`
+	BatchSize int32     `json:"batch_size"`
+}
+
+func (q *Queries) ListUsersForEmailBackfill(ctx context.Context, arg ListUsersForEmailBackfillParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersForEmailBackfill, arg.AfterID, arg.BatchSize)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +342,11 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.Email,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EmailHash,
+			&i.Metadata,
+			&i.DuplicateOf,
+			&i.Version,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -130,21 +358,56 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const mergeUserMetadata = `-- name: MergeUserMetadata :execrows
+UPDATE users
+SET
+    metadata = $1 || metadata,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = $2 AND duplicate_of IS NULL
+`
+
+type MergeUserMetadataParams struct {
+	Metadata []byte    `json:"metadata"`
+	ID       uuid.UUID `json:"id"`
+}
+
+func (q *Queries) MergeUserMetadata(ctx context.Context, arg MergeUserMetadataParams) (int64, error) {
+	result, err := q.db.Exec(ctx, mergeUserMetadata, arg.Metadata, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const searchUsers = `-- name: SearchUsers :many
-SELECT id, name, email, created_at, updated_at FROM users
-WHERE name ILIKE $3 OR email ILIKE $3
-ORDER BY created_at
-LIMIT $1 OFFSET $2
+SELECT id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role FROM users
+WHERE (name ILIKE $1 OR email ILIKE $2 OR email_hash = $3)
+  AND (created_at, id) > ($4::timestamptz, $5::uuid)
+ORDER BY created_at, id
+LIMIT $6 OFFSET $7
 `
 
 type SearchUsersParams struct {
-	Limit       int32  `json:"limit"`
-	Offset      int32  `json:"offset"`
-	SearchQuery string `json:"search_query"`
+	SearchQuery    string             `json:"search_query"`
+	EmailQuery     pgtype.Text        `json:"email_query"`
+	EmailHash      pgtype.Text        `json:"email_hash"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        uuid.UUID          `json:"after_id"`
+	PageSize       int32              `json:"page_size"`
+	PageOffset     int32              `json:"page_offset"`
 }
 
 func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
-	rows, err := q.db.Query(ctx, searchUsers, arg.Limit, arg.Offset, arg.SearchQuery)
+	rows, err := q.db.Query(ctx, searchUsers,
+		arg.SearchQuery,
+		arg.EmailQuery,
+		arg.EmailHash,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+		arg.PageOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -158,6 +421,11 @@ func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]Use
 			&i.Email,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EmailHash,
+			&i.Metadata,
+			&i.DuplicateOf,
+			&i.Version,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -174,19 +442,83 @@ UPDATE users
 SET 
     name = $1,
     email = $2,
-    updated_at = NOW()
-WHERE id = $3
-RETURNING id, name, email, created_at, updated_at
+    email_hash = $3,
+    metadata = $4,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = $5
+RETURNING id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role
 `
 
 type UpdateUserParams struct {
-	Name  string    `json:"name"`
-	Email string    `json:"email"`
-	ID    uuid.UUID `json:"id"`
+	Name      string      `json:"name"`
+	Email     string      `json:"email"`
+	EmailHash pgtype.Text `json:"email_hash"`
+	Metadata  []byte      `json:"metadata"`
+	ID        uuid.UUID   `json:"id"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, updateUser, arg.Name, arg.Email, arg.ID)
+	row := q.db.QueryRow(ctx, updateUser,
+		arg.Name,
+		arg.Email,
+		arg.EmailHash,
+		arg.Metadata,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailHash,
+		&i.Metadata,
+		&i.DuplicateOf,
+		&i.Version,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updateUserEmailCiphertext = `-- name: UpdateUserEmailCiphertext :exec
+UPDATE users
+SET
+    email = $1,
+    email_hash = $2,
+    version = version + 1
+WHERE id = $3
+`
+
+type UpdateUserEmailCiphertextParams struct {
+	Email     string      `json:"email"`
+	EmailHash pgtype.Text `json:"email_hash"`
+	ID        uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) UpdateUserEmailCiphertext(ctx context.Context, arg UpdateUserEmailCiphertextParams) error {
+	_, err := q.db.Exec(ctx, updateUserEmailCiphertext, arg.Email, arg.EmailHash, arg.ID)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET
+    role = $1,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = $2
+RETURNING id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role
+`
+
+type UpdateUserRoleParams struct {
+	Role string    `json:"role"`
+	ID   uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.Role, arg.ID)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -194,6 +526,11 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailHash,
+		&i.Metadata,
+		&i.DuplicateOf,
+		&i.Version,
+		&i.Role,
 	)
 	return i, err
 }
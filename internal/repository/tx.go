@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TxBeginner starts database transactions, e.g. a *pgxpool.Pool. It is what
+// stores writing several statements atomically take next to sqlc.DBTX.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/filter"
+	"github.com/erry-az/go-init/pkg/pagetoken"
+	"github.com/jackc/pgx/v5"
+)
+
+// userColumns are the users columns in sqlc.User field order
+const userColumns = "id, name, email, created_at, updated_at, email_hash, metadata, duplicate_of, version, role"
+
+// UserFilter lists users matching a filter expression, like ProductFilter
+type UserFilter struct {
+	db sqlc.DBTX
+}
+
+// NewUserFilter creates a user filter querying db, e.g. a *RegionRouter
+func NewUserFilter(db sqlc.DBTX) *UserFilter {
+	return &UserFilter{db: db}
+}
+
+// FilterUsers returns one page of the users matching where, oldest first
+func (f *UserFilter) FilterUsers(ctx context.Context, where filter.Expr, page pagetoken.Page, limit int32) ([]sqlc.User, error) {
+	condition, args := filter.Build(where, 5)
+	query := fmt.Sprintf("SELECT %s FROM users WHERE (created_at, id) > ($1, $2) AND (%s) ORDER BY created_at, id LIMIT $3 OFFSET $4",
+		userColumns, condition)
+
+	params := []any{page.After.CreatedAt, page.After.ID, limit, page.Offset}
+	rows, err := f.db.Query(ctx, query, append(params, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (sqlc.User, error) {
+		var u sqlc.User
+		err := row.Scan(&u.ID, &u.Name, &u.Email, &u.CreatedAt, &u.UpdatedAt, &u.EmailHash, &u.Metadata, &u.DuplicateOf, &u.Version, &u.Role)
+		return u, err
+	})
+}
+
+// CountFilteredUsers counts every user matching where
+func (f *UserFilter) CountFilteredUsers(ctx context.Context, where filter.Expr) (int64, error) {
+	condition, args := filter.Build(where, 1)
+
+	var count int64
+	err := f.db.QueryRow(ctx, "SELECT COUNT(*) FROM users WHERE "+condition, args...).Scan(&count)
+	return count, err
+}
+
+// EncryptedUserFilter is a UserFilter returning plaintext emails of users
+// stored through an EncryptedQuerier
+type EncryptedUserFilter struct {
+	*UserFilter
+	querier *EncryptedQuerier
+}
+
+// NewEncryptedUserFilter wraps filter to decrypt emails with querier
+func NewEncryptedUserFilter(filter *UserFilter, querier *EncryptedQuerier) *EncryptedUserFilter {
+	return &EncryptedUserFilter{UserFilter: filter, querier: querier}
+}
+
+func (f *EncryptedUserFilter) FilterUsers(ctx context.Context, where filter.Expr, page pagetoken.Page, limit int32) ([]sqlc.User, error) {
+	users, err := f.UserFilter.FilterUsers(ctx, where, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	return f.querier.decryptUsers(ctx, users)
+}
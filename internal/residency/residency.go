@@ -0,0 +1,86 @@
+package residency
+
+import (
+	"context"
+	"errors"
+
+	"github.com/erry-az/go-init/config"
+)
+
+// TenantMetadataKey is the gRPC metadata key carrying the tenant ID
+const TenantMetadataKey = "x-tenant-id"
+
+var (
+	// ErrCrossRegionAccess is returned when a tenant's data lives in a region this deployment does not serve
+	ErrCrossRegionAccess = errors.New("cross-region access is not allowed")
+	// ErrUnknownRegion is returned when a tenant resolves to a region without a configured database
+	ErrUnknownRegion = errors.New("region is not configured")
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant ID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ID stored in ctx, if any
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// Resolver maps tenants to their home region and enforces which regions may be served
+type Resolver struct {
+	defaultRegion string
+	tenants       map[string]string
+	served        map[string]struct{}
+}
+
+// NewResolver creates a resolver from the residency configuration
+func NewResolver(cfg config.ResidencyConfig) *Resolver {
+	served := make(map[string]struct{}, len(cfg.ServedRegions))
+	for _, region := range cfg.ServedRegions {
+		served[region] = struct{}{}
+	}
+
+	// Without an explicit list every configured region is served
+	if len(served) == 0 {
+		for region := range cfg.Regions {
+			served[region] = struct{}{}
+		}
+		if cfg.DefaultRegion != "" {
+			served[cfg.DefaultRegion] = struct{}{}
+		}
+	}
+
+	return &Resolver{
+		defaultRegion: cfg.DefaultRegion,
+		tenants:       cfg.Tenants,
+		served:        served,
+	}
+}
+
+// RegionFor returns the home region of a tenant, falling back to the default region
+func (r *Resolver) RegionFor(tenantID string) string {
+	if region, ok := r.tenants[tenantID]; ok {
+		return region
+	}
+	return r.defaultRegion
+}
+
+// Serves reports whether this deployment serves the given region
+func (r *Resolver) Serves(region string) bool {
+	_, ok := r.served[region]
+	return ok
+}
+
+// Resolve returns the region for the tenant in ctx, rejecting regions not served locally
+func (r *Resolver) Resolve(ctx context.Context) (string, error) {
+	tenantID, _ := TenantFromContext(ctx)
+	region := r.RegionFor(tenantID)
+	if !r.Serves(region) {
+		return "", ErrCrossRegionAccess
+	}
+	return region, nil
+}
@@ -0,0 +1,69 @@
+// Package sandbox marks the requests of integrators testing against the API.
+// Their data is kept in a schema of its own, purged regularly, so it never
+// shows up in production reads, and the events of their writes are dropped.
+package sandbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"strconv"
+
+	"github.com/erry-az/go-init/config"
+	"github.com/erry-az/go-init/pkg/watmil"
+)
+
+// MetadataKey is the gRPC metadata key, the X-Sandbox header over HTTP, with
+// which callers opt into sandbox mode when headers are allowed to
+const MetadataKey = "x-sandbox"
+
+type sandboxKey struct{}
+
+// With returns a copy of ctx whose queries go to the sandbox schema and
+// whose events are dropped
+func With(ctx context.Context) context.Context {
+	return watmil.WithoutEvents(context.WithValue(ctx, sandboxKey{}, true))
+}
+
+// FromContext reports whether ctx belongs to a sandbox request
+func FromContext(ctx context.Context) bool {
+	sandboxed, _ := ctx.Value(sandboxKey{}).(bool)
+	return sandboxed
+}
+
+// Detector tells sandbox requests apart from production ones by their API
+// key or, when allowed, their X-Sandbox header
+type Detector struct {
+	keys        [][sha256.Size]byte
+	headerOptIn bool
+}
+
+// NewDetector creates a detector from the sandbox configuration
+func NewDetector(cfg config.SandboxConfig) *Detector {
+	keys := make([][sha256.Size]byte, len(cfg.APIKeys))
+	for i, key := range cfg.APIKeys {
+		keys[i] = sha256.Sum256([]byte(key))
+	}
+	return &Detector{keys: keys, headerOptIn: cfg.Header}
+}
+
+// Detect reports whether a request sent with apiKey and the sandbox header
+// value header, either possibly empty, runs in sandbox mode
+func (d *Detector) Detect(apiKey, header string) bool {
+	if apiKey != "" {
+		// Keys are compared as hashes so they are compared in constant time
+		// whatever their length
+		sent := sha256.Sum256([]byte(apiKey))
+		for _, key := range d.keys {
+			if subtle.ConstantTimeCompare(sent[:], key[:]) == 1 {
+				return true
+			}
+		}
+	}
+
+	if !d.headerOptIn || header == "" {
+		return false
+	}
+	optIn, err := strconv.ParseBool(header)
+	return err == nil && optIn
+}
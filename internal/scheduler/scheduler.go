@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// JobFunc is a unit of periodic background work
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       JobFunc
+}
+
+// FailureFunc is told about every failed job run
+type FailureFunc func(ctx context.Context, job string, err error)
+
+// PanicError is the error of a job run that panicked
+type PanicError struct {
+	Value any
+	// Stack is the trace of the panicking goroutine
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Scheduler runs registered jobs on fixed intervals until its context is cancelled
+type Scheduler struct {
+	jobs      []job
+	onFailure FailureFunc
+}
+
+// New creates an empty scheduler
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers fn to run every interval. Jobs are not run on startup;
+// the first run happens after one interval has elapsed.
+func (s *Scheduler) Every(name string, interval time.Duration, fn JobFunc) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, fn: fn})
+}
+
+// OnFailure sets fn to be called after every failed or panicking job run.
+// It must be called before Run.
+func (s *Scheduler) OnFailure(fn FailureFunc) {
+	s.onFailure = fn
+}
+
+// Run starts all jobs and blocks until ctx is cancelled and every job has returned
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if err := s.run(ctx, j); err != nil {
+				slog.Error("Scheduled job failed", "job", j.name, slog.Any("error", err))
+				if s.onFailure != nil {
+					s.onFailure(ctx, j.name, err)
+				}
+				continue
+			}
+			slog.Info("Scheduled job completed", "job", j.name, "duration", time.Since(start))
+		}
+	}
+}
+
+// run runs j once, turning a panic into a *PanicError so one bad run does not
+// take the other jobs down
+func (s *Scheduler) run(ctx context.Context, j job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = &PanicError{Value: recovered, Stack: debug.Stack()}
+		}
+	}()
+	return j.fn(ctx)
+}
@@ -2,42 +2,114 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
+	"time"
 
-	"buf.build/go/protovalidate"
 	handlergrpc "github.com/erry-az/go-init/internal/handler/grpc"
-	protovalidateMidleware "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/protovalidate"
+	"github.com/erry-az/go-init/pkg/protopool"
+	"github.com/erry-az/go-init/proto/api/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 )
 
 type GRPCServer struct {
-	server *grpc.Server
+	server          *grpc.Server
+	shutdownTimeout time.Duration
 }
 
 type GRPCServices struct {
 	UserService    *handlergrpc.UserService
 	ProductService *handlergrpc.ProductService
+	AdminService   *handlergrpc.AdminService
+	// OperationService is only set when async writes are enabled
+	OperationService *handlergrpc.OperationService
 }
 
-func NewGRPCServer(services GRPCServices) (*GRPCServer, error) {
-	validator, err := protovalidate.New()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create validator: %w", err)
+// GRPCOptions configures the gRPC endpoint
+type GRPCOptions struct {
+	// UnaryInterceptors and StreamInterceptors wrap every call in the order
+	// given, the first one outermost
+	UnaryInterceptors  []grpc.UnaryServerInterceptor
+	StreamInterceptors []grpc.StreamServerInterceptor
+	// TLS serves calls over TLS, in plaintext when nil
+	TLS *tls.Config
+	// Reflection registers the server reflection service
+	Reflection bool
+	// MaxRecvMsgSize bounds request messages in bytes, MaxSendMsgSize response
+	// messages; 0 keeps the gRPC defaults
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+	// MaxConcurrentStreams bounds the calls in flight per connection, 0 leaving them unbounded
+	MaxConcurrentStreams uint32
+	// ConnectionTimeout bounds the handshake of new connections, 0 keeping the gRPC default
+	ConnectionTimeout time.Duration
+	// Keepalive and KeepalivePolicy configure pings and connection lifetimes;
+	// zero fields keep the gRPC defaults
+	Keepalive       keepalive.ServerParameters
+	KeepalivePolicy keepalive.EnforcementPolicy
+	// ShutdownTimeout bounds the graceful stop, after which the calls left
+	// are cancelled; 0 waits for them however long they take
+	ShutdownTimeout time.Duration
+}
+
+// NewGRPCServer creates the gRPC endpoint with the given services registered
+func NewGRPCServer(services GRPCServices, opts GRPCOptions) (*GRPCServer, error) {
+	// Create gRPC endpoint; list responses are built from pooled messages,
+	// reused once the RPC ended
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(opts.UnaryInterceptors...),
+		grpc.ChainStreamInterceptor(opts.StreamInterceptors...),
+		grpc.StatsHandler(protopool.StatsHandler()),
+		grpc.KeepaliveParams(opts.Keepalive),
+		grpc.KeepaliveEnforcementPolicy(opts.KeepalivePolicy),
+	}
+	if opts.TLS != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(opts.TLS)))
+	}
+	if opts.MaxRecvMsgSize > 0 {
+		options = append(options, grpc.MaxRecvMsgSize(opts.MaxRecvMsgSize))
 	}
+	if opts.MaxSendMsgSize > 0 {
+		options = append(options, grpc.MaxSendMsgSize(opts.MaxSendMsgSize))
+	}
+	if opts.MaxConcurrentStreams > 0 {
+		options = append(options, grpc.MaxConcurrentStreams(opts.MaxConcurrentStreams))
+	}
+	if opts.ConnectionTimeout > 0 {
+		options = append(options, grpc.ConnectionTimeout(opts.ConnectionTimeout))
+	}
+	server := grpc.NewServer(options...)
 
-	// Create gRPC endpoint
-	server := grpc.NewServer(
-		grpc.UnaryInterceptor(protovalidateMidleware.UnaryServerInterceptor(validator)),
-	)
+	if services.UserService != nil {
+		v1.RegisterUserServiceServer(server, services.UserService)
+	}
+	if services.ProductService != nil {
+		v1.RegisterProductServiceServer(server, services.ProductService)
+	}
+	if services.AdminService != nil {
+		v1.RegisterAdminServiceServer(server, services.AdminService)
+	}
+	if services.OperationService != nil {
+		v1.RegisterOperationServiceServer(server, services.OperationService)
+	}
+	if opts.Reflection {
+		reflection.Register(server)
+	}
 
 	return &GRPCServer{
-		server: server,
+		server:          server,
+		shutdownTimeout: opts.ShutdownTimeout,
 	}, nil
 }
 
+// Start serves on port until ctx is done, then stops gracefully, returning
+// once the calls in flight ended. It fails straight away when the port
+// cannot be bound.
 func (s *GRPCServer) Start(ctx context.Context, port string) error {
 	lis, err := net.Listen("tcp", ":"+port)
 	if err != nil {
@@ -46,9 +118,22 @@ func (s *GRPCServer) Start(ctx context.Context, port string) error {
 
 	log.Printf("gRPC endpoint starting on port %s", port)
 
-	reflection.Register(s.server)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.server.Serve(lis)
+	}()
 
-	return s.server.Serve(lis)
+	// Wait for context cancellation or a serving failure
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down gRPC endpoint...")
+	s.Stop()
+	// Serve returns nil once stopped
+	return <-serveErr
 }
 
 func (s *GRPCServer) RegisterService(services ...func(s *grpc.Server)) {
@@ -57,6 +142,21 @@ func (s *GRPCServer) RegisterService(services ...func(s *grpc.Server)) {
 	}
 }
 
+// Server returns the underlying server, e.g. to answer gRPC-Web calls on the
+// HTTP endpoint
+func (s *GRPCServer) Server() *grpc.Server {
+	return s.server
+}
+
+// Stop stops gracefully, cancelling the calls still running after the
+// shutdown timeout
 func (s *GRPCServer) Stop() {
+	if s.shutdownTimeout > 0 {
+		force := time.AfterFunc(s.shutdownTimeout, func() {
+			log.Printf("gRPC endpoint did not stop within %s, cancelling the calls left", s.shutdownTimeout)
+			s.server.Stop()
+		})
+		defer force.Stop()
+	}
 	s.server.GracefulStop()
 }
@@ -3,4 +3,8 @@ package grpc
 type Config struct {
 	Port           string `mapstructure:"port"`
 	WithValidation bool   `mapstructure:"with_validation"`
+	// ValidationMode is "strict" or "log_only", see pkg/validation
+	ValidationMode string `mapstructure:"validation_mode"`
+	// ValidationPackages lists the proto packages whose rules are compiled at boot
+	ValidationPackages []string `mapstructure:"validation_packages"`
 }
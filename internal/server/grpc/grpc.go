@@ -4,10 +4,11 @@ import (
 	"fmt"
 	"net"
 
-	"buf.build/go/protovalidate"
-	protovalidateMidleware "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/protovalidate"
+	"github.com/erry-az/go-init/internal/server/interceptor"
+	"github.com/erry-az/go-init/pkg/validation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type Server struct {
@@ -16,15 +17,22 @@ type Server struct {
 }
 
 func NewServer(config *Config) (*Server, error) {
-	validator, err := protovalidate.New()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create validator: %w", err)
+	var opts []grpc.ServerOption
+	if config.WithValidation {
+		packages := make([]protoreflect.FullName, len(config.ValidationPackages))
+		for i, pkg := range config.ValidationPackages {
+			packages[i] = protoreflect.FullName(pkg)
+		}
+
+		validator, err := validation.New(config.ValidationMode, packages...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create validator: %w", err)
+		}
+		opts = append(opts, grpc.UnaryInterceptor(interceptor.Validation(validator)))
 	}
 
 	// Create gRPC endpoint
-	server := grpc.NewServer(
-		grpc.UnaryInterceptor(protovalidateMidleware.UnaryServerInterceptor(validator)),
-	)
+	server := grpc.NewServer(opts...)
 
 	reflection.Register(server)
 
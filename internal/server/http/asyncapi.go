@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// setupAsyncAPIRoutes serves the AsyncAPI documents of the events next to
+// the Swagger UI, newest version first
+func (s *HTTPServer) setupAsyncAPIRoutes(mux *http.ServeMux) {
+	// AsyncAPI UI endpoint
+	mux.HandleFunc("/asyncapi/", s.serveAsyncAPIUI)
+
+	// AsyncAPI documents list endpoint
+	mux.HandleFunc("/asyncapi/specs", s.serveAsyncAPISpecs)
+
+	// Individual document endpoints, reusing the swagger spec handler
+	for name, path := range s.asyncAPISpecs {
+		mux.HandleFunc("/asyncapi/spec/"+name, s.serveSwaggerSpec(path))
+	}
+}
+
+func (s *HTTPServer) serveAsyncAPIUI(w http.ResponseWriter, r *http.Request) {
+	asyncAPIHTML := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Event Documentation</title>
+    <link rel="stylesheet" href="https://unpkg.com/@asyncapi/react-component@1/styles/default.min.css" />
+    <style>
+        .spec-selector {
+            margin: 20px;
+            padding: 10px;
+            background: #f8f9fa;
+            border-radius: 5px;
+        }
+        .spec-selector select {
+            padding: 8px 12px;
+            font-size: 14px;
+            border: 1px solid #ccc;
+            border-radius: 4px;
+            background: white;
+            min-width: 300px;
+        }
+    </style>
+</head>
+<body>
+    <div class="spec-selector">
+        <label for="spec-select">Select event contract version: </label>
+        <select id="spec-select" onchange="loadSpec()">
+            <option value="">Choose a document...</option>
+        </select>
+    </div>
+    <div id="asyncapi"></div>
+
+    <script src="https://unpkg.com/@asyncapi/react-component@1/browser/standalone/index.js"></script>
+    <script>
+        async function loadSpecs() {
+            try {
+                const response = await fetch('/asyncapi/specs');
+                const specs = await response.json();
+                const select = document.getElementById('spec-select');
+
+                (specs || []).forEach(spec => {
+                    const option = document.createElement('option');
+                    option.value = spec.path;
+                    option.textContent = spec.name;
+                    select.appendChild(option);
+                });
+
+                // Load the newest document by default if available
+                if (specs && specs.length > 0) {
+                    select.value = specs[0].path;
+                    loadSpec();
+                }
+            } catch (error) {
+                console.error('Failed to load asyncapi documents:', error);
+            }
+        }
+
+        function loadSpec() {
+            const specPath = document.getElementById('spec-select').value;
+            if (!specPath) return;
+
+            const container = document.getElementById('asyncapi');
+            container.innerHTML = '';
+            AsyncApiStandalone.render({
+                schema: { url: specPath, options: { method: 'GET' } },
+                config: { show: { sidebar: true } },
+            }, container);
+        }
+
+        // Load documents on page load
+        loadSpecs();
+    </script>
+</body>
+</html>`
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte(asyncAPIHTML))
+}
+
+func (s *HTTPServer) serveAsyncAPISpecs(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(s.asyncAPISpecs))
+	for name := range s.asyncAPISpecs {
+		names = append(names, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	specs := make([]SwaggerSpec, len(names))
+	for i, name := range names {
+		specs[i] = SwaggerSpec{
+			Name: name,
+			Path: "/asyncapi/spec/" + name,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(specs)
+}
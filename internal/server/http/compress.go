@@ -0,0 +1,203 @@
+package http
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported content encodings, in server preference order
+const (
+	encodingZstd = "zstd"
+	encodingGzip = "gzip"
+)
+
+// CompressionOptions configures response compression
+type CompressionOptions struct {
+	// MinSize is the smallest response body, in bytes, worth compressing
+	MinSize int
+	// ExcludedPaths lists path prefixes served uncompressed
+	ExcludedPaths []string
+}
+
+// Compression compresses responses with zstd or gzip as negotiated through
+// Accept-Encoding. Bodies smaller than MinSize are sent as-is.
+func Compression(opts CompressionOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || isExcluded(r.URL.Path, opts.ExcludedPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			w.Header().Add("Vary", "Accept-Encoding")
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: opts.MinSize}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+func isExcluded(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding picks the supported encoding with the highest q-value,
+// preferring zstd on ties. It returns "" when only identity is acceptable.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != encodingZstd && name != encodingGzip {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if q > bestQ || (q == bestQ && name == encodingZstd) {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of the body until it knows whether the
+// response reaches the size threshold, then commits to compressing or not
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	encoder io.WriteCloser
+	decided bool
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush commits to compression so streamed responses are not held back
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(len(w.buf) > 0)
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close writes any buffered body and finishes the compressed stream
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide sends the headers and the buffered body, compressed if requested and
+// the response is eligible
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+
+	header := w.Header()
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if header.Get("Content-Encoding") != "" || status == http.StatusNoContent || status == http.StatusNotModified {
+		compress = false
+	}
+
+	if compress {
+		encoder, err := newEncoder(w.encoding, w.ResponseWriter)
+		if err != nil {
+			return err
+		}
+		w.encoder = encoder
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.encoder != nil {
+		_, err := w.encoder.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func newEncoder(encoding string, w io.Writer) (io.WriteCloser, error) {
+	if encoding == encodingZstd {
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	}
+	return gzip.NewWriter(w), nil
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/erry-az/go-init/pkg/correlation"
+)
+
+// Headers of the request and correlation IDs, forwarded to gRPC metadata
+const (
+	correlationIDHeader = correlation.CorrelationIDKey
+	requestIDHeader     = correlation.RequestIDKey
+)
+
+// Correlation stores the request and correlation IDs of requests in their
+// context, taken from X-Request-Id and X-Correlation-Id or generated. The
+// headers are rewritten to the stored IDs so the gRPC endpoint is given the
+// same ones, and returned in the response, so the gateway and gRPC records
+// of a request can be matched.
+func Correlation() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ids := correlation.Resolve(r.Header.Get(requestIDHeader), r.Header.Get(correlationIDHeader))
+			r.Header.Set(requestIDHeader, ids.RequestID)
+			r.Header.Set(correlationIDHeader, ids.CorrelationID)
+			w.Header().Set(requestIDHeader, ids.RequestID)
+			w.Header().Set(correlationIDHeader, ids.CorrelationID)
+
+			next.ServeHTTP(w, r.WithContext(correlation.NewContext(r.Context(), ids)))
+		})
+	}
+}
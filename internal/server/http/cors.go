@@ -0,0 +1,144 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures cross-origin requests
+type CORSOptions struct {
+	// AllowedOrigins lists the allowed origins; a * in one matches any
+	// subdomain, e.g. https://*.example.com, and "*" alone every origin
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers callers may send, "*" allowing any
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers scripts may read
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and authorization headers
+	AllowCredentials bool
+	// MaxAge is how long browsers cache a preflight response, 0 leaving it to them
+	MaxAge time.Duration
+}
+
+// CORS answers preflight requests and marks the responses of allowed origins
+// as readable across origins. Preflights are answered without calling next,
+// so they pass no authentication. Requests of other origins are served
+// without CORS headers, leaving browsers to refuse them.
+func CORS(opts CORSOptions) (func(http.Handler) http.Handler, error) {
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	if anyOrigin && opts.AllowCredentials {
+		return nil, errors.New("credentials cannot be allowed for every origin")
+	}
+	anyHeader := slices.Contains(opts.AllowedHeaders, "*")
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+
+	allowed := func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+		for _, pattern := range opts.AllowedOrigins {
+			if matchOrigin(pattern, origin) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			if !anyOrigin {
+				header.Add("Vary", "Origin")
+			}
+			if !allowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowOrigin := func() {
+				if anyOrigin {
+					header.Set("Access-Control-Allow-Origin", "*")
+				} else {
+					header.Set("Access-Control-Allow-Origin", origin)
+				}
+				if opts.AllowCredentials {
+					header.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if !preflight {
+				allowOrigin()
+				if exposed != "" {
+					header.Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			method := r.Header.Get("Access-Control-Request-Method")
+			requested := r.Header.Get("Access-Control-Request-Headers")
+			if !slices.Contains(opts.AllowedMethods, method) || !anyHeader && !headersAllowed(requested, opts.AllowedHeaders) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			allowOrigin()
+			header.Set("Access-Control-Allow-Methods", methods)
+			if requested != "" {
+				header.Set("Access-Control-Allow-Headers", requested)
+			}
+			if opts.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}, nil
+}
+
+// matchOrigin reports whether origin matches pattern, whose * stands for one
+// or more subdomain labels
+func matchOrigin(pattern, origin string) bool {
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return strings.EqualFold(pattern, origin)
+	}
+	if len(origin) <= len(prefix)+len(suffix) {
+		return false
+	}
+	origin = strings.ToLower(origin)
+	return strings.HasPrefix(origin, strings.ToLower(prefix)) && strings.HasSuffix(origin, strings.ToLower(suffix))
+}
+
+// headersAllowed reports whether every header of the comma-separated list
+// requested is one of allowed
+func headersAllowed(requested string, allowed []string) bool {
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, name) }) {
+			return false
+		}
+	}
+	return true
+}
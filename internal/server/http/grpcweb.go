@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"google.golang.org/grpc"
+)
+
+// grpcWebContentType prefixes the content types of gRPC-Web calls, binary
+// (application/grpc-web+proto) and base64 (application/grpc-web-text)
+const grpcWebContentType = "application/grpc-web"
+
+// Headers gRPC-Web clients send besides those of the API, and the response
+// headers they read the status of calls answered without a body from
+var (
+	grpcWebRequestHeaders  = []string{"Content-Type", "X-Grpc-Web", "X-User-Agent", "Grpc-Timeout"}
+	grpcWebResponseHeaders = []string{"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"}
+)
+
+// GRPCWebOptions configures gRPC-Web calls
+type GRPCWebOptions struct {
+	// Services lists the full names of the services browsers may call, e.g.
+	// proto.api.v1.UserService
+	Services []string
+}
+
+// ServeGRPCWeb answers gRPC-Web calls of the services in opts on the HTTP
+// endpoint with server, so browsers call them without a proxy in between.
+// Calls go through the middlewares like any request and then through the
+// interceptors of server; other requests are left to the gateway. It must
+// be called before Start.
+func (s *HTTPServer) ServeGRPCWeb(server *grpc.Server, opts GRPCWebOptions) {
+	wrapped := grpcweb.WrapServer(server)
+	prefixes := make([]string, len(opts.Services))
+	for i, service := range opts.Services {
+		prefixes[i] = "/" + service + "/"
+	}
+
+	s.grpcWeb = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served := slices.ContainsFunc(prefixes, func(prefix string) bool {
+				return strings.HasPrefix(r.URL.Path, prefix)
+			})
+			if !served || !wrapped.IsGrpcWebRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.HandleGrpcWebRequest(w, r)
+		})
+	}
+}
+
+// isGRPCWeb reports whether r is a gRPC-Web call, whose body is protobuf
+func isGRPCWeb(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), grpcWebContentType)
+}
+
+// WithGRPCWeb returns opts also allowing POST and the headers gRPC-Web
+// clients send, and exposing the status headers they read
+func (opts CORSOptions) WithGRPCWeb() CORSOptions {
+	opts.AllowedMethods = appendMissing(opts.AllowedMethods, http.MethodPost)
+	if !slices.Contains(opts.AllowedHeaders, "*") {
+		opts.AllowedHeaders = appendMissing(opts.AllowedHeaders, grpcWebRequestHeaders...)
+	}
+	opts.ExposedHeaders = appendMissing(opts.ExposedHeaders, grpcWebResponseHeaders...)
+	return opts
+}
+
+// appendMissing returns a copy of list with the values it lacks appended,
+// ignoring case
+func appendMissing(list []string, values ...string) []string {
+	list = slices.Clone(list)
+	for _, value := range values {
+		if !slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, value) }) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/erry-az/go-init/internal/health"
+)
+
+// HealthReporter reports the latest dependency checks, e.g. *health.Monitor
+type HealthReporter interface {
+	Report() health.Report
+}
+
+// healthResponse is the body of /healthz and /readyz
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+type checkResult struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS float64   `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at,omitzero"`
+}
+
+// Statuses of the health responses and their checks
+const (
+	healthOK   = "ok"
+	healthFail = "fail"
+)
+
+// Liveness answers liveness probes, e.g. on /healthz: 503 once dependency
+// checks stopped completing, 200 otherwise. Unhealthy dependencies do not fail
+// it, as restarting the process would not bring them back; the checks are
+// listed all the same.
+func Liveness(reporter HealthReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := reporter.Report()
+		writeHealth(w, report, report.Live)
+	})
+}
+
+// Readiness answers readiness probes, e.g. on /readyz: 200 while every
+// dependency is healthy, 503 otherwise so load balancers route elsewhere
+func Readiness(reporter HealthReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := reporter.Report()
+		writeHealth(w, report, report.Live && report.Healthy)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, report health.Report, ok bool) {
+	response := healthResponse{
+		Status: healthOK,
+		Checks: make(map[string]checkResult, len(report.Results)),
+	}
+	for _, result := range report.Results {
+		check := checkResult{
+			Status:     healthOK,
+			Error:      result.Error,
+			DurationMS: float64(result.Duration.Microseconds()) / 1000,
+			CheckedAt:  result.CheckedAt,
+		}
+		if !result.Healthy {
+			check.Status = healthFail
+		}
+		response.Checks[result.Name] = check
+	}
+
+	status := http.StatusOK
+	if !ok {
+		response.Status = healthFail
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -1,26 +1,48 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"log"
+	"math"
+	"net"
 	"net/http"
-	"path/filepath"
+	"path"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/erry-az/go-init/docs"
+	handlergrpc "github.com/erry-az/go-init/internal/handler/grpc"
+	"github.com/erry-az/go-init/pkg/locale"
 	"github.com/erry-az/go-init/proto/api/v1"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 )
 
 type HTTPServer struct {
-	server       *http.Server
-	mux          *runtime.ServeMux
-	swaggerSpecs map[string]string
+	server        *http.Server
+	tlsConfig     *tls.Config
+	mux           *runtime.ServeMux
+	swaggerSpecs  map[string]string
+	asyncAPISpecs map[string]string
+	routes        map[string]http.Handler
+	middlewares   []func(http.Handler) http.Handler
+	// grpcWeb answers gRPC-Web calls in front of the routes, see ServeGRPCWeb
+	grpcWeb func(http.Handler) http.Handler
+	// swaggerUI serves the embedded Swagger UI assets
+	swaggerUI http.Handler
+	// shutdownTimeout bounds the graceful shutdown, see SetShutdownTimeout
+	shutdownTimeout time.Duration
 }
 
 type SwaggerSpec struct {
@@ -28,16 +50,34 @@ type SwaggerSpec struct {
 	Path string `json:"path"`
 }
 
-func NewHTTPServer(grpcPort string) (*HTTPServer, error) {
-	// Create gRPC connection for gateway
+// NewHTTPServer creates the gateway to the gRPC endpoint on grpcPort.
+// Idempotent requests are retried as retry allows while the endpoint is
+// unavailable; a MaxAttempts of 1 disables retries. Requests are served over
+// TLS with tlsConfig, or in plaintext when it is nil, which must be the config
+// the gRPC endpoint is served with. maxResponseSize is the largest response
+// message the endpoint sends in bytes, 0 when it sends any.
+func NewHTTPServer(grpcPort string, retry RetryOptions, tlsConfig *tls.Config, maxResponseSize int) (*HTTPServer, error) {
+	if maxResponseSize <= 0 {
+		maxResponseSize = math.MaxInt32
+	}
+
+	// Create gRPC connection for gateway, accepting every response the
+	// endpoint sends rather than the 4 MiB gRPC clients accept by default
 	conn, err := grpc.NewClient("localhost:"+grpcPort,
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
+		grpc.WithTransportCredentials(gatewayCredentials(tlsConfig)),
+		grpc.WithChainUnaryInterceptor(retryUnavailable(retry)),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxResponseSize)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial gRPC endpoint: %w", err)
 	}
 
 	// Create HTTP gateway mux
-	mux := runtime.NewServeMux()
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithForwardResponseOption(forwardHTTPStatus),
+		runtime.WithForwardResponseOption(varyByLocale),
+		runtime.SetQueryParameterParser(&queryParser{}),
+	)
 
 	// Register gRPC-Gateway handlers
 	err = v1.RegisterUserServiceHandler(context.Background(), mux, conn)
@@ -50,77 +90,249 @@ func NewHTTPServer(grpcPort string) (*HTTPServer, error) {
 		return nil, fmt.Errorf("failed to register product service handler: %w", err)
 	}
 
+	err = v1.RegisterAdminServiceHandler(context.Background(), mux, conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register admin service handler: %w", err)
+	}
+
+	err = v1.RegisterOperationServiceHandler(context.Background(), mux, conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register operation service handler: %w", err)
+	}
+
 	// Load swagger specifications
-	swaggerSpecs, err := loadSwaggerSpecs()
+	swaggerSpecs, err := loadSpecs(".swagger.json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to load swagger specs: %w", err)
 	}
 
+	// Load the AsyncAPI documents of the events, see cmd/asyncapigen
+	asyncAPISpecs, err := loadSpecs(".asyncapi.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load asyncapi specs: %w", err)
+	}
+
+	swaggerUI, err := swaggerUIHandler()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load swagger ui assets: %w", err)
+	}
+
 	return &HTTPServer{
-		mux:          mux,
-		swaggerSpecs: swaggerSpecs,
+		tlsConfig:     tlsConfig,
+		mux:           mux,
+		swaggerSpecs:  swaggerSpecs,
+		asyncAPISpecs: asyncAPISpecs,
+		swaggerUI:     swaggerUI,
+		routes:        make(map[string]http.Handler),
 	}, nil
 }
 
+// gatewayCredentials secures the gateway connection to the gRPC endpoint
+// served with tlsConfig. The endpoint is dialled on localhost, which its
+// certificate need not name, so it is trusted when it presents the server
+// certificate itself. The gateway presents the same certificate, which must
+// therefore be valid for client authentication under mutual TLS.
+func gatewayCredentials(tlsConfig *tls.Config) credentials.TransportCredentials {
+	if tlsConfig == nil {
+		return insecure.NewCredentials()
+	}
+
+	cert := tlsConfig.Certificates[0]
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tlsConfig.MinVersion,
+		// The certificate is verified below instead
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], cert.Certificate[0]) {
+				return errors.New("gRPC endpoint presented another certificate than the server certificate")
+			}
+			return nil
+		},
+	})
+}
+
+// Handle mounts an extra handler next to the gateway, e.g. /metrics.
+// It must be called before Start.
+func (s *HTTPServer) Handle(pattern string, handler http.Handler) {
+	s.routes[pattern] = handler
+}
+
+// Use wraps every route with middleware; the first one added runs outermost.
+// It must be called before Start.
+func (s *HTTPServer) Use(middleware func(http.Handler) http.Handler) {
+	s.middlewares = append(s.middlewares, middleware)
+}
+
+// incomingHeaderMatcher forwards application headers to gRPC metadata
+// in addition to the gateway defaults
+func incomingHeaderMatcher(key string) (string, bool) {
+	switch strings.ToLower(key) {
+	case "x-tenant-id", "x-api-key", "x-sandbox", correlationIDHeader, requestIDHeader, locale.MetadataKey:
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// forwardHTTPStatus answers with the status a handler set through
+// handlergrpc.HTTPStatusHeader, e.g. 202 for a write accepted as an operation
+func forwardHTTPStatus(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	values := md.HeaderMD.Get(handlergrpc.HTTPStatusHeader)
+	if len(values) == 0 {
+		return nil
+	}
+	w.Header().Del("Grpc-Metadata-" + handlergrpc.HTTPStatusHeader)
+
+	code, err := strconv.Atoi(values[0])
+	if err != nil {
+		return fmt.Errorf("invalid %s header %q: %w", handlergrpc.HTTPStatusHeader, values[0], err)
+	}
+	w.WriteHeader(code)
+	return nil
+}
+
+// varyByLocale tells caches that responses depend on Accept-Language, as
+// products are answered in the locale negotiated from it
+func varyByLocale(_ context.Context, w http.ResponseWriter, _ proto.Message) error {
+	w.Header().Add("Vary", "Accept-Language")
+	return nil
+}
+
+// gRPC status codes reported in the bodies of errors raised before the gateway
+const (
+	codeInvalidArgument   = 3
+	codeDeadlineExceeded  = 4
+	codeNotFound          = 5
+	codePermissionDenied  = 7
+	codeResourceExhausted = 8
+	codeInternal          = 13
+	codeUnavailable       = 14
+	codeUnauthenticated   = 16
+)
+
+// writeError answers with status and a body shaped like the gateway's errors
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"code": code, "message": message})
+}
+
+// Start serves on port until ctx is done. It fails straight away when the
+// port cannot be bound.
 func (s *HTTPServer) Start(ctx context.Context, port string) error {
 	// Create main HTTP mux to combine gRPC gateway and swagger
 	mainMux := http.NewServeMux()
 
 	// Mount gRPC gateway
-	mainMux.Handle("/", s.mux)
+	mainMux.Handle("/", markIdempotent(s.mux))
 
 	// Mount swagger endpoints
 	s.setupSwaggerRoutes(mainMux)
+	s.setupAsyncAPIRoutes(mainMux)
+
+	// Mount extra routes
+	for pattern, handler := range s.routes {
+		mainMux.Handle(pattern, handler)
+	}
+
+	var handler http.Handler = mainMux
+	if s.grpcWeb != nil {
+		handler = s.grpcWeb(handler)
+	}
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
 
 	// Create HTTP endpoint
 	s.server = &http.Server{
-		Addr:    ":" + port,
-		Handler: mainMux,
+		Addr:      ":" + port,
+		Handler:   handler,
+		TLSConfig: s.tlsConfig,
+	}
+
+	lis, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %s: %w", port, err)
 	}
 
 	log.Printf("HTTP endpoint starting on port %s", port)
 
-	// Start endpoint in goroutine
+	serveErr := make(chan error, 1)
 	go func() {
-		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Printf("HTTP endpoint error: %v", err)
+		if s.tlsConfig != nil {
+			// The certificate comes from TLSConfig
+			serveErr <- s.server.ServeTLS(lis, "", "")
+			return
 		}
+		serveErr <- s.server.Serve(lis)
 	}()
 
-	// Wait for context cancellation
-	<-ctx.Done()
+	// Wait for context cancellation or a serving failure
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("HTTP endpoint error: %w", err)
+	case <-ctx.Done():
+	}
 
 	log.Println("Shutting down HTTP endpoint...")
-	return s.server.Shutdown(context.Background())
+	return s.Stop()
+}
+
+// SetShutdownTimeout bounds how long stopping waits for the requests in
+// flight before closing their connections; 0 waits however long they take
+func (s *HTTPServer) SetShutdownTimeout(timeout time.Duration) {
+	s.shutdownTimeout = timeout
 }
 
+// Stop shuts down gracefully, closing the connections still active after
+// the shutdown timeout
 func (s *HTTPServer) Stop() error {
-	if s.server != nil {
-		return s.server.Shutdown(context.Background())
+	if s.server == nil {
+		return nil
 	}
-	return nil
+
+	ctx := context.Background()
+	if s.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.shutdownTimeout)
+		defer cancel()
+	}
+
+	err := s.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Printf("HTTP endpoint did not stop within %s, closing the connections left", s.shutdownTimeout)
+		return s.server.Close()
+	}
+	return err
 }
 
-func loadSwaggerSpecs() (map[string]string, error) {
+// loadSpecs returns the path of every generated document embedded from docs
+// whose name ends in suffix, keyed by its path without suffix
+func loadSpecs(suffix string) (map[string]string, error) {
 	specs := make(map[string]string)
-	docsDir := "docs"
 
-	err := filepath.WalkDir(docsDir, func(path string, d fs.DirEntry, err error) error {
+	err := fs.WalkDir(docs.FS, ".", func(filePath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !d.IsDir() && strings.HasSuffix(path, ".swagger.json") {
-			relPath := strings.TrimPrefix(path, docsDir+"/")
-			name := strings.TrimSuffix(filepath.Base(path), ".swagger.json")
+		if !d.IsDir() && strings.HasSuffix(filePath, suffix) {
+			name := strings.TrimSuffix(path.Base(filePath), suffix)
 
 			// Create a more descriptive name based on path
-			parts := strings.Split(relPath, "/")
-			if len(parts) > 1 {
-				name = strings.Join(parts[:len(parts)-1], "/") + "/" + name
+			if dir := path.Dir(filePath); dir != "." {
+				name = dir + "/" + name
 			}
 
-			specs[name] = path
+			specs[name] = filePath
 		}
 		return nil
 	})
@@ -136,6 +348,9 @@ func (s *HTTPServer) setupSwaggerRoutes(mux *http.ServeMux) {
 	// Swagger UI endpoint
 	mux.HandleFunc("/swagger/", s.serveSwaggerUI)
 
+	// Swagger UI assets
+	mux.Handle(swaggerUIPath, http.StripPrefix(swaggerUIPath, s.swaggerUI))
+
 	// Swagger specs list endpoint
 	mux.HandleFunc("/swagger/specs", s.serveSwaggerSpecs)
 
@@ -152,7 +367,7 @@ func (s *HTTPServer) serveSwaggerUI(w http.ResponseWriter, r *http.Request) {
 <html>
 <head>
     <title>API Documentation</title>
-    <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css" />
+    <link rel="stylesheet" type="text/css" href="` + swaggerUIPath + `swagger-ui.css" />
     <style>
         .swagger-ui .topbar { display: none; }
         .spec-selector {
@@ -181,7 +396,7 @@ func (s *HTTPServer) serveSwaggerUI(w http.ResponseWriter, r *http.Request) {
         </div>
     </div>
     
-    <script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js"></script>
+    <script src="` + swaggerUIPath + `swagger-ui-bundle.js"></script>
     <script>
         let ui;
         
@@ -266,7 +481,6 @@ func (s *HTTPServer) serveSwaggerSpecs(w http.ResponseWriter, r *http.Request) {
 func (s *HTTPServer) serveSwaggerSpec(filePath string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		http.ServeFile(w, r, filePath)
+		http.ServeFileFS(w, r, docs.FS, filePath)
 	}
 }
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/erry-az/go-init/internal/ipaccess"
+)
+
+// IPAccess refuses requests from client addresses controller rejects with 403.
+// The client address is read through X-Forwarded-For when the connection comes
+// from a trusted proxy.
+func IPAccess(controller *ipaccess.Controller) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addr := controller.ClientAddr(ipaccess.ParseAddr(r.RemoteAddr), r.Header.Values(ipaccess.ForwardedForMetadataKey))
+			if err := controller.Check(addr, r.URL.Path); err != nil {
+				writeError(w, http.StatusForbidden, codePermissionDenied, err.Error())
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
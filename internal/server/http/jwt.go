@@ -0,0 +1,44 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/erry-az/go-init/internal/auth"
+)
+
+// Authentication verifies the bearer token of requests sending one and
+// attaches its principal to the request context, answering 401 when it is
+// invalid. Requests without a token are let through: the gRPC endpoint
+// decides whether the RPCs behind the gateway need one.
+func Authentication(verifier *auth.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := auth.BearerToken(header)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request"`)
+				writeError(w, http.StatusUnauthorized, codeUnauthenticated, "authorization must be a bearer token")
+				return
+			}
+
+			principal, err := verifier.Verify(r.Context(), token)
+			switch {
+			case errors.Is(err, auth.ErrKeysUnavailable):
+				writeError(w, http.StatusServiceUnavailable, codeUnavailable, "token cannot be verified right now")
+				return
+			case err != nil:
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, codeUnauthenticated, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"expvar"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Defaults used when LimitOptions leaves a value unset. The body size matches
+// the default maximum message size of the gRPC server behind the gateway.
+const (
+	defaultMaxBodySize     = 4 << 20
+	defaultMaxJSONDepth    = 32
+	defaultBodyReadTimeout = 10 * time.Second
+)
+
+// limitRejections counts requests rejected by RequestLimits keyed by reason
+var limitRejections = expvar.NewMap("http_request_limit_rejections_total")
+
+// LimitOptions bounds the request bodies the gateway decodes
+type LimitOptions struct {
+	// MaxBodySize is the largest request body in bytes
+	MaxBodySize int64
+	// MaxJSONDepth is how deeply objects and arrays may nest in a request body
+	MaxJSONDepth int
+	// BodyReadTimeout bounds reading the whole request body, so slow clients
+	// cannot hold a handler open by trickling bytes
+	BodyReadTimeout time.Duration
+}
+
+// RequestLimits reads request bodies up front within opts and answers 413 when
+// a body is too large, 408 when it arrives too slowly and 400 when its JSON
+// nests too deeply. Accepted bodies are handed on in memory.
+func RequestLimits(opts LimitOptions) func(http.Handler) http.Handler {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultMaxBodySize
+	}
+	if opts.MaxJSONDepth <= 0 {
+		opts.MaxJSONDepth = defaultMaxJSONDepth
+	}
+	if opts.BodyReadTimeout <= 0 {
+		opts.BodyReadTimeout = defaultBodyReadTimeout
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > opts.MaxBodySize {
+				rejectRequest(w, "body_size", http.StatusRequestEntityTooLarge, codeResourceExhausted,
+					"request body exceeds "+strconv.FormatInt(opts.MaxBodySize, 10)+" bytes")
+				return
+			}
+
+			// The deadline stays on rejected requests so the server does not
+			// wait on the rest of a slow body before closing the connection
+			rc := http.NewResponseController(w)
+			deadline := rc.SetReadDeadline(time.Now().Add(opts.BodyReadTimeout)) == nil
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, opts.MaxBodySize+1))
+			switch {
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				w.Header().Set("Connection", "close")
+				rejectRequest(w, "body_timeout", http.StatusRequestTimeout, codeDeadlineExceeded,
+					"request body was not received in time")
+				return
+			case err != nil:
+				rejectRequest(w, "body_read", http.StatusBadRequest, codeInvalidArgument, "failed to read request body")
+				return
+			case int64(len(body)) > opts.MaxBodySize:
+				rejectRequest(w, "body_size", http.StatusRequestEntityTooLarge, codeResourceExhausted,
+					"request body exceeds "+strconv.FormatInt(opts.MaxBodySize, 10)+" bytes")
+				return
+			case !isGRPCWeb(r) && exceedsJSONDepth(body, opts.MaxJSONDepth):
+				rejectRequest(w, "json_depth", http.StatusBadRequest, codeInvalidArgument,
+					"request body nests deeper than "+strconv.Itoa(opts.MaxJSONDepth)+" levels")
+				return
+			}
+
+			if deadline {
+				rc.SetReadDeadline(time.Time{})
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// exceedsJSONDepth reports whether body nests objects and arrays deeper than
+// maxDepth. It only tracks brackets outside strings and leaves validating the
+// JSON to the decoder.
+func exceedsJSONDepth(body []byte, maxDepth int) bool {
+	depth, inString, escaped := 0, false, false
+	for _, c := range body {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return true
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return false
+}
+
+func rejectRequest(w http.ResponseWriter, reason string, status, code int, message string) {
+	limitRejections.Add(reason, 1)
+	writeError(w, status, code, message)
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/erry-az/go-init/pkg/correlation"
+)
+
+// LoggingOptions configures RequestLogging
+type LoggingOptions struct {
+	// SampleRate is the fraction of successful requests logged, from 0 to 1;
+	// failed requests are always logged
+	SampleRate float64
+	// Payloads logs request and response bodies
+	Payloads bool
+	// MaxPayloadSize is how many bytes of a body are logged at most
+	MaxPayloadSize int
+}
+
+// RequestLogging logs requests with their method, path, status, duration,
+// peer, correlation and request IDs, the latter stored by Correlation. 5xx
+// responses are logged as errors, 4xx as warnings, and others as they are
+// sampled.
+//
+// Bodies are captured as they are read and written rather than up front, so
+// logging them does not change how the handlers behind consume them;
+// compressed responses are not logged.
+func RequestLogging(opts LoggingOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			var reqBody *capturingReader
+			if opts.Payloads && r.Body != nil && r.Body != http.NoBody {
+				reqBody = &capturingReader{ReadCloser: r.Body, limit: opts.MaxPayloadSize}
+				r.Body = reqBody
+			}
+			lw := &loggingWriter{ResponseWriter: w, capture: opts.Payloads, limit: opts.MaxPayloadSize}
+
+			next.ServeHTTP(lw, r)
+
+			status := lw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status < http.StatusBadRequest && opts.SampleRate < 1 && rand.Float64() >= opts.SampleRate {
+				return
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Duration("duration", time.Since(start)),
+				slog.Int64("bytes", lw.written),
+				slog.String("peer", r.RemoteAddr),
+			}
+			if ids := correlation.FromContext(r.Context()); ids.RequestID != "" {
+				attrs = append(attrs,
+					slog.String("correlation_id", ids.CorrelationID),
+					slog.String("request_id", ids.RequestID),
+				)
+			}
+			if opts.Payloads {
+				if reqBody != nil {
+					attrs = append(attrs, slog.String("request", truncated(reqBody.buf, reqBody.total)))
+				}
+				if lw.Header().Get("Content-Encoding") == "" {
+					attrs = append(attrs, slog.String("response", truncated(lw.buf, lw.written)))
+				}
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+			slog.LogAttrs(r.Context(), level, "HTTP request", attrs...)
+		})
+	}
+}
+
+// truncated renders a captured body of total bytes, marking it when cut short
+func truncated(buf []byte, total int64) string {
+	if int64(len(buf)) < total {
+		return string(buf) + "…"
+	}
+	return string(buf)
+}
+
+// capture appends p to buf up to limit bytes, without a limit when it is 0
+func capture(buf, p []byte, limit int) []byte {
+	if limit > 0 {
+		p = p[:min(len(p), limit-len(buf))]
+	}
+	return append(buf, p...)
+}
+
+// capturingReader keeps the first limit bytes read from a request body
+type capturingReader struct {
+	io.ReadCloser
+	limit int
+	buf   []byte
+	total int64
+}
+
+func (r *capturingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf = capture(r.buf, p[:n], r.limit)
+	r.total += int64(n)
+	return n, err
+}
+
+// loggingWriter records the status and size of a response, and its first
+// limit bytes when capturing
+type loggingWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+	capture bool
+	limit   int
+	buf     []byte
+}
+
+func (w *loggingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *loggingWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	if w.capture {
+		w.buf = capture(w.buf, p[:n], w.limit)
+	}
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *loggingWriter) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *loggingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *loggingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// queryParser parses query parameters like the default parser, also taking
+// repeated enum fields as comma-separated lists and enum values by their
+// short lowercase names, so `status=active,draft` reads as
+// `status=PRODUCT_STATUS_ACTIVE&status=PRODUCT_STATUS_DRAFT`
+type queryParser struct {
+	runtime.DefaultQueryParser
+}
+
+func (p *queryParser) Parse(msg proto.Message, values url.Values, filter *utilities.DoubleArray) error {
+	fields := msg.ProtoReflect().Descriptor().Fields()
+
+	var normalized url.Values
+	for key, vals := range values {
+		field := fields.ByName(protoreflect.Name(key))
+		if field == nil {
+			field = fields.ByJSONName(key)
+		}
+		if field == nil || field.Kind() != protoreflect.EnumKind {
+			continue
+		}
+
+		if normalized == nil {
+			normalized = make(url.Values, len(values))
+			for k, v := range values {
+				normalized[k] = v
+			}
+		}
+		normalized[key] = enumValues(field, vals)
+	}
+	if normalized != nil {
+		values = normalized
+	}
+
+	return p.DefaultQueryParser.Parse(msg, values, filter)
+}
+
+// enumValues splits the values of the enum field on commas when repeated and
+// expands short names to the names of their enum values, leaving others for
+// the default parser to accept or reject
+func enumValues(field protoreflect.FieldDescriptor, vals []string) []string {
+	if field.IsList() {
+		var split []string
+		for _, val := range vals {
+			split = append(split, strings.Split(val, ",")...)
+		}
+		vals = split
+	}
+
+	enum := field.Enum().Values()
+	// Enum values are prefixed with the enum name, as its zero value
+	// <PREFIX>_UNSPECIFIED shows
+	var prefix string
+	if zero := enum.ByNumber(0); zero != nil && strings.HasSuffix(string(zero.Name()), "_UNSPECIFIED") {
+		prefix = strings.TrimSuffix(string(zero.Name()), "UNSPECIFIED")
+	}
+
+	expanded := make([]string, len(vals))
+	for i, val := range vals {
+		val = strings.TrimSpace(val)
+		name := protoreflect.Name(prefix + strings.ToUpper(val))
+		if prefix != "" && enum.ByName(name) != nil {
+			val = string(name)
+		}
+		expanded[i] = val
+	}
+	return expanded
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erry-az/go-init/internal/clientid"
+	"github.com/erry-az/go-init/internal/ipaccess"
+	"github.com/erry-az/go-init/pkg/ratelimit"
+)
+
+// RateLimitOptions configures RateLimit
+type RateLimitOptions struct {
+	Limiter *ratelimit.Limiter
+	// Proxies resolves the client address behind trusted proxies
+	Proxies *ipaccess.Controller
+	// ByAPIKey counts requests by accepted API key rather than by client
+	// address, which still counts the other requests
+	ByAPIKey bool
+	// Keys are the API keys callers are counted by
+	Keys clientid.Keys
+}
+
+// RateLimit answers requests over the limit of their path with 429 and a
+// Retry-After header. Requests are counted apart from the gRPC calls the
+// gateway makes for them, so a separate limiter keeps them from being
+// counted twice.
+func RateLimit(opts RateLimitOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, wait := opts.Limiter.Allow(r.URL.Path, opts.caller(r))
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, codeResourceExhausted,
+					"rate limit exceeded, retry in "+wait.Round(time.Millisecond).String())
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// caller identifies the caller requests are counted by
+func (o RateLimitOptions) caller(r *http.Request) string {
+	if o.ByAPIKey {
+		if id := o.Keys.AuthenticatedHeader(r.Header); id != clientid.Anonymous {
+			return id
+		}
+	}
+	addr := o.Proxies.ClientAddr(ipaccess.ParseAddr(r.RemoteAddr), r.Header.Values(ipaccess.ForwardedForMetadataKey))
+	return "addr:" + addr.String()
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"expvar"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Defaults used when RetryOptions leaves a value unset
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBudget   = time.Second
+	defaultRetryBackoff  = 50 * time.Millisecond
+)
+
+// gatewayRetries counts gateway calls repeated after the gRPC endpoint was unavailable
+var gatewayRetries = expvar.NewInt("http_gateway_retries_total")
+
+// RetryOptions bounds how the gateway retries idempotent requests while the
+// gRPC endpoint is unavailable, e.g. during a rolling restart
+type RetryOptions struct {
+	// MaxAttempts includes the first call; 1 disables retries
+	MaxAttempts int
+	// Budget caps the total time spent waiting between attempts
+	Budget time.Duration
+	// InitialBackoff is the longest first wait; it doubles per attempt and
+	// each wait is drawn at random below it
+	InitialBackoff time.Duration
+}
+
+type idempotentKey struct{}
+
+// markIdempotent records in the request context whether its HTTP method is
+// idempotent, so the gateway client knows which calls are safe to repeat
+func markIdempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+			r = r.WithContext(context.WithValue(r.Context(), idempotentKey{}, true))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isIdempotent(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+// retryUnavailable retries idempotent gateway calls failing with UNAVAILABLE,
+// which is also how a refused connection surfaces, with jittered exponential
+// backoff until MaxAttempts or Budget is exhausted
+func retryUnavailable(opts RetryOptions) grpc.UnaryClientInterceptor {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultRetryAttempts
+	}
+	if opts.Budget <= 0 {
+		opts.Budget = defaultRetryBudget
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = defaultRetryBackoff
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		if !isIdempotent(ctx) {
+			return err
+		}
+
+		deadline := time.Now().Add(opts.Budget)
+		backoff := opts.InitialBackoff
+		for attempt := 1; attempt < opts.MaxAttempts && status.Code(err) == codes.Unavailable; attempt++ {
+			wait := rand.N(backoff) + 1
+			if time.Now().Add(wait).After(deadline) {
+				return err
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+
+			gatewayRetries.Add(1)
+			err = invoker(ctx, method, req, reply, cc, callOpts...)
+			backoff *= 2
+		}
+		return err
+	}
+}
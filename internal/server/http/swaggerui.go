@@ -0,0 +1,21 @@
+package http
+
+import (
+	"io/fs"
+	"net/http"
+
+	"github.com/erry-az/go-init/docs"
+)
+
+// swaggerUIPath is where the embedded Swagger UI assets are served
+const swaggerUIPath = "/swagger/assets/"
+
+// swaggerUIHandler serves the Swagger UI assets embedded from docs/swagger-ui,
+// the swagger-ui-dist release `make swagger-ui` fetches
+func swaggerUIHandler() (http.Handler, error) {
+	assets, err := fs.Sub(docs.FS, "swagger-ui")
+	if err != nil {
+		return nil, err
+	}
+	return http.FileServerFS(assets), nil
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// WebhookPattern is the route webhooks of every provider are received on
+const WebhookPattern = "POST /webhooks/{provider}"
+
+// defaultWebhookBodySize is used when WebhookOptions leaves MaxBodySize unset
+const defaultWebhookBodySize = 1 << 20
+
+// ErrInvalidWebhookPayload is returned by verifiers for a correctly signed
+// body that is not a valid event
+var ErrInvalidWebhookPayload = errors.New("invalid webhook payload")
+
+// webhookRejections counts rejected webhook deliveries keyed by provider
+var webhookRejections = expvar.NewMap("http_webhook_rejections_total")
+
+// WebhookEvent is a webhook delivery whose signature was verified
+type WebhookEvent struct {
+	// ID identifies the event at the provider, which resends it with the
+	// same ID until a delivery is acknowledged
+	ID      string
+	Type    string
+	Payload []byte
+}
+
+// WebhookVerifier authenticates the deliveries of one webhook provider
+type WebhookVerifier interface {
+	// Verify checks the signature of r, whose body is body, and returns the
+	// event it carries
+	Verify(r *http.Request, body []byte) (WebhookEvent, error)
+}
+
+// WebhookReceiver stores verified events for asynchronous processing
+type WebhookReceiver interface {
+	ReceiveWebhook(ctx context.Context, provider, eventID, eventType string, payload []byte) error
+}
+
+// WebhookOptions configures the webhook endpoint
+type WebhookOptions struct {
+	// Verifiers maps provider names, the last segment of WebhookPattern, to
+	// the verifier of their deliveries
+	Verifiers map[string]WebhookVerifier
+	// MaxBodySize caps the body read to verify a signature
+	MaxBodySize int64
+}
+
+// Webhooks receives the webhooks of the providers in opts on WebhookPattern.
+// A verified event is handed to receiver and acknowledged with 200 straight
+// away, leaving its processing to the receiver, so providers do not time out
+// and resend it. A delivery that could not be stored is answered with 500 for
+// the provider to retry; the receiver must store resent events once.
+func Webhooks(receiver WebhookReceiver, opts WebhookOptions) http.Handler {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultWebhookBodySize
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider := r.PathValue("provider")
+		verifier, ok := opts.Verifiers[provider]
+		if !ok {
+			writeError(w, http.StatusNotFound, codeNotFound, "unknown webhook provider")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, opts.MaxBodySize+1))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidArgument, "failed to read webhook body")
+			return
+		}
+		if int64(len(body)) > opts.MaxBodySize {
+			webhookRejections.Add(provider, 1)
+			writeError(w, http.StatusRequestEntityTooLarge, codeResourceExhausted, "webhook body is too large")
+			return
+		}
+
+		event, err := verifier.Verify(r, body)
+		if err != nil {
+			webhookRejections.Add(provider, 1)
+			if errors.Is(err, ErrInvalidWebhookPayload) {
+				writeError(w, http.StatusBadRequest, codeInvalidArgument, err.Error())
+				return
+			}
+			writeError(w, http.StatusUnauthorized, codeUnauthenticated, err.Error())
+			return
+		}
+
+		if err := receiver.ReceiveWebhook(r.Context(), provider, event.ID, event.Type, event.Payload); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to receive webhook", "provider", provider, "event_id", event.ID, slog.Any("error", err))
+			writeError(w, http.StatusInternalServerError, codeInternal, "failed to receive webhook")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StripeSignatureHeader carries the signatures of a Stripe webhook delivery
+const StripeSignatureHeader = "Stripe-Signature"
+
+// StripeWebhooks verifies Stripe webhook deliveries. The Stripe-Signature
+// header holds the Unix time t and one or more v1 signatures, the hex
+// HMAC-SHA256 of
+//
+//	t + "." + body
+//
+// keyed with the endpoint's signing secret; several are sent while the
+// secret is rolled. Replays within the tolerance are harmless, as a resent
+// event ID is stored once.
+type StripeWebhooks struct {
+	Secret []byte
+	// Tolerance is how far t may be from the server clock
+	Tolerance time.Duration
+}
+
+// Verify implements WebhookVerifier
+func (s StripeWebhooks) Verify(r *http.Request, body []byte) (WebhookEvent, error) {
+	timestamp, signatures := parseStripeSignature(r.Header.Get(StripeSignatureHeader))
+	if timestamp == "" || len(signatures) == 0 {
+		return WebhookEvent{}, errors.New("webhook signature is required")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return WebhookEvent{}, errors.New("invalid webhook signature timestamp")
+	}
+	tolerance := s.Tolerance
+	if tolerance <= 0 {
+		tolerance = defaultSignatureTolerance
+	}
+	if skew := time.Since(time.Unix(unix, 0)); skew > tolerance || skew < -tolerance {
+		return WebhookEvent{}, errors.New("webhook signature timestamp is outside the allowed window")
+	}
+
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	verified := false
+	for _, signature := range signatures {
+		if got, err := hex.DecodeString(signature); err == nil && hmac.Equal(got, expected) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return WebhookEvent{}, errors.New("invalid webhook signature")
+	}
+
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return WebhookEvent{}, fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+	}
+	if event.ID == "" {
+		return WebhookEvent{}, fmt.Errorf("%w: missing event id", ErrInvalidWebhookPayload)
+	}
+
+	return WebhookEvent{ID: event.ID, Type: event.Type, Payload: body}, nil
+}
+
+// parseStripeSignature splits a Stripe-Signature header such as
+// t=1492774577,v1=5257a869...,v0=6ffbb59b... into its timestamp and v1
+// signatures, ignoring other schemes
+func parseStripeSignature(header string) (string, []string) {
+	var (
+		timestamp  string
+		signatures []string
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	return timestamp, signatures
+}
@@ -0,0 +1,54 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/erry-az/go-init/pkg/admission"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdmissionOptions configures the admission interceptor
+type AdmissionOptions struct {
+	Controller *admission.Controller
+	// Usecases names the queue of the calls of each service, keyed by
+	// service prefix, e.g. /proto.api.v1.UserService/; calls of other
+	// services are not queued
+	Usecases map[string]string
+}
+
+// Admission runs calls through the admission queue of the usecase their
+// service calls into. Calls the queue refuses fail with RESOURCE_EXHAUSTED
+// right away, before they hold a database connection, so callers can back
+// off; UNAVAILABLE would have the gateway retry them into the full queue.
+func Admission(opts AdmissionOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		queue := opts.queue(info.FullMethod)
+		if queue == nil {
+			return handler(ctx, req)
+		}
+
+		release, err := queue.Acquire(ctx)
+		switch {
+		case errors.Is(err, admission.ErrQueueFull), errors.Is(err, admission.ErrWaitTimeout):
+			return nil, status.Error(codes.ResourceExhausted, "server is overloaded, retry later")
+		case err != nil:
+			return nil, status.FromContextError(err).Err()
+		}
+		defer release()
+
+		return handler(ctx, req)
+	}
+}
+
+func (o AdmissionOptions) queue(method string) *admission.Queue {
+	service := method[:strings.LastIndex(method, "/")+1]
+	usecase, ok := o.Usecases[service]
+	if !ok {
+		return nil
+	}
+	return o.Controller.Queue(usecase)
+}
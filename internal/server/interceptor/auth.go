@@ -0,0 +1,88 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadataKey carries the caller's API key, the X-Api-Key header over HTTP
+const apiKeyMetadataKey = "x-api-key"
+
+// AuthOptions configures API key authentication
+type AuthOptions struct {
+	// APIKeys lists the keys callers may send
+	APIKeys []string
+	// PublicMethods lists full method names, or prefixes ending in / or .,
+	// callable without an API key
+	PublicMethods []string
+}
+
+// Auth rejects calls to methods other than the public ones that do not carry
+// one of the API keys in opts with UNAUTHENTICATED
+func Auth(opts AuthOptions) grpc.UnaryServerInterceptor {
+	keys := hashAPIKeys(opts.APIKeys)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authenticate(ctx, keys, opts.PublicMethods, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuth is Auth for streaming calls
+func StreamAuth(opts AuthOptions) grpc.StreamServerInterceptor {
+	keys := hashAPIKeys(opts.APIKeys)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authenticate(ss.Context(), keys, opts.PublicMethods, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// hashAPIKeys hashes keys so they are compared in constant time whatever their length
+func hashAPIKeys(keys []string) [][sha256.Size]byte {
+	hashes := make([][sha256.Size]byte, len(keys))
+	for i, key := range keys {
+		hashes[i] = sha256.Sum256([]byte(key))
+	}
+	return hashes
+}
+
+func authenticate(ctx context.Context, keys [][sha256.Size]byte, publicMethods []string, method string) error {
+	if isPublicMethod(method, publicMethods) {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(apiKeyMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return status.Error(codes.Unauthenticated, "API key is required")
+	}
+
+	sent := sha256.Sum256([]byte(values[0]))
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare(sent[:], key[:]) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid API key")
+}
+
+// isPublicMethod reports whether method is listed in publicMethods, by full
+// name or by a prefix ending in / or .
+func isPublicMethod(method string, publicMethods []string) bool {
+	for _, public := range publicMethods {
+		if method == public || (strings.HasSuffix(public, "/") || strings.HasSuffix(public, ".")) && strings.HasPrefix(method, public) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/pkg/correlation"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Correlation stores the request and correlation IDs of calls in their
+// context, taken from x-request-id and x-correlation-id metadata or
+// generated, so the logs, reports and events of a call carry them. The
+// request ID is returned in x-request-id header metadata and both are set
+// on the current span. It runs ahead of every other interceptor.
+func Correlation() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, ids := correlationContext(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(correlation.RequestIDKey, ids.RequestID))
+		return handler(ctx, req)
+	}
+}
+
+// StreamCorrelation is Correlation for streaming calls
+func StreamCorrelation() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, ids := correlationContext(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(correlation.RequestIDKey, ids.RequestID))
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func correlationContext(ctx context.Context) (context.Context, correlation.IDs) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ids := correlation.Resolve(firstValue(md, correlation.RequestIDKey), firstValue(md, correlation.CorrelationIDKey))
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("request_id", ids.RequestID),
+		attribute.String("correlation_id", ids.CorrelationID),
+	)
+	return correlation.NewContext(ctx, ids), ids
+}
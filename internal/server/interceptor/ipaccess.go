@@ -0,0 +1,48 @@
+package interceptor
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/erry-az/go-init/internal/ipaccess"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// IPAccess refuses calls from client addresses controller rejects. Calls
+// through the gateway are checked against the client the gateway forwarded for.
+func IPAccess(controller *ipaccess.Controller) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := checkIPAccess(ctx, controller, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamIPAccess is IPAccess for streaming calls
+func StreamIPAccess(controller *ipaccess.Controller) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkIPAccess(ss.Context(), controller, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func checkIPAccess(ctx context.Context, controller *ipaccess.Controller, method string) error {
+	var remote netip.Addr
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remote = ipaccess.ParseAddr(p.Addr.String())
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	addr := controller.ClientAddr(remote, md.Get(ipaccess.ForwardedForMetadataKey))
+	if err := controller.Check(addr, method); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/erry-az/go-init/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authorizationMetadataKey carries the caller's bearer token, forwarded by
+// the gateway from the Authorization header
+const authorizationMetadataKey = "authorization"
+
+// JWTOptions configures JWT authentication
+type JWTOptions struct {
+	Verifier *auth.Verifier
+	// Rules maps full method names to what their callers need, e.g. as
+	// annotated on the RPCs; methods without a rule need any valid token
+	Rules map[string]auth.Rule
+	// PublicMethods lists full method names, or prefixes ending in / or .,
+	// callable without a token
+	PublicMethods []string
+}
+
+// JWT authenticates calls by their bearer token and authorizes them against
+// the rule of their method, attaching the principal to the context of the
+// handler. Calls without a token are refused with UNAUTHENTICATED unless the
+// method is public, calls with an invalid token always are, and calls whose
+// token lacks the scopes of the method are refused with PERMISSION_DENIED.
+func JWT(opts JWTOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authorize(ctx, opts, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamJWT is JWT for streaming calls
+func StreamJWT(opts JWTOptions) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), opts, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authorize(ctx context.Context, opts JWTOptions, method string) (context.Context, error) {
+	rule, ok := opts.Rules[method]
+	if !ok && isPublicMethod(method, opts.PublicMethods) {
+		rule.Public = true
+	}
+
+	var principal *auth.Principal
+	md, _ := metadata.FromIncomingContext(ctx)
+	if header := firstValue(md, authorizationMetadataKey); header != "" {
+		token, ok := auth.BearerToken(header)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+		}
+
+		verified, err := opts.Verifier.Verify(ctx, token)
+		switch {
+		case errors.Is(err, auth.ErrKeysUnavailable):
+			return nil, status.Error(codes.Unavailable, "token cannot be verified right now")
+		case err != nil:
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		principal = verified
+	}
+
+	switch err := rule.Authorize(principal); {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if principal != nil {
+		ctx = auth.WithPrincipal(ctx, principal)
+	}
+	return ctx, nil
+}
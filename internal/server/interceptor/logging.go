@@ -0,0 +1,120 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/erry-az/go-init/internal/clientid"
+	"github.com/erry-az/go-init/pkg/correlation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// LoggingOptions configures the logging interceptors
+type LoggingOptions struct {
+	// SampleRate is the fraction of successful calls logged, from 0 to 1;
+	// failed calls are always logged
+	SampleRate float64
+	// Payloads logs the request and response messages of unary calls
+	Payloads bool
+	// MaxPayloadSize is how many bytes of a message are logged at most
+	MaxPayloadSize int
+}
+
+// Logging logs calls with their method, status code, duration, peer, client,
+// correlation and request IDs. Server faults such as INTERNAL are logged as
+// errors, other failures as warnings, and successful calls as they are
+// sampled.
+func Logging(opts LoggingOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		if !opts.sampled(err) {
+			return resp, err
+		}
+
+		attrs := callAttrs(ctx, info.FullMethod, start, err)
+		if opts.Payloads {
+			attrs = append(attrs, slog.String("request", opts.payload(req)))
+			if err == nil {
+				attrs = append(attrs, slog.String("response", opts.payload(resp)))
+			}
+		}
+		logCall(ctx, err, attrs)
+		return resp, err
+	}
+}
+
+// StreamLogging is Logging for streaming calls, logged once they end. Their
+// messages are not logged.
+func StreamLogging(opts LoggingOptions) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		if opts.sampled(err) {
+			logCall(ss.Context(), err, callAttrs(ss.Context(), info.FullMethod, start, err))
+		}
+		return err
+	}
+}
+
+// sampled reports whether a call ending with err is logged
+func (o LoggingOptions) sampled(err error) bool {
+	return err != nil || o.SampleRate >= 1 || rand.Float64() < o.SampleRate
+}
+
+// payload renders a message as JSON, truncated to MaxPayloadSize
+func (o LoggingOptions) payload(msg any) string {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return ""
+	}
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	if o.MaxPayloadSize > 0 && len(data) > o.MaxPayloadSize {
+		return string(data[:o.MaxPayloadSize]) + "…"
+	}
+	return string(data)
+}
+
+func callAttrs(ctx context.Context, method string, start time.Time, err error) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("client_id", clientid.FromContext(ctx)),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	if ids := correlation.FromContext(ctx); ids.RequestID != "" {
+		attrs = append(attrs,
+			slog.String("correlation_id", ids.CorrelationID),
+			slog.String("request_id", ids.RequestID),
+		)
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	return attrs
+}
+
+func logCall(ctx context.Context, err error, attrs []slog.Attr) {
+	level := slog.LevelInfo
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unimplemented:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	slog.LogAttrs(ctx, level, "gRPC call", attrs...)
+}
@@ -0,0 +1,44 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/erry-az/go-init/pkg/metrics"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics counts calls per method and status code and records their duration
+// per method, with the trace of sampled calls as exemplar
+func Metrics(registry *metrics.Registry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeCall(ctx, registry, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamMetrics is Metrics for streaming calls, recorded once they end
+func StreamMetrics(registry *metrics.Registry) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeCall(ss.Context(), registry, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeCall(ctx context.Context, registry *metrics.Registry, method string, start time.Time, err error) {
+	registry.AddCounter("grpc_server_handled", "gRPC calls handled per method and status code.",
+		map[string]string{"method": method, "code": status.Code(err).String()}, 1)
+
+	var exemplar map[string]string
+	if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
+		exemplar = map[string]string{"trace_id": span.TraceID().String()}
+	}
+	registry.ObserveHistogram("grpc_server_handling_duration_seconds", "Duration of gRPC calls per method.",
+		metrics.DefaultBuckets, map[string]string{"method": method}, time.Since(start).Seconds(), exemplar)
+}
@@ -0,0 +1,94 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/erry-az/go-init/internal/clientid"
+	"github.com/erry-az/go-init/internal/ipaccess"
+	"github.com/erry-az/go-init/pkg/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// retryAfterMetadataKey tells rate limited callers how many seconds to wait
+const retryAfterMetadataKey = "retry-after"
+
+// RateLimitOptions configures the rate limit interceptors
+type RateLimitOptions struct {
+	Limiter *ratelimit.Limiter
+	// Proxies resolves the client address behind trusted proxies, the
+	// gateway included
+	Proxies *ipaccess.Controller
+	// ByAPIKey counts calls by authenticated principal or accepted API key
+	// rather than by client address, which still counts the other calls
+	ByAPIKey bool
+	// Keys are the API keys callers are counted by
+	Keys clientid.Keys
+}
+
+// RateLimit refuses calls over the limit of their method with
+// RESOURCE_EXHAUSTED, telling callers how long to wait in retry-after
+func RateLimit(opts RateLimitOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if wait, ok := opts.allow(ctx, info.FullMethod); !ok {
+			grpc.SetHeader(ctx, retryAfter(wait))
+			return nil, rateLimited(wait)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimit is RateLimit for streaming calls, counted once when opened
+func StreamRateLimit(opts RateLimitOptions) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if wait, ok := opts.allow(ss.Context(), info.FullMethod); !ok {
+			ss.SetHeader(retryAfter(wait))
+			return rateLimited(wait)
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (o RateLimitOptions) allow(ctx context.Context, method string) (time.Duration, bool) {
+	ok, wait := o.Limiter.Allow(method, o.caller(ctx))
+	return wait, ok
+}
+
+// caller identifies the caller calls are counted by. Only callers that
+// authenticated, by a token once after jwt or by an accepted API key, are
+// counted apart from their address, as other values can be made up per call.
+func (o RateLimitOptions) caller(ctx context.Context) string {
+	if o.ByAPIKey {
+		if id := o.Keys.Authenticated(ctx); id != clientid.Anonymous {
+			return id
+		}
+	}
+
+	var remote netip.Addr
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remote = ipaccess.ParseAddr(p.Addr.String())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return "addr:" + o.Proxies.ClientAddr(remote, md.Get(ipaccess.ForwardedForMetadataKey)).String()
+}
+
+func retryAfter(wait time.Duration) metadata.MD {
+	return metadata.Pairs(retryAfterMetadataKey, strconv.Itoa(retryAfterSeconds(wait)))
+}
+
+// retryAfterSeconds rounds wait up to whole seconds, as Retry-After expects
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
+
+func rateLimited(wait time.Duration) error {
+	return status.Error(codes.ResourceExhausted, fmt.Sprintf("rate limit exceeded, retry in %s", wait.Round(time.Millisecond)))
+}
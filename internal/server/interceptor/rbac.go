@@ -0,0 +1,58 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RBACOptions configures role-based access control
+type RBACOptions struct {
+	Policy *auth.Policy
+	Roles  *auth.RoleResolver
+}
+
+// RBAC authorizes calls against the role the RBAC policy requires for their
+// method, the caller being the principal the jwt interceptor, which must run
+// first, attached. Calls of methods needing a role without a token are
+// refused with UNAUTHENTICATED, and calls whose role does not include it
+// with PERMISSION_DENIED.
+func RBAC(opts RBACOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorizeRole(ctx, opts, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRBAC is RBAC for streaming calls
+func StreamRBAC(opts RBACOptions) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorizeRole(ss.Context(), opts, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func authorizeRole(ctx context.Context, opts RBACOptions, method string) error {
+	principal, _ := auth.FromContext(ctx)
+	err := opts.Policy.Authorize(ctx, method, principal, opts.Roles.Role)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, auth.ErrRoleForbidden), errors.Is(err, auth.ErrMethodDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		slog.Error("Failed to resolve caller role", "method", method, slog.Any("error", err))
+		return status.Error(codes.Unavailable, "role cannot be resolved right now")
+	}
+}
@@ -0,0 +1,63 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/erry-az/go-init/internal/clientid"
+	"github.com/erry-az/go-init/internal/errreport"
+	"github.com/erry-az/go-init/pkg/correlation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Recovery turns a panicking handler into an INTERNAL error and reports the
+// panic with the caller's correlation and client IDs. reporter may be nil.
+func Recovery(reporter *errreport.Reporter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		ctx = reportingContext(ctx)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = recoverPanic(ctx, reporter, info.FullMethod, recovered)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery is Recovery for streaming RPCs
+func StreamRecovery(reporter *errreport.Reporter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := reportingContext(ss.Context())
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = recoverPanic(ctx, reporter, info.FullMethod, recovered)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+func recoverPanic(ctx context.Context, reporter *errreport.Reporter, method string, recovered any) error {
+	stack := debug.Stack()
+	slog.Error("Recovered panic in gRPC handler", "method", method, "panic", recovered, "stack", string(stack))
+	reporter.CapturePanic(ctx, recovered, stack, map[string]string{"grpc_method": method})
+	return status.Error(codes.Internal, "internal error")
+}
+
+// reportingContext attaches the correlation and client IDs of the incoming
+// request so reports made while handling it carry them
+func reportingContext(ctx context.Context) context.Context {
+	if id := correlation.ID(ctx); id != "" {
+		ctx = errreport.WithCorrelationID(ctx, id)
+	}
+
+	if id := clientid.FromContext(ctx); id != clientid.Anonymous {
+		ctx = errreport.WithUser(ctx, id)
+	}
+	return ctx
+}
@@ -0,0 +1,71 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/auth"
+	"github.com/erry-az/go-init/internal/residency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ResidencyOptions configures data residency routing
+type ResidencyOptions struct {
+	Resolver *residency.Resolver
+	// TenantClaim names the claim of the caller's token holding its tenant
+	TenantClaim string
+}
+
+// Residency takes the tenant of the request from the authenticated principal,
+// so it must run after jwt, and rejects requests for tenants whose home region
+// is not served by this deployment. Tenants sent in x-tenant-id metadata are
+// only accepted when they are the principal's; callers without a token carry
+// no tenant and are served by the default region.
+func Residency(opts ResidencyOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := residencyContext(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamResidency is Residency for streaming calls
+func StreamResidency(opts ResidencyOptions) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := residencyContext(ss.Context(), opts)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func residencyContext(ctx context.Context, opts ResidencyOptions) (context.Context, error) {
+	var tenantID string
+	if principal, ok := auth.FromContext(ctx); ok {
+		tenantID, _ = principal.Claims[opts.TenantClaim].(string)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if sent := firstValue(md, residency.TenantMetadataKey); sent != "" && sent != tenantID {
+		return nil, status.Error(codes.PermissionDenied, fmt.Sprintf("tenant %q is not the authenticated tenant", sent))
+	}
+
+	if tenantID != "" {
+		ctx = residency.WithTenant(ctx, tenantID)
+	}
+
+	if _, err := opts.Resolver.Resolve(ctx); err != nil {
+		if errors.Is(err, residency.ErrCrossRegionAccess) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return ctx, nil
+}
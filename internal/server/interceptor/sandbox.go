@@ -0,0 +1,53 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/sandbox"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Sandbox runs the requests of sandbox API keys, or opting in with x-sandbox
+// metadata when allowed, in sandbox mode
+func Sandbox(detector *sandbox.Detector) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(sandboxContext(ctx, detector), req)
+	}
+}
+
+// StreamSandbox is Sandbox for streaming calls
+func StreamSandbox(detector *sandbox.Detector) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := sandboxContext(ss.Context(), detector)
+		if ctx == ss.Context() {
+			return handler(srv, ss)
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func sandboxContext(ctx context.Context, detector *sandbox.Detector) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if !detector.Detect(firstValue(md, apiKeyMetadataKey), firstValue(md, sandbox.MetadataKey)) {
+		return ctx
+	}
+	return sandbox.With(ctx)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream is a server stream whose handlers see ctx
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
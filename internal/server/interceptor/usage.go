@@ -0,0 +1,38 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/erry-az/go-init/internal/clientid"
+	"github.com/erry-az/go-init/internal/usage"
+	"google.golang.org/grpc"
+)
+
+// UsageOptions configures usage recording
+type UsageOptions struct {
+	Recorder *usage.Recorder
+	// Keys are the API keys usage is attributed to
+	Keys clientid.Keys
+}
+
+// Usage records per-client request counts, errors and latency. Requests are
+// attributed to the principal jwt authenticated or to an accepted API key,
+// and recorded as anonymous otherwise, so callers cannot charge their usage
+// to others. Recording is asynchronous and never fails the request.
+func Usage(opts UsageOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		opts.Recorder.Record(usage.Record{
+			ClientID: opts.Keys.Authenticated(ctx),
+			Method:   info.FullMethod,
+			Failed:   err != nil,
+			Latency:  time.Since(start),
+			At:       start,
+		})
+
+		return resp, err
+	}
+}
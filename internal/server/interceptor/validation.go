@@ -0,0 +1,25 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/pkg/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Validation rejects requests violating their buf.validate rules with
+// InvalidArgument, unless the validator runs in log-only mode.
+func Validation(validator *validation.Validator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if msg, ok := req.(proto.Message); ok {
+			if err := validator.Validate(msg); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
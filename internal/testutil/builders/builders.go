@@ -0,0 +1,28 @@
+// Package builders provides fluent fixtures for domain entities and events,
+// e.g. builders.User().WithEmail("a@example.com").Persist(ctx, q).
+// Every builder starts from valid, unique defaults so tests only set the
+// fields they care about.
+package builders
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// sequence makes default names and emails unique within a test binary
+var sequence atomic.Int64
+
+func next() int64 {
+	return sequence.Add(1)
+}
+
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s %d", prefix, next())
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
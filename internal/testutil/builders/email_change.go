@@ -0,0 +1,92 @@
+package builders
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/google/uuid"
+)
+
+// EmailChangeRequestBuilder builds pending email changes
+type EmailChangeRequestBuilder struct {
+	request domain.EmailChangeRequest
+	token   string
+}
+
+// EmailChangeRequest starts a change for userID to a unique address,
+// valid for a day and confirmed by a unique token
+func EmailChangeRequest(userID uuid.UUID) *EmailChangeRequestBuilder {
+	n := next()
+	return &EmailChangeRequestBuilder{
+		request: domain.EmailChangeRequest{
+			UserID:    userID,
+			NewEmail:  fmt.Sprintf("changed%d@example.com", n),
+			ExpiresAt: time.Now().Add(24 * time.Hour),
+		},
+		token: fmt.Sprintf("token-%d", n),
+	}
+}
+
+func (b *EmailChangeRequestBuilder) WithNewEmail(email string) *EmailChangeRequestBuilder {
+	b.request.NewEmail = email
+	return b
+}
+
+func (b *EmailChangeRequestBuilder) WithToken(token string) *EmailChangeRequestBuilder {
+	b.token = token
+	return b
+}
+
+func (b *EmailChangeRequestBuilder) WithExpiresAt(expiresAt time.Time) *EmailChangeRequestBuilder {
+	b.request.ExpiresAt = expiresAt
+	return b
+}
+
+// Expired makes the request already past its confirmation window
+func (b *EmailChangeRequestBuilder) Expired() *EmailChangeRequestBuilder {
+	return b.WithExpiresAt(time.Now().Add(-time.Minute))
+}
+
+// Token returns the plain confirmation token to pass to ConfirmEmailChange
+func (b *EmailChangeRequestBuilder) Token() string {
+	return b.token
+}
+
+// Build returns the request without storing it
+func (b *EmailChangeRequestBuilder) Build() *domain.EmailChangeRequest {
+	request := b.request
+	return &request
+}
+
+// Persist stores the request and the hash of its token, as the user usecase
+// and the notification consumer do
+func (b *EmailChangeRequestBuilder) Persist(ctx context.Context, q sqlc.Querier) (*domain.EmailChangeRequest, error) {
+	dbRequest, err := q.CreateEmailChangeRequest(ctx, sqlc.CreateEmailChangeRequestParams{
+		UserID:    b.request.UserID,
+		NewEmail:  b.request.NewEmail,
+		ExpiresAt: timestamptz(b.request.ExpiresAt),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("persist email change request: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(b.token))
+	if _, err := q.SetEmailChangeTokenHash(ctx, sqlc.SetEmailChangeTokenHashParams{
+		ID:        dbRequest.ID,
+		TokenHash: hex.EncodeToString(sum[:]),
+	}); err != nil {
+		return nil, fmt.Errorf("persist email change token: %w", err)
+	}
+
+	return &domain.EmailChangeRequest{
+		ID:        dbRequest.ID,
+		UserID:    dbRequest.UserID,
+		NewEmail:  dbRequest.NewEmail,
+		ExpiresAt: dbRequest.ExpiresAt.Time,
+	}, nil
+}
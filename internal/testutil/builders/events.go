@@ -0,0 +1,222 @@
+package builders
+
+import (
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/proto/api/v1"
+	eventv1 "github.com/erry-az/go-init/proto/event/v1"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// envelope holds the fields shared by all events
+type envelope struct {
+	eventID       string
+	correlationID string
+	eventTime     *timestamppb.Timestamp
+	source        string
+}
+
+func newEnvelope() envelope {
+	return envelope{
+		eventID:       uuid.New().String(),
+		correlationID: uuid.New().String(),
+		eventTime:     timestamppb.Now(),
+		source:        "test",
+	}
+}
+
+// UserEventBuilder builds user events
+type UserEventBuilder struct {
+	envelope
+	user *domain.User
+}
+
+// UserEvent starts an event about user, or a default user when nil
+func UserEvent(user *domain.User) *UserEventBuilder {
+	if user == nil {
+		user = User().Build()
+	}
+	return &UserEventBuilder{envelope: newEnvelope(), user: user}
+}
+
+func (b *UserEventBuilder) WithEventID(id string) *UserEventBuilder {
+	b.eventID = id
+	return b
+}
+
+func (b *UserEventBuilder) WithCorrelationID(id string) *UserEventBuilder {
+	b.correlationID = id
+	return b
+}
+
+func (b *UserEventBuilder) Created() *eventv1.UserCreatedEvent {
+	return &eventv1.UserCreatedEvent{
+		EventId:       b.eventID,
+		User:          userToProto(b.user),
+		EventTime:     b.eventTime,
+		CorrelationId: b.correlationID,
+		Data:          &eventv1.UserCreatedEventData{Source: b.source},
+	}
+}
+
+func (b *UserEventBuilder) Updated(previous *domain.User, changedFields ...string) *eventv1.UserUpdatedEvent {
+	return &eventv1.UserUpdatedEvent{
+		EventId:       b.eventID,
+		User:          userToProto(b.user),
+		EventTime:     b.eventTime,
+		CorrelationId: b.correlationID,
+		Data: &eventv1.UserUpdatedEventData{
+			Source:        b.source,
+			PreviousUser:  userToProto(previous),
+			ChangedFields: changedFields,
+		},
+	}
+}
+
+func (b *UserEventBuilder) Deleted(reason string) *eventv1.UserDeletedEvent {
+	return &eventv1.UserDeletedEvent{
+		EventId:       b.eventID,
+		User:          userToProto(b.user),
+		EventTime:     b.eventTime,
+		CorrelationId: b.correlationID,
+		Data:          &eventv1.UserDeletedEventData{Source: b.source, Reason: reason},
+	}
+}
+
+// ProductEventBuilder builds product events
+type ProductEventBuilder struct {
+	envelope
+	product *domain.Product
+}
+
+// ProductEvent starts an event about product, or a default product when nil
+func ProductEvent(product *domain.Product) *ProductEventBuilder {
+	if product == nil {
+		product = Product().Build()
+	}
+	return &ProductEventBuilder{envelope: newEnvelope(), product: product}
+}
+
+func (b *ProductEventBuilder) WithEventID(id string) *ProductEventBuilder {
+	b.eventID = id
+	return b
+}
+
+func (b *ProductEventBuilder) WithCorrelationID(id string) *ProductEventBuilder {
+	b.correlationID = id
+	return b
+}
+
+func (b *ProductEventBuilder) Created() *eventv1.ProductCreatedEvent {
+	return &eventv1.ProductCreatedEvent{
+		EventId:       b.eventID,
+		Product:       productToProto(b.product),
+		EventTime:     b.eventTime,
+		CorrelationId: b.correlationID,
+		Data:          &eventv1.ProductCreatedEventData{Source: b.source},
+	}
+}
+
+func (b *ProductEventBuilder) Updated(previous *domain.Product, changedFields ...string) *eventv1.ProductUpdatedEvent {
+	return &eventv1.ProductUpdatedEvent{
+		EventId:       b.eventID,
+		Product:       productToProto(b.product),
+		EventTime:     b.eventTime,
+		CorrelationId: b.correlationID,
+		Data: &eventv1.ProductUpdatedEventData{
+			Source:          b.source,
+			PreviousProduct: productToProto(previous),
+			ChangedFields:   changedFields,
+		},
+	}
+}
+
+func (b *ProductEventBuilder) Deleted(reason string) *eventv1.ProductDeletedEvent {
+	return &eventv1.ProductDeletedEvent{
+		EventId:       b.eventID,
+		Product:       productToProto(b.product),
+		EventTime:     b.eventTime,
+		CorrelationId: b.correlationID,
+		Data:          &eventv1.ProductDeletedEventData{Source: b.source, Reason: reason},
+	}
+}
+
+func (b *ProductEventBuilder) PriceChanged(previousPrice string) *eventv1.ProductPriceChangedEvent {
+	return &eventv1.ProductPriceChangedEvent{
+		EventId:       b.eventID,
+		Product:       productToProto(b.product),
+		EventTime:     b.eventTime,
+		CorrelationId: b.correlationID,
+		Data: &eventv1.ProductPriceChangedEventData{
+			Source:        b.source,
+			PreviousPrice: previousPrice,
+			NewPrice:      b.product.GetPriceString(),
+		},
+	}
+}
+
+func (b *ProductEventBuilder) StatusChanged(previous domain.ProductStatus) *eventv1.ProductStatusChangedEvent {
+	return &eventv1.ProductStatusChangedEvent{
+		EventId:       b.eventID,
+		Product:       productToProto(b.product),
+		EventTime:     b.eventTime,
+		CorrelationId: b.correlationID,
+		Data: &eventv1.ProductStatusChangedEventData{
+			Source:         b.source,
+			PreviousStatus: productStatuses[previous],
+			NewStatus:      productStatuses[b.product.Status],
+		},
+	}
+}
+
+// productStatuses maps product statuses to their protobuf enum values
+var productStatuses = map[domain.ProductStatus]v1.ProductStatus{
+	domain.ProductDraft:        v1.ProductStatus_PRODUCT_STATUS_DRAFT,
+	domain.ProductActive:       v1.ProductStatus_PRODUCT_STATUS_ACTIVE,
+	domain.ProductDiscontinued: v1.ProductStatus_PRODUCT_STATUS_DISCONTINUED,
+}
+
+func userToProto(user *domain.User) *v1.User {
+	if user == nil {
+		return nil
+	}
+	return &v1.User{
+		Id:        user.ID.String(),
+		Name:      user.Name,
+		Email:     user.Email,
+		Metadata:  user.Metadata,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+		Version:   user.Version,
+		Role:      string(user.Role),
+	}
+}
+
+func productToProto(product *domain.Product) *v1.Product {
+	if product == nil {
+		return nil
+	}
+	attributes, _ := structpb.NewStruct(product.Attributes)
+	var translations map[string]*v1.ProductTranslation
+	for tag, translation := range product.Translations {
+		if translations == nil {
+			translations = make(map[string]*v1.ProductTranslation, len(product.Translations))
+		}
+		translations[tag] = &v1.ProductTranslation{Name: translation.Name, Description: translation.Description}
+	}
+	return &v1.Product{
+		Id:           product.ID.String(),
+		Name:         product.Name,
+		Description:  product.Description,
+		Translations: translations,
+		Price:        product.GetPriceString(),
+		Category:     product.Category,
+		Attributes:   attributes,
+		Metadata:     product.Metadata,
+		Status:       productStatuses[product.Status],
+		CreatedAt:    timestamppb.New(product.CreatedAt),
+		UpdatedAt:    timestamppb.New(product.UpdatedAt),
+		Version:      product.Version,
+	}
+}
@@ -0,0 +1,157 @@
+package builders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+// ProductBuilder builds products
+type ProductBuilder struct {
+	product domain.Product
+}
+
+// Product starts an active, uncategorised product with a unique name priced at 9.99
+func Product() *ProductBuilder {
+	now := time.Now()
+	return &ProductBuilder{product: domain.Product{
+		ID:           uuid.New(),
+		Name:         uniqueName("Product"),
+		Price:        decimal.RequireFromString("9.99"),
+		Attributes:   map[string]any{},
+		Metadata:     map[string]string{},
+		Translations: map[string]domain.ProductTranslation{},
+		Status:       domain.ProductActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}}
+}
+
+func (b *ProductBuilder) WithID(id uuid.UUID) *ProductBuilder {
+	b.product.ID = id
+	return b
+}
+
+func (b *ProductBuilder) WithName(name string) *ProductBuilder {
+	b.product.Name = name
+	return b
+}
+
+// WithPrice sets the price from a decimal string and panics on malformed input
+func (b *ProductBuilder) WithPrice(price string) *ProductBuilder {
+	b.product.Price = decimal.RequireFromString(price)
+	return b
+}
+
+func (b *ProductBuilder) WithCategory(category string) *ProductBuilder {
+	b.product.Category = category
+	return b
+}
+
+// WithAttribute sets a single structured attribute
+func (b *ProductBuilder) WithAttribute(key string, value any) *ProductBuilder {
+	attributes := make(map[string]any, len(b.product.Attributes)+1)
+	for k, v := range b.product.Attributes {
+		attributes[k] = v
+	}
+	attributes[key] = value
+	b.product.Attributes = attributes
+	return b
+}
+
+// WithAttributes replaces all structured attributes
+func (b *ProductBuilder) WithAttributes(attributes map[string]any) *ProductBuilder {
+	b.product.Attributes = attributes
+	return b
+}
+
+// WithDescription sets the description in the default locale
+func (b *ProductBuilder) WithDescription(description string) *ProductBuilder {
+	b.product.Description = description
+	return b
+}
+
+// WithTranslation sets the name and description in locale, which must be canonical
+func (b *ProductBuilder) WithTranslation(locale, name, description string) *ProductBuilder {
+	translations := make(map[string]domain.ProductTranslation, len(b.product.Translations)+1)
+	for k, v := range b.product.Translations {
+		translations[k] = v
+	}
+	translations[locale] = domain.ProductTranslation{Name: name, Description: description}
+	b.product.Translations = translations
+	return b
+}
+
+// WithStatus sets the lifecycle status, bypassing the allowed transitions
+func (b *ProductBuilder) WithStatus(status domain.ProductStatus) *ProductBuilder {
+	b.product.Status = status
+	return b
+}
+
+// WithMetadata replaces the user-defined metadata
+func (b *ProductBuilder) WithMetadata(metadata map[string]string) *ProductBuilder {
+	b.product.Metadata = metadata
+	return b
+}
+
+// Build returns the product without storing it
+func (b *ProductBuilder) Build() *domain.Product {
+	product := b.product
+	return &product
+}
+
+// Persist inserts the product and returns it as stored. Attributes are not
+// validated against category schemas.
+func (b *ProductBuilder) Persist(ctx context.Context, q sqlc.Querier) (*domain.Product, error) {
+	var price pgtype.Numeric
+	if err := price.Scan(b.product.Price.String()); err != nil {
+		return nil, fmt.Errorf("persist product: %w", err)
+	}
+
+	attributes, err := domain.ProductAttributes.Marshal(b.product.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("persist product: %w", err)
+	}
+
+	metadata, err := json.Marshal(b.product.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("persist product: %w", err)
+	}
+
+	translations := make(map[string]map[string]string, len(b.product.Translations))
+	for tag, translation := range b.product.Translations {
+		translations[tag] = map[string]string{"name": translation.Name, "description": translation.Description}
+	}
+	encodedTranslations, err := json.Marshal(translations)
+	if err != nil {
+		return nil, fmt.Errorf("persist product: %w", err)
+	}
+
+	dbProduct, err := q.CreateProduct(ctx, sqlc.CreateProductParams{
+		ID:           b.product.ID,
+		Name:         b.product.Name,
+		Price:        price,
+		Category:     b.product.Category,
+		Attributes:   attributes,
+		Metadata:     metadata,
+		Description:  b.product.Description,
+		Translations: encodedTranslations,
+		Status:       string(b.product.Status),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("persist product: %w", err)
+	}
+
+	product := b.Build()
+	product.CreatedAt = dbProduct.CreatedAt.Time
+	product.UpdatedAt = dbProduct.UpdatedAt.Time
+	product.Version = dbProduct.Version
+	return product, nil
+}
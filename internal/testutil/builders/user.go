@@ -0,0 +1,94 @@
+package builders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/google/uuid"
+)
+
+// UserBuilder builds users
+type UserBuilder struct {
+	user domain.User
+}
+
+// User starts a user with a unique name and email
+func User() *UserBuilder {
+	n := next()
+	now := time.Now()
+	return &UserBuilder{user: domain.User{
+		ID:        uuid.New(),
+		Name:      fmt.Sprintf("User %d", n),
+		Email:     fmt.Sprintf("user%d@example.com", n),
+		Metadata:  map[string]string{},
+		CreatedAt: now,
+		UpdatedAt: now,
+		Role:      domain.RoleViewer,
+	}}
+}
+
+func (b *UserBuilder) WithID(id uuid.UUID) *UserBuilder {
+	b.user.ID = id
+	return b
+}
+
+func (b *UserBuilder) WithName(name string) *UserBuilder {
+	b.user.Name = name
+	return b
+}
+
+func (b *UserBuilder) WithEmail(email string) *UserBuilder {
+	b.user.Email = email
+	return b
+}
+
+// WithMetadata replaces the user-defined metadata
+func (b *UserBuilder) WithMetadata(metadata map[string]string) *UserBuilder {
+	b.user.Metadata = metadata
+	return b
+}
+
+func (b *UserBuilder) WithCreatedAt(createdAt time.Time) *UserBuilder {
+	b.user.CreatedAt = createdAt
+	b.user.UpdatedAt = createdAt
+	return b
+}
+
+// Build returns the user without storing it
+func (b *UserBuilder) Build() *domain.User {
+	user := b.user
+	return &user
+}
+
+// Persist inserts the user and returns it as stored
+func (b *UserBuilder) Persist(ctx context.Context, q sqlc.Querier) (*domain.User, error) {
+	metadata, err := json.Marshal(b.user.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("persist user: %w", err)
+	}
+
+	dbUser, err := q.CreateUser(ctx, sqlc.CreateUserParams{
+		ID:       b.user.ID,
+		Name:     b.user.Name,
+		Email:    b.user.Email,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("persist user: %w", err)
+	}
+
+	return &domain.User{
+		ID:        dbUser.ID,
+		Name:      dbUser.Name,
+		Email:     dbUser.Email,
+		Metadata:  b.user.Metadata,
+		CreatedAt: dbUser.CreatedAt.Time,
+		UpdatedAt: dbUser.UpdatedAt.Time,
+		Version:   dbUser.Version,
+		Role:      domain.Role(dbUser.Role),
+	}, nil
+}
@@ -0,0 +1,152 @@
+package usage
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Record is a single observed API call
+type Record struct {
+	ClientID string
+	Method   string
+	Failed   bool
+	Latency  time.Duration
+	At       time.Time
+}
+
+type bucketKey struct {
+	clientID string
+	method   string
+	start    time.Time
+}
+
+type bucket struct {
+	requests     int64
+	errors       int64
+	totalLatency int64
+	maxLatency   int64
+}
+
+// Recorder aggregates API calls in memory and flushes them to the hourly
+// usage table in the background so request handling never waits on the database.
+type Recorder struct {
+	db            sqlc.Querier
+	records       chan Record
+	flushInterval time.Duration
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+}
+
+// NewRecorder creates a recorder with a bounded buffer of pending records
+func NewRecorder(db sqlc.Querier, bufferSize int, flushInterval time.Duration) *Recorder {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+
+	return &Recorder{
+		db:            db,
+		records:       make(chan Record, bufferSize),
+		flushInterval: flushInterval,
+		buckets:       make(map[bucketKey]*bucket),
+	}
+}
+
+// Record enqueues a call without blocking. Records are dropped when the buffer is full.
+func (r *Recorder) Record(rec Record) {
+	select {
+	case r.records <- rec:
+	default:
+		slog.Warn("API usage buffer full, dropping record", "client_id", rec.ClientID, "method", rec.Method)
+	}
+}
+
+// Run aggregates and periodically flushes records until ctx is cancelled
+func (r *Recorder) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case rec := <-r.records:
+			r.add(rec)
+		case <-ticker.C:
+			r.Flush(ctx)
+		case <-ctx.Done():
+			// Use a fresh context so the final flush is not cancelled
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			r.drain()
+			r.Flush(flushCtx)
+			cancel()
+			return nil
+		}
+	}
+}
+
+func (r *Recorder) drain() {
+	for {
+		select {
+		case rec := <-r.records:
+			r.add(rec)
+		default:
+			return
+		}
+	}
+}
+
+func (r *Recorder) add(rec Record) {
+	key := bucketKey{
+		clientID: rec.ClientID,
+		method:   rec.Method,
+		start:    rec.At.UTC().Truncate(time.Hour),
+	}
+	latency := rec.Latency.Milliseconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{}
+		r.buckets[key] = b
+	}
+	b.requests++
+	if rec.Failed {
+		b.errors++
+	}
+	b.totalLatency += latency
+	if latency > b.maxLatency {
+		b.maxLatency = latency
+	}
+}
+
+// Flush writes all aggregated buckets to the database
+func (r *Recorder) Flush(ctx context.Context) {
+	r.mu.Lock()
+	buckets := r.buckets
+	r.buckets = make(map[bucketKey]*bucket)
+	r.mu.Unlock()
+
+	for key, b := range buckets {
+		err := r.db.UpsertAPIUsageHourly(ctx, sqlc.UpsertAPIUsageHourlyParams{
+			ClientID:       key.clientID,
+			Method:         key.method,
+			BucketStart:    pgtype.Timestamptz{Time: key.start, Valid: true},
+			RequestCount:   b.requests,
+			ErrorCount:     b.errors,
+			TotalLatencyMs: b.totalLatency,
+			MaxLatencyMs:   b.maxLatency,
+		})
+		if err != nil {
+			slog.Error("Failed to flush API usage", "client_id", key.clientID, "method", key.method, slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/residency"
+	"github.com/erry-az/go-init/pkg/fanout"
+	v1 "github.com/erry-az/go-init/proto/api/v1"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// droppedWatchers counts the watchers ended for falling behind, keyed by feed
+var droppedWatchers = expvar.NewMap("change_feed_watchers_dropped_total")
+
+type changeFeedUsecase struct {
+	users    *fanout.Hub[*UserChange]
+	products *fanout.Hub[*ProductChange]
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewChangeFeedUsecase creates a change feed buffering up to buffer changes
+// per watcher
+func NewChangeFeedUsecase(buffer int) ChangeFeedUsecase {
+	return &changeFeedUsecase{
+		users:    fanout.New[*UserChange](buffer),
+		products: fanout.New[*ProductChange](buffer),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (u *changeFeedUsecase) WatchUsers(ctx context.Context, send func(*UserChange) error) error {
+	return watch(ctx, u.closed, "users", u.users, send)
+}
+
+func (u *changeFeedUsecase) WatchProducts(ctx context.Context, send func(*ProductChange) error) error {
+	return watch(ctx, u.closed, "products", u.products, send)
+}
+
+func (u *changeFeedUsecase) Close() {
+	u.closeOnce.Do(func() { close(u.closed) })
+}
+
+// tenantChange is a change of an entity owned by a tenant
+type tenantChange interface {
+	tenant() string
+}
+
+// watch sends the changes published to hub until ctx is done or the feed is
+// closed. Changes of tenants other than the one in ctx are skipped, callers
+// without a tenant only receive changes without one.
+func watch[T tenantChange](ctx context.Context, closed <-chan struct{}, feed string, hub *fanout.Hub[T], send func(T) error) error {
+	tenantID, _ := residency.TenantFromContext(ctx)
+
+	sub := hub.Subscribe()
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-closed:
+			return ErrFeedClosed
+		case change, ok := <-sub.C():
+			if !ok {
+				droppedWatchers.Add(feed, 1)
+				return ErrWatcherBehind
+			}
+			if change.tenant() != tenantID {
+				continue
+			}
+			if err := send(change); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (u *changeFeedUsecase) UserChanged(change ChangeType, eventID, tenantID string, occurredAt time.Time, user *v1.User) error {
+	id, err := uuid.Parse(user.GetId())
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q: %w", user.GetId(), err)
+	}
+
+	u.users.Publish(&UserChange{
+		Type:       change,
+		EventID:    eventID,
+		TenantID:   tenantID,
+		OccurredAt: occurredAt,
+		User: &domain.User{
+			ID:        id,
+			Name:      user.Name,
+			Email:     user.Email,
+			Metadata:  user.Metadata,
+			CreatedAt: user.CreatedAt.AsTime(),
+			UpdatedAt: user.UpdatedAt.AsTime(),
+			Version:   user.Version,
+			Role:      domain.Role(user.Role),
+		},
+	})
+	return nil
+}
+
+func (u *changeFeedUsecase) ProductChanged(change ChangeType, eventID, tenantID string, occurredAt time.Time, product *v1.Product) error {
+	id, err := uuid.Parse(product.GetId())
+	if err != nil {
+		return fmt.Errorf("invalid product ID %q: %w", product.GetId(), err)
+	}
+	price, err := decimal.NewFromString(product.Price)
+	if err != nil {
+		return fmt.Errorf("invalid price %q of product %s: %w", product.Price, id, err)
+	}
+
+	u.products.Publish(&ProductChange{
+		Type:       change,
+		EventID:    eventID,
+		TenantID:   tenantID,
+		OccurredAt: occurredAt,
+		Product: &domain.Product{
+			ID:           id,
+			Name:         product.Name,
+			Description:  product.Description,
+			Translations: translationsFromProto(product.Translations),
+			Price:        price,
+			Category:     product.Category,
+			Attributes:   product.Attributes.AsMap(),
+			Metadata:     product.Metadata,
+			Status:       productStatusFromProto[product.Status],
+			CreatedAt:    product.CreatedAt.AsTime(),
+			UpdatedAt:    product.UpdatedAt.AsTime(),
+			Version:      product.Version,
+		},
+	})
+	return nil
+}
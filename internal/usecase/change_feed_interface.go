@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/erry-az/go-init/internal/domain"
+	v1 "github.com/erry-az/go-init/proto/api/v1"
+)
+
+// ErrWatcherBehind ends a watch whose caller read its changes slower than
+// they came, so some were dropped
+var ErrWatcherBehind = errors.New("watcher fell behind the changes, list again and watch anew")
+
+// ErrFeedClosed ends the watches of an instance shutting down
+var ErrFeedClosed = errors.New("change feed closed as the server is shutting down, watch again")
+
+// ChangeFeedUsecase streams the changes of users and products to the
+// watchers of this endpoint instance, fed by the events it receives
+type ChangeFeedUsecase interface {
+	// WatchUsers sends every user change of the tenant in ctx from now on
+	// until ctx is done, send fails or the caller falls behind, returning
+	// ErrWatcherBehind
+	WatchUsers(ctx context.Context, send func(*UserChange) error) error
+	// WatchProducts is WatchUsers for products
+	WatchProducts(ctx context.Context, send func(*ProductChange) error) error
+	// Close ends every watch with ErrFeedClosed, as do the watches started
+	// afterwards. Watches otherwise last as long as their callers, which
+	// would hold up a graceful stop.
+	Close()
+
+	// UserChanged and ProductChanged hand the change reported by an event to
+	// the watchers of tenantID, the entity being the one the event carries
+	UserChanged(change ChangeType, eventID, tenantID string, occurredAt time.Time, user *v1.User) error
+	ProductChanged(change ChangeType, eventID, tenantID string, occurredAt time.Time, product *v1.Product) error
+}
+
+// ChangeType is what happened to the entity of a change
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+type UserChange struct {
+	Type       ChangeType
+	EventID    string
+	TenantID   string
+	OccurredAt time.Time
+	User       *domain.User
+}
+
+type ProductChange struct {
+	Type       ChangeType
+	EventID    string
+	TenantID   string
+	OccurredAt time.Time
+	Product    *domain.Product
+}
+
+func (c *UserChange) tenant() string    { return c.TenantID }
+func (c *ProductChange) tenant() string { return c.TenantID }
@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/pkg/correlation"
+	"github.com/google/uuid"
+)
+
+// correlationID returns the correlation ID events published while handling
+// ctx carry: that of the request or message being handled, or a new one for
+// work none started, e.g. scheduled jobs
+func correlationID(ctx context.Context) string {
+	if id := correlation.ID(ctx); id != "" {
+		return id
+	}
+	return uuid.New().String()
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/pkg/jsonschema"
+	"github.com/erry-az/go-init/pkg/watmil"
+	"github.com/google/uuid"
+)
+
+type customEventUsecase struct {
+	publisher *cqrs.EventBus
+	schemas   CustomEventSchemas
+}
+
+// NewCustomEventUsecase creates a custom event usecase publishing through
+// publisher the types schemas has a schema for
+func NewCustomEventUsecase(publisher *cqrs.EventBus, schemas CustomEventSchemas) CustomEventUsecase {
+	return &customEventUsecase{
+		publisher: publisher,
+		schemas:   schemas,
+	}
+}
+
+func (u *customEventUsecase) PublishCustomEvent(ctx context.Context, req *PublishCustomEventRequest) (*PublishCustomEventResponse, error) {
+	if !watmil.ValidCustomEventType(req.Type) {
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid event type %q", req.Type))
+	}
+	if !u.schemas.Has(req.Type) {
+		return nil, domain.NewValidationError(fmt.Sprintf("event type %q has no schema", req.Type))
+	}
+
+	payload := req.Payload
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	if err := u.schemas.Validate(req.Type, payload); err != nil {
+		var violations *jsonschema.ValidationError
+		if !errors.As(err, &violations) {
+			return nil, domain.NewInternalError(fmt.Sprintf("failed to validate payload: %v", err))
+		}
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid payload for event type %q: %v", req.Type, err))
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid payload: %v", err))
+	}
+
+	event := &watmil.CustomEvent{
+		Type:    req.Type,
+		ID:      uuid.NewString(),
+		Payload: encoded,
+	}
+	if req.OrderingKey != "" {
+		ctx = watmil.WithOrderingKey(ctx, req.OrderingKey)
+	}
+	if err := u.publisher.Publish(ctx, event); err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to publish event: %v", err))
+	}
+
+	return &PublishCustomEventResponse{
+		EventID: event.ID,
+		Topic:   watmil.EventTopic(event.Name()),
+	}, nil
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"context"
+)
+
+// CustomEventUsecase publishes custom events, which have a JSON Schema rather
+// than a proto message of their own, e.g. for integration experiments
+type CustomEventUsecase interface {
+	PublishCustomEvent(ctx context.Context, req *PublishCustomEventRequest) (*PublishCustomEventResponse, error)
+}
+
+// CustomEventSchemas validates the payloads of custom events by type, e.g. a
+// *jsonschema.Registry. Violations are reported as *jsonschema.ValidationError.
+type CustomEventSchemas interface {
+	Has(eventType string) bool
+	Validate(eventType string, payload any) error
+}
+
+type PublishCustomEventRequest struct {
+	// Type names the event, e.g. order_shipped
+	Type    string
+	Payload map[string]any
+	// OrderingKey delivers the events sharing it in publish order on brokers
+	// supporting ordering; empty leaves the event unordered
+	OrderingKey string
+}
+
+type PublishCustomEventResponse struct {
+	EventID string
+	// Topic is the topic the event was published to, e.g. events.custom_order_shipped
+	Topic string
+}
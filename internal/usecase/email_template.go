@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxEmailTemplateSize bounds each template of an email
+const maxEmailTemplateSize = 256 << 10
+
+// sampleEmailUser is the user of the sample data previews render with
+var sampleEmailUser = map[string]any{
+	"id":    "00000000-0000-0000-0000-000000000000",
+	"name":  "Ada Lovelace",
+	"email": "ada@example.com",
+}
+
+// emailTemplateSampleData is the data previews render the templates the
+// notification consumer sends with, shaped like the data it sends them with
+var emailTemplateSampleData = map[string]map[string]any{
+	domain.EmailTemplateWelcome: {
+		"user": sampleEmailUser,
+	},
+	domain.EmailTemplateEmailChangeConfirmation: {
+		"user":               sampleEmailUser,
+		"new_email":          "ada.lovelace@example.com",
+		"confirmation_token": "sample-confirmation-token",
+		"expires_at":         "2026-01-02T15:04:05Z",
+	},
+	domain.EmailTemplateEmailChanged: {
+		"user":      sampleEmailUser,
+		"old_email": "ada@example.com",
+		"new_email": "ada.lovelace@example.com",
+	},
+}
+
+type emailTemplateUsecase struct {
+	db sqlc.Querier
+}
+
+// NewEmailTemplateUsecase creates a usecase storing email templates in db
+func NewEmailTemplateUsecase(db sqlc.Querier) EmailTemplateUsecase {
+	return &emailTemplateUsecase{
+		db: db,
+	}
+}
+
+func (u *emailTemplateUsecase) ListTemplates(ctx context.Context) ([]*domain.EmailTemplate, error) {
+	rows, err := u.db.ListEmailTemplates(ctx)
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to list email templates: %v", err))
+	}
+
+	templates := make([]*domain.EmailTemplate, len(rows))
+	for i, row := range rows {
+		templates[i] = u.mapDBTemplateToDomain(row)
+	}
+	return templates, nil
+}
+
+func (u *emailTemplateUsecase) GetTemplate(ctx context.Context, name string, version int32) (*domain.EmailTemplate, error) {
+	var row sqlc.EmailTemplate
+	var err error
+	if version == 0 {
+		row, err = u.db.GetEmailTemplate(ctx, name)
+	} else {
+		row, err = u.db.GetEmailTemplateVersion(ctx, sqlc.GetEmailTemplateVersionParams{
+			Name:    name,
+			Version: version,
+		})
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NewNotFoundError("email template not found")
+		}
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to get email template: %v", err))
+	}
+
+	return u.mapDBTemplateToDomain(row), nil
+}
+
+func (u *emailTemplateUsecase) ListVersions(ctx context.Context, name string) ([]*domain.EmailTemplate, error) {
+	rows, err := u.db.ListEmailTemplateVersions(ctx, name)
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to list email template versions: %v", err))
+	}
+	if len(rows) == 0 {
+		return nil, domain.NewNotFoundError("email template not found")
+	}
+
+	templates := make([]*domain.EmailTemplate, len(rows))
+	for i, row := range rows {
+		templates[i] = u.mapDBTemplateToDomain(row)
+	}
+	return templates, nil
+}
+
+func (u *emailTemplateUsecase) CreateTemplate(ctx context.Context, req *SaveEmailTemplateRequest) (*domain.EmailTemplate, error) {
+	if err := validateEmailTemplate(req); err != nil {
+		return nil, err
+	}
+
+	row, err := u.db.CreateEmailTemplate(ctx, sqlc.CreateEmailTemplateParams{
+		Name:     req.Name,
+		Subject:  req.Subject,
+		HtmlBody: req.HTMLBody,
+		TextBody: req.TextBody,
+	})
+	if err != nil {
+		if _, ok := uniqueViolation(err); ok {
+			return nil, domain.NewConflictError("email template already exists")
+		}
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to create email template: %v", err))
+	}
+
+	slog.Info("Email template created", "name", row.Name)
+	return u.mapDBTemplateToDomain(row), nil
+}
+
+func (u *emailTemplateUsecase) UpdateTemplate(ctx context.Context, req *SaveEmailTemplateRequest) (*domain.EmailTemplate, error) {
+	if err := validateEmailTemplate(req); err != nil {
+		return nil, err
+	}
+
+	row, err := u.db.CreateEmailTemplateVersion(ctx, sqlc.CreateEmailTemplateVersionParams{
+		Name:     req.Name,
+		Subject:  req.Subject,
+		HtmlBody: req.HTMLBody,
+		TextBody: req.TextBody,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NewNotFoundError("email template not found")
+		}
+		if _, ok := uniqueViolation(err); ok {
+			// Another update took the version number first
+			return nil, domain.NewConflictError("email template was updated concurrently, retry the update")
+		}
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to update email template: %v", err))
+	}
+
+	slog.Info("Email template updated", "name", row.Name, "version", row.Version)
+	return u.mapDBTemplateToDomain(row), nil
+}
+
+func (u *emailTemplateUsecase) DeleteTemplate(ctx context.Context, name string) error {
+	rows, err := u.db.DeleteEmailTemplate(ctx, name)
+	if err != nil {
+		return domain.NewInternalError(fmt.Sprintf("failed to delete email template: %v", err))
+	}
+	if rows == 0 {
+		return domain.NewNotFoundError("email template not found")
+	}
+
+	slog.Info("Email template deleted", "name", name, "versions", rows)
+	return nil
+}
+
+func (u *emailTemplateUsecase) PreviewTemplate(ctx context.Context, req *PreviewEmailTemplateRequest) (*domain.RenderedEmail, error) {
+	var tmpl *domain.EmailTemplate
+	if req.Draft != nil {
+		draft := *req.Draft
+		draft.Name = req.Name
+		if err := validateEmailTemplate(&draft); err != nil {
+			return nil, err
+		}
+		tmpl = &domain.EmailTemplate{
+			Name:     draft.Name,
+			Subject:  draft.Subject,
+			HTMLBody: draft.HTMLBody,
+			TextBody: draft.TextBody,
+		}
+	} else {
+		var err error
+		tmpl, err = u.GetTemplate(ctx, req.Name, req.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data := req.Data
+	if data == nil {
+		data = emailTemplateSampleData[req.Name]
+	}
+	return tmpl.Render(data)
+}
+
+func (u *emailTemplateUsecase) Render(ctx context.Context, name string, data map[string]any) (*domain.RenderedEmail, error) {
+	tmpl, err := u.GetTemplate(ctx, name, 0)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Render(data)
+}
+
+// validateEmailTemplate checks req before its templates are stored or rendered
+func validateEmailTemplate(req *SaveEmailTemplateRequest) error {
+	for _, field := range []struct{ name, value string }{
+		{"subject", req.Subject},
+		{"HTML body", req.HTMLBody},
+		{"text body", req.TextBody},
+	} {
+		if len(field.value) > maxEmailTemplateSize {
+			return domain.NewValidationError(fmt.Sprintf("template %s must not exceed %d bytes", field.name, maxEmailTemplateSize))
+		}
+	}
+
+	tmpl := domain.EmailTemplate{
+		Name:     req.Name,
+		Subject:  req.Subject,
+		HTMLBody: req.HTMLBody,
+		TextBody: req.TextBody,
+	}
+	return tmpl.Validate()
+}
+
+func (u *emailTemplateUsecase) mapDBTemplateToDomain(row sqlc.EmailTemplate) *domain.EmailTemplate {
+	return &domain.EmailTemplate{
+		Name:      row.Name,
+		Version:   row.Version,
+		Subject:   row.Subject,
+		HTMLBody:  row.HtmlBody,
+		TextBody:  row.TextBody,
+		CreatedAt: row.CreatedAt.Time,
+	}
+}
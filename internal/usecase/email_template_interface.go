@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/domain"
+)
+
+// EmailTemplateUsecase defines the business logic interface for managing the
+// templates of outbound emails
+type EmailTemplateUsecase interface {
+	// ListTemplates returns the latest version of every template
+	ListTemplates(ctx context.Context) ([]*domain.EmailTemplate, error)
+	// GetTemplate returns a version of a template, the latest when version is 0
+	GetTemplate(ctx context.Context, name string, version int32) (*domain.EmailTemplate, error)
+	// ListVersions returns every version of a template, newest first
+	ListVersions(ctx context.Context, name string) ([]*domain.EmailTemplate, error)
+	CreateTemplate(ctx context.Context, req *SaveEmailTemplateRequest) (*domain.EmailTemplate, error)
+	// UpdateTemplate stores a new version of a template, sent from then on
+	UpdateTemplate(ctx context.Context, req *SaveEmailTemplateRequest) (*domain.EmailTemplate, error)
+	// DeleteTemplate deletes a template with all its versions
+	DeleteTemplate(ctx context.Context, name string) error
+	PreviewTemplate(ctx context.Context, req *PreviewEmailTemplateRequest) (*domain.RenderedEmail, error)
+	// Render renders the latest version of a template for sending
+	Render(ctx context.Context, name string, data map[string]any) (*domain.RenderedEmail, error)
+}
+
+type SaveEmailTemplateRequest struct {
+	Name     string
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+type PreviewEmailTemplateRequest struct {
+	Name string
+	// Version previews a stored version, the latest when 0
+	Version int32
+	// Draft previews unsaved content instead of a stored version
+	Draft *SaveEmailTemplateRequest
+	// Data is what the template is rendered with; nil uses sample data of
+	// the emails the template is sent for
+	Data map[string]any
+}
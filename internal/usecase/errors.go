@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgUniqueViolation is the Postgres SQLSTATE for unique_violation
+const pgUniqueViolation = "23505"
+
+// uniqueConstraintErrors maps unique constraint names to the conflict message
+// reported to clients. Constraints not listed get a generic message.
+var uniqueConstraintErrors = map[string]string{
+	"users_pkey":                "user already exists",
+	"users_email_key":           "user with this email already exists",
+	"users_email_canonical_key": "user with this email already exists",
+	"users_email_hash_key":      "user with this email already exists",
+	"products_pkey":             "product already exists",
+}
+
+// uniqueViolation returns the violated constraint name if err is a unique violation
+func uniqueViolation(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgUniqueViolation {
+		return "", false
+	}
+	return pgErr.ConstraintName, true
+}
+
+// conflictError converts a unique violation into a domain conflict error, or returns nil
+func conflictError(err error) *domain.DomainError {
+	constraint, ok := uniqueViolation(err)
+	if !ok {
+		return nil
+	}
+
+	if message, ok := uniqueConstraintErrors[constraint]; ok {
+		return domain.NewConflictError(message)
+	}
+	return domain.NewConflictError("resource already exists")
+}
+
+// isEmailConflict reports whether err is a unique violation on the user email
+func isEmailConflict(err error) bool {
+	constraint, ok := uniqueViolation(err)
+	return ok && (constraint == "users_email_key" || constraint == "users_email_canonical_key" || constraint == "users_email_hash_key")
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/erry-az/go-init/internal/domain"
+)
+
+type eventHistoryUsecase struct {
+	history EventHistoryReader
+}
+
+// NewEventHistoryUsecase creates a new event history usecase instance
+func NewEventHistoryUsecase(history EventHistoryReader) EventHistoryUsecase {
+	return &eventHistoryUsecase{history: history}
+}
+
+func (u *eventHistoryUsecase) ListEvents(ctx context.Context, req *ListEventsRequest) (*ListEventsResponse, error) {
+	id, err := domain.ParseID(req.AggregateType, req.AggregateID)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	// Page tokens hold the sequence of the last event listed
+	var afterID int64
+	if req.PageToken != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(req.PageToken)
+		if err != nil {
+			return nil, domain.NewValidationError("invalid page token")
+		}
+		afterID, err = strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || afterID < 0 {
+			return nil, domain.NewValidationError("invalid page token")
+		}
+	}
+
+	events, err := u.history.List(ctx, req.AggregateType, id.String(), afterID, pageSize+1)
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to list events: %v", err))
+	}
+
+	// Check if there are more pages
+	var nextPageToken string
+	if len(events) > int(pageSize) {
+		events = events[:pageSize]
+		last := events[len(events)-1]
+		nextPageToken = base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(last.Sequence, 10)))
+	}
+
+	return &ListEventsResponse{
+		Events:        events,
+		NextPageToken: nextPageToken,
+	}, nil
+}
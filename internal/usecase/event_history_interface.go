@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/domain"
+)
+
+// EventHistoryUsecase lists the events recorded about users and products
+type EventHistoryUsecase interface {
+	ListEvents(ctx context.Context, req *ListEventsRequest) (*ListEventsResponse, error)
+}
+
+// EventHistoryReader reads the history of an aggregate, e.g. an *eventhistory.Store
+type EventHistoryReader interface {
+	List(ctx context.Context, aggregateType, aggregateID string, afterID int64, limit int32) ([]*domain.EntityEvent, error)
+}
+
+type ListEventsRequest struct {
+	// AggregateType is domain.AggregateUser or domain.AggregateProduct
+	AggregateType string
+	AggregateID   string
+	PageSize      int32
+	PageToken     string
+}
+
+type ListEventsResponse struct {
+	Events        []*domain.EntityEvent
+	NextPageToken string
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/netip"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/ipaccess"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+)
+
+// maxIPRuleDescriptionLength bounds the note stored with a rule
+const maxIPRuleDescriptionLength = 255
+
+type ipAccessUsecase struct {
+	db         sqlc.Querier
+	controller *ipaccess.Controller
+}
+
+// NewIPAccessUsecase creates a usecase storing runtime rules in db and
+// applying them to controller
+func NewIPAccessUsecase(db sqlc.Querier, controller *ipaccess.Controller) IPAccessUsecase {
+	return &ipAccessUsecase{
+		db:         db,
+		controller: controller,
+	}
+}
+
+func (u *ipAccessUsecase) ListRules(ctx context.Context) ([]*domain.IPRule, error) {
+	static := u.controller.Static()
+	rules := make([]*domain.IPRule, 0, len(static.Deny)+len(static.AdminAllow))
+	for _, network := range static.Deny {
+		rules = append(rules, &domain.IPRule{List: domain.IPRuleListDeny, Network: network, Static: true})
+	}
+	for _, network := range static.AdminAllow {
+		rules = append(rules, &domain.IPRule{List: domain.IPRuleListAdminAllow, Network: network, Static: true})
+	}
+
+	rows, err := u.db.ListIPAccessRules(ctx)
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to list IP access rules: %v", err))
+	}
+	for _, row := range rows {
+		rule, err := u.mapDBRuleToDomain(row)
+		if err != nil {
+			// Rows are validated on write, so this only skips hand-edited ones
+			slog.Warn("Skipping invalid IP access rule", "list", row.List, "network", row.Network, slog.Any("error", err))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (u *ipAccessUsecase) AddRule(ctx context.Context, req *AddIPRuleRequest) (*domain.IPRule, error) {
+	if !req.List.IsValid() {
+		return nil, domain.NewValidationError(fmt.Sprintf("unknown IP rule list %q", req.List))
+	}
+	network, err := ipaccess.ParsePrefix(req.Network)
+	if err != nil {
+		return nil, domain.NewValidationError(err.Error())
+	}
+	if len(req.Description) > maxIPRuleDescriptionLength {
+		return nil, domain.NewValidationError(fmt.Sprintf("description must not exceed %d characters", maxIPRuleDescriptionLength))
+	}
+
+	row, err := u.db.CreateIPAccessRule(ctx, sqlc.CreateIPAccessRuleParams{
+		List:        string(req.List),
+		Network:     network.String(),
+		Description: req.Description,
+	})
+	if err != nil {
+		if _, ok := uniqueViolation(err); ok {
+			return nil, domain.NewConflictError("IP access rule already exists")
+		}
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to create IP access rule: %v", err))
+	}
+
+	if err := u.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	slog.Info("IP access rule added", "list", row.List, "network", row.Network)
+	return u.mapDBRuleToDomain(row)
+}
+
+func (u *ipAccessUsecase) DeleteRule(ctx context.Context, list domain.IPRuleList, network string) error {
+	if !list.IsValid() {
+		return domain.NewValidationError(fmt.Sprintf("unknown IP rule list %q", list))
+	}
+	prefix, err := ipaccess.ParsePrefix(network)
+	if err != nil {
+		return domain.NewValidationError(err.Error())
+	}
+
+	rows, err := u.db.DeleteIPAccessRule(ctx, sqlc.DeleteIPAccessRuleParams{
+		List:    string(list),
+		Network: prefix.String(),
+	})
+	if err != nil {
+		return domain.NewInternalError(fmt.Sprintf("failed to delete IP access rule: %v", err))
+	}
+	if rows == 0 {
+		if u.isStatic(list, prefix) {
+			return domain.NewValidationError("configured IP access rules cannot be deleted at runtime")
+		}
+		return domain.NewNotFoundError("IP access rule not found")
+	}
+
+	slog.Info("IP access rule deleted", "list", list, "network", prefix)
+	return u.Reload(ctx)
+}
+
+func (u *ipAccessUsecase) Reload(ctx context.Context) error {
+	rows, err := u.db.ListIPAccessRules(ctx)
+	if err != nil {
+		return domain.NewInternalError(fmt.Sprintf("failed to load IP access rules: %v", err))
+	}
+
+	var rules ipaccess.Rules
+	for _, row := range rows {
+		rule, err := u.mapDBRuleToDomain(row)
+		if err != nil {
+			slog.Warn("Skipping invalid IP access rule", "list", row.List, "network", row.Network, slog.Any("error", err))
+			continue
+		}
+		switch rule.List {
+		case domain.IPRuleListDeny:
+			rules.Deny = append(rules.Deny, rule.Network)
+		case domain.IPRuleListAdminAllow:
+			rules.AdminAllow = append(rules.AdminAllow, rule.Network)
+		}
+	}
+
+	u.controller.SetDynamic(rules)
+	return nil
+}
+
+// isStatic reports whether network is configured on list
+func (u *ipAccessUsecase) isStatic(list domain.IPRuleList, network netip.Prefix) bool {
+	static := u.controller.Static()
+	networks := static.Deny
+	if list == domain.IPRuleListAdminAllow {
+		networks = static.AdminAllow
+	}
+	for _, n := range networks {
+		if n == network {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *ipAccessUsecase) mapDBRuleToDomain(row sqlc.IpAccessRule) (*domain.IPRule, error) {
+	list := domain.IPRuleList(row.List)
+	if !list.IsValid() {
+		return nil, fmt.Errorf("unknown list %q", row.List)
+	}
+	network, err := ipaccess.ParsePrefix(row.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.IPRule{
+		List:        list,
+		Network:     network,
+		Description: row.Description,
+		CreatedAt:   row.CreatedAt.Time,
+	}, nil
+}
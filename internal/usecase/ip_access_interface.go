@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/domain"
+)
+
+// IPAccessUsecase defines the business logic interface for managing network access rules
+type IPAccessUsecase interface {
+	ListRules(ctx context.Context) ([]*domain.IPRule, error)
+	AddRule(ctx context.Context, req *AddIPRuleRequest) (*domain.IPRule, error)
+	DeleteRule(ctx context.Context, list domain.IPRuleList, network string) error
+	// Reload applies the stored rules, picking up changes made by other instances
+	Reload(ctx context.Context) error
+}
+
+type AddIPRuleRequest struct {
+	List domain.IPRuleList
+	// Network is a CIDR network or a single address
+	Network     string
+	Description string
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/residency"
+	"github.com/erry-az/go-init/internal/sandbox"
+	"github.com/erry-az/go-init/pkg/pagetoken"
+	commandv1 "github.com/erry-az/go-init/proto/command/v1"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type jobUsecase struct {
+	db         sqlc.Querier
+	commands   *cqrs.CommandBus
+	products   ProductUsecase
+	pageTokens pagetoken.Codec
+}
+
+// NewJobUsecase creates a new job usecase instance. Jobs are stored through
+// db, which must not be routed by tenant region but may be by sandbox mode,
+// and their work is queued on commands, which may be nil where no work is
+// queued, and performed by the product usecase. pageTokens encodes and
+// decodes the page tokens of ListJobs.
+func NewJobUsecase(db sqlc.Querier, commands *cqrs.CommandBus, products ProductUsecase, pageTokens pagetoken.Codec) JobUsecase {
+	return &jobUsecase{
+		db:         db,
+		commands:   commands,
+		products:   products,
+		pageTokens: pageTokens,
+	}
+}
+
+func (u *jobUsecase) EnqueueReindexProducts(ctx context.Context, batchSize int32) (*domain.Job, error) {
+	// Reindexing republishes products, which sandbox requests never do
+	if sandbox.FromContext(ctx) {
+		return nil, domain.NewForbiddenError("reindexing products is not available in sandbox mode")
+	}
+
+	tenantID, _ := residency.TenantFromContext(ctx)
+
+	return u.enqueue(ctx, domain.JobReindexProducts, func(jobID string) any {
+		return &commandv1.ReindexProductsCommand{
+			ReindexId: jobID,
+			BatchSize: batchSize,
+			TenantId:  tenantID,
+		}
+	})
+}
+
+// enqueue records a pending job of kind and sends the command built for it
+func (u *jobUsecase) enqueue(ctx context.Context, kind string, command func(jobID string) any) (*domain.Job, error) {
+	dbJob, err := u.db.CreateJob(ctx, sqlc.CreateJobParams{
+		ID:   uuid.New(),
+		Kind: kind,
+	})
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to create job: %v", err))
+	}
+
+	if err := u.commands.Send(ctx, command(dbJob.ID.String())); err != nil {
+		u.fail(ctx, dbJob.ID, "failed to enqueue job")
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to enqueue job: %v", err))
+	}
+
+	return mapDBJobToDomain(dbJob), nil
+}
+
+func (u *jobUsecase) GetJob(ctx context.Context, jobID string) (*domain.Job, error) {
+	id, err := domain.ParseID("job", jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbJob, err := u.db.GetJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NewNotFoundError("job not found")
+		}
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to get job: %v", err))
+	}
+
+	return mapDBJobToDomain(dbJob), nil
+}
+
+func (u *jobUsecase) ListJobs(ctx context.Context, req *ListJobsRequest) (*ListJobsResponse, error) {
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	page, err := decodePageToken(ctx, u.pageTokens, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	dbJobs, err := u.db.ListJobs(ctx, sqlc.ListJobsParams{
+		Kind:           req.Kind,
+		Status:         string(req.Status),
+		AfterCreatedAt: pgtype.Timestamptz{Time: page.After.CreatedAt, Valid: true},
+		AfterID:        page.After.ID,
+		PageSize:       pageSize + 1,
+		PageOffset:     page.Offset,
+	})
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to list jobs: %v", err))
+	}
+
+	// Check if there are more pages
+	hasNextPage := len(dbJobs) > int(pageSize)
+	if hasNextPage {
+		dbJobs = dbJobs[:pageSize]
+	}
+
+	jobs := make([]*domain.Job, len(dbJobs))
+	for i, dbJob := range dbJobs {
+		jobs[i] = mapDBJobToDomain(dbJob)
+	}
+
+	var nextPageToken string
+	if hasNextPage {
+		last := dbJobs[len(dbJobs)-1]
+		nextPageToken = u.pageTokens.Encode(pagetoken.Cursor{CreatedAt: last.CreatedAt.Time, ID: last.ID})
+	}
+
+	return &ListJobsResponse{
+		Jobs:          jobs,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (u *jobUsecase) ProcessReindexProducts(ctx context.Context, cmd *commandv1.ReindexProductsCommand) error {
+	// Jobs are not routed by region, the products of the tenant are
+	if cmd.TenantId != "" {
+		ctx = residency.WithTenant(ctx, cmd.TenantId)
+	}
+
+	return u.run(ctx, cmd.ReindexId, func(ctx context.Context, progress ProgressFunc) (string, error) {
+		_, err := u.products.ReindexProducts(ctx, cmd.ReindexId, cmd.BatchSize, progress)
+		return "", err
+	})
+}
+
+// run performs work for a queued job, recording its progress and outcome.
+// work returns a reference to what it produced, if anything. Rejections such
+// as validation errors fail the job; other errors are recorded and returned
+// so the command is redelivered and the job started again.
+func (u *jobUsecase) run(ctx context.Context, jobID string, work func(ctx context.Context, progress ProgressFunc) (string, error)) error {
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		slog.Warn("Dropping command with invalid job ID", "job_id", jobID)
+		return nil
+	}
+
+	dbJob, err := u.db.GetJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			slog.Warn("Dropping command of unknown job", "job_id", jobID)
+			return nil
+		}
+		return fmt.Errorf("get job %s: %w", jobID, err)
+	}
+
+	// A redelivered command must not repeat finished work
+	if mapDBJobToDomain(dbJob).IsDone() {
+		return nil
+	}
+
+	if err := u.db.StartJob(ctx, id); err != nil {
+		return fmt.Errorf("start job %s: %w", jobID, err)
+	}
+
+	resultRef, err := work(ctx, func(ctx context.Context, processed, total int64) {
+		err := u.db.UpdateJobProgress(ctx, sqlc.UpdateJobProgressParams{
+			Processed: processed,
+			Total:     pgtype.Int8{Int64: total, Valid: total > 0},
+			ID:        id,
+		})
+		if err != nil {
+			// Progress is informative, the work goes on without it
+			slog.Warn("Failed to record job progress", "job_id", jobID, slog.Any("error", err))
+		}
+	})
+	if err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) && domainErr.Type != domain.ErrorTypeInternal {
+			return u.fail(ctx, id, domainErr.Message)
+		}
+		recordErr := u.db.RecordJobError(ctx, sqlc.RecordJobErrorParams{
+			Error: pgtype.Text{String: err.Error(), Valid: true},
+			ID:    id,
+		})
+		if recordErr != nil {
+			slog.Error("Failed to record job error", "job_id", jobID, slog.Any("error", recordErr))
+		}
+		return err
+	}
+
+	err = u.db.CompleteJob(ctx, sqlc.CompleteJobParams{
+		ResultRef: pgtype.Text{String: resultRef, Valid: resultRef != ""},
+		ID:        id,
+	})
+	if err != nil {
+		return fmt.Errorf("complete job %s: %w", jobID, err)
+	}
+	return nil
+}
+
+// fail marks the job as failed with reason
+func (u *jobUsecase) fail(ctx context.Context, id uuid.UUID, reason string) error {
+	err := u.db.FailJob(ctx, sqlc.FailJobParams{
+		Error: pgtype.Text{String: reason, Valid: true},
+		ID:    id,
+	})
+	if err != nil {
+		slog.Error("Failed to mark job as failed", "job_id", id, slog.Any("error", err))
+		return fmt.Errorf("fail job %s: %w", id, err)
+	}
+	return nil
+}
+
+func mapDBJobToDomain(dbJob sqlc.Job) *domain.Job {
+	job := &domain.Job{
+		ID:        dbJob.ID,
+		Kind:      dbJob.Kind,
+		Status:    domain.JobStatus(dbJob.Status),
+		Processed: dbJob.Processed,
+		Total:     dbJob.Total.Int64,
+		Attempts:  dbJob.Attempts,
+		Error:     dbJob.Error.String,
+		ResultRef: dbJob.ResultRef.String,
+		CreatedAt: dbJob.CreatedAt.Time,
+		UpdatedAt: dbJob.UpdatedAt.Time,
+	}
+	if dbJob.StartedAt.Valid {
+		startedAt := dbJob.StartedAt.Time
+		job.StartedAt = &startedAt
+	}
+	if dbJob.FinishedAt.Valid {
+		finishedAt := dbJob.FinishedAt.Time
+		job.FinishedAt = &finishedAt
+	}
+	return job
+}
@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/domain"
+	commandv1 "github.com/erry-az/go-init/proto/command/v1"
+)
+
+// ProgressFunc reports that background work went through processed of total items
+type ProgressFunc func(ctx context.Context, processed, total int64)
+
+// JobUsecase queues background work and tracks it as jobs
+type JobUsecase interface {
+	// EnqueueReindexProducts queues a ReindexProducts command tracked by the returned job
+	EnqueueReindexProducts(ctx context.Context, batchSize int32) (*domain.Job, error)
+	GetJob(ctx context.Context, jobID string) (*domain.Job, error)
+	ListJobs(ctx context.Context, req *ListJobsRequest) (*ListJobsResponse, error)
+
+	// Command handlers performing the queued work
+	ProcessReindexProducts(ctx context.Context, cmd *commandv1.ReindexProductsCommand) error
+}
+
+// ListJobsRequest lists jobs oldest first, optionally of one kind or status
+type ListJobsRequest struct {
+	Kind      string
+	Status    domain.JobStatus
+	PageSize  int32
+	PageToken string
+}
+
+type ListJobsResponse struct {
+	Jobs          []*domain.Job
+	NextPageToken string
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/domain"
+)
+
+// encodeMetadata validates metadata and encodes it for its JSONB column
+func encodeMetadata(metadata map[string]string) ([]byte, error) {
+	if err := domain.ValidateMetadata(metadata); err != nil {
+		return nil, err
+	}
+	if len(metadata) == 0 {
+		return []byte("{}"), nil
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to encode metadata: %v", err))
+	}
+	return encoded, nil
+}
+
+// decodeMetadata decodes a JSONB metadata column; unreadable values decode as empty
+func decodeMetadata(data []byte) map[string]string {
+	metadata := map[string]string{}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &metadata)
+	}
+	return metadata
+}
@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/internal/residency"
+	"github.com/erry-az/go-init/internal/sandbox"
+	commandv1 "github.com/erry-az/go-init/proto/command/v1"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type operationUsecase struct {
+	db       sqlc.Querier
+	commands *cqrs.CommandBus
+	users    UserUsecase
+	products ProductUsecase
+}
+
+// NewOperationUsecase creates a new operation usecase instance. Operations are
+// stored through db, which must not be routed by tenant region but may be by
+// sandbox mode, and processed by the user and product usecases.
+func NewOperationUsecase(db sqlc.Querier, commands *cqrs.CommandBus, users UserUsecase, products ProductUsecase) OperationUsecase {
+	return &operationUsecase{
+		db:       db,
+		commands: commands,
+		users:    users,
+		products: products,
+	}
+}
+
+func (u *operationUsecase) EnqueueCreateUser(ctx context.Context, name, email string, metadata map[string]string) (*domain.Operation, error) {
+	tenantID, _ := residency.TenantFromContext(ctx)
+
+	return u.enqueue(ctx, domain.OperationCreateUser, func(operationID string) any {
+		return &commandv1.CreateUserCommand{
+			OperationId: operationID,
+			Name:        name,
+			Email:       email,
+			Metadata:    metadata,
+			TenantId:    tenantID,
+			Sandbox:     sandbox.FromContext(ctx),
+		}
+	})
+}
+
+func (u *operationUsecase) EnqueueCreateProduct(ctx context.Context, name, description, price, category string, attributes map[string]any, metadata map[string]string, translations map[string]domain.ProductTranslation, status domain.ProductStatus) (*domain.Operation, error) {
+	tenantID, _ := residency.TenantFromContext(ctx)
+
+	attributesStruct, err := structpb.NewStruct(attributes)
+	if err != nil {
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid attributes: %v", err))
+	}
+
+	return u.enqueue(ctx, domain.OperationCreateProduct, func(operationID string) any {
+		return &commandv1.CreateProductCommand{
+			OperationId:  operationID,
+			Name:         name,
+			Description:  description,
+			Price:        price,
+			Category:     category,
+			Attributes:   attributesStruct,
+			Metadata:     metadata,
+			Translations: translationsToProto(translations),
+			Status:       productStatusToProto[status],
+			TenantId:     tenantID,
+			Sandbox:      sandbox.FromContext(ctx),
+		}
+	})
+}
+
+// enqueue records a pending operation of kind and sends the command built for it
+func (u *operationUsecase) enqueue(ctx context.Context, kind string, command func(operationID string) any) (*domain.Operation, error) {
+	dbOperation, err := u.db.CreateOperation(ctx, sqlc.CreateOperationParams{
+		ID:   uuid.New(),
+		Kind: kind,
+	})
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to create operation: %v", err))
+	}
+
+	if err := u.commands.Send(ctx, command(dbOperation.ID.String())); err != nil {
+		u.fail(ctx, dbOperation.ID, "failed to enqueue operation")
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to enqueue operation: %v", err))
+	}
+
+	return mapDBOperationToDomain(dbOperation), nil
+}
+
+func (u *operationUsecase) GetOperation(ctx context.Context, operationID string) (*domain.Operation, error) {
+	id, err := domain.ParseID("operation", operationID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbOperation, err := u.db.GetOperation(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NewNotFoundError("operation not found")
+		}
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to get operation: %v", err))
+	}
+
+	return mapDBOperationToDomain(dbOperation), nil
+}
+
+func (u *operationUsecase) ProcessCreateUser(ctx context.Context, cmd *commandv1.CreateUserCommand) error {
+	return u.process(ctx, cmd.OperationId, cmd.TenantId, cmd.Sandbox, operationWrite{
+		create: func(ctx context.Context) (uuid.UUID, error) {
+			user, err := u.users.CreateUser(ctx, cmd.Name, cmd.Email, cmd.Metadata)
+			if err != nil {
+				return uuid.Nil, err
+			}
+			return user.ID, nil
+		},
+		get: func(ctx context.Context, id uuid.UUID) error {
+			_, err := u.users.GetUser(ctx, id.String())
+			return err
+		},
+	})
+}
+
+func (u *operationUsecase) ProcessCreateProduct(ctx context.Context, cmd *commandv1.CreateProductCommand) error {
+	return u.process(ctx, cmd.OperationId, cmd.TenantId, cmd.Sandbox, operationWrite{
+		create: func(ctx context.Context) (uuid.UUID, error) {
+			product, err := u.products.CreateProduct(ctx, cmd.Name, cmd.Description, cmd.Price, cmd.Category, cmd.Attributes.AsMap(), cmd.Metadata, translationsFromProto(cmd.Translations), productStatusFromProto[cmd.Status])
+			if err != nil {
+				return uuid.Nil, err
+			}
+			return product.ID, nil
+		},
+		get: func(ctx context.Context, id uuid.UUID) error {
+			_, err := u.products.GetProduct(ctx, id.String())
+			return err
+		},
+	})
+}
+
+// operationWrite is the write of an operation. create makes the resource
+// under the ID of the operation; get looks up a resource by ID, returning a
+// not found error when there is none.
+type operationWrite struct {
+	create func(ctx context.Context) (uuid.UUID, error)
+	get    func(ctx context.Context, id uuid.UUID) error
+}
+
+// process runs write for a pending operation and records its outcome.
+// Rejections such as validation errors or conflicts fail the operation;
+// other errors are returned so the command is redelivered.
+//
+// The resource is created with the operation ID as its ID, so a command
+// redelivered after its write but before the operation was completed finds
+// the resource instead of creating it twice or failing on the conflict.
+func (u *operationUsecase) process(ctx context.Context, operationID, tenantID string, sandboxed bool, write operationWrite) error {
+	id, err := uuid.Parse(operationID)
+	if err != nil {
+		slog.Warn("Dropping command with invalid operation ID", "operation_id", operationID)
+		return nil
+	}
+
+	// Operations of sandbox requests are kept with the sandbox data
+	if sandboxed {
+		ctx = sandbox.With(ctx)
+	}
+
+	dbOperation, err := u.db.GetOperation(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			slog.Warn("Dropping command of unknown operation", "operation_id", operationID)
+			return nil
+		}
+		return fmt.Errorf("get operation %s: %w", operationID, err)
+	}
+
+	// A redelivered command must not repeat a finished write
+	if mapDBOperationToDomain(dbOperation).IsDone() {
+		return nil
+	}
+
+	if tenantID != "" {
+		ctx = residency.WithTenant(ctx, tenantID)
+	}
+
+	written, err := u.written(ctx, id, write)
+	if err != nil {
+		return err
+	}
+	if written {
+		return u.complete(ctx, id, id)
+	}
+
+	resourceID, err := write.create(withResourceID(ctx, id))
+	if err != nil {
+		// A concurrent delivery of the command may have won the write
+		if written, werr := u.written(ctx, id, write); werr == nil && written {
+			return u.complete(ctx, id, id)
+		}
+
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) && domainErr.Type != domain.ErrorTypeInternal {
+			return u.fail(ctx, id, domainErr.Message)
+		}
+		return err
+	}
+
+	return u.complete(ctx, id, resourceID)
+}
+
+// written reports whether the resource of operation id was already created
+func (u *operationUsecase) written(ctx context.Context, id uuid.UUID, write operationWrite) (bool, error) {
+	err := write.get(ctx, id)
+	if err == nil {
+		return true, nil
+	}
+
+	var domainErr *domain.DomainError
+	if errors.As(err, &domainErr) && domainErr.Type == domain.ErrorTypeNotFound {
+		return false, nil
+	}
+	return false, fmt.Errorf("get resource of operation %s: %w", id, err)
+}
+
+// complete marks the operation as succeeded with the resource it created
+func (u *operationUsecase) complete(ctx context.Context, id, resourceID uuid.UUID) error {
+	err := u.db.CompleteOperation(ctx, sqlc.CompleteOperationParams{
+		ResourceID: pgtype.UUID{Bytes: resourceID, Valid: true},
+		ID:         id,
+	})
+	if err != nil {
+		return fmt.Errorf("complete operation %s: %w", id, err)
+	}
+	return nil
+}
+
+// resourceIDKey carries the ID a resource created for an operation gets
+type resourceIDKey struct{}
+
+// withResourceID makes resources created with ctx take id as their ID
+func withResourceID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, resourceIDKey{}, id)
+}
+
+// resourceID returns the ID set by withResourceID, or fallback without one
+func resourceID(ctx context.Context, fallback uuid.UUID) uuid.UUID {
+	if id, ok := ctx.Value(resourceIDKey{}).(uuid.UUID); ok {
+		return id
+	}
+	return fallback
+}
+
+// fail marks the operation as failed with reason
+func (u *operationUsecase) fail(ctx context.Context, id uuid.UUID, reason string) error {
+	err := u.db.FailOperation(ctx, sqlc.FailOperationParams{
+		Error: pgtype.Text{String: reason, Valid: true},
+		ID:    id,
+	})
+	if err != nil {
+		slog.Error("Failed to mark operation as failed", "operation_id", id, slog.Any("error", err))
+		return fmt.Errorf("fail operation %s: %w", id, err)
+	}
+	return nil
+}
+
+func mapDBOperationToDomain(dbOperation sqlc.Operation) *domain.Operation {
+	operation := &domain.Operation{
+		ID:        dbOperation.ID,
+		Kind:      dbOperation.Kind,
+		Status:    domain.OperationStatus(dbOperation.Status),
+		Error:     dbOperation.Error.String,
+		CreatedAt: dbOperation.CreatedAt.Time,
+		UpdatedAt: dbOperation.UpdatedAt.Time,
+	}
+	if dbOperation.ResourceID.Valid {
+		resourceID := uuid.UUID(dbOperation.ResourceID.Bytes)
+		operation.ResourceID = &resourceID
+	}
+	return operation
+}
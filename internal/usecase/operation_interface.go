@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/domain"
+	commandv1 "github.com/erry-az/go-init/proto/command/v1"
+)
+
+// OperationUsecase accepts writes for asynchronous processing and tracks them as operations
+type OperationUsecase interface {
+	EnqueueCreateUser(ctx context.Context, name, email string, metadata map[string]string) (*domain.Operation, error)
+	EnqueueCreateProduct(ctx context.Context, name, description, price, category string, attributes map[string]any, metadata map[string]string, translations map[string]domain.ProductTranslation, status domain.ProductStatus) (*domain.Operation, error)
+	GetOperation(ctx context.Context, operationID string) (*domain.Operation, error)
+
+	// Command handlers performing the accepted writes
+	ProcessCreateUser(ctx context.Context, cmd *commandv1.CreateUserCommand) error
+	ProcessCreateProduct(ctx context.Context, cmd *commandv1.CreateProductCommand) error
+}
@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/clientid"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/pkg/pagetoken"
+)
+
+// decodePageToken returns where the page of a list request's token starts
+func decodePageToken(ctx context.Context, codec pagetoken.Codec, token string) (pagetoken.Page, error) {
+	page, err := codec.Decode(token, clientid.FromContext(ctx))
+	if err != nil {
+		return pagetoken.Page{}, domain.NewValidationError(err.Error())
+	}
+	return page, nil
+}
@@ -3,40 +3,134 @@ package usecase
 import (
 	"context"
 	"database/sql"
-	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/erry-az/go-init/internal/domain"
 	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/filter"
+	"github.com/erry-az/go-init/pkg/jsonschema"
+	"github.com/erry-az/go-init/pkg/pagetoken"
 	"github.com/erry-az/go-init/proto/api/v1"
 	eventv1 "github.com/erry-az/go-init/proto/event/v1"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// AttributeValidator validates product attributes against the schema of their category,
+// e.g. a *jsonschema.Registry. Violations are reported as *jsonschema.ValidationError.
+type AttributeValidator interface {
+	Validate(category string, attributes any) error
+}
+
+// ProductFilterer lists products matching a filter expression, e.g. a *repository.ProductFilter
+type ProductFilterer interface {
+	FilterProducts(ctx context.Context, where filter.Expr, order filter.Order, page pagetoken.Page, limit int32) ([]sqlc.Product, error)
+	CountFilteredProducts(ctx context.Context, where filter.Expr) (int64, error)
+}
+
+// Product fields accepted in list filters
+var (
+	productNameField       = filter.Field{Column: "name", Type: filter.TypeString}
+	productPriceField      = filter.Field{Column: "price", Type: filter.TypeNumber}
+	productCategoryField   = filter.Field{Column: "category", Type: filter.TypeString}
+	productAttributesField = filter.Field{Column: "attributes", Type: filter.TypeMap}
+	productStatusField     = filter.Field{Column: "status", Type: filter.TypeString}
+	// productTranslationsField holds the names of other locales, searched as
+	// translations.<locale>.name
+	productTranslationsField = filter.Field{Column: "translations", Type: filter.TypeMap}
+
+	productFilterFields = map[string]filter.Field{
+		"name":        productNameField,
+		"description": {Column: "description", Type: filter.TypeString},
+		"price":       productPriceField,
+		"category":    productCategoryField,
+		"attributes":  productAttributesField,
+		"status":      productStatusField,
+		"metadata":    {Column: "metadata", Type: filter.TypeMap},
+		"created_at":  {Column: "created_at", Type: filter.TypeTimestamp},
+		"updated_at":  {Column: "updated_at", Type: filter.TypeTimestamp},
+	}
+
+	// productOrderFields are the fields products can be listed in order of,
+	// each backed by a (field, id) index
+	productOrderFields = map[string]filter.Field{
+		"name":       productNameField,
+		"price":      productPriceField,
+		"created_at": productFilterFields["created_at"],
+		"updated_at": productFilterFields["updated_at"],
+	}
+)
+
 type productUsecase struct {
-	db        sqlc.Querier
-	publisher *cqrs.EventBus
+	db               sqlc.Querier
+	filterer         ProductFilterer
+	publisher        *cqrs.EventBus
+	attributeSchemas AttributeValidator
+	pageTokens       pagetoken.Codec
+	// defaultLocale is the locale of product names and descriptions,
+	// translations holding those of other locales
+	defaultLocale string
+	// changes wakes analytics watchers after product writes
+	changes *changeNotifier
 }
 
-// NewProductUsecase creates a new product usecase instance
-func NewProductUsecase(db sqlc.Querier, publisher *cqrs.EventBus) ProductUsecase {
+// NewProductUsecase creates a new product usecase instance.
+// attributeSchemas validates product attributes per category and may be nil.
+// pageTokens encodes and decodes the page tokens of ListProducts.
+// defaultLocale is the locale product names and descriptions are written in.
+func NewProductUsecase(db sqlc.Querier, filterer ProductFilterer, publisher *cqrs.EventBus, attributeSchemas AttributeValidator, pageTokens pagetoken.Codec, defaultLocale string) ProductUsecase {
 	return &productUsecase{
-		db:        db,
-		publisher: publisher,
+		db:               db,
+		filterer:         filterer,
+		publisher:        publisher,
+		attributeSchemas: attributeSchemas,
+		pageTokens:       pageTokens,
+		defaultLocale:    defaultLocale,
+		changes:          newChangeNotifier(),
 	}
 }
 
-func (p *productUsecase) CreateProduct(ctx context.Context, name, price string) (*domain.Product, error) {
+func (p *productUsecase) CreateProduct(ctx context.Context, name, description, price, category string, attributes map[string]any, metadata map[string]string, translations map[string]domain.ProductTranslation, status domain.ProductStatus) (*domain.Product, error) {
 	// Create domain entity
 	product, err := domain.NewProductFromString(name, price)
 	if err != nil {
 		return nil, err
 	}
+	product.ID = resourceID(ctx, product.ID)
+	if status != "" {
+		if status != domain.ProductDraft && status != domain.ProductActive {
+			return nil, domain.NewValidationError(fmt.Sprintf("products are created as draft or active, not %s", status))
+		}
+		product.Status = status
+	}
+	product.SetAttributes(category, attributes)
+	product.SetMetadata(metadata)
+	if err := product.SetTranslations(description, translations, p.defaultLocale); err != nil {
+		return nil, err
+	}
+
+	dbAttributes, err := p.encodeAttributes(product)
+	if err != nil {
+		return nil, err
+	}
+
+	dbMetadata, err := encodeMetadata(product.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	dbTranslations, err := encodeTranslations(product.Translations)
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert decimal to pgtype.Numeric for database
 	var dbPrice pgtype.Numeric
@@ -45,30 +139,37 @@ func (p *productUsecase) CreateProduct(ctx context.Context, name, price string)
 	}
 
 	params := sqlc.CreateProductParams{
-		ID:    product.ID,
-		Name:  product.Name,
-		Price: dbPrice,
+		ID:           product.ID,
+		Name:         product.Name,
+		Price:        dbPrice,
+		Category:     product.Category,
+		Attributes:   dbAttributes,
+		Metadata:     dbMetadata,
+		Description:  product.Description,
+		Translations: dbTranslations,
+		Status:       string(product.Status),
 	}
 
 	dbProduct, err := p.db.CreateProduct(ctx, params)
 	if err != nil {
 		return nil, domain.NewInternalError(fmt.Sprintf("failed to create product: %v", err))
 	}
+	p.changes.notify()
 
 	createdProduct := p.mapDBProductToDomain(dbProduct)
 
 	// Publish product created event
 	if err := p.publishProductCreatedEvent(ctx, createdProduct); err != nil {
-		fmt.Printf("Failed to publish product created event: %v\n", err)
+		return nil, domain.NewInternalErrorWithCause("failed to publish product created event", err)
 	}
 
 	return createdProduct, nil
 }
 
 func (p *productUsecase) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
-	id, err := uuid.Parse(productID)
+	id, err := domain.ParseID("product", productID)
 	if err != nil {
-		return nil, domain.NewValidationError(fmt.Sprintf("invalid product ID: %v", err))
+		return nil, err
 	}
 
 	dbProduct, err := p.db.GetProductByID(ctx, id)
@@ -82,7 +183,7 @@ func (p *productUsecase) GetProduct(ctx context.Context, productID string) (*dom
 	return p.mapDBProductToDomain(dbProduct), nil
 }
 
-func (p *productUsecase) UpdateProduct(ctx context.Context, productID, name, price string) (*domain.Product, error) {
+func (p *productUsecase) UpdateProduct(ctx context.Context, productID, name, description, price, category string, attributes map[string]any, metadata map[string]string, translations map[string]domain.ProductTranslation) (*domain.Product, error) {
 	// Get existing product for price change detection
 	existingProduct, err := p.GetProduct(ctx, productID)
 	if err != nil {
@@ -96,6 +197,26 @@ func (p *productUsecase) UpdateProduct(ctx context.Context, productID, name, pri
 	if err := existingProduct.UpdateDetailsFromString(name, price); err != nil {
 		return nil, err
 	}
+	existingProduct.SetAttributes(category, attributes)
+	existingProduct.SetMetadata(metadata)
+	if err := existingProduct.SetTranslations(description, translations, p.defaultLocale); err != nil {
+		return nil, err
+	}
+
+	dbAttributes, err := p.encodeAttributes(existingProduct)
+	if err != nil {
+		return nil, err
+	}
+
+	dbMetadata, err := encodeMetadata(existingProduct.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	dbTranslations, err := encodeTranslations(existingProduct.Translations)
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert decimal to pgtype.Numeric for database
 	var dbPrice pgtype.Numeric
@@ -104,28 +225,34 @@ func (p *productUsecase) UpdateProduct(ctx context.Context, productID, name, pri
 	}
 
 	params := sqlc.UpdateProductParams{
-		ID:    existingProduct.ID,
-		Name:  existingProduct.Name,
-		Price: dbPrice,
+		ID:           existingProduct.ID,
+		Name:         existingProduct.Name,
+		Price:        dbPrice,
+		Category:     existingProduct.Category,
+		Attributes:   dbAttributes,
+		Metadata:     dbMetadata,
+		Description:  existingProduct.Description,
+		Translations: dbTranslations,
 	}
 
 	dbProduct, err := p.db.UpdateProduct(ctx, params)
 	if err != nil {
 		return nil, domain.NewInternalError(fmt.Sprintf("failed to update product: %v", err))
 	}
+	p.changes.notify()
 
 	updatedProduct := p.mapDBProductToDomain(dbProduct)
 
 	// Publish product updated event
 	if err := p.publishProductUpdatedEvent(ctx, updatedProduct); err != nil {
-		fmt.Printf("Failed to publish product updated event: %v\n", err)
+		return nil, domain.NewInternalErrorWithCause("failed to publish product updated event", err)
 	}
 
 	// If price changed, also publish price change event
 	newPrice := updatedProduct.Price.String()
 	if oldPrice != newPrice {
 		if err := p.publishProductPriceChangedEvent(ctx, updatedProduct, oldPrice, newPrice); err != nil {
-			fmt.Printf("Failed to publish product price changed event: %v\n", err)
+			return nil, domain.NewInternalErrorWithCause("failed to publish product price changed event", err)
 		}
 	}
 
@@ -142,10 +269,11 @@ func (p *productUsecase) DeleteProduct(ctx context.Context, productID string) er
 	if err := p.db.DeleteProduct(ctx, product.ID); err != nil {
 		return domain.NewInternalError(fmt.Sprintf("failed to delete product: %v", err))
 	}
+	p.changes.notify()
 
 	// Publish product deleted event
 	if err := p.publishProductDeletedEvent(ctx, product); err != nil {
-		fmt.Printf("Failed to publish product deleted event: %v\n", err)
+		return domain.NewInternalErrorWithCause("failed to publish product deleted event", err)
 	}
 
 	return nil
@@ -160,69 +288,26 @@ func (p *productUsecase) ListProducts(ctx context.Context, req *ListProductsRequ
 		pageSize = 100
 	}
 
-	offset := int32(0)
-	if req.PageToken != "" {
-		decodedOffset, err := base64.StdEncoding.DecodeString(req.PageToken)
-		if err != nil {
-			return nil, domain.NewValidationError("invalid page token")
-		}
-		if _, err := fmt.Sscanf(string(decodedOffset), "%d", &offset); err != nil {
-			return nil, domain.NewValidationError("invalid page token format")
-		}
+	page, err := decodePageToken(ctx, p.pageTokens, req.PageToken)
+	if err != nil {
+		return nil, err
 	}
 
-	var dbProducts []sqlc.Product
-	var err error
-
-	// Handle different query types based on request parameters
-	if req.SearchQuery != "" && req.PriceRange != nil {
-		var minPrice, maxPrice pgtype.Numeric
-		if err := minPrice.Scan(req.PriceRange.MinPrice); err != nil {
-			return nil, domain.NewValidationError(fmt.Sprintf("invalid min price: %v", err))
-		}
-		if err := maxPrice.Scan(req.PriceRange.MaxPrice); err != nil {
-			return nil, domain.NewValidationError(fmt.Sprintf("invalid max price: %v", err))
-		}
-
-		params := sqlc.SearchProductsWithPriceRangeParams{
-			Limit:       pageSize + 1,
-			Offset:      offset,
-			SearchQuery: "%" + req.SearchQuery + "%",
-			MinPrice:    minPrice,
-			MaxPrice:    maxPrice,
-		}
-		dbProducts, err = p.db.SearchProductsWithPriceRange(ctx, params)
-	} else if req.SearchQuery != "" {
-		params := sqlc.SearchProductsParams{
-			Limit:       pageSize + 1,
-			Offset:      offset,
-			SearchQuery: "%" + req.SearchQuery + "%",
-		}
-		dbProducts, err = p.db.SearchProducts(ctx, params)
-	} else if req.PriceRange != nil {
-		var minPrice, maxPrice pgtype.Numeric
-		if err := minPrice.Scan(req.PriceRange.MinPrice); err != nil {
-			return nil, domain.NewValidationError(fmt.Sprintf("invalid min price: %v", err))
-		}
-		if err := maxPrice.Scan(req.PriceRange.MaxPrice); err != nil {
-			return nil, domain.NewValidationError(fmt.Sprintf("invalid max price: %v", err))
-		}
+	order, err := filter.ParseOrder(req.OrderBy, productOrderFields)
+	if err != nil {
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid order_by: %v", err))
+	}
+	// Keyset tokens hold the position of a product in the order they were issued for
+	if page.After.ID != uuid.Nil && page.After.Order != order.String() {
+		return nil, domain.NewValidationError("page token was issued for another order_by, list again from the first page")
+	}
 
-		params := sqlc.ListProductsByPriceRangeParams{
-			Limit:    pageSize + 1,
-			Offset:   offset,
-			MinPrice: minPrice,
-			MaxPrice: maxPrice,
-		}
-		dbProducts, err = p.db.ListProductsByPriceRange(ctx, params)
-	} else {
-		params := sqlc.ListProductsParams{
-			Limit:  pageSize + 1,
-			Offset: offset,
-		}
-		dbProducts, err = p.db.ListProducts(ctx, params)
+	where, err := listProductsFilter(req)
+	if err != nil {
+		return nil, err
 	}
 
+	dbProducts, err := p.filterer.FilterProducts(ctx, where, order, page, pageSize+1)
 	if err != nil {
 		return nil, domain.NewInternalError(fmt.Sprintf("failed to list products: %v", err))
 	}
@@ -240,12 +325,17 @@ func (p *productUsecase) ListProducts(ctx context.Context, req *ListProductsRequ
 
 	var nextPageToken string
 	if hasNextPage {
-		nextOffset := offset + pageSize
-		nextPageToken = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%d", nextOffset)))
+		last := products[len(products)-1]
+		cursor := pagetoken.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		if !order.IsZero() {
+			cursor.Order = order.String()
+			cursor.Value = productSortValue(order, last)
+		}
+		nextPageToken = p.pageTokens.Encode(cursor)
 	}
 
 	// Get total count
-	totalCount, err := p.db.CountProducts(ctx)
+	totalCount, err := p.filterer.CountFilteredProducts(ctx, where)
 	if err != nil {
 		return nil, domain.NewInternalError(fmt.Sprintf("failed to count products: %v", err))
 	}
@@ -257,6 +347,74 @@ func (p *productUsecase) ListProducts(ctx context.Context, req *ListProductsRequ
 	}, nil
 }
 
+// productSortValue returns the value product sorts by in order, as text
+func productSortValue(order filter.Order, product *domain.Product) string {
+	switch order.Name {
+	case "name":
+		return product.Name
+	case "price":
+		return product.GetPriceString()
+	case "updated_at":
+		return product.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		return product.CreatedAt.Format(time.RFC3339Nano)
+	}
+}
+
+// listProductsFilter combines the filter expression with the search, price
+// range, category and attribute parameters of a list request
+func listProductsFilter(req *ListProductsRequest) (filter.Expr, error) {
+	expr, err := filter.Parse(req.Filter, productFilterFields)
+	if err != nil {
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid filter: %v", err))
+	}
+	exprs := []filter.Expr{expr}
+
+	if req.SearchQuery != "" {
+		// Names match in the default locale and in those of the request
+		search := []filter.Expr{filter.Contains(productNameField, req.SearchQuery)}
+		for _, tag := range req.Locales {
+			search = append(search, filter.Contains(productTranslationsField.Member(tag, "name"), req.SearchQuery))
+		}
+		exprs = append(exprs, filter.Or(search...))
+	}
+
+	if req.PriceRange != nil {
+		var minPrice, maxPrice pgtype.Numeric
+		if err := minPrice.Scan(req.PriceRange.MinPrice); err != nil {
+			return nil, domain.NewValidationError(fmt.Sprintf("invalid min price: %v", err))
+		}
+		if err := maxPrice.Scan(req.PriceRange.MaxPrice); err != nil {
+			return nil, domain.NewValidationError(fmt.Sprintf("invalid max price: %v", err))
+		}
+		exprs = append(exprs,
+			filter.Compare(productPriceField, filter.OpGreaterEqual, req.PriceRange.MinPrice),
+			filter.Compare(productPriceField, filter.OpLessEqual, req.PriceRange.MaxPrice))
+	}
+
+	if req.Category != "" {
+		exprs = append(exprs, filter.Compare(productCategoryField, filter.OpEqual, req.Category))
+	}
+
+	if len(req.Statuses) > 0 {
+		statuses := make([]filter.Expr, len(req.Statuses))
+		for i, status := range req.Statuses {
+			statuses[i] = filter.Compare(productStatusField, filter.OpEqual, string(status))
+		}
+		exprs = append(exprs, filter.Or(statuses...))
+	}
+
+	if len(req.AttributeFilter) > 0 {
+		encoded, err := json.Marshal(req.AttributeFilter)
+		if err != nil {
+			return nil, domain.NewValidationError(fmt.Sprintf("invalid attribute filter: %v", err))
+		}
+		exprs = append(exprs, filter.JSONContains(productAttributesField, encoded))
+	}
+
+	return filter.And(exprs...), nil
+}
+
 func (p *productUsecase) BulkUpdatePrices(ctx context.Context, updates []BulkPriceUpdate) (*BulkUpdatePricesResponse, error) {
 	var updatedProducts []*domain.Product
 	var failedIDs []string
@@ -269,7 +427,7 @@ func (p *productUsecase) BulkUpdatePrices(ctx context.Context, updates []BulkPri
 			continue
 		}
 
-		updatedProduct, err := p.UpdateProduct(ctx, update.ID, product.Name, update.Price)
+		updatedProduct, err := p.UpdateProduct(ctx, update.ID, product.Name, product.Description, update.Price, product.Category, product.Attributes, product.Metadata, product.Translations)
 		if err != nil {
 			failedIDs = append(failedIDs, update.ID)
 			continue
@@ -336,13 +494,46 @@ func (p *productUsecase) mapDBProductToDomain(dbProduct sqlc.Product) *domain.Pr
 	priceStr := p.numericToString(dbProduct.Price)
 	price, _ := decimal.NewFromString(priceStr) // Safe since we control the conversion
 
+	attributes, err := domain.ProductAttributes.Unmarshal(dbProduct.Attributes)
+	if err != nil {
+		slog.Error("Failed to decode product attributes", "product_id", dbProduct.ID, slog.Any("error", err))
+		attributes = map[string]any{}
+	}
+
 	return &domain.Product{
-		ID:        dbProduct.ID,
-		Name:      dbProduct.Name,
-		Price:     price,
-		CreatedAt: dbProduct.CreatedAt.Time,
-		UpdatedAt: dbProduct.UpdatedAt.Time,
+		ID:           dbProduct.ID,
+		Name:         dbProduct.Name,
+		Description:  dbProduct.Description,
+		Translations: decodeTranslations(dbProduct.ID, dbProduct.Translations),
+		Price:        price,
+		Category:     dbProduct.Category,
+		Attributes:   attributes,
+		Metadata:     decodeMetadata(dbProduct.Metadata),
+		Status:       domain.ProductStatus(dbProduct.Status),
+		CreatedAt:    dbProduct.CreatedAt.Time,
+		UpdatedAt:    dbProduct.UpdatedAt.Time,
+		Version:      dbProduct.Version,
+	}
+}
+
+// encodeAttributes validates the product attributes against its category schema and encodes them for storage
+func (p *productUsecase) encodeAttributes(product *domain.Product) ([]byte, error) {
+	if p.attributeSchemas != nil {
+		if err := p.attributeSchemas.Validate(product.Category, product.Attributes); err != nil {
+			var violations *jsonschema.ValidationError
+			if !errors.As(err, &violations) {
+				return nil, domain.NewInternalError(fmt.Sprintf("failed to validate attributes: %v", err))
+			}
+			return nil, domain.NewValidationError(fmt.Sprintf("invalid attributes for category %q: %v", product.Category, err))
+		}
+	}
+
+	encoded, err := domain.ProductAttributes.Marshal(product.Attributes)
+	if err != nil {
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid attributes: %v", err))
 	}
+
+	return encoded, nil
 }
 
 func (p *productUsecase) numericToString(n pgtype.Numeric) string {
@@ -367,7 +558,8 @@ func (p *productUsecase) publishProductCreatedEvent(ctx context.Context, product
 		EventId:       uuid.New().String(),
 		Product:       p.domainProductToProto(product),
 		EventTime:     timestamppb.Now(),
-		CorrelationId: p.getCorrelationID(ctx),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
 		Data: &eventv1.ProductCreatedEventData{
 			Source: "product-service",
 			Metadata: map[string]string{
@@ -384,10 +576,11 @@ func (p *productUsecase) publishProductUpdatedEvent(ctx context.Context, product
 		EventId:       uuid.New().String(),
 		Product:       p.domainProductToProto(product),
 		EventTime:     timestamppb.Now(),
-		CorrelationId: p.getCorrelationID(ctx),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
 		Data: &eventv1.ProductUpdatedEventData{
 			Source:        "product-service",
-			ChangedFields: []string{"name", "price"},
+			ChangedFields: []string{"name", "description", "price", "category", "attributes", "translations"},
 			Metadata: map[string]string{
 				"operation": "update_product",
 				"version":   "v1",
@@ -402,7 +595,8 @@ func (p *productUsecase) publishProductPriceChangedEvent(ctx context.Context, pr
 		EventId:       uuid.New().String(),
 		Product:       p.domainProductToProto(product),
 		EventTime:     timestamppb.Now(),
-		CorrelationId: p.getCorrelationID(ctx),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
 		Data: &eventv1.ProductPriceChangedEventData{
 			Source:        "product-service",
 			PreviousPrice: oldPrice,
@@ -421,7 +615,8 @@ func (p *productUsecase) publishProductDeletedEvent(ctx context.Context, product
 		EventId:       uuid.New().String(),
 		Product:       p.domainProductToProto(product),
 		EventTime:     timestamppb.Now(),
-		CorrelationId: p.getCorrelationID(ctx),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
 		Data: &eventv1.ProductDeletedEventData{
 			Source: "product-service",
 			Reason: "manual_deletion",
@@ -435,15 +630,20 @@ func (p *productUsecase) publishProductDeletedEvent(ctx context.Context, product
 }
 
 func (p *productUsecase) domainProductToProto(product *domain.Product) *v1.Product {
+	attributes, _ := structpb.NewStruct(product.Attributes)
+
 	return &v1.Product{
-		Id:        product.ID.String(),
-		Name:      product.Name,
-		Price:     product.GetPriceString(),
-		CreatedAt: timestamppb.New(product.CreatedAt),
-		UpdatedAt: timestamppb.New(product.UpdatedAt),
+		Id:           product.ID.String(),
+		Name:         product.Name,
+		Description:  product.Description,
+		Translations: translationsToProto(product.Translations),
+		Price:        product.GetPriceString(),
+		Category:     product.Category,
+		Attributes:   attributes,
+		Metadata:     product.Metadata,
+		Status:       productStatusToProto[product.Status],
+		CreatedAt:    timestamppb.New(product.CreatedAt),
+		UpdatedAt:    timestamppb.New(product.UpdatedAt),
+		Version:      product.Version,
 	}
 }
-
-func (p *productUsecase) getCorrelationID(ctx context.Context) string {
-	return uuid.New().String()
-}
\ No newline at end of file
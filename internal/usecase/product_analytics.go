@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Bounds of WatchProductAnalytics pacing
+const (
+	// minAnalyticsInterval is the shortest pause between two snapshot reads
+	minAnalyticsInterval = time.Second
+	// analyticsRefreshInterval recomputes the aggregates even without local
+	// writes, picking up changes made through other instances
+	analyticsRefreshInterval = 30 * time.Second
+)
+
+// changeNotifier wakes every waiter after a product write
+type changeNotifier struct {
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{changed: make(chan struct{})}
+}
+
+// notify wakes the current waiters
+func (n *changeNotifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+// wait returns a channel closed on the next notify
+func (n *changeNotifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.changed
+}
+
+func (p *productUsecase) WatchProductAnalytics(ctx context.Context, minInterval time.Duration, send func(*ProductAnalyticsResponse) error) error {
+	minInterval = max(minInterval, minAnalyticsInterval)
+
+	refresh := time.NewTicker(analyticsRefreshInterval)
+	defer refresh.Stop()
+
+	var last *ProductAnalyticsResponse
+	for {
+		// Subscribe before reading so a write during the read is not missed
+		changed := p.changes.wait()
+
+		snapshot, err := p.GetProductAnalytics(ctx)
+		if err != nil {
+			return err
+		}
+		next := time.Now().Add(minInterval)
+		if !reflect.DeepEqual(snapshot, last) {
+			if err := send(snapshot); err != nil {
+				return err
+			}
+			last = snapshot
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		case <-refresh.C:
+		}
+
+		// Coalesce bursts of writes into one snapshot per minInterval
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
@@ -2,19 +2,39 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/erry-az/go-init/internal/domain"
 )
 
 // ProductUsecase defines the business logic interface for product operations
 type ProductUsecase interface {
-	CreateProduct(ctx context.Context, name, price string) (*domain.Product, error)
+	// CreateProduct creates a product named and described in the default
+	// locale, translations holding its name and description in others. status
+	// is draft or active, active when empty.
+	CreateProduct(ctx context.Context, name, description, price, category string, attributes map[string]any, metadata map[string]string, translations map[string]domain.ProductTranslation, status domain.ProductStatus) (*domain.Product, error)
 	GetProduct(ctx context.Context, productID string) (*domain.Product, error)
-	UpdateProduct(ctx context.Context, productID, name, price string) (*domain.Product, error)
+	UpdateProduct(ctx context.Context, productID, name, description, price, category string, attributes map[string]any, metadata map[string]string, translations map[string]domain.ProductTranslation) (*domain.Product, error)
 	DeleteProduct(ctx context.Context, productID string) error
+	// SetProductStatus moves a product to status, failing with a validation
+	// error for transitions the lifecycle does not allow
+	SetProductStatus(ctx context.Context, productID string, status domain.ProductStatus) (*domain.Product, error)
 	ListProducts(ctx context.Context, req *ListProductsRequest) (*ListProductsResponse, error)
 	BulkUpdatePrices(ctx context.Context, updates []BulkPriceUpdate) (*BulkUpdatePricesResponse, error)
 	GetProductAnalytics(ctx context.Context) (*ProductAnalyticsResponse, error)
+	// WatchProductAnalytics sends the current analytics and then every changed
+	// snapshot, at most once per minInterval, until ctx is done or send fails
+	WatchProductAnalytics(ctx context.Context, minInterval time.Duration, send func(*ProductAnalyticsResponse) error) error
+	// ReindexProducts publishes a ProductReindexedEvent for every product and
+	// returns how many, reporting progress after every batch when set
+	ReindexProducts(ctx context.Context, reindexID string, batchSize int32, progress ProgressFunc) (int64, error)
+	// SchedulePriceChange changes the price of a product at effectiveAt, which must be in the future
+	SchedulePriceChange(ctx context.Context, productID, price string, effectiveAt time.Time) (*domain.ScheduledPrice, error)
+	// ListScheduledPrices returns the price changes of a product not yet applied, earliest first
+	ListScheduledPrices(ctx context.Context, productID string) ([]*domain.ScheduledPrice, error)
+	// ApplyDuePriceChanges applies up to batchSize scheduled price changes that
+	// have come into effect and returns how many were applied
+	ApplyDuePriceChanges(ctx context.Context, batchSize int32) (int, error)
 }
 
 // Request/Response types for Product operations
@@ -22,7 +42,20 @@ type ListProductsRequest struct {
 	PageSize    int32
 	PageToken   string
 	SearchQuery string
-	PriceRange  *PriceRange
+	// Locales is the fallback chain of the request, whose translated names
+	// SearchQuery matches besides those in the default locale
+	Locales    []string
+	PriceRange *PriceRange
+	// Category and AttributeFilter select products by structured attributes
+	Category        string
+	AttributeFilter map[string]any
+	// Statuses selects products in any of them, every status when empty
+	Statuses []domain.ProductStatus
+	// Filter is an AIP-160 style expression, e.g. `price > 100 AND name:"phone"`
+	Filter string
+	// OrderBy is an AIP-132 style order, e.g. `price desc`; empty lists
+	// oldest first
+	OrderBy string
 }
 
 type PriceRange struct {
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+// defaultPriceScheduleBatchSize is used when applying due price changes without a batch size
+const defaultPriceScheduleBatchSize = 100
+
+func (p *productUsecase) SchedulePriceChange(ctx context.Context, productID, price string, effectiveAt time.Time) (*domain.ScheduledPrice, error) {
+	product, err := p.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled, err := domain.NewScheduledPrice(product.ID, price, effectiveAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbPrice pgtype.Numeric
+	if err := dbPrice.Scan(scheduled.GetPriceString()); err != nil {
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid price conversion: %v", err))
+	}
+
+	dbScheduled, err := p.db.CreateScheduledPrice(ctx, sqlc.CreateScheduledPriceParams{
+		ID:          scheduled.ID,
+		ProductID:   scheduled.ProductID,
+		Price:       dbPrice,
+		EffectiveAt: pgtype.Timestamptz{Time: scheduled.EffectiveAt, Valid: true},
+	})
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to schedule price change: %v", err))
+	}
+
+	return p.mapDBScheduledPriceToDomain(dbScheduled), nil
+}
+
+func (p *productUsecase) ListScheduledPrices(ctx context.Context, productID string) ([]*domain.ScheduledPrice, error) {
+	product, err := p.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbScheduled, err := p.db.ListPendingScheduledPrices(ctx, product.ID)
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to list scheduled prices: %v", err))
+	}
+
+	scheduled := make([]*domain.ScheduledPrice, len(dbScheduled))
+	for i, dbPrice := range dbScheduled {
+		scheduled[i] = p.mapDBScheduledPriceToDomain(dbPrice)
+	}
+	return scheduled, nil
+}
+
+// ApplyDuePriceChanges claims every due change before applying it, so only one
+// instance applies a change when several run the schedule. A change whose
+// update fails is released again and retried on the next run.
+func (p *productUsecase) ApplyDuePriceChanges(ctx context.Context, batchSize int32) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultPriceScheduleBatchSize
+	}
+
+	due, err := p.db.ListDueScheduledPrices(ctx, sqlc.ListDueScheduledPricesParams{
+		Now:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		BatchSize: batchSize,
+	})
+	if err != nil {
+		return 0, domain.NewInternalError(fmt.Sprintf("failed to list due price changes: %v", err))
+	}
+
+	applied := 0
+	var errs []error
+	for _, dbScheduled := range due {
+		claimed, err := p.db.ClaimScheduledPrice(ctx, dbScheduled.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("claiming price change %s: %w", dbScheduled.ID, err))
+			continue
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		scheduled := p.mapDBScheduledPriceToDomain(dbScheduled)
+		if err := p.applyScheduledPrice(ctx, scheduled); err != nil {
+			// A deleted product takes its scheduled changes with it
+			var domainErr *domain.DomainError
+			if errors.As(err, &domainErr) && domainErr.Type == domain.ErrorTypeNotFound {
+				continue
+			}
+			if releaseErr := p.db.ReleaseScheduledPrice(ctx, scheduled.ID); releaseErr != nil {
+				err = errors.Join(err, releaseErr)
+			}
+			errs = append(errs, fmt.Errorf("applying price change %s: %w", scheduled.ID, err))
+			continue
+		}
+		applied++
+	}
+
+	if applied > 0 {
+		slog.Info("Scheduled price changes applied", "applied", applied)
+	}
+	return applied, errors.Join(errs...)
+}
+
+// applyScheduledPrice updates the product price through UpdateProduct, which
+// publishes the price changed event
+func (p *productUsecase) applyScheduledPrice(ctx context.Context, scheduled *domain.ScheduledPrice) error {
+	product, err := p.GetProduct(ctx, scheduled.ProductID.String())
+	if err != nil {
+		return err
+	}
+
+	_, err = p.UpdateProduct(ctx, product.ID.String(), product.Name, product.Description, scheduled.GetPriceString(), product.Category, product.Attributes, product.Metadata, product.Translations)
+	return err
+}
+
+func (p *productUsecase) mapDBScheduledPriceToDomain(dbScheduled sqlc.ScheduledPrice) *domain.ScheduledPrice {
+	price, _ := decimal.NewFromString(p.numericToString(dbScheduled.Price)) // Safe since we control the conversion
+
+	return &domain.ScheduledPrice{
+		ID:          dbScheduled.ID,
+		ProductID:   dbScheduled.ProductID,
+		Price:       price,
+		EffectiveAt: dbScheduled.EffectiveAt.Time,
+		CreatedAt:   dbScheduled.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	eventv1 "github.com/erry-az/go-init/proto/event/v1"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultReindexBatchSize is used when a reindex does not set its batch size
+const defaultReindexBatchSize = 100
+
+func (p *productUsecase) ReindexProducts(ctx context.Context, reindexID string, batchSize int32, progress ProgressFunc) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = defaultReindexBatchSize
+	}
+
+	// Products created meanwhile are reindexed too, so this is an estimate
+	count, err := p.db.CountProducts(ctx)
+	if err != nil {
+		return 0, domain.NewInternalError(fmt.Sprintf("failed to count products: %v", err))
+	}
+
+	var total int64
+	afterID := uuid.Nil
+	for {
+		dbProducts, err := p.db.ListProductsAfterID(ctx, sqlc.ListProductsAfterIDParams{
+			AfterID:   afterID,
+			BatchSize: batchSize,
+		})
+		if err != nil {
+			return total, domain.NewInternalError(fmt.Sprintf("failed to list products: %v", err))
+		}
+
+		for _, dbProduct := range dbProducts {
+			if err := p.publishProductReindexedEvent(ctx, p.mapDBProductToDomain(dbProduct), reindexID); err != nil {
+				return total, domain.NewInternalError(fmt.Sprintf("failed to publish product reindexed event: %v", err))
+			}
+			total++
+		}
+
+		if progress != nil {
+			progress(ctx, total, max(count, total))
+		}
+
+		if len(dbProducts) < int(batchSize) {
+			break
+		}
+		afterID = dbProducts[len(dbProducts)-1].ID
+	}
+
+	slog.Info("Products reindexed", "reindex_id", reindexID, "products", total)
+	return total, nil
+}
+
+func (p *productUsecase) publishProductReindexedEvent(ctx context.Context, product *domain.Product, reindexID string) error {
+	event := &eventv1.ProductReindexedEvent{
+		EventId:       uuid.New().String(),
+		Product:       p.domainProductToProto(product),
+		EventTime:     timestamppb.Now(),
+		CorrelationId: reindexID,
+		TenantId:      eventTenantID(ctx),
+		Data: &eventv1.ProductReindexedEventData{
+			Source:    "product-service",
+			ReindexId: reindexID,
+			Metadata: map[string]string{
+				"operation": "reindex_products",
+				"version":   "v1",
+			},
+		},
+	}
+	return p.publisher.Publish(ctx, event)
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/proto/api/v1"
+	eventv1 "github.com/erry-az/go-init/proto/event/v1"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// productStatusToProto maps product statuses to their protobuf enum values
+var productStatusToProto = map[domain.ProductStatus]v1.ProductStatus{
+	domain.ProductDraft:        v1.ProductStatus_PRODUCT_STATUS_DRAFT,
+	domain.ProductActive:       v1.ProductStatus_PRODUCT_STATUS_ACTIVE,
+	domain.ProductDiscontinued: v1.ProductStatus_PRODUCT_STATUS_DISCONTINUED,
+}
+
+// productStatusFromProto maps protobuf enum values to product statuses,
+// unspecified to none
+var productStatusFromProto = map[v1.ProductStatus]domain.ProductStatus{
+	v1.ProductStatus_PRODUCT_STATUS_DRAFT:        domain.ProductDraft,
+	v1.ProductStatus_PRODUCT_STATUS_ACTIVE:       domain.ProductActive,
+	v1.ProductStatus_PRODUCT_STATUS_DISCONTINUED: domain.ProductDiscontinued,
+}
+
+func (p *productUsecase) SetProductStatus(ctx context.Context, productID string, status domain.ProductStatus) (*domain.Product, error) {
+	product, err := p.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Status == status {
+		return product, nil
+	}
+
+	previous := product.Status
+	if err := product.SetStatus(status); err != nil {
+		return nil, err
+	}
+
+	dbProduct, err := p.db.UpdateProductStatus(ctx, sqlc.UpdateProductStatusParams{
+		ID:     product.ID,
+		Status: string(product.Status),
+	})
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to update product status: %v", err))
+	}
+	p.changes.notify()
+
+	updatedProduct := p.mapDBProductToDomain(dbProduct)
+
+	if err := p.publishProductStatusChangedEvent(ctx, updatedProduct, previous); err != nil {
+		return nil, domain.NewInternalErrorWithCause("failed to publish product status changed event", err)
+	}
+
+	return updatedProduct, nil
+}
+
+func (p *productUsecase) publishProductStatusChangedEvent(ctx context.Context, product *domain.Product, previous domain.ProductStatus) error {
+	event := &eventv1.ProductStatusChangedEvent{
+		EventId:       uuid.New().String(),
+		Product:       p.domainProductToProto(product),
+		EventTime:     timestamppb.Now(),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
+		Data: &eventv1.ProductStatusChangedEventData{
+			Source:         "product-service",
+			PreviousStatus: productStatusToProto[previous],
+			NewStatus:      productStatusToProto[product.Status],
+			Metadata: map[string]string{
+				"operation": "set_product_status",
+				"version":   "v1",
+			},
+		},
+	}
+	return p.publisher.Publish(ctx, event)
+}
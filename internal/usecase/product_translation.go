@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/proto/api/v1"
+	"github.com/google/uuid"
+)
+
+// dbProductTranslation is a translation as stored in the translations
+// column, e.g. {"de": {"name": "Schuh"}}
+type dbProductTranslation struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// encodeTranslations encodes the translations of a product for their JSONB column
+func encodeTranslations(translations map[string]domain.ProductTranslation) ([]byte, error) {
+	if len(translations) == 0 {
+		return []byte("{}"), nil
+	}
+
+	stored := make(map[string]dbProductTranslation, len(translations))
+	for tag, translation := range translations {
+		stored[tag] = dbProductTranslation(translation)
+	}
+	encoded, err := json.Marshal(stored)
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to encode translations: %v", err))
+	}
+	return encoded, nil
+}
+
+// decodeTranslations decodes a JSONB translations column; unreadable values
+// decode as empty
+func decodeTranslations(productID uuid.UUID, data []byte) map[string]domain.ProductTranslation {
+	translations := map[string]domain.ProductTranslation{}
+	if len(data) == 0 {
+		return translations
+	}
+
+	var stored map[string]dbProductTranslation
+	if err := json.Unmarshal(data, &stored); err != nil {
+		slog.Error("Failed to decode product translations", "product_id", productID, slog.Any("error", err))
+		return translations
+	}
+	for tag, translation := range stored {
+		translations[tag] = domain.ProductTranslation(translation)
+	}
+	return translations
+}
+
+func translationsToProto(translations map[string]domain.ProductTranslation) map[string]*v1.ProductTranslation {
+	if len(translations) == 0 {
+		return nil
+	}
+
+	protos := make(map[string]*v1.ProductTranslation, len(translations))
+	for tag, translation := range translations {
+		protos[tag] = &v1.ProductTranslation{Name: translation.Name, Description: translation.Description}
+	}
+	return protos
+}
+
+func translationsFromProto(protos map[string]*v1.ProductTranslation) map[string]domain.ProductTranslation {
+	translations := make(map[string]domain.ProductTranslation, len(protos))
+	for tag, translation := range protos {
+		translations[tag] = domain.ProductTranslation{Name: translation.GetName(), Description: translation.GetDescription()}
+	}
+	return translations
+}
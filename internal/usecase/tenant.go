@@ -0,0 +1,14 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/residency"
+)
+
+// eventTenantID returns the tenant events published while handling ctx
+// belong to, empty for callers without one or with residency disabled
+func eventTenantID(ctx context.Context) string {
+	tenantID, _ := residency.TenantFromContext(ctx)
+	return tenantID
+}
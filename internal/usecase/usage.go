@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// hourlyUsageRetention is how long raw hourly buckets are kept after rollup
+const hourlyUsageRetention = 30 * 24 * time.Hour
+
+type usageUsecase struct {
+	db sqlc.Querier
+}
+
+// NewUsageUsecase creates a new usage usecase instance
+func NewUsageUsecase(db sqlc.Querier) UsageUsecase {
+	return &usageUsecase{
+		db: db,
+	}
+}
+
+func (u *usageUsecase) GetAPIUsage(ctx context.Context, req *GetAPIUsageRequest) ([]*APIUsage, error) {
+	if req.EndDate.Before(req.StartDate) {
+		return nil, domain.NewValidationError("end date must not be before start date")
+	}
+
+	startDay := pgtype.Date{Time: req.StartDate.UTC(), Valid: true}
+	endDay := pgtype.Date{Time: req.EndDate.UTC(), Valid: true}
+
+	var rows []sqlc.ApiUsageDaily
+	var err error
+	if req.ClientID != "" {
+		rows, err = u.db.ListAPIUsageDaily(ctx, sqlc.ListAPIUsageDailyParams{
+			ClientID: req.ClientID,
+			StartDay: startDay,
+			EndDay:   endDay,
+		})
+	} else {
+		rows, err = u.db.ListAllAPIUsageDaily(ctx, sqlc.ListAllAPIUsageDailyParams{
+			StartDay: startDay,
+			EndDay:   endDay,
+		})
+	}
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to list API usage: %v", err))
+	}
+
+	usage := make([]*APIUsage, len(rows))
+	for i, row := range rows {
+		usage[i] = u.mapDBUsageToAPIUsage(row)
+	}
+
+	return usage, nil
+}
+
+func (u *usageUsecase) RollupDailyUsage(ctx context.Context, day time.Time) error {
+	day = day.UTC().Truncate(24 * time.Hour)
+
+	if err := u.db.RollupAPIUsageDaily(ctx, pgtype.Date{Time: day, Valid: true}); err != nil {
+		return domain.NewInternalError(fmt.Sprintf("failed to roll up API usage: %v", err))
+	}
+
+	before := pgtype.Timestamptz{Time: day.Add(-hourlyUsageRetention), Valid: true}
+	if err := u.db.DeleteAPIUsageHourlyBefore(ctx, before); err != nil {
+		return domain.NewInternalError(fmt.Sprintf("failed to prune hourly API usage: %v", err))
+	}
+
+	return nil
+}
+
+// Helper methods
+func (u *usageUsecase) mapDBUsageToAPIUsage(row sqlc.ApiUsageDaily) *APIUsage {
+	usage := &APIUsage{
+		ClientID:     row.ClientID,
+		Day:          row.Day.Time,
+		RequestCount: row.RequestCount,
+		ErrorCount:   row.ErrorCount,
+		MaxLatencyMs: row.MaxLatencyMs,
+	}
+
+	if row.RequestCount > 0 {
+		usage.ErrorRate = float64(row.ErrorCount) / float64(row.RequestCount)
+		usage.AverageLatencyMs = float64(row.TotalLatencyMs) / float64(row.RequestCount)
+	}
+
+	return usage
+}
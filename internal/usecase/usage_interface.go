@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"context"
+	"time"
+)
+
+// UsageUsecase defines the business logic interface for API usage reporting
+type UsageUsecase interface {
+	GetAPIUsage(ctx context.Context, req *GetAPIUsageRequest) ([]*APIUsage, error)
+	RollupDailyUsage(ctx context.Context, day time.Time) error
+}
+
+type GetAPIUsageRequest struct {
+	ClientID  string
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+type APIUsage struct {
+	ClientID         string
+	Day              time.Time
+	RequestCount     int64
+	ErrorCount       int64
+	ErrorRate        float64
+	AverageLatencyMs float64
+	MaxLatencyMs     int64
+}
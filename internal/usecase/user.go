@@ -3,47 +3,80 @@ package usecase
 import (
 	"context"
 	"database/sql"
-	"encoding/base64"
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/erry-az/go-init/internal/domain"
 	"github.com/erry-az/go-init/internal/repository/sqlc"
+	"github.com/erry-az/go-init/pkg/filter"
+	"github.com/erry-az/go-init/pkg/pagetoken"
 	"github.com/erry-az/go-init/proto/api/v1"
 	eventv1 "github.com/erry-az/go-init/proto/event/v1"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// UserFilterer lists users matching a filter expression, e.g. a *repository.UserFilter
+type UserFilterer interface {
+	FilterUsers(ctx context.Context, where filter.Expr, page pagetoken.Page, limit int32) ([]sqlc.User, error)
+	CountFilteredUsers(ctx context.Context, where filter.Expr) (int64, error)
+}
+
+// User fields accepted in list filters. Email is left out as it may be encrypted at rest.
+var (
+	userNameField = filter.Field{Column: "name", Type: filter.TypeString}
+
+	userFilterFields = map[string]filter.Field{
+		"name":       userNameField,
+		"metadata":   {Column: "metadata", Type: filter.TypeMap},
+		"created_at": {Column: "created_at", Type: filter.TypeTimestamp},
+		"updated_at": {Column: "updated_at", Type: filter.TypeTimestamp},
+	}
+)
+
 type userUsecase struct {
 	db        sqlc.Querier
+	filterer  UserFilterer
 	publisher *cqrs.EventBus
+	options   UserOptions
 }
 
 // NewUserUsecase creates a new user usecase instance
-func NewUserUsecase(db sqlc.Querier, publisher *cqrs.EventBus) UserUsecase {
+func NewUserUsecase(db sqlc.Querier, filterer UserFilterer, publisher *cqrs.EventBus, options UserOptions) UserUsecase {
 	return &userUsecase{
 		db:        db,
+		filterer:  filterer,
 		publisher: publisher,
+		options:   options,
 	}
 }
 
-func (u *userUsecase) CreateUser(ctx context.Context, name, email string) (*domain.User, error) {
+func (u *userUsecase) CreateUser(ctx context.Context, name, email string, metadata map[string]string) (*domain.User, error) {
 	// Create domain entity
-	user := domain.NewUser(name, email)
+	user := domain.NewUser(name, u.canonicalEmail(email))
+	user.ID = resourceID(ctx, user.ID)
+	user.SetMetadata(metadata)
+
+	dbMetadata, err := encodeMetadata(user.Metadata)
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert to database params
 	params := sqlc.CreateUserParams{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Metadata: dbMetadata,
 	}
 
 	dbUser, err := u.db.CreateUser(ctx, params)
 	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
-			return nil, domain.NewConflictError(fmt.Sprintf("user with email %s already exists", email))
+		if conflict := conflictError(err); conflict != nil {
+			return nil, conflict
 		}
 		return nil, domain.NewInternalError(fmt.Sprintf("failed to create user: %v", err))
 	}
@@ -53,22 +86,66 @@ func (u *userUsecase) CreateUser(ctx context.Context, name, email string) (*doma
 
 	// Publish user created event
 	if err := u.publishUserCreatedEvent(ctx, createdUser); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Failed to publish user created event: %v\n", err)
+		return nil, domain.NewInternalErrorWithCause("failed to publish user created event", err)
 	}
 
 	return createdUser, nil
 }
 
+// CreateOrGetUser creates the user, or returns the existing user with that email
+// when a concurrent or repeated request already created it. created reports which happened.
+func (u *userUsecase) CreateOrGetUser(ctx context.Context, name, email string, metadata map[string]string) (*domain.User, bool, error) {
+	email = u.canonicalEmail(email)
+	user := domain.NewUser(name, email)
+	user.SetMetadata(metadata)
+
+	dbMetadata, err := encodeMetadata(user.Metadata)
+	if err != nil {
+		return nil, false, err
+	}
+
+	dbUser, err := u.db.CreateUser(ctx, sqlc.CreateUserParams{
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Metadata: dbMetadata,
+	})
+	if err == nil {
+		createdUser := u.mapDBUserToDomain(dbUser)
+		if err := u.publishUserCreatedEvent(ctx, createdUser); err != nil {
+			return nil, false, domain.NewInternalErrorWithCause("failed to publish user created event", err)
+		}
+		return createdUser, true, nil
+	}
+
+	if !isEmailConflict(err) {
+		if conflict := conflictError(err); conflict != nil {
+			return nil, false, conflict
+		}
+		return nil, false, domain.NewInternalError(fmt.Sprintf("failed to create user: %v", err))
+	}
+
+	existing, err := u.db.GetUserByEmail(ctx, sqlc.GetUserByEmailParams{Email: email})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The conflicting user was deleted in between; let the client retry
+			return nil, false, domain.NewConflictError("user with this email was modified concurrently")
+		}
+		return nil, false, domain.NewInternalError(fmt.Sprintf("failed to get existing user: %v", err))
+	}
+
+	return u.mapDBUserToDomain(existing), false, nil
+}
+
 func (u *userUsecase) GetUser(ctx context.Context, userID string) (*domain.User, error) {
-	id, err := uuid.Parse(userID)
+	id, err := domain.ParseID("user", userID)
 	if err != nil {
-		return nil, domain.NewValidationError(fmt.Sprintf("invalid user ID: %v", err))
+		return nil, err
 	}
 
 	dbUser, err := u.db.GetUserByID(ctx, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.NewNotFoundError("user not found")
 		}
 		return nil, domain.NewInternalError(fmt.Sprintf("failed to get user: %v", err))
@@ -77,27 +154,40 @@ func (u *userUsecase) GetUser(ctx context.Context, userID string) (*domain.User,
 	return u.mapDBUserToDomain(dbUser), nil
 }
 
-func (u *userUsecase) UpdateUser(ctx context.Context, userID, name, email string) (*domain.User, error) {
+func (u *userUsecase) UpdateUser(ctx context.Context, userID, name, email string, metadata map[string]string) (*domain.User, error) {
 	// Get existing user
 	user, err := u.GetUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Emails stored before canonicalization count as unchanged when only their case differs
+	email = u.canonicalEmail(email)
+	if u.options.RequireEmailConfirmation && email != u.canonicalEmail(user.Email) {
+		return nil, domain.NewValidationError("email changes require confirmation, use RequestEmailChange")
+	}
+
 	// Update domain entity
 	user.UpdateDetails(name, email)
+	user.SetMetadata(metadata)
+
+	dbMetadata, err := encodeMetadata(user.Metadata)
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert to database params
 	params := sqlc.UpdateUserParams{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Metadata: dbMetadata,
 	}
 
 	dbUser, err := u.db.UpdateUser(ctx, params)
 	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
-			return nil, domain.NewConflictError(fmt.Sprintf("user with email %s already exists", email))
+		if conflict := conflictError(err); conflict != nil {
+			return nil, conflict
 		}
 		return nil, domain.NewInternalError(fmt.Sprintf("failed to update user: %v", err))
 	}
@@ -106,7 +196,7 @@ func (u *userUsecase) UpdateUser(ctx context.Context, userID, name, email string
 
 	// Publish user updated event
 	if err := u.publishUserUpdatedEvent(ctx, updatedUser); err != nil {
-		fmt.Printf("Failed to publish user updated event: %v\n", err)
+		return nil, domain.NewInternalErrorWithCause("failed to publish user updated event", err)
 	}
 
 	return updatedUser, nil
@@ -125,7 +215,7 @@ func (u *userUsecase) DeleteUser(ctx context.Context, userID string) error {
 
 	// Publish user deleted event
 	if err := u.publishUserDeletedEvent(ctx, user); err != nil {
-		fmt.Printf("Failed to publish user deleted event: %v\n", err)
+		return domain.NewInternalErrorWithCause("failed to publish user deleted event", err)
 	}
 
 	return nil
@@ -140,31 +230,38 @@ func (u *userUsecase) ListUsers(ctx context.Context, req *ListUsersRequest) (*Li
 		pageSize = 100
 	}
 
-	offset := int32(0)
-	if req.PageToken != "" {
-		decodedOffset, err := base64.StdEncoding.DecodeString(req.PageToken)
-		if err != nil {
-			return nil, domain.NewValidationError("invalid page token")
-		}
-		if _, err := fmt.Sscanf(string(decodedOffset), "%d", &offset); err != nil {
-			return nil, domain.NewValidationError("invalid page token format")
-		}
+	page, err := decodePageToken(ctx, u.options.PageTokens, req.PageToken)
+	if err != nil {
+		return nil, err
 	}
+	afterCreatedAt := pgtype.Timestamptz{Time: page.After.CreatedAt, Valid: true}
 
 	var dbUsers []sqlc.User
-	var err error
+	var where filter.Expr
 
-	if req.SearchQuery != "" {
+	if req.Filter != "" {
+		where, err = listUsersFilter(req)
+		if err != nil {
+			return nil, err
+		}
+		dbUsers, err = u.filterer.FilterUsers(ctx, where, page, pageSize+1)
+	} else if req.SearchQuery != "" {
+		search := searchPattern(req.SearchQuery)
 		params := sqlc.SearchUsersParams{
-			Limit:       pageSize + 1,
-			Offset:      offset,
-			SearchQuery: "%" + req.SearchQuery + "%",
+			SearchQuery:    search,
+			EmailQuery:     pgtype.Text{String: search, Valid: true},
+			AfterCreatedAt: afterCreatedAt,
+			AfterID:        page.After.ID,
+			PageSize:       pageSize + 1,
+			PageOffset:     page.Offset,
 		}
 		dbUsers, err = u.db.SearchUsers(ctx, params)
 	} else {
 		params := sqlc.ListUsersParams{
-			Limit:  pageSize + 1,
-			Offset: offset,
+			AfterCreatedAt: afterCreatedAt,
+			AfterID:        page.After.ID,
+			PageSize:       pageSize + 1,
+			PageOffset:     page.Offset,
 		}
 		dbUsers, err = u.db.ListUsers(ctx, params)
 	}
@@ -186,14 +283,24 @@ func (u *userUsecase) ListUsers(ctx context.Context, req *ListUsersRequest) (*Li
 
 	var nextPageToken string
 	if hasNextPage {
-		nextOffset := offset + pageSize
-		nextPageToken = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%d", nextOffset)))
+		last := dbUsers[len(dbUsers)-1]
+		nextPageToken = u.options.PageTokens.Encode(pagetoken.Cursor{CreatedAt: last.CreatedAt.Time, ID: last.ID})
 	}
 
 	// Get total count
 	var totalCount int32
-	if req.SearchQuery != "" {
-		count, err := u.db.CountUsersBySearch(ctx, "%"+req.SearchQuery+"%")
+	if req.Filter != "" {
+		count, err := u.filterer.CountFilteredUsers(ctx, where)
+		if err != nil {
+			return nil, domain.NewInternalError(fmt.Sprintf("failed to count users: %v", err))
+		}
+		totalCount = int32(count)
+	} else if req.SearchQuery != "" {
+		search := searchPattern(req.SearchQuery)
+		count, err := u.db.CountUsersBySearch(ctx, sqlc.CountUsersBySearchParams{
+			SearchQuery: search,
+			EmailQuery:  pgtype.Text{String: search, Valid: true},
+		})
 		if err != nil {
 			return nil, domain.NewInternalError(fmt.Sprintf("failed to count users: %v", err))
 		}
@@ -213,12 +320,26 @@ func (u *userUsecase) ListUsers(ctx context.Context, req *ListUsersRequest) (*Li
 	}, nil
 }
 
+// listUsersFilter combines the filter expression with the search query of a
+// list request. The search only matches names here, as emails may be encrypted.
+func listUsersFilter(req *ListUsersRequest) (filter.Expr, error) {
+	expr, err := filter.Parse(req.Filter, userFilterFields)
+	if err != nil {
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid filter: %v", err))
+	}
+
+	if req.SearchQuery != "" {
+		expr = filter.And(expr, filter.Contains(userNameField, req.SearchQuery))
+	}
+	return expr, nil
+}
+
 func (u *userUsecase) BulkCreateUsers(ctx context.Context, users []BulkCreateUserRequest) (*BulkCreateUsersResponse, error) {
 	var createdUsers []*domain.User
 	var failedEmails []string
 
 	for _, userReq := range users {
-		user, err := u.CreateUser(ctx, userReq.Name, userReq.Email)
+		user, err := u.CreateUser(ctx, userReq.Name, userReq.Email, userReq.Metadata)
 		if err != nil {
 			failedEmails = append(failedEmails, userReq.Email)
 			continue
@@ -233,13 +354,28 @@ func (u *userUsecase) BulkCreateUsers(ctx context.Context, users []BulkCreateUse
 }
 
 // Helper methods
+
+// canonicalEmail canonicalizes email according to the configured options
+func (u *userUsecase) canonicalEmail(email string) string {
+	return domain.CanonicalEmail(email, u.options.StripEmailPlusTags)
+}
+
+// searchPattern is the ILIKE pattern of the names and emails containing query.
+// Where emails are encrypted, the repository only matches whole emails.
+func searchPattern(query string) string {
+	return "%" + query + "%"
+}
+
 func (u *userUsecase) mapDBUserToDomain(dbUser sqlc.User) *domain.User {
 	return &domain.User{
 		ID:        dbUser.ID,
 		Name:      dbUser.Name,
 		Email:     dbUser.Email,
+		Metadata:  decodeMetadata(dbUser.Metadata),
 		CreatedAt: dbUser.CreatedAt.Time,
 		UpdatedAt: dbUser.UpdatedAt.Time,
+		Version:   dbUser.Version,
+		Role:      domain.Role(dbUser.Role),
 	}
 }
 
@@ -248,7 +384,8 @@ func (u *userUsecase) publishUserCreatedEvent(ctx context.Context, user *domain.
 		EventId:       uuid.New().String(),
 		User:          u.domainUserToProto(user),
 		EventTime:     timestamppb.Now(),
-		CorrelationId: u.getCorrelationID(ctx),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
 		Data: &eventv1.UserCreatedEventData{
 			Source: "user-service",
 			Metadata: map[string]string{
@@ -265,10 +402,11 @@ func (u *userUsecase) publishUserUpdatedEvent(ctx context.Context, user *domain.
 		EventId:       uuid.New().String(),
 		User:          u.domainUserToProto(user),
 		EventTime:     timestamppb.Now(),
-		CorrelationId: u.getCorrelationID(ctx),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
 		Data: &eventv1.UserUpdatedEventData{
 			Source:        "user-service",
-			ChangedFields: []string{"name", "email"},
+			ChangedFields: []string{"name", "email", "metadata"},
 			Metadata: map[string]string{
 				"operation": "update_user",
 				"version":   "v1",
@@ -283,7 +421,8 @@ func (u *userUsecase) publishUserDeletedEvent(ctx context.Context, user *domain.
 		EventId:       uuid.New().String(),
 		User:          u.domainUserToProto(user),
 		EventTime:     timestamppb.Now(),
-		CorrelationId: u.getCorrelationID(ctx),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
 		Data: &eventv1.UserDeletedEventData{
 			Source: "user-service",
 			Reason: "manual_deletion",
@@ -301,13 +440,10 @@ func (u *userUsecase) domainUserToProto(user *domain.User) *v1.User {
 		Id:        user.ID.String(),
 		Name:      user.Name,
 		Email:     user.Email,
+		Metadata:  user.Metadata,
 		CreatedAt: timestamppb.New(user.CreatedAt),
 		UpdatedAt: timestamppb.New(user.UpdatedAt),
+		Version:   user.Version,
+		Role:      string(user.Role),
 	}
 }
-
-func (u *userUsecase) getCorrelationID(ctx context.Context) string {
-	// Try to get correlation ID from context metadata
-	// This is a placeholder - in a real app you'd extract this from gRPC metadata
-	return uuid.New().String()
-}
\ No newline at end of file
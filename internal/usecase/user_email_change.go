@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	eventv1 "github.com/erry-az/go-init/proto/event/v1"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultEmailChangeTTL is how long a confirmation token stays valid when not configured
+const defaultEmailChangeTTL = 24 * time.Hour
+
+func (u *userUsecase) RequestEmailChange(ctx context.Context, userID, newEmail string) (*domain.EmailChangeRequest, error) {
+	user, err := u.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	newEmail = u.canonicalEmail(newEmail)
+	if user.Email == newEmail {
+		return nil, domain.NewValidationError("new email must differ from the current email")
+	}
+
+	// Reject addresses already in use up front; the unique index still guards confirmation
+	existing, err := u.db.GetUserByEmail(ctx, sqlc.GetUserByEmailParams{Email: newEmail})
+	if err == nil && existing.ID != user.ID {
+		return nil, domain.NewConflictError("user with this email already exists")
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to check email: %v", err))
+	}
+
+	ttl := u.options.EmailChangeTTL
+	if ttl <= 0 {
+		ttl = defaultEmailChangeTTL
+	}
+
+	dbRequest, err := u.db.CreateEmailChangeRequest(ctx, sqlc.CreateEmailChangeRequestParams{
+		UserID:    user.ID,
+		NewEmail:  newEmail,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true},
+	})
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to store email change: %v", err))
+	}
+
+	request := &domain.EmailChangeRequest{
+		ID:        dbRequest.ID,
+		UserID:    dbRequest.UserID,
+		NewEmail:  dbRequest.NewEmail,
+		ExpiresAt: dbRequest.ExpiresAt.Time,
+	}
+
+	// The notification pipeline mints and delivers the token, see
+	// IssueEmailChangeToken; without it the change cannot be confirmed
+	if err := u.publishUserEmailChangeRequestedEvent(ctx, user, request); err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to send email change confirmation: %v", err))
+	}
+
+	return request, nil
+}
+
+func (u *userUsecase) ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	dbRequest, err := u.db.GetEmailChangeRequest(ctx, hashConfirmationToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NewNotFoundError("email change request not found")
+		}
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to get email change request: %v", err))
+	}
+
+	request := &domain.EmailChangeRequest{
+		ID:        dbRequest.ID,
+		UserID:    dbRequest.UserID,
+		NewEmail:  dbRequest.NewEmail,
+		ExpiresAt: dbRequest.ExpiresAt.Time,
+	}
+	if request.IsExpired() {
+		return nil, domain.NewValidationError("email change request has expired")
+	}
+
+	user, err := u.GetUser(ctx, request.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	oldEmail := user.Email
+	user.UpdateDetails(user.Name, request.NewEmail)
+
+	dbMetadata, err := encodeMetadata(user.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	dbUser, err := u.db.UpdateUser(ctx, sqlc.UpdateUserParams{
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Metadata: dbMetadata,
+	})
+	if err != nil {
+		if conflict := conflictError(err); conflict != nil {
+			return nil, conflict
+		}
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to update email: %v", err))
+	}
+
+	// Any other pending change for this user is void once one is applied
+	if err := u.db.DeleteEmailChangeRequests(ctx, user.ID); err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to clear email change requests: %v", err))
+	}
+
+	updatedUser := u.mapDBUserToDomain(dbUser)
+
+	if err := u.publishUserEmailChangedEvent(ctx, updatedUser, oldEmail); err != nil {
+		return nil, domain.NewInternalErrorWithCause("failed to publish user email changed event", err)
+	}
+
+	return updatedUser, nil
+}
+
+func (u *userUsecase) publishUserEmailChangeRequestedEvent(ctx context.Context, user *domain.User, request *domain.EmailChangeRequest) error {
+	event := &eventv1.UserEmailChangeRequestedEvent{
+		EventId:       uuid.New().String(),
+		User:          u.domainUserToProto(user),
+		EventTime:     timestamppb.Now(),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
+		Data: &eventv1.UserEmailChangeRequestedEventData{
+			Source:    "user-service",
+			NewEmail:  request.NewEmail,
+			ExpiresAt: timestamppb.New(request.ExpiresAt),
+			RequestId: request.ID.String(),
+			Metadata: map[string]string{
+				"operation": "request_email_change",
+				"version":   "v1",
+			},
+		},
+	}
+	return u.publisher.Publish(ctx, event)
+}
+
+func (u *userUsecase) publishUserEmailChangedEvent(ctx context.Context, user *domain.User, oldEmail string) error {
+	event := &eventv1.UserEmailChangedEvent{
+		EventId:       uuid.New().String(),
+		User:          u.domainUserToProto(user),
+		EventTime:     timestamppb.Now(),
+		CorrelationId: correlationID(ctx),
+		TenantId:      eventTenantID(ctx),
+		Data: &eventv1.UserEmailChangedEventData{
+			Source:   "user-service",
+			OldEmail: oldEmail,
+			NewEmail: user.Email,
+			Metadata: map[string]string{
+				"operation": "confirm_email_change",
+				"version":   "v1",
+			},
+		},
+	}
+	return u.publisher.Publish(ctx, event)
+}
+
+// IssueEmailChangeToken mints the confirmation token of the email change
+// request requestID and stores its hash through db, so the raw token only
+// exists in the email the caller sends with it. Called again before db
+// commits, it replaces the token. A request that is gone, e.g. confirmed or
+// cleaned up after expiring, returns a not found error.
+func IssueEmailChangeToken(ctx context.Context, db sqlc.Querier, requestID uuid.UUID) (string, error) {
+	token, tokenHash, err := newConfirmationToken()
+	if err != nil {
+		return "", domain.NewInternalError(fmt.Sprintf("failed to generate confirmation token: %v", err))
+	}
+
+	issued, err := db.SetEmailChangeTokenHash(ctx, sqlc.SetEmailChangeTokenHashParams{
+		ID:        requestID,
+		TokenHash: tokenHash,
+	})
+	if err != nil {
+		return "", domain.NewInternalError(fmt.Sprintf("failed to store confirmation token: %v", err))
+	}
+	if issued == 0 {
+		return "", domain.NewNotFoundError("email change request not found")
+	}
+	return token, nil
+}
+
+// newConfirmationToken returns a random URL-safe token and the hash stored in its place
+func newConfirmationToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashConfirmationToken(token), nil
+}
+
+func hashConfirmationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
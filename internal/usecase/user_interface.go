@@ -2,18 +2,36 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/pkg/pagetoken"
 )
 
 // UserUsecase defines the business logic interface for user operations
 type UserUsecase interface {
-	CreateUser(ctx context.Context, name, email string) (*domain.User, error)
+	CreateUser(ctx context.Context, name, email string, metadata map[string]string) (*domain.User, error)
+	CreateOrGetUser(ctx context.Context, name, email string, metadata map[string]string) (user *domain.User, created bool, err error)
 	GetUser(ctx context.Context, userID string) (*domain.User, error)
-	UpdateUser(ctx context.Context, userID, name, email string) (*domain.User, error)
+	UpdateUser(ctx context.Context, userID, name, email string, metadata map[string]string) (*domain.User, error)
 	DeleteUser(ctx context.Context, userID string) error
 	ListUsers(ctx context.Context, req *ListUsersRequest) (*ListUsersResponse, error)
 	BulkCreateUsers(ctx context.Context, users []BulkCreateUserRequest) (*BulkCreateUsersResponse, error)
+	RequestEmailChange(ctx context.Context, userID, newEmail string) (*domain.EmailChangeRequest, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error)
+	SetUserRole(ctx context.Context, userID string, role domain.Role) (*domain.User, error)
+}
+
+// UserOptions tunes the user workflows
+type UserOptions struct {
+	// EmailChangeTTL is how long an email change confirmation token is valid
+	EmailChangeTTL time.Duration
+	// RequireEmailConfirmation rejects email changes through UpdateUser
+	RequireEmailConfirmation bool
+	// StripEmailPlusTags removes "+tag" from the local part when canonicalizing emails
+	StripEmailPlusTags bool
+	// PageTokens encodes and decodes the page tokens of ListUsers
+	PageTokens pagetoken.Codec
 }
 
 // Request/Response types for operations that need multiple parameters
@@ -21,6 +39,8 @@ type ListUsersRequest struct {
 	PageSize    int32
 	PageToken   string
 	SearchQuery string
+	// Filter is an AIP-160 style expression, e.g. `metadata.team = "payments"`
+	Filter string
 }
 
 type ListUsersResponse struct {
@@ -30,8 +50,9 @@ type ListUsersResponse struct {
 }
 
 type BulkCreateUserRequest struct {
-	Name  string
-	Email string
+	Name     string
+	Email    string
+	Metadata map[string]string
 }
 
 type BulkCreateUsersResponse struct {
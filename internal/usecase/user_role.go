@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+)
+
+func (u *userUsecase) SetUserRole(ctx context.Context, userID string, role domain.Role) (*domain.User, error) {
+	if !role.IsValid() {
+		return nil, domain.NewValidationError(fmt.Sprintf("unknown role %q", role))
+	}
+
+	user, err := u.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	dbUser, err := u.db.UpdateUserRole(ctx, sqlc.UpdateUserRoleParams{
+		ID:   user.ID,
+		Role: string(role),
+	})
+	if err != nil {
+		return nil, domain.NewInternalError(fmt.Sprintf("failed to update user role: %v", err))
+	}
+
+	updatedUser := u.mapDBUserToDomain(dbUser)
+	slog.Info("User role changed", "user_id", updatedUser.ID, "previous_role", user.Role, "role", updatedUser.Role)
+
+	if err := u.publishUserUpdatedEvent(ctx, updatedUser); err != nil {
+		return nil, domain.NewInternalErrorWithCause("failed to publish user updated event", err)
+	}
+
+	return updatedUser, nil
+}
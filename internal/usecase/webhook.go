@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/erry-az/go-init/internal/domain"
+	"github.com/erry-az/go-init/internal/repository/sqlc"
+	commandv1 "github.com/erry-az/go-init/proto/command/v1"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type webhookUsecase struct {
+	db       sqlc.Querier
+	commands *cqrs.CommandBus
+	handlers map[string]WebhookHandler
+}
+
+// NewWebhookUsecase creates a new webhook usecase instance. Deliveries are
+// stored through db, which must not be routed by tenant region, and processed
+// by the handler of their provider.
+func NewWebhookUsecase(db sqlc.Querier, commands *cqrs.CommandBus, handlers map[string]WebhookHandler) WebhookUsecase {
+	return &webhookUsecase{
+		db:       db,
+		commands: commands,
+		handlers: handlers,
+	}
+}
+
+func (u *webhookUsecase) ReceiveWebhook(ctx context.Context, provider, eventID, eventType string, payload []byte) error {
+	if eventID == "" {
+		return domain.NewValidationError("webhook event ID is required")
+	}
+
+	dbDelivery, err := u.db.UpsertWebhookDelivery(ctx, sqlc.UpsertWebhookDeliveryParams{
+		ID:        uuid.New(),
+		Provider:  provider,
+		EventID:   eventID,
+		EventType: eventType,
+		Payload:   payload,
+	})
+	if err != nil {
+		return domain.NewInternalError(fmt.Sprintf("failed to store webhook delivery: %v", err))
+	}
+
+	// A resent event is queued again while pending, as the provider resends
+	// when queuing it failed before
+	if mapDBWebhookDeliveryToDomain(dbDelivery).IsDone() {
+		return nil
+	}
+
+	err = u.commands.Send(ctx, &commandv1.ProcessWebhookCommand{
+		DeliveryId: dbDelivery.ID.String(),
+		Provider:   provider,
+	})
+	if err != nil {
+		return domain.NewInternalError(fmt.Sprintf("failed to enqueue webhook delivery: %v", err))
+	}
+	return nil
+}
+
+// ProcessWebhook runs the handler of the delivery's provider and records its
+// outcome. Rejections such as validation errors fail the delivery; other
+// errors are returned so the command is redelivered.
+func (u *webhookUsecase) ProcessWebhook(ctx context.Context, cmd *commandv1.ProcessWebhookCommand) error {
+	id, err := uuid.Parse(cmd.DeliveryId)
+	if err != nil {
+		slog.Warn("Dropping command with invalid webhook delivery ID", "delivery_id", cmd.DeliveryId)
+		return nil
+	}
+
+	dbDelivery, err := u.db.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			slog.Warn("Dropping command of unknown webhook delivery", "delivery_id", cmd.DeliveryId)
+			return nil
+		}
+		return fmt.Errorf("get webhook delivery %s: %w", cmd.DeliveryId, err)
+	}
+
+	// A redelivered command must not process a finished delivery again
+	delivery := mapDBWebhookDeliveryToDomain(dbDelivery)
+	if delivery.IsDone() {
+		return nil
+	}
+
+	handler, ok := u.handlers[delivery.Provider]
+	if !ok {
+		return u.fail(ctx, id, fmt.Sprintf("no webhook handler for provider %q", delivery.Provider))
+	}
+
+	if err := handler(ctx, delivery); err != nil {
+		var domainErr *domain.DomainError
+		if errors.As(err, &domainErr) && domainErr.Type != domain.ErrorTypeInternal {
+			return u.fail(ctx, id, domainErr.Message)
+		}
+
+		recordErr := u.db.RecordWebhookDeliveryError(ctx, sqlc.RecordWebhookDeliveryErrorParams{
+			Error: pgtype.Text{String: err.Error(), Valid: true},
+			ID:    id,
+		})
+		if recordErr != nil {
+			slog.Error("Failed to record webhook delivery error", "delivery_id", id, slog.Any("error", recordErr))
+		}
+		return err
+	}
+
+	if err := u.db.CompleteWebhookDelivery(ctx, id); err != nil {
+		return fmt.Errorf("complete webhook delivery %s: %w", cmd.DeliveryId, err)
+	}
+	return nil
+}
+
+// fail marks the delivery as failed with reason
+func (u *webhookUsecase) fail(ctx context.Context, id uuid.UUID, reason string) error {
+	err := u.db.FailWebhookDelivery(ctx, sqlc.FailWebhookDeliveryParams{
+		Error: pgtype.Text{String: reason, Valid: true},
+		ID:    id,
+	})
+	if err != nil {
+		slog.Error("Failed to mark webhook delivery as failed", "delivery_id", id, slog.Any("error", err))
+		return fmt.Errorf("fail webhook delivery %s: %w", id, err)
+	}
+	return nil
+}
+
+func mapDBWebhookDeliveryToDomain(dbDelivery sqlc.WebhookDelivery) *domain.WebhookDelivery {
+	delivery := &domain.WebhookDelivery{
+		ID:         dbDelivery.ID,
+		Provider:   dbDelivery.Provider,
+		EventID:    dbDelivery.EventID,
+		EventType:  dbDelivery.EventType,
+		Payload:    dbDelivery.Payload,
+		Status:     domain.WebhookDeliveryStatus(dbDelivery.Status),
+		Deliveries: int(dbDelivery.Deliveries),
+		Attempts:   int(dbDelivery.Attempts),
+		Error:      dbDelivery.Error.String,
+		ReceivedAt: dbDelivery.ReceivedAt.Time,
+	}
+	if dbDelivery.ProcessedAt.Valid {
+		processedAt := dbDelivery.ProcessedAt.Time
+		delivery.ProcessedAt = &processedAt
+	}
+	return delivery
+}
@@ -0,0 +1,24 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/erry-az/go-init/internal/domain"
+	commandv1 "github.com/erry-az/go-init/proto/command/v1"
+)
+
+// WebhookUsecase stores verified webhook deliveries and processes them asynchronously
+type WebhookUsecase interface {
+	// ReceiveWebhook stores an event of provider and queues it for processing.
+	// An event received again is stored once, and queued again while pending.
+	ReceiveWebhook(ctx context.Context, provider, eventID, eventType string, payload []byte) error
+
+	// Command handler processing a stored delivery
+	ProcessWebhook(ctx context.Context, cmd *commandv1.ProcessWebhookCommand) error
+}
+
+// WebhookHandler processes the deliveries of one provider. Deliveries are
+// processed at least once, so handlers must tolerate repeats. A domain error
+// other than an internal one fails the delivery for good; any other error
+// retries it.
+type WebhookHandler func(ctx context.Context, delivery *domain.WebhookDelivery) error
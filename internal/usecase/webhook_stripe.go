@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/erry-az/go-init/internal/domain"
+)
+
+// stripeEvent is the envelope of every Stripe webhook event
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// stripeCustomer is the object of customer.* events
+type stripeCustomer struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+// HandleStripeWebhook is an example handler of Stripe events, logging the
+// customers created or updated and ignoring other event types. Add a case per
+// event type the service reacts to.
+func HandleStripeWebhook(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	var event stripeEvent
+	if err := json.Unmarshal(delivery.Payload, &event); err != nil {
+		return domain.NewValidationError(fmt.Sprintf("invalid Stripe event: %v", err))
+	}
+
+	switch event.Type {
+	case "customer.created", "customer.updated":
+		var customer stripeCustomer
+		if err := json.Unmarshal(event.Data.Object, &customer); err != nil {
+			return domain.NewValidationError(fmt.Sprintf("invalid Stripe customer: %v", err))
+		}
+		slog.InfoContext(ctx, "Stripe customer changed", "event_id", event.ID, "type", event.Type,
+			"customer_id", customer.ID, "email", customer.Email)
+	default:
+		slog.DebugContext(ctx, "Ignoring Stripe event", "event_id", event.ID, "type", event.Type)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package watchdog
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Defaults used when Options leaves a value unset
+const (
+	defaultInterval   = 10 * time.Second
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = time.Minute
+)
+
+// Counters keyed by component name, exported on /debug/vars
+var (
+	checkFailures   = expvar.NewMap("watchdog_check_failures_total")
+	restarts        = expvar.NewMap("watchdog_restarts_total")
+	restartFailures = expvar.NewMap("watchdog_restart_failures_total")
+)
+
+// CheckFunc returns an error when a component is unhealthy
+type CheckFunc func(ctx context.Context) error
+
+// RestartFunc tears down and rebuilds a component
+type RestartFunc func(ctx context.Context) error
+
+// Options configures check frequency and restart backoff
+type Options struct {
+	Interval   time.Duration
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type component struct {
+	name    string
+	check   CheckFunc
+	restart RestartFunc
+}
+
+// Watchdog periodically health-checks components and restarts the ones that
+// fail, retrying with jittered exponential backoff until they recover
+type Watchdog struct {
+	opts       Options
+	components []component
+}
+
+// New creates a watchdog with no components
+func New(opts Options) *Watchdog {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = max(defaultMaxBackoff, opts.MinBackoff)
+	}
+	return &Watchdog{opts: opts}
+}
+
+// Watch registers a component to supervise
+func (w *Watchdog) Watch(name string, check CheckFunc, restart RestartFunc) {
+	w.components = append(w.components, component{name: name, check: check, restart: restart})
+}
+
+// Run supervises all components and blocks until ctx is cancelled
+func (w *Watchdog) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, c := range w.components {
+		wg.Add(1)
+		go func(c component) {
+			defer wg.Done()
+			w.supervise(ctx, c)
+		}(c)
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (w *Watchdog) supervise(ctx context.Context, c component) {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := c.check(ctx)
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+
+			checkFailures.Add(c.name, 1)
+			slog.Warn("Component unhealthy, restarting", "component", c.name, slog.Any("error", err))
+			w.recover(ctx, c)
+		}
+	}
+}
+
+// recover restarts c until it passes its health check or ctx is cancelled
+func (w *Watchdog) recover(ctx context.Context, c component) {
+	backoff := w.opts.MinBackoff
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := c.restart(ctx)
+		if err == nil {
+			err = c.check(ctx)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			restarts.Add(c.name, 1)
+			slog.Info("Component restarted", "component", c.name, "attempt", attempt, "duration", time.Since(start))
+			return
+		}
+
+		restartFailures.Add(c.name, 1)
+		wait := jitter(backoff)
+		slog.Error("Component restart failed", "component", c.name, "attempt", attempt,
+			"retry_in", wait, slog.Any("error", err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, w.opts.MaxBackoff)
+	}
+}
+
+// jitter returns a random delay between half of and the full backoff
+func jitter(backoff time.Duration) time.Duration {
+	half := backoff / 2
+	return half + rand.N(backoff-half+1)
+}
@@ -0,0 +1,153 @@
+// Package admission bounds the work a server takes on at once. Every queue
+// runs up to MaxInFlight calls, makes up to MaxQueue more wait for a slot and
+// refuses the rest right away, so under overload callers get fast failures
+// and latency stays bounded instead of goroutines piling up on exhausted
+// database connections.
+package admission
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned when a queue holds MaxQueue waiting calls already
+	ErrQueueFull = errors.New("admission: queue is full")
+	// ErrWaitTimeout is returned when a call waited MaxWait without getting a slot
+	ErrWaitTimeout = errors.New("admission: timed out waiting for a slot")
+)
+
+// Gauges and counters keyed by queue name, exported on /debug/vars
+var (
+	queueLength = expvar.NewMap("admission_queue_length")
+	inFlight    = expvar.NewMap("admission_in_flight")
+	rejections  = expvar.NewMap("admission_rejections_total")
+	timeouts    = expvar.NewMap("admission_wait_timeouts_total")
+)
+
+// Limits bounds the calls of a queue
+type Limits struct {
+	// MaxInFlight is how many calls run at once
+	MaxInFlight int
+	// MaxQueue is how many calls wait for a slot; 0 refuses every call
+	// arriving while MaxInFlight run
+	MaxQueue int
+	// MaxWait is how long a call waits for a slot, until its deadline when 0
+	MaxWait time.Duration
+}
+
+// Queue admits the calls of one kind of work, e.g. one usecase
+type Queue struct {
+	name   string
+	limits Limits
+	slots  chan struct{}
+
+	mu      sync.Mutex
+	waiting int
+}
+
+// NewQueue creates a queue exporting its metrics under name
+func NewQueue(name string, limits Limits) *Queue {
+	limits.MaxInFlight = max(limits.MaxInFlight, 1)
+	limits.MaxQueue = max(limits.MaxQueue, 0)
+
+	queueLength.Set(name, new(expvar.Int))
+	inFlight.Set(name, new(expvar.Int))
+	return &Queue{
+		name:   name,
+		limits: limits,
+		slots:  make(chan struct{}, limits.MaxInFlight),
+	}
+}
+
+// Acquire takes a slot, waiting for one while the queue has room. The
+// returned release gives the slot back and must be called once the call is
+// done. It fails with ErrQueueFull, ErrWaitTimeout or the error of ctx.
+func (q *Queue) Acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case q.slots <- struct{}{}:
+		return q.admitted(), nil
+	default:
+	}
+
+	q.mu.Lock()
+	if q.waiting >= q.limits.MaxQueue {
+		q.mu.Unlock()
+		rejections.Add(q.name, 1)
+		return nil, ErrQueueFull
+	}
+	q.waiting++
+	q.mu.Unlock()
+	queueLength.Add(q.name, 1)
+
+	defer func() {
+		q.mu.Lock()
+		q.waiting--
+		q.mu.Unlock()
+		queueLength.Add(q.name, -1)
+	}()
+
+	var expired <-chan time.Time
+	if q.limits.MaxWait > 0 {
+		timer := time.NewTimer(q.limits.MaxWait)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case q.slots <- struct{}{}:
+		return q.admitted(), nil
+	case <-expired:
+		timeouts.Add(q.name, 1)
+		return nil, ErrWaitTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (q *Queue) admitted() func() {
+	inFlight.Add(q.name, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			inFlight.Add(q.name, -1)
+			<-q.slots
+		})
+	}
+}
+
+// Controller holds a queue per kind of work, each with the default limits
+// unless overridden
+type Controller struct {
+	queues map[string]*Queue
+}
+
+// NewController creates the queues named in names, limited by limits with
+// the values set in their overrides replacing the defaults
+func NewController(names []string, limits Limits, overrides map[string]Limits) *Controller {
+	c := &Controller{queues: make(map[string]*Queue, len(names))}
+	for _, name := range names {
+		l := limits
+		if override, ok := overrides[name]; ok {
+			if override.MaxInFlight > 0 {
+				l.MaxInFlight = override.MaxInFlight
+			}
+			if override.MaxQueue > 0 {
+				l.MaxQueue = override.MaxQueue
+			}
+			if override.MaxWait > 0 {
+				l.MaxWait = override.MaxWait
+			}
+		}
+		c.queues[name] = NewQueue(name, l)
+	}
+	return c
+}
+
+// Queue returns the queue named name, or nil for work that is not queued
+func (c *Controller) Queue(name string) *Queue {
+	return c.queues[name]
+}
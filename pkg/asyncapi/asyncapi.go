@@ -0,0 +1,105 @@
+// Package asyncapi builds AsyncAPI 3.0 documents, describing the payloads of
+// messages with JSON Schemas derived from their proto definitions.
+package asyncapi
+
+// Version is the AsyncAPI specification version of the documents built here
+const Version = "3.0.0"
+
+// Document is an AsyncAPI document, holding the subset of the specification
+// generated here
+type Document struct {
+	AsyncAPI           string               `json:"asyncapi"`
+	Info               Info                 `json:"info"`
+	DefaultContentType string               `json:"defaultContentType,omitempty"`
+	Servers            map[string]Server    `json:"servers,omitempty"`
+	Channels           map[string]Channel   `json:"channels"`
+	Operations         map[string]Operation `json:"operations"`
+	Components         Components           `json:"components"`
+}
+
+// Info describes the application the document is about
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a broker the application connects to
+type Server struct {
+	Host        string `json:"host"`
+	Protocol    string `json:"protocol"`
+	Description string `json:"description,omitempty"`
+}
+
+// Channel is where messages are published, e.g. a topic
+type Channel struct {
+	Address     string         `json:"address"`
+	Description string         `json:"description,omitempty"`
+	Messages    map[string]Ref `json:"messages"`
+}
+
+// Operation is a message the application sends or receives on a channel
+type Operation struct {
+	// Action is send or receive
+	Action   string `json:"action"`
+	Channel  Ref    `json:"channel"`
+	Summary  string `json:"summary,omitempty"`
+	Messages []Ref  `json:"messages"`
+}
+
+// Components holds the messages and schemas channels refer to
+type Components struct {
+	Messages map[string]Message `json:"messages"`
+	Schemas  map[string]Schema  `json:"schemas"`
+}
+
+// Message describes the headers and payload of a message
+type Message struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Summary     string `json:"summary,omitempty"`
+	Description string `json:"description,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Headers     Schema `json:"headers,omitempty"`
+	Payload     Schema `json:"payload"`
+}
+
+// Ref refers to an object elsewhere in the document
+type Ref struct {
+	Ref string `json:"$ref"`
+}
+
+// Schema is a JSON Schema
+type Schema map[string]any
+
+// NewDocument returns an empty document about the application titled title
+func NewDocument(title, version, description string) *Document {
+	return &Document{
+		AsyncAPI:           Version,
+		Info:               Info{Title: title, Version: version, Description: description},
+		DefaultContentType: "application/json",
+		Channels:           make(map[string]Channel),
+		Operations:         make(map[string]Operation),
+		Components: Components{
+			Messages: make(map[string]Message),
+			Schemas:  make(map[string]Schema),
+		},
+	}
+}
+
+// AddSent describes message, published by the application on a channel of
+// its own at address
+func (d *Document) AddSent(address, description string, message Message) {
+	d.Components.Messages[message.Name] = message
+	d.Channels[message.Name] = Channel{
+		Address:     address,
+		Description: description,
+		Messages:    map[string]Ref{message.Name: {Ref: "#/components/messages/" + message.Name}},
+	}
+	d.Operations["send"+message.Name] = Operation{
+		Action:   "send",
+		Channel:  Ref{Ref: "#/channels/" + message.Name},
+		Summary:  message.Summary,
+		Messages: []Ref{{Ref: "#/channels/" + message.Name + "/messages/" + message.Name}},
+	}
+}
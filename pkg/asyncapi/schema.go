@@ -0,0 +1,116 @@
+package asyncapi
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtoSchema returns a reference to the schema of messages of desc encoded
+// with encoding/json, as the watermill JSON marshaler does: fields are named
+// after the proto field, oneofs after their Go field, enums are numbers and
+// well-known types are plain objects, e.g. a Timestamp has seconds and nanos.
+// The schema of desc and of the messages it holds are added to the
+// components of d.
+func (d *Document) ProtoSchema(desc protoreflect.MessageDescriptor) Schema {
+	name := string(desc.FullName())
+	ref := Schema{"$ref": "#/components/schemas/" + name}
+	if _, ok := d.Components.Schemas[name]; ok {
+		return ref
+	}
+
+	// Registered before the fields so recursive messages refer to it
+	schema := Schema{"type": "object"}
+	d.Components.Schemas[name] = schema
+
+	properties := make(map[string]any)
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.ContainingOneof() != nil && !field.ContainingOneof().IsSynthetic() {
+			continue
+		}
+		properties[string(field.Name())] = d.fieldSchema(field)
+	}
+
+	oneofs := desc.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		oneof := oneofs.Get(i)
+		if oneof.IsSynthetic() {
+			continue
+		}
+		// The Go interface field holds a wrapper struct with a single field
+		var options []any
+		oneofFields := oneof.Fields()
+		for j := 0; j < oneofFields.Len(); j++ {
+			field := oneofFields.Get(j)
+			options = append(options, Schema{
+				"type":       "object",
+				"properties": map[string]any{goName(string(field.Name())): d.fieldSchema(field)},
+			})
+		}
+		properties[goName(string(oneof.Name()))] = Schema{"oneOf": options}
+	}
+
+	schema["properties"] = properties
+	return ref
+}
+
+// fieldSchema returns the schema of the value of field
+func (d *Document) fieldSchema(field protoreflect.FieldDescriptor) Schema {
+	switch {
+	case field.IsMap():
+		return Schema{
+			"type":                 "object",
+			"additionalProperties": d.valueSchema(field.MapValue()),
+		}
+	case field.IsList():
+		return Schema{"type": "array", "items": d.valueSchema(field)}
+	}
+	return d.valueSchema(field)
+}
+
+// valueSchema returns the schema of a single value of field
+func (d *Document) valueSchema(field protoreflect.FieldDescriptor) Schema {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return Schema{"type": "boolean"}
+	case protoreflect.StringKind:
+		return Schema{"type": "string"}
+	case protoreflect.BytesKind:
+		return Schema{"type": "string", "contentEncoding": "base64"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return Schema{"type": "number"}
+	case protoreflect.EnumKind:
+		values := field.Enum().Values()
+		numbers := make([]any, values.Len())
+		names := make([]any, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			numbers[i] = int32(values.Get(i).Number())
+			names[i] = string(values.Get(i).Name())
+		}
+		return Schema{"type": "integer", "enum": numbers, "x-enum-varnames": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return d.ProtoSchema(field.Message())
+	}
+	// Every integer kind, 64-bit ones included, is a JSON number
+	return Schema{"type": "integer"}
+}
+
+// goName returns the Go name protoc-gen-go gives a field or oneof, e.g.
+// StringValue for string_value
+func goName(name string) string {
+	out := make([]byte, 0, len(name))
+	upper := true
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '_':
+			upper = true
+			continue
+		case upper && c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		}
+		upper = c >= '0' && c <= '9'
+		out = append(out, c)
+	}
+	return string(out)
+}
@@ -0,0 +1,73 @@
+// Package correlation carries the request and correlation IDs of a request
+// through its context, so the logs, traces and events it causes can be
+// matched. The request ID names one request, the correlation ID the whole
+// flow it is part of, e.g. the request that queued a command and the events
+// its handling published.
+package correlation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Headers and gRPC metadata the IDs are sent in
+const (
+	RequestIDKey     = "x-request-id"
+	CorrelationIDKey = "x-correlation-id"
+)
+
+// maxIDLength bounds the IDs taken from callers, which are logged verbatim
+const maxIDLength = 128
+
+// IDs identifies a request and the flow it is part of
+type IDs struct {
+	RequestID     string
+	CorrelationID string
+}
+
+type idsKey struct{}
+
+// NewContext returns a copy of ctx carrying ids
+func NewContext(ctx context.Context, ids IDs) context.Context {
+	return context.WithValue(ctx, idsKey{}, ids)
+}
+
+// FromContext returns the IDs ctx carries, empty when it carries none
+func FromContext(ctx context.Context) IDs {
+	ids, _ := ctx.Value(idsKey{}).(IDs)
+	return ids
+}
+
+// ID returns the correlation ID ctx carries, empty when it carries none
+func ID(ctx context.Context) string {
+	return FromContext(ctx).CorrelationID
+}
+
+// Resolve returns the IDs of a request sending requestID and correlationID,
+// either of which may be empty. A request without a valid request ID is
+// given a new one, and a request without a valid correlation ID starts a
+// flow correlated by its request ID.
+func Resolve(requestID, correlationID string) IDs {
+	if !valid(requestID) {
+		requestID = uuid.NewString()
+	}
+	if !valid(correlationID) {
+		correlationID = requestID
+	}
+	return IDs{RequestID: requestID, CorrelationID: correlationID}
+}
+
+// valid reports whether id is short and printable ASCII, so it cannot forge
+// log lines or headers
+func valid(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
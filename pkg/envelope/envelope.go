@@ -0,0 +1,166 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix marks values produced by Encryptor so plaintext written before
+// encryption was enabled can still be read
+const prefix = "enc:v1:"
+
+var (
+	ErrMalformedCiphertext = errors.New("malformed ciphertext")
+	ErrUnknownKey          = errors.New("unknown key encryption key")
+)
+
+// KeyProvider wraps and unwraps data encryption keys with a key encryption key,
+// typically held by a KMS. Key IDs must not contain ':'.
+type KeyProvider interface {
+	// ActiveKeyID returns the key used for new encryptions
+	ActiveKeyID() string
+	WrapKey(ctx context.Context, keyID string, dek []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Encryptor performs envelope encryption: every value gets a fresh data key
+// which is stored next to the ciphertext, wrapped by the provider's active key.
+type Encryptor struct {
+	provider KeyProvider
+}
+
+// NewEncryptor creates an encryptor backed by the given key provider
+func NewEncryptor(provider KeyProvider) *Encryptor {
+	return &Encryptor{provider: provider}
+}
+
+// Encrypt returns the encoded ciphertext of plaintext
+func (e *Encryptor) Encrypt(ctx context.Context, plaintext []byte) (string, error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("generate data key: %w", err)
+	}
+
+	keyID := e.provider.ActiveKeyID()
+	wrapped, err := e.provider.WrapKey(ctx, keyID, dek)
+	if err != nil {
+		return "", fmt.Errorf("wrap data key: %w", err)
+	}
+
+	sealed, err := seal(dek, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + keyID + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of value. Values that were never encrypted are returned as-is.
+func (e *Encryptor) Decrypt(ctx context.Context, value string) ([]byte, error) {
+	if !IsEncrypted(value) {
+		return []byte(value), nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return nil, ErrMalformedCiphertext
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedCiphertext
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedCiphertext
+	}
+
+	dek, err := e.provider.UnwrapKey(ctx, parts[0], wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	return open(dek, sealed)
+}
+
+// EncryptString is a convenience wrapper around Encrypt
+func (e *Encryptor) EncryptString(ctx context.Context, plaintext string) (string, error) {
+	return e.Encrypt(ctx, []byte(plaintext))
+}
+
+// DecryptString is a convenience wrapper around Decrypt
+func (e *Encryptor) DecryptString(ctx context.Context, value string) (string, error) {
+	plaintext, err := e.Decrypt(ctx, value)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether value is plaintext or wrapped by a key other than the active one
+func (e *Encryptor) NeedsRotation(value string) bool {
+	return KeyID(value) != e.provider.ActiveKeyID()
+}
+
+// ActivePrefix returns the ciphertext prefix shared by all values encrypted with the active key
+func (e *Encryptor) ActivePrefix() string {
+	return prefix + e.provider.ActiveKeyID() + ":"
+}
+
+// IsEncrypted reports whether value was produced by an Encryptor
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID returns the key encryption key ID of value, or "" for plaintext
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return keyID
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformedCiphertext
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
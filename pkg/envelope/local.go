@@ -0,0 +1,58 @@
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+)
+
+// LocalKeyProvider wraps data keys with AES-256 keys held in memory.
+// It is intended for development and for deployments without a KMS.
+type LocalKeyProvider struct {
+	activeKeyID string
+	keys        map[string][]byte
+}
+
+// NewLocalKeyProvider creates a provider from base64-encoded 32-byte keys keyed by ID
+func NewLocalKeyProvider(activeKeyID string, encodedKeys map[string]string) (*LocalKeyProvider, error) {
+	keys := make(map[string][]byte, len(encodedKeys))
+	for id, encoded := range encodedKeys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode key %s: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %s must be 32 bytes, got %d", id, len(key))
+		}
+		keys[id] = key
+	}
+
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, activeKeyID)
+	}
+
+	return &LocalKeyProvider{
+		activeKeyID: activeKeyID,
+		keys:        keys,
+	}, nil
+}
+
+func (p *LocalKeyProvider) ActiveKeyID() string {
+	return p.activeKeyID
+}
+
+func (p *LocalKeyProvider) WrapKey(_ context.Context, keyID string, dek []byte) ([]byte, error) {
+	kek, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	return seal(kek, dek)
+}
+
+func (p *LocalKeyProvider) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	return open(kek, wrapped)
+}
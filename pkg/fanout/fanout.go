@@ -0,0 +1,97 @@
+// Package fanout delivers every value published to a hub to each of its
+// current subscribers, e.g. the events an instance receives to the streams
+// of its clients.
+//
+// Publishing never blocks: a subscriber whose buffer is full is dropped, its
+// channel closed, so one slow reader holds up neither the publisher nor the
+// others.
+package fanout
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Hub fans published values out to its subscribers
+type Hub[T any] struct {
+	buffer int
+
+	mu   sync.Mutex
+	subs map[*Subscription[T]]struct{}
+}
+
+// New creates a hub buffering up to buffer values per subscriber
+func New[T any](buffer int) *Hub[T] {
+	return &Hub[T]{
+		buffer: max(buffer, 1),
+		subs:   make(map[*Subscription[T]]struct{}),
+	}
+}
+
+// Subscription receives the values published after it was made
+type Subscription[T any] struct {
+	hub     *Hub[T]
+	c       chan T
+	dropped atomic.Bool
+}
+
+// Subscribe subscribes to the values published from now on. The
+// subscription must be closed once no longer read.
+func (h *Hub[T]) Subscribe() *Subscription[T] {
+	s := &Subscription[T]{hub: h, c: make(chan T, h.buffer)}
+
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+// Publish delivers v to every subscriber with room for it and drops the others
+func (h *Hub[T]) Publish(v T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subs {
+		select {
+		case s.c <- v:
+		default:
+			s.dropped.Store(true)
+			h.remove(s)
+		}
+	}
+}
+
+// Len returns the number of subscribers
+func (h *Hub[T]) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs)
+}
+
+// remove closes the channel of s unless already removed; h.mu must be held
+func (h *Hub[T]) remove(s *Subscription[T]) {
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.c)
+	}
+}
+
+// C returns the channel values are delivered on, closed once the
+// subscription is closed or dropped
+func (s *Subscription[T]) C() <-chan T {
+	return s.c
+}
+
+// Dropped reports whether the subscription was dropped for falling behind
+func (s *Subscription[T]) Dropped() bool {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes
+func (s *Subscription[T]) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s)
+}
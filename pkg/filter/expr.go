@@ -0,0 +1,160 @@
+package filter
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Expr is a filter condition rendered to SQL with positional parameters.
+// Column names only ever come from the allowlisted fields; every value is a parameter.
+type Expr interface {
+	build(b *builder)
+}
+
+// Build renders expr as a SQL condition whose parameters are numbered from
+// firstParam. A nil expr matches every row.
+func Build(expr Expr, firstParam int) (string, []any) {
+	if expr == nil {
+		return "TRUE", nil
+	}
+
+	b := &builder{next: firstParam}
+	expr.build(b)
+	return b.sql.String(), b.args
+}
+
+type builder struct {
+	sql  strings.Builder
+	args []any
+	next int
+}
+
+// param writes a placeholder for value
+func (b *builder) param(value any) {
+	b.sql.WriteString("$" + strconv.Itoa(b.next))
+	b.args = append(b.args, value)
+	b.next++
+}
+
+// And matches rows matching every non-nil expr
+func And(exprs ...Expr) Expr {
+	return joinExprs(" AND ", exprs)
+}
+
+// Or matches rows matching any non-nil expr
+func Or(exprs ...Expr) Expr {
+	return joinExprs(" OR ", exprs)
+}
+
+func joinExprs(sep string, exprs []Expr) Expr {
+	var kept []Expr
+	for _, expr := range exprs {
+		if expr != nil {
+			kept = append(kept, expr)
+		}
+	}
+
+	switch len(kept) {
+	case 0:
+		return nil
+	case 1:
+		return kept[0]
+	}
+	return junction{sep: sep, exprs: kept}
+}
+
+type junction struct {
+	sep   string
+	exprs []Expr
+}
+
+func (j junction) build(b *builder) {
+	b.sql.WriteString("(")
+	for i, expr := range j.exprs {
+		if i > 0 {
+			b.sql.WriteString(j.sep)
+		}
+		expr.build(b)
+	}
+	b.sql.WriteString(")")
+}
+
+// Not matches rows not matching expr
+func Not(expr Expr) Expr {
+	return negation{expr: expr}
+}
+
+type negation struct {
+	expr Expr
+}
+
+func (n negation) build(b *builder) {
+	b.sql.WriteString("NOT (")
+	n.expr.build(b)
+	b.sql.WriteString(")")
+}
+
+// Compare matches rows whose field compares to value with op. Numbers are
+// passed as decimal strings so no precision is lost on the way to SQL.
+func Compare(field Field, op Operator, value any) Expr {
+	return comparison{field: field, op: op, value: value}
+}
+
+// Contains matches rows whose string field contains value, ignoring case
+func Contains(field Field, value string) Expr {
+	return comparison{field: field, op: OpHas, value: value}
+}
+
+type comparison struct {
+	field Field
+	op    Operator
+	value any
+}
+
+func (c comparison) build(b *builder) {
+	switch {
+	case c.field.Type == TypeMap && len(c.field.path) > 1:
+		b.sql.WriteString("(" + c.field.Column + " #>> ")
+		b.param(c.field.path)
+		b.sql.WriteString(")")
+	case c.field.Type == TypeMap:
+		b.sql.WriteString("(" + c.field.Column + " ->> ")
+		b.param(c.field.path[0])
+		b.sql.WriteString(")")
+	default:
+		b.sql.WriteString(c.field.Column)
+	}
+
+	if c.op == OpHas {
+		b.sql.WriteString(" ILIKE ")
+		b.param("%" + escapeLike(c.value.(string)) + "%")
+		return
+	}
+
+	b.sql.WriteString(" " + string(c.op) + " ")
+	b.param(c.value)
+	if c.field.Type == TypeNumber {
+		b.sql.WriteString("::numeric")
+	}
+}
+
+// escapeLike makes LIKE wildcards in s match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// JSONContains matches rows whose JSON object column contains every key and value of doc
+func JSONContains(field Field, doc []byte) Expr {
+	return jsonContainment{field: field, doc: doc}
+}
+
+type jsonContainment struct {
+	field Field
+	doc   []byte
+}
+
+func (j jsonContainment) build(b *builder) {
+	b.sql.WriteString(j.field.Column + " @> ")
+	b.param(j.doc)
+	b.sql.WriteString("::jsonb")
+}
@@ -0,0 +1,101 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Order sorts rows by one field, breaking ties by the id column so every
+// row has a stable position for keyset pagination
+type Order struct {
+	// Name is the allowlisted name the order was parsed from, e.g. price
+	Name  string
+	Field Field
+	Desc  bool
+}
+
+// ParseOrder compiles an AIP-132 style order_by such as `price desc` against
+// the allowlisted fields. Fields sort ascending unless followed by desc; only
+// a single field is supported. An empty order_by returns the zero Order,
+// which callers treat as their default order.
+func ParseOrder(orderBy string, fields map[string]Field) (Order, error) {
+	words := strings.Fields(orderBy)
+	if len(words) == 0 {
+		return Order{}, nil
+	}
+	if strings.Contains(orderBy, ",") || len(words) > 2 {
+		return Order{}, fmt.Errorf("only a single field can be ordered by")
+	}
+
+	field, ok := fields[words[0]]
+	if !ok || field.Type == TypeMap || field.Type == TypeBool {
+		return Order{}, fmt.Errorf("unknown order field %q", words[0])
+	}
+
+	order := Order{Name: words[0], Field: field}
+	if len(words) == 2 {
+		switch strings.ToLower(words[1]) {
+		case "asc":
+		case "desc":
+			order.Desc = true
+		default:
+			return Order{}, fmt.Errorf("invalid direction %q, must be asc or desc", words[1])
+		}
+	}
+	return order, nil
+}
+
+// IsZero reports whether o is the zero Order, i.e. no order_by was given
+func (o Order) IsZero() bool {
+	return o.Field.Column == ""
+}
+
+// String returns the canonical order_by of o, e.g. `price desc`
+func (o Order) String() string {
+	if o.Desc {
+		return o.Name + " desc"
+	}
+	return o.Name
+}
+
+// SQL renders the ORDER BY list of o
+func (o Order) SQL() string {
+	if o.Desc {
+		return o.Field.Column + " DESC, id DESC"
+	}
+	return o.Field.Column + ", id"
+}
+
+// After matches the rows sorting after the row whose field holds value and
+// whose id is id. value may be text, e.g. taken from a page token, which is
+// cast to the type of the field.
+func (o Order) After(value, id any) Expr {
+	return keyset{order: o, value: value, id: id}
+}
+
+type keyset struct {
+	order Order
+	value any
+	id    any
+}
+
+func (k keyset) build(b *builder) {
+	op := " > "
+	if k.order.Desc {
+		op = " < "
+	}
+
+	b.sql.WriteString("(" + k.order.Field.Column + ", id)" + op + "(")
+	b.param(k.value)
+	switch k.order.Field.Type {
+	case TypeNumber:
+		b.sql.WriteString("::numeric")
+	case TypeTimestamp:
+		b.sql.WriteString("::timestamptz")
+	case TypeString:
+		b.sql.WriteString("::text")
+	}
+	b.sql.WriteString(", ")
+	b.param(k.id)
+	b.sql.WriteString(")")
+}
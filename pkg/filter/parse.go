@@ -0,0 +1,383 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Limits keeping a hostile filter from producing an expensive query
+const (
+	maxLength = 2048
+	maxDepth  = 16
+)
+
+// Operator compares a field with a value
+type Operator string
+
+// Supported comparators; OpHas matches a substring for strings and maps
+const (
+	OpEqual        Operator = "="
+	OpNotEqual     Operator = "!="
+	OpLess         Operator = "<"
+	OpLessEqual    Operator = "<="
+	OpGreater      Operator = ">"
+	OpGreaterEqual Operator = ">="
+	OpHas          Operator = ":"
+)
+
+// FieldType decides which operators and values a field accepts
+type FieldType int
+
+const (
+	TypeString FieldType = iota
+	TypeNumber
+	TypeBool
+	TypeTimestamp
+	// TypeMap is a JSON object column filtered by key, e.g. attributes.color = "red"
+	TypeMap
+)
+
+// Field maps a filterable name to its column
+type Field struct {
+	Column string
+	Type   FieldType
+
+	// path are the JSON keys of a TypeMap member, nested ones after the first
+	path []string
+}
+
+// Member returns the member of a TypeMap field at path, e.g. attributes.color
+// for color, or the name of the de object of translations for de and name
+func (f Field) Member(path ...string) Field {
+	f.path = path
+	return f
+}
+
+var numberPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+// Parse compiles an AIP-160 style filter such as `price > 100 AND name:"phone"`
+// against the allowlisted fields. AND binds looser than OR as in AIP-160,
+// juxtaposed terms are ANDed, and NOT or a leading - negates a term.
+// Timestamps must be quoted RFC 3339 strings. An empty filter returns a nil Expr.
+func Parse(filter string, fields map[string]Field) (Expr, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+	if len(filter) > maxLength {
+		return nil, fmt.Errorf("filter is longer than %d characters", maxLength)
+	}
+
+	tokens, err := lex(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: fields}
+	expr, err := p.expression(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields map[string]Field
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	if tok.kind == tokenWord && tok.text == word {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expression = sequence { "AND" sequence }
+func (p *parser) expression(depth int) (Expr, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("filter is nested deeper than %d levels", maxDepth)
+	}
+
+	exprs := []Expr{}
+	for {
+		expr, err := p.sequence(depth)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		if !p.keyword("AND") {
+			return And(exprs...), nil
+		}
+	}
+}
+
+// sequence = factor { factor }
+func (p *parser) sequence(depth int) (Expr, error) {
+	exprs := []Expr{}
+	for {
+		expr, err := p.factor(depth)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		tok := p.peek()
+		if tok.kind == tokenEOF || tok.kind == tokenRParen || tok.kind == tokenWord && tok.text == "AND" {
+			return And(exprs...), nil
+		}
+	}
+}
+
+// factor = term { "OR" term }
+func (p *parser) factor(depth int) (Expr, error) {
+	exprs := []Expr{}
+	for {
+		expr, err := p.term(depth)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		if !p.keyword("OR") {
+			return Or(exprs...), nil
+		}
+	}
+}
+
+// term = [ "NOT" | "-" ] ( "(" expression ")" | comparison )
+func (p *parser) term(depth int) (Expr, error) {
+	negate := p.keyword("NOT")
+	if !negate && p.peek().kind == tokenMinus {
+		p.next()
+		negate = true
+	}
+
+	if negate {
+		expr, err := p.simple(depth)
+		if err != nil {
+			return nil, err
+		}
+		return Not(expr), nil
+	}
+	return p.simple(depth)
+}
+
+func (p *parser) simple(depth int) (Expr, error) {
+	if p.peek().kind == tokenLParen {
+		p.next()
+		expr, err := p.expression(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ) at position %d", tok.pos)
+		}
+		return expr, nil
+	}
+	return p.comparison()
+}
+
+// comparison = member comparator value
+func (p *parser) comparison() (Expr, error) {
+	member := p.next()
+	if member.kind != tokenWord {
+		return nil, fmt.Errorf("expected a field at position %d", member.pos)
+	}
+	field, err := p.field(member.text)
+	if err != nil {
+		return nil, err
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokenOperator {
+		return nil, fmt.Errorf("expected a comparator after %s at position %d", member.text, opTok.pos)
+	}
+	op := Operator(opTok.text)
+
+	valueTok := p.next()
+	if valueTok.kind != tokenWord && valueTok.kind != tokenString {
+		return nil, fmt.Errorf("expected a value after %s at position %d", member.text, valueTok.pos)
+	}
+
+	value, err := convert(field, op, valueTok)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", member.text, err)
+	}
+	return Compare(field, op, value), nil
+}
+
+// field resolves a member name, including map keys such as attributes.color
+func (p *parser) field(name string) (Field, error) {
+	if field, ok := p.fields[name]; ok && field.Type != TypeMap {
+		return field, nil
+	}
+
+	if base, key, ok := strings.Cut(name, "."); ok && key != "" {
+		if field, ok := p.fields[base]; ok && field.Type == TypeMap {
+			field.path = []string{key}
+			return field, nil
+		}
+	}
+	return Field{}, fmt.Errorf("unknown filter field %q", name)
+}
+
+// convert checks op is allowed on the field and parses the value for its type
+func convert(field Field, op Operator, tok token) (any, error) {
+	switch field.Type {
+	case TypeNumber:
+		if op == OpHas {
+			return nil, fmt.Errorf("operator : is not supported on numbers")
+		}
+		if tok.kind != tokenWord || !numberPattern.MatchString(tok.text) {
+			return nil, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return tok.text, nil
+
+	case TypeBool:
+		if op != OpEqual && op != OpNotEqual {
+			return nil, fmt.Errorf("operator %s is not supported on booleans", op)
+		}
+		if tok.kind != tokenWord || tok.text != "true" && tok.text != "false" {
+			return nil, fmt.Errorf("invalid boolean %q", tok.text)
+		}
+		return tok.text == "true", nil
+
+	case TypeTimestamp:
+		if op == OpHas {
+			return nil, fmt.Errorf("operator : is not supported on timestamps")
+		}
+		ts, err := time.Parse(time.RFC3339, tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RFC 3339 timestamp %q", tok.text)
+		}
+		return ts, nil
+
+	case TypeMap:
+		if op != OpEqual && op != OpNotEqual && op != OpHas {
+			return nil, fmt.Errorf("operator %s is not supported on map keys", op)
+		}
+	}
+	return tok.text, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenMinus
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex splits a filter into words, quoted strings, comparators and parentheses
+func lex(filter string) ([]token, error) {
+	var tokens []token
+	runes := []rune(filter)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+
+		case r == '"' || r == '\'':
+			text, end, err := lexString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i = end
+
+		case strings.ContainsRune("=!<>:", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' && r != ':' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected ! at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+
+		case r == '-' && i+1 < len(runes) && (runes[i+1] == '(' || unicode.IsLetter(runes[i+1])):
+			// A leading - negates the following term; -5 stays a number
+			tokens = append(tokens, token{kind: tokenMinus, text: "-", pos: i})
+			i++
+
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), pos: start})
+
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "end of filter", pos: len(runes)}), nil
+}
+
+// lexString reads the quoted string starting at start, honouring backslash escapes
+func lexString(runes []rune, start int) (string, int, error) {
+	quote := runes[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				sb.WriteRune(runes[i])
+			}
+		case quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-+", r)
+}
@@ -0,0 +1,52 @@
+package jsonschema
+
+import "fmt"
+
+// Registry holds named schemas, e.g. one per product category
+type Registry struct {
+	schemas map[string]*Schema
+	// fallback validates names without a dedicated schema; nil accepts anything
+	fallback *Schema
+}
+
+// NewRegistry loads the schema file for every name in paths.
+// The entry named "*" (if present) is used for names without their own schema.
+func NewRegistry(paths map[string]string) (*Registry, error) {
+	r := &Registry{schemas: make(map[string]*Schema, len(paths))}
+	for name, path := range paths {
+		schema, err := Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("load schema %s: %w", name, err)
+		}
+		if name == "*" {
+			r.fallback = schema
+			continue
+		}
+		r.schemas[name] = schema
+	}
+	return r, nil
+}
+
+// Has reports whether name has a schema of its own or falls back to the "*" one
+func (r *Registry) Has(name string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.schemas[name]
+	return ok || r.fallback != nil
+}
+
+// Validate checks value against the schema registered for name
+func (r *Registry) Validate(name string, value any) error {
+	if r == nil {
+		return nil
+	}
+	schema, ok := r.schemas[name]
+	if !ok {
+		schema = r.fallback
+	}
+	if schema == nil {
+		return nil
+	}
+	return schema.Validate(value)
+}
@@ -0,0 +1,221 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema (draft 2020-12) supported for validating
+// structured attributes: type, enum, const, required, properties,
+// additionalProperties, items, string length/pattern, numeric bounds and array size.
+type Schema struct {
+	Type                 any                `json:"type,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Parse decodes and compiles a schema document
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decode schema: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Load reads and parses a schema file
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("compile pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, prop := range s.Properties {
+		if err := prop.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// ValidationError lists every violation found in a document
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// Validate checks a decoded JSON value (as produced by encoding/json) against the schema
+func (s *Schema) Validate(value any) error {
+	var violations []string
+	s.validate("$", value, &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, value any, violations *[]string) {
+	fail := func(format string, args ...any) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if s.Type != nil && !matchesType(s.Type, value) {
+		fail("expected type %v", s.Type)
+		return
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		fail("value is not one of %v", s.Enum)
+	}
+	if s.Const != nil && !equalValues(s.Const, value) {
+		fail("value must be %v", s.Const)
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("length must be at least %d", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("length must be at most %d", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("value does not match pattern %q", s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			fail("value must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			fail("value must be <= %v", *s.Maximum)
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			prop, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					fail("property %q is not allowed", key)
+				}
+				continue
+			}
+			prop.validate(path+"."+key, v[key], violations)
+		}
+	}
+}
+
+func matchesType(schemaType any, value any) bool {
+	switch t := schemaType.(type) {
+	case string:
+		return matchesSingleType(t, value)
+	case []any:
+		for _, candidate := range t {
+			if name, ok := candidate.(string); ok && matchesSingleType(name, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesSingleType(name string, value any) bool {
+	switch name {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return false
+}
+
+func containsValue(values []any, value any) bool {
+	for _, candidate := range values {
+		if equalValues(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalValues(a, b any) bool {
+	left, errA := json.Marshal(a)
+	right, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(left) == string(right)
+}
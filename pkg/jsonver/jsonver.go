@@ -0,0 +1,106 @@
+// Package jsonver versions the JSON documents of flexible columns, e.g. JSONB
+// product attributes. Documents are stamped with the version of their schema
+// when written, and older documents are upgraded by the migrations registered
+// since when read, so the shape of a column can evolve without a bulk UPDATE
+// rewriting every row: rows move to the new shape as they are next written.
+//
+// Documents written before versioning have no version and count as version 0.
+package jsonver
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"maps"
+)
+
+// VersionKey holds the schema version of a stamped document
+const VersionKey = "$v"
+
+// ErrReservedKey is returned when writing a document that holds VersionKey itself
+var ErrReservedKey = errors.New("key " + VersionKey + " is reserved")
+
+// upgrades counts the documents read in an older version and upgraded, keyed
+// by schema; once it stays at zero the migrations could be dropped after a
+// bulk rewrite
+var upgrades = expvar.NewMap("jsonver_upgrades_total")
+
+// Migration upgrades a document by one version, in place
+type Migration func(doc map[string]any) error
+
+// Schema is the version history of one kind of document
+type Schema struct {
+	name       string
+	migrations []Migration
+}
+
+// NewSchema creates the schema called name, named in errors and metrics. The
+// migration at index i upgrades documents of version i to i+1, so the current
+// version is the number of migrations; append one to change the shape of the
+// documents, never edit or remove one.
+func NewSchema(name string, migrations ...Migration) *Schema {
+	return &Schema{name: name, migrations: migrations}
+}
+
+// Version is the version documents are written in
+func (s *Schema) Version() int {
+	return len(s.migrations)
+}
+
+// Marshal encodes doc, a document in the current version, stamped with it
+func (s *Schema) Marshal(doc map[string]any) ([]byte, error) {
+	if _, ok := doc[VersionKey]; ok {
+		return nil, fmt.Errorf("%s: %w", s.name, ErrReservedKey)
+	}
+
+	stamped := make(map[string]any, len(doc)+1)
+	maps.Copy(stamped, doc)
+	stamped[VersionKey] = s.Version()
+	return json.Marshal(stamped)
+}
+
+// Unmarshal decodes a stored document and upgrades it to the current version,
+// returning it without its version. Documents of a newer version, written by
+// a newer release during a rolling deploy, are returned as they are.
+func (s *Schema) Unmarshal(data []byte) (map[string]any, error) {
+	doc := map[string]any{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.name, err)
+	}
+	if doc == nil {
+		// A JSON null is an empty document
+		doc = map[string]any{}
+	}
+
+	version, err := s.version(doc)
+	if err != nil {
+		return nil, err
+	}
+	delete(doc, VersionKey)
+
+	if version < s.Version() {
+		for v := version; v < s.Version(); v++ {
+			if err := s.migrations[v](doc); err != nil {
+				return nil, fmt.Errorf("%s: migrate version %d to %d: %w", s.name, v, v+1, err)
+			}
+		}
+		upgrades.Add(s.name, 1)
+	}
+	return doc, nil
+}
+
+// version returns the version doc is stamped with, 0 when it has none
+func (s *Schema) version(doc map[string]any) (int, error) {
+	value, ok := doc[VersionKey]
+	if !ok {
+		return 0, nil
+	}
+
+	// encoding/json decodes numbers as float64
+	version, ok := value.(float64)
+	if !ok || version < 0 || version != float64(int(version)) {
+		return 0, fmt.Errorf("%s: invalid document version %v", s.name, value)
+	}
+	return int(version), nil
+}
@@ -0,0 +1,102 @@
+package lazy
+
+import (
+	"expvar"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Component states reported on /debug/vars under "components"
+const (
+	StatePending  = "pending"
+	StateReady    = "ready"
+	StateDegraded = "degraded"
+)
+
+var (
+	statesMu sync.RWMutex
+	states   = make(map[string]string)
+)
+
+func init() {
+	expvar.Publish("components", expvar.Func(func() any { return States() }))
+}
+
+// Value is a component constructed on first use. A failed construction marks
+// the component degraded and is retried on the next Get.
+type Value[T any] struct {
+	name  string
+	init  func() (T, error)
+	mu    sync.Mutex
+	value T
+	ready atomic.Bool
+}
+
+// New registers a component that is built by init when first needed
+func New[T any](name string, init func() (T, error)) *Value[T] {
+	setState(name, StatePending)
+	return &Value[T]{name: name, init: init}
+}
+
+// Get returns the component, constructing it if needed
+func (v *Value[T]) Get() (T, error) {
+	// Built components are returned without locking, as Get may be on a hot path
+	if v.ready.Load() {
+		return v.value, nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.ready.Load() {
+		return v.value, nil
+	}
+
+	value, err := v.init()
+	if err != nil {
+		setState(v.name, StateDegraded)
+		slog.Warn("Component initialization failed", "component", v.name, slog.Any("error", err))
+		var zero T
+		return zero, err
+	}
+
+	v.value = value
+	v.ready.Store(true)
+	setState(v.name, StateReady)
+	return value, nil
+}
+
+// States returns the state of every registered component
+func States() map[string]string {
+	statesMu.RLock()
+	defer statesMu.RUnlock()
+
+	snapshot := make(map[string]string, len(states))
+	for name, state := range states {
+		snapshot[name] = state
+	}
+	return snapshot
+}
+
+// Degraded lists the components whose last initialization attempt failed
+func Degraded() []string {
+	statesMu.RLock()
+	defer statesMu.RUnlock()
+
+	var degraded []string
+	for name, state := range states {
+		if state == StateDegraded {
+			degraded = append(degraded, name)
+		}
+	}
+	sort.Strings(degraded)
+	return degraded
+}
+
+func setState(name, state string) {
+	statesMu.Lock()
+	defer statesMu.Unlock()
+	states[name] = state
+}
@@ -0,0 +1,87 @@
+// Package locale negotiates the locale of localized content from the
+// Accept-Language of a request, sent as the header through the gateway and
+// as accept-language metadata by gRPC callers.
+package locale
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey is the gRPC metadata the gateway forwards Accept-Language as
+const MetadataKey = "accept-language"
+
+// maxRequested bounds the locales taken from one Accept-Language, keeping a
+// hostile header from producing a long fallback chain
+const maxRequested = 8
+
+// wildcard is what the * of Accept-Language parses as
+var wildcard = language.Make("mul")
+
+// Canonical returns the canonical form of a BCP 47 tag, e.g. de-CH for de-ch
+func Canonical(tag string) (string, error) {
+	t, err := language.Parse(tag)
+	if err != nil || t == language.Und {
+		return "", fmt.Errorf("locale: invalid tag %q", tag)
+	}
+	return t.String(), nil
+}
+
+// Parse returns the locales of an Accept-Language value, most preferred
+// first. Malformed values and the * wildcard are ignored.
+func Parse(header string) []string {
+	if header == "" {
+		return nil
+	}
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil {
+		return nil
+	}
+
+	var locales []string
+	for _, tag := range tags {
+		if tag == language.Und || tag == wildcard || len(locales) == maxRequested {
+			continue
+		}
+		locales = append(locales, tag.String())
+	}
+	return locales
+}
+
+// Chain returns the fallback chain of locales: each followed by its parents
+// not already listed, e.g. de-CH, de, fr for de-CH and fr. Malformed locales
+// are skipped.
+func Chain(locales []string) []string {
+	var chain []string
+	for _, l := range locales {
+		tag, err := language.Parse(l)
+		if err != nil {
+			continue
+		}
+		for ; tag != language.Und; tag = tag.Parent() {
+			if s := tag.String(); !slices.Contains(chain, s) {
+				chain = append(chain, s)
+			}
+		}
+	}
+	return chain
+}
+
+// FromContext returns the fallback chain of the accept-language metadata of
+// a gRPC request, empty when it has none
+func FromContext(ctx context.Context) []string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	values := md.Get(MetadataKey)
+	if len(values) == 0 {
+		return nil
+	}
+	return Chain(Parse(values[0]))
+}
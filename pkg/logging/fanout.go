@@ -0,0 +1,20 @@
+package logging
+
+import (
+	"errors"
+	"io"
+)
+
+// Fanout writes every record to all writers. Unlike io.MultiWriter a failing
+// target does not stop the others from receiving the record.
+type Fanout []io.Writer
+
+func (f Fanout) Write(p []byte) (int, error) {
+	var errs []error
+	for _, w := range f {
+		if _, err := w.Write(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat sorts lexically in creation order
+const backupTimeFormat = "20060102T150405.000"
+
+// RotatingFile is an io.WriteCloser that rotates the underlying file once it
+// exceeds a size limit, keeping a bounded number of timestamped backups.
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens path for appending.
+// maxSizeMB triggers rotation, maxAgeDays and maxBackups bound the rotated
+// files kept next to it; zero disables the respective limit.
+func NewRotatingFile(path string, maxSizeMB, maxAgeDays, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+		maxBackups: maxBackups,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate moves the current file aside and starts a new one
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	r.file = nil
+
+	backup := r.path + "." + time.Now().UTC().Format(backupTimeFormat)
+	if err := os.Rename(r.path, backup); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.prune()
+	return nil
+}
+
+// prune removes backups beyond maxBackups or older than maxAge.
+// Failures are ignored; they only leave extra files behind.
+func (r *RotatingFile) prune() {
+	backups, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	cutoff := time.Now().Add(-r.maxAge)
+	for i, backup := range backups {
+		if r.maxBackups > 0 && i >= r.maxBackups {
+			os.Remove(backup)
+			continue
+		}
+		if r.maxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && info.ModTime().Before(cutoff) {
+				os.Remove(backup)
+			}
+		}
+	}
+}
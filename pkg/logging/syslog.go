@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// NewSyslog connects to a syslog daemon. An empty network and address use the
+// local socket, which journald also listens on.
+func NewSyslog(network, address, tag string) (io.WriteCloser, error) {
+	return syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"errors"
+	"io"
+)
+
+// NewSyslog is not available on this platform
+func NewSyslog(network, address, tag string) (io.WriteCloser, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
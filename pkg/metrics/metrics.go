@@ -0,0 +1,368 @@
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the OpenMetrics text exposition content type
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Metric types
+const (
+	TypeGauge     = "gauge"
+	TypeCounter   = "counter"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets are histogram upper bounds suited to durations in seconds,
+// from 5ms to 10s
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Sample is a single labelled value of a metric family
+type Sample struct {
+	// Suffix is appended to the family name, e.g. _bucket for histograms
+	Suffix   string
+	Labels   map[string]string
+	Value    float64
+	Exemplar *Exemplar
+}
+
+// Exemplar links a sample to an example observation, typically the trace it
+// was recorded in
+type Exemplar struct {
+	Labels    map[string]string
+	Value     float64
+	Timestamp time.Time
+}
+
+// Family groups the samples of one metric
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector returns metric families computed at scrape time
+type Collector func() []Family
+
+// Registry holds gauges set by background jobs, counters and histograms
+// updated as things happen plus collectors evaluated on every scrape, and
+// serves them in the OpenMetrics text format
+type Registry struct {
+	mu         sync.RWMutex
+	gauges     map[string]*Family
+	counters   map[string]*Family
+	histograms map[string]*histogram
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		gauges:     make(map[string]*Family),
+		counters:   make(map[string]*Family),
+		histograms: make(map[string]*histogram),
+	}
+}
+
+// SetGauge records the current value of a gauge for the given labels
+func (r *Registry) SetGauge(name, help string, labels map[string]string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	family, ok := r.gauges[name]
+	if !ok {
+		family = &Family{Name: name, Help: help, Type: TypeGauge}
+		r.gauges[name] = family
+	}
+
+	key := labelString(labels)
+	for i, sample := range family.Samples {
+		if labelString(sample.Labels) == key {
+			family.Samples[i].Value = value
+			return
+		}
+	}
+	family.Samples = append(family.Samples, Sample{Labels: labels, Value: value})
+}
+
+// AddCounter adds delta to a counter for the given labels
+func (r *Registry) AddCounter(name, help string, labels map[string]string, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	family, ok := r.counters[name]
+	if !ok {
+		family = &Family{Name: name, Help: help, Type: TypeCounter}
+		r.counters[name] = family
+	}
+
+	key := labelString(labels)
+	for i, sample := range family.Samples {
+		if labelString(sample.Labels) == key {
+			family.Samples[i].Value += delta
+			return
+		}
+	}
+	family.Samples = append(family.Samples, Sample{Labels: labels, Value: delta})
+}
+
+// ObserveHistogram records value in a histogram for the given labels. buckets
+// are the upper bounds of the histogram, fixed by its first observation. The
+// exemplar labels, e.g. a trace ID, may be nil; when set they are kept as the
+// latest exemplar of the bucket value falls in.
+func (r *Registry) ObserveHistogram(name, help string, buckets []float64, labels map[string]string, value float64, exemplar map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.histograms[name]
+	if !ok {
+		h = &histogram{name: name, help: help, buckets: buckets, series: make(map[string]*histogramSeries)}
+		r.histograms[name] = h
+	}
+
+	key := labelString(labels)
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{
+			labels:    labels,
+			counts:    make([]uint64, len(h.buckets)+1),
+			exemplars: make([]*Exemplar, len(h.buckets)+1),
+		}
+		h.series[key] = series
+	}
+
+	// The last bucket is +Inf
+	bucket := sort.SearchFloat64s(h.buckets, value)
+	series.counts[bucket]++
+	series.count++
+	series.sum += value
+	if len(exemplar) > 0 {
+		series.exemplars[bucket] = &Exemplar{Labels: exemplar, Value: value, Timestamp: time.Now()}
+	}
+}
+
+// Register adds a collector evaluated on every scrape
+func (r *Registry) Register(collector Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collector)
+}
+
+// Handler serves all metrics in the OpenMetrics text format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		out := bufio.NewWriter(w)
+		for _, family := range r.families() {
+			writeFamily(out, family)
+		}
+		out.WriteString("# EOF\n")
+		out.Flush()
+	})
+}
+
+func (r *Registry) families() []Family {
+	r.mu.RLock()
+	families := make([]Family, 0, len(r.gauges)+len(r.counters)+len(r.histograms))
+	for _, family := range r.gauges {
+		copied := *family
+		copied.Samples = append([]Sample(nil), family.Samples...)
+		families = append(families, copied)
+	}
+	for _, family := range r.counters {
+		copied := *family
+		copied.Samples = append([]Sample(nil), family.Samples...)
+		families = append(families, copied)
+	}
+	for _, h := range r.histograms {
+		families = append(families, h.family())
+	}
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	for _, collector := range collectors {
+		families = append(families, collector()...)
+	}
+
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// histogram counts observations per bucket for every label set
+type histogram struct {
+	name    string
+	help    string
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels map[string]string
+	// counts and exemplars hold one entry per bucket, plus one for +Inf
+	counts    []uint64
+	exemplars []*Exemplar
+	count     uint64
+	sum       float64
+}
+
+// family renders h with cumulative buckets, series sorted by labels
+func (h *histogram) family() Family {
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	family := Family{Name: h.name, Help: h.help, Type: TypeHistogram}
+	for _, key := range keys {
+		series := h.series[key]
+
+		var cumulative uint64
+		for i, count := range series.counts {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			labels := map[string]string{"le": formatValue(le)}
+			for name, value := range series.labels {
+				labels[name] = value
+			}
+			family.Samples = append(family.Samples, Sample{
+				Suffix:   "_bucket",
+				Labels:   labels,
+				Value:    float64(cumulative),
+				Exemplar: series.exemplars[i],
+			})
+		}
+		family.Samples = append(family.Samples,
+			Sample{Suffix: "_count", Labels: series.labels, Value: float64(series.count)},
+			Sample{Suffix: "_sum", Labels: series.labels, Value: series.sum})
+	}
+	return family
+}
+
+// RuntimeCollector reports Go runtime metrics
+func RuntimeCollector() []Family {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return []Family{
+		{Name: "go_goroutines", Help: "Number of goroutines that currently exist.", Type: TypeGauge,
+			Samples: []Sample{{Value: float64(runtime.NumGoroutine())}}},
+		{Name: "go_memstats_heap_alloc_bytes", Help: "Number of heap bytes allocated and still in use.", Type: TypeGauge,
+			Samples: []Sample{{Value: float64(mem.HeapAlloc)}}},
+		{Name: "go_gc_cycles", Help: "Number of completed GC cycles.", Type: TypeCounter,
+			Samples: []Sample{{Value: float64(mem.NumGC)}}},
+	}
+}
+
+// ExpvarCollector exports integer expvar values as counters. Maps become one
+// sample per key labelled "key"; a name ending in _total keeps the suffix on samples only.
+func ExpvarCollector() []Family {
+	var families []Family
+	expvar.Do(func(kv expvar.KeyValue) {
+		name := strings.TrimSuffix(sanitizeName(kv.Key), "_total")
+
+		switch v := kv.Value.(type) {
+		case *expvar.Int:
+			families = append(families, Family{Name: name, Type: TypeCounter,
+				Samples: []Sample{{Value: float64(v.Value())}}})
+		case *expvar.Map:
+			family := Family{Name: name, Type: TypeCounter}
+			v.Do(func(entry expvar.KeyValue) {
+				if counter, ok := entry.Value.(*expvar.Int); ok {
+					family.Samples = append(family.Samples, Sample{
+						Labels: map[string]string{"key": entry.Key},
+						Value:  float64(counter.Value()),
+					})
+				}
+			})
+			if len(family.Samples) > 0 {
+				families = append(families, family)
+			}
+		}
+	})
+	return families
+}
+
+func writeFamily(w *bufio.Writer, family Family) {
+	if family.Help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", family.Name, escapeHelp(family.Help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", family.Name, family.Type)
+
+	for _, sample := range family.Samples {
+		sampleName := family.Name + sample.Suffix
+		if family.Type == TypeCounter {
+			sampleName += "_total"
+		}
+		fmt.Fprintf(w, "%s%s %s", sampleName, labelString(sample.Labels), formatValue(sample.Value))
+		if ex := sample.Exemplar; ex != nil {
+			fmt.Fprintf(w, " # %s %s %s", labelString(ex.Labels), formatValue(ex.Value),
+				strconv.FormatFloat(float64(ex.Timestamp.UnixMilli())/1000, 'f', 3, 64))
+		}
+		w.WriteString("\n")
+	}
+}
+
+// labelString renders labels sorted by name, e.g. {a="1",b="2"}
+func labelString(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(labels[name]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// sanitizeName maps characters not allowed in metric names to underscores
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
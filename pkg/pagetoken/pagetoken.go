@@ -0,0 +1,140 @@
+package pagetoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"expvar"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Prefixes starting every decoded keyset token, telling them apart from
+// legacy ones: sortedPrefix starts the tokens of lists in another order than
+// (created_at, id)
+const (
+	keysetPrefix = "k1:"
+	sortedPrefix = "k2:"
+)
+
+var (
+	// ErrInvalid is returned for tokens that are neither keyset nor legacy tokens
+	ErrInvalid = errors.New("invalid page token")
+	// ErrExpired is returned for legacy tokens presented after their sunset
+	ErrExpired = errors.New("page token is no longer supported, list again from the first page")
+)
+
+var (
+	// legacyTokens counts the offset tokens accepted, keyed by client, to
+	// find the clients still holding them before the sunset
+	legacyTokens = expvar.NewMap("page_tokens_legacy_total")
+	// rejectedTokens counts the offset tokens rejected after the sunset, keyed by client
+	rejectedTokens = expvar.NewMap("page_tokens_legacy_rejected_total")
+)
+
+// Cursor is the position of an item in (created_at, id) order, or when Order
+// is set in the order it names, e.g. price desc, where the item sorts by Value
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+	// Order is the order_by the item was listed in, empty for (created_at, id)
+	Order string
+	// Value is the sort value of the item in Order, as text
+	Value string
+}
+
+// first sorts before every item
+var first = Cursor{CreatedAt: time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+// Page is where a page starts: after a cursor, or for legacy tokens at an
+// offset from the first item. One of them is always the zero position, so
+// queries can apply both.
+type Page struct {
+	After  Cursor
+	Offset int32
+}
+
+// Codec encodes keyset page tokens and decodes both those and the offset
+// tokens issued before them, which are base64-encoded decimal offsets.
+// Legacy tokens resume the listing where they point and the next token
+// handed out is a keyset one, so a client holding one is upgraded after a
+// single page.
+type Codec struct {
+	// Sunset is when legacy tokens stop being accepted; zero never
+	Sunset time.Time
+}
+
+// Encode returns the token of the page after cursor
+func (c Codec) Encode(cursor Cursor) string {
+	if cursor.Order != "" {
+		raw := sortedPrefix + cursor.Order + ":" + cursor.ID.String() + ":" + cursor.Value
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	raw := keysetPrefix + strconv.FormatInt(cursor.CreatedAt.UnixMicro(), 10) + ":" + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode returns the page token starts at, the first page for an empty token.
+// client identifies the caller in the legacy token metrics.
+func (c Codec) Decode(token, client string) (Page, error) {
+	if token == "" {
+		return Page{After: first}, nil
+	}
+
+	if raw, err := base64.RawURLEncoding.DecodeString(token); err == nil {
+		switch {
+		case strings.HasPrefix(string(raw), keysetPrefix):
+			return decodeKeyset(strings.TrimPrefix(string(raw), keysetPrefix))
+		case strings.HasPrefix(string(raw), sortedPrefix):
+			return decodeSorted(strings.TrimPrefix(string(raw), sortedPrefix))
+		}
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return Page{}, ErrInvalid
+	}
+	offset, err := strconv.ParseInt(string(raw), 10, 32)
+	if err != nil || offset < 0 {
+		return Page{}, ErrInvalid
+	}
+	if !c.Sunset.IsZero() && !time.Now().Before(c.Sunset) {
+		rejectedTokens.Add(client, 1)
+		return Page{}, ErrExpired
+	}
+	legacyTokens.Add(client, 1)
+	return Page{After: first, Offset: int32(offset)}, nil
+}
+
+// decodeKeyset decodes a keyset token in (created_at, id) order
+func decodeKeyset(raw string) (Page, error) {
+	micros, id, ok := strings.Cut(raw, ":")
+	if !ok {
+		return Page{}, ErrInvalid
+	}
+	createdAt, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return Page{}, ErrInvalid
+	}
+	cursorID, err := uuid.Parse(id)
+	if err != nil {
+		return Page{}, ErrInvalid
+	}
+	return Page{After: Cursor{CreatedAt: time.UnixMicro(createdAt).UTC(), ID: cursorID}}, nil
+}
+
+// decodeSorted decodes a keyset token in another order, holding the order,
+// the ID and the sort value, last as it may contain any character
+func decodeSorted(raw string) (Page, error) {
+	parts := strings.SplitN(raw, ":", 3)
+	if len(parts) != 3 || parts[0] == "" {
+		return Page{}, ErrInvalid
+	}
+	cursorID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return Page{}, ErrInvalid
+	}
+	return Page{After: Cursor{ID: cursorID, Order: parts[0], Value: parts[2]}}, nil
+}
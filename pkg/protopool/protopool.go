@@ -0,0 +1,131 @@
+package protopool
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/stats"
+)
+
+// Pool reuses values of T, e.g. the proto messages of a response, across
+// requests. The zero Pool is ready to use.
+type Pool[T any] struct {
+	pool sync.Pool
+}
+
+// SlicePool reuses the backing arrays of []T across requests. The zero
+// SlicePool is ready to use.
+type SlicePool[T any] struct {
+	pool sync.Pool
+}
+
+// Arena tracks the pooled values allocated for one response, returning them to
+// their pools on Release. Values must not be used once released. A nil Arena
+// allocates without pooling, so code building responses works the same
+// outside the gRPC server.
+type Arena struct {
+	mu       sync.Mutex
+	releases []func()
+}
+
+// NewArena creates an empty arena
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+// Release zeroes every value allocated in the arena and returns it to its
+// pool. The arena can be used again afterwards.
+func (a *Arena) Release() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	releases := a.releases
+	a.releases = nil
+	a.mu.Unlock()
+
+	for _, release := range releases {
+		release()
+	}
+}
+
+func (a *Arena) onRelease(release func()) {
+	a.mu.Lock()
+	a.releases = append(a.releases, release)
+	a.mu.Unlock()
+}
+
+// Get returns a zero T from pool, released with arena
+func Get[T any](arena *Arena, pool *Pool[T]) *T {
+	if arena == nil {
+		return new(T)
+	}
+	value, _ := pool.pool.Get().(*T)
+	if value == nil {
+		value = new(T)
+	}
+	arena.onRelease(func() {
+		*value = *new(T)
+		pool.pool.Put(value)
+	})
+	return value
+}
+
+// MakeSlice returns a []T of length n from pool, released with arena
+func MakeSlice[T any](arena *Arena, pool *SlicePool[T], n int) []T {
+	if arena == nil {
+		return make([]T, n)
+	}
+	slice, _ := pool.pool.Get().(*[]T)
+	if slice == nil || cap(*slice) < n {
+		values := make([]T, n)
+		slice = &values
+	}
+	*slice = (*slice)[:n]
+	arena.onRelease(func() {
+		clear(*slice)
+		pool.pool.Put(slice)
+	})
+	return *slice
+}
+
+type arenaKey struct{}
+
+// WithArena returns ctx carrying arena
+func WithArena(ctx context.Context, arena *Arena) context.Context {
+	return context.WithValue(ctx, arenaKey{}, arena)
+}
+
+// FromContext returns the arena of the RPC ctx belongs to, nil outside an RPC
+// served with StatsHandler
+func FromContext(ctx context.Context) *Arena {
+	arena, _ := ctx.Value(arenaKey{}).(*Arena)
+	return arena
+}
+
+// StatsHandler gives every RPC an arena, released once the RPC ended, i.e.
+// after its response was marshaled and sent. Unary handlers build responses
+// in the arena of their context. Streaming handlers should release an arena
+// of their own after every Send instead, as the RPC arena would grow for as
+// long as the stream lasts.
+func StatsHandler() stats.Handler {
+	return arenaHandler{}
+}
+
+type arenaHandler struct{}
+
+func (arenaHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return WithArena(ctx, NewArena())
+}
+
+func (arenaHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	if _, ok := s.(*stats.End); ok {
+		FromContext(ctx).Release()
+	}
+}
+
+func (arenaHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (arenaHandler) HandleConn(context.Context, stats.ConnStats) {}
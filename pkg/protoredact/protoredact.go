@@ -0,0 +1,139 @@
+// Package protoredact strips the secret fields of proto messages before they
+// are stored for people to read, e.g. in the event history or the audit
+// trail. Secret fields are marked with the debug_redact field option:
+//
+//	string confirmation_token = 3 [debug_redact = true];
+package protoredact
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Redacted reports whether fd is marked as holding a secret
+func Redacted(fd protoreflect.FieldDescriptor) bool {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	return ok && opts.GetDebugRedact()
+}
+
+// Clone returns a copy of m without its redacted fields
+func Clone[M proto.Message](m M) M {
+	clone := proto.Clone(m).(M)
+	Message(clone.ProtoReflect())
+	return clone
+}
+
+// Message clears the redacted fields of m and of the messages in it. Unknown
+// fields are dropped too, as they may be secrets of a newer or older version
+// of the message.
+func Message(m protoreflect.Message) {
+	var redacted []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case Redacted(fd):
+			redacted = append(redacted, fd)
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					Message(v.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				list := v.List()
+				for i := 0; i < list.Len(); i++ {
+					Message(list.Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			Message(v.Message())
+		}
+		return true
+	})
+
+	for _, fd := range redacted {
+		m.Clear(fd)
+	}
+	if len(m.GetUnknown()) > 0 {
+		m.SetUnknown(nil)
+	}
+}
+
+// JSON removes the redacted fields from payload, a message of desc encoded
+// either by protojson or by encoding/json, as watermill's JSON marshaler
+// does. Keys naming no field of desc, e.g. of a field since removed, are
+// removed as well. Well-known types such as google.protobuf.Struct are kept
+// as they are.
+func JSON(desc protoreflect.MessageDescriptor, payload []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	// Keeps 64-bit integers exact
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redactObject(desc, v))
+}
+
+// redactObject redacts v, a JSON value decoded as a message of desc
+func redactObject(desc protoreflect.MessageDescriptor, v any) any {
+	obj, ok := v.(map[string]any)
+	if !ok || wellKnown(desc) {
+		return v
+	}
+
+	fields := desc.Fields()
+	for key, value := range obj {
+		fd := fields.ByName(protoreflect.Name(key))
+		if fd == nil {
+			fd = fields.ByJSONName(key)
+		}
+		if fd == nil || Redacted(fd) {
+			delete(obj, key)
+			continue
+		}
+		obj[key] = redactField(fd, value)
+	}
+	return obj
+}
+
+// redactField redacts v, a JSON value decoded as the field fd
+func redactField(fd protoreflect.FieldDescriptor, v any) any {
+	switch {
+	case fd.IsMap():
+		entries, ok := v.(map[string]any)
+		if !ok || fd.MapValue().Message() == nil {
+			return v
+		}
+		for key, value := range entries {
+			entries[key] = redactObject(fd.MapValue().Message(), value)
+		}
+		return entries
+	case fd.Message() == nil:
+		return v
+	case fd.IsList():
+		elems, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		for i, elem := range elems {
+			elems[i] = redactObject(fd.Message(), elem)
+		}
+		return elems
+	default:
+		return redactObject(fd.Message(), v)
+	}
+}
+
+// wellKnown reports whether desc is a well-known type, whose JSON form need
+// not follow its fields
+func wellKnown(desc protoreflect.MessageDescriptor) bool {
+	return strings.HasPrefix(string(desc.FullName()), "google.protobuf.")
+}
@@ -0,0 +1,66 @@
+package publicid
+
+import (
+	"math/big"
+
+	"github.com/google/uuid"
+)
+
+// alphabet is the Bitcoin base58 alphabet, leaving out 0, O, I and l, which
+// are easily confused
+const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Len is the length of every encoded ID, the digits 128 bits take,
+// zero padded so every ID has a single encoding
+const base58Len = 22
+
+var (
+	radix = big.NewInt(int64(len(alphabet)))
+	// digits maps the characters of alphabet to their values, -1 for others
+	digits = func() [256]int8 {
+		var d [256]int8
+		for i := range d {
+			d[i] = -1
+		}
+		for i := 0; i < len(alphabet); i++ {
+			d[alphabet[i]] = int8(i)
+		}
+		return d
+	}()
+)
+
+func encodeBase58(id uuid.UUID) string {
+	n := new(big.Int).SetBytes(id[:])
+	mod := new(big.Int)
+
+	encoded := make([]byte, base58Len)
+	for i := base58Len - 1; i >= 0; i-- {
+		n.DivMod(n, radix, mod)
+		encoded[i] = alphabet[mod.Int64()]
+	}
+	return string(encoded)
+}
+
+func decodeBase58(s string) (uuid.UUID, error) {
+	if len(s) != base58Len {
+		return uuid.Nil, ErrInvalid
+	}
+
+	n := new(big.Int)
+	for i := 0; i < len(s); i++ {
+		digit := digits[s[i]]
+		if digit < 0 {
+			return uuid.Nil, ErrInvalid
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	// 22 digits hold values somewhat beyond 128 bits
+	if n.BitLen() > 128 {
+		return uuid.Nil, ErrInvalid
+	}
+	var id uuid.UUID
+	n.FillBytes(id[:])
+	return id, nil
+}
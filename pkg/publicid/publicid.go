@@ -0,0 +1,120 @@
+// Package publicid maps the UUIDs entities are stored under to the IDs the
+// external API shows in their place, so clients are not given internal
+// identifiers, or where their form would reveal it, the order entities were
+// created in.
+//
+// Encodings:
+//   - uuid shows IDs as stored
+//   - base58 shortens them to 22 characters, still revealing them
+//   - encrypted encrypts them with a secret key before encoding them as
+//     base58, so public IDs can neither be mapped back nor enumerated
+//     without the key
+package publicid
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Encodings of public IDs
+const (
+	UUID      = "uuid"
+	Base58    = "base58"
+	Encrypted = "encrypted"
+)
+
+// ErrInvalid is returned when decoding a string that is not a public ID of the encoding
+var ErrInvalid = errors.New("publicid: invalid ID")
+
+// canonicalUUIDLen is the length of the hyphenated form of UUIDs, the only one accepted
+const canonicalUUIDLen = 36
+
+// Codec maps internal IDs to public IDs and back
+type Codec interface {
+	Encode(id uuid.UUID) string
+	Decode(id string) (uuid.UUID, error)
+}
+
+// New creates the codec of encoding for the IDs of entity. The encrypted
+// encoding derives its key from secret and entity, so the same secret can
+// serve every entity without their public IDs being comparable.
+func New(encoding string, secret []byte, entity string) (Codec, error) {
+	switch encoding {
+	case "", UUID:
+		return uuidCodec{}, nil
+	case Base58:
+		return base58Codec{}, nil
+	case Encrypted:
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("publicid: encrypted %s IDs need a secret key", entity)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte("publicid:" + entity))
+		block, err := aes.NewCipher(mac.Sum(nil))
+		if err != nil {
+			return nil, fmt.Errorf("publicid: creating cipher: %w", err)
+		}
+		return encryptedCodec{block: block}, nil
+	default:
+		return nil, fmt.Errorf("publicid: unknown encoding %q", encoding)
+	}
+}
+
+// uuidCodec shows IDs as stored
+type uuidCodec struct{}
+
+func (uuidCodec) Encode(id uuid.UUID) string {
+	return id.String()
+}
+
+func (uuidCodec) Decode(id string) (uuid.UUID, error) {
+	if len(id) != canonicalUUIDLen {
+		return uuid.Nil, ErrInvalid
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrInvalid
+	}
+	return parsed, nil
+}
+
+// base58Codec shows the bytes of IDs as base58
+type base58Codec struct{}
+
+func (base58Codec) Encode(id uuid.UUID) string {
+	return encodeBase58(id)
+}
+
+func (base58Codec) Decode(id string) (uuid.UUID, error) {
+	return decodeBase58(id)
+}
+
+// encryptedCodec shows IDs encrypted as a single AES block, as base58. The
+// block cipher is a permutation of 16-byte values, so every ID has exactly
+// one public ID and any string of the right form decodes to some ID, which
+// callers look up like any other.
+type encryptedCodec struct {
+	block cipher.Block
+}
+
+func (c encryptedCodec) Encode(id uuid.UUID) string {
+	var encrypted uuid.UUID
+	c.block.Encrypt(encrypted[:], id[:])
+	return encodeBase58(encrypted)
+}
+
+func (c encryptedCodec) Decode(id string) (uuid.UUID, error) {
+	encrypted, err := decodeBase58(id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	var decrypted uuid.UUID
+	c.block.Decrypt(decrypted[:], encrypted[:])
+	return decrypted, nil
+}
@@ -0,0 +1,159 @@
+// Package ratelimit limits how often callers, e.g. client addresses or API
+// keys, may make requests, with a token bucket per caller and route. Buckets
+// refill continuously at the rate of their limit up to its burst, so a caller
+// may make burst requests at once and rate requests per second after that.
+package ratelimit
+
+import (
+	"expvar"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets refilled to their burst are dropped
+const sweepInterval = time.Minute
+
+// rejections counts requests over their limit keyed by the route prefix of
+// the rule they fell under, default for the default limit
+var rejections = expvar.NewMap("rate_limit_rejections_total")
+
+// Limit is how often a caller may make requests
+type Limit struct {
+	// Rate is how many requests per second are allowed on average; 0 or less
+	// allows any number
+	Rate float64
+	// Burst is how many requests may be made at once, the rate rounded up
+	// when 0 or less
+	Burst int
+}
+
+// unlimited reports whether the limit allows any number of requests
+func (l Limit) unlimited() bool {
+	return l.Rate <= 0
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// Rule overrides the default limit on the routes starting with Prefix, e.g. a
+// gRPC method or service such as /proto.api.v1.UserService/, or an HTTP path
+type Rule struct {
+	Prefix string
+	Limit
+}
+
+// Limiter limits the requests of callers per route. Routes matching a rule
+// share a bucket per caller for that rule, and other routes share a bucket
+// per caller for the default limit.
+type Limiter struct {
+	limit Limit
+	// rules are sorted by descending prefix length so the most specific wins
+	rules []Rule
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	prefix string
+	caller string
+}
+
+type bucket struct {
+	tokens float64
+	limit  Limit
+	at     time.Time
+}
+
+// New creates a limiter applying limit to routes no rule matches
+func New(limit Limit, rules []Rule) *Limiter {
+	rules = append([]Rule(nil), rules...)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].Prefix) > len(rules[j].Prefix)
+	})
+	return &Limiter{
+		limit:     limit,
+		rules:     rules,
+		now:       time.Now,
+		buckets:   make(map[bucketKey]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket of caller on route. It reports whether
+// the request is allowed and, when it is not, how long until it would be.
+func (l *Limiter) Allow(route, caller string) (bool, time.Duration) {
+	prefix, limit := l.match(route)
+	if limit.unlimited() {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	key := bucketKey{prefix: prefix, caller: caller}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.burst(), limit: limit, at: now}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if prefix == "" {
+		rejections.Add("default", 1)
+	} else {
+		rejections.Add(prefix, 1)
+	}
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+// match returns the prefix and limit of the rule matching route, an empty
+// prefix for the default limit
+func (l *Limiter) match(route string) (string, Limit) {
+	for _, rule := range l.rules {
+		if strings.HasPrefix(route, rule.Prefix) {
+			return rule.Prefix, rule.Limit
+		}
+	}
+	return "", l.limit
+}
+
+// sweep drops the buckets refilled to their burst, which behave as new ones
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.limit.burst() {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.at).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = math.Min(b.limit.burst(), b.tokens+elapsed*b.limit.Rate)
+	b.at = now
+}
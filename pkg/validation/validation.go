@@ -0,0 +1,129 @@
+package validation
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Failure modes
+const (
+	// ModeStrict rejects invalid requests
+	ModeStrict = "strict"
+	// ModeLogOnly logs violations and lets requests through, for canary rollouts of new rules
+	ModeLogOnly = "log_only"
+)
+
+// violations counts requests with rule violations keyed by message name.
+// It is exported on /debug/vars as validation_violations_total.
+var violations = expvar.NewMap("validation_violations_total")
+
+// Validator validates protobuf messages against their buf.validate rules.
+// Rules for the warmed-up packages are compiled once at construction and
+// reused for every request.
+type Validator struct {
+	validator protovalidate.Validator
+	logOnly   bool
+}
+
+// New creates a validator for mode, compiling the rules of every message in
+// the given proto packages up front. Rules that fail to compile are reported
+// here rather than on the first request that hits them.
+func New(mode string, packages ...protoreflect.FullName) (*Validator, error) {
+	logOnly := false
+	switch mode {
+	case "", ModeStrict:
+	case ModeLogOnly:
+		logOnly = true
+	default:
+		return nil, fmt.Errorf("unknown validation mode %q", mode)
+	}
+
+	descriptors := messageDescriptors(packages)
+
+	validator, err := protovalidate.New(protovalidate.WithMessageDescriptors(descriptors...))
+	if err != nil {
+		return nil, fmt.Errorf("create validator: %w", err)
+	}
+
+	if err := warmUp(validator, descriptors); err != nil {
+		return nil, err
+	}
+
+	return &Validator{
+		validator: validator,
+		logOnly:   logOnly,
+	}, nil
+}
+
+// Validate returns the rule violations of msg. In log-only mode violations are
+// logged and nil is returned; compilation and runtime errors are always returned.
+func (v *Validator) Validate(msg proto.Message) error {
+	err := v.validator.Validate(msg)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *protovalidate.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	name := string(msg.ProtoReflect().Descriptor().FullName())
+	violations.Add(name, 1)
+
+	if v.logOnly {
+		slog.Warn("Request failed validation", "message", name, slog.Any("error", err))
+		return nil
+	}
+	return err
+}
+
+// messageDescriptors lists every message, including nested ones, declared in packages
+func messageDescriptors(packages []protoreflect.FullName) []protoreflect.MessageDescriptor {
+	var descriptors []protoreflect.MessageDescriptor
+	var collect func(messages protoreflect.MessageDescriptors)
+	collect = func(messages protoreflect.MessageDescriptors) {
+		for i := 0; i < messages.Len(); i++ {
+			message := messages.Get(i)
+			if message.IsMapEntry() {
+				continue
+			}
+			descriptors = append(descriptors, message)
+			collect(message.Messages())
+		}
+	}
+
+	for _, pkg := range packages {
+		protoregistry.GlobalFiles.RangeFilesByPackage(pkg, func(file protoreflect.FileDescriptor) bool {
+			collect(file.Messages())
+			return true
+		})
+	}
+
+	return descriptors
+}
+
+// warmUp validates an empty instance of every message so rule compilation
+// errors surface at boot
+func warmUp(validator protovalidate.Validator, descriptors []protoreflect.MessageDescriptor) error {
+	for _, descriptor := range descriptors {
+		messageType, err := protoregistry.GlobalTypes.FindMessageByName(descriptor.FullName())
+		if err != nil {
+			continue
+		}
+
+		err = validator.Validate(messageType.New().Interface())
+		var compilationErr *protovalidate.CompilationError
+		if errors.As(err, &compilationErr) {
+			return fmt.Errorf("compile rules for %s: %w", descriptor.FullName(), err)
+		}
+	}
+	return nil
+}
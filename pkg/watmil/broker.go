@@ -0,0 +1,58 @@
+package watmil
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill"
+	watersql "github.com/ThreeDotsLabs/watermill-sql/v2/pkg/sql"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Broker creates the transport behind the event bus and the event processors
+type Broker interface {
+	NewPublisher(logger watermill.LoggerAdapter) (message.Publisher, error)
+	// NewSubscriber creates the subscriber of one event handler. Brokers with
+	// consumer groups use handlerName to keep each handler's messages apart.
+	NewSubscriber(handlerName string, logger watermill.LoggerAdapter) (message.Subscriber, error)
+}
+
+// Pinger is implemented by brokers that can check they are reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SQLBroker keeps events in PostgreSQL tables through watermill-sql.
+// The pool is converted to *sql.DB using stdlib connector for watermill-sql compatibility.
+type SQLBroker struct {
+	Pool *pgxpool.Pool
+}
+
+func (b SQLBroker) NewPublisher(logger watermill.LoggerAdapter) (message.Publisher, error) {
+	return watersql.NewPublisher(
+		stdlib.OpenDBFromPool(b.Pool),
+		watersql.PublisherConfig{
+			SchemaAdapter:        watersql.DefaultPostgreSQLSchema{},
+			AutoInitializeSchema: true,
+		},
+		logger,
+	)
+}
+
+// Ping checks the database holding the event tables
+func (b SQLBroker) Ping(ctx context.Context) error {
+	return b.Pool.Ping(ctx)
+}
+
+func (b SQLBroker) NewSubscriber(_ string, logger watermill.LoggerAdapter) (message.Subscriber, error) {
+	return watersql.NewSubscriber(
+		stdlib.OpenDBFromPool(b.Pool),
+		watersql.SubscriberConfig{
+			SchemaAdapter:    watersql.DefaultPostgreSQLSchema{},
+			OffsetsAdapter:   watersql.DefaultPostgreSQLOffsetsAdapter{},
+			InitializeSchema: true,
+		},
+		logger,
+	)
+}
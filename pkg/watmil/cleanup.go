@@ -0,0 +1,110 @@
+package watmil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// offsetsTablePrefix starts the name of the watermill-sql consumer offsets
+// table of every topic; the messages table is the same name without "offsets_"
+const offsetsTablePrefix = "watermill_offsets_"
+
+// ConsumedDeleter is implemented by brokers keeping events once consumed,
+// which must then be removed
+type ConsumedDeleter interface {
+	DeleteConsumed(ctx context.Context, olderThan time.Time, groups []string, limit int, dryRun bool) (int64, error)
+}
+
+var _ ConsumedDeleter = SQLBroker{}
+
+// DefaultConsumerGroup is the consumer group of the subscribers of SQLBroker
+const DefaultConsumerGroup = ""
+
+// consumedCondition matches event rows m that every consumer group in the
+// offsets table has acknowledged, following the order watermill-sql reads them
+// in, once every group of $3 has an offset, i.e. read from the topic
+const consumedCondition = `m.created_at < $1
+	AND NOT EXISTS (
+		SELECT 1 FROM unnest($3::text[]) g
+		WHERE NOT EXISTS (SELECT 1 FROM %[1]s o WHERE o.consumer_group = g)
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM %[1]s o
+		WHERE m.transaction_id > o.last_processed_transaction_id
+			OR (m.transaction_id = o.last_processed_transaction_id AND m."offset" > o.offset_acked)
+	)`
+
+// DeleteConsumed removes up to limit event rows created before olderThan that
+// every consumer group has processed. With dryRun nothing is removed and the
+// number of such rows, up to limit, is returned instead.
+//
+// A consumer group only has offsets once it read from a topic, and a group
+// subscribing later starts from the oldest row left. Nothing is removed from
+// a topic until every group of groups read from it, so groups, e.g. of other
+// services reading the tables, must be listed before they first subscribe.
+func (b SQLBroker) DeleteConsumed(ctx context.Context, olderThan time.Time, groups []string, limit int, dryRun bool) (int64, error) {
+	topics, err := b.topics(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, topic := range topics {
+		if total >= int64(limit) {
+			break
+		}
+
+		messages := pgx.Identifier{"watermill_" + topic}.Sanitize()
+		offsets := pgx.Identifier{offsetsTablePrefix + topic}.Sanitize()
+		condition := fmt.Sprintf(consumedCondition, offsets)
+
+		n, err := b.deleteConsumed(ctx, messages, condition, olderThan, groups, int64(limit)-total, dryRun)
+		if err != nil {
+			return total, fmt.Errorf("delete consumed events of %s: %w", topic, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (b SQLBroker) deleteConsumed(ctx context.Context, messages, condition string, olderThan time.Time, groups []string, limit int64, dryRun bool) (int64, error) {
+	if dryRun {
+		var n int64
+		err := b.Pool.QueryRow(ctx, fmt.Sprintf(
+			`SELECT count(*) FROM (SELECT 1 FROM %s m WHERE %s LIMIT $2) consumed`,
+			messages, condition), olderThan, limit, groups).Scan(&n)
+		return n, err
+	}
+
+	tag, err := b.Pool.Exec(ctx, fmt.Sprintf(`DELETE FROM %[1]s WHERE ("transaction_id", "offset") IN (
+		SELECT m.transaction_id, m."offset" FROM %[1]s m WHERE %[2]s LIMIT $2
+	)`, messages, condition), olderThan, limit, groups)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// topics lists the watermill topics stored in the current schema
+func (b SQLBroker) topics(ctx context.Context) ([]string, error) {
+	rows, err := b.Pool.Query(ctx, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND starts_with(table_name, $1)`, offsetsTablePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("list event tables: %w", err)
+	}
+
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("list event tables: %w", err)
+	}
+
+	topics := make([]string, len(tables))
+	for i, table := range tables {
+		topics[i] = strings.TrimPrefix(table, offsetsTablePrefix)
+	}
+	return topics, nil
+}
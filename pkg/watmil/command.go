@@ -0,0 +1,50 @@
+package watmil
+
+import (
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	wotelfloss "github.com/dentech-floss/watermill-opentelemetry-go-extra/pkg/opentelemetry"
+	wotel "github.com/voi-oss/watermill-opentelemetry/pkg/opentelemetry"
+)
+
+// commandMarshaler encodes commands as protobuf. Unlike events, commands
+// carry well-known types such as google.protobuf.Struct that encoding/json
+// cannot decode back.
+var commandMarshaler = cqrs.ProtoMarshaler{
+	GenerateName: cqrs.StructName,
+}
+
+// NewCommandBus creates a new command bus sending through broker. Commands
+// carry the correlation ID of the context they are sent with, like events.
+func NewCommandBus(broker Broker, logger watermill.LoggerAdapter) (*cqrs.CommandBus, error) {
+	publisher, err := broker.NewPublisher(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	tracePropagation := wotelfloss.NewTracePropagatingPublisherDecorator(publisher)
+
+	return cqrs.NewCommandBusWithConfig(wotel.NewPublisherDecorator(tracePropagation), cqrs.CommandBusConfig{
+		GeneratePublishTopic: func(params cqrs.CommandBusGeneratePublishTopicParams) (string, error) {
+			return generateCommandTopic(params.CommandName), nil
+		},
+		OnSend: func(params cqrs.CommandBusOnSendParams) error {
+			logger.Info("Sending command", watermill.LogFields{
+				"command_name": params.CommandName,
+			})
+
+			params.Message.Metadata.Set("sent_at", time.Now().Format(time.RFC3339))
+			setCorrelationID(params.Message)
+
+			return nil
+		},
+		Marshaler: commandMarshaler,
+		Logger:    logger,
+	})
+}
+
+func generateCommandTopic(commandName string) string {
+	return "commands." + commandName
+}
@@ -0,0 +1,28 @@
+package watmil
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/erry-az/go-init/pkg/correlation"
+)
+
+// setCorrelationID stamps msg with the correlation ID of its context, when
+// it has one
+func setCorrelationID(msg *message.Message) {
+	if id := correlation.ID(msg.Context()); id != "" {
+		middleware.SetCorrelationID(id, msg)
+	}
+}
+
+// messageContext returns the context of msg carrying its correlation ID, so
+// what handling it publishes is correlated with it
+func messageContext(msg *message.Message) context.Context {
+	ctx := msg.Context()
+	id := middleware.MessageCorrelationID(msg)
+	if id == "" || correlation.ID(ctx) != "" {
+		return ctx
+	}
+	return correlation.NewContext(ctx, correlation.IDs{CorrelationID: id})
+}
@@ -0,0 +1,42 @@
+package watmil
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// CustomEventPrefix starts the names of custom events, keeping them apart
+// from the names of proto events
+const CustomEventPrefix = "custom_"
+
+// customEventType is what custom event types look like, e.g. order_shipped
+var customEventType = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// ValidCustomEventType reports whether t may name a custom event
+func ValidCustomEventType(t string) bool {
+	return customEventType.MatchString(t)
+}
+
+// CustomEvent is an event without a proto message of its own, e.g. one made
+// up for an integration experiment. Its payload is published as it is to
+// events.custom_<type>, through the same expiry, encryption, failure policy
+// and tracing as other events, which configuration refers to it by its name,
+// custom_<type>.
+type CustomEvent struct {
+	// Type names the event, e.g. order_shipped; see ValidCustomEventType
+	Type string
+	// ID becomes the message UUID, generated when empty
+	ID string
+	// Payload is the JSON body of the event
+	Payload json.RawMessage
+}
+
+// Name returns the event name, used by cqrs.NamedStruct
+func (e *CustomEvent) Name() string {
+	return CustomEventPrefix + e.Type
+}
+
+// MarshalJSON publishes the payload without a wrapper
+func (e *CustomEvent) MarshalJSON() ([]byte, error) {
+	return e.Payload, nil
+}
@@ -0,0 +1,130 @@
+package watmil
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// PublishMode decides what happens to an event the broker did not accept
+type PublishMode string
+
+const (
+	// PublishStrict fails the publish, and with it the request publishing the event
+	PublishStrict PublishMode = "strict"
+	// PublishRetry stores the event to publish it again later and reports success
+	PublishRetry PublishMode = "retry"
+	// PublishBestEffort drops the event and reports success
+	PublishBestEffort PublishMode = "best_effort"
+)
+
+var (
+	// deferredEvents counts events stored for a later publish, keyed by event name
+	deferredEvents = expvar.NewMap("events_publish_deferred_total")
+	// skippedEvents counts events dropped after failing to publish, keyed by event name
+	skippedEvents = expvar.NewMap("events_publish_skipped_total")
+)
+
+// PublishFailurePolicy decides how failing to publish an event is handled.
+// Events maps an event name (e.g. UserCreatedEvent) to its mode; Default
+// applies to the rest and is PublishRetry when empty.
+type PublishFailurePolicy struct {
+	Default PublishMode
+	Events  map[string]PublishMode
+}
+
+// For returns the mode configured for eventName
+func (p PublishFailurePolicy) For(eventName string) PublishMode {
+	if mode, ok := p.Events[eventName]; ok {
+		return mode
+	}
+	if p.Default == "" {
+		return PublishRetry
+	}
+	return p.Default
+}
+
+// validate checks every mode is known and retried events have a store to go to
+func (p PublishFailurePolicy) validate(retries RetryStore) error {
+	modes := map[string]PublishMode{"default": p.For("")}
+	for eventName, mode := range p.Events {
+		modes[eventName] = mode
+	}
+	for name, mode := range modes {
+		switch mode {
+		case PublishStrict, PublishBestEffort:
+		case PublishRetry:
+			if retries == nil {
+				return fmt.Errorf("publish failure mode of %s is retry, but no retry store is configured", name)
+			}
+		default:
+			return fmt.Errorf("unknown publish failure mode %q of %s", mode, name)
+		}
+	}
+	return nil
+}
+
+// RetryStore keeps events the broker did not accept until they are published again
+type RetryStore interface {
+	// Defer stores msg, ready to be published to topic as is
+	Defer(ctx context.Context, topic string, msg *message.Message, cause error) error
+}
+
+// degradingPublisher applies a PublishFailurePolicy to the failures of the
+// publisher it decorates
+type degradingPublisher struct {
+	message.Publisher
+	policy  PublishFailurePolicy
+	retries RetryStore
+	logger  watermill.LoggerAdapter
+}
+
+func newDegradingPublisher(publisher message.Publisher, policy PublishFailurePolicy, retries RetryStore, logger watermill.LoggerAdapter) (*degradingPublisher, error) {
+	if err := policy.validate(retries); err != nil {
+		return nil, err
+	}
+	return &degradingPublisher{
+		Publisher: publisher,
+		policy:    policy,
+		retries:   retries,
+		logger:    logger,
+	}, nil
+}
+
+// Publish publishes msgs, handling a failure according to the mode of their
+// event. Events that can be neither published nor stored fail like strict ones.
+func (p *degradingPublisher) Publish(topic string, msgs ...*message.Message) error {
+	err := p.Publisher.Publish(topic, msgs...)
+	if err == nil {
+		return nil
+	}
+
+	eventName := strings.TrimPrefix(topic, generateEventTopic(""))
+	fields := watermill.LogFields{"event_name": eventName}
+
+	switch p.policy.For(eventName) {
+	case PublishBestEffort:
+		skippedEvents.Add(eventName, int64(len(msgs)))
+		p.logger.Error("Skipping event the broker did not accept", err, fields)
+		return nil
+
+	case PublishRetry:
+		for _, msg := range msgs {
+			// The request may end before the event is stored
+			ctx := context.WithoutCancel(msg.Context())
+			if storeErr := p.retries.Defer(ctx, topic, msg, err); storeErr != nil {
+				return errors.Join(err, fmt.Errorf("storing event for retry: %w", storeErr))
+			}
+		}
+		deferredEvents.Add(eventName, int64(len(msgs)))
+		p.logger.Info("Deferred event the broker did not accept", fields.Add(watermill.LogFields{"error": err}))
+		return nil
+	}
+
+	return err
+}
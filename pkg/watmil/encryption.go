@@ -0,0 +1,122 @@
+package watmil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/erry-az/go-init/pkg/envelope"
+)
+
+// MetadataEncryptionKeyID is the message metadata key holding the ID of the key
+// encryption key that wrapped an encrypted payload. It is unset on plaintext messages.
+const MetadataEncryptionKeyID = "encryption_key_id"
+
+// ErrEncryptedPayload is returned when a subscriber without encryption keys
+// receives an encrypted message
+var ErrEncryptedPayload = errors.New("message payload is encrypted but no encryption is configured")
+
+// PayloadEncryption encrypts the payloads of selected events so sensitive
+// fields are never stored in the broker in plaintext. Each payload gets a fresh
+// data key wrapped by the provider's active key; rotating the active key only
+// affects new messages, so retired keys must stay with the provider until every
+// message using them has been consumed.
+type PayloadEncryption struct {
+	encryptor *envelope.Encryptor
+	events    map[string]bool
+}
+
+// NewPayloadEncryption encrypts the payloads of the given event names,
+// e.g. UserCreatedEvent, with keys from provider
+func NewPayloadEncryption(provider envelope.KeyProvider, events []string) *PayloadEncryption {
+	names := make(map[string]bool, len(events))
+	for _, name := range events {
+		names[name] = true
+	}
+	return &PayloadEncryption{
+		encryptor: envelope.NewEncryptor(provider),
+		events:    names,
+	}
+}
+
+// encryptingMarshaler encrypts the payloads marshaled by the wrapped marshaler
+// for the configured events and decrypts every encrypted payload it unmarshals.
+// A nil encryption publishes plaintext and rejects encrypted messages.
+type encryptingMarshaler struct {
+	cqrs.CommandEventMarshaler
+	encryption *PayloadEncryption
+}
+
+func newEncryptingMarshaler(base cqrs.CommandEventMarshaler, encryption *PayloadEncryption) cqrs.CommandEventMarshaler {
+	return encryptingMarshaler{CommandEventMarshaler: base, encryption: encryption}
+}
+
+func (m encryptingMarshaler) Marshal(v any) (*message.Message, error) {
+	msg, err := m.CommandEventMarshaler.Marshal(v)
+	if err != nil || m.encryption == nil || !m.encryption.events[m.Name(v)] {
+		return msg, err
+	}
+
+	// The marshaler interface carries no context, so key provider calls use a background one
+	ciphertext, err := m.encryption.encryptor.Encrypt(context.Background(), msg.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt %s payload: %w", m.Name(v), err)
+	}
+
+	msg.Payload = []byte(ciphertext)
+	msg.Metadata.Set(MetadataEncryptionKeyID, envelope.KeyID(ciphertext))
+	return msg, nil
+}
+
+func (m encryptingMarshaler) Unmarshal(msg *message.Message, v any) error {
+	if msg.Metadata.Get(MetadataEncryptionKeyID) == "" {
+		return m.CommandEventMarshaler.Unmarshal(msg, v)
+	}
+	if m.encryption == nil {
+		return ErrEncryptedPayload
+	}
+
+	plaintext, err := m.encryption.encryptor.Decrypt(msg.Context(), string(msg.Payload))
+	if err != nil {
+		return fmt.Errorf("decrypt %s payload: %w", m.NameFromMessage(msg), err)
+	}
+
+	// Decrypt into a copy so the stored message keeps its ciphertext on retries
+	decrypted := msg.Copy()
+	decrypted.Payload = plaintext
+	return m.CommandEventMarshaler.Unmarshal(decrypted, v)
+}
+
+// DecryptPayload returns the plaintext payload of msg, e.g. of an event read
+// back from storage. Payloads that are not encrypted are returned as is; a
+// nil encryption fails on encrypted ones.
+func (e *PayloadEncryption) DecryptPayload(ctx context.Context, msg *message.Message) ([]byte, error) {
+	if msg.Metadata.Get(MetadataEncryptionKeyID) == "" {
+		return msg.Payload, nil
+	}
+	if e == nil {
+		return nil, ErrEncryptedPayload
+	}
+	return e.encryptor.Decrypt(ctx, string(msg.Payload))
+}
+
+// EncryptPayload returns a copy of msg with its payload encrypted and
+// stamped with the key that wrapped it, e.g. to store a payload changed
+// after decrypting it with DecryptPayload
+func (e *PayloadEncryption) EncryptPayload(ctx context.Context, msg *message.Message) (*message.Message, error) {
+	if e == nil {
+		return nil, errors.New("no encryption is configured")
+	}
+
+	ciphertext, err := e.encryptor.Encrypt(ctx, msg.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := msg.Copy()
+	encrypted.Payload = []byte(ciphertext)
+	encrypted.Metadata.Set(MetadataEncryptionKeyID, envelope.KeyID(ciphertext))
+	return encrypted, nil
+}
@@ -0,0 +1,378 @@
+package watmil
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// failoverResubscribeDelay is how long a subscription waits before retrying
+// to subscribe on the broker failed over to
+const failoverResubscribeDelay = 5 * time.Second
+
+// failovers counts broker failovers keyed by the name of the broker taken over by
+var failovers = expvar.NewMap("events_broker_failovers_total")
+
+// FailoverMember is one broker of a FailoverBroker
+type FailoverMember struct {
+	// Name identifies the broker in logs and metrics, e.g. primary
+	Name   string
+	Broker Broker
+}
+
+// FailoverOptions configures a FailoverBroker
+type FailoverOptions struct {
+	// Interval is how often the active broker is pinged
+	Interval time.Duration
+	// Timeout bounds a single ping
+	Timeout time.Duration
+	// Threshold is how many pings in a row must fail before failing over
+	Threshold int
+	// OnFailover is called after failing over from one member to another
+	OnFailover func(from, to string, cause error)
+}
+
+// FailoverBroker sends events through the first of an ordered list of
+// brokers, e.g. a primary and standbys in other regions, and fails over to
+// the next reachable one once the active broker stopped answering pings.
+// It is active-passive: a single broker is in use at a time, and the primary
+// is taken back only when the broker failed over to fails in turn.
+//
+// Publishers and subscribers created by the broker follow the active member.
+// Those of a member are created the first time it becomes active, declaring
+// its topology, e.g. the SQL tables or the SNS topics and SQS queues, and
+// subscriptions move to it as soon as it is. Messages in flight during a
+// failover are delivered again by the broker they came from once it is back.
+type FailoverBroker struct {
+	members []FailoverMember
+	opts    FailoverOptions
+
+	mu     sync.Mutex
+	active int
+	// switched is closed on the next failover
+	switched chan struct{}
+}
+
+// NewFailoverBroker creates a broker failing over between members, in order
+// of preference. Every member must implement Pinger.
+func NewFailoverBroker(members []FailoverMember, opts FailoverOptions) (*FailoverBroker, error) {
+	if len(members) == 0 {
+		return nil, errors.New("failover needs at least one broker")
+	}
+	for _, member := range members {
+		if _, ok := member.Broker.(Pinger); !ok {
+			return nil, fmt.Errorf("broker %s cannot be pinged to detect failures", member.Name)
+		}
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = 3
+	}
+	return &FailoverBroker{members: members, opts: opts, switched: make(chan struct{})}, nil
+}
+
+// Brokers returns the brokers failed over between, in order of preference
+func (b *FailoverBroker) Brokers() []Broker {
+	brokers := make([]Broker, len(b.members))
+	for i, member := range b.members {
+		brokers[i] = member.Broker
+	}
+	return brokers
+}
+
+// Active returns the name of the broker in use
+func (b *FailoverBroker) Active() string {
+	active, _ := b.current()
+	return b.members[active].Name
+}
+
+// current returns the index of the active member and a channel closed once
+// it is no longer active
+func (b *FailoverBroker) current() (int, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.active, b.switched
+}
+
+// Ping checks the active broker is reachable
+func (b *FailoverBroker) Ping(ctx context.Context) error {
+	active, _ := b.current()
+	return b.ping(ctx, active)
+}
+
+func (b *FailoverBroker) ping(ctx context.Context, member int) error {
+	ctx, cancel := context.WithTimeout(ctx, b.opts.Timeout)
+	defer cancel()
+	return b.members[member].Broker.(Pinger).Ping(ctx)
+}
+
+// Run pings the active broker every interval and fails over after threshold
+// failed pings in a row, until ctx is done
+func (b *FailoverBroker) Run(ctx context.Context) error {
+	if len(b.members) == 1 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ticker := time.NewTicker(b.opts.Interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		active, _ := b.current()
+		err := b.ping(ctx, active)
+		if err == nil {
+			failures = 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		failures++
+		slog.Warn("Event broker unreachable", "broker", b.members[active].Name, "failures", failures, slog.Any("error", err))
+		if failures >= b.opts.Threshold && b.failover(ctx, active, err) {
+			failures = 0
+		}
+	}
+}
+
+// failover switches from the from member to the first other member that
+// answers a ping, in order of preference, reporting whether it did
+func (b *FailoverBroker) failover(ctx context.Context, from int, cause error) bool {
+	for to := range b.members {
+		if to == from {
+			continue
+		}
+		if err := b.ping(ctx, to); err != nil {
+			slog.Warn("Standby event broker unreachable", "broker", b.members[to].Name, slog.Any("error", err))
+			continue
+		}
+
+		b.mu.Lock()
+		b.active = to
+		close(b.switched)
+		b.switched = make(chan struct{})
+		b.mu.Unlock()
+
+		failovers.Add(b.members[to].Name, 1)
+		if b.opts.OnFailover != nil {
+			b.opts.OnFailover(b.members[from].Name, b.members[to].Name, cause)
+		}
+		return true
+	}
+	return false
+}
+
+// NewPublisher creates a publisher publishing through the active broker
+func (b *FailoverBroker) NewPublisher(logger watermill.LoggerAdapter) (message.Publisher, error) {
+	p := &failoverPublisher{broker: b, logger: logger, publishers: make(map[int]message.Publisher)}
+
+	// Fail fast on the active broker, as the other brokers would
+	active, _ := b.current()
+	if _, err := p.publisher(active); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewSubscriber creates a subscriber whose subscriptions follow the active broker
+func (b *FailoverBroker) NewSubscriber(handlerName string, logger watermill.LoggerAdapter) (message.Subscriber, error) {
+	s := &failoverSubscriber{
+		broker:      b,
+		handlerName: handlerName,
+		logger:      logger,
+		subscribers: make(map[int]message.Subscriber),
+		closed:      make(chan struct{}),
+	}
+
+	active, _ := b.current()
+	if _, err := s.subscriber(active); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// failoverPublisher publishes through the publisher of the active member,
+// created the first time it is needed
+type failoverPublisher struct {
+	broker *FailoverBroker
+	logger watermill.LoggerAdapter
+
+	mu         sync.Mutex
+	publishers map[int]message.Publisher
+}
+
+func (p *failoverPublisher) publisher(member int) (message.Publisher, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if publisher, ok := p.publishers[member]; ok {
+		return publisher, nil
+	}
+	publisher, err := p.broker.members[member].Broker.NewPublisher(p.logger)
+	if err != nil {
+		return nil, fmt.Errorf("create publisher on %s: %w", p.broker.members[member].Name, err)
+	}
+	p.publishers[member] = publisher
+	return publisher, nil
+}
+
+func (p *failoverPublisher) Publish(topic string, messages ...*message.Message) error {
+	active, _ := p.broker.current()
+	publisher, err := p.publisher(active)
+	if err != nil {
+		return err
+	}
+	return publisher.Publish(topic, messages...)
+}
+
+func (p *failoverPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for _, publisher := range p.publishers {
+		errs = append(errs, publisher.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// failoverSubscriber subscribes through the subscriber of the active member
+// and moves its subscriptions along on failover
+type failoverSubscriber struct {
+	broker      *FailoverBroker
+	handlerName string
+	logger      watermill.LoggerAdapter
+
+	mu          sync.Mutex
+	subscribers map[int]message.Subscriber
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+func (s *failoverSubscriber) subscriber(member int) (message.Subscriber, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if subscriber, ok := s.subscribers[member]; ok {
+		return subscriber, nil
+	}
+	subscriber, err := s.broker.members[member].Broker.NewSubscriber(s.handlerName, s.logger)
+	if err != nil {
+		return nil, fmt.Errorf("create subscriber on %s: %w", s.broker.members[member].Name, err)
+	}
+	s.subscribers[member] = subscriber
+	return subscriber, nil
+}
+
+// subscribe subscribes to topic on the active member until it is no longer
+// active or ctx is done
+func (s *failoverSubscriber) subscribe(ctx context.Context, topic string) (<-chan *message.Message, <-chan struct{}, context.CancelFunc, error) {
+	active, switched := s.broker.current()
+	subscriber, err := s.subscriber(active)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	messages, err := subscriber.Subscribe(subCtx, topic)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, fmt.Errorf("subscribe to %s on %s: %w", topic, s.broker.members[active].Name, err)
+	}
+	return messages, switched, cancel, nil
+}
+
+func (s *failoverSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	messages, switched, cancel, err := s.subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *message.Message)
+	go func() {
+		defer close(out)
+		defer func() { cancel() }()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.closed:
+				return
+			case <-switched:
+				cancel()
+				if messages, switched, cancel = s.resubscribe(ctx, topic); messages == nil {
+					return
+				}
+			case msg, ok := <-messages:
+				if !ok {
+					// The subscription ended on the member's side; wait for a
+					// failover to move it, or for the subscriber to close
+					messages = nil
+					continue
+				}
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					return
+				case <-s.closed:
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// resubscribe subscribes to topic on the member failed over to, retrying
+// until it succeeds or the subscription ends, when it returns nil channels
+func (s *failoverSubscriber) resubscribe(ctx context.Context, topic string) (<-chan *message.Message, <-chan struct{}, context.CancelFunc) {
+	for {
+		messages, switched, cancel, err := s.subscribe(ctx, topic)
+		if err == nil {
+			return messages, switched, cancel
+		}
+		slog.Error("Failed to resubscribe after broker failover", "topic", topic, slog.Any("error", err))
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, func() {}
+		case <-s.closed:
+			return nil, nil, func() {}
+		case <-time.After(failoverResubscribeDelay):
+		}
+	}
+}
+
+func (s *failoverSubscriber) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, subscriber := range s.subscribers {
+		errs = append(errs, subscriber.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package watmil
+
+import (
+	"context"
+	"expvar"
+	"strings"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// Message metadata keys naming the aggregate an event is about, e.g. product
+// and the product ID. They are unset on events without one.
+const (
+	MetadataAggregateType = "aggregate_type"
+	MetadataAggregateID   = "aggregate_id"
+)
+
+// historyFailures counts published events that could not be recorded, keyed by event name
+var historyFailures = expvar.NewMap("event_history_record_failures_total")
+
+// HistoryRecorder keeps published events in the history of their aggregate
+type HistoryRecorder interface {
+	// Record stores msg, an eventName event stamped with its aggregate
+	Record(ctx context.Context, eventName string, msg *message.Message) error
+}
+
+// setAggregate stamps msg with the aggregate carried by event
+func setAggregate(msg *message.Message, event any) {
+	aggregateType, id := aggregateOf(event)
+	if id == "" {
+		return
+	}
+	msg.Metadata.Set(MetadataAggregateType, aggregateType)
+	msg.Metadata.Set(MetadataAggregateID, id)
+}
+
+// historyPublisher records the events the publisher it decorates accepted,
+// whether published or handled by the publish failure policy. The history is
+// secondary to publishing, so failing to record an event is only logged.
+type historyPublisher struct {
+	message.Publisher
+	history HistoryRecorder
+	logger  watermill.LoggerAdapter
+}
+
+func (p *historyPublisher) Publish(topic string, msgs ...*message.Message) error {
+	if err := p.Publisher.Publish(topic, msgs...); err != nil {
+		return err
+	}
+
+	eventName := strings.TrimPrefix(topic, generateEventTopic(""))
+	for _, msg := range msgs {
+		if msg.Metadata.Get(MetadataAggregateID) == "" {
+			continue
+		}
+		// The request may end before the event is recorded
+		ctx := context.WithoutCancel(msg.Context())
+		if err := p.history.Record(ctx, eventName, msg); err != nil {
+			historyFailures.Add(eventName, 1)
+			p.logger.Error("Failed to record event history", err, watermill.LogFields{
+				"event_name": eventName,
+				"message_id": msg.UUID,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package watmil
+
+import (
+	"context"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/erry-az/go-init/pkg/metrics"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EventMetrics records, per event name, how many events a subscriber handled,
+// how many of them failed, how often handlers were retried and how long each
+// attempt took. Durations carry the trace of the attempt as exemplar.
+type EventMetrics struct {
+	registry *metrics.Registry
+}
+
+// NewEventMetrics creates event metrics recorded in registry
+func NewEventMetrics(registry *metrics.Registry) *EventMetrics {
+	return &EventMetrics{registry: registry}
+}
+
+type deliveryKey struct{}
+
+// delivery is what the attempts at handling one message have in common
+type delivery struct {
+	eventName string
+	attempts  int
+}
+
+// middleware counts every message once, however often it was retried, so it
+// must run outside the retry middleware. Messages no event handler saw, e.g.
+// commands, are not counted.
+func (m *EventMetrics) middleware(h message.HandlerFunc) message.HandlerFunc {
+	return func(msg *message.Message) ([]*message.Message, error) {
+		d := &delivery{}
+		msg.SetContext(context.WithValue(msg.Context(), deliveryKey{}, d))
+
+		produced, err := h(msg)
+		if d.eventName == "" {
+			return produced, err
+		}
+
+		labels := map[string]string{"event": d.eventName}
+		m.registry.AddCounter("consumer_events_handled", "Events handled by consumers per event type.", labels, 1)
+		if err != nil {
+			m.registry.AddCounter("consumer_events_failed", "Events consumers failed to handle, retries included, per event type.", labels, 1)
+		}
+		if d.attempts > 1 {
+			m.registry.AddCounter("consumer_events_retried", "Handler retries per event type.", labels, float64(d.attempts-1))
+		}
+		return produced, err
+	}
+}
+
+// observe records one attempt at handling msg, an eventName event
+func (m *EventMetrics) observe(msg *message.Message, eventName string, elapsed time.Duration) {
+	if d, ok := msg.Context().Value(deliveryKey{}).(*delivery); ok {
+		d.eventName = eventName
+		d.attempts++
+	}
+
+	var exemplar map[string]string
+	if span := trace.SpanContextFromContext(msg.Context()); span.IsSampled() {
+		exemplar = map[string]string{"trace_id": span.TraceID().String()}
+	}
+	m.registry.ObserveHistogram("consumer_event_handling_duration_seconds", "Duration of each attempt at handling an event per event type.",
+		metrics.DefaultBuckets, map[string]string{"event": eventName}, elapsed.Seconds(), exemplar)
+}
@@ -0,0 +1,76 @@
+package watmil
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MetadataOrderingKey is the message metadata key holding the aggregate key
+// that brokers with ordered delivery (SQS FIFO, Pub/Sub ordering keys) use
+// to keep related events in publish order
+const MetadataOrderingKey = "ordering_key"
+
+type orderingKeyKey struct{}
+
+// WithOrderingKey overrides the ordering key of events published with ctx
+func WithOrderingKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, orderingKeyKey{}, key)
+}
+
+// setOrderingKey stamps msg with the ordering key from its context or,
+// failing that, the ID of the aggregate carried by event
+func setOrderingKey(msg *message.Message, event any) {
+	key, ok := msg.Context().Value(orderingKeyKey{}).(string)
+	if !ok {
+		key = aggregateID(event)
+	}
+	if key == "" {
+		return
+	}
+
+	msg.Metadata.Set(MetadataOrderingKey, key)
+}
+
+// aggregateID returns the "id" of the first message field of a proto event,
+// e.g. the product of a ProductUpdatedEvent
+func aggregateID(event any) string {
+	_, id := aggregateOf(event)
+	return id
+}
+
+// aggregateOf returns the name and "id" of the first message field of a
+// proto event holding one, e.g. "product" and the product ID of a
+// ProductUpdatedEvent
+func aggregateOf(event any) (string, string) {
+	msg, ok := event.(proto.Message)
+	if !ok {
+		return "", ""
+	}
+
+	reflected := msg.ProtoReflect()
+	fields := reflected.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Kind() != protoreflect.MessageKind || field.IsList() || field.IsMap() {
+			continue
+		}
+
+		id := field.Message().Fields().ByName("id")
+		if id == nil || id.Kind() != protoreflect.StringKind || !reflected.Has(field) {
+			continue
+		}
+		return string(field.Name()), reflected.Get(field).Message().Get(id).String()
+	}
+	return "", ""
+}
+
+// orderingKey returns the ordering key of msg, or fallback when it has none
+func orderingKey(msg *message.Message, fallback string) string {
+	if key := msg.Metadata.Get(MetadataOrderingKey); key != "" {
+		return key
+	}
+	return fallback
+}
@@ -4,30 +4,41 @@ import (
 	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
-	watersql "github.com/ThreeDotsLabs/watermill-sql/v2/pkg/sql"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
 	wotelfloss "github.com/dentech-floss/watermill-opentelemetry-go-extra/pkg/opentelemetry"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jackc/pgx/v5/stdlib"
 	wotel "github.com/voi-oss/watermill-opentelemetry/pkg/opentelemetry"
 )
 
-// NewPublisher creates a new event bus using pgxpool.Pool for database operations.
-// The pool is converted to *sql.DB using stdlib connector for watermill-sql compatibility.
-func NewPublisher(pool *pgxpool.Pool, logger watermill.LoggerAdapter) (*cqrs.EventBus, error) {
-	publisher, err := watersql.NewPublisher(
-		stdlib.OpenDBFromPool(pool),
-		watersql.PublisherConfig{
-			SchemaAdapter:        watersql.DefaultPostgreSQLSchema{},
-			AutoInitializeSchema: true,
-		},
-		logger,
-	)
+// NewPublisher creates a new event bus publishing through broker.
+// Published events are stamped with an expiry according to ttl and their
+// payloads are encrypted according to encryption, which may be nil. Events
+// the broker does not accept are handled according to failures, deferring
+// them to retries, which may be nil when no event is retried. Events about an
+// aggregate are recorded in history, which may be nil to keep none. Events
+// published with a context created by WithoutEvents are dropped. Events
+// carry the correlation ID of the context they are published with, see
+// package correlation, in correlation_id metadata. Besides
+// proto events, the bus publishes a *CustomEvent under its own name.
+func NewPublisher(broker Broker, logger watermill.LoggerAdapter, ttl TTLPolicy, encryption *PayloadEncryption, failures PublishFailurePolicy, retries RetryStore, history HistoryRecorder) (*cqrs.EventBus, error) {
+	publisher, err := broker.NewPublisher(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	degrading, err := newDegradingPublisher(publisher, failures, retries, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	tracePropagation := wotelfloss.NewTracePropagatingPublisherDecorator(publisher)
+	var recorded message.Publisher = degrading
+	if history != nil {
+		recorded = &historyPublisher{Publisher: degrading, history: history, logger: logger}
+	}
+
+	suppressing := &suppressingPublisher{Publisher: recorded}
+
+	tracePropagation := wotelfloss.NewTracePropagatingPublisherDecorator(suppressing)
 
 	eventBus, err := cqrs.NewEventBusWithConfig(wotel.NewPublisherDecorator(tracePropagation), cqrs.EventBusConfig{
 		GeneratePublishTopic: func(params cqrs.GenerateEventPublishTopicParams) (string, error) {
@@ -38,13 +49,30 @@ func NewPublisher(pool *pgxpool.Pool, logger watermill.LoggerAdapter) (*cqrs.Eve
 				"event_name": params.EventName,
 			})
 
+			// Messages are identified by the ID of their event, so consumers
+			// can key what they do per event on the message
+			switch event := params.Event.(type) {
+			case *CustomEvent:
+				if event.ID != "" {
+					params.Message.UUID = event.ID
+				}
+			case interface{ GetEventId() string }:
+				if id := event.GetEventId(); id != "" {
+					params.Message.UUID = id
+				}
+			}
 			params.Message.Metadata.Set("published_at", time.Now().Format(time.RFC3339))
+			setCorrelationID(params.Message)
+			setExpiration(params.Message, params.EventName, ttl)
+			setOrderingKey(params.Message, params.Event)
+			setAggregate(params.Message, params.Event)
 
 			return nil
 		},
-		Marshaler: cqrs.JSONMarshaler{
-			GenerateName: cqrs.StructName,
-		},
+		Marshaler: newEncryptingMarshaler(cqrs.JSONMarshaler{
+			// Custom events are named by their type rather than their struct
+			GenerateName: cqrs.NamedStruct(cqrs.StructName),
+		}, encryption),
 		Logger: logger,
 	})
 
@@ -54,3 +82,9 @@ func NewPublisher(pool *pgxpool.Pool, logger watermill.LoggerAdapter) (*cqrs.Eve
 func generateEventTopic(eventName string) string {
 	return "events." + eventName
 }
+
+// EventTopic returns the topic events named eventName are published to,
+// e.g. events.UserCreatedEvent
+func EventTopic(eventName string) string {
+	return generateEventTopic(eventName)
+}
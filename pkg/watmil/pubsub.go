@@ -0,0 +1,363 @@
+package watmil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pubSubUUIDAttribute carries the watermill message UUID next to its metadata
+const pubSubUUIDAttribute = "_watermill_message_uuid"
+
+// deadLetterSuffix names the dead-letter topic of a subscription
+const deadLetterSuffix = "-dead-letter"
+
+// PubSubOptions configures the Google Cloud Pub/Sub broker. The emulator is
+// used when PUBSUB_EMULATOR_HOST is set.
+type PubSubOptions struct {
+	ProjectID string
+	// SubscriptionPrefix is prepended to the per-handler subscription names
+	SubscriptionPrefix string
+	// Ordering publishes with ordering keys and creates ordered subscriptions,
+	// so events of one aggregate are delivered in publish order
+	Ordering bool
+	// ExactlyOnce creates subscriptions with exactly-once delivery and waits
+	// for acknowledgements to be confirmed
+	ExactlyOnce bool
+	// MaxDeliveryAttempts moves a message to the subscription's dead-letter
+	// topic after that many failed deliveries; zero disables dead-lettering
+	MaxDeliveryAttempts int
+	// AckDeadline is how long Pub/Sub waits for an acknowledgement before redelivering
+	AckDeadline time.Duration
+	// AutoProvision creates missing topics and subscriptions
+	AutoProvision bool
+}
+
+// PubSubBroker publishes events to one Pub/Sub topic per event type. Every
+// event handler consumes from its own subscription, so handlers of the same
+// event each get every message.
+type PubSubBroker struct {
+	client *pubsub.Client
+	opts   PubSubOptions
+
+	mu         sync.Mutex
+	publishers map[string]*pubsub.Publisher
+}
+
+// NewPubSubBroker creates a Pub/Sub broker with credentials from the Google Cloud environment
+func NewPubSubBroker(ctx context.Context, opts PubSubOptions) (*PubSubBroker, error) {
+	client, err := pubsub.NewClient(ctx, opts.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("create pubsub client: %w", err)
+	}
+
+	return &PubSubBroker{
+		client:     client,
+		opts:       opts,
+		publishers: make(map[string]*pubsub.Publisher),
+	}, nil
+}
+
+func (b *PubSubBroker) NewPublisher(logger watermill.LoggerAdapter) (message.Publisher, error) {
+	return &pubSubPublisher{broker: b}, nil
+}
+
+func (b *PubSubBroker) NewSubscriber(handlerName string, logger watermill.LoggerAdapter) (message.Subscriber, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &pubSubSubscriber{
+		broker:       b,
+		subscription: pubSubResourceName(b.opts.SubscriptionPrefix + handlerName),
+		logger:       logger,
+		ctx:          ctx,
+		cancel:       cancel,
+	}, nil
+}
+
+// Close stops every topic publisher and closes the client
+func (b *PubSubBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, publisher := range b.publishers {
+		publisher.Stop()
+	}
+	return b.client.Close()
+}
+
+// Ping checks Pub/Sub is reachable with the configured credentials
+func (b *PubSubBroker) Ping(ctx context.Context) error {
+	topics := b.client.TopicAdminClient.ListTopics(ctx, &pubsubpb.ListTopicsRequest{
+		Project:  "projects/" + b.client.Project(),
+		PageSize: 1,
+	})
+	if _, err := topics.Next(); err != nil && !errors.Is(err, iterator.Done) {
+		return fmt.Errorf("list topics: %w", err)
+	}
+	return nil
+}
+
+// pubSubResourceName maps a name to a valid topic or subscription ID, which
+// must start with a letter and may contain letters, digits and -_.~+%
+func pubSubResourceName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.~+%", r) {
+			return r
+		}
+		return '-'
+	}, name)
+
+	if name == "" || !(name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		name = "s" + name
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
+
+// topicName is the fully qualified name of topic id
+func (b *PubSubBroker) topicName(id string) string {
+	return fmt.Sprintf("projects/%s/topics/%s", b.client.Project(), id)
+}
+
+// subscriptionName is the fully qualified name of subscription id
+func (b *PubSubBroker) subscriptionName(id string) string {
+	return fmt.Sprintf("projects/%s/subscriptions/%s", b.client.Project(), id)
+}
+
+// publisher returns the publisher of a watermill topic, creating the topic when provisioning
+func (b *PubSubBroker) publisher(ctx context.Context, topic string) (*pubsub.Publisher, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if publisher, ok := b.publishers[topic]; ok {
+		return publisher, nil
+	}
+
+	name, err := b.ensureTopic(ctx, pubSubResourceName(topic))
+	if err != nil {
+		return nil, err
+	}
+
+	publisher := b.client.Publisher(name)
+	publisher.EnableMessageOrdering = b.opts.Ordering
+
+	b.publishers[topic] = publisher
+	return publisher, nil
+}
+
+// ensureTopic returns the fully qualified name of topic id, creating it when provisioning
+func (b *PubSubBroker) ensureTopic(ctx context.Context, id string) (string, error) {
+	name := b.topicName(id)
+
+	_, err := b.client.TopicAdminClient.GetTopic(ctx, &pubsubpb.GetTopicRequest{Topic: name})
+	if err == nil {
+		return name, nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return "", fmt.Errorf("check topic %s: %w", id, err)
+	}
+	if !b.opts.AutoProvision {
+		return "", fmt.Errorf("topic %s not found and auto provisioning is disabled", id)
+	}
+
+	_, err = b.client.TopicAdminClient.CreateTopic(ctx, &pubsubpb.Topic{Name: name})
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return "", fmt.Errorf("create topic %s: %w", id, err)
+	}
+	return name, nil
+}
+
+// ensureSubscription checks the subscription id on the topic of a watermill
+// topic exists, creating it and its dead-letter topic when provisioning
+func (b *PubSubBroker) ensureSubscription(ctx context.Context, topic, id string) error {
+	name := b.subscriptionName(id)
+
+	_, err := b.client.SubscriptionAdminClient.GetSubscription(ctx, &pubsubpb.GetSubscriptionRequest{Subscription: name})
+	if err == nil {
+		return nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("check subscription %s: %w", id, err)
+	}
+	if !b.opts.AutoProvision {
+		return fmt.Errorf("subscription %s not found and auto provisioning is disabled", id)
+	}
+
+	topicName, err := b.ensureTopic(ctx, pubSubResourceName(topic))
+	if err != nil {
+		return err
+	}
+
+	subscription := &pubsubpb.Subscription{
+		Name:                      name,
+		Topic:                     topicName,
+		AckDeadlineSeconds:        int32(b.opts.AckDeadline / time.Second),
+		EnableMessageOrdering:     b.opts.Ordering,
+		EnableExactlyOnceDelivery: b.opts.ExactlyOnce,
+	}
+
+	if b.opts.MaxDeliveryAttempts > 0 {
+		deadLetter, err := b.ensureTopic(ctx, pubSubResourceName(id+deadLetterSuffix))
+		if err != nil {
+			return err
+		}
+		subscription.DeadLetterPolicy = &pubsubpb.DeadLetterPolicy{
+			DeadLetterTopic:     deadLetter,
+			MaxDeliveryAttempts: int32(b.opts.MaxDeliveryAttempts),
+		}
+	}
+
+	_, err = b.client.SubscriptionAdminClient.CreateSubscription(ctx, subscription)
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("create subscription %s: %w", id, err)
+	}
+	return nil
+}
+
+type pubSubPublisher struct {
+	broker *PubSubBroker
+}
+
+func (p *pubSubPublisher) Publish(topicName string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		ctx := msg.Context()
+
+		publisher, err := p.broker.publisher(ctx, topicName)
+		if err != nil {
+			return err
+		}
+
+		attributes := make(map[string]string, len(msg.Metadata)+1)
+		for key, value := range msg.Metadata {
+			attributes[key] = value
+		}
+		attributes[pubSubUUIDAttribute] = msg.UUID
+
+		var key string
+		if p.broker.opts.Ordering {
+			key = orderingKey(msg, "")
+		}
+
+		result := publisher.Publish(ctx, &pubsub.Message{
+			Data:        msg.Payload,
+			Attributes:  attributes,
+			OrderingKey: key,
+		})
+		if _, err := result.Get(ctx); err != nil {
+			// A failed publish pauses its ordering key until resumed
+			if key != "" {
+				publisher.ResumePublish(key)
+			}
+			return fmt.Errorf("publish to %s: %w", topicName, err)
+		}
+	}
+	return nil
+}
+
+func (p *pubSubPublisher) Close() error {
+	return nil
+}
+
+type pubSubSubscriber struct {
+	broker       *PubSubBroker
+	subscription string
+	logger       watermill.LoggerAdapter
+
+	// ctx is cancelled on Close, stopping every subscription
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (s *pubSubSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	if s.ctx.Err() != nil {
+		return nil, errors.New("subscriber closed")
+	}
+
+	if err := s.broker.ensureSubscription(ctx, topic, s.subscription); err != nil {
+		return nil, err
+	}
+	subscriber := s.broker.client.Subscriber(s.broker.subscriptionName(s.subscription))
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(s.ctx, cancel)
+
+	out := make(chan *message.Message)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(out)
+		defer stop()
+		defer cancel()
+
+		err := subscriber.Receive(ctx, func(ctx context.Context, received *pubsub.Message) {
+			s.deliver(ctx, received, out)
+		})
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error("Pub/Sub receive stopped", err, watermill.LogFields{"subscription": s.subscription})
+		}
+	}()
+
+	return out, nil
+}
+
+// deliver hands one message to the router and settles it once acked or nacked
+func (s *pubSubSubscriber) deliver(ctx context.Context, received *pubsub.Message, out chan<- *message.Message) {
+	msg := message.NewMessage(received.Attributes[pubSubUUIDAttribute], received.Data)
+	for key, value := range received.Attributes {
+		if key != pubSubUUIDAttribute {
+			msg.Metadata.Set(key, value)
+		}
+	}
+
+	msgCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	msg.SetContext(msgCtx)
+
+	select {
+	case out <- msg:
+	case <-ctx.Done():
+		received.Nack()
+		return
+	}
+
+	select {
+	case <-msg.Acked():
+		s.ack(ctx, received)
+	case <-msg.Nacked():
+		received.Nack()
+	case <-ctx.Done():
+		received.Nack()
+	}
+}
+
+// ack acknowledges received, waiting for confirmation with exactly-once delivery
+func (s *pubSubSubscriber) ack(ctx context.Context, received *pubsub.Message) {
+	if !s.broker.opts.ExactlyOnce {
+		received.Ack()
+		return
+	}
+
+	if _, err := received.AckWithResult().Get(ctx); err != nil {
+		s.logger.Error("Acknowledgement not confirmed, message may be redelivered", err,
+			watermill.LogFields{"subscription": s.subscription, "message_id": received.ID})
+	}
+}
+
+func (s *pubSubSubscriber) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
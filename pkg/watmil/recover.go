@@ -0,0 +1,29 @@
+package watmil
+
+import (
+	"runtime/debug"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+)
+
+// PanicFunc is told about a handler panic along with the message being handled
+type PanicFunc func(msg *message.Message, recovered any, stack []byte)
+
+// ReportPanics recovers handler panics, passes them to report and fails the
+// message like the router's Recoverer would, so it is retried or nacked.
+// Add it before the retry middleware to report once per delivery.
+func ReportPanics(report PanicFunc) message.HandlerMiddleware {
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) (msgs []*message.Message, err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					stack := debug.Stack()
+					report(msg, recovered, stack)
+					err = middleware.RecoveredPanicError{V: recovered, Stacktrace: string(stack)}
+				}
+			}()
+			return h(msg)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package watmil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// fifoSuffix is required at the end of FIFO topic and queue names
+const fifoSuffix = ".fifo"
+
+const (
+	// sqsMaxWaitTime is the longest long-polling duration SQS allows
+	sqsMaxWaitTime = 20 * time.Second
+	// sqsReceiveRetryDelay is how long a subscriber waits after a failed receive
+	sqsReceiveRetryDelay = 5 * time.Second
+)
+
+// SQSOptions configures the SNS/SQS broker
+type SQSOptions struct {
+	// Region overrides the region from the AWS environment
+	Region string
+	// Endpoint overrides the SNS and SQS endpoints, e.g. for LocalStack
+	Endpoint string
+	// QueuePrefix is prepended to the per-handler queue names
+	QueuePrefix string
+	// FIFO uses FIFO topics and queues so events of one aggregate are
+	// delivered in publish order, grouped by their ordering key
+	FIFO bool
+	// AutoProvision creates missing topics, queues and subscriptions
+	AutoProvision bool
+	// VisibilityTimeout is how long a received message stays hidden from other consumers
+	VisibilityTimeout time.Duration
+	// WaitTime is the long-polling duration of a receive, at most and by default 20s
+	WaitTime time.Duration
+}
+
+// SQSBroker publishes events to one SNS topic per event type. Every event
+// handler consumes from its own SQS queue subscribed to the handler's topic,
+// so handlers of the same event each get every message.
+type SQSBroker struct {
+	sns  *sns.Client
+	sqs  *sqs.Client
+	opts SQSOptions
+
+	mu     sync.Mutex
+	topics map[string]string
+}
+
+// NewSQSBroker creates an SNS/SQS broker with credentials from the AWS environment
+func NewSQSBroker(ctx context.Context, opts SQSOptions) (*SQSBroker, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(opts.Region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+
+	if opts.WaitTime <= 0 || opts.WaitTime > sqsMaxWaitTime {
+		opts.WaitTime = sqsMaxWaitTime
+	}
+
+	var endpoint *string
+	if opts.Endpoint != "" {
+		endpoint = aws.String(opts.Endpoint)
+	}
+
+	return &SQSBroker{
+		sns:    sns.NewFromConfig(cfg, func(o *sns.Options) { o.BaseEndpoint = endpoint }),
+		sqs:    sqs.NewFromConfig(cfg, func(o *sqs.Options) { o.BaseEndpoint = endpoint }),
+		opts:   opts,
+		topics: make(map[string]string),
+	}, nil
+}
+
+func (b *SQSBroker) NewPublisher(logger watermill.LoggerAdapter) (message.Publisher, error) {
+	return &snsPublisher{broker: b}, nil
+}
+
+func (b *SQSBroker) NewSubscriber(handlerName string, logger watermill.LoggerAdapter) (message.Subscriber, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &sqsSubscriber{
+		broker: b,
+		queue:  b.opts.QueuePrefix + handlerName,
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Ping checks SQS is reachable with the configured credentials
+func (b *SQSBroker) Ping(ctx context.Context) error {
+	_, err := b.sqs.ListQueues(ctx, &sqs.ListQueuesInput{
+		QueueNamePrefix: aws.String(b.opts.QueuePrefix),
+		MaxResults:      aws.Int32(1),
+	})
+	if err != nil {
+		return fmt.Errorf("list queues: %w", err)
+	}
+	return nil
+}
+
+// resourceName maps a watermill topic or handler name to a valid SNS/SQS name
+func (b *SQSBroker) resourceName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+
+	maxLen := 80
+	if b.opts.FIFO {
+		maxLen -= len(fifoSuffix)
+	}
+	if len(name) > maxLen {
+		name = name[:maxLen]
+	}
+
+	if b.opts.FIFO {
+		name += fifoSuffix
+	}
+	return name
+}
+
+// topicARN resolves the SNS topic of a watermill topic, creating it when provisioning
+func (b *SQSBroker) topicARN(ctx context.Context, topic string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if arn, ok := b.topics[topic]; ok {
+		return arn, nil
+	}
+
+	name := b.resourceName(topic)
+	var arn string
+	if b.opts.AutoProvision {
+		attributes := map[string]string{}
+		if b.opts.FIFO {
+			attributes["FifoTopic"] = "true"
+		}
+		out, err := b.sns.CreateTopic(ctx, &sns.CreateTopicInput{Name: aws.String(name), Attributes: attributes})
+		if err != nil {
+			return "", fmt.Errorf("create topic %s: %w", name, err)
+		}
+		arn = aws.ToString(out.TopicArn)
+	} else {
+		found, err := b.findTopic(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		arn = found
+	}
+
+	b.topics[topic] = arn
+	return arn, nil
+}
+
+func (b *SQSBroker) findTopic(ctx context.Context, name string) (string, error) {
+	pages := sns.NewListTopicsPaginator(b.sns, &sns.ListTopicsInput{})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("list topics: %w", err)
+		}
+		for _, topic := range page.Topics {
+			if arn := aws.ToString(topic.TopicArn); strings.HasSuffix(arn, ":"+name) {
+				return arn, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("topic %s not found and auto provisioning is disabled", name)
+}
+
+// subscribeQueue returns the URL of the queue named queue, subscribed to the
+// SNS topic of a watermill topic. Provisioning creates the queue, allows the
+// topic to deliver to it and subscribes it with raw message delivery.
+func (b *SQSBroker) subscribeQueue(ctx context.Context, topic, queue string) (string, error) {
+	topicARN, err := b.topicARN(ctx, topic)
+	if err != nil {
+		return "", err
+	}
+
+	name := b.resourceName(queue)
+	if !b.opts.AutoProvision {
+		out, err := b.sqs.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(name)})
+		if err != nil {
+			return "", fmt.Errorf("get queue %s: %w", name, err)
+		}
+		return aws.ToString(out.QueueUrl), nil
+	}
+
+	attributes := map[string]string{}
+	if b.opts.FIFO {
+		attributes[string(sqstypes.QueueAttributeNameFifoQueue)] = "true"
+	}
+	if b.opts.VisibilityTimeout > 0 {
+		attributes[string(sqstypes.QueueAttributeNameVisibilityTimeout)] = fmt.Sprint(int(b.opts.VisibilityTimeout.Seconds()))
+	}
+	created, err := b.sqs.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String(name), Attributes: attributes})
+	if err != nil {
+		return "", fmt.Errorf("create queue %s: %w", name, err)
+	}
+	queueURL := aws.ToString(created.QueueUrl)
+
+	queueAttributes, err := b.sqs.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return "", fmt.Errorf("get queue arn of %s: %w", name, err)
+	}
+	queueARN := queueAttributes.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]
+
+	_, err = b.sqs.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl: aws.String(queueURL),
+		Attributes: map[string]string{
+			string(sqstypes.QueueAttributeNamePolicy): topicDeliveryPolicy(queueARN, topicARN),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("set policy of queue %s: %w", name, err)
+	}
+
+	_, err = b.sns.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn:   aws.String(topicARN),
+		Protocol:   aws.String("sqs"),
+		Endpoint:   aws.String(queueARN),
+		Attributes: map[string]string{"RawMessageDelivery": "true"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("subscribe queue %s to %s: %w", name, topicARN, err)
+	}
+
+	return queueURL, nil
+}
+
+// topicDeliveryPolicy allows the SNS topic to send messages to the queue
+func topicDeliveryPolicy(queueARN, topicARN string) string {
+	return fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow",`+
+		`"Principal":{"Service":"sns.amazonaws.com"},"Action":"sqs:SendMessage","Resource":%q,`+
+		`"Condition":{"ArnEquals":{"aws:SourceArn":%q}}}]}`, queueARN, topicARN)
+}
+
+type snsPublisher struct {
+	broker *SQSBroker
+}
+
+func (p *snsPublisher) Publish(topic string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		ctx := msg.Context()
+
+		topicARN, err := p.broker.topicARN(ctx, topic)
+		if err != nil {
+			return err
+		}
+
+		body, err := encodeWire(msg)
+		if err != nil {
+			return err
+		}
+
+		input := &sns.PublishInput{TopicArn: aws.String(topicARN), Message: aws.String(body)}
+		if p.broker.opts.FIFO {
+			input.MessageGroupId = aws.String(orderingKey(msg, topic))
+			input.MessageDeduplicationId = aws.String(msg.UUID)
+		}
+
+		if _, err := p.broker.sns.Publish(ctx, input); err != nil {
+			return fmt.Errorf("publish to %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+func (p *snsPublisher) Close() error {
+	return nil
+}
+
+type sqsSubscriber struct {
+	broker *SQSBroker
+	queue  string
+	logger watermill.LoggerAdapter
+
+	// ctx is cancelled on Close, stopping every subscription
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (s *sqsSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	if s.ctx.Err() != nil {
+		return nil, errors.New("subscriber closed")
+	}
+
+	queueURL, err := s.broker.subscribeQueue(ctx, topic, s.queue)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(s.ctx, cancel)
+
+	out := make(chan *message.Message)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(out)
+		defer stop()
+		defer cancel()
+		s.consume(ctx, queueURL, out)
+	}()
+
+	return out, nil
+}
+
+func (s *sqsSubscriber) consume(ctx context.Context, queueURL string, out chan<- *message.Message) {
+	logFields := watermill.LogFields{"queue": queueURL}
+	for ctx.Err() == nil {
+		batch, err := s.broker.sqs.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(queueURL),
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     int32(s.broker.opts.WaitTime.Seconds()),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger.Error("Failed to receive messages", err, logFields)
+			select {
+			case <-ctx.Done():
+			case <-time.After(sqsReceiveRetryDelay):
+			}
+			continue
+		}
+
+		for i, received := range batch.Messages {
+			acked, ok := s.deliver(ctx, queueURL, received, out)
+			if !ok {
+				return
+			}
+			// In FIFO queues the rest of the batch may belong to the same
+			// group, so it must not overtake the nacked message
+			if !acked && s.broker.opts.FIFO {
+				s.release(queueURL, batch.Messages[i+1:])
+				break
+			}
+		}
+	}
+}
+
+// deliver hands one message to the router and settles it once acked or nacked.
+// ok is false when the subscription is shutting down.
+func (s *sqsSubscriber) deliver(ctx context.Context, queueURL string, received sqstypes.Message, out chan<- *message.Message) (acked, ok bool) {
+	msg, err := decodeWire(aws.ToString(received.Body))
+	if err != nil {
+		// Left in the queue so a redrive policy can move it to a dead-letter queue
+		s.logger.Error("Dropping undecodable message", err, watermill.LogFields{"queue": queueURL})
+		return false, true
+	}
+
+	msgCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	msg.SetContext(msgCtx)
+
+	select {
+	case out <- msg:
+	case <-ctx.Done():
+		return false, false
+	}
+
+	select {
+	case <-msg.Acked():
+		_, err := s.broker.sqs.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(queueURL),
+			ReceiptHandle: received.ReceiptHandle,
+		})
+		if err != nil {
+			s.logger.Error("Failed to delete acked message", err, watermill.LogFields{"uuid": msg.UUID})
+		}
+		return true, true
+	case <-msg.Nacked():
+		s.release(queueURL, []sqstypes.Message{received})
+		return false, true
+	case <-ctx.Done():
+		return false, false
+	}
+}
+
+// release makes messages visible again for immediate redelivery
+func (s *sqsSubscriber) release(queueURL string, messages []sqstypes.Message) {
+	for _, received := range messages {
+		_, err := s.broker.sqs.ChangeMessageVisibility(context.Background(), &sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          aws.String(queueURL),
+			ReceiptHandle:     received.ReceiptHandle,
+			VisibilityTimeout: 0,
+		})
+		if err != nil {
+			s.logger.Error("Failed to release message", err, watermill.LogFields{"queue": queueURL})
+		}
+	}
+}
+
+func (s *sqsSubscriber) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
@@ -0,0 +1,37 @@
+package watmil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgUndefinedTable is returned for topics nobody has published to or consumed yet
+const pgUndefinedTable = "42P01"
+
+// ProcessedEvents returns, per event name, the highest offset acknowledged by
+// any consumer group. Offsets grow by one per published message, so this is
+// the number of events of that type processed so far.
+func ProcessedEvents(ctx context.Context, pool *pgxpool.Pool, eventNames []string) (map[string]int64, error) {
+	processed := make(map[string]int64, len(eventNames))
+	for _, eventName := range eventNames {
+		table := pgx.Identifier{"watermill_offsets_" + generateEventTopic(eventName)}.Sanitize()
+
+		var offset int64
+		err := pool.QueryRow(ctx, "SELECT COALESCE(MAX(offset_acked), 0) FROM "+table).Scan(&offset)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable {
+				processed[eventName] = 0
+				continue
+			}
+			return nil, fmt.Errorf("read offsets of %s: %w", eventName, err)
+		}
+		processed[eventName] = offset
+	}
+	return processed, nil
+}
@@ -5,33 +5,38 @@ import (
 	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
-	watersql "github.com/ThreeDotsLabs/watermill-sql/v2/pkg/sql"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
 	"github.com/ThreeDotsLabs/watermill/message/router/plugin"
 	wotelfloss "github.com/dentech-floss/watermill-opentelemetry-go-extra/pkg/opentelemetry"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jackc/pgx/v5/stdlib"
 	wotel "github.com/voi-oss/watermill-opentelemetry/pkg/opentelemetry"
 )
 
 type Subscriber struct {
-	router         *message.Router
-	logger         watermill.LoggerAdapter
-	eventProcessor *cqrs.EventProcessor
+	router           *message.Router
+	logger           watermill.LoggerAdapter
+	eventProcessor   *cqrs.EventProcessor
+	commandProcessor *cqrs.CommandProcessor
 }
 
-// NewSubscriber creates a new subscriber using pgxpool.Pool for database operations.
-// The pool is converted to *sql.DB using stdlib connector for watermill-sql compatibility.
-func NewSubscriber(pool *pgxpool.Pool, logger watermill.LoggerAdapter, mid ...message.HandlerMiddleware) (*Subscriber, error) {
+// NewSubscriber creates a new subscriber consuming from broker. Encrypted event
+// payloads are decrypted with encryption, which may be nil when no keys are
+// configured. Event handling is recorded in eventMetrics unless it is nil.
+// Handlers are given the correlation ID of the message in their context, so
+// the events and commands they publish carry it on.
+func NewSubscriber(broker Broker, logger watermill.LoggerAdapter, encryption *PayloadEncryption, eventMetrics *EventMetrics, mid ...message.HandlerMiddleware) (*Subscriber, error) {
 	router, err := message.NewRouter(message.RouterConfig{}, logger)
 	if err != nil {
 		return nil, err
 	}
 
 	router.AddPlugin(plugin.SignalsHandler)
-	router.AddMiddleware(middleware.Recoverer, wotelfloss.ExtractRemoteParentSpanContext(), wotel.Trace())
+	router.AddMiddleware(middleware.Recoverer, DropExpired(logger), wotelfloss.ExtractRemoteParentSpanContext(), wotel.Trace())
+	if eventMetrics != nil {
+		// Ahead of mid, which holds the retry middleware
+		router.AddMiddleware(eventMetrics.middleware)
+	}
 	router.AddMiddleware(mid...)
 
 	eventProcessor, err := cqrs.NewEventProcessorWithConfig(
@@ -41,40 +46,71 @@ func NewSubscriber(pool *pgxpool.Pool, logger watermill.LoggerAdapter, mid ...me
 				return generateEventTopic(params.EventName), nil
 			},
 			SubscriberConstructor: func(params cqrs.EventProcessorSubscriberConstructorParams) (message.Subscriber, error) {
-				return watersql.NewSubscriber(
-					stdlib.OpenDBFromPool(pool),
-					watersql.SubscriberConfig{
-						SchemaAdapter:    watersql.DefaultPostgreSQLSchema{},
-						OffsetsAdapter:   watersql.DefaultPostgreSQLOffsetsAdapter{},
-						InitializeSchema: true,
-					},
-					logger,
-				)
+				return broker.NewSubscriber(params.HandlerName, logger)
 			},
 			OnHandle: func(params cqrs.EventProcessorOnHandleParams) error {
 				start := time.Now()
 
-				err := params.Handler.Handle(params.Message.Context(), params.Event)
+				err := params.Handler.Handle(messageContext(params.Message), params.Event)
+				elapsed := time.Since(start)
 
 				logger.Info("Event handled", watermill.LogFields{
 					"event_name": params.EventName,
-					"duration":   time.Since(start),
+					"duration":   elapsed,
 					"err":        err,
 				})
+				if eventMetrics != nil {
+					eventMetrics.observe(params.Message, params.EventName, elapsed)
+				}
 
 				return err
 			},
-			Marshaler: cqrs.JSONMarshaler{
+			Marshaler: newEncryptingMarshaler(cqrs.JSONMarshaler{
 				GenerateName: cqrs.StructName,
-			},
+			}, encryption),
 			Logger: logger,
 		},
 	)
 
+	if err != nil {
+		return nil, err
+	}
+
+	commandProcessor, err := cqrs.NewCommandProcessorWithConfig(
+		router,
+		cqrs.CommandProcessorConfig{
+			GenerateSubscribeTopic: func(params cqrs.CommandProcessorGenerateSubscribeTopicParams) (string, error) {
+				return generateCommandTopic(params.CommandName), nil
+			},
+			SubscriberConstructor: func(params cqrs.CommandProcessorSubscriberConstructorParams) (message.Subscriber, error) {
+				return broker.NewSubscriber(params.HandlerName, logger)
+			},
+			OnHandle: func(params cqrs.CommandProcessorOnHandleParams) error {
+				start := time.Now()
+
+				err := params.Handler.Handle(messageContext(params.Message), params.Command)
+
+				logger.Info("Command handled", watermill.LogFields{
+					"command_name": params.CommandName,
+					"duration":     time.Since(start),
+					"err":          err,
+				})
+
+				return err
+			},
+			Marshaler: commandMarshaler,
+			Logger:    logger,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Subscriber{
-		router:         router,
-		logger:         logger,
-		eventProcessor: eventProcessor,
+		router:           router,
+		logger:           logger,
+		eventProcessor:   eventProcessor,
+		commandProcessor: commandProcessor,
 	}, nil
 }
 
@@ -88,6 +124,32 @@ func (s *Subscriber) RegisterHandlers(handlers ...func(eventProcessor *cqrs.Even
 	return nil
 }
 
+// RegisterCommandHandlers adds command handlers consuming from the subscriber's broker
+func (s *Subscriber) RegisterCommandHandlers(handlers ...func(commandProcessor *cqrs.CommandProcessor) error) error {
+	for _, handler := range handlers {
+		if err := handler(s.commandProcessor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *Subscriber) Run(ctx context.Context) error {
 	return s.router.Run(ctx)
 }
+
+// Started returns a channel closed once the subscriber is running
+func (s *Subscriber) Started() chan struct{} {
+	return s.router.Running()
+}
+
+// IsRunning reports whether the subscriber is consuming messages
+func (s *Subscriber) IsRunning() bool {
+	return s.router.IsRunning()
+}
+
+// Close stops the subscriber. A closed subscriber cannot be run again.
+func (s *Subscriber) Close() error {
+	return s.router.Close()
+}
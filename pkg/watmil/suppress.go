@@ -0,0 +1,48 @@
+package watmil
+
+import (
+	"context"
+	"expvar"
+	"strings"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// suppressedEvents counts events dropped because they were published with a
+// context created by WithoutEvents, keyed by event name
+var suppressedEvents = expvar.NewMap("events_suppressed_total")
+
+type withoutEventsKey struct{}
+
+// WithoutEvents drops the events published with ctx instead of publishing
+// them, e.g. for writes that consumers, webhooks and the event history must
+// never see. Commands are sent all the same.
+func WithoutEvents(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutEventsKey{}, true)
+}
+
+func eventsSuppressed(ctx context.Context) bool {
+	suppressed, _ := ctx.Value(withoutEventsKey{}).(bool)
+	return suppressed
+}
+
+// suppressingPublisher drops the messages published with a context created
+// by WithoutEvents before they reach the publisher it decorates
+type suppressingPublisher struct {
+	message.Publisher
+}
+
+func (p *suppressingPublisher) Publish(topic string, msgs ...*message.Message) error {
+	kept := make([]*message.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if eventsSuppressed(msg.Context()) {
+			suppressedEvents.Add(strings.TrimPrefix(topic, generateEventTopic("")), 1)
+			continue
+		}
+		kept = append(kept, msg)
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return p.Publisher.Publish(topic, kept...)
+}
@@ -0,0 +1,96 @@
+package watmil
+
+import (
+	"context"
+	"expvar"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// MetadataExpiresAt is the message metadata key holding the RFC3339 time after
+// which consumers drop the message instead of handling it
+const MetadataExpiresAt = "expires_at"
+
+// expiredMessages counts messages dropped because they expired before being
+// handled, keyed by handler name. It is exported on /debug/vars.
+var expiredMessages = expvar.NewMap("events_expired_total")
+
+// TTLPolicy decides how long a published event stays worth processing.
+// Events maps an event name (e.g. ProductCreatedEvent) to its TTL, matched
+// ignoring case since config loading lowercases map keys; Default applies to
+// the rest. A zero TTL means the event never expires.
+type TTLPolicy struct {
+	Default time.Duration
+	Events  map[string]time.Duration
+}
+
+// For returns the TTL configured for eventName
+func (p TTLPolicy) For(eventName string) time.Duration {
+	if ttl, ok := p.Events[eventName]; ok {
+		return ttl
+	}
+	for name, ttl := range p.Events {
+		if strings.EqualFold(name, eventName) {
+			return ttl
+		}
+	}
+	return p.Default
+}
+
+type ttlKey struct{}
+
+// WithTTL overrides the configured TTL for events published with ctx.
+// A negative ttl disables expiration for those events.
+func WithTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, ttlKey{}, ttl)
+}
+
+func ttlFromContext(ctx context.Context) (time.Duration, bool) {
+	ttl, ok := ctx.Value(ttlKey{}).(time.Duration)
+	return ttl, ok
+}
+
+// setExpiration stamps msg with its expiry time using the per-publish
+// override if present, otherwise the policy for eventName
+func setExpiration(msg *message.Message, eventName string, policy TTLPolicy) {
+	ttl, ok := ttlFromContext(msg.Context())
+	if !ok {
+		ttl = policy.For(eventName)
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	msg.Metadata.Set(MetadataExpiresAt, time.Now().Add(ttl).UTC().Format(time.RFC3339Nano))
+}
+
+// DropExpired acknowledges messages past their expiry without handling them,
+// so consumers catching up on a backlog skip stale work
+func DropExpired(logger watermill.LoggerAdapter) message.HandlerMiddleware {
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			expiresAt := msg.Metadata.Get(MetadataExpiresAt)
+			if expiresAt == "" {
+				return h(msg)
+			}
+
+			deadline, err := time.Parse(time.RFC3339Nano, expiresAt)
+			if err != nil || time.Now().Before(deadline) {
+				return h(msg)
+			}
+
+			handlerName := message.HandlerNameFromCtx(msg.Context())
+			expiredMessages.Add(handlerName, 1)
+			logger.Info("Dropping expired message", watermill.LogFields{
+				"message_uuid": msg.UUID,
+				"handler":      handlerName,
+				"expires_at":   expiresAt,
+			})
+
+			return nil, nil
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package watmil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// wireMessage carries a watermill message, metadata included, as a JSON body
+// over brokers whose native attributes are too limited to hold it
+type wireMessage struct {
+	UUID     string            `json:"uuid"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Payload  []byte            `json:"payload"`
+}
+
+func encodeWire(msg *message.Message) (string, error) {
+	body, err := json.Marshal(wireMessage{
+		UUID:     msg.UUID,
+		Metadata: msg.Metadata,
+		Payload:  msg.Payload,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode message %s: %w", msg.UUID, err)
+	}
+	return string(body), nil
+}
+
+func decodeWire(body string) (*message.Message, error) {
+	var wire wireMessage
+	if err := json.Unmarshal([]byte(body), &wire); err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+
+	msg := message.NewMessage(wire.UUID, wire.Payload)
+	for key, value := range wire.Metadata {
+		msg.Metadata.Set(key, value)
+	}
+	return msg, nil
+}